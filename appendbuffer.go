@@ -0,0 +1,174 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAppendBufferSize is the default AppendBufferOptions.MaxSize
+	DefaultAppendBufferSize = 64 * 1024
+	// DefaultAppendBufferDelay is the default AppendBufferOptions.MaxDelay
+	DefaultAppendBufferDelay = time.Second
+)
+
+// AppendBufferOptions configure an AppendBuffer
+type AppendBufferOptions struct {
+	// MaxSize is the number of buffered bytes that triggers a flush,
+	// DefaultAppendBufferSize is used if zero or negative.
+	MaxSize int
+
+	// MaxDelay is the time after the first buffered write
+	// until the buffer is flushed in the background,
+	// DefaultAppendBufferDelay is used if zero.
+	// A negative value disables flushing by time.
+	MaxDelay time.Duration
+
+	// Permissions are passed to File.Append
+	Permissions []Permissions
+}
+
+// AppendBuffer is an io.WriteCloser that coalesces many small writes
+// into fewer File.Append calls to reduce the number of requests
+// for file systems like S3 where every append is expensive.
+//
+// Written data is buffered in memory and appended to the file
+// when the buffer reaches AppendBufferOptions.MaxSize,
+// AppendBufferOptions.MaxDelay after the first buffered write,
+// or when Flush or Close is called.
+// Buffered data is lost if the process crashes
+// before it was flushed.
+//
+// Failed appends keep the data buffered so that it's
+// retried with the next flush. The error of a failed
+// background flush is returned by the next Write, Flush, or Close.
+//
+// An AppendBuffer is safe for concurrent use.
+type AppendBuffer struct {
+	file File
+	opts AppendBufferOptions
+
+	// flushMtx serializes flushes to keep the order of the data
+	flushMtx sync.Mutex
+
+	mtx    sync.Mutex
+	buf    []byte
+	timer  *time.Timer
+	err    error // error of the last background flush
+	closed bool
+}
+
+// NewAppendBuffer returns an AppendBuffer that appends to file.
+func NewAppendBuffer(file File, opts AppendBufferOptions) *AppendBuffer {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultAppendBufferSize
+	}
+	if opts.MaxDelay == 0 {
+		opts.MaxDelay = DefaultAppendBufferDelay
+	}
+	return &AppendBuffer{file: file, opts: opts}
+}
+
+// File returns the file that is appended to
+func (b *AppendBuffer) File() File {
+	return b.file
+}
+
+// Buffered returns the number of bytes
+// that have not been appended to the file yet.
+func (b *AppendBuffer) Buffered() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return len(b.buf)
+}
+
+// Write buffers p and appends the buffered data
+// to the file if it reaches AppendBufferOptions.MaxSize.
+// Write implements the io.Writer interface.
+func (b *AppendBuffer) Write(p []byte) (n int, err error) {
+	b.mtx.Lock()
+	if b.closed {
+		b.mtx.Unlock()
+		return 0, os.ErrClosed
+	}
+	if err = b.err; err != nil {
+		b.err = nil
+		b.mtx.Unlock()
+		return 0, err
+	}
+	b.buf = append(b.buf, p...)
+	full := len(b.buf) >= b.opts.MaxSize
+	if !full && b.timer == nil && b.opts.MaxDelay > 0 {
+		b.timer = time.AfterFunc(b.opts.MaxDelay, b.flushInBackground)
+	}
+	b.mtx.Unlock()
+
+	if full {
+		// The data is buffered even if the flush fails
+		// and will be appended with the next flush
+		err = b.Flush(context.Background())
+	}
+	return len(p), err
+}
+
+// WriteString buffers str like Write.
+// WriteString implements the io.StringWriter interface.
+func (b *AppendBuffer) WriteString(str string) (n int, err error) {
+	return b.Write([]byte(str))
+}
+
+// Flush appends all buffered data to the file.
+func (b *AppendBuffer) Flush(ctx context.Context) error {
+	b.flushMtx.Lock()
+	defer b.flushMtx.Unlock()
+
+	b.mtx.Lock()
+	data := b.buf
+	b.buf = nil
+	b.err = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mtx.Unlock()
+
+	if len(data) == 0 {
+		return nil
+	}
+	err := b.file.Append(ctx, data, b.opts.Permissions...)
+	if err != nil {
+		// Keep the data in front of newer writes for the next flush
+		b.mtx.Lock()
+		b.buf = append(data, b.buf...)
+		b.mtx.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (b *AppendBuffer) flushInBackground() {
+	err := b.Flush(context.Background())
+	if err != nil {
+		b.mtx.Lock()
+		b.err = err
+		b.mtx.Unlock()
+	}
+}
+
+// Close flushes the buffered data and
+// makes further writes return os.ErrClosed.
+// Close implements the io.Closer interface.
+func (b *AppendBuffer) Close() error {
+	b.mtx.Lock()
+	if b.closed {
+		b.mtx.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mtx.Unlock()
+
+	return b.Flush(context.Background())
+}
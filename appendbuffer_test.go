@@ -0,0 +1,71 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendBuffer(t *testing.T) {
+	ctx := context.Background()
+	file := File(t.TempDir()).Join("log.txt")
+
+	buf := NewAppendBuffer(file, AppendBufferOptions{MaxSize: 10, MaxDelay: -1})
+	for range 3 {
+		_, err := buf.WriteString("abc")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 9, buf.Buffered())
+	require.False(t, file.Exists(), "not flushed before MaxSize")
+	_, err := buf.WriteString("defg")
+	require.NoError(t, err)
+	require.Zero(t, buf.Buffered())
+	requireFileContent(t, file, "abcabcabcdefg")
+
+	_, err = buf.WriteString("x")
+	require.NoError(t, err)
+	require.NoError(t, buf.Flush(ctx))
+	requireFileContent(t, file, "abcabcabcdefgx")
+
+	_, err = buf.WriteString("y")
+	require.NoError(t, err)
+	require.NoError(t, buf.Close())
+	requireFileContent(t, file, "abcabcabcdefgxy")
+	_, err = buf.WriteString("z")
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.NoError(t, buf.Close(), "closing twice")
+}
+
+func TestAppendBuffer_MaxDelay(t *testing.T) {
+	file := File(t.TempDir()).Join("log.txt")
+	buf := NewAppendBuffer(file, AppendBufferOptions{MaxDelay: 10 * time.Millisecond})
+	t.Cleanup(func() { _ = buf.Close() })
+
+	_, err := buf.WriteString("Hello")
+	require.NoError(t, err)
+	_, err = buf.WriteString(" World")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return buf.Buffered() == 0 }, time.Second, time.Millisecond)
+	requireFileContent(t, file, "Hello World")
+}
+
+func TestAppendBuffer_Retry(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir()).Join("missing")
+	file := dir.Join("log.txt")
+	buf := NewAppendBuffer(file, AppendBufferOptions{MaxDelay: -1})
+
+	_, err := buf.WriteString("first ")
+	require.NoError(t, err)
+	require.Error(t, buf.Flush(ctx), "directory does not exist")
+	_, err = buf.WriteString("second")
+	require.NoError(t, err)
+	require.Equal(t, 12, buf.Buffered(), "data kept after failed flush")
+
+	require.NoError(t, dir.MakeDir())
+	require.NoError(t, buf.Close())
+	requireFileContent(t, file, "first second")
+}
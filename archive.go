@@ -0,0 +1,268 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ArchiveOption configures ZipDir and TarGzDir
+type ArchiveOption func(*archiveConfig)
+
+type archiveConfig struct {
+	level   int
+	exclude []string
+	archive File // skipped if inside of the archived directory
+}
+
+// ArchiveCompressionLevel returns an ArchiveOption that sets
+// the compression level from flate.BestSpeed to flate.BestCompression,
+// zero selects the default level.
+func ArchiveCompressionLevel(level int) ArchiveOption {
+	return func(config *archiveConfig) {
+		config.level = level
+	}
+}
+
+// ArchiveExclude returns an ArchiveOption that skips files
+// and directories with a name matching one of the patterns.
+func ArchiveExclude(patterns ...string) ArchiveOption {
+	return func(config *archiveConfig) {
+		config.exclude = append(config.exclude, patterns...)
+	}
+}
+
+// skip returns if info must not be added to the archive
+func (config *archiveConfig) skip(info *FileInfo) (bool, error) {
+	if info.File == config.archive {
+		return true, nil
+	}
+	if len(config.exclude) == 0 {
+		return false, nil
+	}
+	return info.File.FileSystem().MatchAnyPattern(info.Name, config.exclude)
+}
+
+// ZipDir writes all files and sub-directories of dir
+// as zip archive to the file dest, which can be
+// on a different file system than dir.
+// The paths in the archive are slash separated and relative to dir,
+// the permissions and modification times of the files are preserved.
+// The parent directories of dest are created if they don't exist.
+func ZipDir(ctx context.Context, dir, dest File, options ...ArchiveOption) (err error) {
+	config := archiveConfig{archive: dest}
+	for _, option := range options {
+		option(&config)
+	}
+	if err := dir.CheckIsDir(); err != nil {
+		return err
+	}
+	err = dest.Dir().MakeAllDirs()
+	if err != nil {
+		return fmt.Errorf("ZipDir: %w", err)
+	}
+	w, err := dest.OpenWriter()
+	if err != nil {
+		return fmt.Errorf("ZipDir: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, w.Close())
+	}()
+
+	zw := zip.NewWriter(w)
+	if config.level != 0 {
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, config.level)
+		})
+	}
+	buf := make([]byte, copyBufferSize)
+	err = zipTree(ctx, zw, dir, "", buf, &config)
+	if err != nil {
+		return fmt.Errorf("ZipDir: %w", err)
+	}
+	return zw.Close()
+}
+
+func zipTree(ctx context.Context, zw *zip.Writer, dir File, dirPath string, buf []byte, config *archiveConfig) error {
+	return dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if skip, err := config.skip(info); skip || err != nil {
+			return err
+		}
+		header := &zip.FileHeader{
+			Name:     dirPath + info.Name,
+			Method:   zip.Deflate,
+			Modified: info.Modified,
+		}
+		header.SetMode(info.Permissions.FileMode(info.IsDir))
+		if info.IsDir {
+			header.Name += "/"
+			header.Method = zip.Store
+			if _, err := zw.CreateHeader(header); err != nil {
+				return err
+			}
+			return zipTree(ctx, zw, info.File, header.Name, buf, config)
+		}
+		header.UncompressedSize64 = uint64(info.Size)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		r, err := info.File.OpenReader()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return copyBuffer(ctx, w, r, buf)
+	})
+}
+
+// Unzip extracts the directories and files of the zip archive
+// with their permissions into destDir, which can be
+// on a different file system than archive.
+// destDir is created if it does not exist,
+// existing files are overwritten.
+//
+// Entries with paths outside of destDir result in an error
+// wrapping ErrPathOutsideRoot.
+// Entries other than directories and regular files,
+// like symbolic links, are skipped.
+//
+// Zip archives can only be read with random access,
+// so if the reader of the archive's file system
+// can't seek, then the archive is read into memory.
+func Unzip(ctx context.Context, archive, destDir File) error {
+	r, err := archive.OpenReadSeeker()
+	if err != nil {
+		return fmt.Errorf("Unzip: %w", err)
+	}
+	defer r.Close()
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("Unzip: %w", err)
+	}
+	readerAt, ok := r.(io.ReaderAt)
+	if !ok {
+		readerAt = &readSeekerAt{readSeeker: r}
+	}
+	zr, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return fmt.Errorf("Unzip: %w", err)
+	}
+
+	err = destDir.MakeAllDirs()
+	if err != nil {
+		return fmt.Errorf("Unzip: %w", err)
+	}
+	buf := make([]byte, copyBufferSize)
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name, err := cleanArchivePath(f.Name)
+		if err != nil {
+			return fmt.Errorf("Unzip: %w", err)
+		}
+		if name == "." {
+			continue
+		}
+		dest := destDir.Join(strings.Split(name, "/")...)
+		perm := Permissions(f.Mode().Perm())
+		switch {
+		case f.Mode().IsDir():
+			err = dest.MakeAllDirs(perm)
+		case f.Mode().IsRegular():
+			err = unzipFile(ctx, f, dest, perm, buf)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("Unzip: %w", err)
+		}
+	}
+	return nil
+}
+
+func unzipFile(ctx context.Context, f *zip.File, dest File, perm Permissions, buf []byte) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return receiveTreeFile(ctx, r, dest, perm, buf)
+}
+
+// readSeekerAt implements io.ReaderAt
+// by seeking before every read
+type readSeekerAt struct {
+	mtx        sync.Mutex
+	readSeeker io.ReadSeeker
+}
+
+func (r *readSeekerAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, err := r.readSeeker.Seek(off, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r.readSeeker, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// TarGzDir writes all files and sub-directories of dir
+// as gzip compressed tar archive to the file dest, which can be
+// on a different file system than dir.
+// The archive has the same format as the stream of SendTree.
+// The parent directories of dest are created if they don't exist.
+func TarGzDir(ctx context.Context, dir, dest File, options ...ArchiveOption) (err error) {
+	config := archiveConfig{archive: dest}
+	for _, option := range options {
+		option(&config)
+	}
+	if err := dir.CheckIsDir(); err != nil {
+		return err
+	}
+	err = dest.Dir().MakeAllDirs()
+	if err != nil {
+		return fmt.Errorf("TarGzDir: %w", err)
+	}
+	w, err := dest.OpenGzipWriter(config.level)
+	if err != nil {
+		return fmt.Errorf("TarGzDir: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, w.Close())
+	}()
+
+	tw := tar.NewWriter(w)
+	buf := make([]byte, copyBufferSize)
+	err = sendTree(ctx, tw, dir, "", buf, &config)
+	if err != nil {
+		return fmt.Errorf("TarGzDir: %w", err)
+	}
+	return tw.Close()
+}
+
+// UntarGz extracts the directories and files of the
+// gzip compressed tar archive with their permissions into destDir,
+// which can be on a different file system than archive.
+// Uncompressed tar archives are also supported.
+// See ReceiveTree for how the entries are extracted.
+func UntarGz(ctx context.Context, archive, destDir File) error {
+	r, err := archive.OpenGzipReader()
+	if err != nil {
+		return fmt.Errorf("UntarGz: %w", err)
+	}
+	defer r.Close()
+	return ReceiveTree(ctx, r, destDir)
+}
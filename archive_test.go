@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZipDir(t *testing.T) {
+	ctx := context.Background()
+	src := File(t.TempDir())
+	require.NoError(t, src.Join("sub", "empty").MakeAllDirs())
+	require.NoError(t, src.Join("a.txt").WriteAllString("a", UserReadWrite))
+	require.NoError(t, src.Join("sub", "b.txt").WriteAllString("b"))
+	require.NoError(t, src.Join("skip.tmp").WriteAllString("excluded"))
+
+	for name, archiveFuncs := range map[string]struct {
+		archive func(context.Context, File, File, ...ArchiveOption) error
+		extract func(context.Context, File, File) error
+	}{
+		"zip":    {ZipDir, Unzip},
+		"tar.gz": {TarGzDir, UntarGz},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Archive inside of the archived directory is not archived itself
+			archive := src.Join("archive." + name)
+			err := archiveFuncs.archive(ctx, src, archive, ArchiveExclude("*.tmp"), ArchiveCompressionLevel(9))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = archive.Remove() })
+
+			// Extract to another file system
+			destFS, err := NewSubFileSystem(File(t.TempDir()))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = destFS.Close() })
+			dest := destFS.RootDir().Join("extracted")
+			require.NoError(t, archiveFuncs.extract(ctx, archive, dest))
+
+			requireFileContent(t, dest.Join("a.txt"), "a")
+			requireFileContent(t, dest.Join("sub", "b.txt"), "b")
+			require.True(t, dest.Join("sub", "empty").IsDir(), "empty directory extracted")
+			require.False(t, dest.Join("skip.tmp").Exists(), "excluded file not archived")
+			require.False(t, dest.Join("archive."+name).Exists(), "archive not archived")
+			if runtime.GOOS != "windows" {
+				require.Equal(t, UserReadWrite, dest.Join("a.txt").Permissions(), "permissions preserved")
+			}
+		})
+	}
+}
+
+func TestUnzip_PathOutsideRoot(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, err := zw.Create("../evil.txt")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	dir := File(t.TempDir())
+	archive := dir.Join("evil.zip")
+	require.NoError(t, archive.WriteAll(buf.Bytes()))
+
+	err = Unzip(context.Background(), archive, dir.Join("dest"))
+	require.True(t, errors.Is(err, ErrPathOutsideRoot), "zip slip prevented")
+	require.False(t, dir.Join("evil.txt").Exists())
+}
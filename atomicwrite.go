@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+// atomicWriteTempName returns a hidden unique name
+// for a temporary file used to atomically write name
+func atomicWriteTempName(name string) string {
+	return "." + name + ".tmp-" + fsimpl.RandomString()
+}
+
+// WriteAllAtomic writes data so that readers of the file
+// never observe a partially written file.
+//
+// File systems implementing AtomicWriteFileSystem write atomically.
+// Other file systems implementing RenameFileSystem get the data
+// written to a hidden temporary file in the same directory
+// that is then renamed to the file, which is atomic
+// if the rename of the file system is atomic.
+// File systems without rename support fall back
+// to a regular WriteAllContext as best effort.
+func (file File) WriteAllAtomic(ctx context.Context, data []byte, perm ...Permissions) error {
+	if file == "" {
+		return ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	fileSystem, path := file.ParseRawURI()
+	if atomicFS, ok := fileSystem.(AtomicWriteFileSystem); ok {
+		endOp, err := beginOpContext(ctx, fileSystem)
+		if err != nil {
+			return err
+		}
+		defer endOp()
+		return atomicFS.WriteAllAtomic(ctx, path, data, perm)
+	}
+	renameFS, ok := fileSystem.(RenameFileSystem)
+	if !ok {
+		return file.WriteAllContext(ctx, data, perm...)
+	}
+
+	tempFile := file.Dir().Join(atomicWriteTempName(file.Name()))
+	err := tempFile.WriteAllContext(ctx, data, perm...)
+	if err != nil {
+		return errors.Join(err, RemoveErrDoesNotExist(tempFile.Remove()))
+	}
+	endOp, err := beginOpContext(ctx, fileSystem)
+	if err != nil {
+		return errors.Join(err, tempFile.Remove())
+	}
+	defer endOp()
+	_, err = renameFS.Rename(tempFile.Path(), file.Name())
+	if err != nil {
+		err = fmt.Errorf("can't rename temporary file to %s: %w", file, err)
+		return errors.Join(err, tempFile.Remove())
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_WriteAllAtomic(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	file := dir.Join("file.txt")
+
+	require.NoError(t, file.WriteAllAtomic(ctx, []byte("Hello")))
+	requireFileContent(t, file, "Hello")
+
+	// Replace existing file
+	require.NoError(t, file.WriteAllAtomic(ctx, []byte("World")))
+	requireFileContent(t, file, "World")
+
+	// No temporary files are left
+	files, err := dir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []File{file}, files)
+
+	// Writing into a missing directory fails without leftovers
+	err = dir.Join("missing", "file.txt").WriteAllAtomic(ctx, []byte("x"))
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, file.WriteAllAtomic(ctx, []byte("canceled")), context.Canceled)
+	requireFileContent(t, file, "World")
+}
+
+func TestFile_WriteAllAtomic_SubFileSystem(t *testing.T) {
+	sub, err := NewSubFileSystem(File(t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { sub.Close() })
+
+	file := sub.JoinCleanFile("file.txt")
+	require.NoError(t, file.WriteAllAtomic(context.Background(), []byte("Hello")))
+	requireFileContent(t, file, "Hello")
+	files, err := sub.RootDir().ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []File{file}, files)
+}
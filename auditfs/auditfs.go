@@ -0,0 +1,347 @@
+// Package auditfs implements a file system that writes
+// an audit record for every mutating operation of another file system.
+package auditfs
+
+import (
+	"context"
+	"fmt"
+	iofs "io/fs"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of AuditFileSystem URIs
+	Prefix = "audit://"
+)
+
+var (
+	// Make sure AuditFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(AuditFileSystem)
+	_ fs.ReadAllFileSystem  = new(AuditFileSystem)
+	_ fs.WriteAllFileSystem = new(AuditFileSystem)
+	_ fs.AppendFileSystem   = new(AuditFileSystem)
+	_ fs.TouchFileSystem    = new(AuditFileSystem)
+	_ fs.TruncateFileSystem = new(AuditFileSystem)
+	_ fs.CopyFileSystem     = new(AuditFileSystem)
+	_ fs.RenameFileSystem   = new(AuditFileSystem)
+	_ fs.MoveFileSystem     = new(AuditFileSystem)
+	_ fs.ExistsFileSystem   = new(AuditFileSystem)
+)
+
+// AuditFileSystem wraps a file system and passes a Record
+// with timestamp, operation, path, size, and result
+// of every mutating operation to a Logger.
+// Failed operations are also recorded.
+//
+// The mutating operations are MakeDir, WriteAll, Append, Touch,
+// Truncate, OpenWriter, OpenReadWriter, CopyFile, Rename, Move, and Remove.
+// Writers opened with OpenWriter and OpenReadWriter are recorded
+// when they are closed with the number of written bytes as size.
+//
+// It is registered with the prefix "audit://" followed by a random ID.
+type AuditFileSystem struct {
+	fs.WrapperBase
+
+	label  string
+	logger Logger
+	closed atomic.Bool
+}
+
+// New returns a new AuditFileSystem for inner
+// that passes its records to logger and registers it.
+// The label is used as Record.FileSystem,
+// the name of inner is used if label is empty.
+func New(inner fs.FileSystem, label string, logger Logger) (*AuditFileSystem, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("nil inner file system")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("nil logger")
+	}
+	if label == "" {
+		label = inner.Name()
+	}
+	f := &AuditFileSystem{
+		WrapperBase: fs.NewWrapperBase(Prefix+fsimpl.RandomString(), inner),
+		label:       label,
+		logger:      logger,
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Label returns the label used as Record.FileSystem
+func (f *AuditFileSystem) Label() string {
+	return f.label
+}
+
+// audit passes a record to the logger,
+// use it with defer and a pointer to a named error result.
+func (f *AuditFileSystem) audit(start time.Time, op, filePath, destPath string, size int64, err *error) {
+	f.logger.LogAudit(&Record{
+		Time:       start,
+		FileSystem: f.label,
+		Op:         op,
+		Path:       filePath,
+		DestPath:   destPath,
+		Size:       size,
+		Err:        *err,
+	})
+}
+
+func (f *AuditFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *AuditFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.Inner().ReadableWritable()
+}
+
+func (f *AuditFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.Prefix(), Prefix), nil
+}
+
+func (f *AuditFileSystem) Name() string {
+	return "audited " + f.Inner().Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *AuditFileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+func (f *AuditFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.Inner().Stat(filePath)
+}
+
+func (f *AuditFileSystem) Exists(filePath string) bool {
+	return f.checkOpen() == nil && f.InnerFile(filePath).Exists()
+}
+
+func (f *AuditFileSystem) IsHidden(filePath string) bool {
+	return f.Inner().IsHidden(filePath)
+}
+
+func (f *AuditFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.Inner().IsSymbolicLink(filePath)
+}
+
+func (f *AuditFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().ListDirInfo(ctx, dirPath, func(innerInfo *fs.FileInfo) error {
+		info := *innerInfo
+		info.File = f.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns)
+}
+
+func (f *AuditFileSystem) MakeDir(dirPath string, perm []fs.Permissions) (err error) {
+	defer f.audit(time.Now(), "MakeDir", dirPath, "", 0, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().MakeDir(dirPath, perm)
+}
+
+func (f *AuditFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.InnerFile(filePath).ReadAllContext(ctx)
+}
+
+func (f *AuditFileSystem) OpenReader(filePath string) (fs.ReadCloser, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.Inner().OpenReader(filePath)
+}
+
+func (f *AuditFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	defer f.audit(time.Now(), "WriteAll", filePath, "", int64(len(data)), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).WriteAllContext(ctx, data, perm...)
+}
+
+func (f *AuditFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	defer f.audit(time.Now(), "Append", filePath, "", int64(len(data)), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Append(ctx, data, perm...)
+}
+
+func (f *AuditFileSystem) Touch(filePath string, perm []fs.Permissions) (err error) {
+	defer f.audit(time.Now(), "Touch", filePath, "", 0, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Touch(perm...)
+}
+
+func (f *AuditFileSystem) Truncate(filePath string, size int64) (err error) {
+	defer f.audit(time.Now(), "Truncate", filePath, "", size, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Truncate(size)
+}
+
+func (f *AuditFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (writer fs.WriteCloser, err error) {
+	start := time.Now()
+	if err = f.checkOpen(); err == nil {
+		writer, err = f.Inner().OpenWriter(filePath, perm)
+	}
+	if err != nil {
+		f.audit(start, "OpenWriter", filePath, "", 0, &err)
+		return nil, err
+	}
+	return &auditWriter{WriteCloser: writer, fileSystem: f, start: start, path: filePath}, nil
+}
+
+func (f *AuditFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (readWriter fs.ReadWriteSeekCloser, err error) {
+	start := time.Now()
+	if err = f.checkOpen(); err == nil {
+		readWriter, err = f.Inner().OpenReadWriter(filePath, perm)
+	}
+	if err != nil {
+		f.audit(start, "OpenReadWriter", filePath, "", 0, &err)
+		return nil, err
+	}
+	return &auditReadWriter{ReadWriteSeekCloser: readWriter, fileSystem: f, start: start, path: filePath}, nil
+}
+
+func (f *AuditFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) (err error) {
+	var size int64
+	defer func(start time.Time) { f.audit(start, "CopyFile", srcFile, destFile, size, &err) }(time.Now())
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	err = fs.CopyFileBuf(ctx, f.InnerFile(srcFile), f.InnerFile(destFile), buf)
+	if err == nil {
+		size = f.InnerFile(destFile).Size()
+	}
+	return err
+}
+
+func (f *AuditFileSystem) Rename(filePath string, newName string) (newPath string, err error) {
+	defer func(start time.Time) { f.audit(start, "Rename", filePath, newPath, 0, &err) }(time.Now())
+	if err = f.checkOpen(); err != nil {
+		return "", err
+	}
+	renamed, err := f.InnerFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return renamed.Path(), nil
+}
+
+func (f *AuditFileSystem) Move(filePath string, destPath string) (err error) {
+	defer f.audit(time.Now(), "Move", filePath, destPath, 0, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).MoveTo(f.InnerFile(destPath))
+}
+
+func (f *AuditFileSystem) Remove(filePath string) (err error) {
+	defer f.audit(time.Now(), "Remove", filePath, "", 0, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().Remove(filePath)
+}
+
+// Close unregisters the file system,
+// the inner file system is not closed.
+func (f *AuditFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// auditWriter counts the written bytes
+// and records the first error when closed
+type auditWriter struct {
+	fs.WriteCloser
+	fileSystem *AuditFileSystem
+	start      time.Time
+	path       string
+	written    atomic.Int64
+	err        error
+	closed     atomic.Bool
+}
+
+func (w *auditWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.written.Add(int64(n))
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *auditWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if !w.closed.Swap(true) {
+		recErr := w.err
+		if recErr == nil {
+			recErr = err
+		}
+		w.fileSystem.audit(w.start, "OpenWriter", w.path, "", w.written.Load(), &recErr)
+	}
+	return err
+}
+
+// auditReadWriter counts the written bytes
+// and records the first write error when closed
+type auditReadWriter struct {
+	fs.ReadWriteSeekCloser
+	fileSystem *AuditFileSystem
+	start      time.Time
+	path       string
+	written    atomic.Int64
+	err        error
+	closed     atomic.Bool
+}
+
+func (rw *auditReadWriter) Write(p []byte) (int, error) {
+	n, err := rw.ReadWriteSeekCloser.Write(p)
+	rw.written.Add(int64(n))
+	if err != nil && rw.err == nil {
+		rw.err = err
+	}
+	return n, err
+}
+
+func (rw *auditReadWriter) Close() error {
+	err := rw.ReadWriteSeekCloser.Close()
+	if !rw.closed.Swap(true) {
+		recErr := rw.err
+		if recErr == nil {
+			recErr = err
+		}
+		rw.fileSystem.audit(rw.start, "OpenReadWriter", rw.path, "", rw.written.Load(), &recErr)
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package auditfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+type recorder struct {
+	mtx     sync.Mutex
+	records []*Record
+}
+
+func (r *recorder) LogAudit(record *Record) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.records = append(r.records, record)
+}
+
+func (r *recorder) ops() (ops []string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, rec := range r.records {
+		ops = append(ops, rec.Op)
+	}
+	return ops
+}
+
+func (r *recorder) last() *Record {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.records[len(r.records)-1]
+}
+
+func TestAuditFileSystem(t *testing.T) {
+	rec := new(recorder)
+	auditFS, err := New(fs.Local, "test", rec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = auditFS.Close() })
+	dir := auditFS.JoinCleanFile(t.TempDir())
+	require.True(t, fs.IsRegistered(auditFS))
+	require.Equal(t, "test", auditFS.Label())
+
+	file := dir.Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+	last := rec.last()
+	require.Equal(t, "WriteAll", last.Op)
+	require.Equal(t, "test", last.FileSystem)
+	require.Equal(t, file.Path(), last.Path)
+	require.Equal(t, int64(5), last.Size)
+	require.NoError(t, last.Err)
+	require.False(t, last.Time.IsZero())
+
+	// Reads are not recorded
+	_, err = file.ReadAll()
+	require.NoError(t, err)
+	require.True(t, file.Exists())
+	require.Equal(t, []string{"WriteAll"}, rec.ops())
+
+	writer, err := dir.Join("other.txt").OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("123"))
+	require.NoError(t, err)
+	require.Len(t, rec.ops(), 1, "recorded when closed")
+	require.NoError(t, writer.Close())
+	require.Equal(t, "OpenWriter", rec.last().Op)
+	require.Equal(t, int64(3), rec.last().Size)
+
+	require.NoError(t, fs.CopyFile(context.Background(), file, dir.Join("copy.txt")))
+	last = rec.last()
+	require.Equal(t, "CopyFile", last.Op)
+	require.Equal(t, dir.Join("copy.txt").Path(), last.DestPath)
+	require.Equal(t, int64(5), last.Size)
+
+	// Failed operations are recorded
+	err = dir.Join("missing.txt").Remove()
+	require.Error(t, err)
+	last = rec.last()
+	require.Equal(t, "Remove", last.Op)
+	require.Error(t, last.Err)
+	require.Equal(t, last.Err.Error(), last.Result())
+}
+
+func TestJSONLinesLogger(t *testing.T) {
+	var buf bytes.Buffer
+	auditFS, err := New(fs.Local, "test", JSONLinesLogger(&buf, func(err error) { t.Error(err) }))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = auditFS.Close() })
+	dir := auditFS.JoinCleanFile(t.TempDir())
+
+	require.NoError(t, dir.Join("a.txt").WriteAllString("abc"))
+	require.NoError(t, dir.Join("a.txt").Truncate(1))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	require.Equal(t, "test", entry["fs"])
+	require.Equal(t, "Truncate", entry["op"])
+	require.Equal(t, dir.Join("a.txt").Path(), entry["path"])
+	require.Equal(t, float64(1), entry["size"])
+	require.Equal(t, "ok", entry["result"])
+}
@@ -0,0 +1,107 @@
+package auditfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Record is the audit record of a mutating file system operation
+type Record struct {
+	// Time when the operation started
+	Time time.Time
+	// FileSystem is the label of the AuditFileSystem
+	FileSystem string
+	// Op is the name of the FileSystem method
+	Op string
+	// Path of the file in the wrapped file system
+	Path string
+	// DestPath is the destination of CopyFile, Move, and Rename
+	DestPath string
+	// Size is the number of written bytes
+	// or the size of a truncated or copied file
+	Size int64
+	// Err is the error result of the operation
+	Err error
+}
+
+// Result returns "ok" or the error message of the record
+func (r *Record) Result() string {
+	if r.Err != nil {
+		return r.Err.Error()
+	}
+	return "ok"
+}
+
+// String implements the fmt.Stringer interface.
+func (r *Record) String() string {
+	s := fmt.Sprintf("%s %s %s %s", r.Time.Format(time.RFC3339Nano), r.FileSystem, r.Op, r.Path)
+	if r.DestPath != "" {
+		s += " -> " + r.DestPath
+	}
+	return fmt.Sprintf("%s size=%d result=%q", s, r.Size, r.Result())
+}
+
+// MarshalJSON implements encoding/json.Marshaler
+func (r *Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Time       time.Time `json:"time"`
+		FileSystem string    `json:"fs"`
+		Op         string    `json:"op"`
+		Path       string    `json:"path"`
+		DestPath   string    `json:"dest,omitempty"`
+		Size       int64     `json:"size"`
+		Result     string    `json:"result"`
+	}{
+		Time:       r.Time,
+		FileSystem: r.FileSystem,
+		Op:         r.Op,
+		Path:       r.Path,
+		DestPath:   r.DestPath,
+		Size:       r.Size,
+		Result:     r.Result(),
+	})
+}
+
+// Logger receives the audit records of an AuditFileSystem.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	LogAudit(record *Record)
+}
+
+// LoggerFunc implements Logger as higher order function
+type LoggerFunc func(record *Record)
+
+func (f LoggerFunc) LogAudit(record *Record) {
+	f(record)
+}
+
+// PrintfLogger returns a Logger that prints
+// the String representation of records to logger.
+func PrintfLogger(logger fs.Logger) Logger {
+	return LoggerFunc(func(record *Record) {
+		logger.Printf("audit: %s", record)
+	})
+}
+
+// JSONLinesLogger returns a Logger that writes records
+// as JSON lines to w, for example an append only file.
+// Write errors are passed to onError if it is not nil.
+func JSONLinesLogger(w io.Writer, onError func(error)) Logger {
+	var mtx sync.Mutex
+	return LoggerFunc(func(record *Record) {
+		line, err := json.Marshal(record)
+		if err == nil {
+			mtx.Lock()
+			_, err = w.Write(append(line, '\n'))
+			mtx.Unlock()
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
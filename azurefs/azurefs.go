@@ -0,0 +1,642 @@
+package azurefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	fs "github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of Azure Blob Storage URLs
+	Prefix = "azblob://"
+
+	// Separator used in Azure Blob Storage paths
+	Separator = "/"
+
+	// folderMetadataKey marks empty blobs as directories.
+	// This is how storage accounts with hierarchical namespace
+	// (Data Lake Storage Gen2) represent directories in the blob API.
+	folderMetadataKey = "hdi_isfolder"
+)
+
+var (
+	// DefaultPermissions used for container files
+	DefaultPermissions = fs.UserAndGroupReadWrite
+	// DefaultDirPermissions used for container directories
+	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllReadWrite
+
+	// UploadBlockSize is the size of the blocks staged by OpenWriter
+	// for streaming uploads of block blobs.
+	UploadBlockSize int64 = 8 * 1024 * 1024
+
+	// UploadConcurrency is the number of blocks
+	// uploaded in parallel by OpenWriter.
+	UploadConcurrency = 4
+
+	// CopyPollInterval is the interval used by CopyFile
+	// to check if a server side copy has finished.
+	CopyPollInterval = 500 * time.Millisecond
+
+	// Make sure fileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(fileSystem)
+)
+
+type fileSystem struct {
+	client        *container.Client
+	containerName string
+	prefix        string
+	readOnly      bool
+}
+
+// NewAndRegister returns a fs.FileSystem for the Azure Blob Storage container
+// with the name containerName using client and registers it
+// with the prefix "azblob://" + containerName.
+func NewAndRegister(client *azblob.Client, containerName string, readOnly bool) fs.FileSystem {
+	azfs := &fileSystem{
+		client:        client.ServiceClient().NewContainerClient(containerName),
+		containerName: containerName,
+		prefix:        Prefix + containerName,
+		readOnly:      readOnly,
+	}
+	fs.Register(azfs)
+	return azfs
+}
+
+// NewFromConnectionString creates a new azblob.Client
+// from a storage account connection string and passes it to NewAndRegister.
+func NewFromConnectionString(connectionString, containerName string, readOnly bool) (fs.FileSystem, error) {
+	client, err := azblob.NewClientFromConnectionString(connectionString, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewAndRegister(client, containerName, readOnly), nil
+}
+
+// blobName returns the blob name for filePath
+// which is the absolute path without the leading slash.
+func blobName(filePath string) string {
+	return strings.TrimPrefix(filePath, Separator)
+}
+
+// dirBlobPrefix returns the blob name prefix
+// of all blobs within dirPath.
+func dirBlobPrefix(dirPath string) string {
+	name := blobName(dirPath)
+	if name == "" || strings.HasSuffix(name, Separator) {
+		return name
+	}
+	return name + Separator
+}
+
+// isFolder returns if the metadata marks a directory blob
+func isFolder(metadata map[string]*string) bool {
+	for key, value := range metadata {
+		if strings.EqualFold(key, folderMetadataKey) && value != nil && strings.EqualFold(*value, "true") {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *fileSystem) blob(filePath string) *blob.Client {
+	return a.client.NewBlobClient(blobName(filePath))
+}
+
+func (a *fileSystem) blockBlob(filePath string) *blockblob.Client {
+	return a.client.NewBlockBlobClient(blobName(filePath))
+}
+
+func (a *fileSystem) wrapErrNotExist(filePath string, err error) error {
+	if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound, bloberror.ResourceNotFound) {
+		return fs.NewErrDoesNotExist(a.File(filePath))
+	}
+	return err
+}
+
+func (a *fileSystem) ReadableWritable() (readable, writable bool) {
+	return true, !a.readOnly
+}
+
+func (a *fileSystem) RootDir() fs.File {
+	return fs.File(a.prefix + Separator)
+}
+
+func (a *fileSystem) ID() (string, error) {
+	return a.client.URL(), nil
+}
+
+func (a *fileSystem) Prefix() string {
+	return a.prefix
+}
+
+func (a *fileSystem) Name() string {
+	return "Azure Blob Storage file system for container: " + a.containerName
+}
+
+func (a *fileSystem) String() string {
+	return a.Name() + " with prefix " + a.prefix
+}
+
+func (a *fileSystem) File(filePath string) fs.File {
+	return a.JoinCleanFile(filePath)
+}
+
+func (a *fileSystem) URL(cleanPath string) string {
+	return a.prefix + cleanPath
+}
+
+func (a *fileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, a.prefix)
+}
+
+func (a *fileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(a.prefix + a.JoinCleanPath(uriParts...))
+}
+
+func (a *fileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, a.prefix, Separator)
+}
+
+func (a *fileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, a.prefix, Separator)
+}
+
+func (a *fileSystem) Separator() string {
+	return Separator
+}
+
+func (a *fileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (a *fileSystem) AbsPath(filePath string) string {
+	if path.IsAbs(filePath) {
+		return filePath
+	}
+	return Separator + filePath
+}
+
+func (a *fileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (a *fileSystem) VolumeName(filePath string) string {
+	return a.containerName
+}
+
+// hasBlobsWithPrefix returns if there are any blobs within dirPath
+// which implies a directory without a folder marker blob.
+func (a *fileSystem) hasBlobsWithPrefix(ctx context.Context, dirPath string) (bool, error) {
+	prefix := dirBlobPrefix(dirPath)
+	if prefix == "" {
+		return true, nil // container root
+	}
+	maxResults := int32(1)
+	pager := a.client.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix:     &prefix,
+		MaxResults: &maxResults,
+	})
+	page, err := pager.NextPage(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(page.Segment.BlobItems) > 0, nil
+}
+
+func (a *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	ctx := context.Background()
+	if blobName(filePath) != "" {
+		props, err := a.blob(filePath).GetProperties(ctx, nil)
+		if err == nil {
+			return &fileInfo{
+				name:    path.Base(filePath),
+				size:    deref(props.ContentLength),
+				modTime: deref(props.LastModified),
+				isDir:   isFolder(props.Metadata),
+			}, nil
+		}
+		if !bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, a.wrapErrNotExist(filePath, err)
+		}
+	}
+	isDir, err := a.hasBlobsWithPrefix(ctx, filePath)
+	if err != nil {
+		return nil, a.wrapErrNotExist(filePath, err)
+	}
+	if !isDir {
+		return nil, fs.NewErrDoesNotExist(a.File(filePath))
+	}
+	return &fileInfo{name: path.Base(filePath), isDir: true}, nil
+}
+
+func (a *fileSystem) Exists(filePath string) bool {
+	_, err := a.Stat(filePath)
+	return err == nil
+}
+
+// IsHidden returns true if the given file path is not empty and starts with a
+// dot. There are no real "hidden" files in containers, but since dot prefixes
+// are the general convention to determine which directories/files are hidden
+// and which are not, the function behaves this way.
+func (a *fileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (a *fileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+func (a *fileSystem) blobItemInfo(item *container.BlobItem) *fs.FileInfo {
+	name := deref(item.Name)
+	info := &fileInfo{name: path.Base(name), isDir: isFolder(item.Metadata)}
+	if item.Properties != nil {
+		info.size = deref(item.Properties.ContentLength)
+		info.modTime = deref(item.Properties.LastModified)
+	}
+	return fs.NewFileInfo(a.File(name), info, a.IsHidden(name))
+}
+
+func (a *fileSystem) listDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	prefix := dirBlobPrefix(dirPath)
+	pager := a.client.NewListBlobsHierarchyPager(Separator, &container.ListBlobsHierarchyOptions{
+		Prefix:  &prefix,
+		Include: container.ListBlobsInclude{Metadata: true},
+	})
+	// With hierarchical namespace a directory is listed
+	// as folder blob and as prefix if it's not empty
+	listedDirs := make(map[string]bool)
+	found := false
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return a.wrapErrNotExist(dirPath, err)
+		}
+		var infos []*fs.FileInfo
+		for _, item := range page.Segment.BlobItems {
+			info := a.blobItemInfo(item)
+			if info.IsDir {
+				listedDirs[info.Name] = true
+			}
+			infos = append(infos, info)
+		}
+		for _, blobPrefix := range page.Segment.BlobPrefixes {
+			name := strings.TrimSuffix(deref(blobPrefix.Name), Separator)
+			if listedDirs[path.Base(name)] {
+				continue
+			}
+			infos = append(infos, fs.NewFileInfo(
+				a.File(name),
+				&fileInfo{name: path.Base(name), isDir: true},
+				a.IsHidden(name),
+			))
+		}
+		found = found || len(infos) > 0
+		err = callbackMatching(a, infos, callback, patterns)
+		if err != nil {
+			return err
+		}
+	}
+	if !found && prefix != "" && !a.Exists(dirPath) {
+		return fs.NewErrDoesNotExist(a.File(dirPath))
+	}
+	return nil
+}
+
+func (a *fileSystem) listDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	prefix := dirBlobPrefix(dirPath)
+	pager := a.client.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix:  &prefix,
+		Include: container.ListBlobsInclude{Metadata: true},
+	})
+	found := false
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return a.wrapErrNotExist(dirPath, err)
+		}
+		var infos []*fs.FileInfo
+		for _, item := range page.Segment.BlobItems {
+			found = true
+			info := a.blobItemInfo(item)
+			if info.IsDir {
+				continue // only files are listed recursively
+			}
+			infos = append(infos, info)
+		}
+		err = callbackMatching(a, infos, callback, patterns)
+		if err != nil {
+			return err
+		}
+	}
+	if !found && prefix != "" && !a.Exists(dirPath) {
+		return fs.NewErrDoesNotExist(a.File(dirPath))
+	}
+	return nil
+}
+
+func callbackMatching(a *fileSystem, infos []*fs.FileInfo, callback func(*fs.FileInfo) error, patterns []string) error {
+	for _, info := range infos {
+		match, err := a.MatchAnyPattern(info.Name, patterns)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		err = callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *fileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return a.listDirInfo(ctx, dirPath, callback, patterns)
+}
+
+func (a *fileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return a.listDirInfoRecursive(ctx, dirPath, callback, patterns)
+}
+
+func (a *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if a.Exists(filePath) {
+		return nil // TODO can we change the modified time?
+	}
+	return a.WriteAll(context.Background(), filePath, nil, perm)
+}
+
+// MakeDir creates an empty blob marked as folder
+// the same way as storage accounts with hierarchical namespace do,
+// so empty directories are listed.
+func (a *fileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if blobName(dirPath) == "" {
+		return nil
+	}
+	if a.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if a.Exists(dirPath) {
+		return fs.NewErrAlreadyExists(a.File(dirPath))
+	}
+	isFolder := "true"
+	_, err := a.blockBlob(dirPath).UploadBuffer(context.Background(), nil, &blockblob.UploadBufferOptions{
+		Metadata: map[string]*string{folderMetadataKey: &isFolder},
+	})
+	return err
+}
+
+func (a *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	resp, err := a.blob(filePath).DownloadStream(ctx, nil)
+	if err != nil {
+		return nil, a.wrapErrNotExist(filePath, err)
+	}
+	defer resp.Body.Close()
+
+	return fs.ReadAllContext(ctx, resp.Body)
+}
+
+// ReadRange reads a byte range of a blob.
+// A negative offset reads the last -offset bytes of the blob.
+func (a *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if offset >= 0 && length <= 0 {
+		return []byte{}, nil
+	}
+	blobClient := a.blob(filePath)
+	if offset < 0 {
+		// HTTPRange does not support suffix ranges
+		props, err := blobClient.GetProperties(ctx, nil)
+		if err != nil {
+			return nil, a.wrapErrNotExist(filePath, err)
+		}
+		size := deref(props.ContentLength)
+		length = min(-offset, size)
+		offset = size - length
+		if length == 0 {
+			return []byte{}, nil
+		}
+	}
+	resp, err := blobClient.DownloadStream(ctx, &blob.DownloadStreamOptions{
+		Range: blob.HTTPRange{Offset: offset, Count: length},
+	})
+	if bloberror.HasCode(err, bloberror.InvalidRange) {
+		return []byte{}, nil // offset after the end of the blob
+	}
+	if err != nil {
+		return nil, a.wrapErrNotExist(filePath, err)
+	}
+	defer resp.Body.Close()
+
+	return fs.ReadAllContext(ctx, resp.Body)
+}
+
+func (a *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if a.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	_, err := a.blockBlob(filePath).UploadBuffer(ctx, data, nil)
+	return err
+}
+
+func (a *fileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	resp, err := a.blob(filePath).DownloadStream(context.Background(), nil)
+	if err != nil {
+		return nil, a.wrapErrNotExist(filePath, err)
+	}
+	info := &fileInfo{
+		name:    path.Base(filePath),
+		size:    deref(resp.ContentLength),
+		modTime: deref(resp.LastModified),
+	}
+	return &blobReader{ReadCloser: resp.Body, info: info}, nil
+}
+
+// OpenWriter returns a writer that streams the written data
+// as blocks of UploadBlockSize to a block blob.
+// The blob is only committed when the writer is closed
+// without error.
+func (a *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if a.readOnly {
+		return nil, fs.ErrReadOnlyFileSystem
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	w := &blobWriter{
+		PipeWriter: pipeWriter,
+		done:       make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		_, w.err = a.blockBlob(filePath).UploadStream(context.Background(), pipeReader, &blockblob.UploadStreamOptions{
+			BlockSize:   UploadBlockSize,
+			Concurrency: UploadConcurrency,
+		})
+		// Unblock writes if the upload failed
+		pipeReader.CloseWithError(w.err)
+	}()
+	return w, nil
+}
+
+func (a *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if a.readOnly {
+		return nil, fs.ErrReadOnlyFileSystem
+	}
+	current, err := a.ReadAll(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(current, func() error {
+		return a.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// CopyFile copies a blob on the server side
+// and waits until the copy has finished.
+func (a *fileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if a.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	destBlob := a.blob(destFile)
+	resp, err := destBlob.StartCopyFromURL(ctx, a.blob(srcFile).URL(), nil)
+	if err != nil {
+		return a.wrapErrNotExist(srcFile, err)
+	}
+	status := deref(resp.CopyStatus)
+	for status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(CopyPollInterval):
+		}
+		props, err := destBlob.GetProperties(ctx, nil)
+		if err != nil {
+			return err
+		}
+		status = deref(props.CopyStatus)
+	}
+	if status != blob.CopyStatusTypeSuccess {
+		return fmt.Errorf("copy of %s to %s finished with status %q", a.File(srcFile), a.File(destFile), status)
+	}
+	return nil
+}
+
+func (a *fileSystem) Move(filePath string, destPath string) error {
+	err := a.CopyFile(context.Background(), filePath, destPath, nil)
+	if err != nil {
+		return err
+	}
+	return a.Remove(filePath)
+}
+
+func (a *fileSystem) Remove(filePath string) error {
+	if a.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	_, err := a.blob(filePath).Delete(context.Background(), nil)
+	return a.wrapErrNotExist(filePath, err)
+}
+
+func (a *fileSystem) Watch(filePath string, onEvent func(fs.File, fs.Event)) (cancel func() error, err error) {
+	// Could be implemented with Event Grid subscriptions
+	return nil, errors.ErrUnsupported
+}
+
+func (a *fileSystem) Close() error {
+	if a.client == nil {
+		return nil // already closed
+	}
+	fs.Unregister(a)
+	a.client = nil
+	return nil
+}
+
+// blobReader implements iofs.File for a blob download stream
+type blobReader struct {
+	io.ReadCloser
+	info *fileInfo
+}
+
+func (r *blobReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+// blobWriter writes into a pipe that is
+// read by an UploadStream goroutine
+type blobWriter struct {
+	*io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+// Close finishes the upload and returns its error
+func (w *blobWriter) Close() error {
+	err := w.PipeWriter.Close()
+	<-w.done
+	return errors.Join(err, w.err)
+}
+
+func deref[T any](ptr *T) (val T) {
+	if ptr != nil {
+		val = *ptr
+	}
+	return val
+}
@@ -0,0 +1,28 @@
+package azurefs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobName(t *testing.T) {
+	require.Equal(t, "", blobName("/"))
+	require.Equal(t, "file.txt", blobName("/file.txt"))
+	require.Equal(t, "dir/file.txt", blobName("/dir/file.txt"))
+
+	require.Equal(t, "", dirBlobPrefix("/"))
+	require.Equal(t, "dir/", dirBlobPrefix("/dir"))
+	require.Equal(t, "dir/sub/", dirBlobPrefix("/dir/sub/"))
+}
+
+func TestIsFolder(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	require.False(t, isFolder(nil))
+	require.False(t, isFolder(map[string]*string{"other": str("true")}))
+	require.False(t, isFolder(map[string]*string{folderMetadataKey: str("false")}))
+	require.False(t, isFolder(map[string]*string{folderMetadataKey: nil}))
+	require.True(t, isFolder(map[string]*string{folderMetadataKey: str("true")}))
+	require.True(t, isFolder(map[string]*string{"Hdi_isfolder": str("True")}), "keys are case insensitive")
+}
@@ -0,0 +1,41 @@
+package azurefs
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+// Make sure fileSystem implements fs.ContentTypeFileSystem
+var _ fs.ContentTypeFileSystem = new(fileSystem)
+
+// ContentType returns the Content-Type of a blob without downloading it.
+// An ErrUnsupported error is returned for blobs
+// that were uploaded without a Content-Type
+// and for paths without a blob.
+func (a *fileSystem) ContentType(ctx context.Context, filePath string) (string, error) {
+	if filePath == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if blobName(filePath) == "" {
+		return "", fs.NewErrIsDirectory(a.File(filePath))
+	}
+	props, err := a.blob(filePath).GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			// Could be a directory implied by the prefix of other blobs
+			return "", fs.NewErrUnsupported(a, "ContentType")
+		}
+		return "", a.wrapErrNotExist(filePath, err)
+	}
+	if isFolder(props.Metadata) {
+		return "", fs.NewErrIsDirectory(a.File(filePath))
+	}
+	contentType := deref(props.ContentType)
+	if contentType == "" {
+		return "", fs.NewErrUnsupported(a, "ContentType")
+	}
+	return contentType, nil
+}
@@ -0,0 +1,29 @@
+package azurefs
+
+import (
+	iofs "io/fs"
+	"time"
+)
+
+var _ iofs.FileInfo = new(fileInfo)
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (i *fileInfo) Name() string       { return i.name }    // base name of the file
+func (i *fileInfo) Size() int64        { return i.size }    // length in bytes for regular files; system-dependent for others
+func (i *fileInfo) ModTime() time.Time { return i.modTime } // modification time
+func (i *fileInfo) IsDir() bool        { return i.isDir }   // abbreviation for Mode().IsDir()
+func (i *fileInfo) Sys() any           { return nil }       // underlying data source (can return nil)
+
+// Mode returns the file mode bits
+func (i *fileInfo) Mode() iofs.FileMode {
+	if i.isDir {
+		return DefaultDirPermissions.FileMode(true)
+	}
+	return DefaultPermissions.FileMode(false)
+}
@@ -0,0 +1,25 @@
+module github.com/ungerik/go-fs/azurefs
+
+go 1.23
+
+replace github.com/ungerik/go-fs => ..
+
+require github.com/ungerik/go-fs v0.0.0-00010101000000-000000000000 // replaced
+
+// External
+require github.com/Azure/azure-sdk-for-go/sdk/storage/azblob v1.6.0
+
+require github.com/stretchr/testify v1.10.0
+
+require (
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.17.0 // indirect
+	github.com/Azure/azure-sdk-for-go/sdk/internal v1.10.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/fsnotify/fsnotify v1.8.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,178 @@
+package fs
+
+import (
+	"context"
+	iofs "io/fs"
+)
+
+// BoundFile is a file path bound to a concrete FileSystem instance.
+//
+// In contrast to File, which is resolved through the
+// global registry of file systems on every method call,
+// a BoundFile references its file system directly.
+// This saves the registry lookup in hot paths
+// and works with file systems that are not registered
+// or share a prefix with other file systems.
+type BoundFile struct {
+	fileSystem FileSystem
+	path       string
+}
+
+// NewBoundFile returns a BoundFile for filePath
+// within fileSystem, where filePath is a path
+// of the file system without its prefix.
+func NewBoundFile(fileSystem FileSystem, filePath string) BoundFile {
+	return BoundFile{fileSystem: fileSystem, path: filePath}
+}
+
+// Bind resolves the file system of the file once
+// and returns a BoundFile that is bound to it.
+func (file File) Bind() BoundFile {
+	fileSystem, path := file.ParseRawURI()
+	return BoundFile{fileSystem: fileSystem, path: path}
+}
+
+// FileSystem returns the file system the file is bound to.
+func (b BoundFile) FileSystem() FileSystem {
+	return b.fileSystem
+}
+
+// Path returns the path of the file within its file system.
+func (b BoundFile) Path() string {
+	return b.path
+}
+
+// File returns the file as File that will be resolved
+// through the registry of file systems.
+func (b BoundFile) File() File {
+	if b.fileSystem == nil || b.path == "" {
+		return ""
+	}
+	return b.fileSystem.JoinCleanFile(b.path)
+}
+
+// String returns the URL of the file.
+// String implements the fmt.Stringer interface.
+func (b BoundFile) String() string {
+	if b.fileSystem == nil {
+		return b.path
+	}
+	return b.fileSystem.URL(b.path)
+}
+
+// Name returns the name part of the file path.
+func (b BoundFile) Name() string {
+	_, name := b.fileSystem.SplitDirAndName(b.path)
+	return name
+}
+
+// Dir returns the parent directory of the file
+// bound to the same file system.
+func (b BoundFile) Dir() BoundFile {
+	dir, _ := b.fileSystem.SplitDirAndName(b.path)
+	return BoundFile{fileSystem: b.fileSystem, path: dir}
+}
+
+// Join returns a new BoundFile with pathParts
+// cleanly joined to the path of the file.
+func (b BoundFile) Join(pathParts ...string) BoundFile {
+	return BoundFile{
+		fileSystem: b.fileSystem,
+		path:       b.fileSystem.JoinCleanPath(append([]string{b.path}, pathParts...)...),
+	}
+}
+
+// Stat returns a standard library io/fs.FileInfo describing the file.
+func (b BoundFile) Stat() (iofs.FileInfo, error) {
+	if b.path == "" {
+		return nil, ErrEmptyPath
+	}
+	defer beginOp(b.fileSystem)()
+	return b.fileSystem.Stat(b.path)
+}
+
+// Exists returns a file or directory with the path exists.
+func (b BoundFile) Exists() bool {
+	if b.path == "" {
+		return false
+	}
+	defer beginOp(b.fileSystem)()
+	if fs, ok := b.fileSystem.(ExistsFileSystem); ok {
+		return fs.Exists(b.path)
+	}
+	_, err := b.fileSystem.Stat(b.path)
+	return err == nil
+}
+
+// IsDir returns a directory with the path exists.
+func (b BoundFile) IsDir() bool {
+	info, err := b.Stat()
+	return err == nil && info.IsDir()
+}
+
+// ListDirInfo calls the passed callback function for every file and directory in the directory.
+// If any patterns are passed, then only files with a name that matches
+// at least one of the patterns are returned.
+func (b BoundFile) ListDirInfo(ctx context.Context, callback func(*FileInfo) error, patterns ...string) error {
+	if b.path == "" {
+		return ErrEmptyPath
+	}
+	callback, endOp, err := beginListDirOp(ctx, b.fileSystem, hiddenFileInfoCallback(b.fileSystem, callback))
+	if err != nil {
+		return err
+	}
+	defer endOp()
+	return b.fileSystem.ListDirInfo(ctx, b.path, callback, patterns)
+}
+
+// OpenReader opens the file and returns a io/fs.File that has to be closed after reading
+func (b BoundFile) OpenReader() (ReadCloser, error) {
+	if b.path == "" {
+		return nil, ErrEmptyPath
+	}
+	defer beginOp(b.fileSystem)()
+	return b.fileSystem.OpenReader(b.path)
+}
+
+// OpenWriter opens the file for writing and returns a WriteCloser
+// that has to be closed after writing.
+// See File.OpenWriter.
+func (b BoundFile) OpenWriter(perm ...Permissions) (WriteCloser, error) {
+	if b.path == "" {
+		return nil, ErrEmptyPath
+	}
+	endOp := beginOp(b.fileSystem)
+	w, err := b.fileSystem.OpenWriter(b.path, perm)
+	endOp()
+	if err != nil {
+		return nil, err
+	}
+	return newFileWriter(w), nil
+}
+
+// ReadAll reads and returns all bytes of the file.
+// See File.ReadAllContext.
+func (b BoundFile) ReadAll(ctx context.Context) ([]byte, error) {
+	if b.path == "" {
+		return nil, ErrEmptyPath
+	}
+	return readAll(ctx, b.fileSystem, b.path, b.File())
+}
+
+// WriteAll writes data to the file.
+// See File.WriteAllContext.
+func (b BoundFile) WriteAll(ctx context.Context, data []byte, perm ...Permissions) error {
+	if b.path == "" {
+		return ErrEmptyPath
+	}
+	return writeAll(ctx, b.fileSystem, b.path, data, perm)
+}
+
+// Remove deletes the file.
+func (b BoundFile) Remove() error {
+	if b.path == "" {
+		return ErrEmptyPath
+	}
+	defer beginOp(b.fileSystem)()
+	return b.fileSystem.Remove(b.path)
+}
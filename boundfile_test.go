@@ -0,0 +1,58 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundFile(t *testing.T) {
+	ctx := context.Background()
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+
+	dir := memFS.RootDir().Bind()
+	require.Same(t, memFS, dir.FileSystem())
+	require.Equal(t, "/", dir.Path())
+
+	file := dir.Join("sub", "file.txt")
+	require.Equal(t, "/sub/file.txt", file.Path())
+	require.Equal(t, "file.txt", file.Name())
+	require.Equal(t, memFS.RootDir().Join("sub", "file.txt"), file.File())
+	require.Equal(t, "/sub", file.Dir().Path())
+
+	require.NoError(t, file.Dir().File().MakeDir())
+	require.NoError(t, file.WriteAll(ctx, []byte("content")))
+	require.True(t, file.Exists())
+	require.False(t, file.IsDir())
+	require.True(t, file.Dir().IsDir())
+	data, err := file.ReadAll(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "content", string(data))
+
+	var names []string
+	err = file.Dir().ListDirInfo(ctx, func(info *FileInfo) error {
+		names = append(names, info.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"file.txt"}, names)
+}
+
+func TestBoundFile_Unregistered(t *testing.T) {
+	ctx := context.Background()
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	file := NewBoundFile(memFS, "/file.txt")
+	require.NoError(t, file.WriteAll(ctx, []byte("content")))
+
+	// Check that the BoundFile doesn't use the registry
+	Unregister(memFS)
+	require.False(t, file.File().Exists(), "File can't be resolved through registry")
+	require.True(t, file.Exists(), "BoundFile still works")
+	data, err := file.ReadAll(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "content", string(data))
+}
@@ -0,0 +1,755 @@
+// Package cachefs implements a file system that caches
+// the file contents of a slow file system in a fast one.
+package cachefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of CacheFileSystem URIs
+	Prefix = "cache://"
+)
+
+var (
+	// Make sure CacheFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(CacheFileSystem)
+	_ fs.ReadAllFileSystem  = new(CacheFileSystem)
+	_ fs.WriteAllFileSystem = new(CacheFileSystem)
+	_ fs.AppendFileSystem   = new(CacheFileSystem)
+	_ fs.TouchFileSystem    = new(CacheFileSystem)
+	_ fs.TruncateFileSystem = new(CacheFileSystem)
+	_ fs.CopyFileSystem     = new(CacheFileSystem)
+	_ fs.RenameFileSystem   = new(CacheFileSystem)
+	_ fs.MoveFileSystem     = new(CacheFileSystem)
+	_ fs.ExistsFileSystem   = new(CacheFileSystem)
+)
+
+// CacheFileSystem is a read-through cache for the file contents
+// of a slow file system like S3, SFTP, or Dropbox
+// that stores cached files in a directory of a fast file system
+// like a MemFileSystem or a local temp directory.
+//
+// It is registered with the prefix "cache://" followed by a random ID
+// and uses the paths of the slow file system.
+//
+// ReadAll and OpenReader results are cached,
+// all other methods use the slow file system directly.
+// Cached files expire after the TTL passed to New
+// and the least recently used files are evicted
+// when the total size exceeds the limit set with SetMaxSize.
+// Writing, moving, or removing files through the CacheFileSystem
+// invalidates their cache entries, changes made directly
+// in the slow file system have to be invalidated with Invalidate.
+//
+// With SetStaleWhileRevalidate expired files are still
+// returned from the cache while they are revalidated in the background
+// and SetPathTTL configures different TTLs for directories.
+//
+// The cache index is kept in memory, so cached files
+// are not reused by other instances or processes.
+type CacheFileSystem struct {
+	prefix   string
+	slow     fs.FileSystem
+	cacheDir fs.File
+	ttl      time.Duration
+	closed   atomic.Bool
+
+	mtx       sync.Mutex
+	entries   map[string]*cacheEntry
+	totalSize int64
+	maxSize   int64
+	// gen is incremented with every invalidation
+	// so that data read before can't be cached afterwards
+	gen uint64
+	// pathTTLs maps path prefixes to their TTL
+	pathTTLs             map[string]time.Duration
+	staleWhileRevalidate atomic.Bool
+	revalidations        sync.WaitGroup
+}
+
+type cacheEntry struct {
+	file fs.File
+	info iofs.FileInfo // nil if only cached by ReadAll
+	size int64
+	// cached is the time the file was cached
+	cached time.Time
+	// used is the time the cache entry was used last
+	used time.Time
+	// revalidating is true while a stale entry
+	// is revalidated in the background
+	revalidating bool
+}
+
+// New returns a new CacheFileSystem for slow that stores cached files
+// in cacheDir and registers it.
+// Cached files expire after ttl, a ttl of zero means they never expire.
+// cacheDir is created if it does not exist and should not
+// be used for anything else because cached files are removed.
+func New(slow fs.FileSystem, cacheDir fs.File, ttl time.Duration) (*CacheFileSystem, error) {
+	if slow == nil {
+		return nil, fmt.Errorf("nil slow file system")
+	}
+	if cacheDir == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	err := cacheDir.MakeAllDirs()
+	if err != nil {
+		return nil, err
+	}
+	f := &CacheFileSystem{
+		prefix:   Prefix + fsimpl.RandomString(),
+		slow:     slow,
+		cacheDir: cacheDir,
+		ttl:      ttl,
+		entries:  make(map[string]*cacheEntry),
+	}
+	_, err = fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Slow returns the file system whose files are cached
+func (f *CacheFileSystem) Slow() fs.FileSystem {
+	return f.slow
+}
+
+// CacheDir returns the directory where cached files are stored
+func (f *CacheFileSystem) CacheDir() fs.File {
+	return f.cacheDir
+}
+
+// SetMaxSize sets the maximum total size in bytes of all cached files.
+// Files larger than maxBytes are not cached.
+// Zero or a negative value means no limit, which is the default.
+func (f *CacheFileSystem) SetMaxSize(maxBytes int64) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.maxSize = maxBytes
+	f.evict(time.Now())
+}
+
+// CachedSize returns the total size in bytes of all cached files
+func (f *CacheFileSystem) CachedSize() int64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return f.totalSize
+}
+
+// SetStaleWhileRevalidate sets if expired cached files
+// are still returned while they are revalidated
+// in the background to serve files without waiting
+// for the slow file system, for example for web assets.
+//
+// The revalidation compares the size and modification time
+// of the slow file with the cached ones as change token
+// and only reads the file again if they differ.
+// Expired files are not removed from the cache
+// but still evicted to stay within the limit of SetMaxSize.
+func (f *CacheFileSystem) SetStaleWhileRevalidate(enabled bool) {
+	f.staleWhileRevalidate.Store(enabled)
+}
+
+// SetPathTTL sets the TTL for cached files at or below
+// the file or directory path, overriding the TTL passed to New.
+// The TTL of the longest matching path is used
+// and a ttl of zero means that files never expire.
+func (f *CacheFileSystem) SetPathTTL(path string, ttl time.Duration) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.pathTTLs == nil {
+		f.pathTTLs = make(map[string]time.Duration)
+	}
+	f.pathTTLs[strings.TrimSuffix(path, f.slow.Separator())] = ttl
+	f.evict(time.Now())
+}
+
+// ttlFor returns the TTL for filePath, mtx must be locked
+func (f *CacheFileSystem) ttlFor(filePath string) time.Duration {
+	ttl, longest := f.ttl, -1
+	for p, pathTTL := range f.pathTTLs {
+		if len(p) > longest && (filePath == p || strings.HasPrefix(filePath, p+f.slow.Separator())) {
+			ttl, longest = pathTTL, len(p)
+		}
+	}
+	return ttl
+}
+
+// IsCached returns if the contents of the file at filePath
+// are cached and not expired.
+func (f *CacheFileSystem) IsCached(filePath string) bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	entry, ok := f.entries[filePath]
+	return ok && !f.expired(filePath, entry, time.Now())
+}
+
+// Invalidate removes the cached files of filePath
+// and all files below it if it is a directory.
+// Use it for files that were changed directly in the slow file system.
+func (f *CacheFileSystem) Invalidate(filePath string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.gen++
+	f.remove(filePath)
+	dirPrefix := strings.TrimSuffix(filePath, f.slow.Separator()) + f.slow.Separator()
+	for p := range f.entries {
+		if strings.HasPrefix(p, dirPrefix) {
+			f.remove(p)
+		}
+	}
+}
+
+// Clear removes all cached files
+func (f *CacheFileSystem) Clear() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.gen++
+	for p := range f.entries {
+		f.remove(p)
+	}
+}
+
+// expired returns if the entry for filePath is expired, mtx must be locked
+func (f *CacheFileSystem) expired(filePath string, entry *cacheEntry, now time.Time) bool {
+	ttl := f.ttlFor(filePath)
+	return ttl > 0 && now.Sub(entry.cached) > ttl
+}
+
+// remove removes the cache entry for filePath, mtx must be locked
+func (f *CacheFileSystem) remove(filePath string) {
+	entry, ok := f.entries[filePath]
+	if !ok {
+		return
+	}
+	delete(f.entries, filePath)
+	f.totalSize -= entry.size
+	_ = entry.file.Remove()
+}
+
+// evict removes expired entries if not in stale-while-revalidate mode
+// and then the least recently used ones
+// until the total size is within the limit, mtx must be locked
+func (f *CacheFileSystem) evict(now time.Time) {
+	for p, entry := range f.entries {
+		if !f.staleWhileRevalidate.Load() && f.expired(p, entry, now) {
+			f.remove(p)
+		}
+	}
+	for f.maxSize > 0 && f.totalSize > f.maxSize {
+		var oldestPath string
+		var oldest *cacheEntry
+		for p, entry := range f.entries {
+			if oldest == nil || entry.used.Before(oldest.used) {
+				oldestPath, oldest = p, entry
+			}
+		}
+		f.remove(oldestPath)
+	}
+}
+
+// get returns the cache entry for filePath
+// or nil if it is not cached or expired.
+// In stale-while-revalidate mode expired entries
+// are returned and revalidated in the background.
+func (f *CacheFileSystem) get(filePath string) *cacheEntry {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	entry, ok := f.entries[filePath]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if f.expired(filePath, entry, now) {
+		if !f.staleWhileRevalidate.Load() {
+			f.remove(filePath)
+			return nil
+		}
+		if !entry.revalidating && !f.closed.Load() {
+			entry.revalidating = true
+			f.revalidations.Add(1)
+			go f.revalidate(filePath, entry, f.gen)
+		}
+	}
+	entry.used = now
+	return entry
+}
+
+// revalidate refreshes the stale entry for filePath
+// if the slow file was not changed,
+// else the changed file is read and cached.
+func (f *CacheFileSystem) revalidate(filePath string, entry *cacheEntry, gen uint64) {
+	defer f.revalidations.Done()
+
+	ctx := context.Background()
+	info, err := f.slow.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		f.mtx.Lock()
+		if f.entries[filePath] == entry {
+			f.remove(filePath)
+		}
+		f.mtx.Unlock()
+		return
+	}
+	if err == nil && entry.info != nil && info.Size() == entry.info.Size() && info.ModTime().Equal(entry.info.ModTime()) {
+		f.mtx.Lock()
+		if f.entries[filePath] == entry {
+			entry.cached = time.Now()
+			entry.revalidating = false
+		}
+		f.mtx.Unlock()
+		return
+	}
+	if err == nil {
+		var data []byte
+		data, err = f.slowFile(filePath).ReadAllContext(ctx)
+		if err == nil {
+			err = f.put(ctx, filePath, data, info, gen)
+		}
+	}
+	if err != nil {
+		// Keep serving the stale entry
+		// and revalidate again with the next use
+		f.mtx.Lock()
+		entry.revalidating = false
+		f.mtx.Unlock()
+	}
+}
+
+// currentGen returns the invalidation generation
+// that has to be passed to put for data read afterwards.
+func (f *CacheFileSystem) currentGen() uint64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return f.gen
+}
+
+// put caches data for filePath if there was no invalidation since gen
+// and the data is not larger than the maximum cache size.
+func (f *CacheFileSystem) put(ctx context.Context, filePath string, data []byte, info iofs.FileInfo, gen uint64) error {
+	f.mtx.Lock()
+	tooLarge := f.maxSize > 0 && int64(len(data)) > f.maxSize
+	f.mtx.Unlock()
+	if tooLarge {
+		return nil
+	}
+
+	file := f.cacheDir.Join(fsimpl.RandomString())
+	err := file.WriteAllContext(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.gen != gen || f.closed.Load() {
+		_ = file.Remove()
+		return nil
+	}
+	f.remove(filePath)
+	now := time.Now()
+	f.entries[filePath] = &cacheEntry{
+		file:   file,
+		info:   info,
+		size:   int64(len(data)),
+		cached: now,
+		used:   now,
+	}
+	f.totalSize += int64(len(data))
+	f.evict(now)
+	return nil
+}
+
+// slowFile returns the file of the slow file system for filePath
+func (f *CacheFileSystem) slowFile(filePath string) fs.File {
+	return fs.File(f.slow.URL(filePath))
+}
+
+func (f *CacheFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *CacheFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.slow.ReadableWritable()
+}
+
+func (f *CacheFileSystem) RootDir() fs.File {
+	return f.JoinCleanFile(f.slow.Separator())
+}
+
+func (f *CacheFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *CacheFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *CacheFileSystem) Name() string {
+	return "cached " + f.slow.Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *CacheFileSystem) String() string {
+	return fmt.Sprintf("%s with prefix %s cached in %s", f.Name(), f.prefix, f.cacheDir)
+}
+
+func (f *CacheFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *CacheFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *CacheFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *CacheFileSystem) JoinCleanPath(uriParts ...string) string {
+	return f.slow.JoinCleanPath(uriParts...)
+}
+
+func (f *CacheFileSystem) SplitPath(filePath string) []string {
+	return f.slow.SplitPath(filePath)
+}
+
+func (f *CacheFileSystem) Separator() string {
+	return f.slow.Separator()
+}
+
+func (f *CacheFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return f.slow.MatchAnyPattern(name, patterns)
+}
+
+func (f *CacheFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return f.slow.SplitDirAndName(filePath)
+}
+
+func (f *CacheFileSystem) IsAbsPath(filePath string) bool {
+	return f.slow.IsAbsPath(filePath)
+}
+
+func (f *CacheFileSystem) AbsPath(filePath string) string {
+	return f.slow.AbsPath(filePath)
+}
+
+func (f *CacheFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.slow.Stat(filePath)
+}
+
+func (f *CacheFileSystem) Exists(filePath string) bool {
+	return filePath != "" && f.checkOpen() == nil && f.slowFile(filePath).Exists()
+}
+
+func (f *CacheFileSystem) IsHidden(filePath string) bool {
+	return f.slow.IsHidden(filePath)
+}
+
+func (f *CacheFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.slow.IsSymbolicLink(filePath)
+}
+
+func (f *CacheFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.slow.ListDirInfo(ctx, dirPath, func(slowInfo *fs.FileInfo) error {
+		info := *slowInfo
+		info.File = f.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns)
+}
+
+func (f *CacheFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.slow.MakeDir(dirPath, perm)
+}
+
+// ReadAll returns the cached file contents
+// or reads and caches them from the slow file system.
+func (f *CacheFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	if entry := f.get(filePath); entry != nil {
+		data, err := entry.file.ReadAllContext(ctx)
+		if err == nil {
+			return data, nil
+		}
+		// Cached file is gone, read from the slow file system
+		f.Invalidate(filePath)
+	}
+	gen := f.currentGen()
+	var info iofs.FileInfo
+	if f.staleWhileRevalidate.Load() {
+		// The FileInfo is the change token for revalidations
+		var err error
+		info, err = f.slow.Stat(filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := f.slowFile(filePath).ReadAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data, f.put(ctx, filePath, data, info, gen)
+}
+
+// OpenReader returns a reader for the cached file
+// or reads and caches it from the slow file system.
+func (f *CacheFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	if entry := f.get(filePath); entry != nil {
+		info := entry.info
+		if info == nil {
+			// Only cached by ReadAll
+			var err error
+			info, err = f.slow.Stat(filePath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		reader, err := entry.file.OpenReader()
+		if err == nil {
+			return &cachedFileReader{ReadCloser: reader, info: info}, nil
+		}
+		// Cached file is gone, read from the slow file system
+		f.Invalidate(filePath)
+	}
+	gen := f.currentGen()
+	info, err := f.slow.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.NewErrIsDirectory(f.JoinCleanFile(filePath))
+	}
+	data, err := f.slowFile(filePath).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	err = f.put(context.Background(), filePath, data, info, gen)
+	if err != nil {
+		return nil, err
+	}
+	return fsimpl.NewReadonlyFileBuffer(data, info), nil
+}
+
+func (f *CacheFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(filePath)
+	return f.slowFile(filePath).WriteAllContext(ctx, data, perm...)
+}
+
+func (f *CacheFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(filePath)
+	return f.slowFile(filePath).Append(ctx, data, perm...)
+}
+
+func (f *CacheFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(filePath)
+	return f.slowFile(filePath).Touch(perm...)
+}
+
+func (f *CacheFileSystem) Truncate(filePath string, size int64) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(filePath)
+	return f.slowFile(filePath).Truncate(size)
+}
+
+func (f *CacheFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	f.Invalidate(filePath)
+	writer, err := f.slow.OpenWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &invalidatingWriter{WriteCloser: writer, invalidate: func() { f.Invalidate(filePath) }}, nil
+}
+
+func (f *CacheFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	f.Invalidate(filePath)
+	readWriter, err := f.slow.OpenReadWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &invalidatingReadWriter{ReadWriteSeekCloser: readWriter, invalidate: func() { f.Invalidate(filePath) }}, nil
+}
+
+func (f *CacheFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(destFile)
+	return fs.CopyFileBuf(ctx, f.slowFile(srcFile), f.slowFile(destFile), buf)
+}
+
+func (f *CacheFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return "", err
+	}
+	defer f.Invalidate(filePath)
+	renamed, err := f.slowFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	newPath := renamed.Path()
+	f.Invalidate(newPath)
+	return newPath, nil
+}
+
+func (f *CacheFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(destPath)
+	defer f.Invalidate(filePath)
+	return f.slowFile(filePath).MoveTo(f.slowFile(destPath))
+}
+
+func (f *CacheFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	defer f.Invalidate(filePath)
+	return f.slow.Remove(filePath)
+}
+
+// Close removes all cached files and unregisters the file system,
+// the slow file system is not closed.
+func (f *CacheFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	f.revalidations.Wait()
+	f.Clear()
+	fs.Unregister(f)
+	return nil
+}
+
+// cachedFileReader reads a cached file
+// with the FileInfo of the slow file system
+type cachedFileReader struct {
+	fs.ReadCloser
+	info iofs.FileInfo
+}
+
+func (r *cachedFileReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+// invalidatingWriter invalidates the cache after the file was written
+type invalidatingWriter struct {
+	fs.WriteCloser
+	invalidate func()
+}
+
+func (w *invalidatingWriter) Close() error {
+	defer w.invalidate()
+	return w.WriteCloser.Close()
+}
+
+// invalidatingReadWriter invalidates the cache after the file was written
+type invalidatingReadWriter struct {
+	fs.ReadWriteSeekCloser
+	invalidate func()
+}
+
+func (w *invalidatingReadWriter) Close() error {
+	defer w.invalidate()
+	return w.ReadWriteSeekCloser.Close()
+}
@@ -0,0 +1,213 @@
+package cachefs
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestCacheFileSystem(t *testing.T) {
+	tempDir := fs.File(t.TempDir())
+	cacheDir := tempDir.Join("cache")
+	cacheFS, err := New(fs.Local, cacheDir, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cacheFS.Close() })
+	slowDir := tempDir.Join("slow")
+	require.NoError(t, slowDir.MakeDir())
+	ctx := context.Background()
+	root := cacheFS.JoinCleanFile(slowDir.LocalPath())
+	require.True(t, fs.IsRegistered(cacheFS))
+	require.True(t, cacheDir.IsDir())
+	require.True(t, root.IsDir())
+
+	require.NoError(t, root.Join("dir").MakeDir())
+	file := root.Join("dir", "file.txt")
+	filePath := slowDir.Join("dir", "file.txt").LocalPath()
+	require.Equal(t, filePath, file.Path())
+	require.NoError(t, file.WriteAllString("Hello"))
+	require.False(t, cacheFS.IsCached(filePath))
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+	require.True(t, cacheFS.IsCached(filePath))
+	require.Equal(t, int64(5), cacheFS.CachedSize())
+
+	// Changes in the slow file system are not visible until invalidated
+	require.NoError(t, slowDir.Join("dir", "file.txt").WriteAllString("Changed"))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+	reader, err := file.OpenReader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "Hello", string(data))
+	info, err := reader.Stat()
+	require.NoError(t, err)
+	require.Equal(t, "file.txt", info.Name())
+	require.NoError(t, reader.Close())
+	cacheFS.Invalidate(slowDir.Join("dir").LocalPath())
+	require.False(t, cacheFS.IsCached(filePath))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Changed", str)
+
+	// Writes invalidate the cache
+	require.NoError(t, file.AppendString(ctx, " Again"))
+	require.False(t, cacheFS.IsCached(filePath))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Changed Again", str)
+	writer, err := file.OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("Written"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Written", str)
+
+	renamed, err := file.Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, root.Join("dir", "renamed.txt"), renamed)
+	require.False(t, cacheFS.IsCached(filePath))
+	_, err = file.ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+	str, err = renamed.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Written", str)
+	require.NoError(t, renamed.Remove())
+	require.False(t, renamed.Exists())
+	require.Zero(t, cacheFS.CachedSize())
+
+	files, err := root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []fs.File{root.Join("dir")}, files)
+
+	require.NoError(t, cacheFS.Close())
+	files, err = cacheDir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Empty(t, files, "cached files removed")
+}
+
+func TestCacheFileSystem_Eviction(t *testing.T) {
+	tempDir := fs.File(t.TempDir())
+	cacheDir := tempDir.Join("cache")
+	cacheFS, err := New(fs.Local, cacheDir, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cacheFS.Close() })
+	slowDir := tempDir.Join("slow")
+	require.NoError(t, slowDir.MakeDir())
+	root := cacheFS.JoinCleanFile(slowDir.LocalPath())
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		require.NoError(t, root.Join(name).WriteAllString("12345"))
+	}
+
+	cacheFS.SetMaxSize(10)
+	str, err := root.Join("a.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "12345", str)
+	str, err = root.Join("b.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "12345", str)
+	str, err = root.Join("a.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "12345", str, "a.txt used after b.txt")
+	str, err = root.Join("c.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "12345", str)
+	require.True(t, cacheFS.IsCached(root.Join("a.txt").Path()))
+	require.False(t, cacheFS.IsCached(root.Join("b.txt").Path()), "least recently used evicted")
+	require.True(t, cacheFS.IsCached(root.Join("c.txt").Path()))
+	require.Equal(t, int64(10), cacheFS.CachedSize())
+
+	require.NoError(t, root.Join("large.txt").WriteAllString("0123456789X"))
+	str, err = root.Join("large.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "0123456789X", str)
+	require.False(t, cacheFS.IsCached(root.Join("large.txt").Path()), "larger than max size")
+
+	// Expired entries are not used
+	cacheFS.ttl = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	require.False(t, cacheFS.IsCached(root.Join("a.txt").Path()))
+	cacheFS.Clear()
+	require.Zero(t, cacheFS.CachedSize())
+}
+
+func TestCacheFileSystem_StaleWhileRevalidate(t *testing.T) {
+	tempDir := fs.File(t.TempDir())
+	cacheDir := tempDir.Join("cache")
+	cacheFS, err := New(fs.Local, cacheDir, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cacheFS.Close() })
+	slowDir := tempDir.Join("slow")
+	require.NoError(t, slowDir.MakeDir())
+	root := cacheFS.JoinCleanFile(slowDir.LocalPath())
+	require.NoError(t, root.Join("assets").MakeDir())
+	asset := root.Join("assets", "app.js")
+	other := root.Join("other.txt")
+	require.NoError(t, asset.WriteAllString("v1"))
+	require.NoError(t, other.WriteAllString("v1"))
+
+	cacheFS.SetStaleWhileRevalidate(true)
+	cacheFS.SetPathTTL(root.Join("assets").Path(), time.Nanosecond)
+	str, err := asset.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "v1", str)
+	str, err = other.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "v1", str)
+	time.Sleep(time.Millisecond)
+	require.False(t, cacheFS.IsCached(asset.Path()), "expired by path TTL")
+	require.True(t, cacheFS.IsCached(other.Path()), "default TTL")
+
+	// Unchanged files are revalidated without reading them again
+	cachedFile := cacheFS.entries[asset.Path()].file
+	str, err = asset.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "v1", str)
+	cacheFS.revalidations.Wait()
+	require.Equal(t, cachedFile, cacheFS.entries[asset.Path()].file)
+
+	// Changed files are served stale until revalidated
+	slowAsset := slowDir.Join("assets", "app.js")
+	require.NoError(t, slowAsset.WriteAllString("v2"))
+	modified := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(slowAsset.LocalPath(), modified, modified))
+	time.Sleep(time.Millisecond)
+	str, err = asset.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "v1", str, "stale")
+	cacheFS.revalidations.Wait()
+	reader, err := asset.OpenReader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(data))
+	require.NoError(t, reader.Close())
+	cacheFS.revalidations.Wait()
+
+	// Removed files are removed from the cache
+	require.NoError(t, slowAsset.Remove())
+	time.Sleep(time.Millisecond)
+	str, err = asset.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "v2", str, "stale")
+	cacheFS.revalidations.Wait()
+	require.NotContains(t, cacheFS.entries, asset.Path())
+	_, err = asset.ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Files with the default TTL are not revalidated
+	require.NoError(t, slowDir.Join("other.txt").WriteAllString("v2"))
+	str, err = other.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "v1", str)
+}
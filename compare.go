@@ -80,6 +80,8 @@ func IdenticalFileContents(ctx context.Context, files ...FileReader) (identical
 
 // IdenticalDirContents returns true if the files in dirA and dirB are identical in size and content.
 // If recursive is true, then directories will be considered too.
+// File names are matched after normalization with NormalizeName
+// if enabled with SetNormalizeNames.
 func IdenticalDirContents(ctx context.Context, dirA, dirB File, recursive bool) (identical bool, err error) {
 	if SameFile(dirA, dirB) {
 		return true, nil
@@ -88,7 +90,7 @@ func IdenticalDirContents(ctx context.Context, dirA, dirB File, recursive bool)
 	fileInfosA := make(map[string]*FileInfo)
 	err = dirA.ListDirInfoContext(ctx, func(info *FileInfo) error {
 		if !info.IsDir || recursive {
-			fileInfosA[info.Name] = info
+			fileInfosA[matchName(info.Name)] = info
 		}
 		return nil
 	})
@@ -100,11 +102,12 @@ func IdenticalDirContents(ctx context.Context, dirA, dirB File, recursive bool)
 	hasDiff := errors.New("hasDiff")
 	err = dirB.ListDirInfoContext(ctx, func(info *FileInfo) error {
 		if !info.IsDir || recursive {
-			infoA, found := fileInfosA[info.Name]
+			name := matchName(info.Name)
+			infoA, found := fileInfosA[name]
 			if !found || info.Size != infoA.Size || info.IsDir != infoA.IsDir {
 				return hasDiff
 			}
-			fileInfosB[info.Name] = info
+			fileInfosB[name] = info
 		}
 		return nil
 	})
@@ -115,20 +118,24 @@ func IdenticalDirContents(ctx context.Context, dirA, dirB File, recursive bool)
 		return false, fmt.Errorf("IdenticalDirContents: error listing dirB %q: %w", dirB, err)
 	}
 
-	for filename, infoA := range fileInfosA {
+	for name, infoA := range fileInfosA {
+		// Use the listed names because they
+		// can differ in their Unicode normalization
+		fileA := dirA.Join(infoA.Name)
+		fileB := dirB.Join(fileInfosB[name].Name)
 		if recursive && infoA.IsDir {
-			identical, err = IdenticalDirContents(ctx, dirA.Join(filename), dirB.Join(filename), true)
+			identical, err = IdenticalDirContents(ctx, fileA, fileB, true)
 			if !identical {
 				return false, err
 			}
 		} else {
-			hashA, err := dirA.Join(filename).ContentHash()
+			hashA, err := fileA.ContentHash()
 			if err != nil {
-				return false, fmt.Errorf("IdenticalDirContents: error content hashing %q: %w", filename, err)
+				return false, fmt.Errorf("IdenticalDirContents: error content hashing %q: %w", infoA.Name, err)
 			}
-			hashB, err := dirB.Join(filename).ContentHash()
+			hashB, err := fileB.ContentHash()
 			if err != nil {
-				return false, fmt.Errorf("IdenticalDirContents: error content hashing %q: %w", filename, err)
+				return false, fmt.Errorf("IdenticalDirContents: error content hashing %q: %w", infoA.Name, err)
 			}
 			if hashA != hashB {
 				return false, nil
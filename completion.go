@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// CompletePath returns completion candidates for a partially typed
+// file path or URI of any registered file system,
+// for example to implement auto-completion for a CLI or REPL.
+//
+// The parent directory of partial is listed and every
+// file whose name starts with the last path element of partial
+// is returned as partial completed with the file name.
+// Candidates for directories end with the separator
+// of the file system, so that completing them again
+// lists the content of the directory.
+// Hidden files are only returned if the last path element
+// of partial starts with a dot.
+//
+// The candidates are sorted and nil is returned
+// if the parent directory can't be listed.
+func CompletePath(ctx context.Context, partial string) []string {
+	fileSystem, _ := ParseRawURI(partial)
+	sep := fileSystem.Separator()
+
+	// Split partial at the last separator into the raw
+	// directory part that is kept as typed and the name prefix.
+	// The file system prefix like "s3://" is never split.
+	var dirPart, namePrefix string
+	fsPrefix := fileSystem.Prefix()
+	if !strings.HasPrefix(partial, fsPrefix) {
+		fsPrefix = ""
+	}
+	if i := strings.LastIndexAny(partial[len(fsPrefix):], "/"+sep); i >= 0 {
+		i += len(fsPrefix)
+		dirPart, namePrefix = partial[:i+1], partial[i+1:]
+	} else {
+		dirPart, namePrefix = fsPrefix, partial[len(fsPrefix):]
+		if dirPart != "" && !strings.HasSuffix(dirPart, "/") && !strings.HasSuffix(dirPart, sep) {
+			// Prefix without trailing separator like "mem://id"
+			dirPart += sep
+		}
+	}
+	dir := File(dirPart)
+	if dirPart == "" {
+		dir = "."
+	}
+	showHidden := strings.HasPrefix(namePrefix, ".")
+
+	var candidates []string
+	err := dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if !strings.HasPrefix(info.Name, namePrefix) {
+			return nil
+		}
+		if !showHidden && (info.IsHidden || strings.HasPrefix(info.Name, ".")) {
+			return nil
+		}
+		candidate := dirPart + info.Name
+		if info.IsDir {
+			candidate += sep
+		}
+		candidates = append(candidates, candidate)
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+	slices.Sort(candidates)
+	return candidates
+}
@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletePath(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("docs").MakeDir())
+	require.NoError(t, dir.Join("docs", "readme.md").Touch())
+	require.NoError(t, dir.Join("download.txt").Touch())
+	require.NoError(t, dir.Join("other.txt").Touch())
+	require.NoError(t, dir.Join(".hidden").Touch())
+
+	base := dir.LocalPath() + "/"
+	require.Equal(t, []string{base + "docs/", base + "download.txt"}, CompletePath(ctx, base+"do"))
+	require.Equal(t, []string{base + "docs/"}, CompletePath(ctx, base+"doc"))
+	require.Equal(t, []string{base + "docs/readme.md"}, CompletePath(ctx, base+"docs/"))
+	require.Equal(t, []string{base + "docs/", base + "download.txt", base + "other.txt"}, CompletePath(ctx, base))
+	require.Equal(t, []string{base + ".hidden"}, CompletePath(ctx, base+"."))
+	require.Empty(t, CompletePath(ctx, base+"x"))
+	require.Nil(t, CompletePath(ctx, base+"missing/"))
+
+	url := dir.URL() + "/"
+	require.Equal(t, []string{url + "docs/", url + "download.txt"}, CompletePath(ctx, url+"do"))
+}
+
+func TestCompletePath_MemFileSystem(t *testing.T) {
+	ctx := context.Background()
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+
+	root := memFS.RootDir()
+	require.NoError(t, root.Join("dir").MakeDir())
+	require.NoError(t, root.Join("dir", "a.txt").WriteAllString("a"))
+	require.NoError(t, root.Join("data.txt").WriteAllString("data"))
+
+	rootURI := string(root)
+	require.Equal(t, []string{rootURI + "data.txt", rootURI + "dir/"}, CompletePath(ctx, rootURI+"d"))
+	require.Equal(t, []string{rootURI + "dir/a.txt"}, CompletePath(ctx, rootURI+"dir/"))
+	require.Equal(t, []string{rootURI + "data.txt", rootURI + "dir/"}, CompletePath(ctx, memFS.Prefix()))
+}
@@ -0,0 +1,545 @@
+// Package compressfs implements a file system that transparently
+// compresses file contents stored in a directory of any other file system.
+package compressfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of CompressFileSystem URIs
+	Prefix = "compress://"
+
+	// Separator used in CompressFileSystem paths
+	Separator = "/"
+)
+
+var (
+	// Make sure CompressFileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(CompressFileSystem)
+)
+
+// Options for a CompressFileSystem
+type Options struct {
+	// Compression format, Gzip if nil
+	Compression *Compression
+	// Level of the compression, zero selects the default level
+	Level int
+	// Patterns for the names of files that are compressed,
+	// all files are compressed if empty
+	Patterns []string
+}
+
+// CompressFileSystem compresses the contents of files
+// stored in a base directory of any file system.
+//
+// It is registered with the prefix "compress://" followed by a random ID,
+// so the base directory is accessed as "compress://<id>/".
+//
+// Only files with names matching Options.Patterns are compressed,
+// all other files are stored unchanged.
+// Renaming or moving a file so that it starts or stops
+// matching the patterns re-encodes its data.
+// Files are stored without changing their names.
+//
+// Empty stored files are read as empty files,
+// and Append adds a new compressed stream to the end of a file,
+// so the Compression has to read concatenated streams as one.
+//
+// File sizes and reader FileInfo report the size of the stored data,
+// because the uncompressed size is only known after decompressing.
+type CompressFileSystem struct {
+	prefix  string
+	base    fs.File
+	options Options
+	closed  atomic.Bool
+}
+
+// NewAndRegister returns a new CompressFileSystem that stores compressed files
+// in the directory baseDir and registers it.
+// Use the RootDir of a file system as baseDir to compress all its files.
+func NewAndRegister(baseDir fs.File, options Options) (*CompressFileSystem, error) {
+	if baseDir == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if options.Compression == nil {
+		options.Compression = Gzip
+	}
+	// Validate patterns and level before the file system is used
+	_, err := fsimpl.MatchAnyPattern("", options.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	w, err := options.Compression.newWriter(io.Discard, options.Level)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	f := &CompressFileSystem{
+		prefix:  Prefix + fsimpl.RandomString(),
+		base:    baseDir,
+		options: options,
+	}
+	_, err = fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// BaseDir returns the directory where the compressed files are stored
+func (f *CompressFileSystem) BaseDir() fs.File {
+	return f.base
+}
+
+// Compression returns the format used to compress files
+func (f *CompressFileSystem) Compression() *Compression {
+	return f.options.Compression
+}
+
+// IsCompressed returns if the file at filePath
+// is stored compressed because its name matches the patterns.
+func (f *CompressFileSystem) IsCompressed(filePath string) bool {
+	if len(f.options.Patterns) == 0 {
+		return true
+	}
+	_, name := f.SplitDirAndName(filePath)
+	match, _ := fsimpl.MatchAnyPattern(name, f.options.Patterns)
+	return match
+}
+
+func (f *CompressFileSystem) compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := f.options.Compression.newWriter(&buf, f.options.Level)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *CompressFileSystem) decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+	r, err := f.options.Compression.newReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
+
+// baseFile returns the file in the base directory for filePath
+func (f *CompressFileSystem) baseFile(filePath string) fs.File {
+	return f.base.Join(f.SplitPath(filePath)...)
+}
+
+// fileInfo returns a copy of the FileInfo of a base file for filePath
+func (f *CompressFileSystem) fileInfo(filePath string, baseInfo *fs.FileInfo) *fs.FileInfo {
+	info := *baseInfo
+	info.File = f.File(filePath)
+	return &info
+}
+
+func (f *CompressFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *CompressFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.base.FileSystem().ReadableWritable()
+}
+
+func (f *CompressFileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+func (f *CompressFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *CompressFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *CompressFileSystem) Name() string {
+	return "compressed file system"
+}
+
+// String implements the fmt.Stringer interface.
+func (f *CompressFileSystem) String() string {
+	return fmt.Sprintf("%s %s with prefix %s in %s", f.options.Compression, f.Name(), f.prefix, f.base)
+}
+
+func (f *CompressFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *CompressFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *CompressFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *CompressFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *CompressFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *CompressFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*CompressFileSystem) Separator() string {
+	return Separator
+}
+
+func (*CompressFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*CompressFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (*CompressFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (*CompressFileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *CompressFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	baseFile := f.baseFile(filePath)
+	stat, err := baseFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	baseInfo := fs.NewFileInfo(baseFile, stat, baseFile.IsHidden())
+	return f.fileInfo(filePath, baseInfo).StdFileInfo(), nil
+}
+
+func (f *CompressFileSystem) Exists(filePath string) bool {
+	return filePath != "" && f.checkOpen() == nil && f.baseFile(filePath).Exists()
+}
+
+func (f *CompressFileSystem) IsHidden(filePath string) bool {
+	return f.baseFile(filePath).IsHidden()
+}
+
+func (f *CompressFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.baseFile(filePath).IsSymbolicLink()
+}
+
+func (f *CompressFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(dirPath).ListDirInfoContext(ctx, func(info *fs.FileInfo) error {
+		return callback(f.fileInfo(path.Join(dirPath, info.Name), info))
+	}, patterns...)
+}
+
+func (f *CompressFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(dirPath).MakeDir(perm...)
+}
+
+// Touch sets the modified time of an existing file
+// or creates an empty file.
+func (f *CompressFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).Touch(perm...)
+}
+
+func (f *CompressFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	data, err := f.baseFile(filePath).ReadAllContext(ctx)
+	if err != nil || !f.IsCompressed(filePath) {
+		return data, err
+	}
+	plain, err := f.decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("can't decompress %s: %w", f.File(filePath), err)
+	}
+	return plain, nil
+}
+
+func (f *CompressFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	if f.IsCompressed(filePath) {
+		compressed, err := f.compress(data)
+		if err != nil {
+			return err
+		}
+		data = compressed
+	}
+	return f.baseFile(filePath).WriteAllContext(ctx, data, perm...)
+}
+
+// Append adds data as a new compressed stream to the end of the file.
+func (f *CompressFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	if f.IsCompressed(filePath) && len(data) > 0 {
+		compressed, err := f.compress(data)
+		if err != nil {
+			return err
+		}
+		data = compressed
+	}
+	return f.baseFile(filePath).Append(ctx, data, perm...)
+}
+
+// OpenReader returns a reader that decompresses the stored file
+// while reading from it.
+func (f *CompressFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	info, err := f.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	baseReader, err := f.baseFile(filePath).OpenReader()
+	if err != nil || !f.IsCompressed(filePath) || info.Size() == 0 {
+		return baseReader, err
+	}
+	reader, err := f.options.Compression.newReader(baseReader)
+	if err != nil {
+		return nil, errors.Join(err, baseReader.Close())
+	}
+	return &fileReader{ReadCloser: reader, base: baseReader, info: info}, nil
+}
+
+// OpenWriter returns a writer that compresses the written data
+// while writing to the stored file.
+func (f *CompressFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	baseWriter, err := f.baseFile(filePath).OpenWriter(perm...)
+	if err != nil || !f.IsCompressed(filePath) {
+		return baseWriter, err
+	}
+	writer, err := f.options.Compression.newWriter(baseWriter, f.options.Level)
+	if err != nil {
+		return nil, errors.Join(err, baseWriter.Close())
+	}
+	return &fileWriter{WriteCloser: writer, base: baseWriter}, nil
+}
+
+// OpenReadWriter buffers the decompressed file in memory
+// and compresses and writes it when closed.
+func (f *CompressFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	data, err := f.ReadAll(context.Background(), filePath)
+	if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(data, func() error {
+		return f.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// CopyFile copies the stored data without decompressing it
+// if source and destination use the same encoding.
+func (f *CompressFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	if f.IsCompressed(srcFile) == f.IsCompressed(destFile) {
+		return fs.CopyFileBuf(ctx, f.baseFile(srcFile), f.baseFile(destFile), buf)
+	}
+	data, err := f.ReadAll(ctx, srcFile)
+	if err != nil {
+		return err
+	}
+	return f.WriteAll(ctx, destFile, data, nil)
+}
+
+// reencode rewrites the stored data of the file at filePath
+// for the encoding of destPath if it differs.
+func (f *CompressFileSystem) reencode(filePath, destPath string) error {
+	if f.IsCompressed(filePath) == f.IsCompressed(destPath) {
+		return nil
+	}
+	info, err := f.Stat(filePath)
+	if err != nil || info.IsDir() {
+		return err
+	}
+	data, err := f.ReadAll(context.Background(), filePath)
+	if err != nil {
+		return err
+	}
+	if f.IsCompressed(destPath) {
+		data, err = f.compress(data)
+		if err != nil {
+			return err
+		}
+	}
+	return f.baseFile(filePath).WriteAll(data)
+}
+
+func (f *CompressFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return "", err
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	newPath := path.Join(path.Dir(f.AbsPath(filePath)), newName)
+	err := f.reencode(filePath, newPath)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.baseFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+func (f *CompressFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	err := f.reencode(filePath, destPath)
+	if err != nil {
+		return err
+	}
+	return f.baseFile(filePath).MoveTo(f.baseFile(destPath))
+}
+
+func (f *CompressFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).Remove()
+}
+
+// Close unregisters the file system,
+// the base file system is not closed.
+func (f *CompressFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// fileReader decompresses from a base reader
+type fileReader struct {
+	io.ReadCloser
+	base iofs.File
+	info iofs.FileInfo
+}
+
+func (r *fileReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+func (r *fileReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.base.Close())
+}
+
+// fileWriter compresses to a base writer
+type fileWriter struct {
+	io.WriteCloser
+	base io.Closer
+}
+
+// Close flushes the compressed data and closes the base writer
+func (w *fileWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if err != nil {
+		return errors.Join(err, w.base.Close())
+	}
+	return w.base.Close()
+}
@@ -0,0 +1,156 @@
+package compressfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(uncompressed)
+}
+
+func TestCompressFileSystem(t *testing.T) {
+	baseDir := fs.File(t.TempDir())
+	compressFS, err := NewAndRegister(baseDir, Options{Level: gzip.BestCompression})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = compressFS.Close() })
+	ctx := context.Background()
+	root := compressFS.RootDir()
+	require.True(t, fs.IsRegistered(compressFS))
+	require.True(t, root.IsDir())
+	require.Equal(t, Gzip, compressFS.Compression())
+
+	text := strings.Repeat("Hello World! ", 100)
+	require.NoError(t, root.Join("dir").MakeDir())
+	file := root.Join("dir", "file.txt")
+	require.NoError(t, file.WriteAllString(text))
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, text, str)
+
+	// Stored gzip compressed
+	stored, err := baseDir.Join("dir", "file.txt").ReadAll()
+	require.NoError(t, err)
+	require.Less(t, len(stored), len(text))
+	require.Equal(t, text, gunzip(t, stored))
+	require.Equal(t, int64(len(stored)), file.Size(), "size of the stored data")
+
+	require.NoError(t, file.AppendString(ctx, "Again"))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, text+"Again", str)
+	data, err := baseDir.Join("dir", "file.txt").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, text+"Again", gunzip(t, data))
+
+	require.NoError(t, root.Join("empty.txt").Touch())
+	data, err = root.Join("empty.txt").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, data)
+
+	files, err := root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dir", "empty.txt"}, fs.FileNames(files))
+	require.Equal(t, compressFS, files[0].FileSystem())
+
+	writer, err := root.Join("written.txt").OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("written"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	data, err = baseDir.Join("written.txt").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "written", gunzip(t, data))
+
+	reader, err := root.Join("written.txt").OpenReader()
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "written", string(data))
+	info, err := reader.Stat()
+	require.NoError(t, err)
+	require.Equal(t, "written.txt", info.Name())
+	require.NoError(t, reader.Close())
+
+	require.NoError(t, fs.CopyFile(ctx, file, root.Join("copy.txt")))
+	str, err = root.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, text+"Again", str)
+	renamed, err := root.Join("copy.txt").Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, root.Join("renamed.txt"), renamed)
+	require.NoError(t, renamed.MoveTo(root.Join("dir", "moved.txt")))
+	require.True(t, baseDir.Join("dir", "moved.txt").Exists())
+	require.NoError(t, root.Join("dir", "moved.txt").Remove())
+	require.False(t, root.Join("dir", "moved.txt").Exists())
+
+	_, err = root.Join("missing.txt").ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Uncompressed data can't be read
+	require.NoError(t, baseDir.Join("plain.txt").WriteAllString("plain"))
+	_, err = root.Join("plain.txt").ReadAll()
+	require.Error(t, err)
+}
+
+func TestCompressFileSystem_Patterns(t *testing.T) {
+	baseDir := fs.File(t.TempDir())
+	compressFS, err := NewAndRegister(baseDir, Options{Patterns: []string{"*.log"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = compressFS.Close() })
+	root := compressFS.RootDir()
+	require.True(t, compressFS.IsCompressed("/dir/app.log"))
+	require.False(t, compressFS.IsCompressed("/dir/image.png"))
+
+	require.NoError(t, root.Join("app.log").WriteAllString("log line"))
+	require.NoError(t, root.Join("image.png").WriteAllString("png data"))
+	data, err := baseDir.Join("app.log").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "log line", gunzip(t, data))
+	str, err := baseDir.Join("image.png").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "png data", str, "stored unchanged")
+	str, err = root.Join("image.png").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "png data", str)
+
+	// Renaming across the patterns re-encodes the data
+	renamed, err := root.Join("app.log").Rename("app.txt")
+	require.NoError(t, err)
+	str, err = baseDir.Join("app.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "log line", str)
+	str, err = renamed.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "log line", str)
+	require.NoError(t, renamed.MoveTo(root.Join("moved.log")))
+	data, err = baseDir.Join("moved.log").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "log line", gunzip(t, data))
+	require.NoError(t, fs.CopyFile(context.Background(), root.Join("moved.log"), root.Join("copy.txt")))
+	str, err = baseDir.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "log line", str)
+
+	_, err = NewAndRegister(baseDir, Options{Patterns: []string{"["}})
+	require.Error(t, err, "invalid pattern")
+	_, err = NewAndRegister(baseDir, Options{Level: 100})
+	require.Error(t, err, "invalid level")
+	_, err = NewAndRegister(baseDir, Options{Compression: Zstd})
+	require.ErrorIs(t, err, errors.ErrUnsupported, "zstd not configured")
+}
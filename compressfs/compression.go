@@ -0,0 +1,78 @@
+package compressfs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Compression format used for stored files
+type Compression struct {
+	// Name of the compression format
+	Name string
+	// NewReader returns a reader that decompresses r.
+	// Concatenated compressed streams must be read as one stream
+	// because Append adds a new compressed stream to a file.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	// NewWriter returns a writer that compresses to w.
+	// A level of zero selects the default level of the format.
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+func (c *Compression) String() string {
+	return c.Name
+}
+
+func (c *Compression) newReader(r io.Reader) (io.ReadCloser, error) {
+	if c.NewReader == nil {
+		return nil, fmt.Errorf("%s decompression: %w", c.Name, errors.ErrUnsupported)
+	}
+	return c.NewReader(r)
+}
+
+func (c *Compression) newWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if c.NewWriter == nil {
+		return nil, fmt.Errorf("%s compression: %w", c.Name, errors.ErrUnsupported)
+	}
+	return c.NewWriter(w, level)
+}
+
+var (
+	// Gzip compression with levels from gzip.BestSpeed to gzip.BestCompression
+	Gzip = &Compression{
+		Name: "gzip",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			if level == 0 {
+				level = gzip.DefaultCompression
+			}
+			return gzip.NewWriterLevel(w, level)
+		},
+	}
+
+	// Zstd compression.
+	//
+	// The standard library has no zstd implementation,
+	// so NewReader and NewWriter have to be set before use,
+	// for example with github.com/klauspost/compress/zstd:
+	//
+	//	compressfs.Zstd.NewReader = func(r io.Reader) (io.ReadCloser, error) {
+	//		d, err := zstd.NewReader(r)
+	//		if err != nil {
+	//			return nil, err
+	//		}
+	//		return d.IOReadCloser(), nil
+	//	}
+	//	compressfs.Zstd.NewWriter = func(w io.Writer, level int) (io.WriteCloser, error) {
+	//		if level == 0 {
+	//			return zstd.NewWriter(w)
+	//		}
+	//		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	//	}
+	Zstd = &Compression{
+		Name: "zstd",
+	}
+)
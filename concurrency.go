@@ -0,0 +1,355 @@
+package fs
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Priority of file system operations
+// used to schedule operations waiting for a slot
+// of a file system with limited concurrent operations.
+type Priority int
+
+const (
+	// PriorityInteractive is the default priority
+	// for latency sensitive operations
+	PriorityInteractive Priority = iota
+	// PriorityBackground is for bulk operations like synchronizations,
+	// cleanups, or hashing that should not slow down
+	// interactive operations on the same file system
+	PriorityBackground
+
+	numPriorities = iota
+)
+
+// String implements the fmt.Stringer interface.
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBackground:
+		return "background"
+	default:
+		return "invalid priority"
+	}
+}
+
+type priorityCtxKey struct{}
+
+// ContextWithPriority returns a context that makes
+// File methods called with it use priority.
+// File methods without a context argument
+// use PriorityInteractive.
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, priority)
+}
+
+// PriorityFromContext returns the priority set with ContextWithPriority
+// or PriorityInteractive if ctx has no priority.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityCtxKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return PriorityInteractive
+}
+
+// opLimit are the concurrent operation limits of a file system,
+// zero means no limit
+type opLimit struct {
+	total      int
+	background int
+}
+
+var (
+	opLimitsMtx sync.RWMutex
+	// opLimits maps file system prefixes to their limits
+	opLimits = make(map[string]opLimit)
+	// defaultOpLimit is used for limits not set in opLimits
+	defaultOpLimit opLimit
+	// opSemaphores maps file system prefixes to semaphores
+	// created lazily for their effective limits
+	opSemaphores = make(map[string]*opSemaphore)
+	// hasOpLimits is a fast path flag to skip locking
+	// if no limits are configured at all
+	hasOpLimits atomic.Bool
+)
+
+// updateOpLimits must be called with opLimitsMtx locked
+// after a limit was changed. Operations in progress
+// release the slots of the semaphore they acquired them from.
+func updateOpLimits(prefix string) {
+	if prefix == "" {
+		clear(opSemaphores)
+	} else {
+		delete(opSemaphores, prefix)
+	}
+	hasOpLimits.Store(len(opLimits) > 0 || defaultOpLimit != opLimit{})
+}
+
+// setOpLimit must be called with opLimitsMtx locked
+func setOpLimit(prefix string, limit opLimit) {
+	if limit == (opLimit{}) {
+		delete(opLimits, prefix)
+	} else {
+		opLimits[prefix] = limit
+	}
+	updateOpLimits(prefix)
+}
+
+// SetMaxConcurrentOps limits the number of concurrent operations
+// dispatched by File methods to fileSystem to n.
+// Use it to protect servers like SFTP or APIs with rate limits
+// from an unbounded fan-out of goroutines by application code.
+// A value of n <= 0 removes the limit of fileSystem
+// so that the default limit is used again.
+//
+// Operations waiting for a slot are scheduled by the Priority
+// of the context passed to File methods, see ContextWithPriority.
+//
+// Only the calls to the methods of the file system are limited,
+// readers and writers opened by them are not counted
+// and listing callbacks don't hold an operation slot while running,
+// so they can use the file system without deadlocking.
+// Calls made directly to FileSystem methods are not limited.
+//
+// The limit is bound to the prefix of the registered
+// fileSystem and cleared by Unregister and Replace.
+func SetMaxConcurrentOps(fileSystem FileSystem, n int) {
+	opLimitsMtx.Lock()
+	defer opLimitsMtx.Unlock()
+
+	prefix := fileSystem.Prefix()
+	limit := opLimits[prefix]
+	limit.total = max(n, 0)
+	setOpLimit(prefix, limit)
+}
+
+// MaxConcurrentOps returns the limit of concurrent operations
+// for fileSystem set with SetMaxConcurrentOps
+// or the default limit set with SetDefaultMaxConcurrentOps.
+// Zero means no limit.
+func MaxConcurrentOps(fileSystem FileSystem) int {
+	opLimitsMtx.RLock()
+	defer opLimitsMtx.RUnlock()
+
+	return effectiveOpLimit(fileSystem.Prefix()).total
+}
+
+// SetMaxConcurrentBackgroundOps limits the number of concurrent operations
+// with PriorityBackground dispatched by File methods to fileSystem to n.
+// Set it lower than the limit of SetMaxConcurrentOps
+// to keep slots free for interactive operations.
+// A value of n <= 0 removes the limit.
+// Like SetMaxConcurrentOps the limit is cleared by Unregister and Replace.
+func SetMaxConcurrentBackgroundOps(fileSystem FileSystem, n int) {
+	opLimitsMtx.Lock()
+	defer opLimitsMtx.Unlock()
+
+	prefix := fileSystem.Prefix()
+	limit := opLimits[prefix]
+	limit.background = max(n, 0)
+	setOpLimit(prefix, limit)
+}
+
+// MaxConcurrentBackgroundOps returns the limit of concurrent operations
+// with PriorityBackground for fileSystem
+// set with SetMaxConcurrentBackgroundOps.
+// Zero means no limit.
+func MaxConcurrentBackgroundOps(fileSystem FileSystem) int {
+	opLimitsMtx.RLock()
+	defer opLimitsMtx.RUnlock()
+
+	return effectiveOpLimit(fileSystem.Prefix()).background
+}
+
+// SetDefaultMaxConcurrentOps limits the number of concurrent operations
+// for every file system without a limit set with SetMaxConcurrentOps.
+// A value of n <= 0 removes the default limit.
+//
+// The limit applies to every file system separately
+// instead of all file systems together, because file systems
+// wrapping other file systems would otherwise need two operation slots
+// for one operation and could deadlock.
+func SetDefaultMaxConcurrentOps(n int) {
+	opLimitsMtx.Lock()
+	defer opLimitsMtx.Unlock()
+
+	defaultOpLimit.total = max(n, 0)
+	updateOpLimits("")
+}
+
+// effectiveOpLimit must be called with opLimitsMtx locked
+func effectiveOpLimit(prefix string) opLimit {
+	limit := opLimits[prefix]
+	if limit.total == 0 {
+		limit.total = defaultOpLimit.total
+	}
+	if limit.background == 0 {
+		limit.background = defaultOpLimit.background
+	}
+	return limit
+}
+
+// getOpSemaphore returns the semaphore for fileSystem
+// or nil if its operations are not limited.
+func getOpSemaphore(fileSystem FileSystem) *opSemaphore {
+	if !hasOpLimits.Load() {
+		return nil
+	}
+	prefix := fileSystem.Prefix()
+
+	opLimitsMtx.RLock()
+	sem, ok := opSemaphores[prefix]
+	opLimitsMtx.RUnlock()
+	if ok {
+		return sem
+	}
+
+	opLimitsMtx.Lock()
+	defer opLimitsMtx.Unlock()
+
+	if sem, ok := opSemaphores[prefix]; ok {
+		return sem
+	}
+	limit := effectiveOpLimit(prefix)
+	if limit != (opLimit{}) {
+		sem = &opSemaphore{limit: limit}
+	}
+	// Also remember nil for unlimited file systems
+	opSemaphores[prefix] = sem
+	return sem
+}
+
+// opSemaphore hands out operation slots
+// to waiting operations in the order of their priority
+type opSemaphore struct {
+	mtx     sync.Mutex
+	limit   opLimit
+	inUse   [numPriorities]int
+	waiting [numPriorities][]chan struct{}
+}
+
+// available returns if a slot for priority is available,
+// mtx must be locked
+func (s *opSemaphore) available(priority Priority) bool {
+	if s.limit.total > 0 && s.inUse[PriorityInteractive]+s.inUse[PriorityBackground] >= s.limit.total {
+		return false
+	}
+	if priority == PriorityBackground && s.limit.background > 0 && s.inUse[PriorityBackground] >= s.limit.background {
+		return false
+	}
+	return true
+}
+
+// acquire waits for a slot for priority
+// or returns the context error if ctx is canceled while waiting.
+func (s *opSemaphore) acquire(ctx context.Context, priority Priority) (release func(), err error) {
+	s.mtx.Lock()
+	// Don't overtake waiting operations of the same or higher priority
+	waitingBefore := false
+	for p := PriorityInteractive; p <= priority; p++ {
+		waitingBefore = waitingBefore || len(s.waiting[p]) > 0
+	}
+	if !waitingBefore && s.available(priority) {
+		s.inUse[priority]++
+		s.mtx.Unlock()
+		return s.releaseFunc(priority), nil
+	}
+	ready := make(chan struct{})
+	s.waiting[priority] = append(s.waiting[priority], ready)
+	s.mtx.Unlock()
+
+	select {
+	case <-ready:
+		return s.releaseFunc(priority), nil
+	case <-ctx.Done():
+		s.mtx.Lock()
+		select {
+		case <-ready:
+			// Got the slot while canceling
+			s.mtx.Unlock()
+			s.releaseFunc(priority)()
+		default:
+			s.waiting[priority] = slices.DeleteFunc(s.waiting[priority], func(c chan struct{}) bool { return c == ready })
+			s.mtx.Unlock()
+		}
+		return noopEndOp, ctx.Err()
+	}
+}
+
+func (s *opSemaphore) releaseFunc(priority Priority) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mtx.Lock()
+			defer s.mtx.Unlock()
+
+			s.inUse[priority]--
+			// Hand out free slots by priority
+			for p := PriorityInteractive; p < numPriorities; p++ {
+				for len(s.waiting[p]) > 0 && s.available(p) {
+					s.inUse[p]++
+					close(s.waiting[p][0])
+					s.waiting[p] = s.waiting[p][1:]
+				}
+			}
+		})
+	}
+}
+
+func noopEndOp() {}
+
+// beginOp waits for a free operation slot of fileSystem
+// with PriorityInteractive and returns a function to release it.
+func beginOp(fileSystem FileSystem) (endOp func()) {
+	endOp, _ = beginOpContext(context.Background(), fileSystem)
+	return endOp
+}
+
+// beginOpContext waits for a free operation slot of fileSystem
+// with the priority of ctx and returns a function to release it
+// or the context error if ctx is canceled while waiting.
+func beginOpContext(ctx context.Context, fileSystem FileSystem) (endOp func(), err error) {
+	sem := getOpSemaphore(fileSystem)
+	if sem == nil {
+		return noopEndOp, nil
+	}
+	return sem.acquire(ctx, PriorityFromContext(ctx))
+}
+
+// beginListDirOp waits for a free operation slot of fileSystem
+// and returns a callback wrapper that releases the slot
+// while callback is running so that callback can use the file system.
+// The returned endOp function must be called after the listing.
+func beginListDirOp(ctx context.Context, fileSystem FileSystem, callback func(*FileInfo) error) (wrapped func(*FileInfo) error, endOp func(), err error) {
+	if getOpSemaphore(fileSystem) == nil {
+		return callback, noopEndOp, nil
+	}
+	release, err := beginOpContext(ctx, fileSystem)
+	if err != nil {
+		return nil, nil, err
+	}
+	var mtx sync.Mutex
+	wrapped = func(info *FileInfo) error {
+		mtx.Lock()
+		release()
+		mtx.Unlock()
+		cbErr := callback(info)
+		mtx.Lock()
+		defer mtx.Unlock()
+		var beginErr error
+		release, beginErr = beginOpContext(ctx, fileSystem)
+		if beginErr != nil {
+			return beginErr
+		}
+		return cbErr
+	}
+	endOp = func() {
+		mtx.Lock()
+		defer mtx.Unlock()
+		release()
+	}
+	return wrapped, endOp, nil
+}
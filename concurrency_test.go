@@ -0,0 +1,174 @@
+package fs
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingFileSystem counts the concurrent calls of Stat
+type countingFileSystem struct {
+	*MemFileSystem
+	prefix   string
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (c *countingFileSystem) Prefix() string { return c.prefix }
+
+func (c *countingFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, c.prefix)
+}
+
+func (c *countingFileSystem) Stat(filePath string) (fs.FileInfo, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		seen := c.maxSeen.Load()
+		if n <= seen || c.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c.MemFileSystem.Stat(filePath)
+}
+
+func TestSetMaxConcurrentOps(t *testing.T) {
+	memFS, err := NewMemFileSystem("/", MemFile{FileName: "file.txt", FileData: []byte("Hello")})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+	countingFS := &countingFileSystem{MemFileSystem: memFS, prefix: "counting://"}
+	Register(countingFS)
+	t.Cleanup(func() { Unregister(countingFS) })
+	file := File(countingFS.prefix + "/file.txt")
+
+	require.Zero(t, MaxConcurrentOps(countingFS))
+	SetMaxConcurrentOps(countingFS, 2)
+	t.Cleanup(func() { SetMaxConcurrentOps(countingFS, 0) })
+	require.Equal(t, 2, MaxConcurrentOps(countingFS))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := file.Stat()
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(2), countingFS.maxSeen.Load())
+
+	// Listing callbacks can use the file system with a limit of 1
+	SetMaxConcurrentOps(countingFS, 1)
+	err = File(countingFS.prefix + "/").ListDir(func(f File) error {
+		return f.CheckExists()
+	})
+	require.NoError(t, err)
+
+	// Waiting for a slot can be canceled
+	endOp := beginOp(countingFS)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = file.ReadAllContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	endOp()
+	data, err := file.ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", string(data))
+
+	// Default limits apply to every file system separately
+	SetMaxConcurrentOps(countingFS, 0)
+	SetDefaultMaxConcurrentOps(3)
+	t.Cleanup(func() { SetDefaultMaxConcurrentOps(0) })
+	require.Equal(t, 3, MaxConcurrentOps(countingFS))
+	require.Equal(t, 3, MaxConcurrentOps(Local))
+	SetDefaultMaxConcurrentOps(0)
+	require.Zero(t, MaxConcurrentOps(countingFS))
+}
+
+func TestPriority(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+	SetMaxConcurrentOps(memFS, 1)
+	t.Cleanup(func() { SetMaxConcurrentOps(memFS, 0) })
+
+	require.Equal(t, PriorityInteractive, PriorityFromContext(context.Background()))
+	backgroundCtx := ContextWithPriority(context.Background(), PriorityBackground)
+	require.Equal(t, PriorityBackground, PriorityFromContext(backgroundCtx))
+
+	// Waiting interactive operations get a slot before background operations
+	sem := getOpSemaphore(memFS)
+	endOp := beginOp(memFS)
+	var (
+		mtx   sync.Mutex
+		order []Priority
+		wg    sync.WaitGroup
+	)
+	waitFor := func(ctx context.Context) {
+		defer wg.Done()
+		endOp, err := beginOpContext(ctx, memFS)
+		require.NoError(t, err)
+		mtx.Lock()
+		order = append(order, PriorityFromContext(ctx))
+		mtx.Unlock()
+		endOp()
+	}
+	numWaiting := func(p Priority) int {
+		sem.mtx.Lock()
+		defer sem.mtx.Unlock()
+		return len(sem.waiting[p])
+	}
+	wg.Add(2)
+	go waitFor(backgroundCtx)
+	require.Eventually(t, func() bool { return numWaiting(PriorityBackground) == 1 }, time.Second, time.Millisecond)
+	go waitFor(context.Background())
+	require.Eventually(t, func() bool { return numWaiting(PriorityInteractive) == 1 }, time.Second, time.Millisecond)
+	endOp()
+	wg.Wait()
+	require.Equal(t, []Priority{PriorityInteractive, PriorityBackground}, order)
+
+	// Background operations can be limited to keep slots free for interactive ones
+	SetMaxConcurrentOps(memFS, 2)
+	SetMaxConcurrentBackgroundOps(memFS, 1)
+	require.Equal(t, 1, MaxConcurrentBackgroundOps(memFS))
+	endBackgroundOp, err := beginOpContext(backgroundCtx, memFS)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(backgroundCtx, 10*time.Millisecond)
+	defer cancel()
+	_, err = beginOpContext(ctx, memFS)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "background limit reached")
+	endOp, err = beginOpContext(context.Background(), memFS)
+	require.NoError(t, err, "slot free for interactive operation")
+	endOp()
+	endBackgroundOp()
+	SetMaxConcurrentBackgroundOps(memFS, 0)
+	require.Zero(t, MaxConcurrentBackgroundOps(memFS))
+}
+
+func TestSetMaxConcurrentOps_Unregister(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	SetMaxConcurrentOps(memFS, 2)
+	SetMaxConcurrentBackgroundOps(memFS, 1)
+	require.Equal(t, 2, MaxConcurrentOps(memFS))
+	require.NoError(t, memFS.Close())
+	require.Equal(t, 0, MaxConcurrentOps(memFS), "cleared by Unregister")
+	require.Equal(t, 0, MaxConcurrentBackgroundOps(memFS), "cleared by Unregister")
+
+	basicFS := &basicFileSystem{FileSystem: Local}
+	Register(basicFS)
+	t.Cleanup(func() { Unregister(basicFS) })
+	SetMaxConcurrentOps(basicFS, 2)
+	replacement := &basicFileSystem{FileSystem: Local}
+	require.NoError(t, Replace(basicFS, replacement))
+	basicFS = replacement
+	require.Equal(t, 0, MaxConcurrentOps(replacement), "cleared by Replace")
+}
@@ -15,6 +15,8 @@ type ContentHashFunc func(ctx context.Context, reader io.Reader) (string, error)
 
 // DefaultContentHash configures the default content hash function
 // used by methods like File.ContentHash and FileReader.ContentHash.
+// HashAlgorithm.ContentHashFunc returns functions for standard
+// algorithms that can also be selected per call with File.Hash.
 var DefaultContentHash ContentHashFunc = fsimpl.DropboxContentHash
 
 // FileContentHash returns the hashFunc result for fileReader
@@ -0,0 +1,154 @@
+package fs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ContentHashCache caches content hashes of files.
+// A cached hash is only used as long as the size and
+// modified time of the file did not change,
+// else the hash is re-computed and the cache updated.
+//
+// The cache can be persisted to and loaded from a sidecar file
+// on any file system, including a MemFileSystem,
+// so that repeated hashing of unchanged files
+// is near-instant even across processes.
+//
+// It is valid to call the methods of ContentHashCache
+// for a nil pointer which will compute the hashes without caching.
+// ContentHashCache is safe for concurrent use.
+type ContentHashCache struct {
+	hashFunc ContentHashFunc
+	entries  map[File]contentHashCacheEntry
+	mtx      sync.Mutex
+}
+
+type contentHashCacheEntry struct {
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Hash     string    `json:"hash"`
+}
+
+// NewContentHashCache returns a new ContentHashCache
+// using hashFunc or DefaultContentHash if hashFunc is nil.
+func NewContentHashCache(hashFunc ContentHashFunc) *ContentHashCache {
+	if hashFunc == nil {
+		hashFunc = DefaultContentHash
+	}
+	return &ContentHashCache{
+		hashFunc: hashFunc,
+		entries:  make(map[File]contentHashCacheEntry),
+	}
+}
+
+// ContentHash returns the cached content hash of file
+// if its size and modified time are unchanged since
+// the hash was cached, else the hash is computed and cached.
+// If the file is a directory, then an empty string will be returned.
+func (cache *ContentHashCache) ContentHash(ctx context.Context, file File) (string, error) {
+	if file == "" {
+		return "", ErrEmptyPath
+	}
+	if cache == nil {
+		return file.ContentHashContext(ctx)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", nil
+	}
+
+	cache.mtx.Lock()
+	entry, ok := cache.entries[file]
+	cache.mtx.Unlock()
+	if ok && entry.Size == info.Size() && entry.Modified.Equal(info.ModTime()) {
+		return entry.Hash, nil
+	}
+
+	hash, err := FileContentHash(ctx, file, cache.hashFunc)
+	if err != nil {
+		return "", err
+	}
+	cache.mtx.Lock()
+	cache.entries[file] = contentHashCacheEntry{
+		Size:     info.Size(),
+		Modified: info.ModTime(),
+		Hash:     hash,
+	}
+	cache.mtx.Unlock()
+	return hash, nil
+}
+
+// Delete deletes the cached hash of file.
+func (cache *ContentHashCache) Delete(file File) {
+	if cache == nil {
+		return
+	}
+	cache.mtx.Lock()
+	delete(cache.entries, file)
+	cache.mtx.Unlock()
+}
+
+// Clear deletes all cached hashes.
+func (cache *ContentHashCache) Clear() {
+	if cache == nil {
+		return
+	}
+	cache.mtx.Lock()
+	clear(cache.entries)
+	cache.mtx.Unlock()
+}
+
+// Len returns the number of cached hashes.
+func (cache *ContentHashCache) Len() int {
+	if cache == nil {
+		return 0
+	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	return len(cache.entries)
+}
+
+// Save writes all cached hashes as JSON to the sidecar file.
+// Note that the hash function is not saved,
+// so the same hash function has to be used
+// with a cache that loads the sidecar file.
+func (cache *ContentHashCache) Save(ctx context.Context, sidecar File) error {
+	if cache == nil {
+		return nil
+	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	return sidecar.WriteJSON(ctx, cache.entries)
+}
+
+// Load adds the hashes from a JSON sidecar file
+// written by Save to the cache.
+// A non existing sidecar file is not an error
+// and leaves the cache unchanged.
+func (cache *ContentHashCache) Load(ctx context.Context, sidecar File) error {
+	if cache == nil {
+		return nil
+	}
+	if !sidecar.Exists() {
+		return nil
+	}
+	var entries map[File]contentHashCacheEntry
+	err := sidecar.ReadJSON(ctx, &entries)
+	if err != nil {
+		return err
+	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	for file, entry := range entries {
+		cache.entries[file] = entry
+	}
+	return nil
+}
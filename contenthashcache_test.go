@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentHashCache(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	file := dir.Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+
+	numHashed := 0
+	hashFunc := func(ctx context.Context, r io.Reader) (string, error) {
+		numHashed++
+		return DefaultContentHash(ctx, r)
+	}
+
+	cache := NewContentHashCache(hashFunc)
+	hash, err := cache.ContentHash(ctx, file)
+	require.NoError(t, err)
+	expected, err := file.ContentHash()
+	require.NoError(t, err)
+	require.Equal(t, expected, hash)
+
+	hash, err = cache.ContentHash(ctx, file)
+	require.NoError(t, err)
+	require.Equal(t, expected, hash)
+	require.Equal(t, 1, numHashed, "second call uses cached hash")
+
+	// Change content but not size, only the modified time changes
+	require.NoError(t, file.WriteAllString("Hallo"))
+	modified := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(file.LocalPath(), modified, modified))
+	hash, err = cache.ContentHash(ctx, file)
+	require.NoError(t, err)
+	require.NotEqual(t, expected, hash, "changed file is re-hashed")
+	require.Equal(t, 2, numHashed)
+
+	// Persist to sidecar and load into new cache
+	sidecar := dir.Join(".hashes.json")
+	require.NoError(t, cache.Save(ctx, sidecar))
+	loaded := NewContentHashCache(hashFunc)
+	require.NoError(t, loaded.Load(ctx, sidecar))
+	require.Equal(t, 1, loaded.Len())
+	loadedHash, err := loaded.ContentHash(ctx, file)
+	require.NoError(t, err)
+	require.Equal(t, hash, loadedHash)
+	require.Equal(t, 2, numHashed, "loaded cache used")
+
+	require.NoError(t, NewContentHashCache(nil).Load(ctx, dir.Join("missing.json")))
+
+	var nilCache *ContentHashCache
+	hash, err = nilCache.ContentHash(ctx, file)
+	require.NoError(t, err)
+	require.Equal(t, loadedHash, hash)
+}
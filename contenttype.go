@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// ContentTypeSniffLen is the number of bytes read from the
+// beginning of a file by ContentType to detect the content type.
+const ContentTypeSniffLen = 512
+
+// ContentType returns the MIME content type of the file.
+//
+// If the file system implements ContentTypeFileSystem
+// then a stored content type is returned without reading the file.
+// Else the content type is looked up by the extension of the file name
+// and if that fails it is detected with http.DetectContentType
+// from the first ContentTypeSniffLen bytes of the file.
+func (file File) ContentType(ctx context.Context) (string, error) {
+	if file == "" {
+		return "", ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	if ctFS, ok := fileSystem.(ContentTypeFileSystem); ok {
+		contentType, err := ctFS.ContentType(ctx, path)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return contentType, err
+		}
+	}
+	if file.IsDir() {
+		return "", NewErrIsDirectory(file)
+	}
+	if contentType := mime.TypeByExtension(file.Ext()); contentType != "" {
+		return contentType, nil
+	}
+	head, err := file.ReadFirst(ctx, ContentTypeSniffLen)
+	if err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head), nil
+}
+
+// ContentType returns the MIME content type of the MemFile
+// looked up by the extension of its name or
+// detected with http.DetectContentType from its data.
+func (f MemFile) ContentType() string {
+	if contentType := mime.TypeByExtension(f.Ext()); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(f.FileData)
+}
@@ -0,0 +1,50 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_ContentType(t *testing.T) {
+	dir := File(t.TempDir())
+	ctx := context.Background()
+
+	for name, content := range map[string]string{
+		"page.html":    "<!DOCTYPE html><html></html>",
+		"page":         "<!DOCTYPE html><html></html>",
+		"image":        "\x89PNG\r\n\x1a\n",
+		"text":         "Hello World",
+		"data.json":    `{"key": "value"}`,
+		"no-extension": "\x00\x01\x02",
+	} {
+		require.NoError(t, dir.Join(name).WriteAllString(content))
+	}
+
+	for name, expected := range map[string]string{
+		"page.html":    "text/html",
+		"page":         "text/html",
+		"image":        "image/png",
+		"text":         "text/plain",
+		"data.json":    "application/json",
+		"no-extension": "application/octet-stream",
+	} {
+		contentType, err := dir.Join(name).ContentType(ctx)
+		require.NoError(t, err, name)
+		require.True(t, strings.HasPrefix(contentType, expected), "%s: %s", name, contentType)
+	}
+
+	_, err := dir.ContentType(ctx)
+	require.True(t, errors.As(err, new(ErrIsDirectory)), "directory has no content type")
+
+	_, err = dir.Join("missing").ContentType(ctx)
+	require.Error(t, err)
+}
+
+func TestMemFile_ContentType(t *testing.T) {
+	require.True(t, strings.HasPrefix(NewMemFile("page.html", nil).ContentType(), "text/html"))
+	require.Equal(t, "image/png", NewMemFile("image", []byte("\x89PNG\r\n\x1a\n")).ContentType())
+}
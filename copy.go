@@ -28,6 +28,8 @@ func CopyFile(ctx context.Context, src FileReader, dest File, perm ...Permission
 // If that variable holds a non zero length byte slice then this slice will be used as buffer,
 // else a byte slice will be allocated and assigned to the variable.
 // Use this function to re-use buffers between CopyFileBuf calls.
+// The progress of the copy is reported to a ProgressFunc
+// set with ContextWithProgress.
 func CopyFileBuf(ctx context.Context, src FileReader, dest File, buf *[]byte, perm ...Permissions) error {
 	if buf == nil {
 		panic("CopyFileBuf: buf is nil") // not a file system error
@@ -52,7 +54,17 @@ func CopyFileBuf(ctx context.Context, src FileReader, dest File, buf *[]byte, pe
 		// Use same file system copy if possible
 		if fs := f.FileSystem(); fs == dest.FileSystem() {
 			if copyFS, ok := fs.(CopyFileSystem); ok {
-				return copyFS.CopyFile(ctx, f.Path(), dest.Path(), buf)
+				endOp, err := beginOpContext(ctx, fs)
+				if err != nil {
+					return err
+				}
+				defer endOp()
+				err = copyFS.CopyFile(ctx, f.Path(), dest.Path(), buf)
+				if err != nil {
+					return err
+				}
+				reportProgressDone(ctx, src, dest, dest.Size())
+				return nil
 			}
 		}
 		// Else use at least same permissions
@@ -61,14 +73,27 @@ func CopyFileBuf(ctx context.Context, src FileReader, dest File, buf *[]byte, pe
 		}
 	case MemFile:
 		// Don't use io.CopyBuffer in case of MemFile
-		return dest.WriteAllContext(ctx, f.FileData, perm...)
+		err := dest.WriteAllContext(ctx, f.FileData, perm...)
+		if err != nil {
+			return err
+		}
+		reportProgressDone(ctx, src, dest, int64(len(f.FileData)))
+		return nil
 	}
 
-	r, err := src.OpenReader()
+	srcReader, err := src.OpenReader()
 	if err != nil {
 		return fmt.Errorf("CopyFileBuf: can't open src reader: %w", err)
 	}
-	defer r.Close()
+	defer srcReader.Close()
+	var (
+		r         io.Reader = srcReader
+		progressR *progressReader
+	)
+	if onProgress := ProgressFromContext(ctx); onProgress != nil {
+		progressR = newProgressReader(srcReader, onProgress, src, dest, src.Size())
+		r = progressR
+	}
 
 	w, err := dest.OpenWriter(perm...)
 	if err != nil {
@@ -83,6 +108,9 @@ func CopyFileBuf(ctx context.Context, src FileReader, dest File, buf *[]byte, pe
 	if err != nil {
 		return fmt.Errorf("CopyFileBuf: error from io.CopyBuffer: %w", err)
 	}
+	if progressR != nil {
+		progressR.done()
+	}
 	return nil
 }
 
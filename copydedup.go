@@ -0,0 +1,121 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// HasIdenticalContentHash returns true if dest exists and its
+// file system implements HashFileSystem and reports
+// the same hash as calculated for src with the
+// first HashAlgorithm known by the dest file system.
+//
+// Only the content of src is read, the hash
+// of dest is queried from its file system
+// so no file data of dest has to be transferred.
+// Always returns false if the file system of dest
+// does not implement HashFileSystem
+// or knows no hash of dest.
+func HasIdenticalContentHash(ctx context.Context, src FileReader, dest File) (bool, error) {
+	fileSystem, destPath := dest.ParseRawURI()
+	hashFS, ok := fileSystem.(HashFileSystem)
+	if !ok {
+		return false, nil
+	}
+	destInfo, err := dest.InfoContext(ctx)
+	if err != nil {
+		return false, RemoveErrDoesNotExist(err)
+	}
+	if destInfo.IsDir || destInfo.Size != src.Size() {
+		return false, nil
+	}
+	for algo := range HashAlgorithm(numHashAlgorithms) {
+		destHash, err := hashFS.Hash(ctx, destPath, algo)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return false, RemoveErrDoesNotExist(err)
+		}
+		srcHash, err := FileContentHash(ctx, src, algo.ContentHashFunc())
+		if err != nil {
+			return false, err
+		}
+		return srcHash == destHash, nil
+	}
+	return false, nil
+}
+
+// CopyFileDedup works like CopyFile but skips copying
+// if dest already contains a file with the same
+// content hash as src as reported by HasIdenticalContentHash.
+// Returns if the file was copied.
+//
+// Use it to save bandwidth for uploads of files
+// that are likely to already exist at the destination.
+func CopyFileDedup(ctx context.Context, src FileReader, dest File, perm ...Permissions) (copied bool, err error) {
+	var buf []byte
+	return copyFileDedup(ctx, src, dest, &buf, perm)
+}
+
+func copyFileDedup(ctx context.Context, src FileReader, dest File, buf *[]byte, perm []Permissions) (copied bool, err error) {
+	if dest.IsDir() {
+		dest = dest.Join(src.Name())
+	}
+	identical, err := HasIdenticalContentHash(ctx, src, dest)
+	if err != nil {
+		return false, fmt.Errorf("CopyFileDedup: %w", err)
+	}
+	if identical {
+		return false, nil
+	}
+	err = CopyFileBuf(ctx, src, dest, buf, perm...)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CopyRecursiveDedup works like CopyRecursive but skips copying
+// of files where dest already contains a file with the same
+// content hash as reported by HasIdenticalContentHash.
+// Returns the number of copied and skipped files.
+func CopyRecursiveDedup(ctx context.Context, src, dest File, patterns ...string) (copied, skipped int, err error) {
+	var buf []byte
+	err = copyRecursiveDedup(ctx, src, dest, patterns, &buf, &copied, &skipped)
+	return copied, skipped, err
+}
+
+func copyRecursiveDedup(ctx context.Context, src, dest File, patterns []string, buf *[]byte, copied, skipped *int) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if !src.IsDir() {
+		fileCopied, err := copyFileDedup(ctx, src, dest, buf, nil)
+		if err != nil {
+			return err
+		}
+		if fileCopied {
+			*copied++
+		} else {
+			*skipped++
+		}
+		return nil
+	}
+
+	if dest.Exists() && !dest.IsDir() {
+		return fmt.Errorf("can not copy a directory (%s) over a file (%s)", src.URL(), dest.URL())
+	}
+	if !dest.Exists() {
+		err := dest.MakeDir()
+		if err != nil {
+			return fmt.Errorf("copyRecursiveDedup: can't make dest dir %q: %w", dest, err)
+		}
+	}
+
+	return src.ListDirContext(ctx, func(file File) error {
+		return copyRecursiveDedup(ctx, file, dest.Join(file.Name()), patterns, buf, copied, skipped)
+	}, patterns...)
+}
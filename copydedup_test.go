@@ -0,0 +1,46 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFileDedup(t *testing.T) {
+	ctx := context.Background()
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+
+	dest := memFS.RootDir().Join("file.txt")
+	src := NewMemFile("file.txt", []byte("Hello World"))
+
+	identical, err := HasIdenticalContentHash(ctx, src, dest)
+	require.NoError(t, err)
+	require.False(t, identical, "dest does not exist")
+
+	copied, err := CopyFileDedup(ctx, src, dest)
+	require.NoError(t, err)
+	require.True(t, copied)
+
+	copied, err = CopyFileDedup(ctx, src, memFS.RootDir())
+	require.NoError(t, err)
+	require.False(t, copied, "identical file in dest dir")
+
+	src = NewMemFile("file.txt", []byte("Hello Moon!"))
+	copied, err = CopyFileDedup(ctx, src, dest)
+	require.NoError(t, err)
+	require.True(t, copied, "same size but different content")
+	data, err := dest.ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "Hello Moon!", string(data))
+
+	// Local file system does not implement HashFileSystem
+	localDest := File(t.TempDir()).Join("file.txt")
+	for range 2 {
+		copied, err = CopyFileDedup(ctx, src, localDest)
+		require.NoError(t, err)
+		require.True(t, copied)
+	}
+}
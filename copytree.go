@@ -0,0 +1,250 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OverwritePolicy defines how CopyTree handles
+// files that already exist at the destination.
+type OverwritePolicy int
+
+const (
+	// OverwriteAlways replaces existing files
+	OverwriteAlways OverwritePolicy = iota
+	// OverwriteNever skips existing files
+	OverwriteNever
+	// OverwriteIfNewer replaces existing files
+	// if the source file was modified after them
+	OverwriteIfNewer
+	// OverwriteError stops the copying with an ErrAlreadyExists error
+	OverwriteError
+)
+
+// String implements the fmt.Stringer interface.
+func (p OverwritePolicy) String() string {
+	switch p {
+	case OverwriteAlways:
+		return "OverwriteAlways"
+	case OverwriteNever:
+		return "OverwriteNever"
+	case OverwriteIfNewer:
+		return "OverwriteIfNewer"
+	case OverwriteError:
+		return "OverwriteError"
+	default:
+		return fmt.Sprintf("OverwritePolicy(%d)", int(p))
+	}
+}
+
+// CopyTreeProgress is passed to the callback of the
+// CopyTreeOnProgress option after every copied or skipped file.
+type CopyTreeProgress struct {
+	// Src is the copied or skipped source file
+	Src File
+	// Dest is the destination of Src
+	Dest File
+	// Skipped is true if Src was not copied
+	// because Dest already existed
+	Skipped bool
+
+	FilesCopied  int
+	FilesSkipped int
+	BytesCopied  int64
+}
+
+// CopyTreeOption configures CopyTree
+type CopyTreeOption func(*copyTreeConfig)
+
+type copyTreeConfig struct {
+	include             []string
+	exclude             []string
+	overwrite           OverwritePolicy
+	preservePermissions bool
+	onProgress          func(CopyTreeProgress)
+	modTime             ModTimeComparison
+}
+
+// CopyTreeInclude returns a CopyTreeOption that only copies
+// files with a name matching at least one of the patterns.
+// Directories are not filtered by the patterns.
+func CopyTreeInclude(patterns ...string) CopyTreeOption {
+	return func(config *copyTreeConfig) {
+		config.include = append(config.include, patterns...)
+	}
+}
+
+// CopyTreeExclude returns a CopyTreeOption that skips files
+// and directories with a name matching one of the patterns.
+func CopyTreeExclude(patterns ...string) CopyTreeOption {
+	return func(config *copyTreeConfig) {
+		config.exclude = append(config.exclude, patterns...)
+	}
+}
+
+// CopyTreeOverwrite returns a CopyTreeOption that sets
+// the OverwritePolicy for existing files, default is OverwriteAlways.
+func CopyTreeOverwrite(policy OverwritePolicy) CopyTreeOption {
+	return func(config *copyTreeConfig) {
+		config.overwrite = policy
+	}
+}
+
+// CopyTreeModTimeComparison returns a CopyTreeOption that sets
+// how modification times are compared for OverwriteIfNewer,
+// default is the exact comparison of the zero ModTimeComparison.
+func CopyTreeModTimeComparison(comparison ModTimeComparison) CopyTreeOption {
+	return func(config *copyTreeConfig) {
+		config.modTime = comparison
+	}
+}
+
+// CopyTreePreservePermissions is a CopyTreeOption that sets
+// the permissions of the copied files and directories
+// to the permissions of the source if the destination
+// file system implements PermissionsFileSystem.
+func CopyTreePreservePermissions(config *copyTreeConfig) {
+	config.preservePermissions = true
+}
+
+// CopyTreeOnProgress returns a CopyTreeOption that calls
+// callback after every copied or skipped file.
+func CopyTreeOnProgress(callback func(CopyTreeProgress)) CopyTreeOption {
+	return func(config *copyTreeConfig) {
+		config.onProgress = callback
+	}
+}
+
+// CopyTree copies the directory src recursively to dest
+// which can be on a different file system.
+// dest and all its parent directories are created if they don't exist.
+//
+// Files are copied with CopyFileBuf so that copies within
+// the same file system use CopyFileSystem if implemented.
+// Directories are created even if they contain
+// no files matching the CopyTreeInclude patterns.
+// The byte progress of every copied file is reported
+// to a ProgressFunc set with ContextWithProgress.
+func CopyTree(ctx context.Context, src, dest File, options ...CopyTreeOption) error {
+	var config copyTreeConfig
+	for _, option := range options {
+		option(&config)
+	}
+	if err := src.CheckIsDir(); err != nil {
+		return err
+	}
+	if src.FileSystem() == dest.FileSystem() {
+		srcPath := strings.TrimSuffix(src.Path(), src.FileSystem().Separator())
+		if destPath := dest.Path(); destPath == srcPath || strings.HasPrefix(destPath, srcPath+src.FileSystem().Separator()) {
+			return fmt.Errorf("CopyTree: destination %s is inside of source %s", dest, src)
+		}
+	}
+	var (
+		buf      []byte
+		progress CopyTreeProgress
+	)
+	return copyTree(ctx, src, dest, &config, &buf, &progress)
+}
+
+func copyTree(ctx context.Context, srcDir, destDir File, config *copyTreeConfig, buf *[]byte, progress *CopyTreeProgress) error {
+	var perm []Permissions
+	if config.preservePermissions {
+		perm = []Permissions{srcDir.Permissions()}
+	}
+	err := destDir.MakeAllDirs(perm...)
+	if err != nil {
+		return fmt.Errorf("CopyTree: can't make directory %s: %w", destDir, err)
+	}
+	if config.preservePermissions {
+		err = setPreservedPermissions(destDir, perm[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	srcFS := srcDir.FileSystem()
+	return srcDir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if len(config.exclude) > 0 {
+			excluded, err := srcFS.MatchAnyPattern(info.Name, config.exclude)
+			if excluded || err != nil {
+				return err
+			}
+		}
+		dest := destDir.Join(info.Name)
+		if info.IsDir {
+			return copyTree(ctx, info.File, dest, config, buf, progress)
+		}
+		if len(config.include) > 0 {
+			included, err := srcFS.MatchAnyPattern(info.Name, config.include)
+			if !included || err != nil {
+				return err
+			}
+		}
+
+		skip, err := config.skipExisting(info, dest)
+		if err != nil {
+			return err
+		}
+		if !skip {
+			var perm []Permissions
+			if config.preservePermissions {
+				perm = []Permissions{info.Permissions}
+			}
+			err = CopyFileBuf(ctx, info.File, dest, buf, perm...)
+			if err != nil {
+				return err
+			}
+			if config.preservePermissions {
+				err = setPreservedPermissions(dest, info.Permissions)
+				if err != nil {
+					return err
+				}
+			}
+			progress.FilesCopied++
+			progress.BytesCopied += info.Size
+		} else {
+			progress.FilesSkipped++
+		}
+		if config.onProgress != nil {
+			progress.Src = info.File
+			progress.Dest = dest
+			progress.Skipped = skip
+			config.onProgress(*progress)
+		}
+		return nil
+	})
+}
+
+// skipExisting returns if the copying of src to an existing dest
+// should be skipped according to the OverwritePolicy
+func (config *copyTreeConfig) skipExisting(src *FileInfo, dest File) (skip bool, err error) {
+	destInfo, err := dest.InfoContext(context.Background())
+	if err != nil {
+		return false, RemoveErrDoesNotExist(err)
+	}
+	if destInfo.IsDir {
+		return false, fmt.Errorf("CopyTree: can't copy file %s over directory: %w", src.File, NewErrIsDirectory(dest))
+	}
+	switch config.overwrite {
+	case OverwriteNever:
+		return true, nil
+	case OverwriteIfNewer:
+		return !config.modTime.After(src.Modified, destInfo.Modified), nil
+	case OverwriteError:
+		return false, fmt.Errorf("CopyTree: %w", NewErrAlreadyExists(dest))
+	default:
+		return false, nil
+	}
+}
+
+// setPreservedPermissions sets the permissions of file
+// if its file system supports it
+func setPreservedPermissions(file File, perm Permissions) error {
+	err := file.SetPermissions(perm)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return fmt.Errorf("CopyTree: can't set permissions of %s: %w", file, err)
+	}
+	return nil
+}
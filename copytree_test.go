@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyTree(t *testing.T) {
+	ctx := context.Background()
+	src := File(t.TempDir()).Join("src")
+	require.NoError(t, src.Join("sub").MakeAllDirs())
+	require.NoError(t, src.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, src.Join("b.log").WriteAllString("B"))
+	require.NoError(t, src.Join("sub", "c.txt").WriteAllString("C"))
+	require.NoError(t, src.Join(".git").MakeDir())
+	require.NoError(t, src.Join(".git", "HEAD").WriteAllString("ref"))
+
+	t.Run("filter", func(t *testing.T) {
+		dest := File(t.TempDir()).Join("dest")
+		var progress []CopyTreeProgress
+		err := CopyTree(ctx, src, dest,
+			CopyTreeInclude("*.txt"),
+			CopyTreeExclude(".git"),
+			CopyTreeOnProgress(func(p CopyTreeProgress) { progress = append(progress, p) }),
+		)
+		require.NoError(t, err)
+		requireFileContent(t, dest.Join("a.txt"), "A")
+		requireFileContent(t, dest.Join("sub", "c.txt"), "C")
+		require.False(t, dest.Join("b.log").Exists())
+		require.False(t, dest.Join(".git").Exists())
+		require.Len(t, progress, 2)
+		require.Equal(t, 2, progress[1].FilesCopied)
+		require.Equal(t, int64(2), progress[1].BytesCopied)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		dest := File(t.TempDir())
+		require.NoError(t, dest.Join("a.txt").WriteAllString("old"))
+
+		err := CopyTree(ctx, src, dest, CopyTreeOverwrite(OverwriteError))
+		require.ErrorIs(t, err, os.ErrExist)
+
+		var last CopyTreeProgress
+		err = CopyTree(ctx, src, dest, CopyTreeOverwrite(OverwriteNever), CopyTreeOnProgress(func(p CopyTreeProgress) { last = p }))
+		require.NoError(t, err)
+		requireFileContent(t, dest.Join("a.txt"), "old")
+		require.Positive(t, last.FilesSkipped)
+		require.Equal(t, 4, last.FilesCopied+last.FilesSkipped)
+
+		// Make the source file newer than the destination
+		future := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(src.Join("a.txt").LocalPath(), future, future))
+		require.NoError(t, CopyTree(ctx, src, dest, CopyTreeOverwrite(OverwriteIfNewer)))
+		requireFileContent(t, dest.Join("a.txt"), "A")
+
+		require.NoError(t, CopyTree(ctx, src, dest))
+		requireFileContent(t, dest.Join(".git", "HEAD"), "ref")
+	})
+
+	t.Run("preserve permissions", func(t *testing.T) {
+		require.NoError(t, src.Join("b.log").SetPermissions(UserReadWrite))
+		dest := File(t.TempDir())
+		require.NoError(t, CopyTree(ctx, src, dest, CopyTreePreservePermissions))
+		require.Equal(t, UserReadWrite, dest.Join("b.log").Permissions())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		require.Error(t, CopyTree(ctx, src, src.Join("sub", "dest")), "dest inside src")
+		require.Error(t, CopyTree(ctx, src.Join("a.txt"), File(t.TempDir())), "src not a dir")
+	})
+}
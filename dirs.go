@@ -3,6 +3,7 @@ package fs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/user"
 )
@@ -23,6 +24,32 @@ func CurrentWorkingDir() File {
 	return File(cwd)
 }
 
+// ListDirMaxLimit is the maximum number of files returned by
+// File.ListDirMax and File.ListDirRecursiveMax for a negative max.
+// If more files would be returned, then an error wrapping ErrTooMany
+// is returned instead of holding all of them in memory.
+// The default of zero means no limit.
+var ListDirMaxLimit int
+
+// listDirMaxLimited calls listDirMax with max
+// or with ListDirMaxLimit+1 if max is negative
+// to return an ErrTooMany error if there are more
+// than ListDirMaxLimit files in dir.
+func listDirMaxLimited(dir File, max int, listDirMax func(max int) ([]File, error)) ([]File, error) {
+	limit := ListDirMaxLimit
+	if max >= 0 || limit <= 0 {
+		return listDirMax(max)
+	}
+	files, err := listDirMax(limit + 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > limit {
+		return nil, fmt.Errorf("%w: more than %d in %s", ErrTooMany, limit, dir)
+	}
+	return files, nil
+}
+
 // listDirMaxImpl implements the ListDirMax method functionality by calling listDir.
 // It returns the passed max number of files or an unlimited number if max is < 0.
 // FileSystem implementations can use this function to implement ListDirMax,
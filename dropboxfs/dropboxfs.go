@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	iofs "io/fs"
 	"path"
 	"strings"
@@ -29,7 +30,8 @@ var (
 	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllExecute
 
 	// Make sure DropboxFileSystem implements fs.FileSystem
-	_ fs.FileSystem = new(fileSystem)
+	_ fs.FileSystem     = new(fileSystem)
+	_ fs.HashFileSystem = new(fileSystem)
 )
 
 // fileSystem implements fs.FileSystem for a Dropbox app.
@@ -38,16 +40,30 @@ type fileSystem struct {
 	prefix        string
 	client        *dropbox.Client
 	fileInfoCache *fs.FileInfoCache
+
+	stageWritesInTempFile bool
+}
+
+// Options configure a Dropbox file system.
+type Options struct {
+	// StageWritesInTempFile configures OpenWriter to stage written data
+	// in a local temp file instead of a memory buffer.
+	// The temp file is uploaded on Close and then removed
+	// which bounds the memory usage regardless of the upload size.
+	StageWritesInTempFile bool
 }
 
 // NewAndRegister returns a new fs.FileSystem for a Dropbox with
 // the passed accessToken and registers it.
-func NewAndRegister(accessToken string, cacheTimeout time.Duration) fs.FileSystem {
+func NewAndRegister(accessToken string, cacheTimeout time.Duration, options ...Options) fs.FileSystem {
 	dbfs := &fileSystem{
 		prefix:        Prefix + fsimpl.RandomString(),
 		client:        dropbox.New(dropbox.NewConfig(accessToken)),
 		fileInfoCache: fs.NewFileInfoCache(cacheTimeout),
 	}
+	for _, o := range options {
+		dbfs.stageWritesInTempFile = dbfs.stageWritesInTempFile || o.StageWritesInTempFile
+	}
 	fs.Register(dbfs)
 	return dbfs
 }
@@ -153,6 +169,9 @@ func metadataToFileInfo(meta *dropbox.Metadata) *fs.FileInfo {
 		info.Permissions = DefaultPermissions
 	}
 	// info.ContentHash = meta.ContentHash
+	// Rev, ID, and ContentHash are available as
+	// fs.FileInfoExtra[*dropbox.Metadata](info)
+	info.Extra = meta
 	return &info
 }
 
@@ -286,6 +305,27 @@ func (dbfs *fileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string
 	return dbfs.listDirInfo(ctx, dirPath, callback, patterns, true)
 }
 
+// Hash returns the content hash stored by Dropbox
+// without downloading the file.
+// Only fs.HashDropbox is supported,
+// an ErrUnsupported error is returned for other algorithms.
+func (dbfs *fileSystem) Hash(ctx context.Context, filePath string, algo fs.HashAlgorithm) (string, error) {
+	if algo != fs.HashDropbox {
+		return "", fs.NewErrUnsupported(dbfs, algo.String()+" hash of "+filePath)
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	meta, err := dbfs.client.Files.GetMetadata(&dropbox.GetMetadataInput{Path: filePath})
+	if err != nil {
+		return "", dbfs.wrapErrNotExist(filePath, err)
+	}
+	if meta.Tag == "folder" {
+		return "", fs.NewErrIsDirectory(dbfs.File(filePath))
+	}
+	return meta.ContentHash, nil
+}
+
 func (dbfs *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
 	if dbfs.info(filePath).Exists {
 		return errors.New("Touch can't change time on Dropbox")
@@ -342,6 +382,19 @@ func (dbfs *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.W
 	if !dbfs.info(path.Dir(filePath)).IsDir {
 		return nil, fs.NewErrIsNotDirectory(dbfs.File(path.Dir(filePath)))
 	}
+	if dbfs.stageWritesInTempFile {
+		return fsimpl.NewTempFileWriter(func(r io.ReadSeeker, size int64) error {
+			_, err := dbfs.client.Files.Upload(
+				&dropbox.UploadInput{
+					Path:   filePath,
+					Mode:   dropbox.WriteModeOverwrite,
+					Mute:   true,
+					Reader: r,
+				},
+			)
+			return dbfs.wrapErrNotExist(filePath, err)
+		})
+	}
 	var fileBuffer *fsimpl.FileBuffer
 	fileBuffer = fsimpl.NewFileBufferWithClose(nil, func() error {
 		return dbfs.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), nil)
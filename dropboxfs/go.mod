@@ -10,8 +10,10 @@ require github.com/ungerik/go-fs v0.0.0-00010101000000-000000000000 // replaced
 require github.com/tj/go-dropbox v0.0.0-20171107035848-42dd2be3662d
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/fsnotify/fsnotify v1.8.0 // indirect
 	github.com/segmentio/go-env v1.1.0 // indirect
 	github.com/ungerik/go-dry v0.0.0-20231011182423-d9a07fd18c5f // indirect
-	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
@@ -0,0 +1,220 @@
+package fs
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FindDuplicatesOption configures FindDuplicates
+type FindDuplicatesOption func(*findDuplicatesConfig)
+
+type findDuplicatesConfig struct {
+	hash        HashAlgorithm
+	concurrency int
+	minSize     int64
+	patterns    []string
+}
+
+// FindDuplicatesHash returns a FindDuplicatesOption that sets
+// the HashAlgorithm used to compare the content of files
+// with the same size, default is HashSHA256.
+// Hashes known by a file system implementing HashFileSystem
+// are used without reading the files.
+func FindDuplicatesHash(algo HashAlgorithm) FindDuplicatesOption {
+	return func(config *findDuplicatesConfig) {
+		config.hash = algo
+	}
+}
+
+// FindDuplicatesConcurrency returns a FindDuplicatesOption that sets
+// the number of files hashed in parallel,
+// default is runtime.NumCPU().
+func FindDuplicatesConcurrency(concurrency int) FindDuplicatesOption {
+	return func(config *findDuplicatesConfig) {
+		config.concurrency = concurrency
+	}
+}
+
+// FindDuplicatesMinSize returns a FindDuplicatesOption that skips
+// files smaller than size bytes, default is 1 so that
+// empty files are not reported as duplicates of each other.
+func FindDuplicatesMinSize(size int64) FindDuplicatesOption {
+	return func(config *findDuplicatesConfig) {
+		config.minSize = size
+	}
+}
+
+// FindDuplicatesPatterns returns a FindDuplicatesOption that only
+// compares files with a name matching at least one of the patterns.
+// Directories are not filtered by the patterns.
+func FindDuplicatesPatterns(patterns ...string) FindDuplicatesOption {
+	return func(config *findDuplicatesConfig) {
+		config.patterns = append(config.patterns, patterns...)
+	}
+}
+
+// FindDuplicates returns an iterator that yields groups of files
+// with identical content found in the directory trees of roots
+// which can be on different file systems.
+//
+// Files are grouped by size first and only files
+// sharing a size with other files get hashed in parallel.
+// Every group has at least two files sorted by their string value
+// and groups are yielded in descending size order.
+// A file found under multiple overlapping roots is only reported once.
+//
+// The first error stops the search and is yielded
+// together with a nil group.
+func FindDuplicates(ctx context.Context, roots []File, options ...FindDuplicatesOption) iter.Seq2[[]File, error] {
+	config := findDuplicatesConfig{
+		hash:    HashSHA256,
+		minSize: 1,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+	return func(yield func([]File, error) bool) {
+		groups, err := findDuplicates(ctx, roots, &config)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, group := range groups {
+			if !yield(group, nil) {
+				return
+			}
+		}
+	}
+}
+
+func findDuplicates(ctx context.Context, roots []File, config *findDuplicatesConfig) ([][]File, error) {
+	if !config.hash.Valid() {
+		return nil, fmt.Errorf("FindDuplicates: invalid %s", config.hash)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = runtime.NumCPU()
+	}
+
+	// Group files by size
+	var (
+		bySize = make(map[int64][]File)
+		seen   = make(map[File]struct{})
+	)
+	for _, root := range roots {
+		err := root.ListDirInfoRecursiveContext(ctx,
+			func(info *FileInfo) error {
+				if info.Size < config.minSize {
+					return nil
+				}
+				if _, ok := seen[info.File]; ok {
+					return nil
+				}
+				seen[info.File] = struct{}{}
+				bySize[info.Size] = append(bySize[info.Size], info.File)
+				return nil
+			},
+			config.patterns...,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Hash all files that share their size with other files
+	var candidates []File
+	for _, files := range bySize {
+		if len(files) > 1 {
+			candidates = append(candidates, files...)
+		}
+	}
+	hashes, err := hashFiles(ctx, candidates, config.hash, config.concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group files of the same size by hash
+	type sizeGroup struct {
+		size  int64
+		files []File
+	}
+	var groups []sizeGroup
+	for size, files := range bySize {
+		if len(files) < 2 {
+			continue
+		}
+		byHash := make(map[string][]File)
+		for _, file := range files {
+			hash := hashes[file]
+			byHash[hash] = append(byHash[hash], file)
+		}
+		for _, group := range byHash {
+			if len(group) > 1 {
+				slices.Sort(group)
+				groups = append(groups, sizeGroup{size, group})
+			}
+		}
+	}
+	slices.SortFunc(groups, func(a, b sizeGroup) int {
+		if c := cmp.Compare(b.size, a.size); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.files[0]), string(b.files[0]))
+	})
+	result := make([][]File, len(groups))
+	for i, group := range groups {
+		result[i] = group.files
+	}
+	return result, nil
+}
+
+// hashFiles returns the hashes of files computed with concurrency
+// number of parallel workers. The first error stops the hashing.
+func hashFiles(ctx context.Context, files []File, algo HashAlgorithm, concurrency int) (map[File]string, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		queue   = make(chan File, concurrency)
+		hashes  = make(map[File]string, len(files))
+		mtx     sync.Mutex
+		workers sync.WaitGroup
+	)
+	for range concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for file := range queue {
+				hash, err := file.Hash(ctx, algo)
+				if err != nil {
+					cancel(err)
+					continue // drain queue channel
+				}
+				mtx.Lock()
+				hashes[file] = hash
+				mtx.Unlock()
+			}
+		}()
+	}
+
+loop:
+	for _, file := range files {
+		select {
+		case queue <- file:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(queue)
+	workers.Wait()
+
+	if cause := context.Cause(ctx); cause != nil {
+		return nil, cause
+	}
+	return hashes, nil
+}
@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dirA := File(t.TempDir())
+	dirB := File(t.TempDir())
+	require.NoError(t, dirA.Join("sub").MakeDir())
+	for file, content := range map[File]string{
+		dirA.Join("a.txt"):            "duplicate",
+		dirA.Join("sub", "a.txt"):     "duplicate",
+		dirB.Join("b.txt"):            "duplicate",
+		dirA.Join("same-size.txt"):    "different",
+		dirA.Join("unique.txt"):       "unique content",
+		dirA.Join("long.txt"):         "longer duplicate",
+		dirB.Join("long.txt"):         "longer duplicate",
+		dirA.Join("empty1.txt"):       "",
+		dirB.Join("empty2.txt"):       "",
+		dirA.Join("sub", "other.dat"): "duplicate",
+	} {
+		require.NoError(t, file.WriteAllString(content))
+	}
+
+	var groups [][]File
+	for group, err := range FindDuplicates(context.Background(), []File{dirA, dirB, dirA.Join("sub")}) {
+		require.NoError(t, err)
+		groups = append(groups, group)
+	}
+	require.Equal(t, [][]File{
+		{dirA.Join("long.txt"), dirB.Join("long.txt")},
+		{dirA.Join("a.txt"), dirA.Join("sub", "a.txt"), dirA.Join("sub", "other.dat"), dirB.Join("b.txt")},
+	}, groups)
+
+	t.Run("options", func(t *testing.T) {
+		var groups [][]File
+		for group, err := range FindDuplicates(
+			context.Background(),
+			[]File{dirA, dirB},
+			FindDuplicatesHash(HashXXHash),
+			FindDuplicatesConcurrency(1),
+			FindDuplicatesMinSize(0),
+			FindDuplicatesPatterns("*.txt"),
+		) {
+			require.NoError(t, err)
+			groups = append(groups, group)
+		}
+		require.Equal(t, [][]File{
+			{dirA.Join("long.txt"), dirB.Join("long.txt")},
+			{dirA.Join("a.txt"), dirA.Join("sub", "a.txt"), dirB.Join("b.txt")},
+			{dirA.Join("empty1.txt"), dirB.Join("empty2.txt")},
+		}, groups)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var errs int
+		for group, err := range FindDuplicates(context.Background(), []File{dirA.Join("missing")}) {
+			require.Nil(t, group)
+			require.Error(t, err)
+			errs++
+		}
+		require.Equal(t, 1, errs)
+	})
+}
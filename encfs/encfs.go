@@ -0,0 +1,427 @@
+// Package encfs implements a file system that transparently
+// encrypts file contents stored in a directory of any other file system.
+package encfs
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of EncFileSystem URIs
+	Prefix = "enc://"
+
+	// Separator used in EncFileSystem paths
+	Separator = "/"
+
+	// ErrDecrypt is returned when a file can't be decrypted
+	// because of a wrong key or modified data.
+	ErrDecrypt fs.SentinelError = "can't decrypt file, wrong key or corrupted data"
+)
+
+var (
+	// Make sure EncFileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(EncFileSystem)
+)
+
+// EncFileSystem encrypts the contents of files
+// stored in a base directory of any file system
+// with AES-GCM using a random nonce per write.
+//
+// It is registered with the prefix "enc://" followed by a random ID,
+// so the base directory is accessed as "enc://<id>/".
+//
+// Every stored file consists of the nonce followed by the sealed data
+// which includes the GCM authentication tag.
+// Reading a file that was modified or encrypted
+// with a different key returns ErrDecrypt.
+//
+// File names, directory structure, sizes and modification times
+// are not encrypted. Files are encrypted as a whole,
+// so they are buffered in memory for reading and writing.
+type EncFileSystem struct {
+	prefix string
+	base   fs.File
+	aead   cipher.AEAD
+	closed atomic.Bool
+}
+
+// NewAndRegister returns a new EncFileSystem that stores encrypted files
+// in the directory baseDir and registers it.
+// Use the RootDir of a file system as baseDir to encrypt all its files.
+// The key must be 16, 24, or 32 bytes long
+// to select AES-128, AES-192, or AES-256.
+func NewAndRegister(baseDir fs.File, key []byte) (*EncFileSystem, error) {
+	if baseDir == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	f := &EncFileSystem{
+		prefix: Prefix + fsimpl.RandomString(),
+		base:   baseDir,
+		aead:   aead,
+	}
+	_, err = fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// BaseDir returns the directory where the encrypted files are stored
+func (f *EncFileSystem) BaseDir() fs.File {
+	return f.base
+}
+
+// overhead is the number of bytes that are added to the file size
+func (f *EncFileSystem) overhead() int64 {
+	return int64(f.aead.NonceSize() + f.aead.Overhead())
+}
+
+func (f *EncFileSystem) encrypt(data []byte) ([]byte, error) {
+	nonce := make([]byte, f.aead.NonceSize(), f.aead.NonceSize()+len(data)+f.aead.Overhead())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return f.aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func (f *EncFileSystem) decrypt(data []byte) ([]byte, error) {
+	if len(data) < f.aead.NonceSize()+f.aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := data[:f.aead.NonceSize()], data[f.aead.NonceSize():]
+	plain, err := f.aead.Open(sealed[:0], nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if plain == nil {
+		plain = []byte{}
+	}
+	return plain, nil
+}
+
+// baseFile returns the file in the base directory for filePath
+func (f *EncFileSystem) baseFile(filePath string) fs.File {
+	return f.base.Join(f.SplitPath(filePath)...)
+}
+
+// fileInfo returns a copy of the FileInfo of a base file
+// for filePath with the size of the decrypted data
+func (f *EncFileSystem) fileInfo(filePath string, baseInfo *fs.FileInfo) *fs.FileInfo {
+	info := *baseInfo
+	info.File = f.File(filePath)
+	if !info.IsDir {
+		info.Size = max(info.Size-f.overhead(), 0)
+	}
+	return &info
+}
+
+func (f *EncFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *EncFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.base.FileSystem().ReadableWritable()
+}
+
+func (f *EncFileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+func (f *EncFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *EncFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *EncFileSystem) Name() string {
+	return "encrypted file system"
+}
+
+// String implements the fmt.Stringer interface.
+func (f *EncFileSystem) String() string {
+	return fmt.Sprintf("%s with prefix %s in %s", f.Name(), f.prefix, f.base)
+}
+
+func (f *EncFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *EncFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *EncFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *EncFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *EncFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *EncFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*EncFileSystem) Separator() string {
+	return Separator
+}
+
+func (*EncFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*EncFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (*EncFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (*EncFileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *EncFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	baseFile := f.baseFile(filePath)
+	stat, err := baseFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	baseInfo := fs.NewFileInfo(baseFile, stat, baseFile.IsHidden())
+	return f.fileInfo(filePath, baseInfo).StdFileInfo(), nil
+}
+
+func (f *EncFileSystem) Exists(filePath string) bool {
+	return filePath != "" && f.checkOpen() == nil && f.baseFile(filePath).Exists()
+}
+
+func (f *EncFileSystem) IsHidden(filePath string) bool {
+	return f.baseFile(filePath).IsHidden()
+}
+
+func (f *EncFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.baseFile(filePath).IsSymbolicLink()
+}
+
+func (f *EncFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(dirPath).ListDirInfoContext(ctx, func(info *fs.FileInfo) error {
+		return callback(f.fileInfo(path.Join(dirPath, info.Name), info))
+	}, patterns...)
+}
+
+func (f *EncFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(dirPath).MakeDir(perm...)
+}
+
+// Touch sets the modified time of an existing file
+// or creates an encrypted empty file.
+func (f *EncFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	baseFile := f.baseFile(filePath)
+	if baseFile.Exists() {
+		return baseFile.Touch(perm...)
+	}
+	return f.WriteAll(context.Background(), filePath, nil, perm)
+}
+
+func (f *EncFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	data, err := f.baseFile(filePath).ReadAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := f.decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, f.File(filePath))
+	}
+	return plain, nil
+}
+
+func (f *EncFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	sealed, err := f.encrypt(data)
+	if err != nil {
+		return err
+	}
+	return f.baseFile(filePath).WriteAllContext(ctx, sealed, perm...)
+}
+
+// Append decrypts the existing file, appends data
+// and encrypts the result with a new nonce.
+func (f *EncFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	existing, err := f.ReadAll(ctx, filePath)
+	if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return err
+	}
+	return f.WriteAll(ctx, filePath, append(existing, data...), perm)
+}
+
+func (f *EncFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	info, err := f.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	data, err := f.ReadAll(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	return fsimpl.NewReadonlyFileBuffer(data, info), nil
+}
+
+// OpenWriter buffers the written data in memory
+// and encrypts and writes it when the writer is closed.
+func (f *EncFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(nil, func() error {
+		return f.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// OpenReadWriter buffers the decrypted file in memory
+// and encrypts and writes it when closed.
+func (f *EncFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	data, err := f.ReadAll(context.Background(), filePath)
+	if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(data, func() error {
+		return f.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// CopyFile copies the encrypted data without decrypting it
+func (f *EncFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return fs.CopyFileBuf(ctx, f.baseFile(srcFile), f.baseFile(destFile), buf)
+}
+
+func (f *EncFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return "", err
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	_, err := f.baseFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(f.AbsPath(filePath)), newName), nil
+}
+
+func (f *EncFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).MoveTo(f.baseFile(destPath))
+}
+
+func (f *EncFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).Remove()
+}
+
+// Close unregisters the file system,
+// the base file system is not closed.
+func (f *EncFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
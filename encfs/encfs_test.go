@@ -0,0 +1,108 @@
+package encfs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestEncFileSystem(t *testing.T) {
+	baseDir := fs.File(t.TempDir())
+	encFS, err := NewAndRegister(baseDir, testKey)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = encFS.Close() })
+	ctx := context.Background()
+	root := encFS.RootDir()
+	require.True(t, fs.IsRegistered(encFS))
+	require.True(t, root.IsDir())
+
+	require.NoError(t, root.Join("dir").MakeDir())
+	file := root.Join("dir", "file.txt")
+	require.NoError(t, file.WriteAllString("Hello World!"))
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World!", str)
+	require.Equal(t, int64(12), file.Size(), "size of the decrypted data")
+
+	// Stored encrypted with a random nonce per write
+	stored, err := baseDir.Join("dir", "file.txt").ReadAll()
+	require.NoError(t, err)
+	require.Len(t, stored, 12+encFS.aead.NonceSize()+encFS.aead.Overhead())
+	require.NotContains(t, string(stored), "Hello")
+	require.NoError(t, file.WriteAllString("Hello World!"))
+	data, err := baseDir.Join("dir", "file.txt").ReadAll()
+	require.NoError(t, err)
+	require.NotEqual(t, stored, data, "new nonce per write")
+
+	require.NoError(t, file.AppendString(ctx, " Again"))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World! Again", str)
+
+	require.NoError(t, root.Join("empty.txt").Touch())
+	data, err = root.Join("empty.txt").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, data)
+	require.Equal(t, int64(0), root.Join("empty.txt").Size())
+
+	files, err := root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dir", "empty.txt"}, fs.FileNames(files))
+	require.Equal(t, encFS, files[0].FileSystem())
+
+	writer, err := root.Join("written.txt").OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("written"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	str, err = root.Join("written.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "written", str)
+
+	require.NoError(t, fs.CopyFile(ctx, file, root.Join("copy.txt")))
+	str, err = root.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World! Again", str)
+	renamed, err := root.Join("copy.txt").Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, root.Join("renamed.txt"), renamed)
+	require.NoError(t, renamed.MoveTo(root.Join("dir", "moved.txt")))
+	require.True(t, baseDir.Join("dir", "moved.txt").Exists())
+	require.NoError(t, root.Join("dir", "moved.txt").Remove())
+	require.False(t, root.Join("dir", "moved.txt").Exists())
+
+	_, err = root.Join("missing.txt").ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Modified data can't be decrypted
+	stored, err = baseDir.Join("dir", "file.txt").ReadAll()
+	require.NoError(t, err)
+	stored[len(stored)-1] ^= 1
+	require.NoError(t, baseDir.Join("dir", "file.txt").WriteAll(stored))
+	_, err = file.ReadAll()
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestEncFileSystem_WrongKey(t *testing.T) {
+	baseDir := fs.File(t.TempDir())
+	encFS, err := NewAndRegister(baseDir, testKey)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = encFS.Close() })
+	require.NoError(t, encFS.RootDir().Join("file.txt").WriteAllString("secret"))
+
+	otherFS, err := NewAndRegister(baseDir, bytes.Repeat([]byte{8}, 32))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = otherFS.Close() })
+	_, err = otherFS.RootDir().Join("file.txt").ReadAll()
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = NewAndRegister(baseDir, []byte("short"))
+	require.Error(t, err, "invalid key size")
+}
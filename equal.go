@@ -0,0 +1,193 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+const equalChunkSize = 64 * 1024
+
+// Equal returns if the files a and b, which can be
+// on different file systems, have identical content.
+//
+// Files with different sizes are not equal without reading them.
+// If both file systems implement HashFileSystem and know
+// a hash of the same algorithm, then the hashes are compared,
+// else both files are read and compared chunk by chunk
+// until the first difference.
+//
+// An error is returned if one of the files
+// does not exist or is a directory.
+func Equal(ctx context.Context, a, b File) (bool, error) {
+	infoA, err := a.InfoContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if infoA.IsDir {
+		return false, NewErrIsDirectory(a)
+	}
+	infoB, err := b.InfoContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if infoB.IsDir {
+		return false, NewErrIsDirectory(b)
+	}
+	if SameFile(a, b) {
+		return true, nil
+	}
+	if infoA.Size != infoB.Size {
+		return false, nil
+	}
+
+	equal, compared, err := equalKnownHashes(ctx, a, b)
+	if compared || err != nil {
+		return equal, err
+	}
+	return equalChunks(ctx, a, b)
+}
+
+// equalKnownHashes compares the hashes of a and b
+// if both file systems know a hash of the same algorithm
+// and returns if they could be compared.
+func equalKnownHashes(ctx context.Context, a, b File) (equal, compared bool, err error) {
+	fsA, pathA := a.ParseRawURI()
+	fsB, pathB := b.ParseRawURI()
+	hashFSA, okA := fsA.(HashFileSystem)
+	hashFSB, okB := fsB.(HashFileSystem)
+	if !okA || !okB {
+		return false, false, nil
+	}
+	for algo := range HashAlgorithm(numHashAlgorithms) {
+		hashA, err := hashFSA.Hash(ctx, pathA, algo)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return false, false, err
+		}
+		hashB, err := hashFSB.Hash(ctx, pathB, algo)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return hashA == hashB, true, nil
+	}
+	return false, false, nil
+}
+
+func equalChunks(ctx context.Context, a, b File) (bool, error) {
+	readerA, err := a.OpenReader()
+	if err != nil {
+		return false, err
+	}
+	defer readerA.Close()
+	readerB, err := b.OpenReader()
+	if err != nil {
+		return false, err
+	}
+	defer readerB.Close()
+
+	bufA := make([]byte, equalChunkSize)
+	bufB := make([]byte, equalChunkSize)
+	for {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		nA, errA := io.ReadFull(readerA, bufA)
+		nB, errB := io.ReadFull(readerB, bufB)
+		if !bytes.Equal(bufA[:nA], bufB[:nB]) {
+			return false, nil
+		}
+		endA := errors.Is(errA, io.EOF) || errors.Is(errA, io.ErrUnexpectedEOF)
+		endB := errors.Is(errB, io.EOF) || errors.Is(errB, io.ErrUnexpectedEOF)
+		switch {
+		case errA != nil && !endA:
+			return false, errA
+		case errB != nil && !endB:
+			return false, errB
+		case endA || endB:
+			// Equal chunks, so both ended
+			return endA && endB, nil
+		}
+	}
+}
+
+// EqualDirs compares the directories a and b recursively
+// and returns the sorted slash separated paths relative
+// to the directories of the files and directories
+// that differ between them.
+// The directories are equal if no paths are returned.
+//
+// A path differs if it exists only in one of the directories,
+// if it is a file in one and a directory in the other,
+// or if the content of the files is not Equal.
+// Sub-directories that exist only in one of the directories
+// are reported without their content.
+// File names are matched after normalization with NormalizeName
+// if enabled with SetNormalizeNames.
+func EqualDirs(ctx context.Context, a, b File) (diffs []string, err error) {
+	infosA, err := equalDirsScan(ctx, a)
+	if err != nil {
+		return nil, fmt.Errorf("EqualDirs: %w", err)
+	}
+	infosB, err := equalDirsScan(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("EqualDirs: %w", err)
+	}
+
+	relPaths := slices.Collect(maps.Keys(infosA))
+	relPaths = slices.AppendSeq(relPaths, maps.Keys(infosB))
+	slices.Sort(relPaths)
+	skipDirs := make(map[string]bool)
+	for _, relPath := range slices.Compact(relPaths) {
+		if skipDirs[path.Dir(relPath)] {
+			skipDirs[relPath] = true
+			continue
+		}
+		infoA, infoB := infosA[relPath], infosB[relPath]
+		switch {
+		case infoA == nil || infoB == nil || infoA.IsDir != infoB.IsDir:
+			diffs = append(diffs, relPath)
+			skipDirs[relPath] = true
+		case infoA.IsDir:
+			// Compare content of both directories
+		default:
+			equal, err := Equal(ctx, infoA.File, infoB.File)
+			if err != nil {
+				return nil, fmt.Errorf("EqualDirs: %s: %w", relPath, err)
+			}
+			if !equal {
+				diffs = append(diffs, relPath)
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// equalDirsScan returns the FileInfo of all files and directories
+// below dir by slash separated relative path
+func equalDirsScan(ctx context.Context, dir File) (map[string]*FileInfo, error) {
+	if err := dir.CheckIsDir(); err != nil {
+		return nil, err
+	}
+	infos := make(map[string]*FileInfo)
+	dirPrefix := strings.TrimSuffix(dir.PathWithSlashes(), "/") + "/"
+	err := dir.WalkContext(ctx, func(file File, info *FileInfo) error {
+		relPath, ok := strings.CutPrefix(file.PathWithSlashes(), dirPrefix)
+		if ok && relPath != "" {
+			infos[matchName(relPath)] = info
+		}
+		return nil
+	})
+	return infos, err
+}
@@ -0,0 +1,89 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	large := strings.Repeat("0123456789", equalChunkSize/5)
+	files := map[string]string{
+		"a.txt":      "Hello",
+		"b.txt":      "Hello",
+		"c.txt":      "World",
+		"d.txt":      "Hello World",
+		"large1.txt": large + "X",
+		"large2.txt": large + "X",
+		"large3.txt": large + "Y",
+		"empty1.txt": "",
+		"empty2.txt": "",
+	}
+	for name, content := range files {
+		require.NoError(t, dir.Join(name).WriteAllString(content))
+	}
+	sub, err := NewSubFileSystem(File(t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Close() })
+	require.NoError(t, sub.RootDir().Join("a.txt").WriteAllString("Hello"))
+
+	tests := []struct {
+		a, b File
+		want bool
+	}{
+		{a: dir.Join("a.txt"), b: dir.Join("a.txt"), want: true},
+		{a: dir.Join("a.txt"), b: dir.Join("b.txt"), want: true},
+		{a: dir.Join("a.txt"), b: dir.Join("c.txt"), want: false},
+		{a: dir.Join("a.txt"), b: dir.Join("d.txt"), want: false},
+		{a: dir.Join("large1.txt"), b: dir.Join("large2.txt"), want: true},
+		{a: dir.Join("large1.txt"), b: dir.Join("large3.txt"), want: false},
+		{a: dir.Join("empty1.txt"), b: dir.Join("empty2.txt"), want: true},
+		{a: dir.Join("a.txt"), b: sub.RootDir().Join("a.txt"), want: true},
+		{a: sub.RootDir().Join("a.txt"), b: dir.Join("c.txt"), want: false},
+	}
+	for _, tt := range tests {
+		equal, err := Equal(ctx, tt.a, tt.b)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, equal, "Equal(%s, %s)", tt.a, tt.b)
+	}
+
+	_, err = Equal(ctx, dir.Join("a.txt"), dir.Join("missing.txt"))
+	require.True(t, errors.As(err, new(ErrDoesNotExist)))
+	_, err = Equal(ctx, dir, dir.Join("a.txt"))
+	require.True(t, errors.As(err, new(ErrIsDirectory)))
+}
+
+func TestEqualDirs(t *testing.T) {
+	ctx := context.Background()
+	a := File(t.TempDir())
+	b := File(t.TempDir())
+	for _, dir := range []File{a, b} {
+		require.NoError(t, dir.Join("sub", "deep").MakeAllDirs())
+		require.NoError(t, dir.Join("same.txt").WriteAllString("same"))
+		require.NoError(t, dir.Join("sub", "deep", "same.txt").WriteAllString("same"))
+	}
+
+	diffs, err := EqualDirs(ctx, a, b)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	require.NoError(t, a.Join("sub", "changed.txt").WriteAllString("A"))
+	require.NoError(t, b.Join("sub", "changed.txt").WriteAllString("B"))
+	require.NoError(t, a.Join("only-a.txt").WriteAllString("A"))
+	require.NoError(t, b.Join("only-b", "nested").MakeAllDirs())
+	require.NoError(t, b.Join("only-b", "nested", "file.txt").WriteAllString("B"))
+	require.NoError(t, a.Join("kind").MakeDir())
+	require.NoError(t, b.Join("kind").WriteAllString("file"))
+
+	diffs, err = EqualDirs(ctx, a, b)
+	require.NoError(t, err)
+	require.Equal(t, []string{"kind", "only-a.txt", "only-b", "sub/changed.txt"}, diffs)
+
+	_, err = EqualDirs(ctx, a, b.Join("same.txt"))
+	require.Error(t, err)
+}
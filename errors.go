@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"syscall"
 )
 
 // SentinelError is used for const sentinel errors
@@ -27,8 +28,28 @@ const (
 	// ErrFileSystemClosed is returned after a file system Close method was called
 	ErrFileSystemClosed SentinelError = "file system is closed"
 
+	// ErrTooLarge is returned when more data than allowed was written
+	ErrTooLarge SentinelError = "data too large"
+
+	// ErrTooMany is returned when more files than
+	// allowed by ListDirMaxLimit would be listed
+	ErrTooMany SentinelError = "too many files"
+
+	// ErrPathOutsideRoot is returned for paths that use ".."
+	// to escape the root directory of a SubFileSystem
+	ErrPathOutsideRoot SentinelError = "path is outside of the file system root"
+
+	// ErrExtensionNotAllowed is returned by SaveUpload
+	// for names with an extension that is not allowed
+	ErrExtensionNotAllowed SentinelError = "file extension not allowed"
+
+	// ErrXAttrDoesNotExist is returned when an
+	// extended attribute of a file does not exist
+	ErrXAttrDoesNotExist SentinelError = "extended attribute does not exist"
+
 	ErrUnmarshalJSON SentinelError = "can't unmarshal JSON"
 	ErrMarshalJSON   SentinelError = "can't marshal JSON"
+	ErrValidateJSON  SentinelError = "invalid JSON content"
 
 	ErrUnmarshalXML SentinelError = "can't unmarshal XML"
 	ErrMarshalXML   SentinelError = "can't marshal XML"
@@ -219,6 +240,30 @@ func (err ErrIsNotDirectory) File() File {
 	return err.file
 }
 
+// normalizeIsDirectoryErr returns an ErrIsDirectory for file
+// if err reports with syscall.EISDIR that an operation
+// that needs a file failed because file is a directory,
+// so that all file systems return the same error
+// independent of how they report it.
+func normalizeIsDirectoryErr(file File, err error) error {
+	if errors.Is(err, syscall.EISDIR) && !errors.As(err, new(ErrIsDirectory)) {
+		return NewErrIsDirectory(file)
+	}
+	return err
+}
+
+// normalizeIsNotDirectoryErr returns an ErrIsNotDirectory for file
+// if err reports with syscall.ENOTDIR that an operation
+// that needs a directory failed because file is not a directory,
+// so that all file systems return the same error
+// independent of how they report it.
+func normalizeIsNotDirectoryErr(file File, err error) error {
+	if errors.Is(err, syscall.ENOTDIR) && !errors.As(err, new(ErrIsNotDirectory)) {
+		return NewErrIsNotDirectory(file)
+	}
+	return err
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // ErrUnsupported
 
@@ -239,3 +284,88 @@ func (err ErrUnsupported) Error() string {
 func (ErrUnsupported) Unwrap() error {
 	return errors.ErrUnsupported
 }
+
+///////////////////////////////////////////////////////////////////////////////
+// ErrPrefixCollision
+
+// ErrPrefixCollision is returned by Register when
+// a different file system is already registered
+// with the same prefix.
+// Check for this error type with:
+//
+//	errors.As(err, new(ErrPrefixCollision))
+type ErrPrefixCollision struct {
+	prefix     string
+	registered FileSystem
+	colliding  FileSystem
+}
+
+// NewErrPrefixCollision returns a new ErrPrefixCollision
+func NewErrPrefixCollision(prefix string, registered, colliding FileSystem) ErrPrefixCollision {
+	return ErrPrefixCollision{prefix, registered, colliding}
+}
+
+func (err ErrPrefixCollision) Error() string {
+	return fmt.Sprintf("prefix %q of %s is already registered for %s", err.prefix, err.colliding, err.registered)
+}
+
+// Prefix returns the colliding prefix
+func (err ErrPrefixCollision) Prefix() string {
+	return err.prefix
+}
+
+// Registered returns the file system that is registered with the prefix
+func (err ErrPrefixCollision) Registered() FileSystem {
+	return err.registered
+}
+
+// Colliding returns the file system that could not be registered
+func (err ErrPrefixCollision) Colliding() FileSystem {
+	return err.colliding
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// ErrQuotaExceeded
+
+// ErrQuotaExceeded is returned when an operation would exceed
+// a storage quota of a file system.
+// Check for this error type with:
+//
+//	errors.As(err, new(ErrQuotaExceeded))
+//
+// Implements http.Handler by responding with 507 Insufficient Storage.
+type ErrQuotaExceeded struct {
+	file  File
+	quota string
+	limit int64
+}
+
+// NewErrQuotaExceeded returns a new ErrQuotaExceeded
+// for an operation on file exceeding the limit
+// of the quota with the passed name like "bytes" or "files".
+func NewErrQuotaExceeded(file File, quota string, limit int64) ErrQuotaExceeded {
+	return ErrQuotaExceeded{file, quota, limit}
+}
+
+func (err ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("quota of %d %s exceeded: %s", err.limit, err.quota, err.file)
+}
+
+// File returns the file that error concerns
+func (err ErrQuotaExceeded) File() File {
+	return err.file
+}
+
+// Quota returns the name of the exceeded quota like "bytes" or "files"
+func (err ErrQuotaExceeded) Quota() string {
+	return err.quota
+}
+
+// Limit returns the limit of the exceeded quota
+func (err ErrQuotaExceeded) Limit() int64 {
+	return err.limit
+}
+
+func (err ErrQuotaExceeded) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusInsufficientStorage), http.StatusInsufficientStorage)
+}
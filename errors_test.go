@@ -28,3 +28,19 @@ func TestErrDoesNotExist(t *testing.T) {
 	assert.True(t, ok, "wrapped as ErrDoesNotExist")
 	assert.Equal(t, target, err, "wrapped as ErrDoesNotExist")
 }
+
+func TestErrIsDirectory(t *testing.T) {
+	dir := File(t.TempDir())
+
+	reader, err := dir.OpenReader()
+	assert.Nil(t, reader, "no reader for directory")
+	assert.True(t, errors.As(err, new(ErrIsDirectory)), "OpenReader of directory returns ErrIsDirectory")
+
+	_, err = dir.ReadAll()
+	assert.True(t, errors.As(err, new(ErrIsDirectory)), "ReadAll of directory returns ErrIsDirectory")
+
+	file := dir.Join("file.txt")
+	assert.NoError(t, file.WriteAllString("content"))
+	_, err = file.ListDirMax(-1)
+	assert.True(t, errors.As(err, new(ErrIsNotDirectory)), "ListDirMax of file returns ErrIsNotDirectory")
+}
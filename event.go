@@ -15,7 +15,7 @@ func (e Event) HasRemove() bool { return fsnotify.Op(e).Has(fsnotify.Remove) }
 func (e Event) HasRename() bool { return fsnotify.Op(e).Has(fsnotify.Rename) }
 func (e Event) HasChmod() bool  { return fsnotify.Op(e).Has(fsnotify.Chmod) }
 
-// Used only for testing
+// Used for testing and polling watches
 const (
 	eventCreate = Event(fsnotify.Create)
 	eventWrite  = Event(fsnotify.Write)
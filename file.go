@@ -196,6 +196,7 @@ func (file File) IsReadable() bool {
 	if readFS, _ := fileSystem.ReadableWritable(); !readFS {
 		return false
 	}
+	defer beginOp(fileSystem)()
 	info, err := fileSystem.Stat(filePath)
 	if err != nil {
 		return false
@@ -214,6 +215,7 @@ func (file File) IsWriteable() bool {
 	if _, writeFS := fileSystem.ReadableWritable(); !writeFS {
 		return false
 	}
+	defer beginOp(fileSystem)()
 	info, err := fileSystem.Stat(pathFile)
 	if err == nil {
 		return info.Mode().IsRegular() && (info.Mode()&0200 != 0)
@@ -233,19 +235,57 @@ func (file File) Stat() (iofs.FileInfo, error) {
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	defer beginOp(fileSystem)()
 	return fileSystem.Stat(path)
 }
 
 // Info returns FileInfo.
 //
 // Use File.Stat to get a standard library io/fs.FileInfo.
+//
+// Deprecated: Info returns a FileInfo with Exists set to false
+// for any error of the file system, so transient failures
+// like network errors can't be distinguished from non existing files.
+// New code should use File.InfoContext which returns the error.
 func (file File) Info() *FileInfo {
 	fileSystem, path := file.ParseRawURI()
+	defer beginOp(fileSystem)()
 	info, err := fileSystem.Stat(path)
 	if err != nil {
 		return NewNonExistingFileInfo(file)
 	}
-	return NewFileInfo(file, info, fileSystem.IsHidden(path))
+	return NewFileInfo(file, info, isHidden(fileSystem, path))
+}
+
+// InfoContext returns the FileInfo of an existing file
+// or the error of the file system.
+// An error matching ErrDoesNotExist is returned
+// if the file does not exist.
+//
+// Use File.Stat to get a standard library io/fs.FileInfo.
+func (file File) InfoContext(ctx context.Context) (*FileInfo, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fileSystem, path := file.ParseRawURI()
+	endOp, err := beginOpContext(ctx, fileSystem)
+	if err != nil {
+		return nil, err
+	}
+	defer endOp()
+	var info iofs.FileInfo
+	if statFS, ok := fileSystem.(StatContextFileSystem); ok {
+		info, err = statFS.StatContext(ctx, path)
+	} else {
+		info, err = fileSystem.Stat(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewFileInfo(file, info, isHidden(fileSystem, path)), nil
 }
 
 // InfoWithContentHash returns a FileInfo, but in contrast to Stat
@@ -278,6 +318,7 @@ func (file File) Info() *FileInfo {
 // Exists returns a file or directory with the path of File exists.
 func (file File) Exists() bool {
 	fileSystem, path := file.ParseRawURI()
+	defer beginOp(fileSystem)()
 	if fs, ok := fileSystem.(ExistsFileSystem); ok {
 		return fs.Exists(path)
 	}
@@ -326,11 +367,25 @@ func (file File) CheckIsDir() error {
 
 // AbsPath returns the absolute path of the file
 // depending on the file system.
+// Use AbsPathErr to get errors from file systems
+// that can't always make a path absolute.
 func (file File) AbsPath() string {
 	fileSystem, path := file.ParseRawURI()
 	return fileSystem.AbsPath(path)
 }
 
+// AbsPathErr returns the absolute path of the file
+// depending on the file system or an error
+// if the file system implements AbsPathErrFileSystem
+// and can't make the path absolute.
+func (file File) AbsPathErr() (string, error) {
+	fileSystem, path := file.ParseRawURI()
+	if absFS, ok := fileSystem.(AbsPathErrFileSystem); ok {
+		return absFS.AbsPathErr(path)
+	}
+	return fileSystem.AbsPath(path), nil
+}
+
 // HasAbsPath returns wether the file has an absolute
 // path depending on the file system.
 func (file File) HasAbsPath() bool {
@@ -340,12 +395,37 @@ func (file File) HasAbsPath() bool {
 
 // ToAbsPath returns the file with an absolute
 // path depending on the file system.
+// Use ToAbsPathErr to get errors from file systems
+// that can't always make a path absolute.
 func (file File) ToAbsPath() File {
 	fileSystem, path := file.ParseRawURI()
 	uri := fileSystem.Prefix() + fileSystem.AbsPath(path)
 	return File(strings.TrimPrefix(uri, LocalPrefix))
 }
 
+// ToAbsPathErr returns the file with an absolute
+// path depending on the file system
+// or the error from File.AbsPathErr.
+func (file File) ToAbsPathErr() (File, error) {
+	absPath, err := file.AbsPathErr()
+	if err != nil {
+		return "", err
+	}
+	uri := file.FileSystem().Prefix() + absPath
+	return File(strings.TrimPrefix(uri, LocalPrefix)), nil
+}
+
+// MustToAbsPath returns the file with an absolute
+// path depending on the file system
+// or panics if that's not possible.
+func (file File) MustToAbsPath() File {
+	absFile, err := file.ToAbsPathErr()
+	if err != nil {
+		panic(err)
+	}
+	return absFile
+}
+
 // IsRegular reports if this is a regular file.
 func (file File) IsRegular() bool {
 	stat, err := file.Stat()
@@ -363,13 +443,15 @@ func (file File) IsEmptyDir() bool {
 
 // IsHidden returns true if the filename begins with a dot,
 // or if on Windows the hidden file attribute is set.
+// A different behavior can be configured per file system
+// with SetHiddenFunc.
 func (file File) IsHidden() bool {
 	fileSystem, path := file.ParseRawURI()
-	return fileSystem.IsHidden(path)
+	return isHidden(fileSystem, path)
 }
 
-// IsSymbolicLink returns if the file is a symbolic link
-// Use LocalFileSystem.CreateSymbolicLink and LocalFileSystem.ReadSymbolicLink
+// IsSymbolicLink returns if the file is a symbolic link.
+// Use File.CreateSymbolicLinkTo and File.ReadSymbolicLink
 // to handle symbolic links.
 func (file File) IsSymbolicLink() bool {
 	fileSystem, path := file.ParseRawURI()
@@ -434,6 +516,7 @@ func (file File) SetPermissions(perm Permissions) error {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(PermissionsFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.SetPermissions(path, perm)
 	}
 	return NewErrUnsupported(fileSystem, "SetPermissions")
@@ -456,7 +539,13 @@ func (file File) ListDirContext(ctx context.Context, callback func(File) error,
 		return ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
-	return fileSystem.ListDirInfo(ctx, path, FileInfoToFileCallback(callback), patterns)
+	infoCallback, endOp, err := beginListDirOp(ctx, fileSystem, FileInfoToFileCallback(callback))
+	if err != nil {
+		return err
+	}
+	defer endOp()
+	err = fileSystem.ListDirInfo(ctx, path, infoCallback, patterns)
+	return normalizeIsNotDirectoryErr(file, err)
 }
 
 // ListDirIter returns an iterator that yields every file and directory in the directory.
@@ -505,7 +594,19 @@ func (file File) ListDirIterContext(ctx context.Context, patterns ...string) ite
 // if the pattern contains wildcards for the last segment.
 // Non wildcard segments are not included.
 //
-// The syntax of patterns is the same as in [path.Match].
+// The syntax of patterns is the same as in [path.Match]
+// with the addition of "**" as complete path segment
+// matching any number of directories including none,
+// like in "src/**/*.go". The yielded value for a "**" segment
+// is the slash separated path of the matched directories.
+// A trailing "**" matches all files and directories recursively.
+// Alternatives separated by commas within curly braces
+// like in "logs/{app,db}/*.{log,txt}" are expanded to
+// multiple patterns and files matched by more than one
+// of them are yielded only once with the values
+// of the first expanded pattern that matched.
+// A character class can be negated with '^' or '!'
+// like in "[!a-z]".
 // It always uses slash '/' as path segment separator
 // independently of the file's file system.
 //
@@ -529,7 +630,19 @@ func MustGlob(pattern string) iter.Seq2[File, []string] {
 // if the pattern contains wildcards for the last segment.
 // Non wildcard segments are not included.
 //
-// The syntax of patterns is the same as in [path.Match].
+// The syntax of patterns is the same as in [path.Match]
+// with the addition of "**" as complete path segment
+// matching any number of directories including none,
+// like in "src/**/*.go". The yielded value for a "**" segment
+// is the slash separated path of the matched directories.
+// A trailing "**" matches all files and directories recursively.
+// Alternatives separated by commas within curly braces
+// like in "logs/{app,db}/*.{log,txt}" are expanded to
+// multiple patterns and files matched by more than one
+// of them are yielded only once with the values
+// of the first expanded pattern that matched.
+// A character class can be negated with '^' or '!'
+// like in "[!a-z]".
 // It always uses slash '/' as path segment separator
 // independently of the file's file system.
 //
@@ -558,7 +671,19 @@ func (file File) MustGlob(pattern string) iter.Seq2[File, []string] {
 // if the pattern contains wildcards for the last segment.
 // Non wildcard segments are not included.
 //
-// The syntax of patterns is the same as in [path.Match].
+// The syntax of patterns is the same as in [path.Match]
+// with the addition of "**" as complete path segment
+// matching any number of directories including none,
+// like in "src/**/*.go". The yielded value for a "**" segment
+// is the slash separated path of the matched directories.
+// A trailing "**" matches all files and directories recursively.
+// Alternatives separated by commas within curly braces
+// like in "logs/{app,db}/*.{log,txt}" are expanded to
+// multiple patterns and files matched by more than one
+// of them are yielded only once with the values
+// of the first expanded pattern that matched.
+// A character class can be negated with '^' or '!'
+// like in "[!a-z]".
 // It always uses slash '/' as path segment separator
 // independently of the file's file system.
 //
@@ -568,21 +693,36 @@ func (file File) MustGlob(pattern string) iter.Seq2[File, []string] {
 // The only possible returned error is [path.ErrBadPattern],
 // reporting that the pattern is malformed.
 func Glob(pattern string) (iter.Seq2[File, []string], error) {
+	patterns, err := expandGlobPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	iters := make([]iter.Seq2[File, []string], len(patterns))
+	for i, p := range patterns {
+		iters[i], err = globPattern(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uniqueGlobResults(iters), nil
+}
+
+func globPattern(pattern string) (iter.Seq2[File, []string], error) {
 	// Find the first wildcard
 	i := strings.IndexAny(pattern, `*?[\`)
 	if i == -1 {
 		// No wildcard in pattern, yield the pattern as File
-		return File(path.Clean(pattern)).Glob("")
+		return File(path.Clean(pattern)).globPattern("")
 	}
 	// Find the last path separator before the first wildcard
 	i = strings.LastIndexByte(pattern[:i], '/')
 	if i == -1 {
 		// No path separator before the first wildcard
 		// means that the pattern is relative to the current directory
-		return CurrentWorkingDir().Glob(pattern)
+		return CurrentWorkingDir().globPattern(pattern)
 	}
 	// Split pattern into base directory and glob pattern
-	return File(pattern[:i+1]).Glob(pattern[i+1:])
+	return File(pattern[:i+1]).globPattern(pattern[i+1:])
 }
 
 // Glob yields files and wildcard substituting path segments
@@ -595,7 +735,19 @@ func Glob(pattern string) (iter.Seq2[File, []string], error) {
 // if the pattern contains wildcards for the last segment.
 // Non wildcard segments are not included.
 //
-// The syntax of patterns is the same as in [path.Match].
+// The syntax of patterns is the same as in [path.Match]
+// with the addition of "**" as complete path segment
+// matching any number of directories including none,
+// like in "src/**/*.go". The yielded value for a "**" segment
+// is the slash separated path of the matched directories.
+// A trailing "**" matches all files and directories recursively.
+// Alternatives separated by commas within curly braces
+// like in "logs/{app,db}/*.{log,txt}" are expanded to
+// multiple patterns and files matched by more than one
+// of them are yielded only once with the values
+// of the first expanded pattern that matched.
+// A character class can be negated with '^' or '!'
+// like in "[!a-z]".
 // It always uses slash '/' as path segment separator
 // independently of the file's file system.
 //
@@ -605,6 +757,21 @@ func Glob(pattern string) (iter.Seq2[File, []string], error) {
 // The only possible returned error is [path.ErrBadPattern],
 // reporting that the pattern is malformed.
 func (file File) Glob(pattern string) (iter.Seq2[File, []string], error) {
+	patterns, err := expandGlobPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	iters := make([]iter.Seq2[File, []string], len(patterns))
+	for i, p := range patterns {
+		iters[i], err = file.globPattern(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uniqueGlobResults(iters), nil
+}
+
+func (file File) globPattern(pattern string) (iter.Seq2[File, []string], error) {
 	onlyDirs := strings.HasSuffix(pattern, "/")
 	pattern = strings.Trim(pattern, "/")
 	// Check if the pattern is valid
@@ -636,7 +803,92 @@ func containsWildcard(pattern string) bool {
 	return strings.ContainsAny(pattern, `*?[\`)
 }
 
+// expandGlobPattern returns the patterns resulting from
+// the expansion of {a,b,c} alternations in pattern
+// with "[!" character class negations replaced by "[^".
+func expandGlobPattern(pattern string) ([]string, error) {
+	var (
+		escaped bool
+		inClass bool
+		start   = -1
+		depth   int
+		commas  []int
+	)
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			if i+1 < len(pattern) && pattern[i+1] == '!' {
+				pattern = pattern[:i+1] + "^" + pattern[i+2:]
+			}
+		case c == '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}' && depth > 0:
+			depth--
+			if depth > 0 {
+				continue
+			}
+			// Expand the first top level alternation
+			// and recurse for the rest of the pattern
+			// which might contain more alternations
+			prefix, suffix := pattern[:start], pattern[i+1:]
+			bounds := append(append([]int{start}, commas...), i)
+			var expanded []string
+			for j := 1; j < len(bounds); j++ {
+				alternative := pattern[bounds[j-1]+1 : bounds[j]]
+				patterns, err := expandGlobPattern(prefix + alternative + suffix)
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, patterns...)
+			}
+			return expanded, nil
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("%w, unclosed '{': %s", path.ErrBadPattern, pattern)
+	}
+	return []string{pattern}, nil
+}
+
+// uniqueGlobResults yields the results of iters
+// without yielding a file more than once.
+func uniqueGlobResults(iters []iter.Seq2[File, []string]) iter.Seq2[File, []string] {
+	if len(iters) == 1 {
+		return iters[0]
+	}
+	return func(yield func(File, []string) bool) {
+		yielded := make(map[File]struct{})
+		for _, it := range iters {
+			for file, values := range it {
+				if _, ok := yielded[file]; ok {
+					continue
+				}
+				yielded[file] = struct{}{}
+				if !yield(file, values) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func (file File) glob(onlyDirs bool, segments, values []string) iter.Seq2[File, []string] {
+	if len(segments) > 0 && segments[0] == "**" {
+		return file.globDoublestar(onlyDirs, segments[1:], values, "")
+	}
 	return func(yield func(File, []string) bool) {
 		switch len(segments) {
 		case 0:
@@ -695,6 +947,48 @@ func (file File) glob(onlyDirs bool, segments, values []string) iter.Seq2[File,
 	}
 }
 
+// globDoublestar yields the files matching the segments
+// after a "**" segment in file or any of its sub-directories.
+// matched is the slash separated path of the directories
+// within the directory of the "**" segment matched so far
+// and will be used as value for the "**" segment.
+// Symbolic links to directories are not followed to avoid cycles.
+func (file File) globDoublestar(onlyDirs bool, segments, values []string, matched string) iter.Seq2[File, []string] {
+	return func(yield func(File, []string) bool) {
+		// "**" matches zero more directories
+		for f, v := range file.glob(onlyDirs, segments, append(slices.Clone(values), matched)) {
+			if !yield(f, v) {
+				return
+			}
+		}
+		// "**" matches one more directory
+		for entry, err := range file.ListDirIter() {
+			// If file is not a directory then ErrIsNotDirectory is expected
+			if err != nil {
+				return
+			}
+			entryMatched := path.Join(matched, entry.Name())
+			if entry.IsDir() {
+				if entry.IsSymbolicLink() {
+					continue
+				}
+				for f, v := range entry.globDoublestar(onlyDirs, segments, values, entryMatched) {
+					if !yield(f, v) {
+						return
+					}
+				}
+				continue
+			}
+			// Files are only matched by a trailing "**"
+			if len(segments) == 0 && !onlyDirs {
+				if !yield(entry, append(slices.Clone(values), entryMatched)) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // ListDirInfo calls the passed callback function for every file and directory in dirPath.
 // If any patterns are passed, then only files with a name that matches
 // at least one of the patterns are returned.
@@ -710,7 +1004,48 @@ func (file File) ListDirInfoContext(ctx context.Context, callback func(*FileInfo
 		return ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
-	return fileSystem.ListDirInfo(ctx, path, callback, patterns)
+	callback, endOp, err := beginListDirOp(ctx, fileSystem, hiddenFileInfoCallback(fileSystem, callback))
+	if err != nil {
+		return err
+	}
+	defer endOp()
+	err = fileSystem.ListDirInfo(ctx, path, callback, patterns)
+	return normalizeIsNotDirectoryErr(file, err)
+}
+
+// ListDirInfoIter returns an iterator that yields the FileInfo
+// of every file and directory in the directory.
+// If any patterns are passed, then only files with a name that matches
+// at least one of the patterns are returned.
+// In case of an error, the iterator will yield nil and the error
+// as last key and value and then stop the iteration.
+func (file File) ListDirInfoIter(patterns ...string) iter.Seq2[*FileInfo, error] {
+	return file.ListDirInfoIterContext(context.Background(), patterns...)
+}
+
+// ListDirInfoIterContext returns an iterator that yields the FileInfo
+// of every file and directory in the directory.
+// If any patterns are passed, then only files with a name that matches
+// at least one of the patterns are returned.
+// In case of an error, the iterator will yield nil and the error
+// as last key and value and then stop the iteration.
+// Canceling the context will stop the iteration and yield the context error.
+func (file File) ListDirInfoIterContext(ctx context.Context, patterns ...string) iter.Seq2[*FileInfo, error] {
+	return func(yield func(*FileInfo, error) bool) {
+		var cancel SentinelError
+		err := file.ListDirInfoContext(ctx,
+			func(info *FileInfo) error {
+				if !yield(info, nil) {
+					return cancel
+				}
+				return nil
+			},
+			patterns...,
+		)
+		if err != nil && !errors.Is(err, cancel) {
+			yield(nil, err)
+		}
+	}
 }
 
 // ListDirRecursive returns only files.
@@ -777,14 +1112,25 @@ func (file File) ListDirInfoRecursiveContext(ctx context.Context, callback func(
 		return ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	callback, endOp, err := beginListDirOp(ctx, fileSystem, hiddenFileInfoCallback(fileSystem, callback))
+	if err != nil {
+		return err
+	}
+	defer endOp()
 	if fs, ok := fileSystem.(ListDirRecursiveFileSystem); ok {
-		return fs.ListDirInfoRecursive(ctx, path, callback, patterns)
+		err = fs.ListDirInfoRecursive(ctx, path, callback, patterns)
+	} else {
+		err = listDirInfoRecursive(ctx, fileSystem, path, callback, patterns)
 	}
-	return fileSystem.ListDirInfo(ctx, path,
+	return normalizeIsNotDirectoryErr(file, err)
+}
+
+func listDirInfoRecursive(ctx context.Context, fileSystem FileSystem, dirPath string, callback func(*FileInfo) error, patterns []string) error {
+	return fileSystem.ListDirInfo(ctx, dirPath,
 		func(info *FileInfo) error {
 			if info.IsDir {
 				// Not returning directories, but recursing into them
-				err := info.File.ListDirInfoRecursiveContext(ctx, callback, patterns...)
+				err := listDirInfoRecursive(ctx, fileSystem, info.File.Path(), callback, patterns)
 				// Don't mind files that have been deleted while iterating
 				return RemoveErrDoesNotExist(err)
 			}
@@ -799,7 +1145,9 @@ func (file File) ListDirInfoRecursiveContext(ctx context.Context, callback func(
 }
 
 // ListDirMax returns at most max files and directories in dirPath.
-// A max value of -1 returns all files.
+// A max value of -1 returns all files, limited by ListDirMaxLimit,
+// use ListDirIter or ListDirInfoIter to process large directories
+// without holding all files in memory.
 // If any patterns are passed, then only files or directories with a name that matches
 // at least one of the patterns are returned.
 func (file File) ListDirMax(max int, patterns ...string) (files []File, err error) {
@@ -807,19 +1155,33 @@ func (file File) ListDirMax(max int, patterns ...string) (files []File, err erro
 }
 
 // ListDirMaxContext returns at most max files and directories in dirPath.
-// A max value of -1 returns all files.
+// A max value of -1 returns all files, limited by ListDirMaxLimit,
+// use ListDirIterContext or ListDirInfoIterContext to process large directories
+// without holding all files in memory.
 // If any patterns are passed, then only files or directories with a name that matches
 // at least one of the patterns are returned.
 func (file File) ListDirMaxContext(ctx context.Context, max int, patterns ...string) (files []File, err error) {
 	if file == "" {
 		return nil, ErrEmptyPath
 	}
+	return listDirMaxLimited(file, max, func(max int) ([]File, error) {
+		return file.listDirMax(ctx, max, patterns)
+	})
+}
+
+func (file File) listDirMax(ctx context.Context, max int, patterns []string) (files []File, err error) {
 	if max == 0 {
 		return nil, nil
 	}
 	fileSystem, path := file.ParseRawURI()
+	endOp, err := beginOpContext(ctx, fileSystem)
+	if err != nil {
+		return nil, err
+	}
+	defer endOp()
 	if fs, ok := fileSystem.(ListDirMaxFileSystem); ok {
-		return fs.ListDirMax(ctx, path, max, patterns)
+		files, err = fs.ListDirMax(ctx, path, max, patterns)
+		return files, normalizeIsNotDirectoryErr(file, err)
 	}
 	done := errors.New("done") // used as an internal flag, won't be returned
 	err = fileSystem.ListDirInfo(ctx, path, func(info *FileInfo) error {
@@ -838,21 +1200,29 @@ func (file File) ListDirMaxContext(ctx context.Context, max int, patterns ...str
 		return nil
 	}, patterns)
 	if err != nil && !errors.Is(err, done) {
-		return nil, err
+		return nil, normalizeIsNotDirectoryErr(file, err)
 	}
 	return files, nil
 }
 
+// ListDirRecursiveMax returns at most max files
+// in the directory and all its sub-directories.
+// A max value of -1 returns all files, limited by ListDirMaxLimit.
 func (file File) ListDirRecursiveMax(max int, patterns ...string) (files []File, err error) {
 	return file.ListDirRecursiveMaxContext(context.Background(), max, patterns...)
 }
 
+// ListDirRecursiveMaxContext returns at most max files
+// in the directory and all its sub-directories.
+// A max value of -1 returns all files, limited by ListDirMaxLimit.
 func (file File) ListDirRecursiveMaxContext(ctx context.Context, max int, patterns ...string) (files []File, err error) {
 	if file == "" {
 		return nil, ErrEmptyPath
 	}
-	return listDirMaxImpl(ctx, max, func(ctx context.Context, callback func(File) error) error {
-		return file.ListDirRecursiveContext(ctx, callback, patterns...)
+	return listDirMaxLimited(file, max, func(max int) ([]File, error) {
+		return listDirMaxImpl(ctx, max, func(ctx context.Context, callback func(File) error) error {
+			return file.ListDirRecursiveContext(ctx, callback, patterns...)
+		})
 	})
 }
 
@@ -918,6 +1288,7 @@ func (file File) User() (string, error) {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(UserFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.User(path)
 	}
 	return "", NewErrUnsupported(fileSystem, "User")
@@ -929,6 +1300,7 @@ func (file File) SetUser(user string) error {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(UserFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.SetUser(path, user)
 	}
 	return NewErrUnsupported(fileSystem, "SetUser")
@@ -940,6 +1312,7 @@ func (file File) Group() (string, error) {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(GroupFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.Group(path)
 	}
 	return "", NewErrUnsupported(fileSystem, "Group")
@@ -951,6 +1324,7 @@ func (file File) SetGroup(group string) error {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(GroupFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.SetGroup(path, group)
 	}
 	return NewErrUnsupported(fileSystem, "SetGroup")
@@ -962,6 +1336,7 @@ func (file File) Touch(perm ...Permissions) error {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(TouchFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.Touch(path, perm)
 	}
 	w, err := file.OpenWriter(perm...)
@@ -981,6 +1356,7 @@ func (file File) MakeDir(perm ...Permissions) error {
 		return nil
 	}
 	fileSystem, path := file.ParseRawURI()
+	defer beginOp(fileSystem)()
 	return fileSystem.MakeDir(path, perm)
 }
 
@@ -1048,7 +1424,12 @@ func (file File) OpenReader() (ReadCloser, error) {
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
-	return fileSystem.OpenReader(path)
+	defer beginOp(fileSystem)()
+	reader, err := fileSystem.OpenReader(path)
+	if err != nil {
+		return nil, normalizeIsDirectoryErr(file, err)
+	}
+	return reader, nil
 }
 
 // OpenReadSeeker opens the file and returns a ReadSeekCloser.
@@ -1060,7 +1441,9 @@ func (file File) OpenReadSeeker() (ReadSeekCloser, error) {
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	endOp := beginOp(fileSystem)
 	readCloser, err := fileSystem.OpenReader(path)
+	endOp()
 	if err != nil {
 		return nil, err
 	}
@@ -1075,21 +1458,59 @@ func (file File) OpenReadSeeker() (ReadSeekCloser, error) {
 	return fsimpl.NewReadonlyFileBufferReadAll(readCloser, info)
 }
 
+// OpenWriter opens the file for writing and returns a WriteCloser
+// that has to be closed after writing.
+// An existing file will be truncated.
+//
+// The returned writer can be closed multiple times,
+// only the first call closes the file.
+// Writers of the local file system are returned as *os.File,
+// writers of other file systems implement Flusher to write
+// data buffered by the FileSystem implementation.
+// Use NotifyCloseError to not lose errors from deferred Close calls.
 func (file File) OpenWriter(perm ...Permissions) (WriteCloser, error) {
 	if file == "" {
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
-	return fileSystem.OpenWriter(path, perm)
+	endOp := beginOp(fileSystem)
+	w, err := fileSystem.OpenWriter(path, perm)
+	endOp()
+	if err != nil {
+		return nil, err
+	}
+	return newFileWriter(w), nil
 }
 
+// OpenAppendWriter opens the file for appending and returns a WriteCloser
+// that has to be closed after writing.
+//
+// The returned writer can be closed multiple times,
+// only the first call closes the file.
+// Writers of the local file system are returned as *os.File,
+// writers of other file systems implement Flusher to write
+// data buffered by the FileSystem implementation.
+// Use NotifyCloseError to not lose errors from deferred Close calls.
+//
+// If the FileSystem implementation doesn't support append writers,
+// then the file is read into a buffer that is written back on close,
+// or an ErrUnsupported error is returned in strict mode, see SetStrict.
 func (file File) OpenAppendWriter(perm ...Permissions) (WriteCloser, error) {
 	if file == "" {
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(AppendWriterFileSystem); ok {
-		return fs.OpenAppendWriter(path, perm)
+		endOp := beginOp(fileSystem)
+		w, err := fs.OpenAppendWriter(path, perm)
+		endOp()
+		if err != nil {
+			return nil, err
+		}
+		return newFileWriter(w), nil
+	}
+	if IsStrict(fileSystem) {
+		return nil, NewErrUnsupported(fileSystem, "OpenAppendWriter")
 	}
 	// Emulate append writer by reading file into
 	// a buffer first and write everything back to
@@ -1102,8 +1523,39 @@ func (file File) OpenAppendWriter(perm ...Permissions) (WriteCloser, error) {
 	fileBuffer = fsimpl.NewFileBufferWithClose(current, func() error {
 		return file.WriteAll(fileBuffer.Bytes(), perm...)
 	})
-	return fileBuffer, nil
+	return newFileWriter(fileBuffer), nil
+}
 
+// OpenWriteSeeker opens the file for writing at random positions
+// and returns a WriteSeekCloser that has to be closed after writing.
+// An existing file will be truncated.
+// If the FileSystem implementation doesn't support WriteSeekerFileSystem,
+// then the data is written into a memory buffer
+// that will be written to the file when the WriteSeekCloser is closed.
+// Warning: this can use up a lot of memory for big files.
+// In strict mode an ErrUnsupported error is returned instead, see SetStrict.
+func (file File) OpenWriteSeeker(perm ...Permissions) (WriteSeekCloser, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	if fs, ok := fileSystem.(WriteSeekerFileSystem); ok {
+		defer beginOp(fileSystem)()
+		return fs.OpenWriteSeeker(path, perm)
+	}
+	if _, writable := fileSystem.ReadableWritable(); !writable {
+		return nil, ErrReadOnlyFileSystem
+	}
+	if IsStrict(fileSystem) {
+		return nil, NewErrUnsupported(fileSystem, "OpenWriteSeeker")
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(nil, func() error {
+		return file.WriteAll(fileBuffer.Bytes(), perm...)
+	})
+	// Overwritten files often have a similar size
+	fileBuffer.Grow(int(file.Size()))
+	return fileBuffer, nil
 }
 
 func (file File) OpenReadWriter(perm ...Permissions) (ReadWriteSeekCloser, error) {
@@ -1111,6 +1563,7 @@ func (file File) OpenReadWriter(perm ...Permissions) (ReadWriteSeekCloser, error
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	defer beginOp(fileSystem)()
 	return fileSystem.OpenReadWriter(path, perm)
 }
 
@@ -1119,21 +1572,53 @@ func (file File) ReadAll() (data []byte, err error) {
 	return file.ReadAllContext(context.Background())
 }
 
-// ReadAllContext reads and returns all bytes of the file
+// ReadAllContext reads and returns all bytes of the file.
+// The progress of the read is reported to a ProgressFunc
+// set with ContextWithProgress.
 func (file File) ReadAllContext(ctx context.Context) (data []byte, err error) {
 	if file == "" {
 		return nil, ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	return readAll(ctx, fileSystem, path, file)
+}
+
+// readAll implements File.ReadAllContext and BoundFile.ReadAll
+func readAll(ctx context.Context, fileSystem FileSystem, path string, file File) (data []byte, err error) {
+	endOp, err := beginOpContext(ctx, fileSystem)
+	if err != nil {
+		return nil, err
+	}
+	defer endOp()
+	defer func() {
+		err = normalizeIsDirectoryErr(file, err)
+	}()
 	if fs, ok := fileSystem.(ReadAllFileSystem); ok {
-		return fs.ReadAll(ctx, path)
+		data, err = fs.ReadAll(ctx, path)
+		if err == nil {
+			reportProgressDone(ctx, file, "", int64(len(data)))
+		}
+		return data, err
 	}
 	r, err := fileSystem.OpenReader(path)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Close()
-	return ReadAllContext(ctx, r)
+	onProgress := ProgressFromContext(ctx)
+	if onProgress == nil {
+		return ReadAllContext(ctx, r)
+	}
+	total := int64(-1)
+	if info, err := r.Stat(); err == nil {
+		total = info.Size()
+	}
+	progressR := newProgressReader(r, onProgress, file, "", total)
+	data, err = ReadAllContext(ctx, progressR)
+	if err == nil {
+		progressR.done()
+	}
+	return data, err
 }
 
 // ReadAllContentHash reads and returns all bytes of the file
@@ -1173,15 +1658,36 @@ func (file File) WriteAllContext(ctx context.Context, data []byte, perm ...Permi
 		return ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	return writeAll(ctx, fileSystem, path, data, perm)
+}
+
+// writeAll implements File.WriteAllContext and BoundFile.WriteAll
+func writeAll(ctx context.Context, fileSystem FileSystem, path string, data []byte, perm []Permissions) (err error) {
+	endOp, err := beginOpContext(ctx, fileSystem)
+	if err != nil {
+		return err
+	}
+	defer endOp()
+	defer func() {
+		err = normalizeIsDirectoryErr(fileSystem.JoinCleanFile(path), err)
+	}()
 	if fs, ok := fileSystem.(WriteAllFileSystem); ok {
 		return fs.WriteAll(ctx, path, data, perm)
 	}
-	w, err := fileSystem.OpenReadWriter(path, perm)
+	w, err := fileSystem.OpenWriter(path, perm)
+	if err != nil {
+		return err
+	}
+	err = WriteAllContext(ctx, w, data)
+	if e := w.Close(); err == nil {
+		err = e
+	}
 	if err != nil {
+		// Don't leave a partially written file behind
+		_ = fileSystem.Remove(path)
 		return err
 	}
-	defer w.Close()
-	return WriteAllContext(ctx, w, data)
+	return nil
 }
 
 func (file File) WriteAllString(str string, perm ...Permissions) error {
@@ -1195,15 +1701,29 @@ func (file File) WriteAllStringContext(ctx context.Context, str string, perm ...
 	return file.WriteAllContext(ctx, []byte(str), perm...)
 }
 
+// Append appends data to the file.
+// If the FileSystem implementation doesn't support appending,
+// then the whole file is read and written again with data appended,
+// or an ErrUnsupported error is returned in strict mode, see SetStrict.
 func (file File) Append(ctx context.Context, data []byte, perm ...Permissions) error {
 	if file == "" {
 		return ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(AppendFileSystem); ok {
+		endOp, err := beginOpContext(ctx, fileSystem)
+		if err != nil {
+			return err
+		}
+		defer endOp()
 		return fs.Append(ctx, path, data, perm)
 	}
 	if fs, ok := fileSystem.(AppendWriterFileSystem); ok {
+		endOp, err := beginOpContext(ctx, fileSystem)
+		if err != nil {
+			return err
+		}
+		defer endOp()
 		w, err := fs.OpenAppendWriter(path, perm)
 		if err != nil {
 			return err
@@ -1211,6 +1731,9 @@ func (file File) Append(ctx context.Context, data []byte, perm ...Permissions) e
 		defer w.Close()
 		return WriteAllContext(ctx, w, data)
 	}
+	if isStrict(ctx, fileSystem) {
+		return NewErrUnsupported(fileSystem, "Append")
+	}
 	// Emulate append by first reading all file
 	// content and then writing the file with
 	// appended data.
@@ -1243,11 +1766,17 @@ func (file File) Watch(onEvent func(File, Event)) (cancel func() error, err erro
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(WatchFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.Watch(path, onEvent)
 	}
 	return nil, NewErrUnsupported(fileSystem, "Watch")
 }
 
+// Truncate resizes the file to newSize,
+// appending zeros if the file is smaller.
+// If the FileSystem implementation doesn't support truncating,
+// then the file is read and written again,
+// or an ErrUnsupported error is returned in strict mode, see SetStrict.
 func (file File) Truncate(newSize int64) error {
 	if file == "" {
 		return ErrEmptyPath
@@ -1257,9 +1786,13 @@ func (file File) Truncate(newSize int64) error {
 	}
 	fileSystem, path := file.ParseRawURI()
 	if fs, ok := fileSystem.(TruncateFileSystem); ok {
+		defer beginOp(fileSystem)()
 		return fs.Truncate(path, newSize)
 	}
-	info, err := fileSystem.Stat(path)
+	if IsStrict(fileSystem) {
+		return NewErrUnsupported(fileSystem, "Truncate")
+	}
+	info, err := file.Stat()
 	if err != nil {
 		return NewErrDoesNotExist(file)
 	}
@@ -1275,7 +1808,7 @@ func (file File) Truncate(newSize int64) error {
 		return file.Append(context.Background(), zeros)
 	}
 	// Truncate be reading up to newSize and then rewriting the file
-	r, err := fileSystem.OpenReader(path)
+	r, err := file.OpenReader()
 	if err != nil {
 		return err
 	}
@@ -1285,7 +1818,7 @@ func (file File) Truncate(newSize int64) error {
 	if err != nil {
 		return err
 	}
-	w, err := fileSystem.OpenWriter(path, []Permissions{PermissionsFromStdFileInfo(info)})
+	w, err := file.OpenWriter(PermissionsFromStdFileInfo(info))
 	if err != nil {
 		return err
 	}
@@ -1302,6 +1835,9 @@ func (file File) Truncate(newSize int64) error {
 // Rename changes the name of a file where newName is the name part after file.Dir().
 // Note: this does not move the file like in other rename implementations,
 // it only changes the name of the file within its directdory.
+// If the FileSystem implementation doesn't support renaming or moving,
+// then the file is copied and deleted,
+// or an ErrUnsupported error is returned in strict mode, see SetStrict.
 func (file File) Rename(newName string) (renamedFile File, err error) {
 	if file == "" {
 		return "", ErrEmptyPath
@@ -1312,7 +1848,9 @@ func (file File) Rename(newName string) (renamedFile File, err error) {
 	}
 	switch fs := fileSystem.(type) {
 	case RenameFileSystem:
+		endOp := beginOp(fileSystem)
 		newPath, err := fs.Rename(path, newName)
+		endOp()
 		if err != nil {
 			return "", err
 		}
@@ -1320,12 +1858,17 @@ func (file File) Rename(newName string) (renamedFile File, err error) {
 	case MoveFileSystem:
 		dir, _ := fs.SplitDirAndName(path)
 		newPath := fs.JoinCleanPath(dir, newName)
+		endOp := beginOp(fileSystem)
 		err = fs.Move(path, newPath)
+		endOp()
 		if err != nil {
 			return "", err
 		}
 		return fs.RootDir().Join(newPath), nil
 	default:
+		if IsStrict(fileSystem) {
+			return "", NewErrUnsupported(fileSystem, "Rename")
+		}
 		renamedFile = file.Dir().Join(newName)
 		if file.IsDir() {
 			err = renamedFile.MakeDir()
@@ -1360,6 +1903,7 @@ func (file File) Remove() error {
 		return ErrEmptyPath
 	}
 	fileSystem, path := file.ParseRawURI()
+	defer beginOp(fileSystem)()
 	return fileSystem.Remove(path)
 }
 
@@ -1437,15 +1981,21 @@ func (file File) RemoveDirContentsContext(ctx context.Context, patterns ...strin
 //
 // Returns a wrapped ErrUnmarshalJSON when the unmarshalling failed.
 func (file File) ReadJSON(ctx context.Context, output any) error {
+	return file.ReadJSONWithOptions(ctx, output)
+}
+
+// ReadJSONWithOptions reads and unmarshalles the JSON content of the file
+// to output like ReadJSON. Options like DisallowUnknownJSONFields
+// or ValidateJSON can be passed to fail fast on unexpected content.
+//
+// Returns a wrapped ErrUnmarshalJSON when the unmarshalling failed
+// or a wrapped ErrValidateJSON when a ValidateJSON option returned an error.
+func (file File) ReadJSONWithOptions(ctx context.Context, output any, options ...ReadJSONOption) error {
 	data, err := file.ReadAllContext(ctx)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(data, output)
-	if err != nil {
-		return fmt.Errorf("%w because: %w", ErrUnmarshalJSON, err)
-	}
-	return nil
+	return UnmarshalJSON(data, output, options...)
 }
 
 // WriteJSON mashalles input to JSON and writes it as the file.
@@ -1506,10 +2056,18 @@ func (file File) WriteXML(ctx context.Context, input any, indent ...string) (err
 
 // GobEncode reads and gob encodes the file name and content,
 // implementing encoding/gob.GobEncoder.
+//
+// Directories are encoded with the names and contents
+// of all contained files and sub-directories, see GobDecode.
+// An error wrapping ErrTooLarge is returned if the total size
+// of the files in a directory exceeds MaxGobEncodeDirSize.
 func (file File) GobEncode() ([]byte, error) {
 	if file == "" {
 		return nil, ErrEmptyPath
 	}
+	if file.IsDir() {
+		return file.gobEncodeDir()
+	}
 	fileName := file.Name()
 	fileData, err := file.ReadAll()
 	if err != nil {
@@ -1532,6 +2090,14 @@ func (file File) GobEncode() ([]byte, error) {
 // GobDecode decodes a file name and content from gobBytes
 // and writes the content to this file ignoring the decoded name.
 // Implements encoding/gob.GobDecoder.
+//
+// If a directory was encoded, then this file is created
+// as directory if it doesn't exist and the decoded files
+// and sub-directories are written into it.
+// Existing files with other names are not removed.
+// A round trip restores the names, contents, and directory structure
+// including empty directories, but not permissions, modification times,
+// or symbolic links which are encoded as the files they point to.
 func (file File) GobDecode(gobBytes []byte) error {
 	if file == "" {
 		return ErrEmptyPath
@@ -1549,6 +2115,15 @@ func (file File) GobDecode(gobBytes []byte) error {
 	if err != nil {
 		return fmt.Errorf("File.GobDecode: error decoding file data: %w", err)
 	}
+	// Directories have their entries encoded after empty file data
+	var entries []gobDirEntry
+	err = dec.Decode(&entries)
+	if err == nil {
+		return file.gobDecodeDir(entries)
+	}
+	if !errors.Is(err, io.EOF) {
+		return fmt.Errorf("File.GobDecode: error decoding directory entries: %w", err)
+	}
 	err = file.WriteAll(fileData)
 	if err != nil {
 		return fmt.Errorf("File.GobDecode: error writing file data: %w", err)
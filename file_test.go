@@ -1,9 +1,14 @@
 package fs
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"testing"
 	"time"
@@ -280,11 +285,80 @@ func TestFile_ListDirIter(t *testing.T) {
 	require.Empty(t, files, "not all files listed")
 }
 
+func TestFile_ListDirInfoIter(t *testing.T) {
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("a.txt").WriteAllString("a"))
+	require.NoError(t, dir.Join("b.txt").WriteAllString("bb"))
+	require.NoError(t, dir.Join("c.log").WriteAllString("ccc"))
+
+	sizes := make(map[string]int64)
+	for info, err := range dir.ListDirInfoIter("*.txt") {
+		require.NoError(t, err)
+		sizes[info.Name] = info.Size
+	}
+	require.Equal(t, map[string]int64{"a.txt": 1, "b.txt": 2}, sizes)
+
+	for info, err := range dir.Join("missing").ListDirInfoIter() {
+		require.Nil(t, info)
+		require.True(t, errors.As(err, new(ErrDoesNotExist)))
+	}
+}
+
+func TestFile_ListDirMax_Limit(t *testing.T) {
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("sub").MakeDir())
+	for _, name := range []string{"a", "b", "sub/c"} {
+		require.NoError(t, dir.Join(name).Touch())
+	}
+
+	defer func(limit int) { ListDirMaxLimit = limit }(ListDirMaxLimit)
+	ListDirMaxLimit = 2
+
+	files, err := dir.ListDirMax(2)
+	require.NoError(t, err, "explicit max is not limited")
+	require.Len(t, files, 2)
+	_, err = dir.ListDirMax(-1)
+	require.ErrorIs(t, err, ErrTooMany)
+	_, err = dir.ListDirRecursiveMax(-1)
+	require.ErrorIs(t, err, ErrTooMany)
+
+	ListDirMaxLimit = 3
+	files, err = dir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	files, err = dir.ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+}
+
 func TestFile_String(t *testing.T) {
 	path := filepath.Join("dir", "file.ext")
 	require.Equal(t, path+" (local file system)", File(path).String())
 }
 
+func TestFile_InfoContext(t *testing.T) {
+	dir := File(t.TempDir())
+	file := dir.Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+
+	info, err := file.InfoContext(context.Background())
+	require.NoError(t, err)
+	require.True(t, info.Exists)
+	require.Equal(t, file, info.File)
+	require.Equal(t, int64(5), info.Size)
+
+	_, err = dir.Join("missing.txt").InfoContext(context.Background())
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = InvalidFile.InfoContext(context.Background())
+	require.ErrorIs(t, err, ErrEmptyPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = file.InfoContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
 func TestFile_Glob(t *testing.T) {
 	dir := MustMakeTempDir()
 	t.Cleanup(func() { dir.RemoveRecursive() })
@@ -399,6 +473,74 @@ func TestFile_Glob(t *testing.T) {
 				{xFile3, []string{"file3.txt"}},
 			},
 		},
+		{
+			name:    "doublestar",
+			file:    dir,
+			pattern: "a/**/*.txt",
+			want: []result{
+				{xFile1, []string{"b/c/Hello/World/x", "file1.txt"}},
+				{xFile2, []string{"b/c/Hello/World/x", "file2.txt"}},
+				{xFile3, []string{"b/c/Hello/World/x", "file3.txt"}},
+			},
+		},
+		{
+			name:    "doublestar matching no directory",
+			file:    dir,
+			pattern: "a/b/c/**/cFile",
+			want: []result{
+				{cFile, []string{""}},
+			},
+		},
+		{
+			name:    "doublestar with following segments",
+			file:    dir,
+			pattern: "**/World/*/",
+			want: []result{
+				{xDir, []string{"a/b/c/Hello", "x"}},
+				{yDir, []string{"a/b/c/Hello", "y"}},
+			},
+		},
+		{
+			name:    "trailing doublestar",
+			file:    dir,
+			pattern: "a/b/c/Hello/World/**",
+			want: []result{
+				{dir.Join("a", "b", "c", "Hello", "World"), []string{""}},
+				{xDir, []string{"x"}},
+				{xFile1, []string{"x/file1.txt"}},
+				{xFile2, []string{"x/file2.txt"}},
+				{xFile3, []string{"x/file3.txt"}},
+				{yDir, []string{"y"}},
+			},
+		},
+		{
+			name:    "brace alternatives",
+			file:    dir,
+			pattern: "a/b/c/Hello/World/x/file{1,3}.{txt,md}",
+			want: []result{
+				// No values because the expanded patterns have no wildcards
+				{xFile1, nil},
+				{xFile3, nil},
+			},
+		},
+		{
+			name:    "brace alternatives yield files once",
+			file:    dir,
+			pattern: "a/b/c/{*,cFile}",
+			want: []result{
+				{dir.Join("a", "b", "c", "Hello"), []string{"Hello"}},
+				{cFile, []string{"cFile"}},
+			},
+		},
+		{
+			name:    "negated character class",
+			file:    dir,
+			pattern: "a/b/c/Hello/World/x/file[!1].txt",
+			want: []result{
+				{xFile2, []string{"file2.txt"}},
+				{xFile3, []string{"file3.txt"}},
+			},
+		},
 		// Errors
 		{
 			name:    "malformed pattern",
@@ -406,6 +548,12 @@ func TestFile_Glob(t *testing.T) {
 			pattern: "a/b/c/Hello/World/x/[file1.txt",
 			wantErr: true,
 		},
+		{
+			name:    "unclosed brace",
+			file:    dir,
+			pattern: "a/b/{c,d/*",
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -486,6 +634,14 @@ func TestGlob(t *testing.T) {
 				{dir.Join("a"), []string{"a"}},
 			},
 		},
+		{
+			name:    "doublestar",
+			pattern: dir.PathWithSlashes() + "/**/file[13].txt",
+			want: []result{
+				{xFile1, []string{"a/b/c/Hello/World/x", "file1.txt"}},
+				{xFile3, []string{"a/b/c/Hello/World/x", "file3.txt"}},
+			},
+		},
 		{
 			name:    "file and dir",
 			pattern: dir.PathWithSlashes() + "/a/b/c/*",
@@ -543,3 +699,93 @@ func TestGlob(t *testing.T) {
 		})
 	}
 }
+
+func TestFile_OpenWriteSeeker(t *testing.T) {
+	file := File(t.TempDir()).Join("seek.txt")
+	require.NoError(t, file.WriteAllString("previous content to be truncated"))
+
+	w, err := file.OpenWriteSeeker()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("Hello _____!"))
+	require.NoError(t, err)
+	_, err = w.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("Wxrld"))
+	require.NoError(t, err)
+	_, err = w.WriteAt([]byte("o"), 7)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World!", str)
+}
+
+func TestFile_AbsPathErr(t *testing.T) {
+	absPath, err := File("relative/file.txt").AbsPathErr()
+	require.NoError(t, err)
+	require.True(t, filepath.IsAbs(absPath))
+
+	absFile, err := File("relative/file.txt").ToAbsPathErr()
+	require.NoError(t, err)
+	require.Equal(t, File(absPath), absFile)
+	require.Equal(t, absFile, File("relative/file.txt").MustToAbsPath())
+	require.Equal(t, absFile, File("relative/file.txt").ToAbsPath())
+
+	_, err = InvalidFile.AbsPathErr()
+	require.ErrorIs(t, err, ErrInvalidFileSystem)
+
+	if runtime.GOOS == "windows" {
+		return // Can't remove the working directory
+	}
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	removedDir := t.TempDir()
+	require.NoError(t, os.Chdir(removedDir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	require.NoError(t, os.Remove(removedDir))
+
+	_, err = File("relative/file.txt").AbsPathErr()
+	require.Error(t, err)
+	_, err = File("relative/file.txt").ToAbsPathErr()
+	require.Error(t, err)
+	require.Panics(t, func() { File("relative/file.txt").MustToAbsPath() })
+	// AbsPath doesn't panic anymore
+	require.Equal(t, filepath.Clean("relative/file.txt"), File("relative/file.txt").AbsPath())
+	require.Equal(t, File("relative/file.txt"), File("relative/file.txt").ToAbsPath())
+	// Absolute paths don't need the working directory
+	absPath, err = File("/file.txt").AbsPathErr()
+	require.NoError(t, err)
+	require.Equal(t, "/file.txt", absPath)
+}
+
+func Test_expandGlobPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{pattern: "", want: []string{""}},
+		{pattern: "*.txt", want: []string{"*.txt"}},
+		{pattern: "{a,b}", want: []string{"a", "b"}},
+		{pattern: "logs/{app,db}/*.{log,txt}", want: []string{"logs/app/*.log", "logs/app/*.txt", "logs/db/*.log", "logs/db/*.txt"}},
+		{pattern: "{a,b{c,d}}e", want: []string{"ae", "bce", "bde"}},
+		{pattern: "x{,y}", want: []string{"x", "xy"}},
+		{pattern: `\{a,b}`, want: []string{`\{a,b}`}},
+		{pattern: "[{,}]", want: []string{"[{,}]"}},
+		{pattern: "[!a-z]", want: []string{"[^a-z]"}},
+		{pattern: "a}", want: []string{"a}"}},
+		{pattern: "{a,b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := expandGlobPattern(tt.pattern)
+			if tt.wantErr {
+				require.ErrorIs(t, err, path.ErrBadPattern)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -20,6 +20,23 @@ type FileInfo struct {
 	Size        int64
 	Modified    time.Time
 	Permissions Permissions
+
+	// Extra is optional backend specific data like the
+	// io/fs.FileInfo.Sys() result of the file system,
+	// for example *syscall.Stat_t for local files,
+	// *sftp.FileStat for SFTP files, or *s3fs.ObjectInfo.
+	// Use FileInfoExtra to get it as a typed value.
+	Extra any
+}
+
+// FileInfoExtra returns info.Extra as type T
+// or false if info is nil or Extra has a different type.
+func FileInfoExtra[T any](info *FileInfo) (extra T, ok bool) {
+	if info == nil {
+		return extra, false
+	}
+	extra, ok = info.Extra.(T)
+	return extra, ok
 }
 
 // Validate returns an error if the FileInfo is invalid.
@@ -33,9 +50,31 @@ func (i *FileInfo) Validate() error {
 	return nil
 }
 
+// ExistsErr returns nil if the FileInfo is a snapshot of an existing file
+// or an ErrDoesNotExist error for the file if not,
+// for example if it was returned by NewNonExistingFileInfo.
+// ErrEmptyPath is returned for a nil FileInfo.
+//
+// Use it instead of checking the Exists field
+// to pass on the non existing state as error:
+//
+//	if err := info.ExistsErr(); err != nil {
+//		return err
+//	}
+func (i *FileInfo) ExistsErr() error {
+	if i == nil {
+		return ErrEmptyPath
+	}
+	if !i.Exists {
+		return NewErrDoesNotExist(i.File)
+	}
+	return nil
+}
+
 // NewFileInfo returns a FileInfo using the
 // data from an io/fs.FileInfo as snapshot
 // of an existing file.
+// The result of info.Sys() is used as Extra.
 // Use NewNonExistingFileInfo to get
 // a FileInfo for non existing file.
 func NewFileInfo(file File, info iofs.FileInfo, hidden bool) *FileInfo {
@@ -50,6 +89,7 @@ func NewFileInfo(file File, info iofs.FileInfo, hidden bool) *FileInfo {
 		Size:        info.Size(),
 		Modified:    info.ModTime(),
 		Permissions: Permissions(mode.Perm()),
+		Extra:       info.Sys(),
 	}
 }
 
@@ -58,6 +98,7 @@ func NewFileInfo(file File, info iofs.FileInfo, hidden bool) *FileInfo {
 // FileInfo.Exists will be false, but the
 // file may exist at any point of time.
 // IsHidden will be true if the name starts with a dot.
+// FileInfo.ExistsErr returns an ErrDoesNotExist error for it.
 func NewNonExistingFileInfo(file File) *FileInfo {
 	name := file.Name()
 	return &FileInfo{
@@ -80,7 +121,7 @@ func (f fileInfo) Size() int64        { return f.i.Size }
 func (f fileInfo) Mode() os.FileMode  { return f.i.Permissions.FileMode(f.i.IsDir) }
 func (f fileInfo) ModTime() time.Time { return f.i.Modified }
 func (f fileInfo) IsDir() bool        { return f.i.IsDir }
-func (f fileInfo) Sys() any           { return nil }
+func (f fileInfo) Sys() any           { return f.i.Extra }
 
 // type NameSizeProvider interface {
 // 	Name() string
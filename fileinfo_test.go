@@ -0,0 +1,55 @@
+package fs
+
+import (
+	iofs "io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSysInfo struct {
+	Owner string
+}
+
+type testStdFileInfo struct{ sys any }
+
+func (testStdFileInfo) Name() string        { return "file.txt" }
+func (testStdFileInfo) Size() int64         { return 3 }
+func (testStdFileInfo) Mode() iofs.FileMode { return 0644 }
+func (testStdFileInfo) ModTime() time.Time  { return time.Time{} }
+func (testStdFileInfo) IsDir() bool         { return false }
+func (i testStdFileInfo) Sys() any          { return i.sys }
+
+func TestFileInfoExtra(t *testing.T) {
+	sys := &testSysInfo{Owner: "me"}
+	info := NewFileInfo("/file.txt", testStdFileInfo{sys}, false)
+	require.Same(t, sys, info.Extra)
+	require.Same(t, sys, info.StdFileInfo().Sys())
+
+	extra, ok := FileInfoExtra[*testSysInfo](info)
+	require.True(t, ok)
+	require.Equal(t, "me", extra.Owner)
+
+	_, ok = FileInfoExtra[string](info)
+	require.False(t, ok)
+	_, ok = FileInfoExtra[*testSysInfo](NewNonExistingFileInfo("/file.txt"))
+	require.False(t, ok)
+	_, ok = FileInfoExtra[*testSysInfo](nil)
+	require.False(t, ok)
+}
+
+func TestFileInfo_ExistsErr(t *testing.T) {
+	info := NewFileInfo("/file.txt", testStdFileInfo{}, false)
+	require.NoError(t, info.ExistsErr())
+
+	err := NewNonExistingFileInfo("/file.txt").ExistsErr()
+	require.ErrorIs(t, err, os.ErrNotExist)
+	file, ok := err.(ErrDoesNotExist).File()
+	require.True(t, ok)
+	require.Equal(t, File("/file.txt"), file)
+
+	var nilInfo *FileInfo
+	require.ErrorIs(t, nilInfo.ExistsErr(), ErrEmptyPath)
+}
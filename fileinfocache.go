@@ -1,11 +1,16 @@
 package fs
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // FileInfoCache is a cache with timeout for FileInfo data.
+// It is safe for concurrent use.
 type FileInfoCache struct {
 	infos   map[string]fileInfoCacheEntry
 	timeout time.Duration
+	mtx     sync.Mutex
 }
 
 type fileInfoCacheEntry struct {
@@ -31,6 +36,9 @@ func (cache *FileInfoCache) Put(path string, info *FileInfo) {
 	if cache == nil {
 		return
 	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
 	cache.infos[path] = fileInfoCacheEntry{
 		FileInfo: info,
 		time:     time.Now(),
@@ -44,6 +52,9 @@ func (cache *FileInfoCache) Get(path string) (info *FileInfo, ok bool) {
 	if cache == nil {
 		return nil, false
 	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
 	entry, ok := cache.infos[path]
 	if !ok {
 		return nil, false
@@ -60,5 +71,19 @@ func (cache *FileInfoCache) Delete(path string) {
 	if cache == nil {
 		return
 	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
 	delete(cache.infos, path)
 }
+
+// Clear deletes all cached FileInfo data.
+func (cache *FileInfoCache) Clear() {
+	if cache == nil {
+		return
+	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	clear(cache.infos)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	iofs "io/fs"
+	"time"
 )
 
 type (
@@ -38,6 +39,7 @@ type FileSystem interface {
 	// CleanPathFromURI returns the clean path part of an URI
 	// specific to the implementation of the FileSystem.
 	// It's the inverse of the URL method.
+	// Percent-encoded sequences are not decoded.
 	CleanPathFromURI(uri string) string
 
 	// JoinCleanFile joins the file system prefix with uriParts
@@ -45,7 +47,9 @@ type FileSystem interface {
 	JoinCleanFile(uriParts ...string) File
 
 	// JoinCleanPath joins the uriParts into a cleaned path
-	// of the file system style without the file system prefix
+	// of the file system style without the file system prefix.
+	// The uriParts are used literally, percent-encoded
+	// sequences are not decoded.
 	JoinCleanPath(uriParts ...string) string
 
 	// SplitPath returns all Separator() delimited components of filePath
@@ -113,8 +117,17 @@ type fullyFeaturedFileSystem interface {
 	WriteAllFileSystem
 	AppendFileSystem
 	AppendWriterFileSystem
+	WriteSeekerFileSystem
+	HashFileSystem
+	ContentTypeFileSystem
+	ReadRangeFileSystem
 	TruncateFileSystem
 	ExistsFileSystem
+	AbsPathErrFileSystem
+	XAttrFileSystem
+	HardLinkFileSystem
+	SymlinkFileSystem
+	DiskUsageFileSystem
 	UserFileSystem
 	GroupFileSystem
 	PermissionsFileSystem
@@ -151,6 +164,28 @@ type MoveFileSystem interface {
 	Move(filePath string, destinationPath string) error
 }
 
+// MoveContextFileSystem can be implemented by file systems
+// like tracing wrappers that need the context
+// of the Move function for a native move.
+// It is used instead of MoveFileSystem.
+type MoveContextFileSystem interface {
+	MoveFileSystem
+
+	// MoveContext is like Move but with a context argument.
+	MoveContext(ctx context.Context, filePath string, destinationPath string) error
+}
+
+// StatContextFileSystem can be implemented by file systems
+// like tracing wrappers that need the context
+// of File.InfoContext and the other context taking
+// File methods that call Stat.
+type StatContextFileSystem interface {
+	FileSystem
+
+	// StatContext is like Stat but with a context argument.
+	StatContext(ctx context.Context, filePath string) (iofs.FileInfo, error)
+}
+
 // RenameFileSystem can be implemented by file systems
 // that have native file renaming functionality.
 //
@@ -211,6 +246,15 @@ type WriteAllFileSystem interface {
 	WriteAll(ctx context.Context, filePath string, data []byte, perm []Permissions) error
 }
 
+// AtomicWriteFileSystem can be implemented by file systems
+// that can replace the content of a file atomically,
+// so that readers never observe partially written files.
+type AtomicWriteFileSystem interface {
+	FileSystem
+
+	WriteAllAtomic(ctx context.Context, filePath string, data []byte, perm []Permissions) error
+}
+
 type AppendFileSystem interface {
 	FileSystem
 
@@ -223,6 +267,75 @@ type AppendWriterFileSystem interface {
 	OpenAppendWriter(filePath string, perm []Permissions) (WriteCloser, error)
 }
 
+// WriteSeekerFileSystem can be implemented by file systems
+// that support writing at random positions of a file
+// without having to open it for reading too.
+//
+// If a file system does not implement this interface
+// then it's functionality will be emulated with
+// an in memory buffer.
+type WriteSeekerFileSystem interface {
+	FileSystem
+
+	// OpenWriteSeeker opens a file for writing only,
+	// truncating it if it already exists.
+	OpenWriteSeeker(filePath string, perm []Permissions) (WriteSeekCloser, error)
+}
+
+// HashFileSystem can be implemented by file systems
+// that know hashes of files for some of the algorithms
+// of HashAlgorithm without transferring the file content,
+// like S3 ETags and checksums or Dropbox content hashes.
+// It's also used to skip copying files when the destination
+// already contains a file with identical content.
+type HashFileSystem interface {
+	FileSystem
+
+	// Hash returns the hex encoded hash of the file
+	// or an ErrUnsupported error if the hash
+	// is not known for the file or the algorithm
+	// so that the file content has to be hashed instead.
+	Hash(ctx context.Context, filePath string, algo HashAlgorithm) (string, error)
+}
+
+// ContentTypeFileSystem can be implemented by file systems
+// that store the MIME content type of files as metadata,
+// like the Content-Type of objects in cloud storage buckets.
+type ContentTypeFileSystem interface {
+	FileSystem
+
+	// ContentType returns the stored MIME content type of the file
+	// or an ErrUnsupported error if no content type
+	// is stored for the file so that it has to be detected instead.
+	ContentType(ctx context.Context, filePath string) (string, error)
+}
+
+// ReadRangeFileSystem can be implemented by file systems
+// that can read a byte range of a file without
+// transferring the rest of the file, like HTTP range requests.
+type ReadRangeFileSystem interface {
+	FileSystem
+
+	// ReadRange reads up to length bytes starting at offset.
+	// Less bytes are returned if the file ends before.
+	// A negative offset reads the last -offset bytes
+	// of the file and length is ignored.
+	ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error)
+}
+
+// PresignFileSystem can be implemented by file systems
+// that can create time-limited URLs to read a file
+// without the credentials of the file system,
+// like presigned URLs of S3.
+type PresignFileSystem interface {
+	FileSystem
+
+	// PresignURL returns a URL that allows
+	// reading the file with a HTTP GET request
+	// until the expires duration has passed.
+	PresignURL(ctx context.Context, filePath string, expires time.Duration) (string, error)
+}
+
 type TruncateFileSystem interface {
 	FileSystem
 
@@ -233,6 +346,76 @@ type TruncateFileSystem interface {
 	Truncate(filePath string, size int64) error
 }
 
+// AbsPathErrFileSystem can be implemented by file systems
+// where making a path absolute can fail, like for
+// relative local paths if the working directory was removed.
+type AbsPathErrFileSystem interface {
+	FileSystem
+
+	// AbsPathErr returns the passed filePath in absolute form
+	// or an error if that's not possible.
+	AbsPathErr(filePath string) (string, error)
+}
+
+// XAttrFileSystem can be implemented by file systems
+// that support extended attributes of files,
+// which are name value pairs of metadata
+// stored together with a file.
+type XAttrFileSystem interface {
+	FileSystem
+
+	// GetXAttr returns the value of the extended attribute name
+	// or ErrXAttrDoesNotExist if the file has no such attribute.
+	GetXAttr(filePath, name string) ([]byte, error)
+
+	// SetXAttr creates or replaces the extended attribute name
+	SetXAttr(filePath, name string, value []byte) error
+
+	// ListXAttrs returns the sorted names of all extended attributes
+	ListXAttrs(filePath string) ([]string, error)
+
+	// RemoveXAttr removes the extended attribute name
+	// or returns ErrXAttrDoesNotExist if the file has no such attribute.
+	RemoveXAttr(filePath, name string) error
+}
+
+// HardLinkFileSystem can be implemented by file systems
+// that support multiple directory entries
+// sharing the same file data.
+type HardLinkFileSystem interface {
+	FileSystem
+
+	// CreateHardLink creates newPath as hard link
+	// to the existing file at existingPath.
+	// Directories can't be hard linked.
+	CreateHardLink(existingPath, newPath string) error
+}
+
+// SymlinkFileSystem can be implemented by file systems
+// that support symbolic links.
+type SymlinkFileSystem interface {
+	FileSystem
+
+	// CreateSymlink creates linkPath as symbolic link to targetPath.
+	// targetPath is stored unchanged and can be
+	// relative to the directory of linkPath.
+	CreateSymlink(targetPath, linkPath string) error
+
+	// ReadSymlink returns the unchanged target path
+	// of the symbolic link at linkPath.
+	ReadSymlink(linkPath string) (targetPath string, err error)
+}
+
+// DiskUsageFileSystem can be implemented by file systems
+// that can report the space of the storage device
+// or quota containing a path.
+type DiskUsageFileSystem interface {
+	FileSystem
+
+	// DiskUsage returns the space of the storage containing filePath.
+	DiskUsage(filePath string) (DiskUsage, error)
+}
+
 type ExistsFileSystem interface {
 	FileSystem
 
@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// ForEachFileConcurrent calls fn for every file of files
+// using concurrency number of parallel workers.
+// If concurrency is less than 1 then runtime.NumCPU() workers are used.
+//
+// The first error returned by fn or canceling ctx stops
+// calling fn for further files, calls already running are awaited.
+// All errors returned by fn are returned joined with errors.Join
+// together with the context error if ctx was canceled.
+// fn should use its own context derived from ctx
+// to abort long running operations.
+func ForEachFileConcurrent(ctx context.Context, files iter.Seq[File], concurrency int, fn func(File) error) error {
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var (
+		fileChan = make(chan File)
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+		errs     []error
+		mtx      sync.Mutex
+		workers  sync.WaitGroup
+	)
+	for range concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for file := range fileChan {
+				if err := fn(file); err != nil {
+					mtx.Lock()
+					errs = append(errs, err)
+					mtx.Unlock()
+					stopOnce.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return ctx.Err() != nil
+		}
+	}
+	for file := range files {
+		if stopped() {
+			break
+		}
+		select {
+		case fileChan <- file:
+			continue
+		case <-stop:
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(fileChan)
+	workers.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
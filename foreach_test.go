@@ -0,0 +1,74 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachFileConcurrent(t *testing.T) {
+	var files []File
+	for i := range 20 {
+		files = append(files, File("/file"+string(rune('a'+i))))
+	}
+
+	t.Run("all files", func(t *testing.T) {
+		var (
+			mtx     sync.Mutex
+			visited []File
+			running atomic.Int32
+			maxRun  atomic.Int32
+		)
+		err := ForEachFileConcurrent(context.Background(), slices.Values(files), 3, func(file File) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRun.Load()
+				if n <= m || maxRun.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			mtx.Lock()
+			visited = append(visited, file)
+			mtx.Unlock()
+			return nil
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, files, visited)
+		require.LessOrEqual(t, maxRun.Load(), int32(3))
+	})
+
+	t.Run("error stops", func(t *testing.T) {
+		errTest := errors.New("test error")
+		var calls atomic.Int32
+		err := ForEachFileConcurrent(context.Background(), slices.Values(files), 2, func(file File) error {
+			calls.Add(1)
+			if file == files[0] {
+				return errTest
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, errTest)
+		require.Less(t, calls.Load(), int32(len(files)))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls atomic.Int32
+		err := ForEachFileConcurrent(ctx, slices.Values(files), 1, func(file File) error {
+			if calls.Add(1) == 2 {
+				cancel()
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Less(t, calls.Load(), int32(len(files)))
+	})
+}
@@ -26,6 +26,9 @@ func JoinCleanFilePath(uriParts ...string) File {
 // If source and destination are using the same FileSystem,
 // then FileSystem.Move will be used, else source will be
 // copied recursively first to destination and then deleted.
+// In strict mode an ErrUnsupported error is returned instead
+// of copying within a file system that can't move files,
+// see SetStrict.
 func Move(ctx context.Context, source, destination File) error {
 	if source == "" || destination == "" {
 		return ErrEmptyPath
@@ -34,8 +37,19 @@ func Move(ctx context.Context, source, destination File) error {
 	destFS, destPath := destination.ParseRawURI()
 	if srcFS == destFS {
 		if moveFS, ok := srcFS.(MoveFileSystem); ok {
+			endOp, err := beginOpContext(ctx, srcFS)
+			if err != nil {
+				return err
+			}
+			defer endOp()
+			if moveCtxFS, ok := moveFS.(MoveContextFileSystem); ok {
+				return moveCtxFS.MoveContext(ctx, srcPath, destPath)
+			}
 			return moveFS.Move(srcPath, destPath)
 		}
+		if isStrict(ctx, srcFS) {
+			return NewErrUnsupported(srcFS, "Move")
+		}
 	}
 	err := CopyRecursive(ctx, source, destination)
 	if err != nil {
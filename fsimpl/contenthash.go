@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"hash"
 	"io"
 )
 
@@ -41,3 +42,53 @@ func DropboxContentHash(ctx context.Context, reader io.Reader) (string, error) {
 	}
 	return hex.EncodeToString(resultHash.Sum(nil)), nil
 }
+
+// NewDropboxContentHasher returns a hash.Hash that calculates
+// the same Dropbox content hash as DropboxContentHash
+// from the data written to it.
+// See https://www.dropbox.com/developers/reference/content-hash
+func NewDropboxContentHasher() hash.Hash {
+	return &dropboxContentHasher{block: sha256.New()}
+}
+
+type dropboxContentHasher struct {
+	blockHashes []byte
+	block       hash.Hash
+	blockSize   int
+}
+
+func (h *dropboxContentHasher) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		num := min(len(p), hashBlockSize-h.blockSize)
+		h.block.Write(p[:num])
+		h.blockSize += num
+		n += num
+		p = p[num:]
+		if h.blockSize == hashBlockSize {
+			h.blockHashes = h.block.Sum(h.blockHashes)
+			h.block.Reset()
+			h.blockSize = 0
+		}
+	}
+	return n, nil
+}
+
+func (h *dropboxContentHasher) Sum(b []byte) []byte {
+	blockHashes := h.blockHashes
+	if h.blockSize > 0 {
+		// Don't modify h.blockHashes because Sum must not change the state
+		blockHashes = h.block.Sum(blockHashes[:len(blockHashes):len(blockHashes)])
+	}
+	sum := sha256.Sum256(blockHashes)
+	return append(b, sum[:]...)
+}
+
+func (h *dropboxContentHasher) Reset() {
+	h.blockHashes = nil
+	h.block.Reset()
+	h.blockSize = 0
+}
+
+func (*dropboxContentHasher) Size() int { return sha256.Size }
+
+func (*dropboxContentHasher) BlockSize() int { return sha256.BlockSize }
@@ -3,6 +3,7 @@ package fsimpl
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"io"
 	"net/http"
 	"testing"
@@ -54,3 +55,19 @@ func TestDropboxContentHash(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDropboxContentHasher(t *testing.T) {
+	for _, size := range []int{0, 5, hashBlockSize, hashBlockSize + 1, 2*hashBlockSize + 7} {
+		data := bytes.Repeat([]byte{'x'}, size)
+		want, err := DropboxContentHash(context.Background(), bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("DropboxContentHash() error: %s", err)
+		}
+		h := NewDropboxContentHasher()
+		h.Write(data[:size/3])
+		h.Write(data[size/3:])
+		if got := hex.EncodeToString(h.Sum(nil)); got != want {
+			t.Errorf("NewDropboxContentHasher() size %d = %v, want %v", size, got, want)
+		}
+	}
+}
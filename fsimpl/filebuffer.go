@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	iofs "io/fs"
+	"slices"
 )
 
 var _ iofs.File = new(ReadonlyFileBuffer)
@@ -139,15 +140,49 @@ type FileBuffer struct {
 }
 
 // NewFileBuffer returns a new FileBuffer
+// that uses data as initial buffer contents.
+// Writes can modify data and use its spare capacity.
 func NewFileBuffer(data []byte) *FileBuffer {
 	return &FileBuffer{ReadonlyFileBuffer: ReadonlyFileBuffer{data: data}}
 }
 
+// NewFileBufferWithCapacity returns a new empty FileBuffer
+// with memory preallocated for capacity bytes
+// so that writing up to capacity bytes does not reallocate.
+func NewFileBufferWithCapacity(capacity int) *FileBuffer {
+	return NewFileBuffer(make([]byte, 0, capacity))
+}
+
 // NewFileBufferWithClose returns a new FileBuffer
 func NewFileBufferWithClose(data []byte, close func() error) *FileBuffer {
 	return &FileBuffer{ReadonlyFileBuffer: ReadonlyFileBuffer{data: data, close: close}}
 }
 
+// Flush calls the close function passed to NewFileBufferWithClose
+// without closing the buffer, which usually writes the
+// current buffer data to the underlying storage.
+// Does nothing if the buffer has no close function.
+func (buf *FileBuffer) Flush() error {
+	if buf.close == nil {
+		return nil
+	}
+	return buf.close()
+}
+
+// Grow grows the capacity of the buffer if necessary
+// to guarantee space for another n bytes
+// so that writing them does not reallocate.
+func (buf *FileBuffer) Grow(n int) {
+	if n > 0 {
+		buf.data = slices.Grow(buf.data, n)
+	}
+}
+
+// Cap returns the capacity of the buffer in bytes.
+func (buf *FileBuffer) Cap() int {
+	return cap(buf.data)
+}
+
 // Write writes len(p) bytes from p to the underlying data stream.
 // It returns the number of bytes written from p (0 <= n <= len(p))
 // and any error encountered that caused the write to stop early.
@@ -162,13 +197,19 @@ func (buf *FileBuffer) Write(p []byte) (n int, err error) {
 // and any error encountered that caused the write to stop early.
 // WriteAt must return a non-nil error if it returns n < len(p).
 func (buf *FileBuffer) WriteAt(p []byte, off int64) (n int, err error) {
-	numBytes := len(p)
-	pos := int(buf.pos)
-	writeEnd := pos + numBytes
-	if writeEnd > len(buf.data) {
-		newData := make([]byte, writeEnd)
-		copy(newData, buf.data)
-		buf.data = newData
+	if off < 0 {
+		return 0, errors.New("FileBuffer.WriteAt: negative offset")
+	}
+	pos := int(off)
+	writeEnd := pos + len(p)
+	if oldLen := len(buf.data); writeEnd > oldLen {
+		// Grow with the amortized strategy of append
+		// that doubles small and grows large buffers by a fraction
+		buf.data = slices.Grow(buf.data, writeEnd-oldLen)[:writeEnd]
+		if pos > oldLen {
+			// Spare capacity could contain old data
+			clear(buf.data[oldLen:pos])
+		}
 	}
 	return copy(buf.data[pos:], p), nil
 }
@@ -31,3 +31,64 @@ func TestFileBuffer(t *testing.T) {
 		}
 	}
 }
+
+func TestFileBuffer_WriteAt(t *testing.T) {
+	buf := NewFileBuffer(nil)
+	n, err := buf.WriteAt([]byte("World"), 6)
+	if err != nil || n != 5 {
+		t.Fatalf("WriteAt returned %d, %v", n, err)
+	}
+	n, err = buf.WriteAt([]byte("Hello"), 0)
+	if err != nil || n != 5 {
+		t.Fatalf("WriteAt returned %d, %v", n, err)
+	}
+	if got, want := string(buf.Bytes()), "Hello\x00World"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, err = buf.WriteAt([]byte("x"), -1); err == nil {
+		t.Fatal("expected error for negative offset")
+	}
+}
+
+func TestFileBuffer_Grow(t *testing.T) {
+	buf := NewFileBufferWithCapacity(100)
+	if buf.Cap() != 100 || buf.Size() != 0 {
+		t.Fatalf("got cap %d and size %d", buf.Cap(), buf.Size())
+	}
+	chunk := make([]byte, 10)
+	for range 10 {
+		buf.Write(chunk)
+	}
+	if buf.Cap() != 100 {
+		t.Fatalf("writing within capacity reallocated to cap %d", buf.Cap())
+	}
+
+	// Amortized growth while writing many small chunks
+	reallocs := 0
+	for range 100_000 {
+		capBefore := buf.Cap()
+		buf.Write(chunk)
+		if buf.Cap() != capBefore {
+			reallocs++
+		}
+	}
+	if buf.Size() != 1_000_100 {
+		t.Fatalf("got size %d", buf.Size())
+	}
+	if reallocs > 30 {
+		t.Fatalf("%d reallocations", reallocs)
+	}
+
+	buf.Grow(1000)
+	if buf.Cap()-int(buf.Size()) < 1000 {
+		t.Fatalf("Grow(1000) left only %d bytes", buf.Cap()-int(buf.Size()))
+	}
+
+	// Gaps in spare capacity are zeroed
+	data := []byte("HelloWorld")
+	buf = NewFileBuffer(data[:5])
+	buf.WriteAt([]byte("!"), 7)
+	if got, want := string(buf.Bytes()), "Hello\x00\x00!"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -22,13 +22,19 @@ func RandomString() string {
 }
 
 // Ext returns the extension of filePath including the point, or an empty string.
+// A query or fragment of an HTTP URI is ignored
+// and the scheme and authority of an URI never have an extension.
 // Example:
 //
 //	Ext("image.png", "/") == ".png"
 //	Ext("image.png/file", "/") == ""
+//	Ext("https://example.com/image.png?v=1.2", "/") == ".png"
+//	Ext("https://example.com", "/") == ""
 func Ext(filePath, separator string) string {
+	filePath, _ = SplitURIQuery(filePath)
+	_, filePath = splitURIRoot(filePath, separator)
 	if separator != "" {
-		filePath = filePath[strings.LastIndex(filePath, separator)+1:]
+		filePath = filePath[strings.LastIndex(filePath, separator)+len(separator):]
 	}
 	p := strings.LastIndexByte(filePath, '.')
 	if p == -1 {
@@ -38,16 +44,68 @@ func Ext(filePath, separator string) string {
 }
 
 // TrimExt returns a filePath with a path where the extension is removed.
+// A query or fragment of an HTTP URI is kept
+// and the scheme and authority of an URI are never trimmed.
 func TrimExt(filePath, separator string) string {
+	filePath, query := SplitURIQuery(filePath)
+	root, filePath := splitURIRoot(filePath, separator)
 	sep := -1
 	if separator != "" {
 		sep = strings.LastIndex(filePath, separator)
 	}
 	p := strings.LastIndexByte(filePath, '.')
 	if p == -1 || p < sep {
-		return filePath
+		return root + filePath + query
 	}
-	return filePath[:p]
+	return root + filePath[:p] + query
+}
+
+// SplitURIQuery splits an URI with the scheme "http://" or "https://"
+// into the path and the query and/or fragment part
+// beginning with '?' or '#'.
+// The query may contain separators that must not
+// be interpreted as part of the path.
+// All other filePaths are returned unchanged with an empty query
+// because '?' and '#' are valid characters in file names
+// and literal parts of keys like those of S3 objects.
+// Percent-encoded characters like "%2F" are not decoded
+// so they are never interpreted as separators.
+func SplitURIQuery(filePath string) (pathPart, query string) {
+	var pathStart int
+	switch {
+	case hasPrefixFold(filePath, "http://"):
+		pathStart = len("http://")
+	case hasPrefixFold(filePath, "https://"):
+		pathStart = len("https://")
+	default:
+		return filePath, ""
+	}
+	pos := strings.IndexAny(filePath[pathStart:], "?#")
+	if pos == -1 {
+		return filePath, ""
+	}
+	return filePath[:pathStart+pos], filePath[pathStart+pos:]
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// splitURIRoot splits the scheme and authority like "https://example.com"
+// from the path of an URI that is returned as root
+// which is never part of a name or extension.
+// The root is empty for a filePath without a scheme.
+func splitURIRoot(filePath, separator string) (root, pathPart string) {
+	schemeEnd := strings.Index(filePath, "://")
+	if schemeEnd == -1 || separator == "" {
+		return "", filePath
+	}
+	authorityStart := schemeEnd + len("://")
+	pos := strings.Index(filePath[authorityStart:], separator)
+	if pos == -1 {
+		return filePath, ""
+	}
+	return filePath[:authorityStart+pos], filePath[authorityStart+pos:]
 }
 
 // SplitDirAndName is a generic helper for FileSystem.SplitDirAndName implementations.
@@ -55,11 +113,27 @@ func TrimExt(filePath, separator string) string {
 // SplitDirAndName returns the parent directory of filePath and the name with that directory of the last filePath element.
 // If filePath is the root of the file systeme, then an empty string will be returned as name.
 // If filePath does not contain a separator before the name part, then "." will be returned as dir.
+// Separators can have more than one byte.
+// The query and fragment of an HTTP URI are split off
+// before splitting the path and are not part of the name,
+// see SplitURIQuery.
+// The scheme and authority of an URI are its root directory
+// and never returned as name.
 func SplitDirAndName(filePath string, volumeLen int, separator string) (dir, name string) {
 	if filePath == "" {
 		return "", ""
 	}
 
+	// A query of an URI may contain separators
+	filePath, _ = SplitURIQuery(filePath)
+	if root, pathPart := splitURIRoot(filePath, separator); root != "" {
+		pathPart = strings.TrimSuffix(pathPart, separator)
+		pos := strings.LastIndex(pathPart, separator)
+		if pos == -1 {
+			return root, ""
+		}
+		return root + pathPart[:pos], pathPart[pos+len(separator):]
+	}
 	filePath = strings.TrimSuffix(filePath, separator)
 
 	if filePath == "" {
@@ -71,12 +145,12 @@ func SplitDirAndName(filePath string, volumeLen int, separator string) (dir, nam
 	case pos == -1:
 		return ".", filePath
 	case pos == 0:
-		return separator, filePath[1:]
+		return separator, filePath[len(separator):]
 	case pos < volumeLen:
 		return filePath, ""
 	}
 
-	return filePath[:pos], filePath[pos+1:]
+	return filePath[:pos], filePath[pos+len(separator):]
 }
 
 // MatchAnyPattern returns true if name matches any of patterns,
@@ -97,32 +171,53 @@ func MatchAnyPattern(name string, patterns []string) (bool, error) {
 	return false, nil
 }
 
+// JoinCleanPath joins uriParts after trimming trimPrefix
+// from the first part and returns a clean path starting with separator.
+//
+// The parts are used literally, percent-encoded sequences
+// like "%20" are not decoded. Use EscapePath and UnescapePath
+// to convert between file paths and URL paths.
 func JoinCleanPath(uriParts []string, trimPrefix, separator string) string {
 	if len(uriParts) > 0 {
 		uriParts[0] = strings.TrimPrefix(uriParts[0], trimPrefix)
 	}
 	cleanPath := path.Join(uriParts...)
-	unescPath, err := url.PathUnescape(cleanPath)
-	if err == nil {
-		cleanPath = unescPath
-	}
 	if !strings.HasPrefix(cleanPath, separator) {
 		cleanPath = separator + cleanPath
 	}
 	return path.Clean(cleanPath) // TODO works only when separator is "/"
 }
 
+// CleanPath returns p as clean path starting with separator.
+// Percent-encoded sequences in p are not decoded.
 func CleanPath(p, separator string) string {
-	unescPath, err := url.PathUnescape(p)
-	if err == nil {
-		p = unescPath
-	}
 	if !strings.HasPrefix(p, separator) {
 		p = separator + p
 	}
 	return path.Clean(p) // TODO works only when separator is "/"
 }
 
+// EscapePath percent-encodes every element of filePath
+// separated by separator so that the result can be used
+// as path of an URL. Characters like space, '#', '?' and '%'
+// in file names are escaped, separator is preserved.
+func EscapePath(filePath, separator string) string {
+	if separator == "" {
+		return url.PathEscape(filePath)
+	}
+	parts := strings.Split(filePath, separator)
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, separator)
+}
+
+// UnescapePath decodes percent-encoded sequences in an URL path
+// to get the literal file path. It's the inverse of EscapePath.
+func UnescapePath(urlPath string) (string, error) {
+	return url.PathUnescape(urlPath)
+}
+
 func SplitPath(filePath, prefix, separator string) []string {
 	filePath = strings.TrimPrefix(filePath, prefix)
 	filePath = strings.Trim(filePath, separator)
@@ -3,6 +3,7 @@ package fsimpl
 import (
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,20 +12,32 @@ import (
 
 func TestSplitDirAndName(t *testing.T) {
 	refTable := map[string][2]string{
-		"/":                             {"/", ""},
-		"./":                            {".", "."},
-		".":                             {".", "."},
-		"/.":                            {"/", "."},
-		"hello":                         {".", "hello"},
-		"./hello":                       {".", "hello"},
-		"hello/":                        {".", "hello"},
-		"./hello/":                      {".", "hello"},
-		"/hello/world":                  {"/hello", "world"},
-		"hello/world":                   {"hello", "world"},
-		"/hello/world/":                 {"/hello", "world"},
-		"hello/world/":                  {"hello", "world"},
-		"http://example.com/dir":        {"http://example.com", "dir"},
-		"sftp://example.com/dir/subdir": {"sftp://example.com/dir", "subdir"},
+		"/":                                     {"/", ""},
+		"./":                                    {".", "."},
+		".":                                     {".", "."},
+		"/.":                                    {"/", "."},
+		"hello":                                 {".", "hello"},
+		"./hello":                               {".", "hello"},
+		"hello/":                                {".", "hello"},
+		"./hello/":                              {".", "hello"},
+		"/hello/world":                          {"/hello", "world"},
+		"hello/world":                           {"hello", "world"},
+		"/hello/world/":                         {"/hello", "world"},
+		"hello/world/":                          {"hello", "world"},
+		"http://example.com/dir":                {"http://example.com", "dir"},
+		"sftp://example.com/dir/subdir":         {"sftp://example.com/dir", "subdir"},
+		"http://example.com/dir/file?x=a/b#c/d": {"http://example.com/dir", "file"},
+		"https://example.com/dir/?x=/":          {"https://example.com", "dir"},
+		"https://example.com/dir/file#a/b":      {"https://example.com/dir", "file"},
+		"http://a.com/?q=x":                     {"http://a.com", ""},
+		"http://a.com?q=x/y":                    {"http://a.com", ""},
+		"http://a.com":                          {"http://a.com", ""},
+		"s3://bucket/a#b":                       {"s3://bucket", "a#b"},
+		"s3://bucket/dir/a?b/c":                 {"s3://bucket/dir/a?b", "c"},
+		"file:///dir":                           {"file://", "dir"},
+		"https://example.com/a%2Fb":             {"https://example.com", "a%2Fb"},
+		"/dir/file?name":                        {"/dir", "file?name"},
+		"/dir/what?/file":                       {"/dir/what?", "file"},
 	}
 
 	for filePath, dirAndName := range refTable {
@@ -34,6 +47,57 @@ func TestSplitDirAndName(t *testing.T) {
 	}
 }
 
+func TestSplitDirAndName_MultiByteSeparator(t *testing.T) {
+	refTable := map[string][2]string{
+		"::":             {"::", ""},
+		"hello":          {".", "hello"},
+		"::hello":        {"::", "hello"},
+		"::hello::":      {"::", "hello"},
+		"::hello::wörld": {"::hello", "wörld"},
+		"a→b→c":          {"a→b", "c"},
+	}
+	for filePath, dirAndName := range refTable {
+		sep := "::"
+		if strings.Contains(filePath, "→") {
+			sep = "→"
+		}
+		dir, name := SplitDirAndName(filePath, 0, sep)
+		assert.Equalf(t, dirAndName[0], dir, "SplitDirAndName(%#v) = %#v, %#v", filePath, dir, name)
+		assert.Equalf(t, dirAndName[1], name, "SplitDirAndName(%#v) = %#v, %#v", filePath, dir, name)
+	}
+}
+
+func TestSplitURIQuery(t *testing.T) {
+	for _, tt := range []struct{ filePath, pathPart, query string }{
+		{"", "", ""},
+		{"/dir/file?.txt", "/dir/file?.txt", ""},
+		{"https://example.com/file.txt", "https://example.com/file.txt", ""},
+		{"https://example.com/file.txt?a=1/2.3", "https://example.com/file.txt", "?a=1/2.3"},
+		{"https://example.com/file.txt#frag?x", "https://example.com/file.txt", "#frag?x"},
+		{"HTTP://example.com?q", "HTTP://example.com", "?q"},
+		{"s3://bucket/a#b", "s3://bucket/a#b", ""},
+		{"sftp://example.com/file?.txt", "sftp://example.com/file?.txt", ""},
+	} {
+		pathPart, query := SplitURIQuery(tt.filePath)
+		assert.Equal(t, tt.pathPart, pathPart, tt.filePath)
+		assert.Equal(t, tt.query, query, tt.filePath)
+	}
+}
+
+func TestExtAndTrimExt_URIRoot(t *testing.T) {
+	for _, tt := range []struct{ filePath, ext, trimmed string }{
+		{"http://a.com?q=x/y", "", "http://a.com?q=x/y"},
+		{"http://a.com/?q=x.y", "", "http://a.com/?q=x.y"},
+		{"https://example.com", "", "https://example.com"},
+		{"https://example.com/file.txt?v=1.2", ".txt", "https://example.com/file?v=1.2"},
+		{"s3://bucket.example", "", "s3://bucket.example"},
+		{"s3://bucket/file.txt#b", ".txt#b", "s3://bucket/file"},
+	} {
+		assert.Equal(t, tt.ext, Ext(tt.filePath, "/"), tt.filePath)
+		assert.Equal(t, tt.trimmed, TrimExt(tt.filePath, "/"), tt.filePath)
+	}
+}
+
 func TestRandomString(t *testing.T) {
 	require.Len(t, RandomString(), 20, "RandomString length should be 20")
 }
@@ -56,6 +120,10 @@ func ExampleExt() {
 	fmt.Println(Ext("dir.with.ext/file", "\\"))
 	fmt.Println(Ext("dir.with.ext/file", ""))
 
+	fmt.Println(Ext("https://example.com/file.json?v=1.2", "/"))
+	fmt.Println(Ext("https://example.com/file?v=1.2", "/") == "")
+	fmt.Println(Ext("dir::file.ext", "::"))
+
 	// Output:
 	// .png
 	// .png
@@ -65,6 +133,9 @@ func ExampleExt() {
 	// .ext
 	// .ext/file
 	// .ext/file
+	// .json
+	// true
+	// .ext
 }
 
 func ExampleTrimExt() {
@@ -77,6 +148,9 @@ func ExampleTrimExt() {
 	fmt.Println(TrimExt("dir.with.ext/file", "\\"))
 	fmt.Println(TrimExt("dir.with.ext/file", ""))
 
+	fmt.Println(TrimExt("https://example.com/file.json?v=1.2", "/"))
+	fmt.Println(TrimExt("https://example.com/file?v=1.2", "/"))
+
 	// Output:
 	// image
 	// image
@@ -86,6 +160,8 @@ func ExampleTrimExt() {
 	// dir.with.ext/file
 	// dir.with
 	// dir.with
+	// https://example.com/file?v=1.2
+	// https://example.com/file?v=1.2
 }
 
 func TestJoinCleanPath(t *testing.T) {
@@ -158,3 +234,36 @@ func TestJoinCleanPath(t *testing.T) {
 		})
 	}
 }
+
+func TestJoinCleanPath_SpecialCharacters(t *testing.T) {
+	for _, name := range []string{`with space`, `hash#tag`, `question?`, `100%`, `literal%20escape`, `percent%2Fslash`} {
+		require.Equal(t, `/dir/`+name, JoinCleanPath([]string{`dir`, name}, ``, `/`), "not decoded")
+		require.Equal(t, `/dir/`+name, CleanPath(`dir/`+name, `/`), "not decoded")
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct {
+		filePath  string
+		separator string
+		want      string
+	}{
+		{filePath: ``, separator: `/`, want: ``},
+		{filePath: `/dir/file.txt`, separator: `/`, want: `/dir/file.txt`},
+		{filePath: `/with space/hash#tag`, separator: `/`, want: `/with%20space/hash%23tag`},
+		{filePath: `/question?/100%`, separator: `/`, want: `/question%3F/100%25`},
+		{filePath: `/literal%20escape`, separator: `/`, want: `/literal%2520escape`},
+		{filePath: `\dir\a b`, separator: `\`, want: `\dir\a%20b`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			got := EscapePath(tt.filePath, tt.separator)
+			require.Equal(t, tt.want, got)
+			if tt.separator == `/` {
+				unescaped, err := UnescapePath(got)
+				require.NoError(t, err)
+				require.Equal(t, tt.filePath, unescaped, "round trip")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package fsimpl
+
+import (
+	"errors"
+	"io"
+	iofs "io/fs"
+	"os"
+)
+
+// TempFileWriter is an io.WriteCloser that stages written data
+// in a local temp file instead of memory and passes it
+// to an upload function on Close.
+// This way the memory usage is bounded independent of the file size
+// for file system implementations that can only upload complete files.
+type TempFileWriter struct {
+	file   *os.File
+	upload func(r io.ReadSeeker, size int64) error
+}
+
+// NewTempFileWriter creates a new temp file in the
+// temp directory of the operating system and returns
+// a TempFileWriter that passes a reader for the temp file
+// content to upload on Close.
+func NewTempFileWriter(upload func(r io.ReadSeeker, size int64) error) (*TempFileWriter, error) {
+	if upload == nil {
+		return nil, errors.New("NewTempFileWriter: nil upload function")
+	}
+	file, err := os.CreateTemp("", "go-fs-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &TempFileWriter{file: file, upload: upload}, nil
+}
+
+// Write writes len(p) bytes from p to the temp file.
+func (w *TempFileWriter) Write(p []byte) (n int, err error) {
+	if w.file == nil {
+		return 0, iofs.ErrClosed
+	}
+	return w.file.Write(p)
+}
+
+// Flush passes the data written so far to the upload function
+// without closing the writer.
+func (w *TempFileWriter) Flush() error {
+	if w.file == nil {
+		return iofs.ErrClosed
+	}
+	return w.uploadFile()
+}
+
+func (w *TempFileWriter) uploadFile() error {
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	// SectionReader uses ReadAt which does not
+	// change the write offset of the temp file
+	return w.upload(io.NewSectionReader(w.file, 0, info.Size()), info.Size())
+}
+
+// Close passes the written data to the upload function
+// and then closes and removes the temp file.
+// Calling Close more than once does nothing.
+func (w *TempFileWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.uploadFile()
+	err = errors.Join(err, w.file.Close(), os.Remove(w.file.Name()))
+	w.file = nil
+	return err
+}
@@ -0,0 +1,52 @@
+package fsimpl
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestTempFileWriter(t *testing.T) {
+	var uploaded []string
+	w, err := NewTempFileWriter(func(r io.ReadSeeker, size int64) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) != size {
+			t.Fatalf("read %d bytes but size is %d", len(data), size)
+		}
+		uploaded = append(uploaded, string(data))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempPath := w.file.Name()
+
+	if _, err = w.Write([]byte("Hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte(" World")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uploaded) != 2 || uploaded[0] != "Hello" || uploaded[1] != "Hello World" {
+		t.Fatalf("unexpected uploads: %q", uploaded)
+	}
+	if _, err = os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Fatalf("temp file %s not removed", tempPath)
+	}
+	if _, err = w.Write([]byte("!")); err == nil {
+		t.Fatal("expected error writing after Close")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jlaffaye/ftp"
@@ -34,6 +35,13 @@ func init() {
 	fs.Register(&fileSystem{secure: true, prefix: PrefixTLS})
 }
 
+// ReconnectCheckInterval is the idle time after which the connection
+// of a file system returned by Dial is checked with a NOOP command
+// before it is used again.
+// If the check fails because the server dropped the control connection,
+// then a new connection is dialed with the same credentials.
+var ReconnectCheckInterval = 15 * time.Second
+
 // CredentialsCallback is called by Dial to get the username and password for a SFTP connection.
 type CredentialsCallback func(*url.URL) (username, password string, err error)
 
@@ -68,6 +76,27 @@ type fileSystem struct {
 	conn   *ftp.ServerConn
 	prefix string
 	secure bool
+
+	// Used to reconnect dropped connections
+	host     string
+	username string
+	password string
+	debugOut io.Writer
+	lastUsed time.Time
+	connMtx  sync.Mutex
+}
+
+func newFileSystem(conn *ftp.ServerConn, u *url.URL, username, password, prefix string, secure bool, debugOut io.Writer) *fileSystem {
+	return &fileSystem{
+		conn:     conn,
+		prefix:   prefix,
+		secure:   secure,
+		host:     u.Host,
+		username: username,
+		password: password,
+		debugOut: debugOut,
+		lastUsed: time.Now(),
+	}
 }
 
 // Dial a new FTP or FTPS connection and registers it as file system.
@@ -82,11 +111,7 @@ func Dial(ctx context.Context, address string, credentialsCallback CredentialsCa
 	if err != nil {
 		return nil, err
 	}
-	return &fileSystem{
-		conn:   conn,
-		prefix: prefix,
-		secure: secure,
-	}, nil
+	return newFileSystem(conn, u, username, password, prefix, secure, debugOut), nil
 }
 
 func dial(ctx context.Context, host, username, password string, secure bool, debugOut io.Writer) (conn *ftp.ServerConn, err error) {
@@ -123,7 +148,10 @@ func DialAndRegister(ctx context.Context, address string, credentialsCallback Cr
 	if err != nil {
 		return nil, err
 	}
-	fs.Register(fileSystem)
+	_, err = fs.TryRegister(fileSystem)
+	if err != nil {
+		return nil, errors.Join(err, fileSystem.Close())
+	}
 	return fileSystem, nil
 }
 
@@ -147,12 +175,13 @@ func EnsureRegistered(ctx context.Context, address string, credentialsCallback C
 	if err != nil {
 		return nop, err
 	}
-	f = &fileSystem{
-		conn:   conn,
-		prefix: prefix,
-		secure: secure,
+	f = newFileSystem(conn, u, username, password, prefix, secure, debugOut)
+	if _, err = fs.TryRegister(f); err != nil {
+		// Someone else registered a file system with
+		// the same prefix in the meantime, so use that one
+		_ = f.Close()
+		return EnsureRegistered(ctx, address, credentialsCallback, debugOut)
 	}
-	fs.Register(f) // TODO somone else might have registered, so free should not close it
 	return func() error { return f.Close() }, nil
 }
 
@@ -204,7 +233,11 @@ func (f *fileSystem) getConn(ctx context.Context, filePath string) (conn *ftp.Se
 		return nil, "", nop, err
 	}
 	if f.conn != nil {
-		return f.conn, filePath, nop, nil
+		conn, err = f.liveConn(ctx)
+		if err != nil {
+			return nil, "", nop, err
+		}
+		return conn, filePath, nop, nil
 	}
 
 	// fmt.Printf("%s file system not registered, trying to dial with credentials from URL: %s", f.Name(), f.URL(filePath))
@@ -229,6 +262,31 @@ func (f *fileSystem) getConn(ctx context.Context, filePath string) (conn *ftp.Se
 	return conn, u.Path, func() error { return conn.Quit() }, nil
 }
 
+// liveConn returns the connection of the file system
+// after checking it with a NOOP command if it was idle
+// longer than ReconnectCheckInterval.
+// A new connection is dialed if the check failed.
+func (f *fileSystem) liveConn(ctx context.Context) (*ftp.ServerConn, error) {
+	f.connMtx.Lock()
+	defer f.connMtx.Unlock()
+
+	if f.conn == nil {
+		return nil, fs.ErrFileSystemClosed
+	}
+	if time.Since(f.lastUsed) > ReconnectCheckInterval && f.conn.NoOp() != nil {
+		conn, err := dial(ctx, f.host, f.username, f.password, f.secure, f.debugOut)
+		if err != nil {
+			return nil, fmt.Errorf("can't reconnect dropped %s connection: %w", f.Name(), err)
+		}
+		// Quit could block on a half open connection
+		// and its error is irrelevant for a dropped connection
+		go f.conn.Quit()
+		f.conn = conn
+	}
+	f.lastUsed = time.Now()
+	return f.conn, nil
+}
+
 func (f *fileSystem) ReadableWritable() (readable, writable bool) {
 	return true, true
 }
@@ -464,13 +522,53 @@ func (f *fileSystem) OpenReader(filePath string) (reader iofs.File, err error) {
 	}, nil
 }
 
+// OpenWriter returns a writer that streams the written data
+// to the server, the file is complete when the writer is closed.
 func (f *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
-	return f.OpenReadWriter(filePath, perm)
+	return f.openTransferWriter(filePath, (*ftp.ServerConn).Stor)
 }
 
-// func (f *FTPFileSystem) OpenAppendWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+// OpenAppendWriter returns a writer that streams the written data
+// to the server where it's appended to the file.
+func (f *fileSystem) OpenAppendWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	return f.openTransferWriter(filePath, (*ftp.ServerConn).Append)
+}
 
-// }
+func (f *fileSystem) openTransferWriter(filePath string, transfer func(conn *ftp.ServerConn, path string, r io.Reader) error) (w fs.WriteCloser, err error) {
+	defer f.convertResultError(&err, filePath)
+
+	conn, filePath, release, err := f.getConn(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	tw := &transferWriter{
+		PipeWriter: pipeWriter,
+		done:       make(chan struct{}),
+		release:    release,
+	}
+	go func() {
+		defer close(tw.done)
+		tw.err = transfer(conn, filePath, pipeReader)
+		f.convertResultError(&tw.err, filePath)
+		// Unblock writes if the transfer failed
+		pipeReader.CloseWithError(tw.err)
+	}()
+	return tw, nil
+}
+
+// Append appends data to the file using the APPE command.
+func (f *fileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	defer f.convertResultError(&err, filePath)
+
+	conn, filePath, release, err := f.getConn(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return conn.Append(filePath, bytes.NewReader(data))
+}
 
 func (f *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (rw fs.ReadWriteSeekCloser, err error) {
 	defer f.convertResultError(&err, filePath)
@@ -519,6 +617,9 @@ func (f *fileSystem) Remove(filePath string) (err error) {
 }
 
 func (f *fileSystem) Close() error {
+	f.connMtx.Lock()
+	defer f.connMtx.Unlock()
+
 	if f.conn == nil {
 		return nil // already closed
 	}
@@ -531,6 +632,22 @@ func (f *fileSystem) Close() error {
 	return err
 }
 
+// transferWriter writes into a pipe that is
+// read by a STOR or APPE transfer goroutine
+type transferWriter struct {
+	*io.PipeWriter
+	done    chan struct{}
+	err     error
+	release func() error
+}
+
+// Close finishes the transfer and returns its error
+func (w *transferWriter) Close() error {
+	err := w.PipeWriter.Close()
+	<-w.done
+	return errors.Join(err, w.err, w.release())
+}
+
 type file struct {
 	// fs      *fileSystem
 	path    string
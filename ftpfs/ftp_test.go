@@ -73,3 +73,25 @@ func TestDialAndRegister(t *testing.T) {
 	// 	require.NoError(t, err, "Close")
 	// }
 }
+
+func TestPrepareDial(t *testing.T) {
+	u, username, password, prefix, secure, err := prepareDial("ftps://user@example.com:990/dir", Password("secret"))
+	require.NoError(t, err)
+	require.Equal(t, "example.com", u.Host, "default port trimmed")
+	require.Equal(t, "user", username)
+	require.Equal(t, "secret", password)
+	require.Equal(t, "ftps://user@example.com", prefix)
+	require.True(t, secure)
+
+	_, _, _, prefix, secure, err = prepareDial("example.com:2121", UsernameAndPassword("a", "b"))
+	require.NoError(t, err)
+	require.Equal(t, "ftp://a@example.com:2121", prefix)
+	require.False(t, secure)
+
+	_, _, _, _, _, err = prepareDial("sftp://example.com", Password("secret"))
+	require.Error(t, err, "wrong scheme")
+	_, _, _, _, _, err = prepareDial("ftp://user@example.com", Password(""))
+	require.Error(t, err, "missing password")
+	_, _, _, _, _, err = prepareDial("ftp://user@example.com", nil)
+	require.Error(t, err, "nil callback")
+}
@@ -0,0 +1,39 @@
+package gcsfs
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/storage"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+// Make sure fileSystem implements fs.ContentTypeFileSystem
+var _ fs.ContentTypeFileSystem = new(fileSystem)
+
+// ContentType returns the Content-Type of an object without downloading it.
+// An ErrUnsupported error is returned for objects
+// that were uploaded without a Content-Type
+// and for paths without an object.
+func (g *fileSystem) ContentType(ctx context.Context, filePath string) (string, error) {
+	if filePath == "" {
+		return "", fs.ErrEmptyPath
+	}
+	name := objectName(filePath)
+	if name == "" {
+		return "", fs.NewErrIsDirectory(g.File(filePath))
+	}
+	attrs, err := g.bucket.Object(name).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			// Could be a directory implied by the prefix of other objects
+			return "", fs.NewErrUnsupported(g, "ContentType")
+		}
+		return "", g.wrapErrNotExist(filePath, err)
+	}
+	if attrs.ContentType == "" {
+		return "", fs.NewErrUnsupported(g, "ContentType")
+	}
+	return attrs.ContentType, nil
+}
@@ -0,0 +1,40 @@
+package gcsfs
+
+import (
+	iofs "io/fs"
+	"path"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+var _ iofs.FileInfo = new(fileInfo)
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func newFileInfo(attrs *storage.ObjectAttrs) *fileInfo {
+	return &fileInfo{
+		name:    path.Base(attrs.Name),
+		size:    attrs.Size,
+		modTime: attrs.Updated,
+	}
+}
+
+func (i *fileInfo) Name() string       { return i.name }    // base name of the file
+func (i *fileInfo) Size() int64        { return i.size }    // length in bytes for regular files; system-dependent for others
+func (i *fileInfo) ModTime() time.Time { return i.modTime } // modification time
+func (i *fileInfo) IsDir() bool        { return i.isDir }   // abbreviation for Mode().IsDir()
+func (i *fileInfo) Sys() any           { return nil }       // underlying data source (can return nil)
+
+// Mode returns the file mode bits
+func (i *fileInfo) Mode() iofs.FileMode {
+	if i.isDir {
+		return DefaultDirPermissions.FileMode(true)
+	}
+	return DefaultPermissions.FileMode(false)
+}
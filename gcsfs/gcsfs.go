@@ -0,0 +1,491 @@
+package gcsfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	fs "github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of Google Cloud Storage URLs
+	Prefix = "gs://"
+
+	// Separator used in Google Cloud Storage paths
+	Separator = "/"
+)
+
+var (
+	// DefaultPermissions used for bucket files
+	DefaultPermissions = fs.UserAndGroupReadWrite
+	// DefaultDirPermissions used for bucket directories
+	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllReadWrite
+
+	// UploadChunkSize is the size of the chunks used by OpenWriter
+	// for resumable multi-request uploads of large objects.
+	// Objects smaller than UploadChunkSize are uploaded with a single request.
+	// Zero disables chunking and uploads everything with a single request
+	// without buffering, but also without the ability to retry.
+	UploadChunkSize = 16 * 1024 * 1024
+
+	// Make sure fileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(fileSystem)
+)
+
+type fileSystem struct {
+	client     *storage.Client
+	bucket     *storage.BucketHandle
+	bucketName string
+	prefix     string
+	readOnly   bool
+}
+
+// NewAndRegister returns a fs.FileSystem for the Google Cloud Storage bucket
+// with the name bucketName using client and registers it
+// with the prefix "gs://" + bucketName.
+// The client is not closed by the file system.
+func NewAndRegister(client *storage.Client, bucketName string, readOnly bool) fs.FileSystem {
+	gcsfs := &fileSystem{
+		client:     client,
+		bucket:     client.Bucket(bucketName),
+		bucketName: bucketName,
+		prefix:     Prefix + bucketName,
+		readOnly:   readOnly,
+	}
+	fs.Register(gcsfs)
+	return gcsfs
+}
+
+// NewDefaultClient creates a new storage.Client using
+// Application Default Credentials and passes it to NewAndRegister.
+func NewDefaultClient(ctx context.Context, bucketName string, readOnly bool) (fs.FileSystem, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAndRegister(client, bucketName, readOnly), nil
+}
+
+// objectName returns the object name for filePath
+// which is the absolute path without the leading slash.
+func objectName(filePath string) string {
+	return strings.TrimPrefix(filePath, Separator)
+}
+
+// dirObjectPrefix returns the object name prefix
+// of all objects within dirPath.
+func dirObjectPrefix(dirPath string) string {
+	name := objectName(dirPath)
+	if name == "" || strings.HasSuffix(name, Separator) {
+		return name
+	}
+	return name + Separator
+}
+
+func (g *fileSystem) object(filePath string) *storage.ObjectHandle {
+	return g.bucket.Object(objectName(filePath))
+}
+
+func (g *fileSystem) wrapErrNotExist(filePath string, err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		return fs.NewErrDoesNotExist(g.File(filePath))
+	}
+	return err
+}
+
+func (g *fileSystem) ReadableWritable() (readable, writable bool) {
+	return true, !g.readOnly
+}
+
+func (g *fileSystem) RootDir() fs.File {
+	return fs.File(g.prefix + Separator)
+}
+
+func (g *fileSystem) ID() (string, error) {
+	return g.bucketName, nil
+}
+
+func (g *fileSystem) Prefix() string {
+	return g.prefix
+}
+
+func (g *fileSystem) Name() string {
+	return "Google Cloud Storage file system for bucket: " + g.bucketName
+}
+
+func (g *fileSystem) String() string {
+	return g.Name() + " with prefix " + g.prefix
+}
+
+func (g *fileSystem) File(filePath string) fs.File {
+	return g.JoinCleanFile(filePath)
+}
+
+func (g *fileSystem) URL(cleanPath string) string {
+	return g.prefix + cleanPath
+}
+
+func (g *fileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, g.prefix)
+}
+
+func (g *fileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(g.prefix + g.JoinCleanPath(uriParts...))
+}
+
+func (g *fileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, g.prefix, Separator)
+}
+
+func (g *fileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, g.prefix, Separator)
+}
+
+func (g *fileSystem) Separator() string {
+	return Separator
+}
+
+func (g *fileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (g *fileSystem) AbsPath(filePath string) string {
+	if path.IsAbs(filePath) {
+		return filePath
+	}
+	return Separator + filePath
+}
+
+func (g *fileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (g *fileSystem) VolumeName(filePath string) string {
+	return g.bucketName
+}
+
+// isDir returns if there are any objects with
+// the directory prefix of dirPath.
+func (g *fileSystem) isDir(ctx context.Context, dirPath string) (bool, error) {
+	prefix := dirObjectPrefix(dirPath)
+	if prefix == "" {
+		return true, nil // bucket root
+	}
+	it := g.bucket.Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: Separator})
+	_, err := it.Next()
+	if errors.Is(err, iterator.Done) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	ctx := context.Background()
+	if name := objectName(filePath); name != "" {
+		attrs, err := g.bucket.Object(name).Attrs(ctx)
+		if err == nil {
+			return newFileInfo(attrs), nil
+		}
+		if !errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, err
+		}
+	}
+	// Directories are only implied by object name prefixes
+	isDir, err := g.isDir(ctx, filePath)
+	if err != nil {
+		return nil, g.wrapErrNotExist(filePath, err)
+	}
+	if !isDir {
+		return nil, fs.NewErrDoesNotExist(g.File(filePath))
+	}
+	return &fileInfo{name: path.Base(filePath), isDir: true}, nil
+}
+
+func (g *fileSystem) Exists(filePath string) bool {
+	_, err := g.Stat(filePath)
+	return err == nil
+}
+
+// IsHidden returns true if the given file path is not empty and starts with a
+// dot. There are no real "hidden" files in buckets, but since dot prefixes
+// are the general convention to determine which directories/files are hidden
+// and which are not, the function behaves this way.
+func (g *fileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (g *fileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+func (g *fileSystem) listDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string, recursive bool) (err error) {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	prefix := dirObjectPrefix(dirPath)
+	query := &storage.Query{Prefix: prefix}
+	if !recursive {
+		query.Delimiter = Separator
+	}
+	err = query.SetAttrSelection([]string{"Name", "Size", "Updated"})
+	if err != nil {
+		return err
+	}
+
+	// The iterator fetches the next page of
+	// results from the API when the current one is exhausted
+	it := g.bucket.Objects(ctx, query)
+	found := false
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return g.wrapErrNotExist(dirPath, err)
+		}
+		found = true
+
+		var info *fs.FileInfo
+		switch {
+		case attrs.Prefix != "":
+			// Sub-directory implied by the delimiter
+			info = fs.NewFileInfo(
+				g.File(attrs.Prefix),
+				&fileInfo{name: path.Base(attrs.Prefix), isDir: true},
+				g.IsHidden(attrs.Prefix),
+			)
+		case strings.HasSuffix(attrs.Name, Separator):
+			// Skip empty directory marker objects
+			continue
+		default:
+			info = fs.NewFileInfo(g.File(attrs.Name), newFileInfo(attrs), g.IsHidden(attrs.Name))
+		}
+
+		match, err := g.MatchAnyPattern(info.Name, patterns)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		err = callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	if !found && prefix != "" {
+		return fs.NewErrDoesNotExist(g.File(dirPath))
+	}
+	return nil
+}
+
+func (g *fileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) (err error) {
+	return g.listDirInfo(ctx, dirPath, callback, patterns, false)
+}
+
+func (g *fileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) (err error) {
+	return g.listDirInfo(ctx, dirPath, callback, patterns, true)
+}
+
+func (g *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if g.Exists(filePath) {
+		return nil // TODO can we change the modified time?
+	}
+	return g.WriteAll(context.Background(), filePath, nil, perm)
+}
+
+func (g *fileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if objectName(dirPath) == "" {
+		return nil
+	}
+	// Directories don't exist in buckets, so write an empty
+	// marker object with a trailing slash to make it visible
+	return g.WriteAll(context.Background(), dirObjectPrefix(dirPath), nil, perm)
+}
+
+func (g *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	r, err := g.object(filePath).NewReader(ctx)
+	if err != nil {
+		return nil, g.wrapErrNotExist(filePath, err)
+	}
+	defer r.Close()
+
+	return fs.ReadAllContext(ctx, r)
+}
+
+// ReadRange reads a byte range of an object.
+// A negative offset reads the last -offset bytes of the object.
+func (g *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	switch {
+	case offset < 0:
+		length = -1 // required by NewRangeReader for negative offsets
+	case length <= 0:
+		return []byte{}, nil
+	}
+	r, err := g.object(filePath).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, g.wrapErrNotExist(filePath, err)
+	}
+	defer r.Close()
+
+	return fs.ReadAllContext(ctx, r)
+}
+
+func (g *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if g.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	w := g.object(filePath).NewWriter(ctx)
+	// Data is already in memory, no need for chunked uploads
+	w.ChunkSize = 0
+	_, err := io.Copy(w, bytes.NewReader(data))
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (g *fileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	r, err := g.object(filePath).NewReader(context.Background())
+	if err != nil {
+		return nil, g.wrapErrNotExist(filePath, err)
+	}
+	info := &fileInfo{
+		name:    path.Base(filePath),
+		size:    r.Attrs.Size,
+		modTime: r.Attrs.LastModified,
+	}
+	return &objectReader{Reader: r, info: info}, nil
+}
+
+// OpenWriter returns a writer that streams the written data
+// to a resumable upload with chunks of UploadChunkSize.
+// The object is only created when the writer is closed
+// without error.
+func (g *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if g.readOnly {
+		return nil, fs.ErrReadOnlyFileSystem
+	}
+	w := g.object(filePath).NewWriter(context.Background())
+	w.ChunkSize = UploadChunkSize
+	return w, nil
+}
+
+func (g *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if g.readOnly {
+		return nil, fs.ErrReadOnlyFileSystem
+	}
+	current, err := g.ReadAll(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(current, func() error {
+		return g.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+func (g *fileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if g.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	_, err := g.object(destFile).CopierFrom(g.object(srcFile)).Run(ctx)
+	return g.wrapErrNotExist(srcFile, err)
+}
+
+func (g *fileSystem) Move(filePath string, destPath string) error {
+	err := g.CopyFile(context.Background(), filePath, destPath, nil)
+	if err != nil {
+		return err
+	}
+	return g.Remove(filePath)
+}
+
+func (g *fileSystem) Remove(filePath string) error {
+	if g.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	err := g.object(filePath).Delete(context.Background())
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		// Try to remove an empty directory marker object
+		dirErr := g.bucket.Object(dirObjectPrefix(filePath)).Delete(context.Background())
+		if dirErr == nil {
+			return nil
+		}
+	}
+	return g.wrapErrNotExist(filePath, err)
+}
+
+func (g *fileSystem) Watch(filePath string, onEvent func(fs.File, fs.Event)) (cancel func() error, err error) {
+	// Could be implemented with Pub/Sub notifications
+	return nil, errors.ErrUnsupported
+}
+
+func (g *fileSystem) Close() error {
+	if g.client == nil {
+		return nil // already closed
+	}
+	fs.Unregister(g)
+	g.client = nil
+	return nil
+}
+
+// objectReader implements iofs.File for a storage.Reader
+type objectReader struct {
+	*storage.Reader
+	info *fileInfo
+}
+
+func (r *objectReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
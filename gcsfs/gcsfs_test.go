@@ -0,0 +1,17 @@
+package gcsfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectName(t *testing.T) {
+	require.Equal(t, "", objectName("/"))
+	require.Equal(t, "file.txt", objectName("/file.txt"))
+	require.Equal(t, "dir/file.txt", objectName("/dir/file.txt"))
+
+	require.Equal(t, "", dirObjectPrefix("/"))
+	require.Equal(t, "dir/", dirObjectPrefix("/dir"))
+	require.Equal(t, "dir/sub/", dirObjectPrefix("/dir/sub/"))
+}
@@ -0,0 +1,201 @@
+package gdrivefs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+const (
+	// DefaultBaseURL of the Google Drive API
+	DefaultBaseURL = "https://www.googleapis.com"
+
+	folderMimeType = "application/vnd.google-apps.folder"
+
+	// fileFields are the requested fields of Drive files
+	fileFields = "id,name,mimeType,size,modifiedTime,parents"
+)
+
+// driveFile is the subset of the Drive API v3 file resource used by gdrivefs
+type driveFile struct {
+	ID           string    `json:"id,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	MimeType     string    `json:"mimeType,omitempty"`
+	Size         int64     `json:"size,omitempty,string"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	Parents      []string  `json:"parents,omitempty"`
+}
+
+func (f *driveFile) isFolder() bool {
+	return f.MimeType == folderMimeType
+}
+
+type fileList struct {
+	NextPageToken string       `json:"nextPageToken"`
+	Files         []*driveFile `json:"files"`
+}
+
+// apiError is the error response of the Drive API
+type apiError struct {
+	StatusCode int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("Google Drive API error %d: %s", e.StatusCode, e.Message)
+}
+
+// quote returns s as string literal for Drive search queries
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// request sends an API request with an optional JSON body
+// and decodes a JSON response into result if not nil.
+func (f *fileSystem) request(ctx context.Context, method, apiPath string, query url.Values, body, result any) error {
+	response, err := f.send(ctx, method, f.apiURL("/drive/v3"+apiPath, query), body, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// apiURL returns the URL for apiPath with query
+// and the supportsAllDrives parameter
+func (f *fileSystem) apiURL(apiPath string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("supportsAllDrives", "true")
+	return f.baseURL + apiPath + "?" + query.Encode()
+}
+
+// send sends a request and returns the response
+// or an error for status codes that are not 2xx or 308.
+// A []byte body is sent as is, any other body as JSON.
+// The caller has to close the response body.
+func (f *fileSystem) send(ctx context.Context, method, requestURL string, body any, header http.Header) (*http.Response, error) {
+	if f.client == nil {
+		return nil, fs.ErrFileSystemClosed
+	}
+	var bodyReader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		bodyReader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	if _, isRaw := body.([]byte); body != nil && !isRaw {
+		request.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode/100 != 2 && response.StatusCode != http.StatusPermanentRedirect {
+		defer response.Body.Close()
+		return nil, readAPIError(response)
+	}
+	return response, nil
+}
+
+func readAPIError(response *http.Response) error {
+	var errorResponse struct {
+		Error *apiError `json:"error"`
+	}
+	err := json.NewDecoder(response.Body).Decode(&errorResponse)
+	if err != nil || errorResponse.Error == nil {
+		return &apiError{StatusCode: response.StatusCode, Message: response.Status}
+	}
+	errorResponse.Error.StatusCode = response.StatusCode
+	return errorResponse.Error
+}
+
+func (f *fileSystem) getFile(ctx context.Context, fileID string) (*driveFile, error) {
+	var file driveFile
+	err := f.request(ctx, http.MethodGet, "/files/"+url.PathEscape(fileID), url.Values{"fields": {fileFields}}, nil, &file)
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// listFiles calls callback for every file matching the search query q
+// that is not in the trash.
+func (f *fileSystem) listFiles(ctx context.Context, q, orderBy string, callback func(*driveFile) error) error {
+	query := url.Values{
+		"q":                         {q + " and trashed = false"},
+		"fields":                    {"nextPageToken,files(" + fileFields + ")"},
+		"pageSize":                  {"1000"},
+		"orderBy":                   {orderBy},
+		"includeItemsFromAllDrives": {"true"},
+	}
+	if f.driveID != "" {
+		query.Set("corpora", "drive")
+		query.Set("driveId", f.driveID)
+	}
+	for {
+		var list fileList
+		err := f.request(ctx, http.MethodGet, "/files", query, nil, &list)
+		if err != nil {
+			return err
+		}
+		for _, file := range list.Files {
+			err = callback(file)
+			if err != nil {
+				return err
+			}
+		}
+		if list.NextPageToken == "" {
+			return nil
+		}
+		query.Set("pageToken", list.NextPageToken)
+	}
+}
+
+// findChild returns the oldest file with name in the folder parentID
+// or nil if there is no such file.
+// Drive allows multiple files with the same name in a folder,
+// only the oldest one is addressable by path.
+func (f *fileSystem) findChild(ctx context.Context, parentID, name string) (*driveFile, error) {
+	var found *driveFile
+	err := f.listFiles(ctx,
+		fmt.Sprintf("name = %s and %s in parents", quote(name), quote(parentID)),
+		"createdTime",
+		func(file *driveFile) error {
+			if found == nil {
+				found = file
+			}
+			return nil
+		},
+	)
+	return found, err
+}
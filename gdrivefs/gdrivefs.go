@@ -0,0 +1,781 @@
+// Package gdrivefs implements a file system for Google Drive
+// using the Drive API v3.
+//
+// Drive identifies files by IDs and allows multiple files
+// with the same name in a folder. gdrivefs maps paths to IDs
+// by looking up the names from the root folder,
+// where the oldest file wins if names are not unique.
+package gdrivefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of Google Drive file systems
+	Prefix = "gdrive://"
+	// Separator used in Google Drive paths
+	Separator = "/"
+)
+
+var (
+	// DefaultPermissions used for Drive files
+	DefaultPermissions = fs.UserAndGroupReadWrite
+	// DefaultDirPermissions used for Drive folders
+	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllExecute
+
+	// UploadChunkSize is the size of the chunks
+	// of resumable uploads used for writing files.
+	// It must be a multiple of 256 KiB.
+	UploadChunkSize = 8 * 1024 * 1024
+
+	// Make sure fileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(fileSystem)
+)
+
+// fileSystem implements fs.FileSystem for Google Drive
+type fileSystem struct {
+	prefix  string
+	client  *http.Client
+	baseURL string
+	// driveID of a shared drive or empty for My Drive
+	driveID string
+	// rootID is the ID of the root folder
+	rootID        string
+	fileInfoCache *fs.FileInfoCache
+
+	// ids maps clean paths to file IDs
+	ids    map[string]string
+	idsMtx sync.Mutex
+}
+
+// NewAndRegister returns a new fs.FileSystem for the My Drive
+// of the user that client is authenticated for and registers it.
+// The client has to add OAuth 2.0 tokens to the requests,
+// for example a client returned by golang.org/x/oauth2.Config.Client.
+//
+// File metadata is cached for cacheTimeout,
+// a zero cacheTimeout disables the cache.
+func NewAndRegister(client *http.Client, cacheTimeout time.Duration) fs.FileSystem {
+	return newAndRegister(client, "", cacheTimeout)
+}
+
+// NewSharedDriveAndRegister returns a new fs.FileSystem for
+// the shared drive with driveID and registers it.
+// See NewAndRegister.
+func NewSharedDriveAndRegister(client *http.Client, driveID string, cacheTimeout time.Duration) fs.FileSystem {
+	return newAndRegister(client, driveID, cacheTimeout)
+}
+
+func newAndRegister(client *http.Client, driveID string, cacheTimeout time.Duration) *fileSystem {
+	rootID := "root"
+	if driveID != "" {
+		// The ID of the root folder of a shared drive is the drive ID
+		rootID = driveID
+	}
+	gdfs := &fileSystem{
+		prefix:        Prefix + fsimpl.RandomString(),
+		client:        client,
+		baseURL:       DefaultBaseURL,
+		driveID:       driveID,
+		rootID:        rootID,
+		fileInfoCache: fs.NewFileInfoCache(cacheTimeout),
+		ids:           make(map[string]string),
+	}
+	fs.Register(gdfs)
+	return gdfs
+}
+
+// FileID returns the Drive file ID of a file
+// of a file system returned by this package.
+func FileID(ctx context.Context, file fs.File) (string, error) {
+	gdfs, ok := file.FileSystem().(*fileSystem)
+	if !ok {
+		return "", fmt.Errorf("not a Google Drive file: %s", file)
+	}
+	return gdfs.resolveID(ctx, gdfs.CleanPathFromURI(string(file)))
+}
+
+// FileByID returns the file with the Drive file ID
+// in fileSys that has to be returned by this package.
+// The path is built from the first parent folders of the file.
+func FileByID(ctx context.Context, fileSys fs.FileSystem, fileID string) (fs.File, error) {
+	gdfs, ok := fileSys.(*fileSystem)
+	if !ok {
+		return "", fmt.Errorf("not a Google Drive file system: %s", fileSys)
+	}
+	var names []string
+	for id := fileID; id != gdfs.rootID; {
+		file, err := gdfs.getFile(ctx, id)
+		if err != nil {
+			if isNotFound(err) {
+				return "", fs.NewErrPathDoesNotExist(id)
+			}
+			return "", err
+		}
+		if len(file.Parents) == 0 {
+			if id == fileID {
+				return "", fmt.Errorf("Google Drive file %s is not in %s", fileID, gdfs)
+			}
+			// The root folder of My Drive has no parents
+			// and its real ID is different from the alias "root"
+			break
+		}
+		names = append(names, file.Name)
+		id = file.Parents[0]
+	}
+	parts := make([]string, 0, len(names)+1)
+	parts = append(parts, Separator)
+	for i := len(names) - 1; i >= 0; i-- {
+		parts = append(parts, names[i])
+	}
+	return gdfs.JoinCleanFile(parts...), nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+func (gdfs *fileSystem) wrapErrNotExist(filePath string, err error) error {
+	if isNotFound(err) {
+		gdfs.forget(filePath)
+		return fs.NewErrDoesNotExist(gdfs.File(filePath))
+	}
+	return err
+}
+
+func (gdfs *fileSystem) cachedID(filePath string) (id string, ok bool) {
+	gdfs.idsMtx.Lock()
+	defer gdfs.idsMtx.Unlock()
+
+	id, ok = gdfs.ids[filePath]
+	return id, ok
+}
+
+func (gdfs *fileSystem) cache(filePath string, file *driveFile) {
+	gdfs.idsMtx.Lock()
+	gdfs.ids[filePath] = file.ID
+	gdfs.idsMtx.Unlock()
+
+	gdfs.fileInfoCache.Put(filePath, gdfs.fileInfo(filePath, file))
+}
+
+// forget removes filePath and all paths below it from the caches
+// because they can't be resolved to the same IDs anymore.
+func (gdfs *fileSystem) forget(filePath string) {
+	filePath = gdfs.AbsPath(filePath)
+	dirPrefix := strings.TrimSuffix(filePath, Separator) + Separator
+
+	gdfs.idsMtx.Lock()
+	isDir := false
+	for p := range gdfs.ids {
+		if strings.HasPrefix(p, dirPrefix) {
+			delete(gdfs.ids, p)
+			isDir = true
+		}
+	}
+	delete(gdfs.ids, filePath)
+	gdfs.idsMtx.Unlock()
+
+	if isDir {
+		// FileInfoCache can't delete by path prefix
+		gdfs.fileInfoCache.Clear()
+	} else {
+		gdfs.fileInfoCache.Delete(filePath)
+	}
+}
+
+// lookup returns the metadata of the file at filePath
+// by looking up the name in the parent folder.
+func (gdfs *fileSystem) lookup(ctx context.Context, filePath string) (*driveFile, error) {
+	if filePath == Separator {
+		return gdfs.getFile(ctx, gdfs.rootID)
+	}
+	parentID, err := gdfs.resolveID(ctx, path.Dir(filePath))
+	if err != nil {
+		return nil, err
+	}
+	file, err := gdfs.findChild(ctx, parentID, path.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fs.NewErrDoesNotExist(gdfs.File(filePath))
+	}
+	gdfs.cache(filePath, file)
+	return file, nil
+}
+
+// resolveID returns the file ID for filePath
+func (gdfs *fileSystem) resolveID(ctx context.Context, filePath string) (string, error) {
+	filePath = gdfs.AbsPath(filePath)
+	if filePath == Separator {
+		return gdfs.rootID, nil
+	}
+	if id, ok := gdfs.cachedID(filePath); ok {
+		return id, nil
+	}
+	file, err := gdfs.lookup(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
+
+// resolveFile returns the current metadata of the file at filePath
+func (gdfs *fileSystem) resolveFile(ctx context.Context, filePath string) (*driveFile, error) {
+	filePath = gdfs.AbsPath(filePath)
+	if id, ok := gdfs.cachedID(filePath); ok {
+		file, err := gdfs.getFile(ctx, id)
+		if err == nil {
+			gdfs.cache(filePath, file)
+			return file, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		gdfs.forget(filePath)
+	}
+	return gdfs.lookup(ctx, filePath)
+}
+
+func (gdfs *fileSystem) fileInfo(filePath string, file *driveFile) *fs.FileInfo {
+	name := path.Base(filePath)
+	if filePath == Separator {
+		name = ""
+	}
+	info := &fs.FileInfo{
+		File:        gdfs.File(filePath),
+		Name:        name,
+		Exists:      true,
+		IsRegular:   true,
+		IsDir:       file.isFolder(),
+		IsHidden:    len(name) > 0 && name[0] == '.',
+		Size:        file.Size,
+		Modified:    file.ModifiedTime,
+		Permissions: DefaultPermissions,
+	}
+	if info.IsDir {
+		info.Permissions = DefaultDirPermissions
+	}
+	return info
+}
+
+// info returns the cached FileInfo for filePath
+// or requests the metadata of the file
+func (gdfs *fileSystem) info(ctx context.Context, filePath string) (*fs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	filePath = gdfs.AbsPath(filePath)
+	if info, ok := gdfs.fileInfoCache.Get(filePath); ok {
+		return info, nil
+	}
+	file, err := gdfs.resolveFile(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	info := gdfs.fileInfo(filePath, file)
+	gdfs.fileInfoCache.Put(filePath, info)
+	return info, nil
+}
+
+func (gdfs *fileSystem) ReadableWritable() (readable, writable bool) {
+	return true, true
+}
+
+func (gdfs *fileSystem) RootDir() fs.File {
+	return fs.File(gdfs.prefix + Separator)
+}
+
+// ID returns the drive ID of a shared drive
+// or the ID of the root folder of My Drive.
+func (gdfs *fileSystem) ID() (string, error) {
+	if gdfs.driveID != "" {
+		return gdfs.driveID, nil
+	}
+	root, err := gdfs.getFile(context.Background(), gdfs.rootID)
+	if err != nil {
+		return "", err
+	}
+	return root.ID, nil
+}
+
+func (gdfs *fileSystem) Prefix() string {
+	return gdfs.prefix
+}
+
+func (gdfs *fileSystem) Name() string {
+	if gdfs.driveID != "" {
+		return "Google Drive file system for shared drive " + gdfs.driveID
+	}
+	return "Google Drive file system"
+}
+
+// String implements the fmt.Stringer interface.
+func (gdfs *fileSystem) String() string {
+	return gdfs.Name() + " with prefix " + gdfs.Prefix()
+}
+
+func (gdfs *fileSystem) File(filePath string) fs.File {
+	return gdfs.JoinCleanFile(filePath)
+}
+
+func (gdfs *fileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(gdfs.prefix + gdfs.JoinCleanPath(uriParts...))
+}
+
+func (gdfs *fileSystem) URL(cleanPath string) string {
+	return gdfs.prefix + cleanPath
+}
+
+func (gdfs *fileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, gdfs.prefix)
+}
+
+func (gdfs *fileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, gdfs.prefix, Separator)
+}
+
+func (gdfs *fileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, gdfs.prefix, Separator)
+}
+
+func (gdfs *fileSystem) Separator() string {
+	return Separator
+}
+
+func (*fileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (gdfs *fileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (gdfs *fileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (gdfs *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	info, err := gdfs.info(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	return info.StdFileInfo(), nil
+}
+
+func (gdfs *fileSystem) Exists(filePath string) bool {
+	_, err := gdfs.info(context.Background(), filePath)
+	return err == nil
+}
+
+func (gdfs *fileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (gdfs *fileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+// ListDirInfo lists the folders first
+// and then the files sorted by name.
+func (gdfs *fileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	info, err := gdfs.info(ctx, dirPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir {
+		return fs.NewErrIsNotDirectory(gdfs.File(dirPath))
+	}
+	dirPath = gdfs.AbsPath(dirPath)
+	dirID, err := gdfs.resolveID(ctx, dirPath)
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]bool)
+	return gdfs.listFiles(ctx, quote(dirID)+" in parents", "folder,name,createdTime", func(file *driveFile) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if listed[file.Name] {
+			return nil // Only the oldest file with the same name has a path
+		}
+		listed[file.Name] = true
+		match, err := gdfs.MatchAnyPattern(file.Name, patterns)
+		if !match || err != nil {
+			return err
+		}
+		filePath := path.Join(dirPath, file.Name)
+		gdfs.cache(filePath, file)
+		return callback(gdfs.fileInfo(filePath, file))
+	})
+}
+
+// Touch creates an empty file if filePath does not exist
+// or sets the modified time of an existing file to now.
+func (gdfs *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	ctx := context.Background()
+	id, err := gdfs.resolveID(ctx, filePath)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return gdfs.WriteAll(ctx, filePath, nil, perm)
+	}
+	if err != nil {
+		return err
+	}
+	var file driveFile
+	err = gdfs.request(ctx, http.MethodPatch, "/files/"+url.PathEscape(id),
+		url.Values{"fields": {fileFields}},
+		map[string]any{"modifiedTime": time.Now().UTC()},
+		&file,
+	)
+	if err != nil {
+		return gdfs.wrapErrNotExist(filePath, err)
+	}
+	gdfs.cache(gdfs.AbsPath(filePath), &file)
+	return nil
+}
+
+func (gdfs *fileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	ctx := context.Background()
+	dirPath = gdfs.AbsPath(dirPath)
+	if gdfs.Exists(dirPath) {
+		return fs.NewErrAlreadyExists(gdfs.File(dirPath))
+	}
+	parentID, err := gdfs.resolveID(ctx, path.Dir(dirPath))
+	if err != nil {
+		return err
+	}
+	var file driveFile
+	err = gdfs.request(ctx, http.MethodPost, "/files",
+		url.Values{"fields": {fileFields}},
+		map[string]any{
+			"name":     path.Base(dirPath),
+			"mimeType": folderMimeType,
+			"parents":  []string{parentID},
+		},
+		&file,
+	)
+	if err != nil {
+		return err
+	}
+	gdfs.cache(dirPath, &file)
+	return nil
+}
+
+// download starts the download of the file content,
+// rangeHeader is optional.
+func (gdfs *fileSystem) download(ctx context.Context, filePath, rangeHeader string) (*http.Response, *fs.FileInfo, error) {
+	info, err := gdfs.info(ctx, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir {
+		return nil, nil, fs.NewErrIsDirectory(gdfs.File(filePath))
+	}
+	id, err := gdfs.resolveID(ctx, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var header http.Header
+	if rangeHeader != "" {
+		header = http.Header{"Range": {rangeHeader}}
+	}
+	requestURL := gdfs.apiURL("/drive/v3/files/"+url.PathEscape(id), url.Values{"alt": {"media"}})
+	response, err := gdfs.send(ctx, http.MethodGet, requestURL, nil, header)
+	if err != nil {
+		return nil, nil, gdfs.wrapErrNotExist(filePath, err)
+	}
+	return response, info, nil
+}
+
+func (gdfs *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	response, _, err := gdfs.download(ctx, filePath, "")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	return fs.ReadAllContext(ctx, response.Body)
+}
+
+// ReadRange reads a byte range of a file using a HTTP Range header.
+// A negative offset reads the last -offset bytes of the file.
+func (gdfs *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset >= 0 && length <= 0 {
+		return []byte{}, nil
+	}
+	rangeHeader := fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	if offset < 0 {
+		rangeHeader = fmt.Sprintf("bytes=%d", offset)
+	}
+	response, _, err := gdfs.download(ctx, filePath, rangeHeader)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return []byte{}, nil // offset after the end of the file
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusPartialContent && offset > 0 {
+		// The whole file was returned
+		_, err = io.CopyN(io.Discard, response.Body, offset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if offset < 0 {
+		length = -offset
+	}
+	return fs.ReadAllContext(ctx, io.LimitReader(response.Body, length))
+}
+
+func (gdfs *fileSystem) OpenReader(filePath string) (iofs.File, error) {
+	response, info, err := gdfs.download(context.Background(), filePath, "")
+	if err != nil {
+		return nil, err
+	}
+	return &fileReader{ReadCloser: response.Body, info: info.StdFileInfo()}, nil
+}
+
+// WriteAll uploads data with a resumable upload.
+// The parent folder must exist.
+func (gdfs *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	upload, err := gdfs.startUpload(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	_, err = upload.Write(data)
+	return errors.Join(err, upload.Close())
+}
+
+// OpenWriter starts a resumable upload
+// that sends the written data in chunks of UploadChunkSize.
+// The parent folder must exist.
+func (gdfs *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	return gdfs.startUpload(context.Background(), filePath)
+}
+
+func (gdfs *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	data, err := gdfs.ReadAll(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(data, func() error {
+		return gdfs.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// removeID deletes the file with id if not empty
+func (gdfs *fileSystem) removeID(ctx context.Context, id string) error {
+	if id == "" {
+		return nil
+	}
+	return gdfs.request(ctx, http.MethodDelete, "/files/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// existingFileID returns the ID of the file at filePath,
+// an empty string if it does not exist,
+// or an error if it is a directory.
+func (gdfs *fileSystem) existingFileID(ctx context.Context, filePath string) (string, error) {
+	info, err := gdfs.info(ctx, filePath)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir {
+		return "", fs.NewErrIsDirectory(gdfs.File(filePath))
+	}
+	return gdfs.resolveID(ctx, filePath)
+}
+
+// CopyFile copies the file on the server side.
+// An existing destFile is replaced.
+func (gdfs *fileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	srcID, err := gdfs.resolveID(ctx, srcFile)
+	if err != nil {
+		return err
+	}
+	destFile = gdfs.AbsPath(destFile)
+	destParentID, err := gdfs.resolveID(ctx, path.Dir(destFile))
+	if err != nil {
+		return err
+	}
+	replacedID, err := gdfs.existingFileID(ctx, destFile)
+	if err != nil {
+		return err
+	}
+	var file driveFile
+	err = gdfs.request(ctx, http.MethodPost, "/files/"+url.PathEscape(srcID)+"/copy",
+		url.Values{"fields": {fileFields}},
+		map[string]any{
+			"name":    path.Base(destFile),
+			"parents": []string{destParentID},
+		},
+		&file,
+	)
+	if err != nil {
+		return gdfs.wrapErrNotExist(srcFile, err)
+	}
+	// Delete the replaced file after the copy
+	// so that the oldest file with the name is the copy
+	gdfs.forget(destFile)
+	err = gdfs.removeID(ctx, replacedID)
+	if err != nil {
+		return err
+	}
+	gdfs.cache(destFile, &file)
+	return nil
+}
+
+// update changes the name and parent folder of a file,
+// an existing file at destPath is replaced.
+func (gdfs *fileSystem) update(ctx context.Context, filePath, destPath string) error {
+	filePath = gdfs.AbsPath(filePath)
+	destPath = gdfs.AbsPath(destPath)
+	file, err := gdfs.resolveFile(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	if filePath == Separator {
+		return fmt.Errorf("can't move root folder of %s", gdfs)
+	}
+	replacedID, err := gdfs.existingFileID(ctx, destPath)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"fields": {fileFields}}
+	if path.Dir(filePath) != path.Dir(destPath) {
+		oldParentID, err := gdfs.resolveID(ctx, path.Dir(filePath))
+		if err != nil {
+			return err
+		}
+		newParentID, err := gdfs.resolveID(ctx, path.Dir(destPath))
+		if err != nil {
+			return err
+		}
+		query.Set("addParents", newParentID)
+		query.Set("removeParents", oldParentID)
+	}
+	var updated driveFile
+	err = gdfs.request(ctx, http.MethodPatch, "/files/"+url.PathEscape(file.ID), query,
+		map[string]any{"name": path.Base(destPath)},
+		&updated,
+	)
+	if err != nil {
+		return gdfs.wrapErrNotExist(filePath, err)
+	}
+	gdfs.forget(filePath)
+	gdfs.forget(destPath)
+	if replacedID != file.ID {
+		err = gdfs.removeID(ctx, replacedID)
+		if err != nil {
+			return err
+		}
+	}
+	gdfs.cache(destPath, &updated)
+	return nil
+}
+
+func (gdfs *fileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	newPath := path.Join(path.Dir(gdfs.AbsPath(filePath)), newName)
+	err := gdfs.update(context.Background(), filePath, newPath)
+	if err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+// Move moves the file on the server side.
+// If destPath is an existing directory, then the file is moved into it,
+// else an existing file at destPath is replaced.
+func (gdfs *fileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if info, err := gdfs.info(context.Background(), destPath); err == nil && info.IsDir {
+		destPath = path.Join(destPath, path.Base(filePath))
+	}
+	return gdfs.update(context.Background(), filePath, destPath)
+}
+
+// Remove deletes the file permanently without moving it to the trash.
+// Note that folders are deleted recursively.
+func (gdfs *fileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	ctx := context.Background()
+	id, err := gdfs.resolveID(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	if id == gdfs.rootID {
+		return fmt.Errorf("can't remove root folder of %s", gdfs)
+	}
+	err = gdfs.removeID(ctx, id)
+	gdfs.forget(filePath)
+	return gdfs.wrapErrNotExist(filePath, err)
+}
+
+func (gdfs *fileSystem) Close() error {
+	if gdfs.client == nil {
+		return nil // already closed
+	}
+	fs.Unregister(gdfs)
+	gdfs.client = nil
+	return nil
+}
+
+// fileReader implements iofs.File for a download response body
+type fileReader struct {
+	io.ReadCloser
+	info iofs.FileInfo
+}
+
+func (r *fileReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
@@ -0,0 +1,363 @@
+package gdrivefs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+// fakeDrive is a minimal in-memory implementation
+// of the Drive API v3 endpoints used by gdrivefs
+type fakeDrive struct {
+	mtx      sync.Mutex
+	server   *httptest.Server
+	files    map[string]*driveFile
+	content  map[string][]byte
+	created  map[string]int
+	sessions map[string]*fakeSession
+	nextID   int
+}
+
+type fakeSession struct {
+	file *driveFile
+	data []byte
+}
+
+var (
+	queryNameRegexp   = regexp.MustCompile(`^name = '((?:[^'\\]|\\.)*)' and '([^']*)' in parents and trashed = false$`)
+	queryParentRegexp = regexp.MustCompile(`^'([^']*)' in parents and trashed = false$`)
+)
+
+func newFakeDrive(t *testing.T) *fakeDrive {
+	d := &fakeDrive{
+		files:    map[string]*driveFile{"root": {ID: "root", Name: "My Drive", MimeType: folderMimeType}},
+		content:  make(map[string][]byte),
+		created:  make(map[string]int),
+		sessions: make(map[string]*fakeSession),
+	}
+	d.server = httptest.NewServer(http.HandlerFunc(d.serveHTTP))
+	t.Cleanup(d.server.Close)
+	return d
+}
+
+func (d *fakeDrive) newFile(name, mimeType, parentID string) *driveFile {
+	d.nextID++
+	file := &driveFile{
+		ID:           fmt.Sprintf("id%d", d.nextID),
+		Name:         name,
+		MimeType:     mimeType,
+		ModifiedTime: time.Now().UTC(),
+		Parents:      []string{parentID},
+	}
+	d.files[file.ID] = file
+	d.created[file.ID] = d.nextID
+	return file
+}
+
+func (d *fakeDrive) remove(id string) {
+	for childID, child := range d.files {
+		if slices.Contains(child.Parents, id) {
+			d.remove(childID)
+		}
+	}
+	delete(d.files, id)
+	delete(d.content, id)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int) {
+	writeJSON(w, status, map[string]any{"error": apiError{StatusCode: status, Message: http.StatusText(status)}})
+}
+
+func (d *fakeDrive) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	query := r.URL.Query()
+	var body map[string]any
+	if r.Header.Get("Content-Type") == "application/json; charset=UTF-8" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+	}
+	parents := func() []string {
+		var ids []string
+		for _, id := range body["parents"].([]any) {
+			ids = append(ids, id.(string))
+		}
+		return ids
+	}
+
+	switch p := r.URL.Path; {
+	case p == "/drive/v3/files" && r.Method == http.MethodGet:
+		var match func(*driveFile) bool
+		if m := queryNameRegexp.FindStringSubmatch(query.Get("q")); m != nil {
+			name := strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(m[1])
+			match = func(f *driveFile) bool { return f.Name == name && slices.Contains(f.Parents, m[2]) }
+		} else if m := queryParentRegexp.FindStringSubmatch(query.Get("q")); m != nil {
+			match = func(f *driveFile) bool { return slices.Contains(f.Parents, m[1]) }
+		} else {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		list := fileList{Files: []*driveFile{}}
+		for _, file := range d.files {
+			if match(file) {
+				list.Files = append(list.Files, file)
+			}
+		}
+		slices.SortFunc(list.Files, func(a, b *driveFile) int {
+			if query.Get("orderBy") != "createdTime" {
+				if a.isFolder() != b.isFolder() {
+					if a.isFolder() {
+						return -1
+					}
+					return 1
+				}
+				if c := strings.Compare(a.Name, b.Name); c != 0 {
+					return c
+				}
+			}
+			return d.created[a.ID] - d.created[b.ID]
+		})
+		writeJSON(w, http.StatusOK, list)
+
+	case p == "/drive/v3/files" && r.Method == http.MethodPost:
+		file := d.newFile(body["name"].(string), body["mimeType"].(string), parents()[0])
+		writeJSON(w, http.StatusOK, file)
+
+	case p == "/upload/drive/v3/files" && r.Method == http.MethodPost:
+		file := &driveFile{Name: body["name"].(string), Parents: parents()}
+		d.startSession(w, file)
+
+	case strings.HasPrefix(p, "/upload/drive/v3/files/") && r.Method == http.MethodPatch:
+		file, ok := d.files[strings.TrimPrefix(p, "/upload/drive/v3/files/")]
+		if !ok {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		d.startSession(w, file)
+
+	case strings.HasPrefix(p, "/upload/session/") && r.Method == http.MethodPut:
+		session, ok := d.sessions[p]
+		if !ok {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		session.data = append(session.data, data...)
+		if strings.HasSuffix(r.Header.Get("Content-Range"), "/*") {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(session.data)-1))
+			w.WriteHeader(http.StatusPermanentRedirect)
+			return
+		}
+		file := session.file
+		if file.ID == "" {
+			file = d.newFile(file.Name, "text/plain", file.Parents[0])
+		}
+		file.Size = int64(len(session.data))
+		file.ModifiedTime = time.Now().UTC()
+		d.content[file.ID] = session.data
+		delete(d.sessions, p)
+		writeJSON(w, http.StatusOK, file)
+
+	case strings.HasPrefix(p, "/drive/v3/files/"):
+		id, op, _ := strings.Cut(strings.TrimPrefix(p, "/drive/v3/files/"), "/")
+		file, ok := d.files[id]
+		if !ok {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		switch {
+		case op == "copy" && r.Method == http.MethodPost:
+			copied := d.newFile(body["name"].(string), file.MimeType, parents()[0])
+			copied.Size = file.Size
+			d.content[copied.ID] = d.content[id]
+			writeJSON(w, http.StatusOK, copied)
+		case r.Method == http.MethodGet && query.Get("alt") == "media":
+			data := d.content[id]
+			if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+				first, last, _ := strings.Cut(strings.TrimPrefix(rangeHeader, "bytes="), "-")
+				start, _ := strconv.Atoi(first)
+				end, err := strconv.Atoi(last)
+				if first == "" {
+					// Suffix range of the last bytes
+					start, err = max(len(data)-end, 0), io.EOF
+				}
+				if err != nil || end >= len(data) {
+					end = len(data) - 1
+				}
+				if start >= len(data) {
+					writeError(w, http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write(data[start : end+1])
+				return
+			}
+			_, _ = w.Write(data)
+		case r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, file)
+		case r.Method == http.MethodPatch:
+			if name, ok := body["name"].(string); ok {
+				file.Name = name
+			}
+			if _, ok := body["modifiedTime"]; ok {
+				file.ModifiedTime = time.Now().UTC()
+			}
+			if add := query.Get("addParents"); add != "" {
+				file.Parents = []string{add}
+			}
+			writeJSON(w, http.StatusOK, file)
+		case r.Method == http.MethodDelete:
+			d.remove(id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed)
+		}
+
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func (d *fakeDrive) startSession(w http.ResponseWriter, file *driveFile) {
+	sessionPath := fmt.Sprintf("/upload/session/%d", len(d.sessions)+d.nextID)
+	d.sessions[sessionPath] = &fakeSession{file: file}
+	w.Header().Set("Location", d.server.URL+sessionPath)
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestFileSystem(t *testing.T) {
+	drive := newFakeDrive(t)
+	gdfs := newAndRegister(drive.server.Client(), "", time.Minute)
+	gdfs.baseURL = drive.server.URL
+	t.Cleanup(func() { _ = gdfs.Close() })
+	ctx := context.Background()
+	root := gdfs.RootDir()
+
+	require.True(t, root.IsDir())
+	require.NoError(t, root.Join("dir").MakeDir())
+	require.NoError(t, root.Join("dir", "sub").MakeDir())
+	require.ErrorAs(t, gdfs.MakeDir("/dir", nil), new(fs.ErrAlreadyExists))
+	require.NoError(t, root.Join("dir", "file.txt").WriteAllString("Hello World!"))
+	require.NoError(t, root.Join("dir", ".hidden").Touch())
+
+	files, err := root.Join("dir").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sub", ".hidden", "file.txt"}, fs.FileNames(files), "folders first sorted by name")
+	require.True(t, root.Join("dir", ".hidden").IsHidden())
+	require.Equal(t, int64(0), root.Join("dir", ".hidden").Size())
+
+	file := root.Join("dir", "file.txt")
+	require.Equal(t, int64(12), file.Size())
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World!", str)
+	data, err := gdfs.ReadRange(ctx, "/dir/file.txt", 6, 5)
+	require.NoError(t, err)
+	require.Equal(t, []byte("World"), data)
+	data, err = gdfs.ReadRange(ctx, "/dir/file.txt", 6, 100)
+	require.NoError(t, err)
+	require.Equal(t, []byte("World!"), data)
+	data, err = gdfs.ReadRange(ctx, "/dir/file.txt", -2, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("d!"), data)
+	data, err = gdfs.ReadRange(ctx, "/dir/file.txt", 100, 5)
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, data)
+
+	require.NoError(t, file.WriteAllString("Overwritten"))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Overwritten", str)
+	files, err = root.Join("dir").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Len(t, files, 3, "overwriting keeps the file ID")
+
+	require.NoError(t, fs.CopyFile(ctx, file, root.Join("copy.txt")))
+	str, err = root.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Overwritten", str)
+
+	renamed, err := root.Join("copy.txt").Rename("renamed.txt")
+	require.NoError(t, err)
+	require.False(t, root.Join("copy.txt").Exists())
+	str, err = renamed.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Overwritten", str)
+
+	require.NoError(t, renamed.MoveTo(root.Join("dir", "sub")))
+	require.False(t, renamed.Exists())
+	str, err = root.Join("dir", "sub", "renamed.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Overwritten", str)
+
+	id, err := FileID(ctx, root.Join("dir", "sub", "renamed.txt"))
+	require.NoError(t, err)
+	byID, err := FileByID(ctx, gdfs, id)
+	require.NoError(t, err)
+	require.Equal(t, root.Join("dir", "sub", "renamed.txt"), byID)
+
+	require.NoError(t, root.Join("dir").Remove())
+	require.False(t, root.Join("dir").Exists())
+	require.False(t, root.Join("dir", "sub", "renamed.txt").Exists(), "folders are removed recursively")
+	_, err = file.ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestResumableUpload(t *testing.T) {
+	defer func(chunkSize int) { UploadChunkSize = chunkSize }(UploadChunkSize)
+	UploadChunkSize = 256 * 1024
+
+	drive := newFakeDrive(t)
+	gdfs := newAndRegister(drive.server.Client(), "", time.Minute)
+	gdfs.baseURL = drive.server.URL
+	t.Cleanup(func() { _ = gdfs.Close() })
+	file := gdfs.RootDir().Join("large.bin")
+	data := bytes.Repeat([]byte("0123456789"), 60*1024)
+
+	writer, err := file.OpenWriter()
+	require.NoError(t, err)
+	for i := 0; i < len(data); i += 1000 {
+		_, err = writer.Write(data[i:min(i+1000, len(data))])
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.Equal(t, int64(len(data)), file.Size())
+	read, err := file.ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+
+	UploadChunkSize = 1000
+	_, err = file.OpenWriter()
+	require.Error(t, err, "chunk size must be a multiple of 256 KiB")
+}
+
+func TestQuote(t *testing.T) {
+	require.Equal(t, `'name'`, quote("name"))
+	require.Equal(t, `'it\'s'`, quote("it's"))
+	require.Equal(t, `'a\\b'`, quote(`a\b`))
+}
@@ -0,0 +1,165 @@
+package gdrivefs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ungerik/go-fs"
+)
+
+// upload implements fs.WriteCloser as a resumable upload session
+// that sends the written data in chunks of UploadChunkSize.
+type upload struct {
+	gdfs       *fileSystem
+	ctx        context.Context
+	filePath   string
+	sessionURL string
+	chunkSize  int
+	buf        []byte
+	// offset is the number of bytes received by the server
+	offset int64
+	closed bool
+}
+
+// startUpload starts a resumable upload session
+// that creates filePath or updates an existing file.
+func (gdfs *fileSystem) startUpload(ctx context.Context, filePath string) (*upload, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	chunkSize := UploadChunkSize
+	if chunkSize <= 0 || chunkSize%(256*1024) != 0 {
+		return nil, fmt.Errorf("gdrivefs.UploadChunkSize %d is not a positive multiple of 256 KiB", chunkSize)
+	}
+	filePath = gdfs.AbsPath(filePath)
+	fileID, err := gdfs.existingFileID(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{
+		"uploadType": {"resumable"},
+		"fields":     {fileFields},
+	}
+	var response *http.Response
+	if fileID != "" {
+		requestURL := gdfs.apiURL("/upload/drive/v3/files/"+url.PathEscape(fileID), query)
+		response, err = gdfs.send(ctx, http.MethodPatch, requestURL, map[string]any{}, nil)
+	} else {
+		parentID, e := gdfs.resolveID(ctx, path.Dir(filePath))
+		if e != nil {
+			return nil, e
+		}
+		requestURL := gdfs.apiURL("/upload/drive/v3/files", query)
+		response, err = gdfs.send(ctx, http.MethodPost, requestURL, map[string]any{
+			"name":    path.Base(filePath),
+			"parents": []string{parentID},
+		}, nil)
+	}
+	if err != nil {
+		return nil, gdfs.wrapErrNotExist(filePath, err)
+	}
+	response.Body.Close()
+	sessionURL := response.Header.Get("Location")
+	if sessionURL == "" {
+		return nil, errors.New("Google Drive API returned no upload session URL")
+	}
+	return &upload{
+		gdfs:       gdfs,
+		ctx:        ctx,
+		filePath:   filePath,
+		sessionURL: sessionURL,
+		chunkSize:  chunkSize,
+	}, nil
+}
+
+func (u *upload) Write(data []byte) (int, error) {
+	if u.closed {
+		return 0, fs.ErrFileSystemClosed
+	}
+	u.buf = append(u.buf, data...)
+	// Keep at least one byte for the final chunk
+	// because only Close knows the total size
+	for len(u.buf) > u.chunkSize {
+		_, err := u.sendChunk(u.chunkSize, false)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// sendChunk sends the first n bytes of the buffer
+// and removes the bytes received by the server from it.
+// The final chunk returns the uploaded file.
+func (u *upload) sendChunk(n int, final bool) (*driveFile, error) {
+	var contentRange string
+	switch {
+	case final && u.offset+int64(n) == 0:
+		contentRange = "bytes */0"
+	case final:
+		contentRange = fmt.Sprintf("bytes %d-%d/%d", u.offset, u.offset+int64(n)-1, u.offset+int64(n))
+	default:
+		contentRange = fmt.Sprintf("bytes %d-%d/*", u.offset, u.offset+int64(n)-1)
+	}
+	header := http.Header{"Content-Range": {contentRange}}
+	response, err := u.gdfs.send(u.ctx, http.MethodPut, u.sessionURL, u.buf[:n], header)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusPermanentRedirect {
+		// Resume Incomplete, the Range header
+		// contains the bytes received so far
+		received := int64(0)
+		if r := response.Header.Get("Range"); r != "" {
+			_, last, _ := strings.Cut(r, "-")
+			end, err := strconv.ParseInt(last, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Range header %q of upload response: %w", r, err)
+			}
+			received = end + 1
+		}
+		if received < u.offset || received > u.offset+int64(n) {
+			return nil, fmt.Errorf("invalid Range header %q of upload response", response.Header.Get("Range"))
+		}
+		u.buf = u.buf[received-u.offset:]
+		u.offset = received
+		if final {
+			return nil, fmt.Errorf("upload of %s is incomplete", u.filePath)
+		}
+		return nil, nil
+	}
+
+	u.offset += int64(n)
+	u.buf = u.buf[n:]
+	var file driveFile
+	err = json.NewDecoder(response.Body).Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Close sends the buffered data as final chunk
+// and completes the upload.
+func (u *upload) Close() error {
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+	file, err := u.sendChunk(len(u.buf), true)
+	if err != nil {
+		return err
+	}
+	u.gdfs.forget(u.filePath)
+	u.gdfs.cache(u.filePath, file)
+	return nil
+}
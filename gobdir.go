@@ -0,0 +1,101 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MaxGobEncodeDirSize is the maximum total size in bytes of all files
+// in a directory tree that File.GobEncode will encode.
+var MaxGobEncodeDirSize int64 = 64 * 1024 * 1024
+
+// gobDirEntry is a file or sub-directory
+// of a directory encoded by File.GobEncode
+type gobDirEntry struct {
+	Name    string
+	IsDir   bool
+	Data    []byte
+	Entries []gobDirEntry
+}
+
+// gobEncodeDir encodes the directory name followed by
+// empty file data for compatibility with the file encoding
+// and the entries of the directory tree
+func (file File) gobEncodeDir() ([]byte, error) {
+	var size int64
+	entries, err := gobDirEntries(file, &size)
+	if err != nil {
+		return nil, fmt.Errorf("File.GobEncode: %w", err)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 64+size))
+	enc := gob.NewEncoder(buf)
+	err = enc.Encode(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("File.GobEncode: error encoding directory name: %w", err)
+	}
+	err = enc.Encode([]byte{})
+	if err != nil {
+		return nil, fmt.Errorf("File.GobEncode: error encoding directory data: %w", err)
+	}
+	err = enc.Encode(entries)
+	if err != nil {
+		return nil, fmt.Errorf("File.GobEncode: error encoding directory entries: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gobDirEntries reads the entries of dir recursively sorted by name
+// and adds the size of the read files to totalSize
+func gobDirEntries(dir File, totalSize *int64) (entries []gobDirEntry, err error) {
+	err = dir.ListDirInfo(func(info *FileInfo) (err error) {
+		entry := gobDirEntry{Name: info.Name, IsDir: info.IsDir}
+		if info.IsDir {
+			entry.Entries, err = gobDirEntries(info.File, totalSize)
+		} else {
+			*totalSize += info.Size
+			if *totalSize > MaxGobEncodeDirSize {
+				return fmt.Errorf("%w: directory %s is larger than MaxGobEncodeDirSize of %d bytes", ErrTooLarge, dir, MaxGobEncodeDirSize)
+			}
+			entry.Data, err = info.File.ReadAll()
+		}
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b gobDirEntry) int { return strings.Compare(a.Name, b.Name) })
+	return entries, nil
+}
+
+// gobDecodeDir writes the decoded entries into file as directory
+func (file File) gobDecodeDir(entries []gobDirEntry) error {
+	err := file.MakeAllDirs()
+	if err != nil {
+		return fmt.Errorf("File.GobDecode: error creating directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name == "" || entry.Name == "." || entry.Name == ".." || strings.ContainsAny(entry.Name, `/\`) {
+			return fmt.Errorf("File.GobDecode: invalid directory entry name %q in %s", entry.Name, file)
+		}
+		child := file.Join(entry.Name)
+		if entry.IsDir {
+			err = child.gobDecodeDir(entry.Entries)
+		} else {
+			err = child.WriteAll(entry.Data)
+			if err != nil {
+				err = fmt.Errorf("File.GobDecode: error writing file data: %w", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_GobEncodeDir(t *testing.T) {
+	src := File(t.TempDir())
+	require.NoError(t, src.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, src.Join("sub").MakeDir())
+	require.NoError(t, src.Join("sub", "b.txt").WriteAllString("B"))
+	require.NoError(t, src.Join("empty").MakeDir())
+
+	encoded, err := src.GobEncode()
+	require.NoError(t, err)
+
+	dest := File(t.TempDir()).Join("dest")
+	require.NoError(t, dest.GobDecode(encoded))
+	require.True(t, dest.IsDir())
+	requireFileContent(t, dest.Join("a.txt"), "A")
+	requireFileContent(t, dest.Join("sub", "b.txt"), "B")
+	require.True(t, dest.Join("empty").IsDir())
+
+	// Regular files are still encoded as before
+	encodedFile, err := src.Join("a.txt").GobEncode()
+	require.NoError(t, err)
+	decodedFile := dest.Join("decoded.txt")
+	require.NoError(t, decodedFile.GobDecode(encodedFile))
+	requireFileContent(t, decodedFile, "A")
+
+	prevMax := MaxGobEncodeDirSize
+	t.Cleanup(func() { MaxGobEncodeDirSize = prevMax })
+	MaxGobEncodeDirSize = 1
+	_, err = src.GobEncode()
+	require.ErrorIs(t, err, ErrTooLarge)
+}
@@ -0,0 +1,182 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"regexp"
+	"runtime"
+	"sync"
+)
+
+// Match is a line of a file matched by Grep.
+type Match struct {
+	File File
+	// Line is the 1 based line number
+	Line int
+	// Text of the line without line ending
+	Text string
+}
+
+// Grep searches all files for lines matching pattern
+// using runtime.NumCPU() files in parallel
+// and returns an iterator over the matches.
+//
+// The matches of a file are yielded together in line order,
+// but the order of the files depends on when their search finished.
+// An error of a file is yielded with a Match that only has the File set
+// and the search continues with the other files.
+// Stopping the iteration or canceling the context stops the search.
+func Grep(ctx context.Context, pattern *regexp.Regexp, files iter.Seq[File]) iter.Seq2[Match, error] {
+	parentCtx := ctx
+	return func(yield func(Match, error) bool) {
+		ctx, cancel := context.WithCancel(parentCtx)
+		defer cancel()
+
+		type result struct {
+			file    File
+			matches []Match
+			err     error
+		}
+		var (
+			fileChan   = make(chan File)
+			resultChan = make(chan result)
+			workers    sync.WaitGroup
+		)
+		for range runtime.NumCPU() {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				for file := range fileChan {
+					matches, err := grepFile(ctx, pattern, file)
+					select {
+					case resultChan <- result{file, matches, err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			defer func() {
+				close(fileChan)
+				workers.Wait()
+				close(resultChan)
+			}()
+			for file := range files {
+				select {
+				case fileChan <- file:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		stopped := false
+		for r := range resultChan {
+			if r.err != nil {
+				stopped = !yield(Match{File: r.file}, r.err)
+			}
+			for _, match := range r.matches {
+				if stopped = !yield(match, nil); stopped {
+					break
+				}
+			}
+			if stopped {
+				cancel()
+				break
+			}
+		}
+		// Let the goroutines finish
+		for range resultChan {
+		}
+		if !stopped && parentCtx.Err() != nil {
+			yield(Match{}, parentCtx.Err())
+		}
+	}
+}
+
+func grepFile(ctx context.Context, pattern *regexp.Regexp, file File) ([]Match, error) {
+	r, err := file.OpenReader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var matches []Match
+	err = forEachLine(ctx, r, func(lineNo int, line []byte) {
+		if pattern.Match(line) {
+			matches = append(matches, Match{File: file, Line: lineNo, Text: string(line)})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// forEachLine calls onLine for every line of r
+// without the line ending.
+func forEachLine(ctx context.Context, r io.Reader, onLine func(lineNo int, line []byte)) error {
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimSuffix(line, []byte{'\n'})
+			line = bytes.TrimSuffix(line, []byte{'\r'})
+			onLine(lineNo, line)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CountLines returns the number of lines of the file
+// counted the same way as the lines returned by ReadAllLines,
+// meaning that a line ending at the end of the file
+// does not start another line.
+// The file is read as a stream, so it is not loaded into memory completely.
+func (file File) CountLines(ctx context.Context) (int, error) {
+	r, err := file.OpenReader()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var (
+		buf      = make([]byte, 64*1024)
+		count    = 0
+		lastByte = byte('\n')
+	)
+	for {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			count += bytes.Count(buf[:n], []byte{'\n'})
+			lastByte = buf[n-1]
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if lastByte != '\n' {
+		// Last line without line ending
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrep(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	var files []File
+	for i := range 10 {
+		file := dir.Join(fmt.Sprintf("file%d.txt", i))
+		err := file.WriteAllString(fmt.Sprintf("first line\r\nmatch %d\nno\nmatch again %d", i, i))
+		require.NoError(t, err)
+		files = append(files, file)
+	}
+	missing := dir.Join("missing.txt")
+
+	var (
+		matches []string
+		errs    []error
+	)
+	pattern := regexp.MustCompile(`^match`)
+	for match, err := range Grep(ctx, pattern, slices.Values(append(files, missing))) {
+		if err != nil {
+			require.Equal(t, missing, match.File)
+			errs = append(errs, err)
+			continue
+		}
+		matches = append(matches, fmt.Sprintf("%s:%d:%s", match.File.Name(), match.Line, match.Text))
+	}
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], os.ErrNotExist)
+	sort.Strings(matches)
+	require.Len(t, matches, 20)
+	require.Equal(t, "file0.txt:2:match 0", matches[0])
+	require.Equal(t, "file0.txt:4:match again 0", matches[1])
+
+	// Stop iteration early
+	count := 0
+	for range Grep(ctx, pattern, slices.Values(files)) {
+		count++
+		break
+	}
+	require.Equal(t, 1, count)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	var lastErr error
+	for _, err := range Grep(canceledCtx, pattern, slices.Values(files)) {
+		lastErr = err
+	}
+	require.ErrorIs(t, lastErr, context.Canceled)
+}
+
+func TestFile_CountLines(t *testing.T) {
+	ctx := context.Background()
+	file := File(t.TempDir()).Join("lines.txt")
+	for _, str := range []string{"", "a", "a\n", "a\r\nb", "a\nb\n", "a\n\nb\n\n"} {
+		require.NoError(t, file.WriteAllString(str))
+		count, err := file.CountLines(ctx)
+		require.NoError(t, err)
+		require.Equal(t, len(SplitLines(str)), count, "%q", str)
+	}
+	_, err := file.Dir().Join("missing").CountLines(ctx)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
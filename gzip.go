@@ -0,0 +1,139 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// gzipMagic are the first bytes of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// OpenGzipReader opens the file and returns a reader
+// that decompresses gzip content and has to be closed after reading.
+// Concatenated gzip streams are read as one stream.
+//
+// Content that does not start with the gzip magic bytes
+// is returned unchanged, so that compressed and uncompressed
+// files like rotated logs can be read the same way.
+func (file File) OpenGzipReader() (io.ReadCloser, error) {
+	r, err := file.OpenReader()
+	if err != nil {
+		return nil, err
+	}
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Join(err, r.Close())
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return &gzipReader{Reader: buffered, file: r}, nil
+	}
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, errors.Join(err, r.Close())
+	}
+	return &gzipReader{Reader: gz, gz: gz, file: r}, nil
+}
+
+// gzipReader closes the gzip.Reader
+// and the underlying file reader
+type gzipReader struct {
+	io.Reader
+	gz   *gzip.Reader // nil for uncompressed content
+	file io.Closer
+}
+
+func (r *gzipReader) Close() error {
+	var err error
+	if r.gz != nil {
+		err = r.gz.Close()
+	}
+	return errors.Join(err, r.file.Close())
+}
+
+// OpenGzipWriter opens the file for writing and returns a writer
+// that compresses the written data with gzip and has to be closed
+// after writing to write the end of the compressed stream.
+// An existing file will be truncated.
+//
+// level is the compression level from gzip.BestSpeed
+// to gzip.BestCompression, zero selects gzip.DefaultCompression.
+// The name of the file without a ".gz" extension
+// is stored in the gzip header.
+func (file File) OpenGzipWriter(level int, perm ...Permissions) (WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	w, err := file.OpenWriter(perm...)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, errors.Join(err, w.Close())
+	}
+	gz.Name = strings.TrimSuffix(file.Name(), ".gz")
+	return &gzipWriter{Writer: gz, file: w}, nil
+}
+
+// gzipWriter closes the gzip.Writer
+// and the underlying file writer
+type gzipWriter struct {
+	*gzip.Writer
+	file io.Closer
+}
+
+func (w *gzipWriter) Close() error {
+	return errors.Join(w.Writer.Close(), w.file.Close())
+}
+
+// ReadAllGunzip reads the complete file
+// and returns the decompressed content.
+// Content that is not gzip compressed is returned unchanged,
+// see OpenGzipReader.
+func (file File) ReadAllGunzip(ctx context.Context) ([]byte, error) {
+	data, err := file.ReadAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return ReadAllContext(ctx, gz)
+}
+
+// WriteAllGzip compresses data with gzip and writes it to the file.
+// level is the compression level from gzip.BestSpeed
+// to gzip.BestCompression, zero selects gzip.DefaultCompression.
+// The data is compressed in memory before
+// the file is written with WriteAllContext.
+func (file File) WriteAllGzip(ctx context.Context, data []byte, level int, perm ...Permissions) error {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return err
+	}
+	gz.Name = strings.TrimSuffix(file.Name(), ".gz")
+	_, err = gz.Write(data)
+	if err != nil {
+		return err
+	}
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+	return file.WriteAllContext(ctx, buf.Bytes(), perm...)
+}
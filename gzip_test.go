@@ -0,0 +1,87 @@
+package fs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Gzip(t *testing.T) {
+	dir := File(t.TempDir())
+	ctx := context.Background()
+	content := bytes.Repeat([]byte("log line\n"), 100)
+
+	t.Run("WriteAllGzip", func(t *testing.T) {
+		file := dir.Join("all.log.gz")
+		require.NoError(t, file.WriteAllGzip(ctx, content, gzip.BestCompression))
+
+		compressed, err := file.ReadAll()
+		require.NoError(t, err)
+		require.Less(t, len(compressed), len(content), "content is compressed")
+		gz, err := gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		require.Equal(t, "all.log", gz.Name)
+
+		data, err := file.ReadAllGunzip(ctx)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("OpenGzipWriter", func(t *testing.T) {
+		file := dir.Join("stream.log.gz")
+		w, err := file.OpenGzipWriter(0)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		r, err := file.OpenGzipReader()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, content, data)
+	})
+
+	t.Run("concatenated streams", func(t *testing.T) {
+		file := dir.Join("concat.log.gz")
+		require.NoError(t, file.WriteAllGzip(ctx, []byte("first\n"), 0))
+		second := dir.Join("second.gz")
+		require.NoError(t, second.WriteAllGzip(ctx, []byte("second\n"), 0))
+		compressed, err := second.ReadAll()
+		require.NoError(t, err)
+		require.NoError(t, file.Append(ctx, compressed))
+
+		r, err := file.OpenGzipReader()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "first\nsecond\n", string(data))
+	})
+
+	t.Run("uncompressed", func(t *testing.T) {
+		for _, content := range []string{"plain text", "x", ""} {
+			file := dir.Join("plain.log")
+			require.NoError(t, file.WriteAllString(content))
+
+			data, err := file.ReadAllGunzip(ctx)
+			require.NoError(t, err)
+			require.Equal(t, content, string(data))
+
+			r, err := file.OpenGzipReader()
+			require.NoError(t, err)
+			data, err = io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, content, string(data))
+		}
+	})
+
+	_, err := dir.Join("missing.gz").OpenGzipReader()
+	require.Error(t, err)
+}
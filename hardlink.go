@@ -0,0 +1,28 @@
+package fs
+
+import "fmt"
+
+// LinkTo creates dest as hard link to the file,
+// so that both share the same data.
+// Useful for deduplication tools that want to
+// link identical files instead of copying them.
+//
+// Both files must be on the same file system
+// that has to implement HardLinkFileSystem,
+// else an ErrUnsupported error is returned.
+func (file File) LinkTo(dest File) error {
+	if file == "" || dest == "" {
+		return ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	destFileSystem, destPath := dest.ParseRawURI()
+	if fileSystem != destFileSystem {
+		return fmt.Errorf("can't hard link %s to %s on a different file system", file, dest)
+	}
+	linkFS, ok := fileSystem.(HardLinkFileSystem)
+	if !ok {
+		return NewErrUnsupported(fileSystem, "CreateHardLink")
+	}
+	defer beginOp(fileSystem)()
+	return linkFS.CreateHardLink(path, destPath)
+}
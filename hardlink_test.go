@@ -0,0 +1,66 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_LinkTo_LocalFileSystem(t *testing.T) {
+	dir := File(t.TempDir())
+	file := dir.Join("file.txt")
+	link := dir.Join("link.txt")
+	require.NoError(t, file.WriteAllString("original"))
+
+	require.NoError(t, file.LinkTo(link))
+	requireFileContent(t, link, "original")
+
+	require.NoError(t, link.Append(context.Background(), []byte(" appended")))
+	requireFileContent(t, file, "original appended")
+
+	require.True(t, errors.As(file.LinkTo(link), new(ErrAlreadyExists)))
+	require.True(t, errors.As(dir.LinkTo(dir.Join("dirlink")), new(ErrIsDirectory)))
+}
+
+func TestFile_LinkTo_MemFileSystem(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+
+	root := memFS.RootDir()
+	file := root.Join("file.txt")
+	link := root.Join("sub", "link.txt")
+	require.NoError(t, file.WriteAllString("original"))
+	require.NoError(t, root.Join("sub").MakeDir())
+
+	require.NoError(t, file.LinkTo(link))
+	requireFileContent(t, link, "original")
+	info, err := link.Stat()
+	require.NoError(t, err)
+	require.Equal(t, "link.txt", info.Name(), "link has its own name")
+	names, err := root.Join("sub").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []File{link}, names)
+
+	require.NoError(t, link.WriteAllString("modified"))
+	requireFileContent(t, file, "modified")
+
+	// Hard links are kept when nodes shared
+	// with a clone are copied for modification
+	clone := memFS.Clone()
+	t.Cleanup(func() { _ = clone.Close() })
+	cloneFile := clone.RootDir().Join("file.txt")
+	cloneLink := clone.RootDir().Join("sub", "link.txt")
+	require.NoError(t, cloneLink.WriteAllString("clone"))
+	requireFileContent(t, cloneFile, "clone")
+	requireFileContent(t, file, "modified")
+	require.NoError(t, file.WriteAllString("original again"))
+	requireFileContent(t, link, "original again")
+	requireFileContent(t, cloneLink, "clone")
+
+	require.True(t, errors.As(root.Join("sub").LinkTo(root.Join("dirlink")), new(ErrIsDirectory)))
+	require.True(t, errors.As(file.LinkTo(link), new(ErrAlreadyExists)))
+	require.Error(t, file.LinkTo(File(t.TempDir()).Join("link.txt")), "different file systems")
+}
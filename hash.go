@@ -0,0 +1,210 @@
+package fs
+
+import (
+	"context"
+	"crypto/md5"  //#nosec G501 -- used for checksums like S3 ETags
+	"crypto/sha1" //#nosec G505 -- used for checksums like Git
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+// HashAlgorithm selects the algorithm of File.Hash
+type HashAlgorithm int
+
+const (
+	// HashMD5 is the MD5 algorithm used for example by S3 ETags
+	HashMD5 HashAlgorithm = iota
+	// HashSHA1 is the SHA-1 algorithm
+	HashSHA1
+	// HashSHA256 is the SHA-256 algorithm
+	HashSHA256
+	// HashSHA512 is the SHA-512 algorithm
+	HashSHA512
+	// HashCRC32C is the CRC-32 checksum with the Castagnoli polynomial
+	HashCRC32C
+	// HashXXHash is the 64 bit xxHash (XXH64) non-cryptographic algorithm
+	HashXXHash
+	// HashDropbox is the Dropbox content hash of SHA-256 hashed 4 MB blocks,
+	// see https://www.dropbox.com/developers/reference/content-hash
+	HashDropbox
+
+	numHashAlgorithms = iota
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// String implements the fmt.Stringer interface.
+func (algo HashAlgorithm) String() string {
+	switch algo {
+	case HashMD5:
+		return "MD5"
+	case HashSHA1:
+		return "SHA-1"
+	case HashSHA256:
+		return "SHA-256"
+	case HashSHA512:
+		return "SHA-512"
+	case HashCRC32C:
+		return "CRC32C"
+	case HashXXHash:
+		return "xxHash"
+	case HashDropbox:
+		return "Dropbox"
+	default:
+		return fmt.Sprintf("HashAlgorithm(%d)", int(algo))
+	}
+}
+
+// Valid returns if algo is one of the defined algorithms.
+func (algo HashAlgorithm) Valid() bool {
+	return algo >= 0 && algo < numHashAlgorithms
+}
+
+// New returns a new hash.Hash of the algorithm
+// or nil if algo is not valid.
+func (algo HashAlgorithm) New() hash.Hash {
+	switch algo {
+	case HashMD5:
+		return md5.New() //#nosec G401
+	case HashSHA1:
+		return sha1.New() //#nosec G401
+	case HashSHA256:
+		return sha256.New()
+	case HashSHA512:
+		return sha512.New()
+	case HashCRC32C:
+		return crc32.New(crc32cTable)
+	case HashXXHash:
+		return xxhash.New()
+	case HashDropbox:
+		return fsimpl.NewDropboxContentHasher()
+	default:
+		return nil
+	}
+}
+
+// ContentHashFunc returns a ContentHashFunc
+// that returns the hex encoded hash of the algorithm.
+// It can be used for example as DefaultContentHash.
+func (algo HashAlgorithm) ContentHashFunc() ContentHashFunc {
+	return func(ctx context.Context, reader io.Reader) (string, error) {
+		return hashReader(ctx, reader, algo)
+	}
+}
+
+func hashReader(ctx context.Context, reader io.Reader, algo HashAlgorithm) (string, error) {
+	h := algo.New()
+	if h == nil {
+		return "", fmt.Errorf("invalid %s", algo)
+	}
+	err := copyBuffer(ctx, h, reader, make([]byte, copyBufferSize))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Hash returns the hex encoded hash of the file content
+// using the passed algorithm.
+//
+// If the file system implements HashFileSystem
+// then a hash known by the file system is returned
+// without reading the file, else the whole file is read.
+// Use DefaultContentHash and File.ContentHash
+// for the hash format configured for the whole package.
+func (file File) Hash(ctx context.Context, algo HashAlgorithm) (string, error) {
+	if file == "" {
+		return "", ErrEmptyPath
+	}
+	if !algo.Valid() {
+		return "", fmt.Errorf("invalid %s", algo)
+	}
+	fileSystem, path := file.ParseRawURI()
+	if hashFS, ok := fileSystem.(HashFileSystem); ok {
+		hash, err := hashFS.Hash(ctx, path, algo)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return hash, err
+		}
+	}
+	if file.IsDir() {
+		return "", NewErrIsDirectory(file)
+	}
+	reader, err := file.OpenReader()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	return hashReader(ctx, reader, algo)
+}
+
+// Hashes returns the hex encoded hashes of the file content
+// for all passed algorithms while reading the file only once.
+//
+// Hashes known by a file system implementing HashFileSystem
+// are used without reading the file,
+// only the remaining ones are computed from the file content.
+func (file File) Hashes(ctx context.Context, algos ...HashAlgorithm) (map[HashAlgorithm]string, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	hashes := make(map[HashAlgorithm]string, len(algos))
+	hashers := make(map[HashAlgorithm]hash.Hash, len(algos))
+	fileSystem, path := file.ParseRawURI()
+	hashFS, _ := fileSystem.(HashFileSystem)
+	for _, algo := range algos {
+		if !algo.Valid() {
+			return nil, fmt.Errorf("invalid %s", algo)
+		}
+		if _, ok := hashes[algo]; ok {
+			continue
+		}
+		if _, ok := hashers[algo]; ok {
+			continue
+		}
+		if hashFS != nil {
+			hash, err := hashFS.Hash(ctx, path, algo)
+			if err == nil {
+				hashes[algo] = hash
+				continue
+			}
+			if !errors.Is(err, errors.ErrUnsupported) {
+				return nil, err
+			}
+		}
+		hashers[algo] = algo.New()
+	}
+	if len(hashers) == 0 {
+		return hashes, nil
+	}
+
+	if file.IsDir() {
+		return nil, NewErrIsDirectory(file)
+	}
+	writers := make([]io.Writer, 0, len(hashers))
+	for _, h := range hashers {
+		writers = append(writers, h)
+	}
+	reader, err := file.OpenReader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	err = copyBuffer(ctx, io.MultiWriter(writers...), reader, make([]byte, copyBufferSize))
+	if err != nil {
+		return nil, err
+	}
+	for algo, h := range hashers {
+		hashes[algo] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
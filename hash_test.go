@@ -0,0 +1,80 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Hash(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	file := dir.Join("hello.txt")
+	require.NoError(t, file.WriteAllString("hello"))
+
+	tests := []struct {
+		algo HashAlgorithm
+		want string
+	}{
+		{algo: HashMD5, want: "5d41402abc4b2a76b9719d911017c592"},
+		{algo: HashSHA1, want: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{algo: HashSHA256, want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{algo: HashCRC32C, want: "9a71bb4c"},
+		{algo: HashXXHash, want: "26c7827d889f6da3"},
+		{algo: HashDropbox, want: "9595c9df90075148eb06860365df33584b75bff782a510c6cd4883a419833d50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algo.String(), func(t *testing.T) {
+			hash, err := file.Hash(ctx, tt.algo)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, hash)
+
+			hash, err = FileContentHash(ctx, file, tt.algo.ContentHashFunc())
+			require.NoError(t, err)
+			require.Equal(t, tt.want, hash)
+		})
+	}
+
+	hash, err := file.Hash(ctx, HashSHA512)
+	require.NoError(t, err)
+	require.Len(t, hash, 128)
+
+	_, err = file.Hash(ctx, HashAlgorithm(99))
+	require.Error(t, err)
+	require.Equal(t, "HashAlgorithm(99)", HashAlgorithm(99).String())
+
+	_, err = dir.Hash(ctx, HashMD5)
+	require.True(t, errors.As(err, new(ErrIsDirectory)))
+
+	_, err = dir.Join("missing").Hash(ctx, HashMD5)
+	require.True(t, errors.As(err, new(ErrDoesNotExist)))
+}
+
+func TestFile_Hashes(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	file := dir.Join("hello.txt")
+	require.NoError(t, file.WriteAllString("hello"))
+
+	hashes, err := file.Hashes(ctx, HashSHA256, HashMD5, HashSHA256)
+	require.NoError(t, err)
+	require.Equal(t, map[HashAlgorithm]string{
+		HashMD5:    "5d41402abc4b2a76b9719d911017c592",
+		HashSHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}, hashes)
+
+	hashes, err = file.Hashes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, hashes)
+
+	_, err = file.Hashes(ctx, HashMD5, HashAlgorithm(-1))
+	require.Error(t, err)
+
+	_, err = dir.Hashes(ctx, HashMD5)
+	require.True(t, errors.As(err, new(ErrIsDirectory)))
+
+	_, err = dir.Join("missing").Hashes(ctx, HashMD5)
+	require.True(t, errors.As(err, new(ErrDoesNotExist)))
+}
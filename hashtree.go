@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// HashTree computes the content hashes of all files
+// in dir and its sub-directories using concurrency number
+// of parallel workers and returns a map from the
+// slash separated file paths relative to dir to the hashes.
+// The paths are normalized with NormalizeName if enabled with SetNormalizeNames.
+//
+// If hashFunc is nil then DefaultContentHash will be used.
+// If concurrency is less than 1 then runtime.NumCPU() workers are used.
+// The first error stops the hashing and will be returned.
+func HashTree(ctx context.Context, dir File, hashFunc ContentHashFunc, concurrency int) (map[string]string, error) {
+	if hashFunc == nil {
+		hashFunc = DefaultContentHash
+	}
+	return hashTree(ctx, dir, concurrency, func(ctx context.Context, file File) (string, error) {
+		return FileContentHash(ctx, file, hashFunc)
+	})
+}
+
+// HashTreeCached works like HashTree but uses the passed
+// ContentHashCache to skip hashing of unchanged files.
+func HashTreeCached(ctx context.Context, dir File, cache *ContentHashCache, concurrency int) (map[string]string, error) {
+	return hashTree(ctx, dir, concurrency, cache.ContentHash)
+}
+
+func hashTree(ctx context.Context, dir File, concurrency int, hashFile func(context.Context, File) (string, error)) (map[string]string, error) {
+	if err := dir.CheckIsDir(); err != nil {
+		return nil, err
+	}
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	dirPrefix := strings.TrimSuffix(dir.PathWithSlashes(), "/") + "/"
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		files   = make(chan File, concurrency)
+		hashes  = make(map[string]string)
+		mtx     sync.Mutex
+		workers sync.WaitGroup
+	)
+	for range concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for file := range files {
+				hash, err := hashFile(ctx, file)
+				if err != nil {
+					cancel(err)
+					continue // drain files channel
+				}
+				relPath := matchName(strings.TrimPrefix(file.PathWithSlashes(), dirPrefix))
+				mtx.Lock()
+				hashes[relPath] = hash
+				mtx.Unlock()
+			}
+		}()
+	}
+
+	err := dir.ListDirRecursiveContext(ctx, func(file File) error {
+		select {
+		case files <- file:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	})
+	close(files)
+	workers.Wait()
+
+	// A hashing error cancels the listing,
+	// so prefer it over the resulting listing error
+	if cause := context.Cause(ctx); cause != nil {
+		return nil, cause
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashTree(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, dir.Join("sub", "deeper").MakeAllDirs())
+	require.NoError(t, dir.Join("sub", "b.txt").WriteAllString("B"))
+	require.NoError(t, dir.Join("sub", "deeper", "c.txt").WriteAllString("C"))
+
+	hashes, err := HashTree(ctx, dir, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, hashes, 3)
+	for relPath, hash := range hashes {
+		expected, err := dir.Join(relPath).ContentHash()
+		require.NoError(t, err)
+		require.Equal(t, expected, hash, relPath)
+	}
+	require.Contains(t, hashes, "sub/deeper/c.txt")
+
+	cached, err := HashTreeCached(ctx, dir, NewContentHashCache(nil), 0)
+	require.NoError(t, err)
+	require.Equal(t, hashes, cached)
+
+	hashErr := errors.New("hash error")
+	_, err = HashTree(ctx, dir, func(context.Context, io.Reader) (string, error) { return "", hashErr }, 2)
+	require.ErrorIs(t, err, hashErr)
+
+	_, err = HashTree(ctx, dir.Join("a.txt"), nil, 2)
+	require.ErrorAs(t, err, new(ErrIsNotDirectory))
+}
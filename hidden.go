@@ -0,0 +1,165 @@
+package fs
+
+import (
+	"context"
+	"sync"
+)
+
+// HiddenFunc returns if the file with filePath
+// in fileSystem is hidden.
+type HiddenFunc func(fileSystem FileSystem, filePath string) bool
+
+var (
+	// HiddenDotPrefix is a HiddenFunc that treats
+	// files with a name starting with a dot as hidden.
+	HiddenDotPrefix HiddenFunc = func(fileSystem FileSystem, filePath string) bool {
+		_, name := fileSystem.SplitDirAndName(filePath)
+		return len(name) > 0 && name[0] == '.'
+	}
+
+	// HiddenWindowsAttribute is a HiddenFunc that treats
+	// local files with the Windows hidden file attribute as hidden.
+	// Always returns false for other file systems and operating systems.
+	HiddenWindowsAttribute HiddenFunc = func(fileSystem FileSystem, filePath string) bool {
+		local, isLocal := fileSystem.(*LocalFileSystem)
+		if !isLocal {
+			return false
+		}
+		hidden, _ := hasLocalFileAttributeHidden(local.expandTilde(filePath))
+		return hidden
+	}
+
+	// HiddenNever is a HiddenFunc that treats no file as hidden.
+	HiddenNever HiddenFunc = func(FileSystem, string) bool { return false }
+)
+
+// HiddenAny returns a HiddenFunc that treats a file
+// as hidden if any of the passed funcs does.
+func HiddenAny(funcs ...HiddenFunc) HiddenFunc {
+	return func(fileSystem FileSystem, filePath string) bool {
+		for _, f := range funcs {
+			if f(fileSystem, filePath) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var (
+	hiddenFuncs    = make(map[string]HiddenFunc)
+	hiddenFuncsMtx sync.RWMutex
+)
+
+// SetHiddenFunc configures what counts as hidden for fileSystem
+// instead of the IsHidden method of the file system.
+// The function is used by File.IsHidden, File.Info,
+// for FileInfo.IsHidden of listed files
+// and by the ListDirVisible methods.
+// Passing nil restores the IsHidden method of the file system.
+//
+// The configuration is bound to the prefix of the registered
+// fileSystem and cleared by Unregister and Replace.
+func SetHiddenFunc(fileSystem FileSystem, isHidden HiddenFunc) {
+	hiddenFuncsMtx.Lock()
+	defer hiddenFuncsMtx.Unlock()
+
+	if isHidden == nil {
+		delete(hiddenFuncs, fileSystem.Prefix())
+		return
+	}
+	hiddenFuncs[fileSystem.Prefix()] = isHidden
+}
+
+// hiddenFunc returns the HiddenFunc configured
+// for fileSystem or nil
+func hiddenFunc(fileSystem FileSystem) HiddenFunc {
+	hiddenFuncsMtx.RLock()
+	defer hiddenFuncsMtx.RUnlock()
+
+	return hiddenFuncs[fileSystem.Prefix()]
+}
+
+// isHidden uses the HiddenFunc configured for fileSystem
+// or the IsHidden method of the file system.
+func isHidden(fileSystem FileSystem, filePath string) bool {
+	if f := hiddenFunc(fileSystem); f != nil {
+		return f(fileSystem, filePath)
+	}
+	return fileSystem.IsHidden(filePath)
+}
+
+// hiddenFileInfoCallback returns callback unchanged if no HiddenFunc
+// is configured for fileSystem, else a callback that sets
+// FileInfo.IsHidden using the configured HiddenFunc.
+func hiddenFileInfoCallback(fileSystem FileSystem, callback func(*FileInfo) error) func(*FileInfo) error {
+	f := hiddenFunc(fileSystem)
+	if f == nil {
+		return callback
+	}
+	return func(info *FileInfo) error {
+		info.IsHidden = f(fileSystem, info.File.Path())
+		return callback(info)
+	}
+}
+
+// ListDirVisible calls the passed callback function for every
+// file and directory that is not hidden.
+// If any patterns are passed, then only files with a name that matches
+// at least one of the patterns are returned.
+func (file File) ListDirVisible(callback func(File) error, patterns ...string) error {
+	return file.ListDirVisibleContext(context.Background(), callback, patterns...)
+}
+
+// ListDirVisibleContext calls the passed callback function for every
+// file and directory that is not hidden.
+// If any patterns are passed, then only files with a name that matches
+// at least one of the patterns are returned.
+// Canceling the context or returning an error from the callback
+// will stop the listing and return the context or callback error.
+func (file File) ListDirVisibleContext(ctx context.Context, callback func(File) error, patterns ...string) error {
+	return file.ListDirInfoContext(ctx,
+		func(info *FileInfo) error {
+			if info.IsHidden {
+				return nil
+			}
+			return callback(info.File)
+		},
+		patterns...,
+	)
+}
+
+// ListDirRecursiveVisible returns only files that are not hidden
+// and does not recurse into hidden directories.
+// patterns are only applied to files, not to directories
+func (file File) ListDirRecursiveVisible(callback func(File) error, patterns ...string) error {
+	return file.ListDirRecursiveVisibleContext(context.Background(), callback, patterns...)
+}
+
+// ListDirRecursiveVisibleContext returns only files that are not hidden
+// and does not recurse into hidden directories.
+// patterns are only applied to files, not to directories
+func (file File) ListDirRecursiveVisibleContext(ctx context.Context, callback func(File) error, patterns ...string) error {
+	if file == "" {
+		return ErrEmptyPath
+	}
+	fileSystem := file.FileSystem()
+	return file.ListDirInfoContext(ctx,
+		func(info *FileInfo) error {
+			if info.IsHidden {
+				return nil
+			}
+			if info.IsDir {
+				err := info.File.ListDirRecursiveVisibleContext(ctx, callback, patterns...)
+				// Don't mind files that have been deleted while iterating
+				return RemoveErrDoesNotExist(err)
+			}
+			match, err := fileSystem.MatchAnyPattern(info.Name, patterns)
+			if !match || err != nil {
+				return err
+			}
+			return callback(info.File)
+		},
+		// No patterns
+	)
+}
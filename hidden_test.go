@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDirVisible(t *testing.T) {
+	dir := File(t.TempDir())
+	for _, name := range []string{"visible.txt", ".hidden.txt", "_private.txt", ".git/config", "sub/a.txt", "sub/.b.txt", "_sub/c.txt"} {
+		file := dir.Join(strings.Split(name, "/")...)
+		require.NoError(t, file.Dir().MakeAllDirs())
+		require.NoError(t, file.Touch())
+	}
+	listVisible := func() (names []string) {
+		err := dir.ListDirVisible(func(file File) error {
+			names = append(names, file.Name())
+			return nil
+		})
+		require.NoError(t, err)
+		return names
+	}
+	listRecursiveVisible := func() (names []string) {
+		err := dir.ListDirRecursiveVisible(func(file File) error {
+			names = append(names, file.Name())
+			return nil
+		})
+		require.NoError(t, err)
+		return names
+	}
+
+	require.True(t, dir.Join(".hidden.txt").IsHidden())
+	require.False(t, dir.Join("_private.txt").IsHidden())
+	require.ElementsMatch(t, []string{"visible.txt", "_private.txt", "sub", "_sub"}, listVisible())
+	require.ElementsMatch(t, []string{"visible.txt", "_private.txt", "a.txt", "c.txt"}, listRecursiveVisible())
+
+	t.Cleanup(func() { SetHiddenFunc(Local, nil) })
+	underscore := func(fileSystem FileSystem, filePath string) bool {
+		_, name := fileSystem.SplitDirAndName(filePath)
+		return strings.HasPrefix(name, "_")
+	}
+	SetHiddenFunc(Local, HiddenAny(HiddenDotPrefix, underscore))
+	require.True(t, dir.Join("_private.txt").IsHidden())
+	require.True(t, dir.Join("_private.txt").Info().IsHidden)
+	require.ElementsMatch(t, []string{"visible.txt", "sub"}, listVisible())
+	require.ElementsMatch(t, []string{"visible.txt", "a.txt"}, listRecursiveVisible())
+	var hidden []string
+	err := dir.ListDirInfoRecursive(func(info *FileInfo) error {
+		if info.IsHidden {
+			hidden = append(hidden, info.Name)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{".hidden.txt", "_private.txt", ".b.txt"}, hidden, "FileInfo.IsHidden uses the configured HiddenFunc")
+
+	SetHiddenFunc(Local, HiddenNever)
+	require.False(t, dir.Join(".hidden.txt").IsHidden())
+}
+
+func TestSetHiddenFunc_Unregister(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	SetHiddenFunc(memFS, HiddenNever)
+	require.NotNil(t, hiddenFunc(memFS))
+	require.NoError(t, memFS.Close())
+	require.Nil(t, hiddenFunc(memFS), "cleared by Unregister")
+
+	basicFS := &basicFileSystem{FileSystem: Local}
+	Register(basicFS)
+	t.Cleanup(func() { Unregister(basicFS) })
+	SetHiddenFunc(basicFS, HiddenNever)
+	replacement := &basicFileSystem{FileSystem: Local}
+	require.NoError(t, Replace(basicFS, replacement))
+	basicFS = replacement
+	require.Nil(t, hiddenFunc(replacement), "cleared by Replace")
+}
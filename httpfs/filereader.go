@@ -0,0 +1,135 @@
+package httpfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+
+	"github.com/ungerik/go-fs"
+)
+
+var (
+	_ iofs.File   = new(fileReader)
+	_ io.Seeker   = new(fileReader)
+	_ io.ReaderAt = new(fileReader)
+)
+
+// fileReader streams the body of a GET request
+// and implements seeking using HTTP range requests.
+type fileReader struct {
+	fs       *fileSystem
+	filePath string
+	info     *fs.FileInfo
+	offset   int64
+	body     io.ReadCloser // response body starting at offset or nil
+	closed   bool
+}
+
+func (r *fileReader) Stat() (iofs.FileInfo, error) {
+	return r.info.StdFileInfo(), nil
+}
+
+// openBody requests the file starting at the current offset
+func (r *fileReader) openBody() error {
+	var byteRange string
+	if r.offset > 0 {
+		byteRange = fmt.Sprintf("bytes=%d-", r.offset)
+	}
+	response, err := r.fs.getRange(context.Background(), r.filePath, byteRange)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		response.Body.Close()
+		r.body = http.NoBody // offset after the end of the file
+		return nil
+	}
+	if err = r.fs.checkResponse(r.filePath, response); err != nil {
+		return err
+	}
+	if r.offset > 0 && response.StatusCode != http.StatusPartialContent {
+		// Server ignored the Range header,
+		// so skip the bytes before the offset
+		_, err = io.CopyN(io.Discard, response.Body, r.offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			response.Body.Close()
+			return err
+		}
+	}
+	r.body = response.Body
+	return nil
+}
+
+func (r *fileReader) Read(p []byte) (n int, err error) {
+	if r.closed {
+		return 0, iofs.ErrClosed
+	}
+	if r.body == nil {
+		if err = r.openBody(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *fileReader) Seek(offset int64, whence int) (int64, error) {
+	if r.closed {
+		return 0, iofs.ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.info.Size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative seek position: %d", offset)
+	}
+	if offset != r.offset && r.body != nil {
+		// Request the body again starting at the new offset
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// ReadAt reads len(p) bytes starting at off
+// with a separate range request
+// independent of the offset used by Read and Seek.
+func (r *fileReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if r.closed {
+		return 0, iofs.ErrClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	data, err := r.fs.ReadRange(context.Background(), r.filePath, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	n = copy(p, data)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *fileReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
@@ -33,6 +33,11 @@ const (
 var (
 	FileSystem    = &fileSystem{prefix: Prefix}
 	FileSystemTLS = &fileSystem{prefix: PrefixTLS}
+
+	// Client used for all HTTP requests
+	Client = http.DefaultClient
+
+	_ fs.ReadRangeFileSystem = FileSystem
 )
 
 type fileSystem struct {
@@ -98,37 +103,45 @@ func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
 	return fsimpl.SplitDirAndName(filePath, 0, Separator)
 }
 
-func (f *fileSystem) info(filePath string) fs.FileInfo {
-	// First try fast HEAD request
-	request, err := http.NewRequest("HEAD", f.URL(filePath), nil)
-	if err != nil {
-		return fs.FileInfo{}
+// checkResponse returns an error for response status codes
+// that are not 2xx and closes the response body in that case.
+func (f *fileSystem) checkResponse(filePath string, response *http.Response) error {
+	if response.StatusCode >= 200 && response.StatusCode <= 299 {
+		return nil
 	}
-	response, err := http.DefaultClient.Do(request)
+	response.Body.Close()
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone {
+		return fs.NewErrDoesNotExist(fs.File(f.URL(filePath)))
+	}
+	return fmt.Errorf("HTTP GET %s: %s", f.URL(filePath), response.Status)
+}
+
+// get sends a GET request for filePath and checks the response status.
+func (f *fileSystem) get(ctx context.Context, filePath string) (*http.Response, error) {
+	response, err := f.getRange(ctx, filePath, "")
 	if err != nil {
-		return fs.FileInfo{}
+		return nil, err
 	}
-	if response.ContentLength >= 0 {
-		modified, err := http.ParseTime(response.Header.Get("Last-Modified"))
-		if err != nil {
-			modified, err = http.ParseTime(response.Header.Get("Date"))
-			if err != nil {
-				modified = time.Time{}
-			}
-		}
-		return fs.FileInfo{
-			Exists:   true,
-			Name:     path.Base(request.URL.Path),
-			Size:     response.ContentLength,
-			Modified: modified,
-		}
+	if err = f.checkResponse(filePath, response); err != nil {
+		return nil, err
 	}
+	return response, nil
+}
 
-	// If HEAD request did not return a ContentLength do a full GET request
-	response, err = http.DefaultClient.Get(f.URL(filePath))
+// getRange sends a GET request for filePath with an optional
+// Range header value without checking the response status.
+func (f *fileSystem) getRange(ctx context.Context, filePath, byteRange string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL(filePath), nil)
 	if err != nil {
-		return fs.FileInfo{}
+		return nil, err
 	}
+	if byteRange != "" {
+		request.Header.Set("Range", byteRange)
+	}
+	return Client.Do(request)
+}
+
+func lastModified(response *http.Response) time.Time {
 	modified, err := http.ParseTime(response.Header.Get("Last-Modified"))
 	if err != nil {
 		modified, err = http.ParseTime(response.Header.Get("Date"))
@@ -136,36 +149,66 @@ func (f *fileSystem) info(filePath string) fs.FileInfo {
 			modified = time.Time{}
 		}
 	}
-	size := response.ContentLength
+	return modified
+}
 
-	if size < 0 {
+func (f *fileSystem) info(filePath string) (*fs.FileInfo, error) {
+	// First try fast HEAD request
+	request, err := http.NewRequest(http.MethodHead, f.URL(filePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	if err = f.checkResponse(filePath, response); err != nil {
+		return nil, err
+	}
+	info := &fs.FileInfo{
+		File:        fs.File(f.URL(filePath)),
+		Exists:      true,
+		IsRegular:   true,
+		Name:        path.Base(request.URL.Path),
+		Size:        response.ContentLength,
+		Modified:    lastModified(response),
+		Permissions: fs.AllRead,
+	}
+	if info.Size >= 0 {
+		return info, nil
+	}
+
+	// If HEAD request did not return a ContentLength do a full GET request
+	response, err = f.get(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	info.Modified = lastModified(response)
+	info.Size = response.ContentLength
+	if info.Size < 0 {
 		// Read full body if still no ContentLength available
-		defer response.Body.Close()
-		body, err := io.ReadAll(response.Body)
+		info.Size, err = io.Copy(io.Discard, response.Body)
 		if err != nil {
-			return fs.FileInfo{}
+			return nil, err
 		}
-		size = int64(len(body))
-	}
-
-	return fs.FileInfo{
-		Exists:   true,
-		Name:     path.Base(request.URL.Path),
-		Size:     size,
-		Modified: modified,
 	}
+	return info, nil
 }
 
 func (f *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
-	info := f.info(filePath)
-	if !info.Exists {
-		return nil, fs.NewErrDoesNotExist(fs.File(filePath))
+	info, err := f.info(filePath)
+	if err != nil {
+		return nil, err
 	}
 	return info.StdFileInfo(), nil
 }
 
 func (f *fileSystem) Exists(filePath string) bool {
-	return f.info(filePath).Exists
+	_, err := f.info(filePath)
+	return err == nil
 }
 
 func (f *fileSystem) IsHidden(filePath string) bool       { return false }
@@ -179,34 +222,70 @@ func (f *fileSystem) ReadAll(ctx context.Context, filePath string) (data []byte,
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
-	// TODO use HTTP GET with context
-	reader, err := f.OpenReader(filePath)
+	response, err := f.get(ctx, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("HTTPFileSystem.ReadAll: %w", err)
 	}
+	defer response.Body.Close()
 
-	data, err = io.ReadAll(reader)
+	data, err = fs.ReadAllContext(ctx, response.Body)
 	if err != nil {
 		return nil, fmt.Errorf("HTTPFileSystem.ReadAll: %w", err)
 	}
-
 	return data, nil
 }
 
-func (f *fileSystem) OpenReader(filePath string) (reader iofs.File, err error) {
-	info, err := f.Stat(filePath)
+// ReadRange reads a byte range of the file using a HTTP Range header.
+// A negative offset reads the last -offset bytes of the file.
+// If the server does not support range requests,
+// then the requested range is cut out of the complete response.
+func (f *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	var byteRange string
+	switch {
+	case offset < 0:
+		byteRange = fmt.Sprintf("bytes=%d", offset)
+	case length <= 0:
+		return []byte{}, nil
+	default:
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	response, err := f.getRange(ctx, filePath, byteRange)
 	if err != nil {
 		return nil, err
 	}
-	response, err := http.DefaultClient.Get(f.URL(filePath))
-	if err != nil {
-		return nil, fmt.Errorf("HTTPFileSystem.OpenReader: %w", err)
+	if response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		response.Body.Close()
+		return []byte{}, nil // offset after the end of the file
 	}
-	if response.StatusCode < 200 || response.StatusCode > 299 {
-		return nil, fmt.Errorf("HTTPFileSystem.OpenReader: %d: %s", response.StatusCode, response.Status)
+	if err = f.checkResponse(filePath, response); err != nil {
+		return nil, err
 	}
 	defer response.Body.Close()
-	return fsimpl.NewReadonlyFileBufferReadAll(response.Body, info)
+
+	data, err := fs.ReadAllContext(ctx, response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusPartialContent {
+		return data, nil
+	}
+	// Server ignored the Range header and returned the complete file
+	if offset < 0 {
+		return data[max(int64(len(data))+offset, 0):], nil
+	}
+	data = data[min(offset, int64(len(data))):]
+	return data[:min(length, int64(len(data)))], nil
+}
+
+// OpenReader returns a reader that streams the response body
+// of a GET request and implements io.Seeker and io.ReaderAt
+// using HTTP range requests.
+func (f *fileSystem) OpenReader(filePath string) (reader iofs.File, err error) {
+	info, err := f.info(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &fileReader{fs: f, filePath: filePath, info: info}, nil
 }
 
 func (f *fileSystem) Close() error {
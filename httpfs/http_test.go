@@ -2,9 +2,16 @@ package httpfs
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ungerik/go-fs"
 )
@@ -32,3 +39,72 @@ func TestReadAll(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, data, data2)
 }
+
+func newTestServer(t *testing.T, content string, acceptRanges bool) string {
+	t.Helper()
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/file.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		if !acceptRanges {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "file.txt", modTime, strings.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestFileSystem_LocalServer(t *testing.T) {
+	ctx := context.Background()
+	const content = "0123456789"
+	for _, acceptRanges := range []bool{true, false} {
+		serverURL := newTestServer(t, content, acceptRanges)
+		file := fs.File(serverURL + "/file.txt")
+
+		info := file.Info()
+		require.True(t, info.Exists)
+		require.Equal(t, int64(len(content)), info.Size)
+		require.Equal(t, "file.txt", info.Name)
+		require.Equal(t, 2024, info.Modified.Year())
+
+		missing := fs.File(serverURL + "/missing.txt")
+		require.False(t, missing.Exists())
+		_, err := missing.ReadAll()
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		data, err := file.ReadAllContext(ctx)
+		require.NoError(t, err)
+		require.Equal(t, content, string(data))
+
+		first, err := file.ReadFirst(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, "012", string(first))
+		last, err := file.ReadLast(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, "789", string(last))
+		last, err = file.ReadLast(ctx, 100)
+		require.NoError(t, err)
+		require.Equal(t, content, string(last))
+
+		reader, err := file.OpenReader()
+		require.NoError(t, err)
+		seeker := reader.(io.ReadSeeker)
+		buf := make([]byte, 2)
+		_, err = io.ReadFull(seeker, buf)
+		require.NoError(t, err)
+		require.Equal(t, "01", string(buf))
+		_, err = seeker.Seek(-3, io.SeekEnd)
+		require.NoError(t, err)
+		rest, err := io.ReadAll(seeker)
+		require.NoError(t, err)
+		require.Equal(t, "789", string(rest))
+		n, err := reader.(io.ReaderAt).ReadAt(buf, 4)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, "45", string(buf))
+		require.NoError(t, reader.Close())
+	}
+}
@@ -3,7 +3,6 @@ package fs
 import (
 	"context"
 	iofs "io/fs"
-	"net/url"
 	"path"
 	"strings"
 
@@ -69,6 +68,10 @@ func (fs InvalidFileSystem) AbsPath(filePath string) string {
 	return fs.JoinCleanPath(filePath)
 }
 
+func (InvalidFileSystem) AbsPathErr(filePath string) (string, error) {
+	return "", ErrInvalidFileSystem
+}
+
 func (fs InvalidFileSystem) URL(cleanPath string) string {
 	return fs.Prefix() + cleanPath
 }
@@ -82,10 +85,6 @@ func (fs InvalidFileSystem) JoinCleanPath(uriParts ...string) string {
 		uriParts[0] = strings.TrimPrefix(uriParts[0], fs.Prefix())
 	}
 	cleanPath := path.Join(uriParts...)
-	unescPath, err := url.PathUnescape(cleanPath)
-	if err == nil {
-		cleanPath = unescPath
-	}
 	cleanPath = path.Clean(cleanPath)
 	return cleanPath
 }
@@ -145,6 +144,38 @@ func (InvalidFileSystem) SetPermissions(filePath string, perm Permissions) error
 	return ErrInvalidFileSystem
 }
 
+func (InvalidFileSystem) CreateSymlink(targetPath, linkPath string) error {
+	return ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) ReadSymlink(linkPath string) (string, error) {
+	return "", ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) CreateHardLink(existingPath, newPath string) error {
+	return ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) GetXAttr(filePath, name string) ([]byte, error) {
+	return nil, ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) SetXAttr(filePath, name string, value []byte) error {
+	return ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) ListXAttrs(filePath string) ([]string, error) {
+	return nil, ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) RemoveXAttr(filePath, name string) error {
+	return ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) DiskUsage(filePath string) (DiskUsage, error) {
+	return DiskUsage{}, ErrInvalidFileSystem
+}
+
 func (InvalidFileSystem) User(filePath string) (string, error) {
 	return "", ErrInvalidFileSystem
 }
@@ -197,6 +228,10 @@ func (InvalidFileSystem) OpenAppendWriter(filePath string, perm []Permissions) (
 	return nil, ErrInvalidFileSystem
 }
 
+func (InvalidFileSystem) OpenWriteSeeker(filePath string, perm []Permissions) (WriteSeekCloser, error) {
+	return nil, ErrInvalidFileSystem
+}
+
 func (InvalidFileSystem) OpenReadWriter(filePath string, perm []Permissions) (ReadWriteSeekCloser, error) {
 	return nil, ErrInvalidFileSystem
 }
@@ -205,6 +240,18 @@ func (InvalidFileSystem) Watch(filePath string, onEvent func(File, Event)) (canc
 	return nil, ErrInvalidFileSystem
 }
 
+func (InvalidFileSystem) Hash(ctx context.Context, filePath string, algo HashAlgorithm) (string, error) {
+	return "", ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) ContentType(ctx context.Context, filePath string) (string, error) {
+	return "", ErrInvalidFileSystem
+}
+
+func (InvalidFileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	return nil, ErrInvalidFileSystem
+}
+
 func (InvalidFileSystem) Truncate(filePath string, size int64) error {
 	return ErrInvalidFileSystem
 }
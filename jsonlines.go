@@ -0,0 +1,78 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// AppendJSONLines marshalls every value to a single line of JSON
+// and appends the lines to the file in newline delimited
+// JSON format (NDJSON, also known as JSON Lines).
+// All lines are appended with a single append operation
+// using the native append functionality of the file system if available.
+//
+// Returns a wrapped ErrMarshalJSON when the marshalling failed.
+func (file File) AppendJSONLines(ctx context.Context, values ...any) error {
+	if file == "" {
+		return ErrEmptyPath
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, value := range values {
+		// Encode writes a newline after every value
+		err := enc.Encode(value)
+		if err != nil {
+			return fmt.Errorf("%w because: %w", ErrMarshalJSON, err)
+		}
+	}
+	return file.Append(ctx, buf.Bytes())
+}
+
+// JSONLines returns an iterator that yields every JSON value
+// of a newline delimited JSON file (NDJSON, also known as JSON Lines)
+// without unmarshalling it.
+// The file is read as a stream, so it is not loaded into memory completely.
+// In case of an error, the iterator will yield nil and the error
+// as last key and value and then stop the iteration.
+// Canceling the context will stop the iteration and yield the context error.
+//
+// Invalid JSON is reported as a wrapped ErrUnmarshalJSON.
+func (file File) JSONLines(ctx context.Context) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		r, err := file.OpenReader()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer r.Close()
+
+		dec := json.NewDecoder(r)
+		for {
+			if ctx.Err() != nil {
+				yield(nil, ctx.Err())
+				return
+			}
+			var line json.RawMessage
+			err := dec.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("%w because: %w", ErrUnmarshalJSON, err))
+				return
+			}
+			if !yield(line, nil) {
+				return
+			}
+		}
+	}
+}
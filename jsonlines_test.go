@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_JSONLines(t *testing.T) {
+	ctx := context.Background()
+	file := File(t.TempDir()).Join("log.ndjson")
+
+	err := file.AppendJSONLines(ctx, map[string]int{"a": 1}, "<b>")
+	require.NoError(t, err)
+	err = file.AppendJSONLines(ctx, []int{3})
+	require.NoError(t, err)
+
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "{\"a\":1}\n\"<b>\"\n[3]\n", str)
+
+	var lines []string
+	for line, err := range file.JSONLines(ctx) {
+		require.NoError(t, err)
+		lines = append(lines, string(line))
+	}
+	require.Equal(t, []string{`{"a":1}`, `"<b>"`, `[3]`}, lines)
+
+	err = file.AppendJSONLines(ctx, func() {})
+	require.ErrorIs(t, err, ErrMarshalJSON)
+
+	err = file.AppendString(ctx, "{invalid\n")
+	require.NoError(t, err)
+	var lastErr error
+	for line, err := range file.JSONLines(ctx) {
+		if err != nil {
+			require.Nil(t, line)
+			lastErr = err
+		}
+	}
+	require.ErrorIs(t, lastErr, ErrUnmarshalJSON)
+
+	for _, err := range File(t.TempDir()).Join("missing.ndjson").JSONLines(ctx) {
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
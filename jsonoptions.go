@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReadJSONOption configures how ReadJSONWithOptions
+// and UnmarshalJSON unmarshall and validate JSON.
+// Options are created with DisallowUnknownJSONFields and ValidateJSON.
+type ReadJSONOption func(*readJSONConfig)
+
+type readJSONConfig struct {
+	disallowUnknownFields bool
+	validators            []func(output any) error
+}
+
+// DisallowUnknownJSONFields returns a ReadJSONOption that causes
+// an error when the JSON contains object keys which do not match
+// any non-ignored, exported fields of the output struct.
+func DisallowUnknownJSONFields() ReadJSONOption {
+	return func(config *readJSONConfig) {
+		config.disallowUnknownFields = true
+	}
+}
+
+// ValidateJSON returns a ReadJSONOption that calls validate
+// with the unmarshalled output after successful unmarshalling.
+// An error returned from validate will be
+// returned wrapped by ErrValidateJSON.
+func ValidateJSON(validate func(output any) error) ReadJSONOption {
+	return func(config *readJSONConfig) {
+		if validate != nil {
+			config.validators = append(config.validators, validate)
+		}
+	}
+}
+
+// UnmarshalJSON unmarshalls data to output with the passed options.
+//
+// Returns a wrapped ErrUnmarshalJSON when the unmarshalling failed
+// or a wrapped ErrValidateJSON when a ValidateJSON option returned an error.
+func UnmarshalJSON(data []byte, output any, options ...ReadJSONOption) error {
+	var config readJSONConfig
+	for _, option := range options {
+		option(&config)
+	}
+	err := unmarshalJSON(data, output, config.disallowUnknownFields)
+	if err != nil {
+		return fmt.Errorf("%w because: %w", ErrUnmarshalJSON, err)
+	}
+	for _, validate := range config.validators {
+		err = validate(output)
+		if err != nil {
+			return fmt.Errorf("%w because: %w", ErrValidateJSON, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalJSON(data []byte, output any, disallowUnknownFields bool) error {
+	if !disallowUnknownFields {
+		return json.Unmarshal(data, output)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(output)
+	if err != nil {
+		return err
+	}
+	// Reject data after the top-level value like json.Unmarshal
+	if _, err = dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid data after top-level JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFile_ReadJSON_Options(t *testing.T) {
+	type config struct {
+		Port int `json:"port"`
+	}
+	ctx := context.Background()
+	validatePort := ValidateJSON(func(output any) error {
+		if output.(*config).Port <= 0 {
+			return errors.New("port must be positive")
+		}
+		return nil
+	})
+
+	var c config
+	err := NewMemFile("config.json", []byte(`{"port":8080,"host":"x"}`)).ReadJSON(ctx, &c)
+	require.NoError(t, err, "unknown fields allowed by default")
+	require.Equal(t, 8080, c.Port)
+
+	err = NewMemFile("config.json", []byte(`{"port":8080,"host":"x"}`)).ReadJSONWithOptions(ctx, &c, DisallowUnknownJSONFields())
+	require.ErrorIs(t, err, ErrUnmarshalJSON)
+	require.ErrorContains(t, err, `unknown field "host"`)
+
+	err = NewMemFile("config.json", []byte(`{"port":8080} {}`)).ReadJSONWithOptions(ctx, &c, DisallowUnknownJSONFields())
+	require.ErrorIs(t, err, ErrUnmarshalJSON, "data after top-level value")
+
+	c = config{}
+	err = NewMemFile("config.json", []byte(`{"port":443}`)).ReadJSONWithOptions(ctx, &c, DisallowUnknownJSONFields(), validatePort)
+	require.NoError(t, err)
+	require.Equal(t, 443, c.Port)
+
+	err = NewMemFile("config.json", []byte(`{"port":-1}`)).ReadJSONWithOptions(ctx, &c, validatePort)
+	require.ErrorIs(t, err, ErrValidateJSON)
+	require.ErrorContains(t, err, "port must be positive")
+}
+
+func TestFile_ReadJSONWithOptions(t *testing.T) {
+	type config struct {
+		Port int `json:"port"`
+	}
+	ctx := context.Background()
+	file := File(t.TempDir()).Join("config.json")
+	require.NoError(t, file.WriteAllString(`{"port":8080,"host":"x"}`))
+
+	var c config
+	require.NoError(t, file.ReadJSON(ctx, &c), "unknown fields allowed by ReadJSON")
+	require.Equal(t, 8080, c.Port)
+
+	err := file.ReadJSONWithOptions(ctx, &c, DisallowUnknownJSONFields())
+	require.ErrorIs(t, err, ErrUnmarshalJSON)
+}
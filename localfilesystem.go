@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"io"
 	iofs "io/fs"
-	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -33,6 +33,19 @@ type LocalFileSystem struct {
 	// DefaultCreateDirPermissions are the default file permissions used for creating new directories
 	DefaultCreateDirPermissions Permissions
 
+	// LockAppends makes Append and OpenAppendWriter take an exclusive
+	// advisory lock on the file until the data is written
+	// or the writer is closed, so that appends from multiple processes
+	// that also lock the file don't interleave.
+	// On Unix flock is used, on Windows LockFileEx.
+	LockAppends bool
+
+	// DisableTildeExpansion disables replacing a leading '~'
+	// of paths with the home directory of the current user,
+	// so that paths are used literally for files or
+	// directories with names starting with '~'.
+	DisableTildeExpansion bool
+
 	WatchEventLogger Logger
 	WatchErrorLogger Logger
 
@@ -40,6 +53,9 @@ type LocalFileSystem struct {
 	watcher        *fsnotify.Watcher
 	lastCallbackID uint64
 	callbacks      map[string]map[uint64]func(File, Event)
+
+	networkMountsMtx sync.RWMutex
+	networkMounts    map[string]NetworkMount
 }
 
 func wrapOSErr(filePath string, err error) error {
@@ -52,13 +68,20 @@ func wrapOSErr(filePath string, err error) error {
 		return NewErrAlreadyExists(File(filePath))
 	case errors.Is(err, os.ErrPermission):
 		return NewErrPermission(File(filePath))
+	case errors.Is(err, syscall.EISDIR):
+		return NewErrIsDirectory(File(filePath))
+	case errors.Is(err, syscall.ENOTDIR):
+		return NewErrIsNotDirectory(File(filePath))
 	default:
 		return err
 	}
 }
 
-func expandTilde(path string) string {
-	if len(path) == 0 || path[0] != '~' {
+// expandTilde replaces a leading '~' of path with the
+// home directory of the current user if not disabled
+// with LocalFileSystem.DisableTildeExpansion.
+func (local *LocalFileSystem) expandTilde(path string) string {
+	if local.DisableTildeExpansion || len(path) == 0 || path[0] != '~' {
 		return path
 	}
 	currentUser, _ := user.Current()
@@ -101,15 +124,25 @@ func (local *LocalFileSystem) IsAbsPath(filePath string) bool {
 	return filepath.IsAbs(filePath)
 }
 
+// AbsPath returns the absolute form of filePath
+// or the cleaned filePath if the current working directory
+// can't be determined for a relative path.
+// Use AbsPathErr to get that error.
 func (local *LocalFileSystem) AbsPath(filePath string) string {
-	filePath = expandTilde(filePath)
-	absPath, err := filepath.Abs(filePath)
+	absPath, err := local.AbsPathErr(filePath)
 	if err != nil {
-		panic(err)
+		return filepath.Clean(local.expandTilde(filePath))
 	}
 	return absPath
 }
 
+// AbsPathErr returns the absolute form of filePath
+// or an error if the current working directory
+// can't be determined for a relative path.
+func (local *LocalFileSystem) AbsPathErr(filePath string) (string, error) {
+	return filepath.Abs(local.expandTilde(filePath))
+}
+
 func (local *LocalFileSystem) URL(cleanPath string) string {
 	return LocalPrefix + filepath.ToSlash(local.AbsPath(cleanPath))
 }
@@ -120,7 +153,7 @@ func (local *LocalFileSystem) CleanPathFromURI(uri string) string {
 		cleanPath = Separator + cleanPath
 	}
 	cleanPath = filepath.Clean(cleanPath)
-	cleanPath = expandTilde(cleanPath)
+	cleanPath = local.expandTilde(cleanPath)
 	return cleanPath
 }
 
@@ -129,18 +162,14 @@ func (local *LocalFileSystem) JoinCleanPath(uriParts ...string) string {
 		uriParts[0] = strings.TrimPrefix(uriParts[0], LocalPrefix)
 	}
 	cleanPath := filepath.Join(uriParts...)
-	unescPath, err := url.PathUnescape(cleanPath)
-	if err == nil {
-		cleanPath = unescPath
-	}
 	cleanPath = filepath.Clean(cleanPath)
-	cleanPath = expandTilde(cleanPath)
+	cleanPath = local.expandTilde(cleanPath)
 	return cleanPath
 }
 
 func (local *LocalFileSystem) SplitPath(filePath string) []string {
 	filePath = strings.TrimPrefix(filePath, LocalPrefix)
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	filePath = strings.Trim(filePath, Separator)
 	if filePath == "" {
 		return nil
@@ -177,18 +206,18 @@ func (local *LocalFileSystem) MatchAnyPattern(name string, patterns []string) (b
 	return false, nil
 }
 
-func (*LocalFileSystem) SplitDirAndName(filePath string) (dir, name string) {
-	filePath = expandTilde(filePath)
+func (local *LocalFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	filePath = local.expandTilde(filePath)
 	return fsimpl.SplitDirAndName(filePath, len(filepath.VolumeName(filePath)), Separator)
 }
 
 func (local *LocalFileSystem) VolumeName(filePath string) string {
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	return filepath.VolumeName(filePath)
 }
 
 func (local *LocalFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	info, err := os.Stat(filePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -200,7 +229,7 @@ func (local *LocalFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
 }
 
 func (local *LocalFileSystem) IsHidden(filePath string) bool {
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	name := filepath.Base(filePath)
 	if len(name) > 0 && name[0] == '.' {
 		return true
@@ -215,7 +244,7 @@ func (local *LocalFileSystem) IsHidden(filePath string) bool {
 }
 
 func (local *LocalFileSystem) IsSymbolicLink(filePath string) bool {
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	info, err := os.Lstat(filePath)
 	if err != nil {
 		return false
@@ -223,36 +252,59 @@ func (local *LocalFileSystem) IsSymbolicLink(filePath string) bool {
 	return info.Mode()&os.ModeSymlink != 0
 }
 
+// CreateSymbolicLink creates newFile as symbolic link to oldFile.
+//
+// Deprecated: Use File.CreateSymbolicLinkTo or CreateSymlink.
 func (local *LocalFileSystem) CreateSymbolicLink(oldFile, newFile File) error {
 	if oldFile == "" || newFile == "" {
 		return ErrEmptyPath
 	}
 	oldFs, oldPath := oldFile.ParseRawURI()
 	newFs, newPath := newFile.ParseRawURI()
-	oldPath = expandTilde(oldPath)
-	newPath = expandTilde(newPath)
 	if oldFs != local || newFs != local {
 		return errors.New("LocalFileSystem.CreateSymbolicLink needs LocalFileSystem files")
 	}
-	return os.Symlink(oldPath, newPath)
+	return local.CreateSymlink(oldPath, newPath)
 }
 
+// ReadSymbolicLink returns the unchanged target of the symbolic link file.
+//
+// Deprecated: Use File.ReadSymbolicLink or ReadSymlink.
 func (local *LocalFileSystem) ReadSymbolicLink(file File) (linked File, err error) {
 	if file == "" {
 		return "", ErrEmptyPath
 	}
 	fileFs, filePath := file.ParseRawURI()
 	if fileFs != local {
-		return "", errors.New("LocalFileSystem.CreateSymbolicLink needs LocalFileSystem files")
+		return "", errors.New("LocalFileSystem.ReadSymbolicLink needs LocalFileSystem files")
 	}
-	filePath = expandTilde(filePath)
-	linkedPath, err := os.Readlink(filePath)
+	linkedPath, err := local.ReadSymlink(filePath)
 	if err != nil {
-		return "", fmt.Errorf("LocalFileSystem.ReadSymbolicLink(%#v): error reading link: %w", file, err)
+		return "", err
 	}
 	return File(linkedPath), nil
 }
 
+func (local *LocalFileSystem) CreateSymlink(targetPath, linkPath string) error {
+	if targetPath == "" || linkPath == "" {
+		return ErrEmptyPath
+	}
+	linkPath = local.expandTilde(linkPath)
+	return wrapOSErr(linkPath, os.Symlink(local.expandTilde(targetPath), linkPath))
+}
+
+func (local *LocalFileSystem) ReadSymlink(linkPath string) (targetPath string, err error) {
+	if linkPath == "" {
+		return "", ErrEmptyPath
+	}
+	linkPath = local.expandTilde(linkPath)
+	targetPath, err = os.Readlink(linkPath)
+	if err != nil {
+		return "", wrapOSErr(linkPath, err)
+	}
+	return targetPath, nil
+}
+
 func (local *LocalFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) (err error) {
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -262,7 +314,7 @@ func (local *LocalFileSystem) ListDirInfo(ctx context.Context, dirPath string, c
 	}
 
 	dirPath = filepath.Clean(dirPath)
-	dirPath = expandTilde(dirPath)
+	dirPath = local.expandTilde(dirPath)
 
 	defer func() {
 		if err != nil {
@@ -284,6 +336,7 @@ func (local *LocalFileSystem) ListDirInfo(ctx context.Context, dirPath string, c
 	}
 	defer f.Close() //#nosec G307
 
+	_, mount, _ := local.NetworkMountOf(dirPath)
 	for eof := false; !eof; {
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -306,11 +359,18 @@ func (local *LocalFileSystem) ListDirInfo(ctx context.Context, dirPath string, c
 			if !match {
 				continue
 			}
+			filePath := filepath.Join(dirPath, name)
+			if mount.ListWithoutStat {
+				err = callback(newDirEntryFileInfo(File(filePath), entry))
+				if err != nil {
+					return err
+				}
+				continue
+			}
 			info, err := entry.Info()
 			if err != nil {
 				return fmt.Errorf("error from fs.DirEntry.Info: %w", err)
 			}
-			filePath := filepath.Join(dirPath, name)
 			hidden := strings.HasPrefix(name, ".")
 			if !hidden {
 				hidden, err = hasLocalFileAttributeHidden(filePath)
@@ -339,7 +399,7 @@ func (local *LocalFileSystem) ListDirMax(ctx context.Context, dirPath string, ma
 	}
 
 	dirPath = filepath.Clean(dirPath)
-	dirPath = expandTilde(dirPath)
+	dirPath = local.expandTilde(dirPath)
 
 	defer func() {
 		if err != nil {
@@ -405,7 +465,7 @@ func (local *LocalFileSystem) SetPermissions(filePath string, perm Permissions)
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return err
@@ -420,7 +480,7 @@ func (local *LocalFileSystem) Touch(filePath string, perm []Permissions) error {
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	if _, e := os.Stat(filePath); e == nil {
 		now := time.Now()
 		return os.Chtimes(filePath, now, now)
@@ -437,7 +497,7 @@ func (local *LocalFileSystem) MakeDir(dirPath string, perm []Permissions) error
 	if dirPath == "" {
 		return ErrEmptyPath
 	}
-	dirPath = expandTilde(dirPath)
+	dirPath = local.expandTilde(dirPath)
 	p := JoinPermissions(perm, Local.DefaultCreateDirPermissions) | extraDirPermissions
 	err := wrapOSErr(dirPath, os.Mkdir(dirPath, p.FileMode(true)))
 	if err != nil {
@@ -459,7 +519,7 @@ func (local *LocalFileSystem) MakeAllDirs(dirPath string, perm []Permissions) er
 	if dirPath == "" {
 		return ErrEmptyPath
 	}
-	dirPath = expandTilde(dirPath)
+	dirPath = local.expandTilde(dirPath)
 	p := JoinPermissions(perm, Local.DefaultCreateDirPermissions) | extraDirPermissions
 	err := wrapOSErr(dirPath, os.MkdirAll(dirPath, p.FileMode(true)))
 	if err != nil {
@@ -481,30 +541,100 @@ func (local *LocalFileSystem) MakeAllDirs(dirPath string, perm []Permissions) er
 	return nil
 }
 
+// ReadAll reads the file in chunks and checks the context between them
+// so that reading large files can be canceled.
 func (local *LocalFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
-	// TODO make really large file op cancelable
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 	if filePath == "" {
 		return nil, ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
-	data, err := os.ReadFile(filePath) //#nosec G304
-	return data, wrapOSErr(filePath, err)
+	filePath = local.expandTilde(filePath)
+	if ctx.Done() == nil {
+		// Context can't be canceled
+		data, err := os.ReadFile(filePath) //#nosec G304
+		return data, wrapOSErr(filePath, err)
+	}
+	f, err := os.Open(filePath) //#nosec G304
+	if err != nil {
+		return nil, wrapOSErr(filePath, err)
+	}
+	defer f.Close()
+	sizeHint := 512
+	if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+		// One more byte so that reading EOF does not reallocate
+		sizeHint = int(info.Size()) + 1
+	}
+	const chunkSize = 4 * 1024 * 1024 // 4MB
+	data, err := readAllContext(ctx, f, sizeHint, chunkSize)
+	if err != nil {
+		return nil, wrapOSErr(filePath, err)
+	}
+	return data, nil
 }
 
+// WriteAll writes data in chunks so that the write can be canceled
+// by ctx and removes the partially written file in that case
+// or if any other error occurs while writing.
 func (local *LocalFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
-	// TODO make really large file op cancelable
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	p := JoinPermissions(perm, Local.DefaultCreatePermissions)
-	return wrapOSErr(filePath, os.WriteFile(filePath, data, p.FileMode(false)))
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, p.FileMode(false)) //#nosec G304
+	if err != nil {
+		return wrapOSErr(filePath, err)
+	}
+	const chunkSize = 4 * 1024 * 1024 // 4MB
+	err = writeAllContext(ctx, f, data, chunkSize)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(filePath)
+		return wrapOSErr(filePath, err)
+	}
+	return nil
+}
+
+// WriteAllAtomic writes data to a temporary file in the directory
+// of filePath that is synced to disk and then renamed to filePath,
+// so readers either see the complete old or the complete new content.
+func (local *LocalFileSystem) WriteAllAtomic(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if filePath == "" {
+		return ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	p := JoinPermissions(perm, Local.DefaultCreatePermissions)
+	tempPath := filepath.Join(filepath.Dir(filePath), atomicWriteTempName(filepath.Base(filePath)))
+	f, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, p.FileMode(false)) //#nosec G304
+	if err != nil {
+		return wrapOSErr(filePath, err)
+	}
+	const chunkSize = 4 * 1024 * 1024 // 4MB
+	err = writeAllContext(ctx, f, data, chunkSize)
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tempPath, filePath)
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return wrapOSErr(filePath, err)
+	}
+	return nil
 }
 
 func (local *LocalFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
@@ -528,16 +658,23 @@ func (local *LocalFileSystem) OpenReader(filePath string) (ReadCloser, error) {
 	if filePath == "" {
 		return nil, ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	f, err := os.OpenFile(filePath, os.O_RDONLY, 0) //#nosec G304
-	return f, wrapOSErr(filePath, err)
+	if err != nil {
+		return nil, wrapOSErr(filePath, err)
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		_ = f.Close()
+		return nil, NewErrIsDirectory(File(filePath))
+	}
+	return f, nil
 }
 
 func (local *LocalFileSystem) OpenWriter(filePath string, perm []Permissions) (WriteCloser, error) {
 	if filePath == "" {
 		return nil, ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	p := JoinPermissions(perm, Local.DefaultCreatePermissions)
 	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, p.FileMode(false)) //#nosec G304
 	return f, wrapOSErr(filePath, err)
@@ -547,17 +684,49 @@ func (local *LocalFileSystem) OpenAppendWriter(filePath string, perm []Permissio
 	if filePath == "" {
 		return nil, ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	p := JoinPermissions(perm, Local.DefaultCreatePermissions)
 	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, p.FileMode(false)) //#nosec G304
-	return f, wrapOSErr(filePath, err)
+	if err != nil {
+		return nil, wrapOSErr(filePath, err)
+	}
+	if !local.LockAppends {
+		return f, nil
+	}
+	err = lockLocalFile(f)
+	if err != nil {
+		return nil, errors.Join(wrapOSErr(filePath, err), f.Close())
+	}
+	return &lockedLocalFile{f}, nil
+}
+
+// lockedLocalFile unlocks the file before closing it
+type lockedLocalFile struct {
+	*os.File
+}
+
+func (f *lockedLocalFile) Close() error {
+	return errors.Join(unlockLocalFile(f.File), f.File.Close())
+}
+
+func (local *LocalFileSystem) OpenWriteSeeker(filePath string, perm []Permissions) (WriteSeekCloser, error) {
+	if filePath == "" {
+		return nil, ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	p := JoinPermissions(perm, Local.DefaultCreatePermissions)
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, p.FileMode(false)) //#nosec G304
+	if err != nil {
+		return nil, wrapOSErr(filePath, err)
+	}
+	return f, nil
 }
 
 func (local *LocalFileSystem) OpenReadWriter(filePath string, perm []Permissions) (ReadWriteSeekCloser, error) {
 	if filePath == "" {
 		return nil, ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	p := JoinPermissions(perm, Local.DefaultCreatePermissions)
 	f, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, p.FileMode(false)) //#nosec G304
 	return f, wrapOSErr(filePath, err)
@@ -567,7 +736,7 @@ func (local *LocalFileSystem) Truncate(filePath string, newSize int64) error {
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	info, err := local.Stat(filePath)
 	if err != nil {
 		return NewErrDoesNotExist(File(filePath))
@@ -589,8 +758,8 @@ func (local *LocalFileSystem) CopyFile(ctx context.Context, srcFilePath string,
 		return ErrEmptyPath
 	}
 
-	srcFilePath = expandTilde(srcFilePath)
-	destFilePath = expandTilde(destFilePath)
+	srcFilePath = local.expandTilde(srcFilePath)
+	destFilePath = local.expandTilde(destFilePath)
 	srcStat, _ := os.Stat(srcFilePath)
 	destStat, _ := os.Stat(destFilePath)
 	if os.SameFile(srcStat, destStat) {
@@ -609,7 +778,9 @@ func (local *LocalFileSystem) CopyFile(ctx context.Context, srcFilePath string,
 	}
 	defer w.Close() //#nosec G307
 
-	if len(*buf) == 0 {
+	if size := local.networkCopyBufferSize(srcFilePath, destFilePath); len(*buf) < size {
+		*buf = make([]byte, size)
+	} else if len(*buf) == 0 {
 		*buf = make([]byte, copyBufferSize)
 	}
 	_, err = io.CopyBuffer(w, r, *buf)
@@ -619,11 +790,25 @@ func (local *LocalFileSystem) CopyFile(ctx context.Context, srcFilePath string,
 	return nil
 }
 
+func (local *LocalFileSystem) CreateHardLink(existingPath, newPath string) error {
+	if existingPath == "" || newPath == "" {
+		return ErrEmptyPath
+	}
+	existingPath = local.expandTilde(existingPath)
+	newPath = local.expandTilde(newPath)
+	if info, err := os.Stat(existingPath); err != nil {
+		return wrapOSErr(existingPath, err)
+	} else if info.IsDir() {
+		return NewErrIsDirectory(File(existingPath))
+	}
+	return wrapOSErr(newPath, os.Link(existingPath, newPath))
+}
+
 func (local *LocalFileSystem) Rename(filePath string, newName string) (newPath string, err error) {
 	if filePath == "" || newName == "" {
 		return "", ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	if strings.ContainsAny(newName, local.Separator()) {
 		return "", fmt.Errorf("newName %#v for File.Rename contains path separator %s", newName, local.Separator())
 	}
@@ -642,8 +827,8 @@ func (local *LocalFileSystem) Move(filePath string, destPath string) error {
 	if filePath == "" || destPath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
-	destPath = expandTilde(destPath)
+	filePath = local.expandTilde(filePath)
+	destPath = local.expandTilde(destPath)
 	info, err := local.Stat(filePath)
 	if err != nil {
 		return err
@@ -658,7 +843,7 @@ func (local *LocalFileSystem) Remove(filePath string) error {
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 	return wrapOSErr(filePath, os.Remove(filePath))
 }
 
@@ -669,7 +854,11 @@ func (local *LocalFileSystem) Watch(filePath string, onEvent func(File, Event))
 	if _, e := os.Stat(filePath); e != nil {
 		return nil, NewErrDoesNotExist(File(filePath))
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
+
+	if _, mount, ok := local.NetworkMountOf(filePath); ok && mount.PollWatchInterval > 0 {
+		return local.pollWatch(filePath, mount.PollWatchInterval, onEvent), nil
+	}
 
 	local.watcherMtx.Lock()
 	defer local.watcherMtx.Unlock()
@@ -0,0 +1,23 @@
+//go:build darwin || freebsd || linux
+
+package fs
+
+import "golang.org/x/sys/unix"
+
+func (local *LocalFileSystem) DiskUsage(filePath string) (DiskUsage, error) {
+	if filePath == "" {
+		return DiskUsage{}, ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	var stat unix.Statfs_t
+	err := unix.Statfs(filePath, &stat)
+	if err != nil {
+		return DiskUsage{}, wrapOSErr(filePath, err)
+	}
+	blockSize := int64(stat.Bsize)
+	return DiskUsage{
+		Total:     int64(stat.Blocks) * blockSize,
+		Free:      int64(stat.Bfree) * blockSize,
+		Available: int64(stat.Bavail) * blockSize,
+	}, nil
+}
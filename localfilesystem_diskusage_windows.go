@@ -0,0 +1,24 @@
+package fs
+
+import "golang.org/x/sys/windows"
+
+func (local *LocalFileSystem) DiskUsage(filePath string) (DiskUsage, error) {
+	if filePath == "" {
+		return DiskUsage{}, ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	pathPtr, err := windows.UTF16PtrFromString(filePath)
+	if err != nil {
+		return DiskUsage{}, err
+	}
+	var available, total, free uint64
+	err = windows.GetDiskFreeSpaceEx(pathPtr, &available, &total, &free)
+	if err != nil {
+		return DiskUsage{}, wrapOSErr(filePath, err)
+	}
+	return DiskUsage{
+		Total:     int64(total),
+		Free:      int64(free),
+		Available: int64(available),
+	}, nil
+}
@@ -0,0 +1,207 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultNetworkCopyBufferSize is the copy buffer size used by
+// LocalFileSystem.CopyFile for network mounts
+// without a NetworkMount.CopyBufferSize
+const DefaultNetworkCopyBufferSize = 1024 * 1024 * 16
+
+// NetworkMount configures optimizations for a directory
+// of the local file system that is mounted from a network
+// file system like NFS, Amazon EFS, Amazon FSx, or SMB,
+// see LocalFileSystem.SetNetworkMount.
+type NetworkMount struct {
+	// CopyBufferSize is the minimum buffer size used by CopyFile
+	// if the source or destination is in the mount
+	// to reduce the number of network round trips.
+	// Zero uses DefaultNetworkCopyBufferSize.
+	CopyBufferSize int
+
+	// ListWithoutStat makes ListDirInfo return only the name
+	// and type of files as read from the directory
+	// without a Stat call per file.
+	// Size, Modified, and Permissions of the listed FileInfo are zero
+	// and only names starting with a dot are reported as hidden.
+	ListWithoutStat bool
+
+	// PollWatchInterval makes Watch poll for changes with this interval
+	// instead of using fsnotify which is not notified
+	// about changes made by other clients of a network file system.
+	// Zero keeps using fsnotify.
+	PollWatchInterval time.Duration
+}
+
+// SetNetworkMount tags mountDir and all files below it
+// as network backed and applies the optimizations of mount.
+// Mounts can be nested, the mount with the longest path
+// containing a file is used for it.
+func (local *LocalFileSystem) SetNetworkMount(mountDir string, mount NetworkMount) error {
+	mountDir, err := filepath.Abs(local.expandTilde(mountDir))
+	if err != nil {
+		return err
+	}
+	local.networkMountsMtx.Lock()
+	defer local.networkMountsMtx.Unlock()
+
+	if local.networkMounts == nil {
+		local.networkMounts = make(map[string]NetworkMount)
+	}
+	local.networkMounts[mountDir] = mount
+	return nil
+}
+
+// RemoveNetworkMount removes a mount set with SetNetworkMount
+func (local *LocalFileSystem) RemoveNetworkMount(mountDir string) {
+	mountDir, err := filepath.Abs(local.expandTilde(mountDir))
+	if err != nil {
+		return
+	}
+	local.networkMountsMtx.Lock()
+	defer local.networkMountsMtx.Unlock()
+
+	delete(local.networkMounts, mountDir)
+}
+
+// NetworkMountOf returns the network mount
+// set with SetNetworkMount that contains filePath.
+func (local *LocalFileSystem) NetworkMountOf(filePath string) (mountDir string, mount NetworkMount, ok bool) {
+	local.networkMountsMtx.RLock()
+	defer local.networkMountsMtx.RUnlock()
+
+	if len(local.networkMounts) == 0 {
+		return "", NetworkMount{}, false
+	}
+	dir, err := filepath.Abs(local.expandTilde(filePath))
+	if err != nil {
+		return "", NetworkMount{}, false
+	}
+	for {
+		if mount, ok := local.networkMounts[dir]; ok {
+			return dir, mount, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", NetworkMount{}, false
+		}
+		dir = parent
+	}
+}
+
+// networkCopyBufferSize returns the copy buffer size
+// if srcPath or destPath is in a network mount, else zero
+func (local *LocalFileSystem) networkCopyBufferSize(srcPath, destPath string) int {
+	size := 0
+	for _, filePath := range []string{srcPath, destPath} {
+		if _, mount, ok := local.NetworkMountOf(filePath); ok {
+			if mount.CopyBufferSize > 0 {
+				size = max(size, mount.CopyBufferSize)
+			} else {
+				size = max(size, DefaultNetworkCopyBufferSize)
+			}
+		}
+	}
+	return size
+}
+
+// newDirEntryFileInfo returns a FileInfo with the
+// information of a directory entry without calling Stat
+func newDirEntryFileInfo(file File, entry os.DirEntry) *FileInfo {
+	return &FileInfo{
+		File:      file,
+		Name:      entry.Name(),
+		Exists:    true,
+		IsDir:     entry.IsDir(),
+		IsRegular: entry.Type().IsRegular(),
+		IsHidden:  strings.HasPrefix(entry.Name(), "."),
+	}
+}
+
+// pollState is the state of a polled file
+type pollState struct {
+	size     int64
+	modified time.Time
+}
+
+// pollWatch calls onEvent for changes of filePath and
+// if it's a directory of the files directly in it
+// detected by comparing their size and modification time
+// every interval.
+func (local *LocalFileSystem) pollWatch(filePath string, interval time.Duration, onEvent func(File, Event)) (cancel func() error) {
+	stop := make(chan struct{})
+	last := pollSnapshot(filePath)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			current := pollSnapshot(filePath)
+			for name, state := range current {
+				lastState, existed := last[name]
+				switch {
+				case !existed:
+					local.pollEventCallback(name, eventCreate, onEvent)
+				case state != lastState:
+					local.pollEventCallback(name, eventWrite, onEvent)
+				}
+			}
+			for name := range last {
+				if _, exists := current[name]; !exists {
+					local.pollEventCallback(name, eventRemove, onEvent)
+				}
+			}
+			last = current
+		}
+	}()
+	var once sync.Once
+	return func() error {
+		once.Do(func() { close(stop) })
+		return nil
+	}
+}
+
+func (local *LocalFileSystem) pollEventCallback(filePath string, event Event, callback func(File, Event)) {
+	defer func() {
+		p := recover()
+		if p != nil && local.WatchErrorLogger != nil {
+			local.WatchErrorLogger.Printf("watch callback panic: %#v", p)
+		}
+	}()
+	if local.WatchEventLogger != nil {
+		local.WatchEventLogger.Printf("watch poll event: %s %q", event, filePath)
+	}
+	callback(File(filePath), event)
+}
+
+// pollSnapshot returns the state of filePath
+// and the files in it if it's a directory
+func pollSnapshot(filePath string) map[string]pollState {
+	snapshot := make(map[string]pollState)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return snapshot
+	}
+	if !info.IsDir() {
+		snapshot[filePath] = pollState{size: info.Size(), modified: info.ModTime()}
+		return snapshot
+	}
+	entries, _ := os.ReadDir(filePath)
+	for _, entry := range entries {
+		entryInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshot[filepath.Join(filePath, entry.Name())] = pollState{size: entryInfo.Size(), modified: entryInfo.ModTime()}
+	}
+	return snapshot
+}
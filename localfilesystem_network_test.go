@@ -0,0 +1,100 @@
+package fs
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalFileSystem_NetworkMount(t *testing.T) {
+	local := &LocalFileSystem{}
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+
+	_, _, ok := local.NetworkMountOf(dir)
+	require.False(t, ok)
+
+	require.NoError(t, local.SetNetworkMount(dir, NetworkMount{ListWithoutStat: true}))
+	require.NoError(t, local.SetNetworkMount(sub, NetworkMount{CopyBufferSize: 1024}))
+
+	mountDir, mount, ok := local.NetworkMountOf(filepath.Join(dir, "file.txt"))
+	require.True(t, ok)
+	require.Equal(t, dir, mountDir)
+	require.True(t, mount.ListWithoutStat)
+
+	mountDir, mount, ok = local.NetworkMountOf(filepath.Join(sub, "a", "b.txt"))
+	require.True(t, ok)
+	require.Equal(t, sub, mountDir)
+	require.Equal(t, 1024, mount.CopyBufferSize)
+
+	_, _, ok = local.NetworkMountOf(filepath.Dir(dir))
+	require.False(t, ok)
+
+	require.Equal(t, DefaultNetworkCopyBufferSize, local.networkCopyBufferSize(filepath.Join(sub, "x"), filepath.Join(dir, "y")))
+	require.Equal(t, 1024, local.networkCopyBufferSize(filepath.Join(sub, "x"), filepath.Join(dir, "..", "y")))
+	require.Equal(t, 0, local.networkCopyBufferSize("/", "/"))
+
+	local.RemoveNetworkMount(sub)
+	mountDir, _, _ = local.NetworkMountOf(sub)
+	require.Equal(t, dir, mountDir)
+}
+
+func TestLocalFileSystem_ListWithoutStat(t *testing.T) {
+	local := &LocalFileSystem{}
+	dir := t.TempDir()
+	require.NoError(t, File(dir).Join("file.txt").WriteAllString("Hello"))
+	require.NoError(t, File(dir).Join("sub").MakeDir())
+	require.NoError(t, local.SetNetworkMount(dir, NetworkMount{ListWithoutStat: true}))
+
+	infos := make(map[string]*FileInfo)
+	err := local.ListDirInfo(context.Background(), dir, func(info *FileInfo) error {
+		infos[info.Name] = info
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	require.True(t, infos["file.txt"].IsRegular)
+	require.Equal(t, int64(0), infos["file.txt"].Size)
+	require.True(t, infos["sub"].IsDir)
+	require.Equal(t, File(dir).Join("sub"), infos["sub"].File)
+}
+
+func TestLocalFileSystem_PollWatch(t *testing.T) {
+	local := &LocalFileSystem{}
+	dir := t.TempDir()
+	require.NoError(t, local.SetNetworkMount(dir, NetworkMount{PollWatchInterval: 10 * time.Millisecond}))
+
+	var (
+		mtx    sync.Mutex
+		events = make(map[File]Event)
+	)
+	cancel, err := local.Watch(dir, func(file File, event Event) {
+		mtx.Lock()
+		events[file] |= event
+		mtx.Unlock()
+	})
+	require.NoError(t, err)
+	defer cancel()
+
+	file := File(dir).Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+	require.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return events[file].HasCreate()
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, file.Remove())
+	require.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return events[file].HasRemove()
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, cancel())
+	require.NoError(t, cancel())
+}
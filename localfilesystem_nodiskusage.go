@@ -0,0 +1,7 @@
+//go:build !(darwin || freebsd || linux || windows)
+
+package fs
+
+func (local *LocalFileSystem) DiskUsage(filePath string) (DiskUsage, error) {
+	return DiskUsage{}, NewErrUnsupported(local, "DiskUsage")
+}
@@ -0,0 +1,16 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package fs
+
+import (
+	"errors"
+	"os"
+)
+
+func lockLocalFile(*os.File) error {
+	return errors.ErrUnsupported
+}
+
+func unlockLocalFile(*os.File) error {
+	return nil
+}
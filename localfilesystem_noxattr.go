@@ -0,0 +1,19 @@
+//go:build !(darwin || freebsd || linux || netbsd)
+
+package fs
+
+func (local *LocalFileSystem) GetXAttr(filePath, name string) ([]byte, error) {
+	return nil, NewErrUnsupported(local, "GetXAttr")
+}
+
+func (local *LocalFileSystem) SetXAttr(filePath, name string, value []byte) error {
+	return NewErrUnsupported(local, "SetXAttr")
+}
+
+func (local *LocalFileSystem) ListXAttrs(filePath string) ([]string, error) {
+	return nil, NewErrUnsupported(local, "ListXAttrs")
+}
+
+func (local *LocalFileSystem) RemoveXAttr(filePath, name string) error {
+	return NewErrUnsupported(local, "RemoveXAttr")
+}
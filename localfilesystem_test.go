@@ -1,9 +1,14 @@
 package fs
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_LocalFileSystem_MakeAllDirs(t *testing.T) {
@@ -35,3 +40,112 @@ func Test_LocalFileSystem_SplitDirAndName(t *testing.T) {
 	assert.Equal(t, root, dir)
 	assert.Equal(t, "FileInRoot", name)
 }
+
+func Test_LocalFileSystem_SpecialCharacterNames(t *testing.T) {
+	dir := File(t.TempDir())
+	for _, name := range []string{
+		"with space.txt",
+		"hash#tag.txt",
+		"question?.txt",
+		"100%.txt",
+		"literal%20escape.txt",
+		"percent%2Fslash.txt",
+	} {
+		t.Run(name, func(t *testing.T) {
+			file := dir.Join(name)
+			require.Equal(t, name, file.Name(), "name is not decoded")
+			require.Equal(t, name, dir.Joinf("%s", name).Name())
+
+			err := file.WriteAllString(name)
+			require.NoError(t, err)
+			require.True(t, File(file.LocalPath()).Exists(), "written to literal local path")
+			require.True(t, Local.JoinCleanFile(dir.LocalPath(), name).Exists())
+
+			content, err := file.ReadAllString()
+			require.NoError(t, err)
+			require.Equal(t, name, content)
+
+			files, err := dir.ListDirMax(-1, name)
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+			require.Equal(t, name, files[0].Name())
+		})
+	}
+}
+
+func Test_LocalFileSystem_LockAppends(t *testing.T) {
+	localFileSystem := &LocalFileSystem{
+		DefaultCreatePermissions:    UserAndGroupReadWrite,
+		DefaultCreateDirPermissions: UserAndGroupReadWrite,
+		LockAppends:                 true,
+	}
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "log.txt")
+
+	writer, err := localFileSystem.OpenAppendWriter(filePath, nil)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	appended := make(chan error)
+	go func() {
+		appended <- localFileSystem.Append(ctx, filePath, []byte("second\n"), nil)
+	}()
+	select {
+	case err := <-appended:
+		t.Fatalf("Append did not wait for the lock of the open writer: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, <-appended)
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "first\nsecond\n", string(data))
+}
+
+func Test_LocalFileSystem_ReadAllCanceled(t *testing.T) {
+	file := File(t.TempDir()).Join("large")
+	require.NoError(t, file.WriteAll(make([]byte, 10*1024*1024)))
+
+	ctx := &doneFailContext{
+		failContext: failContext{errAfter: 2, err: context.Canceled},
+		done:        make(chan struct{}),
+	}
+	_, err := Local.ReadAll(ctx, file.LocalPath())
+	require.ErrorIs(t, err, context.Canceled)
+
+	data, err := Local.ReadAll(&doneFailContext{done: make(chan struct{})}, file.LocalPath())
+	require.NoError(t, err)
+	require.Len(t, data, 10*1024*1024)
+}
+
+func Test_LocalFileSystem_WriteAllCanceled(t *testing.T) {
+	file := File(t.TempDir()).Join("large")
+	require.NoError(t, file.WriteAllString("existing"))
+
+	ctx := &doneFailContext{
+		failContext: failContext{errAfter: 2, err: context.Canceled},
+		done:        make(chan struct{}),
+	}
+	err := Local.WriteAll(ctx, file.LocalPath(), make([]byte, 10*1024*1024), nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, file.Exists(), "partially written file removed")
+
+	err = Local.WriteAll(&doneFailContext{done: make(chan struct{})}, file.LocalPath(), make([]byte, 10*1024*1024), nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(10*1024*1024), file.Size())
+}
+
+func Test_LocalFileSystem_DisableTildeExpansion(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory:", err)
+	}
+	localFileSystem := &LocalFileSystem{}
+	require.Equal(t, filepath.Join(home, "file.txt"), localFileSystem.JoinCleanPath("~", "file.txt"))
+
+	localFileSystem.DisableTildeExpansion = true
+	require.Equal(t, filepath.Join("~", "file.txt"), localFileSystem.JoinCleanPath("~", "file.txt"))
+	require.Equal(t, []string{"~", "file.txt"}, localFileSystem.SplitPath(filepath.Join("~", "file.txt")))
+}
@@ -15,6 +15,8 @@ const localRoot = `/`
 
 var extraDirPermissions Permissions = AllExecute
 
+const localEOL = EOLLF
+
 func hasLocalFileAttributeHidden(string) (bool, error) {
 	return false, nil
 }
@@ -23,7 +25,7 @@ func (local *LocalFileSystem) User(filePath string) (string, error) {
 	if filePath == "" {
 		return "", ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -44,7 +46,7 @@ func (local *LocalFileSystem) SetUser(filePath string, username string) error {
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 
 	u, err := user.Lookup(username)
 	if err != nil {
@@ -61,7 +63,7 @@ func (local *LocalFileSystem) Group(filePath string) (string, error) {
 	if filePath == "" {
 		return "", ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -79,12 +81,12 @@ func (local *LocalFileSystem) Group(filePath string) (string, error) {
 }
 
 func (local *LocalFileSystem) SetGroup(filePath string, group string) error {
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 
 	if filePath == "" {
 		return ErrEmptyPath
 	}
-	filePath = expandTilde(filePath)
+	filePath = local.expandTilde(filePath)
 
 	g, err := user.LookupGroup(group)
 	if err != nil {
@@ -6,6 +6,8 @@ const localRoot = `C:\`
 
 var extraDirPermissions Permissions = 0
 
+const localEOL = EOLCRLF
+
 func hasLocalFileAttributeHidden(filePath string) (bool, error) {
 	p, e := syscall.UTF16PtrFromString(filePath)
 	if e != nil {
@@ -0,0 +1,88 @@
+//go:build darwin || freebsd || linux || netbsd
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+func (local *LocalFileSystem) GetXAttr(filePath, name string) ([]byte, error) {
+	if filePath == "" {
+		return nil, ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	for {
+		size, err := unix.Getxattr(filePath, name, nil)
+		if err != nil {
+			return nil, wrapXAttrErr(filePath, err)
+		}
+		value := make([]byte, size)
+		n, err := unix.Getxattr(filePath, name, value)
+		if errors.Is(err, unix.ERANGE) {
+			continue // Value grew after getting its size
+		}
+		if err != nil {
+			return nil, wrapXAttrErr(filePath, err)
+		}
+		return value[:n], nil
+	}
+}
+
+func (local *LocalFileSystem) SetXAttr(filePath, name string, value []byte) error {
+	if filePath == "" {
+		return ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	return wrapXAttrErr(filePath, unix.Setxattr(filePath, name, value, 0))
+}
+
+func (local *LocalFileSystem) ListXAttrs(filePath string) ([]string, error) {
+	if filePath == "" {
+		return nil, ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	for {
+		size, err := unix.Listxattr(filePath, nil)
+		if err != nil {
+			return nil, wrapXAttrErr(filePath, err)
+		}
+		buf := make([]byte, size)
+		n, err := unix.Listxattr(filePath, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // List grew after getting its size
+		}
+		if err != nil {
+			return nil, wrapXAttrErr(filePath, err)
+		}
+		// Names are zero terminated
+		names := strings.FieldsFunc(string(buf[:n]), func(r rune) bool { return r == 0 })
+		slices.Sort(names)
+		return names, nil
+	}
+}
+
+func (local *LocalFileSystem) RemoveXAttr(filePath, name string) error {
+	if filePath == "" {
+		return ErrEmptyPath
+	}
+	filePath = local.expandTilde(filePath)
+	return wrapXAttrErr(filePath, unix.Removexattr(filePath, name))
+}
+
+func wrapXAttrErr(filePath string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errnoXAttrDoesNotExist):
+		return fmt.Errorf("%w: %s", ErrXAttrDoesNotExist, filePath)
+	case errors.Is(err, unix.ENOTSUP):
+		return NewErrUnsupported(Local, "extended attributes on "+filePath)
+	default:
+		return wrapOSErr(filePath, err)
+	}
+}
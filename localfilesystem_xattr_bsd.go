@@ -0,0 +1,8 @@
+//go:build darwin || freebsd || netbsd
+
+package fs
+
+import "golang.org/x/sys/unix"
+
+// errnoXAttrDoesNotExist is returned by BSDs for missing extended attributes
+const errnoXAttrDoesNotExist = unix.ENOATTR
@@ -0,0 +1,6 @@
+package fs
+
+import "golang.org/x/sys/unix"
+
+// errnoXAttrDoesNotExist is returned by Linux for missing extended attributes
+const errnoXAttrDoesNotExist = unix.ENODATA
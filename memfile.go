@@ -298,15 +298,21 @@ func (f MemFile) OpenReadSeeker() (ReadSeekCloser, error) {
 //
 // Returns a wrapped ErrUnmarshalJSON when the unmarshalling failed.
 func (f MemFile) ReadJSON(ctx context.Context, output any) error {
+	return f.ReadJSONWithOptions(ctx, output)
+}
+
+// ReadJSONWithOptions reads and unmarshalles the JSON content of the file
+// to output like ReadJSON. Options like DisallowUnknownJSONFields
+// or ValidateJSON can be passed to fail fast on unexpected content.
+//
+// Returns a wrapped ErrUnmarshalJSON when the unmarshalling failed
+// or a wrapped ErrValidateJSON when a ValidateJSON option returned an error.
+func (f MemFile) ReadJSONWithOptions(ctx context.Context, output any, options ...ReadJSONOption) error {
 	// Context is passed for identical call signature as other types
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	err := json.Unmarshal(f.FileData, output)
-	if err != nil {
-		return fmt.Errorf("%w because: %w", ErrUnmarshalJSON, err)
-	}
-	return nil
+	return UnmarshalJSON(f.FileData, output, options...)
 }
 
 // WriteJSON mashalles input to JSON and writes it as the file.
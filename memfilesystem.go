@@ -1,14 +1,17 @@
 package fs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	iofs "io/fs"
-	"net/url"
+	"maps"
 	"path"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -16,7 +19,14 @@ import (
 )
 
 var (
-	_ FileSystem = new(MemFileSystem)
+	_ FileSystem                 = new(MemFileSystem)
+	_ HashFileSystem             = new(MemFileSystem)
+	_ ExistsFileSystem           = new(MemFileSystem)
+	_ ListDirMaxFileSystem       = new(MemFileSystem)
+	_ ListDirRecursiveFileSystem = new(MemFileSystem)
+	_ XAttrFileSystem            = new(MemFileSystem)
+	_ HardLinkFileSystem         = new(MemFileSystem)
+	_ SymlinkFileSystem          = new(MemFileSystem)
 
 	// memFileNode implements io/fs.FileInfo
 	_ iofs.FileInfo = new(memFileInfo)
@@ -24,12 +34,28 @@ var (
 
 var memFileSystemDefaultPermissions = UserAndGroupReadWrite
 
+// memFileSystemGen is the last generation used for MemFileSystem nodes
+var memFileSystemGen atomic.Uint64
+
 // memFileNode implements io/fs.FileInfo
 type memFileNode struct {
 	MemFile
 	Modified    time.Time
 	Permissions Permissions
 	Dir         map[string]*memFileNode
+	XAttrs      map[string][]byte
+	// SymlinkTarget is the target path
+	// if the node is a symbolic link
+	SymlinkTarget string
+
+	// linked is true if the node is referenced
+	// by multiple directory entries as hard link
+	linked bool
+
+	// gen is the generation of the MemFileSystem that owns the node.
+	// Nodes of other generations are shared with clones
+	// and must be copied before modification.
+	gen uint64
 }
 
 func (n *memFileNode) IsDir() bool {
@@ -37,6 +63,9 @@ func (n *memFileNode) IsDir() bool {
 }
 
 func (n *memFileNode) Mode() iofs.FileMode {
+	if n.SymlinkTarget != "" {
+		return n.Permissions.FileMode(false) | iofs.ModeSymlink
+	}
 	return n.Permissions.FileMode(n.Dir != nil)
 }
 
@@ -46,11 +75,71 @@ func (n *memFileNode) ModTime() time.Time {
 
 func (n *memFileNode) Sys() any { return nil }
 
+// infoWithName returns the node as io/fs.FileInfo
+// with name as result of the Name method,
+// which differs for hard links to the node.
+func (n *memFileNode) infoWithName(name string) iofs.FileInfo {
+	if !n.linked || n.FileName == name {
+		return n
+	}
+	return memLinkInfo{n, name}
+}
+
+// containsNode returns true if node is
+// within the directory tree of n
+func (n *memFileNode) containsNode(node *memFileNode) bool {
+	for _, child := range n.Dir {
+		if child == node || child.containsNode(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// memLinkInfo is the io/fs.FileInfo of a
+// hard link with a different name than the node
+type memLinkInfo struct {
+	*memFileNode
+	name string
+}
+
+func (i memLinkInfo) Name() string { return i.name }
+
+// sortedDirNames returns the names of the directory entries
+// sorted by name or nil if the node is not a directory
+func (n *memFileNode) sortedDirNames() []string {
+	if n.Dir == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(n.Dir))
+}
+
+// copyForGen returns a shallow copy of the node for gen.
+// The directory map is copied but not the nodes in it,
+// and FileData is clipped so that appending does not
+// write into the shared array.
+func (n *memFileNode) copyForGen(gen uint64) *memFileNode {
+	c := *n
+	c.gen = gen
+	c.FileData = slices.Clip(n.FileData)
+	if n.Dir != nil {
+		c.Dir = maps.Clone(n.Dir)
+	}
+	if n.XAttrs != nil {
+		c.XAttrs = maps.Clone(n.XAttrs)
+	}
+	return &c
+}
+
 // MemFileSystem is a fully featured thread-safe
 // file system living in random access memory.
 //
 // Usefull as mock file system for tests
 // or caching of slow file systems.
+//
+// Directories are listed sorted by name
+// so that iterating over them is deterministic
+// and code using a MemFileSystem can be tested reliably.
 type MemFileSystem struct {
 	id       string
 	sep      string
@@ -58,7 +147,10 @@ type MemFileSystem struct {
 	prefix   string
 	readOnly bool
 	root     memFileNode
-	mtx      sync.RWMutex
+	gen      uint64
+	// maxLoadDirSize limits the bytes loaded by LoadDir if > 0
+	maxLoadDirSize int64
+	mtx            sync.RWMutex
 }
 
 func NewMemFileSystem(separator string, initialFiles ...MemFile) (*MemFileSystem, error) {
@@ -74,13 +166,16 @@ func NewMemFileSystem(separator string, initialFiles ...MemFile) (*MemFileSystem
 
 	// Create MemFileSystem
 	now := time.Now()
+	gen := memFileSystemGen.Add(1)
 	memFS := &MemFileSystem{
 		sep: separator,
 		root: memFileNode{
 			MemFile:  MemFile{FileName: separator},
 			Modified: now,
 			Dir:      make(map[string]*memFileNode, len(initialFiles)),
+			gen:      gen,
 		},
+		gen: gen,
 	}
 	memFS.id = fmt.Sprintf("%x", unsafe.Pointer(memFS))
 	memFS.updatePrefix()
@@ -94,7 +189,10 @@ func NewMemFileSystem(separator string, initialFiles ...MemFile) (*MemFileSystem
 	}
 
 	// Register with global file system registry
-	Register(memFS)
+	_, err := TryRegister(memFS)
+	if err != nil {
+		return nil, err
+	}
 	return memFS, nil
 }
 
@@ -110,6 +208,38 @@ func NewSingleMemFileSystem(file MemFile) (*MemFileSystem, File, error) {
 	return fs, fs.JoinCleanFile("/", file.FileName), nil
 }
 
+// Clone returns a registered copy of the file system
+// that can be modified independently of the original.
+//
+// Directories and file data are shared copy-on-write
+// between the original and the clone, so cloning is cheap
+// and only modified paths are copied.
+// This makes it possible to fork a large fixture tree per test.
+//
+// Cloning a closed file system returns a closed file system.
+func (fs *MemFileSystem) Clone() *MemFileSystem {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	clone := &MemFileSystem{
+		sep:      fs.sep,
+		volume:   fs.volume,
+		readOnly: fs.readOnly,
+		root:     *fs.root.copyForGen(memFileSystemGen.Add(1)),
+	}
+	clone.gen = clone.root.gen
+	clone.id = fmt.Sprintf("%x", unsafe.Pointer(clone))
+	clone.updatePrefix()
+	// All existing nodes are shared now,
+	// so the original needs a new generation too
+	fs.gen = memFileSystemGen.Add(1)
+
+	if clone.root.Dir != nil {
+		Register(clone)
+	}
+	return clone
+}
+
 func newMemDirNode(name string, modified time.Time, perm ...Permissions) *memFileNode {
 	if name == "" {
 		panic("empty dir name")
@@ -180,20 +310,34 @@ func (fs *MemFileSystem) AddMemFile(f MemFile, modified time.Time) (File, error)
 	}
 
 	pathParts := fs.SplitPath(f.FileName)
-	parentDir := &fs.root
-	// Make all dirs first
-	for i := 0; i < len(pathParts)-2; i++ {
-		// TODO makeAllDirs
-		err := fs.makeDir(fs.JoinCleanPath(pathParts[0:i+1]...), nil)
-		if err != nil {
-			return "", err
-		}
-		panic(" todo set parentDir ")
+	if len(pathParts) == 0 {
+		return "", NewErrIsDirectory(fs.RootDir())
+	}
+	f.FileName = pathParts[len(pathParts)-1]
+	err := fs.addFileNode(pathParts[:len(pathParts)-1], newMemFileNode(f, modified))
+	if err != nil {
+		return "", err
 	}
-	parentDir.Dir[pathParts[len(pathParts)-1]] = newMemFileNode(f, modified)
 	return fs.JoinCleanFile(pathParts...), nil
 }
 
+// addFileNode adds a file node to the directory dirParts
+// after making all directories of dirParts.
+// An existing file with the same name is replaced.
+func (fs *MemFileSystem) addFileNode(dirParts []string, node *memFileNode) error {
+	dirPath := fs.JoinCleanPath(append([]string{fs.sep}, dirParts...)...)
+	err := fs.makeAllDirs(dirPath, nil)
+	if err != nil {
+		return err
+	}
+	dir, _ := fs.mutablePathNodeOrNil(dirPath)
+	if existing, ok := dir.Dir[node.FileName]; ok && existing.IsDir() {
+		return NewErrIsDirectory(fs.RootDir().Join(dirPath, node.FileName))
+	}
+	fs.addNode(dir, node.FileName, node)
+	return nil
+}
+
 func (fs *MemFileSystem) pathNodeOrNil(filePath string) (node, parent *memFileNode) {
 	if filePath == "" {
 		return nil, nil
@@ -210,6 +354,70 @@ func (fs *MemFileSystem) pathNodeOrNil(filePath string) (node, parent *memFileNo
 	return node, parent
 }
 
+// mutablePathNodeOrNil is like pathNodeOrNil
+// but copies all nodes on the path that are shared
+// with clones of the file system so they can be modified.
+// If only the last path element does not exist,
+// then its parent directory node is returned
+// so that it can be created.
+func (fs *MemFileSystem) mutablePathNodeOrNil(filePath string) (node, parent *memFileNode) {
+	if filePath == "" {
+		return nil, nil
+	}
+	if fs.root.gen != fs.gen {
+		fs.root = *fs.root.copyForGen(fs.gen)
+	}
+	node = &fs.root
+	names := fs.SplitPath(filePath)
+	for i, name := range names {
+		subNode, ok := node.Dir[name]
+		if !ok {
+			if i == len(names)-1 {
+				// Only the last path element does not exist
+				return nil, node
+			}
+			return nil, nil
+		}
+		if subNode.gen != fs.gen {
+			shared := subNode
+			subNode = subNode.copyForGen(fs.gen)
+			node.Dir[name] = subNode
+			if subNode.linked {
+				fs.relinkNode(&fs.root, shared, subNode)
+			}
+		}
+		parent = node
+		node = subNode
+	}
+	return node, parent
+}
+
+// relinkNode replaces all hard links to the shared node
+// within dir with its copy so that the links are kept
+// when the node is copied for modification.
+// dir has to be owned by the file system.
+func (fs *MemFileSystem) relinkNode(dir, shared, copied *memFileNode) {
+	for name, child := range dir.Dir {
+		switch {
+		case child == shared:
+			dir.Dir[name] = copied
+		case child.IsDir() && child.containsNode(shared):
+			if child.gen != fs.gen {
+				child = child.copyForGen(fs.gen)
+				dir.Dir[name] = child
+			}
+			fs.relinkNode(child, shared, copied)
+		}
+	}
+}
+
+// addNode adds a new node owned by the file system to parent
+// that has to be returned by mutablePathNodeOrNil.
+func (fs *MemFileSystem) addNode(parent *memFileNode, name string, node *memFileNode) {
+	node.gen = fs.gen
+	parent.Dir[name] = node
+}
+
 func (fs *MemFileSystem) MakeDir(dirPath string, perm []Permissions) error {
 	fs.mtx.Lock()
 	defer fs.mtx.Unlock()
@@ -225,7 +433,7 @@ func (fs *MemFileSystem) makeDir(dirPath string, _ []Permissions) error {
 		return ErrReadOnlyFileSystem
 	}
 
-	node, parent := fs.pathNodeOrNil(dirPath)
+	node, parent := fs.mutablePathNodeOrNil(dirPath)
 	if node != nil {
 		return NewErrAlreadyExists(fs.RootDir().Join(dirPath))
 	}
@@ -236,7 +444,7 @@ func (fs *MemFileSystem) makeDir(dirPath string, _ []Permissions) error {
 	if !parent.IsDir() {
 		return NewErrIsNotDirectory(fs.RootDir().Join(parentDir))
 	}
-	parent.Dir[name] = newMemDirNode(name, time.Now())
+	fs.addNode(parent, name, newMemDirNode(name, time.Now()))
 	return nil
 }
 
@@ -247,7 +455,7 @@ func (fs *MemFileSystem) MakeAllDirs(dirPath string, perm []Permissions) error {
 	return fs.makeAllDirs(dirPath, perm)
 }
 
-func (fs *MemFileSystem) makeAllDirs(dirPath string, _ []Permissions) error {
+func (fs *MemFileSystem) makeAllDirs(dirPath string, perm []Permissions) error {
 	if dirPath == "" {
 		return ErrEmptyPath
 	}
@@ -255,19 +463,26 @@ func (fs *MemFileSystem) makeAllDirs(dirPath string, _ []Permissions) error {
 		return ErrReadOnlyFileSystem
 	}
 
-	// pathParts := fs.SplitPath(f.FileName)
-	// parentDir := &fs.root
-	// // Make all dirs first
-	// for i := 0; i < len(pathParts)-2; i++ {
-	// 	err := fs.MakeDir(fs.JoinCleanPath(pathParts[0:i+1]...), nil)
-	// 	if err != nil {
-	// 		return "", err
-	// 	}
-	// 	panic(" todo set parentDir ")
-	// }
-	// parentDir.Dir[pathParts[len(pathParts)-1]] = fs.newMemFileInfo(f, modified)
-
-	panic("TODO")
+	node, _ := fs.mutablePathNodeOrNil(fs.sep)
+	if node == nil || node.Dir == nil {
+		return ErrFileSystemClosed
+	}
+	names := fs.SplitPath(dirPath)
+	for i, name := range names {
+		subNode, ok := node.Dir[name]
+		switch {
+		case !ok:
+			subNode = newMemDirNode(name, time.Now(), perm...)
+			fs.addNode(node, name, subNode)
+		case !subNode.IsDir():
+			return NewErrIsNotDirectory(fs.JoinCleanFile(names[:i+1]...))
+		case subNode.gen != fs.gen:
+			subNode = subNode.copyForGen(fs.gen)
+			node.Dir[name] = subNode
+		}
+		node = subNode
+	}
+	return nil
 }
 
 func (fs *MemFileSystem) ReadableWritable() (readable, writable bool) {
@@ -336,10 +551,6 @@ func (fs *MemFileSystem) JoinCleanPath(uriParts ...string) string {
 		uriParts[0] = strings.TrimPrefix(uriParts[0], fs.prefix)
 	}
 	cleanPath := strings.Join(uriParts, fs.sep)
-	unescPath, err := url.PathUnescape(cleanPath)
-	if err == nil {
-		cleanPath = unescPath
-	}
 	cleanPath = path.Clean(cleanPath) // TODO use sep
 	return cleanPath
 }
@@ -353,7 +564,7 @@ func (fs *MemFileSystem) Separator() string {
 }
 
 func (*MemFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
-	return false, nil
+	return fsimpl.MatchAnyPattern(name, patterns)
 }
 
 func (fs *MemFileSystem) SplitDirAndName(filePath string) (dir, name string) {
@@ -382,7 +593,8 @@ func (fs *MemFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
 	if node == nil {
 		return nil, NewErrDoesNotExist(fs.RootDir().Join(filePath))
 	}
-	return node, nil
+	_, name := fs.SplitDirAndName(filePath)
+	return node.infoWithName(name), nil
 }
 
 func (fs *MemFileSystem) Exists(filePath string) bool {
@@ -400,12 +612,315 @@ func (*MemFileSystem) IsHidden(filePath string) bool {
 	return false
 }
 
-func (*MemFileSystem) IsSymbolicLink(filePath string) bool {
-	return false
+func (fs *MemFileSystem) IsSymbolicLink(filePath string) bool {
+	if filePath == "" {
+		return false
+	}
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+
+	node, _ := fs.pathNodeOrNil(filePath)
+	return node != nil && node.SymlinkTarget != ""
+}
+
+// ListDirInfo calls callback for the files and directories
+// in dirPath sorted by name.
+// The callback is called with a snapshot of the directory
+// so it can modify the file system.
+func (fs *MemFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if dirPath == "" {
+		return ErrEmptyPath
+	}
+
+	fs.mtx.RLock()
+	dir, _ := fs.pathNodeOrNil(dirPath)
+	if dir == nil {
+		fs.mtx.RUnlock()
+		return NewErrDoesNotExist(fs.RootDir().Join(dirPath))
+	}
+	if !dir.IsDir() {
+		fs.mtx.RUnlock()
+		return NewErrIsNotDirectory(fs.RootDir().Join(dirPath))
+	}
+	var infos []*FileInfo
+	for _, name := range dir.sortedDirNames() {
+		match, err := fs.MatchAnyPattern(name, patterns)
+		if err != nil {
+			fs.mtx.RUnlock()
+			return err
+		}
+		if match {
+			file := fs.JoinCleanFile(dirPath, name)
+			infos = append(infos, NewFileInfo(file, dir.Dir[name].infoWithName(name), fs.IsHidden(name)))
+		}
+	}
+	fs.mtx.RUnlock()
+
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMaxLoadDirSize sets the maximum number of bytes
+// that LoadDir loads into memory per call.
+// Zero or a negative value means no limit, which is the default.
+func (fs *MemFileSystem) SetMaxLoadDirSize(maxBytes int64) {
+	fs.mtx.Lock()
+	fs.maxLoadDirSize = maxBytes
+	fs.mtx.Unlock()
+}
+
+// LoadDir recursively loads all files and directories of dir
+// from any file system into the root directory of the MemFileSystem,
+// keeping their modified time and permissions.
+// Existing files are replaced.
+//
+// If any patterns are passed, then only files with a name that matches
+// at least one of the patterns are loaded, but all directories are created.
+//
+// If the total size of the loaded files would exceed
+// the limit set with SetMaxLoadDirSize, then an error
+// wrapping ErrTooLarge is returned and the files
+// loaded up to then remain in the MemFileSystem.
+func (fs *MemFileSystem) LoadDir(ctx context.Context, dir File, patterns ...string) error {
+	fs.mtx.RLock()
+	maxSize := fs.maxLoadDirSize
+	fs.mtx.RUnlock()
+
+	var totalSize int64
+	var loadDir func(dir File, dirParts []string) error
+	loadDir = func(dir File, dirParts []string) error {
+		return dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+			parts := append(slices.Clip(dirParts), info.Name)
+			if info.IsDir {
+				err := fs.MakeAllDirs(fs.JoinCleanPath(append([]string{fs.sep}, parts...)...), []Permissions{info.Permissions})
+				if err != nil {
+					return err
+				}
+				return loadDir(info.File, parts)
+			}
+			match, err := fs.MatchAnyPattern(info.Name, patterns)
+			if !match || err != nil {
+				return err
+			}
+			totalSize += info.Size
+			if maxSize > 0 && totalSize > maxSize {
+				return fmt.Errorf("%w: loading more than %d bytes from %s", ErrTooLarge, maxSize, dir)
+			}
+			// Read before locking because dir could be
+			// in this or a slow file system
+			data, err := info.File.ReadAllContext(ctx)
+			if err != nil {
+				return err
+			}
+			// The file could have changed since listing
+			totalSize += int64(len(data)) - info.Size
+			if maxSize > 0 && totalSize > maxSize {
+				return fmt.Errorf("%w: loading more than %d bytes from %s", ErrTooLarge, maxSize, dir)
+			}
+
+			fs.mtx.Lock()
+			defer fs.mtx.Unlock()
+
+			if fs.readOnly {
+				return ErrReadOnlyFileSystem
+			}
+			node := newMemFileNode(MemFile{FileName: info.Name, FileData: data}, info.Modified, info.Permissions)
+			return fs.addFileNode(dirParts, node)
+		})
+	}
+	return loadDir(dir, nil)
+}
+
+// snapshot returns the root node as immutable snapshot
+// by sharing all nodes copy-on-write like Clone.
+func (fs *MemFileSystem) snapshot() memFileNode {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	// All existing nodes are shared with the snapshot now
+	fs.gen = memFileSystemGen.Add(1)
+	return fs.root
+}
+
+// Dump recursively writes all files and directories
+// of the MemFileSystem to destDir in any file system,
+// which is created if it does not exist.
+// Existing files are overwritten.
+//
+// Permissions are set if the file system of destDir
+// implements PermissionsFileSystem.
+//
+// The MemFileSystem can be modified while dumping,
+// the written files are a snapshot from the start of the call.
+func (fs *MemFileSystem) Dump(ctx context.Context, destDir File) error {
+	if destDir == "" {
+		return ErrEmptyPath
+	}
+	root := fs.snapshot()
+	if root.Dir == nil {
+		return ErrFileSystemClosed
+	}
+	permFS, _ := destDir.FileSystem().(PermissionsFileSystem)
+	setPermissions := func(file File, perm Permissions) error {
+		if permFS == nil {
+			return nil
+		}
+		return permFS.SetPermissions(file.Path(), perm)
+	}
+
+	err := destDir.MakeAllDirs()
+	if err != nil {
+		return err
+	}
+	var dump func(dir *memFileNode, destDir File) error
+	dump = func(dir *memFileNode, destDir File) error {
+		for _, name := range dir.sortedDirNames() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			node := dir.Dir[name]
+			destFile := destDir.Join(name)
+			if node.IsDir() {
+				err := destFile.MakeDir(node.Permissions)
+				if err != nil {
+					return err
+				}
+				err = setPermissions(destFile, node.Permissions)
+				if err != nil {
+					return err
+				}
+				err = dump(node, destFile)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err := destFile.WriteAllContext(ctx, node.FileData, node.Permissions)
+			if err != nil {
+				return err
+			}
+			err = setPermissions(destFile, node.Permissions)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return dump(&root, destDir)
+}
+
+// ListDirInfoRecursive calls callback for all files (not directories)
+// in dirPath and its sub-directories, depth first sorted by name.
+// The callback is called with a snapshot of the directory tree
+// so it can modify the file system.
+func (fs *MemFileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if dirPath == "" {
+		return ErrEmptyPath
+	}
+
+	fs.mtx.RLock()
+	dir, _ := fs.pathNodeOrNil(dirPath)
+	if dir == nil {
+		fs.mtx.RUnlock()
+		return NewErrDoesNotExist(fs.RootDir().Join(dirPath))
+	}
+	if !dir.IsDir() {
+		fs.mtx.RUnlock()
+		return NewErrIsNotDirectory(fs.RootDir().Join(dirPath))
+	}
+	var infos []*FileInfo
+	var collect func(dirPath string, dir *memFileNode) error
+	collect = func(dirPath string, dir *memFileNode) error {
+		for _, name := range dir.sortedDirNames() {
+			node := dir.Dir[name]
+			if node.IsDir() {
+				err := collect(fs.JoinCleanPath(dirPath, name), node)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			match, err := fs.MatchAnyPattern(name, patterns)
+			if err != nil {
+				return err
+			}
+			if match {
+				file := fs.JoinCleanFile(dirPath, name)
+				infos = append(infos, NewFileInfo(file, node.infoWithName(name), fs.IsHidden(name)))
+			}
+		}
+		return nil
+	}
+	err := collect(dirPath, dir)
+	fs.mtx.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (*MemFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
-	panic("TODO")
+// ListDirMax returns at most max files and directories
+// in dirPath sorted by name.
+// A max value of -1 returns all files.
+func (fs *MemFileSystem) ListDirMax(ctx context.Context, dirPath string, max int, patterns []string) ([]File, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if dirPath == "" {
+		return nil, ErrEmptyPath
+	}
+	if max == 0 {
+		return nil, nil
+	}
+
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+
+	dir, _ := fs.pathNodeOrNil(dirPath)
+	if dir == nil {
+		return nil, NewErrDoesNotExist(fs.RootDir().Join(dirPath))
+	}
+	if !dir.IsDir() {
+		return nil, NewErrIsNotDirectory(fs.RootDir().Join(dirPath))
+	}
+	var files []File
+	for _, name := range dir.sortedDirNames() {
+		if max > 0 && len(files) >= max {
+			break
+		}
+		match, err := fs.MatchAnyPattern(name, patterns)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			files = append(files, fs.JoinCleanFile(dirPath, name))
+		}
+	}
+	return files, nil
 }
 
 // func (*MemFileSystem) SetPermissions(filePath string, perm Permissions) error {
@@ -439,7 +954,7 @@ func (fs *MemFileSystem) Touch(filePath string, perm []Permissions) error {
 		return ErrReadOnlyFileSystem
 	}
 
-	node, parent := fs.pathNodeOrNil(filePath)
+	node, parent := fs.mutablePathNodeOrNil(filePath)
 	if node != nil {
 		node.Modified = time.Now()
 		return nil
@@ -449,14 +964,16 @@ func (fs *MemFileSystem) Touch(filePath string, perm []Permissions) error {
 	if parent == nil {
 		return NewErrDoesNotExist(fs.RootDir().Join(parentDir))
 	}
-	parent.Dir[name] = newMemFileNode(
+	fs.addNode(parent, name, newMemFileNode(
 		MemFile{FileName: name},
 		time.Now(),
 		JoinPermissions(perm, memFileSystemDefaultPermissions),
-	)
+	))
 	return nil
 }
 
+// ReadAll returns a copy of the file data,
+// use FileData to access it without copying.
 func (fs *MemFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
@@ -471,7 +988,34 @@ func (fs *MemFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte,
 	if node == nil {
 		return nil, NewErrDoesNotExist(fs.RootDir().Join(filePath))
 	}
-	return node.FileData, nil
+	if node.IsDir() {
+		return nil, NewErrIsDirectory(fs.RootDir().Join(filePath))
+	}
+	return slices.Clone(node.FileData), nil
+}
+
+// FileData returns the data of the file at filePath without copying it
+// and true, or nil and false if the file does not exist or is a directory.
+//
+// The returned slice aliases the memory of the file system
+// and must not be modified.
+// The file system never modifies file data in place,
+// so later writes to the file don't change the returned bytes.
+// Use ReadAll to get a copy that can be modified.
+func (fs *MemFileSystem) FileData(filePath string) ([]byte, bool) {
+	if filePath == "" {
+		return nil, false
+	}
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+
+	node, _ := fs.pathNodeOrNil(filePath)
+	if node == nil || node.IsDir() {
+		return nil, false
+	}
+	// Clip so that appending to the result can't
+	// write into the capacity used by Append
+	return slices.Clip(node.FileData), true
 }
 
 func (fs *MemFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
@@ -488,7 +1032,12 @@ func (fs *MemFileSystem) WriteAll(ctx context.Context, filePath string, data []b
 		return ErrReadOnlyFileSystem
 	}
 
-	node, parent := fs.pathNodeOrNil(filePath)
+	// Copy because the caller could modify data later
+	data = slices.Clone(data)
+	node, parent := fs.mutablePathNodeOrNil(filePath)
+	if node != nil && node.IsDir() {
+		return NewErrIsDirectory(fs.RootDir().Join(filePath))
+	}
 	if node != nil {
 		node.FileData = data
 		node.Modified = time.Now()
@@ -499,11 +1048,11 @@ func (fs *MemFileSystem) WriteAll(ctx context.Context, filePath string, data []b
 	if parent == nil {
 		return NewErrDoesNotExist(fs.RootDir().Join(parentDir))
 	}
-	parent.Dir[name] = newMemFileNode(
+	fs.addNode(parent, name, newMemFileNode(
 		MemFile{FileName: name, FileData: data},
 		time.Now(),
 		JoinPermissions(perm, memFileSystemDefaultPermissions),
-	)
+	))
 	return nil
 }
 
@@ -521,7 +1070,10 @@ func (fs *MemFileSystem) Append(ctx context.Context, filePath string, data []byt
 		return ErrReadOnlyFileSystem
 	}
 
-	node, parent := fs.pathNodeOrNil(filePath)
+	node, parent := fs.mutablePathNodeOrNil(filePath)
+	if node != nil && node.IsDir() {
+		return NewErrIsDirectory(fs.RootDir().Join(filePath))
+	}
 	if node != nil {
 		node.FileData = append(node.FileData, data...)
 		node.Modified = time.Now()
@@ -532,14 +1084,33 @@ func (fs *MemFileSystem) Append(ctx context.Context, filePath string, data []byt
 	if parent == nil {
 		return NewErrDoesNotExist(fs.RootDir().Join(parentDir))
 	}
-	parent.Dir[name] = newMemFileNode(
-		MemFile{FileName: name, FileData: data},
+	fs.addNode(parent, name, newMemFileNode(
+		MemFile{FileName: name, FileData: slices.Clone(data)},
 		time.Now(),
 		JoinPermissions(perm, memFileSystemDefaultPermissions),
-	)
+	))
 	return nil
 }
 
+// Hash returns the hash of a file
+// calculated from the data in memory.
+func (fs *MemFileSystem) Hash(ctx context.Context, filePath string, algo HashAlgorithm) (string, error) {
+	if filePath == "" {
+		return "", ErrEmptyPath
+	}
+	fs.mtx.RLock()
+	node, _ := fs.pathNodeOrNil(filePath)
+	fs.mtx.RUnlock()
+
+	if node == nil {
+		return "", NewErrDoesNotExist(fs.RootDir().Join(filePath))
+	}
+	if node.IsDir() {
+		return "", NewErrIsDirectory(fs.RootDir().Join(filePath))
+	}
+	return hashReader(ctx, bytes.NewReader(node.FileData), algo)
+}
+
 func (fs *MemFileSystem) OpenReader(filePath string) (iofs.File, error) {
 	return nil, nil
 }
@@ -571,16 +1142,21 @@ func (fs *MemFileSystem) Truncate(filePath string, newSize int64) error {
 		return ErrReadOnlyFileSystem
 	}
 
-	node, _ := fs.pathNodeOrNil(filePath)
+	node, _ := fs.mutablePathNodeOrNil(filePath)
 	if node == nil {
 		return NewErrDoesNotExist(fs.RootDir().Join(filePath))
 	}
+	if node.IsDir() {
+		return NewErrIsDirectory(fs.RootDir().Join(filePath))
+	}
 	currentSize := int64(len(node.FileData))
 	if currentSize == newSize {
 		return nil
 	}
 	if currentSize > newSize {
-		node.FileData = node.FileData[:newSize]
+		// Clip capacity so that appending does not overwrite
+		// the truncated data that could be shared with clones
+		node.FileData = node.FileData[:newSize:newSize]
 	} else {
 		node.FileData = append(node.FileData, make([]byte, newSize-currentSize)...)
 	}
@@ -588,6 +1164,159 @@ func (fs *MemFileSystem) Truncate(filePath string, newSize int64) error {
 	return nil
 }
 
+func (fs *MemFileSystem) GetXAttr(filePath, name string) ([]byte, error) {
+	if filePath == "" {
+		return nil, ErrEmptyPath
+	}
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+
+	node, _ := fs.pathNodeOrNil(filePath)
+	if node == nil {
+		return nil, NewErrDoesNotExist(fs.RootDir().Join(filePath))
+	}
+	value, ok := node.XAttrs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrXAttrDoesNotExist, name)
+	}
+	return slices.Clone(value), nil
+}
+
+func (fs *MemFileSystem) SetXAttr(filePath, name string, value []byte) error {
+	if filePath == "" {
+		return ErrEmptyPath
+	}
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.readOnly {
+		return ErrReadOnlyFileSystem
+	}
+	node, _ := fs.mutablePathNodeOrNil(filePath)
+	if node == nil {
+		return NewErrDoesNotExist(fs.RootDir().Join(filePath))
+	}
+	if node.XAttrs == nil {
+		node.XAttrs = make(map[string][]byte)
+	}
+	node.XAttrs[name] = slices.Clone(value)
+	return nil
+}
+
+func (fs *MemFileSystem) ListXAttrs(filePath string) ([]string, error) {
+	if filePath == "" {
+		return nil, ErrEmptyPath
+	}
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+
+	node, _ := fs.pathNodeOrNil(filePath)
+	if node == nil {
+		return nil, NewErrDoesNotExist(fs.RootDir().Join(filePath))
+	}
+	return slices.Sorted(maps.Keys(node.XAttrs)), nil
+}
+
+func (fs *MemFileSystem) RemoveXAttr(filePath, name string) error {
+	if filePath == "" {
+		return ErrEmptyPath
+	}
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.readOnly {
+		return ErrReadOnlyFileSystem
+	}
+	node, _ := fs.mutablePathNodeOrNil(filePath)
+	if node == nil {
+		return NewErrDoesNotExist(fs.RootDir().Join(filePath))
+	}
+	if _, ok := node.XAttrs[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrXAttrDoesNotExist, name)
+	}
+	delete(node.XAttrs, name)
+	return nil
+}
+
+// CreateSymlink creates linkPath as symbolic link to targetPath.
+// Symbolic links are stored as nodes with the target path
+// but are not followed when resolving paths,
+// so operations on a link always affect the link itself.
+func (fs *MemFileSystem) CreateSymlink(targetPath, linkPath string) error {
+	if targetPath == "" || linkPath == "" {
+		return ErrEmptyPath
+	}
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.readOnly {
+		return ErrReadOnlyFileSystem
+	}
+	existing, parent := fs.mutablePathNodeOrNil(linkPath)
+	if existing != nil {
+		return NewErrAlreadyExists(fs.RootDir().Join(linkPath))
+	}
+	parentDir, name := fs.SplitDirAndName(linkPath)
+	if parent == nil {
+		return NewErrDoesNotExist(fs.RootDir().Join(parentDir))
+	}
+	node := newMemFileNode(MemFile{FileName: name}, time.Now())
+	node.SymlinkTarget = targetPath
+	fs.addNode(parent, name, node)
+	return nil
+}
+
+func (fs *MemFileSystem) ReadSymlink(linkPath string) (targetPath string, err error) {
+	if linkPath == "" {
+		return "", ErrEmptyPath
+	}
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+
+	node, _ := fs.pathNodeOrNil(linkPath)
+	if node == nil {
+		return "", NewErrDoesNotExist(fs.RootDir().Join(linkPath))
+	}
+	if node.SymlinkTarget == "" {
+		return "", fmt.Errorf("not a symbolic link: %s", fs.RootDir().Join(linkPath))
+	}
+	return node.SymlinkTarget, nil
+}
+
+// CreateHardLink creates newPath as directory entry
+// that shares the file node of existingPath,
+// so that modifications are visible through both paths.
+// Hard links are kept in clones of the file system.
+func (fs *MemFileSystem) CreateHardLink(existingPath, newPath string) error {
+	if existingPath == "" || newPath == "" {
+		return ErrEmptyPath
+	}
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.readOnly {
+		return ErrReadOnlyFileSystem
+	}
+	node, _ := fs.mutablePathNodeOrNil(existingPath)
+	if node == nil {
+		return NewErrDoesNotExist(fs.RootDir().Join(existingPath))
+	}
+	if node.IsDir() {
+		return NewErrIsDirectory(fs.RootDir().Join(existingPath))
+	}
+	existing, parent := fs.mutablePathNodeOrNil(newPath)
+	if existing != nil {
+		return NewErrAlreadyExists(fs.RootDir().Join(newPath))
+	}
+	parentDir, name := fs.SplitDirAndName(newPath)
+	if parent == nil {
+		return NewErrDoesNotExist(fs.RootDir().Join(parentDir))
+	}
+	node.linked = true
+	parent.Dir[name] = node
+	return nil
+}
+
 func (fs *MemFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
 	return nil
 }
@@ -620,6 +1349,11 @@ func (fs *MemFileSystem) Clear() {
 	fs.mtx.Lock()
 	defer fs.mtx.Unlock()
 
-	clear(fs.root.Dir)
+	if fs.root.Dir == nil {
+		return // closed
+	}
+	// Don't clear the map because it could be shared with clones
+	fs.root.Dir = make(map[string]*memFileNode)
+	fs.root.gen = fs.gen
 	fs.root.Modified = time.Now()
 }
@@ -1,8 +1,11 @@
 package fs
 
 import (
+	"context"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -62,3 +65,274 @@ func TestNewSingleMemFileSystem(t *testing.T) {
 	require.False(t, fs.RootDir().Exists(), "root dir does not exist after close")
 	require.False(t, fs.RootDir().IsDir(), "root dir does not exist after close")
 }
+
+func TestMemFileSystem_SpecialCharacterNames(t *testing.T) {
+	fs, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	for _, name := range []string{"with space.txt", "hash#tag.txt", "100%.txt", "literal%20escape.txt"} {
+		file := fs.RootDir().Join(name)
+		require.Equal(t, name, file.Name(), "name is not decoded")
+		require.Equal(t, "/"+name, fs.JoinCleanPath("/", name))
+
+		err = file.WriteAllString(name)
+		require.NoError(t, err)
+		content, err := file.ReadAllString()
+		require.NoError(t, err)
+		require.Equal(t, name, content)
+	}
+	require.False(t, fs.RootDir().Join("with%20space.txt").Exists(), "escaped name is a different file")
+}
+
+func TestMemFileSystem_ListDirInfo_Sorted(t *testing.T) {
+	fs, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	names := []string{"m.txt", "b", "z.txt", "a.txt", "c", "y.txt", "10.txt", "2.txt"}
+	for _, name := range names {
+		if strings.HasSuffix(name, ".txt") {
+			require.NoError(t, fs.RootDir().Join(name).WriteAllString(name))
+		} else {
+			require.NoError(t, fs.RootDir().Join(name).MakeDir())
+		}
+	}
+	want := []string{"10.txt", "2.txt", "a.txt", "b", "c", "m.txt", "y.txt", "z.txt"}
+	for range 10 {
+		var listed []string
+		err = fs.RootDir().ListDirInfo(func(info *FileInfo) error {
+			listed = append(listed, info.Name)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, want, listed, "sorted by name")
+	}
+
+	var listed []string
+	err = fs.RootDir().ListDir(func(file File) error {
+		listed = append(listed, file.Name())
+		return nil
+	}, "*.txt")
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.txt", "2.txt", "a.txt", "m.txt", "y.txt", "z.txt"}, listed)
+
+	err = fs.RootDir().Join("a.txt").ListDir(func(File) error { return nil })
+	require.Error(t, err)
+	err = fs.RootDir().Join("missing").ListDir(func(File) error { return nil })
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMemFileSystem_ListDirRecursiveAndMax(t *testing.T) {
+	fs, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+	root := fs.RootDir()
+	require.NoError(t, root.Join("b", "c").MakeAllDirs())
+	for _, name := range []string{"b/z.txt", "b/a.md", "b/c/x.txt", "a.txt", "c.md"} {
+		require.NoError(t, root.Join(name).WriteAllString(name))
+	}
+
+	files, err := root.ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/a.txt", "/b/a.md", "/b/c/x.txt", "/b/z.txt", "/c.md"}, filePaths(files), "depth first sorted by name")
+
+	files, err = root.ListDirRecursiveMax(-1, "*.txt")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/a.txt", "/b/c/x.txt", "/b/z.txt"}, filePaths(files), "patterns only match files")
+
+	files, err = root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/a.txt", "/b", "/c.md"}, filePaths(files))
+	files, err = root.ListDirMax(2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/a.txt", "/b"}, filePaths(files))
+	files, err = root.ListDirMax(-1, "*.md")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/c.md"}, filePaths(files))
+	files, err = root.ListDirMax(0)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	_, err = root.Join("a.txt").ListDirMax(-1)
+	require.ErrorAs(t, err, new(ErrIsNotDirectory))
+	_, err = root.Join("missing").ListDirRecursiveMax(-1)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.True(t, root.Join("b", "c", "x.txt").Exists())
+	require.False(t, root.Join("b", "missing").Exists())
+}
+
+func filePaths(files []File) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path()
+	}
+	return paths
+}
+
+func TestMemFileSystem_Clone(t *testing.T) {
+	fs, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+	require.NoError(t, fs.RootDir().Join("dir").MakeDir())
+	require.NoError(t, fs.RootDir().Join("dir", "file.txt").WriteAllString("original"))
+	require.NoError(t, fs.RootDir().Join("dir", "log.txt").WriteAllString("0123456789"))
+	require.NoError(t, fs.Truncate("/dir/log.txt", 4))
+
+	clone := fs.Clone()
+	t.Cleanup(func() { _ = clone.Close() })
+	require.NotEqual(t, fs.Prefix(), clone.Prefix())
+	require.True(t, IsRegistered(clone))
+	require.Equal(t, "original", readString(t, clone.RootDir().Join("dir", "file.txt")))
+
+	// Modify the clone
+	require.NoError(t, clone.RootDir().Join("dir", "file.txt").WriteAllString("changed"))
+	require.NoError(t, clone.RootDir().Join("dir", "new.txt").WriteAllString("new"))
+	require.NoError(t, clone.RootDir().Join("dir", "log.txt").AppendString(context.Background(), "clone"))
+	require.NoError(t, clone.RootDir().Join("clone-dir").MakeDir())
+
+	// Modify the original
+	require.NoError(t, fs.RootDir().Join("dir", "log.txt").AppendString(context.Background(), "orig"))
+	require.NoError(t, fs.RootDir().Join("orig.txt").Touch())
+
+	require.Equal(t, "original", readString(t, fs.RootDir().Join("dir", "file.txt")))
+	require.Equal(t, "changed", readString(t, clone.RootDir().Join("dir", "file.txt")))
+	require.Equal(t, "0123orig", readString(t, fs.RootDir().Join("dir", "log.txt")))
+	require.Equal(t, "0123clone", readString(t, clone.RootDir().Join("dir", "log.txt")))
+	require.False(t, fs.RootDir().Join("dir", "new.txt").Exists())
+	require.False(t, fs.RootDir().Join("clone-dir").Exists())
+	require.False(t, clone.RootDir().Join("orig.txt").Exists())
+
+	// A clone of the clone is independent too
+	cloneOfClone := clone.Clone()
+	t.Cleanup(func() { _ = cloneOfClone.Close() })
+	cloneOfClone.Clear()
+	require.False(t, cloneOfClone.RootDir().Join("dir").Exists())
+	require.True(t, clone.RootDir().Join("dir").Exists())
+
+	require.NoError(t, clone.Close())
+	require.True(t, fs.RootDir().Join("dir", "file.txt").Exists())
+}
+
+func readString(t *testing.T, file File) string {
+	t.Helper()
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	return str
+}
+
+func TestMemFileSystem_LoadDir(t *testing.T) {
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("sub", "empty").MakeAllDirs())
+	require.NoError(t, dir.Join("a.txt").WriteAllString("a"))
+	require.NoError(t, dir.Join("b.md").WriteAllString("b"))
+	require.NoError(t, dir.Join("sub", "c.txt").WriteAllString("c"))
+
+	fs, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+	root := fs.RootDir()
+
+	require.NoError(t, fs.LoadDir(context.Background(), dir, "*.txt"))
+	files, err := root.ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/a.txt", "/sub/c.txt"}, filePaths(files))
+	require.True(t, root.Join("sub", "empty").IsDir(), "all directories are created")
+	require.Equal(t, "c", readString(t, root.Join("sub", "c.txt")))
+	require.Equal(t, dir.Join("a.txt").Modified(), root.Join("a.txt").Modified())
+
+	// Existing files are replaced
+	require.NoError(t, dir.Join("a.txt").WriteAllString("changed"))
+	require.NoError(t, fs.LoadDir(context.Background(), dir))
+	require.Equal(t, "changed", readString(t, root.Join("a.txt")))
+	require.Equal(t, "b", readString(t, root.Join("b.md")))
+
+	fs.SetMaxLoadDirSize(5)
+	err = fs.LoadDir(context.Background(), dir)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestMemFileSystem_Dump(t *testing.T) {
+	fs, err := NewMemFileSystem("/",
+		NewMemFile("a.txt", []byte("a")),
+		NewMemFile("dir/sub/b.txt", []byte("b")),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+	require.NoError(t, fs.MakeDir("/empty", nil))
+	require.NoError(t, fs.WriteAll(context.Background(), "/dir/script.sh", []byte("#!/bin/sh"), []Permissions{UserReadWriteExecute}))
+
+	destDir := File(t.TempDir()).Join("dest")
+	require.NoError(t, fs.Dump(context.Background(), destDir))
+	files, err := destDir.ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a.txt", "b.txt", "script.sh"}, FileNames(files))
+	require.True(t, destDir.Join("empty").IsDir())
+	require.Equal(t, "b", readString(t, destDir.Join("dir", "sub", "b.txt")))
+	require.Equal(t, UserReadWriteExecute, destDir.Join("dir", "script.sh").Permissions())
+
+	// Dumping a snapshot while modifying
+	require.NoError(t, fs.WriteAll(context.Background(), "/a.txt", []byte("changed"), nil))
+	require.NoError(t, fs.Dump(context.Background(), destDir))
+	require.Equal(t, "changed", readString(t, destDir.Join("a.txt")))
+
+	require.NoError(t, fs.Close())
+	require.ErrorIs(t, fs.Dump(context.Background(), destDir), ErrFileSystemClosed)
+}
+
+func TestMemFileSystem_AddMemFile(t *testing.T) {
+	fs, err := NewMemFileSystem("/",
+		NewMemFile("file.txt", []byte("file")),
+		NewMemFile("dir/sub/nested.txt", []byte("nested")),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+
+	require.Equal(t, "file", readString(t, fs.RootDir().Join("file.txt")))
+	require.Equal(t, "nested", readString(t, fs.RootDir().Join("dir", "sub", "nested.txt")))
+	require.True(t, fs.RootDir().Join("dir", "sub").IsDir())
+	_, err = fs.AddMemFile(NewMemFile("dir/sub", nil), time.Now())
+	require.ErrorAs(t, err, new(ErrIsDirectory))
+	require.NoError(t, fs.MakeAllDirs("/x/y/z", nil))
+	require.True(t, fs.RootDir().Join("x", "y", "z").IsDir())
+	require.ErrorAs(t, fs.MakeAllDirs("/file.txt/sub", nil), new(ErrIsNotDirectory))
+}
+
+func TestMemFileSystem_FileData(t *testing.T) {
+	fs, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fs.Close() })
+	ctx := context.Background()
+
+	written := []byte("Hello")
+	require.NoError(t, fs.WriteAll(ctx, "/file.txt", written, nil))
+	written[0] = 'X'
+	data, ok := fs.FileData("/file.txt")
+	require.True(t, ok)
+	require.Equal(t, "Hello", string(data), "written data is copied")
+
+	// ReadAll returns a copy
+	read, err := fs.ReadAll(ctx, "/file.txt")
+	require.NoError(t, err)
+	read[0] = 'X'
+	require.Equal(t, "Hello", readString(t, fs.RootDir().Join("file.txt")))
+
+	// Writes don't change returned data
+	require.NoError(t, fs.Append(ctx, "/file.txt", []byte(" World"), nil))
+	require.NoError(t, fs.Truncate("/file.txt", 2))
+	require.NoError(t, fs.WriteAll(ctx, "/file.txt", []byte("Changed"), nil))
+	require.Equal(t, "Hello", string(data))
+	data2, ok := fs.FileData("/file.txt")
+	require.True(t, ok)
+	require.Equal(t, "Changed", string(data2))
+	_ = append(data2, '!')
+	require.NoError(t, fs.Append(ctx, "/file.txt", []byte("?"), nil))
+	require.Equal(t, "Changed", string(data2))
+	require.Equal(t, "Changed?", readString(t, fs.RootDir().Join("file.txt")))
+
+	_, ok = fs.FileData("/missing.txt")
+	require.False(t, ok)
+	require.NoError(t, fs.MakeDir("/dir", nil))
+	_, ok = fs.FileData("/dir")
+	require.False(t, ok)
+}
@@ -0,0 +1,352 @@
+// Package metricsfs implements a file system that measures
+// the operations, transferred bytes, errors, and latencies
+// of another file system.
+package metricsfs
+
+import (
+	"context"
+	"fmt"
+	iofs "io/fs"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of MetricsFileSystem URIs
+	Prefix = "metrics://"
+)
+
+var (
+	// Make sure MetricsFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(MetricsFileSystem)
+	_ fs.ReadAllFileSystem  = new(MetricsFileSystem)
+	_ fs.WriteAllFileSystem = new(MetricsFileSystem)
+	_ fs.AppendFileSystem   = new(MetricsFileSystem)
+	_ fs.TouchFileSystem    = new(MetricsFileSystem)
+	_ fs.TruncateFileSystem = new(MetricsFileSystem)
+	_ fs.CopyFileSystem     = new(MetricsFileSystem)
+	_ fs.RenameFileSystem   = new(MetricsFileSystem)
+	_ fs.MoveFileSystem     = new(MetricsFileSystem)
+	_ fs.ExistsFileSystem   = new(MetricsFileSystem)
+)
+
+// MetricsFileSystem wraps a file system and passes
+// the duration and error of every FileSystem method call
+// and the number of read and written bytes to a Sink.
+//
+// It is registered with the prefix "metrics://" followed by a random ID.
+//
+// Pure path methods like JoinCleanPath or SplitPath are not measured.
+type MetricsFileSystem struct {
+	fs.WrapperBase
+
+	label  string
+	sink   Sink
+	closed atomic.Bool
+}
+
+// New returns a new MetricsFileSystem for inner
+// that passes its measurements to sink and registers it.
+// The label is passed to the sink to distinguish file systems,
+// the name of inner is used if label is empty.
+func New(inner fs.FileSystem, label string, sink Sink) (*MetricsFileSystem, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("nil inner file system")
+	}
+	if sink == nil {
+		return nil, fmt.Errorf("nil sink")
+	}
+	if label == "" {
+		label = inner.Name()
+	}
+	f := &MetricsFileSystem{
+		WrapperBase: fs.NewWrapperBase(Prefix+fsimpl.RandomString(), inner),
+		label:       label,
+		sink:        sink,
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Label returns the label passed to the sink
+func (f *MetricsFileSystem) Label() string {
+	return f.label
+}
+
+func (f *MetricsFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+// observe passes the measurement of a method call started at start
+// to the sink, use it with defer and a pointer to a named error result.
+func (f *MetricsFileSystem) observe(method string, start time.Time, err *error) {
+	f.sink.ObserveOp(f.label, method, time.Since(start), *err)
+}
+
+func (f *MetricsFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.Inner().ReadableWritable()
+}
+
+func (f *MetricsFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.Prefix(), Prefix), nil
+}
+
+func (f *MetricsFileSystem) Name() string {
+	return "measured " + f.Inner().Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *MetricsFileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+func (f *MetricsFileSystem) Stat(filePath string) (info iofs.FileInfo, err error) {
+	defer f.observe("Stat", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.Inner().Stat(filePath)
+}
+
+func (f *MetricsFileSystem) Exists(filePath string) bool {
+	var err error
+	defer f.observe("Exists", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return false
+	}
+	return f.InnerFile(filePath).Exists()
+}
+
+func (f *MetricsFileSystem) IsHidden(filePath string) bool {
+	return f.Inner().IsHidden(filePath)
+}
+
+func (f *MetricsFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.Inner().IsSymbolicLink(filePath)
+}
+
+func (f *MetricsFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) (err error) {
+	defer f.observe("ListDirInfo", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().ListDirInfo(ctx, dirPath, func(innerInfo *fs.FileInfo) error {
+		info := *innerInfo
+		info.File = f.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns)
+}
+
+func (f *MetricsFileSystem) MakeDir(dirPath string, perm []fs.Permissions) (err error) {
+	defer f.observe("MakeDir", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().MakeDir(dirPath, perm)
+}
+
+func (f *MetricsFileSystem) ReadAll(ctx context.Context, filePath string) (data []byte, err error) {
+	defer f.observe("ReadAll", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	data, err = f.InnerFile(filePath).ReadAllContext(ctx)
+	f.sink.ObserveBytes(f.label, int64(len(data)), 0)
+	return data, err
+}
+
+func (f *MetricsFileSystem) OpenReader(filePath string) (reader fs.ReadCloser, err error) {
+	defer f.observe("OpenReader", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	reader, err = f.Inner().OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{ReadCloser: reader, fileSystem: f}, nil
+}
+
+func (f *MetricsFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	defer f.observe("WriteAll", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	err = f.InnerFile(filePath).WriteAllContext(ctx, data, perm...)
+	if err == nil {
+		f.sink.ObserveBytes(f.label, 0, int64(len(data)))
+	}
+	return err
+}
+
+func (f *MetricsFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	defer f.observe("Append", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	err = f.InnerFile(filePath).Append(ctx, data, perm...)
+	if err == nil {
+		f.sink.ObserveBytes(f.label, 0, int64(len(data)))
+	}
+	return err
+}
+
+func (f *MetricsFileSystem) Touch(filePath string, perm []fs.Permissions) (err error) {
+	defer f.observe("Touch", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Touch(perm...)
+}
+
+func (f *MetricsFileSystem) Truncate(filePath string, size int64) (err error) {
+	defer f.observe("Truncate", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Truncate(size)
+}
+
+func (f *MetricsFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (writer fs.WriteCloser, err error) {
+	defer f.observe("OpenWriter", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	writer, err = f.Inner().OpenWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &countingWriter{WriteCloser: writer, fileSystem: f}, nil
+}
+
+func (f *MetricsFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (readWriter fs.ReadWriteSeekCloser, err error) {
+	defer f.observe("OpenReadWriter", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	readWriter, err = f.Inner().OpenReadWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReadWriter{ReadWriteSeekCloser: readWriter, fileSystem: f}, nil
+}
+
+func (f *MetricsFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) (err error) {
+	defer f.observe("CopyFile", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return fs.CopyFileBuf(ctx, f.InnerFile(srcFile), f.InnerFile(destFile), buf)
+}
+
+func (f *MetricsFileSystem) Rename(filePath string, newName string) (newPath string, err error) {
+	defer f.observe("Rename", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return "", err
+	}
+	renamed, err := f.InnerFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return renamed.Path(), nil
+}
+
+func (f *MetricsFileSystem) Move(filePath string, destPath string) (err error) {
+	defer f.observe("Move", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).MoveTo(f.InnerFile(destPath))
+}
+
+func (f *MetricsFileSystem) Remove(filePath string) (err error) {
+	defer f.observe("Remove", time.Now(), &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().Remove(filePath)
+}
+
+// Close unregisters the file system,
+// the inner file system is not closed.
+func (f *MetricsFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// countingReader counts the read bytes
+// and passes them to the sink when closed
+type countingReader struct {
+	fs.ReadCloser
+	fileSystem *MetricsFileSystem
+	read       atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read.Add(int64(n))
+	return n, err
+}
+
+func (r *countingReader) Close() error {
+	r.fileSystem.sink.ObserveBytes(r.fileSystem.label, r.read.Swap(0), 0)
+	return r.ReadCloser.Close()
+}
+
+// countingWriter counts the written bytes
+// and passes them to the sink when closed
+type countingWriter struct {
+	fs.WriteCloser
+	fileSystem *MetricsFileSystem
+	written    atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.written.Add(int64(n))
+	return n, err
+}
+
+func (w *countingWriter) Close() error {
+	w.fileSystem.sink.ObserveBytes(w.fileSystem.label, 0, w.written.Swap(0))
+	return w.WriteCloser.Close()
+}
+
+// countingReadWriter counts the read and written bytes
+// and passes them to the sink when closed
+type countingReadWriter struct {
+	fs.ReadWriteSeekCloser
+	fileSystem *MetricsFileSystem
+	read       atomic.Int64
+	written    atomic.Int64
+}
+
+func (rw *countingReadWriter) Read(p []byte) (int, error) {
+	n, err := rw.ReadWriteSeekCloser.Read(p)
+	rw.read.Add(int64(n))
+	return n, err
+}
+
+func (rw *countingReadWriter) Write(p []byte) (int, error) {
+	n, err := rw.ReadWriteSeekCloser.Write(p)
+	rw.written.Add(int64(n))
+	return n, err
+}
+
+func (rw *countingReadWriter) Close() error {
+	rw.fileSystem.sink.ObserveBytes(rw.fileSystem.label, rw.read.Swap(0), rw.written.Swap(0))
+	return rw.ReadWriteSeekCloser.Close()
+}
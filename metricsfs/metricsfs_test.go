@@ -0,0 +1,82 @@
+package metricsfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestMetricsFileSystem(t *testing.T) {
+	stats := new(Stats)
+	metricsFS, err := New(fs.Local, "", stats)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = metricsFS.Close() })
+	dir := metricsFS.JoinCleanFile(t.TempDir())
+	require.True(t, fs.IsRegistered(metricsFS))
+	require.Equal(t, fs.Local.Name(), metricsFS.Label())
+
+	file := dir.Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+	require.NoError(t, file.AppendString(context.Background(), " World"))
+	data, err := file.ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(data))
+	read, written := stats.Bytes()
+	require.Equal(t, int64(11), read)
+	require.Equal(t, int64(11), written)
+
+	reader, err := file.OpenReader()
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	writer, err := dir.Join("other.txt").OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("123"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	read, written = stats.Bytes()
+	require.Equal(t, int64(22), read, "counted when reader closed")
+	require.Equal(t, int64(14), written, "counted when writer closed")
+
+	_, err = dir.Join("missing.txt").ReadAll()
+	require.Error(t, err)
+	readAll := stats.Method("ReadAll")
+	require.Equal(t, int64(2), readAll.Count)
+	require.Equal(t, int64(1), readAll.Errors)
+	require.Equal(t, 0.5, readAll.ErrorRate())
+	require.Positive(t, readAll.TotalDuration)
+	require.GreaterOrEqual(t, readAll.MaxDuration, readAll.AvgDuration())
+
+	files, err := dir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []fs.File{file, dir.Join("other.txt")}, files)
+	require.Equal(t, int64(1), stats.Method("ListDirInfo").Count)
+	require.NoError(t, file.Remove())
+	require.Contains(t, stats.Methods(), "Remove")
+
+	stats.Reset()
+	require.Empty(t, stats.Methods())
+	require.NoError(t, metricsFS.Close())
+	_, err = metricsFS.Stat(file.Path())
+	require.True(t, errors.Is(err, fs.ErrFileSystemClosed))
+	require.Equal(t, int64(1), stats.Method("Stat").Errors)
+}
+
+func TestMultiSink(t *testing.T) {
+	a, b := new(Stats), new(Stats)
+	sink := MultiSink(a, b)
+	sink.ObserveOp("label", "Stat", 1, nil)
+	sink.ObserveBytes("label", 1, 2)
+	for _, stats := range []*Stats{a, b} {
+		require.Equal(t, int64(1), stats.Method("Stat").Count)
+		read, written := stats.Bytes()
+		require.Equal(t, int64(1), read)
+		require.Equal(t, int64(2), written)
+	}
+}
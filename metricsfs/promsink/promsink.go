@@ -0,0 +1,129 @@
+// Package promsink implements a metricsfs.Sink
+// that exports the measurements as Prometheus metrics.
+package promsink
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ungerik/go-fs/metricsfs"
+)
+
+var (
+	_ metricsfs.Sink       = new(Collector)
+	_ prometheus.Collector = new(Collector)
+)
+
+// Collector is a metricsfs.Sink and a prometheus.Collector
+// that has to be registered with a prometheus.Registerer.
+//
+// The metrics have a "filesystem" label with the label
+// of the metricsfs.MetricsFileSystem and operation metrics
+// an additional "method" label:
+//
+//	<namespace>_operations_total
+//	<namespace>_operation_errors_total
+//	<namespace>_operation_duration_seconds
+//	<namespace>_read_bytes_total
+//	<namespace>_written_bytes_total
+type Collector struct {
+	operations   *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	durations    *prometheus.HistogramVec
+	bytesRead    *prometheus.CounterVec
+	bytesWritten *prometheus.CounterVec
+}
+
+// NewCollector returns a new Collector with metrics
+// prefixed by namespace, "gofs" is used if namespace is empty.
+// Pass nil as buckets to use prometheus.DefBuckets
+// for the operation duration histogram.
+func NewCollector(namespace string, buckets []float64) *Collector {
+	if namespace == "" {
+		namespace = "gofs"
+	}
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	return &Collector{
+		operations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "operations_total",
+				Help:      "Number of file system method calls.",
+			},
+			[]string{"filesystem", "method"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "operation_errors_total",
+				Help:      "Number of file system method calls that returned an error.",
+			},
+			[]string{"filesystem", "method"},
+		),
+		durations: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "operation_duration_seconds",
+				Help:      "Duration of file system method calls.",
+				Buckets:   buckets,
+			},
+			[]string{"filesystem", "method"},
+		),
+		bytesRead: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "read_bytes_total",
+				Help:      "Number of bytes read from files.",
+			},
+			[]string{"filesystem"},
+		),
+		bytesWritten: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "written_bytes_total",
+				Help:      "Number of bytes written to files.",
+			},
+			[]string{"filesystem"},
+		),
+	}
+}
+
+// ObserveOp implements metricsfs.Sink
+func (c *Collector) ObserveOp(label, method string, duration time.Duration, err error) {
+	c.operations.WithLabelValues(label, method).Inc()
+	if err != nil {
+		c.errors.WithLabelValues(label, method).Inc()
+	}
+	c.durations.WithLabelValues(label, method).Observe(duration.Seconds())
+}
+
+// ObserveBytes implements metricsfs.Sink
+func (c *Collector) ObserveBytes(label string, read, written int64) {
+	if read > 0 {
+		c.bytesRead.WithLabelValues(label).Add(float64(read))
+	}
+	if written > 0 {
+		c.bytesWritten.WithLabelValues(label).Add(float64(written))
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.operations.Describe(ch)
+	c.errors.Describe(ch)
+	c.durations.Describe(ch)
+	c.bytesRead.Describe(ch)
+	c.bytesWritten.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.operations.Collect(ch)
+	c.errors.Collect(ch)
+	c.durations.Collect(ch)
+	c.bytesRead.Collect(ch)
+	c.bytesWritten.Collect(ch)
+}
@@ -0,0 +1,54 @@
+package promsink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/metricsfs"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector("", nil)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	metricsFS, err := metricsfs.New(fs.Local, "local", collector)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = metricsFS.Close() })
+	file := metricsFS.JoinCleanFile(t.TempDir(), "file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+	_, err = file.ReadAll()
+	require.NoError(t, err)
+	collector.ObserveOp("local", "ReadAll", time.Millisecond, errors.New("failed"))
+
+	expected := `
+		# HELP gofs_operation_errors_total Number of file system method calls that returned an error.
+		# TYPE gofs_operation_errors_total counter
+		gofs_operation_errors_total{filesystem="local",method="ReadAll"} 1
+		# HELP gofs_operations_total Number of file system method calls.
+		# TYPE gofs_operations_total counter
+		gofs_operations_total{filesystem="local",method="ReadAll"} 2
+		gofs_operations_total{filesystem="local",method="WriteAll"} 1
+		# HELP gofs_read_bytes_total Number of bytes read from files.
+		# TYPE gofs_read_bytes_total counter
+		gofs_read_bytes_total{filesystem="local"} 5
+		# HELP gofs_written_bytes_total Number of bytes written to files.
+		# TYPE gofs_written_bytes_total counter
+		gofs_written_bytes_total{filesystem="local"} 5
+	`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"gofs_operations_total",
+		"gofs_operation_errors_total",
+		"gofs_read_bytes_total",
+		"gofs_written_bytes_total",
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, testutil.CollectAndCount(collector, "gofs_operation_duration_seconds"))
+}
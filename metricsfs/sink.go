@@ -0,0 +1,134 @@
+package metricsfs
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// Sink receives the measurements of a MetricsFileSystem.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	// ObserveOp is called after every call of a FileSystem method
+	// with the label of the MetricsFileSystem, the method name,
+	// the duration of the call, and its error result.
+	ObserveOp(label, method string, duration time.Duration, err error)
+
+	// ObserveBytes is called with the number of bytes
+	// read from or written to files.
+	// For readers and writers it is called when they are closed.
+	ObserveBytes(label string, read, written int64)
+}
+
+// MultiSink returns a Sink that passes all measurements to sinks
+func MultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+type multiSink []Sink
+
+func (m multiSink) ObserveOp(label, method string, duration time.Duration, err error) {
+	for _, sink := range m {
+		sink.ObserveOp(label, method, duration, err)
+	}
+}
+
+func (m multiSink) ObserveBytes(label string, read, written int64) {
+	for _, sink := range m {
+		sink.ObserveBytes(label, read, written)
+	}
+}
+
+// MethodStats are the aggregated measurements of a FileSystem method
+type MethodStats struct {
+	Count         int64
+	Errors        int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// ErrorRate returns the fraction of calls that returned an error
+func (s MethodStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// AvgDuration returns the average duration of a call
+func (s MethodStats) AvgDuration() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Count)
+}
+
+// Stats is a Sink that aggregates measurements in memory.
+// The zero value is ready to use.
+type Stats struct {
+	mtx          sync.Mutex
+	methods      map[string]MethodStats
+	bytesRead    int64
+	bytesWritten int64
+}
+
+var _ Sink = new(Stats)
+
+func (s *Stats) ObserveOp(label, method string, duration time.Duration, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.methods == nil {
+		s.methods = make(map[string]MethodStats)
+	}
+	m := s.methods[method]
+	m.Count++
+	if err != nil {
+		m.Errors++
+	}
+	m.TotalDuration += duration
+	m.MaxDuration = max(m.MaxDuration, duration)
+	s.methods[method] = m
+}
+
+func (s *Stats) ObserveBytes(label string, read, written int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.bytesRead += read
+	s.bytesWritten += written
+}
+
+// Method returns the stats of a FileSystem method
+func (s *Stats) Method(method string) MethodStats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.methods[method]
+}
+
+// Methods returns a copy of the stats of all called FileSystem methods
+func (s *Stats) Methods() map[string]MethodStats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return maps.Clone(s.methods)
+}
+
+// Bytes returns the total number of bytes read and written
+func (s *Stats) Bytes() (read, written int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bytesRead, s.bytesWritten
+}
+
+// Reset sets all stats to zero
+func (s *Stats) Reset() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.methods = nil
+	s.bytesRead = 0
+	s.bytesWritten = 0
+}
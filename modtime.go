@@ -0,0 +1,57 @@
+package fs
+
+import "time"
+
+// ModTimeComparison compares modification times of files
+// from file systems that store them at different resolutions,
+// like S3 and FTP that use seconds and local file systems
+// that use nanoseconds.
+//
+// The zero value compares the exact times.
+type ModTimeComparison struct {
+	// Resolution both times are truncated to before comparing them,
+	// for example time.Second if one of the file systems
+	// stores modification times in seconds.
+	// Zero or negative values don't truncate.
+	Resolution time.Duration
+
+	// Tolerance is the maximum difference of the
+	// truncated times that are still considered equal,
+	// for example to allow for clock skew between servers.
+	Tolerance time.Duration
+}
+
+// Compare returns -1 if a is before b, +1 if a is after b,
+// and 0 if both times are considered equal.
+func (c ModTimeComparison) Compare(a, b time.Time) int {
+	if c.Resolution > 0 {
+		a = a.Truncate(c.Resolution)
+		b = b.Truncate(c.Resolution)
+	}
+	diff := a.Sub(b)
+	switch {
+	case diff > c.Tolerance:
+		return +1
+	case diff < -c.Tolerance:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Equal returns if a and b are considered equal.
+func (c ModTimeComparison) Equal(a, b time.Time) bool {
+	return c.Compare(a, b) == 0
+}
+
+// After returns if a is after b
+// and not considered equal to b.
+func (c ModTimeComparison) After(a, b time.Time) bool {
+	return c.Compare(a, b) > 0
+}
+
+// Before returns if a is before b
+// and not considered equal to b.
+func (c ModTimeComparison) Before(a, b time.Time) bool {
+	return c.Compare(a, b) < 0
+}
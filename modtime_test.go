@@ -0,0 +1,58 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModTimeComparison(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	nanos := base.Add(700 * time.Millisecond)
+
+	var exact ModTimeComparison
+	require.Equal(t, 0, exact.Compare(base, base))
+	require.Equal(t, +1, exact.Compare(nanos, base))
+	require.Equal(t, -1, exact.Compare(base, nanos))
+	require.True(t, exact.After(nanos, base))
+	require.True(t, exact.Before(base, nanos))
+
+	seconds := ModTimeComparison{Resolution: time.Second}
+	require.True(t, seconds.Equal(nanos, base))
+	require.False(t, seconds.After(nanos, base))
+	require.True(t, seconds.After(base.Add(time.Second), nanos))
+
+	tolerant := ModTimeComparison{Resolution: time.Second, Tolerance: 2 * time.Second}
+	require.True(t, tolerant.Equal(base.Add(2*time.Second), base))
+	require.True(t, tolerant.Equal(base, base.Add(2*time.Second+time.Millisecond)))
+	require.True(t, tolerant.After(base.Add(3*time.Second), base))
+	require.True(t, tolerant.Before(base, base.Add(3*time.Second)))
+}
+
+func TestSyncDir_ModTime(t *testing.T) {
+	ctx := context.Background()
+	src := File(t.TempDir())
+	dest := File(t.TempDir())
+	require.NoError(t, src.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, dest.Join("a.txt").WriteAllString("B"))
+
+	// src is newer only by fractions of a second
+	// like a local file compared to a copy on S3
+	destTime := time.Now().Truncate(time.Second).Add(-time.Hour)
+	srcTime := destTime.Add(500 * time.Millisecond)
+	require.NoError(t, os.Chtimes(src.Join("a.txt").LocalPath(), srcTime, srcTime))
+	require.NoError(t, os.Chtimes(dest.Join("a.txt").LocalPath(), destTime, destTime))
+
+	report, err := SyncDir(ctx, src, dest, SyncOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt"}, report.Updated)
+
+	report, err = SyncDir(ctx, src, dest, SyncOptions{ModTime: ModTimeComparison{Resolution: time.Second}})
+	require.NoError(t, err)
+	require.Empty(t, report.Updated)
+	require.Equal(t, 1, report.Unchanged)
+	requireFileContent(t, dest.Join("a.txt"), "B")
+}
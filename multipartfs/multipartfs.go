@@ -2,6 +2,7 @@ package multipartfs
 
 import (
 	"context"
+	"errors"
 	iofs "io/fs"
 	"mime/multipart"
 	"net/http"
@@ -44,8 +45,11 @@ func FromRequestForm(request *http.Request, maxMemory int64) (*MultipartFileSyst
 		prefix: Prefix + fsimpl.RandomString(),
 		Form:   request.MultipartForm,
 	}
-	fs.Register(f)
-	return f, err
+	_, err = fs.TryRegister(f)
+	if err != nil {
+		return nil, errors.Join(err, request.MultipartForm.RemoveAll())
+	}
+	return f, nil
 }
 
 // FormFile returns the first file uploaded under name
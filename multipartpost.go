@@ -0,0 +1,89 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// WriteMultipartFormFiles writes every file as a form-data part
+// with the passed fieldName to the multipart writer.
+func WriteMultipartFormFiles(ctx context.Context, w *multipart.Writer, fieldName string, files ...FileReader) error {
+	var buf []byte
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		part, err := w.CreateFormFile(fieldName, file.Name())
+		if err != nil {
+			return err
+		}
+		r, err := file.OpenReader()
+		if err != nil {
+			return err
+		}
+		if buf == nil {
+			buf = make([]byte, copyBufferSize)
+		}
+		err = copyBuffer(ctx, part, r, buf)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("can't write multipart form file %s: %w", file.Name(), err)
+		}
+	}
+	return nil
+}
+
+// PostDirectory streams all files (not sub-directories) of dir
+// as multipart/form-data parts with the passed fieldName
+// in the body of a POST request to url.
+// The files are not loaded into memory completely.
+// If client is nil then http.DefaultClient will be used.
+//
+// The returned response is not checked for its status code
+// and the response body has to be closed by the caller.
+func PostDirectory(ctx context.Context, client *http.Client, url string, dir File, fieldName string) (*http.Response, error) {
+	if err := dir.CheckIsDir(); err != nil {
+		return nil, err
+	}
+	var files []FileReader
+	err := dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if !info.IsDir {
+			files = append(files, info.File)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	formWriter := multipart.NewWriter(pipeWriter)
+	go func() {
+		err := WriteMultipartFormFiles(ctx, formWriter, fieldName, files...)
+		if err == nil {
+			err = formWriter.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pipeReader)
+	if err != nil {
+		pipeReader.CloseWithError(err)
+		return nil, err
+	}
+	request.Header.Set("Content-Type", formWriter.FormDataContentType())
+	response, err := client.Do(request)
+	if err != nil {
+		// Stop the writing goroutine in case the
+		// request failed before reading the whole body
+		pipeReader.CloseWithError(err)
+		return nil, err
+	}
+	return response, nil
+}
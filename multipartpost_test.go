@@ -0,0 +1,55 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostDirectory(t *testing.T) {
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, dir.Join("b.txt").WriteAllString("BB"))
+	require.NoError(t, dir.Join("sub").MakeDir())
+
+	received := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseMultipartForm(1024)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, header := range r.MultipartForm.File["files"] {
+			f, err := header.Open()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			f.Close()
+			received[header.Filename] = string(data)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	response, err := PostDirectory(context.Background(), nil, server.URL, dir, "files")
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	names := make([]string, 0, len(received))
+	for name := range received {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"a.txt", "b.txt"}, names)
+	require.Equal(t, "BB", received["b.txt"])
+
+	_, err = PostDirectory(context.Background(), nil, server.URL, dir.Join("a.txt"), "files")
+	require.ErrorAs(t, err, new(ErrIsNotDirectory))
+}
@@ -0,0 +1,58 @@
+package fs
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var normalizeNames atomic.Bool
+
+// SetNormalizeNames enables or disables the Unicode normalization
+// of file names with NormalizeName where listings
+// of different directories are matched by name,
+// like in IdenticalDirContents, SyncDir, SyncBidirectional,
+// and for the keys returned by HashTree.
+// The normalization is disabled by default.
+//
+// macOS file systems store names in the decomposed form NFD
+// while most other systems use the composed form NFC,
+// so the same name listed on macOS and Linux would not match
+// without normalization.
+//
+// The paths of listed files are never changed because
+// not every file system treats NFC and NFD names as equal.
+func SetNormalizeNames(normalize bool) {
+	normalizeNames.Store(normalize)
+}
+
+// NormalizeNamesEnabled returns if the normalization
+// of file names was enabled with SetNormalizeNames.
+func NormalizeNamesEnabled() bool {
+	return normalizeNames.Load()
+}
+
+// NormalizeName returns the Unicode normalization form NFC of name.
+// Works also with paths because separators are not changed
+// by the normalization.
+func NormalizeName(name string) string {
+	if norm.NFC.IsNormalString(name) {
+		return name // fast path without allocation
+	}
+	return norm.NFC.String(name)
+}
+
+// EqualNames returns if the names a and b are equal
+// after Unicode normalization with NormalizeName.
+func EqualNames(a, b string) bool {
+	return a == b || NormalizeName(a) == NormalizeName(b)
+}
+
+// matchName returns the name normalized with NormalizeName
+// if enabled with SetNormalizeNames, else the name unchanged.
+func matchName(name string) string {
+	if normalizeNames.Load() {
+		return NormalizeName(name)
+	}
+	return name
+}
@@ -0,0 +1,55 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nameNFC = "café.txt"  // é as single code point
+	nameNFD = "café.txt" // e followed by combining acute accent
+)
+
+func TestNormalizeName(t *testing.T) {
+	require.NotEqual(t, nameNFC, nameNFD)
+	require.Equal(t, nameNFC, NormalizeName(nameNFC))
+	require.Equal(t, nameNFC, NormalizeName(nameNFD))
+	require.Equal(t, "dir/"+nameNFC, NormalizeName("dir/"+nameNFD), "paths")
+	require.Equal(t, "", NormalizeName(""))
+	require.Equal(t, "ascii.txt", NormalizeName("ascii.txt"))
+
+	require.True(t, EqualNames(nameNFC, nameNFD))
+	require.True(t, EqualNames("a", "a"))
+	require.False(t, EqualNames(nameNFC, "cafe.txt"))
+}
+
+func TestNormalizeNames(t *testing.T) {
+	t.Cleanup(func() { SetNormalizeNames(false) })
+	ctx := context.Background()
+
+	dirA := File(t.TempDir())
+	dirB := File(t.TempDir())
+	require.NoError(t, dirA.Join(nameNFC).WriteAllString("content"))
+	require.NoError(t, dirB.Join(nameNFD).WriteAllString("content"))
+
+	SetNormalizeNames(false)
+	identical, err := IdenticalDirContents(ctx, dirA, dirB, true)
+	require.NoError(t, err)
+	require.False(t, identical, "names differ without normalization")
+	hashes, err := HashTree(ctx, dirB, nil, 1)
+	require.NoError(t, err)
+	require.Contains(t, hashes, nameNFD)
+
+	SetNormalizeNames(true)
+	require.True(t, NormalizeNamesEnabled())
+	identical, err = IdenticalDirContents(ctx, dirA, dirB, true)
+	require.NoError(t, err)
+	require.True(t, identical, "names match with normalization")
+	hashes, err = HashTree(ctx, dirB, nil, 1)
+	require.NoError(t, err)
+	require.Contains(t, hashes, nameNFC)
+
+	require.True(t, dirB.Join(nameNFD).Exists(), "listed paths are not changed")
+}
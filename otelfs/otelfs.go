@@ -0,0 +1,429 @@
+// Package otelfs implements a file system that traces
+// the operations of another file system with OpenTelemetry.
+package otelfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of TracingFileSystem URIs
+	Prefix = "otel://"
+
+	// TracerName is the name of the tracer used for spans
+	TracerName = "github.com/ungerik/go-fs/otelfs"
+)
+
+// Span attribute keys
+const (
+	AttrFileSystem   = attribute.Key("fs.name")
+	AttrPath         = attribute.Key("fs.path")
+	AttrDestPath     = attribute.Key("fs.dest_path")
+	AttrBytesRead    = attribute.Key("fs.bytes_read")
+	AttrBytesWritten = attribute.Key("fs.bytes_written")
+)
+
+var (
+	// Make sure TracingFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(TracingFileSystem)
+	_ fs.ReadAllFileSystem  = new(TracingFileSystem)
+	_ fs.WriteAllFileSystem = new(TracingFileSystem)
+	_ fs.AppendFileSystem   = new(TracingFileSystem)
+	_ fs.TouchFileSystem    = new(TracingFileSystem)
+	_ fs.TruncateFileSystem = new(TracingFileSystem)
+	_ fs.CopyFileSystem     = new(TracingFileSystem)
+	_ fs.RenameFileSystem   = new(TracingFileSystem)
+	_ fs.MoveFileSystem     = new(TracingFileSystem)
+	_ fs.ExistsFileSystem   = new(TracingFileSystem)
+
+	_ fs.StatContextFileSystem = new(TracingFileSystem)
+	_ fs.MoveContextFileSystem = new(TracingFileSystem)
+)
+
+// TracingFileSystem wraps a file system and starts
+// an OpenTelemetry span named "fs.<Method>" for every
+// FileSystem method call with the attributes
+// fs.name, fs.path, and for transfers fs.bytes_read or fs.bytes_written.
+//
+// It is registered with the prefix "otel://" followed by a random ID.
+//
+// Spans of methods with a context argument like ReadAll, WriteAll,
+// ListDirInfo, or CopyFile are children of the span in the context.
+// StatContext and MoveContext are implemented so that File.InfoContext
+// and fs.Move also pass their context to the span.
+// The FileSystem interface has no context argument for other methods
+// so their spans are started without a parent.
+// Spans of OpenReader, OpenWriter, and OpenReadWriter
+// end when the returned reader or writer is closed.
+type TracingFileSystem struct {
+	fs.WrapperBase
+
+	tracer trace.Tracer
+	closed atomic.Bool
+}
+
+// New returns a new TracingFileSystem for inner
+// that uses a tracer of tracerProvider and registers it.
+// The global tracer provider is used if tracerProvider is nil.
+func New(inner fs.FileSystem, tracerProvider trace.TracerProvider) (*TracingFileSystem, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("nil inner file system")
+	}
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	f := &TracingFileSystem{
+		WrapperBase: fs.NewWrapperBase(Prefix+fsimpl.RandomString(), inner),
+		tracer:      tracerProvider.Tracer(TracerName),
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// start starts a span for method that has to be ended with end
+func (f *TracingFileSystem) start(ctx context.Context, method, filePath string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, AttrFileSystem.String(f.Inner().Name()), AttrPath.String(filePath))
+	return f.tracer.Start(ctx, "fs."+method, trace.WithAttributes(attrs...))
+}
+
+// end records err if not nil and ends span,
+// use it with defer and a pointer to a named error result.
+func end(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+func (f *TracingFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *TracingFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.Inner().ReadableWritable()
+}
+
+func (f *TracingFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.Prefix(), Prefix), nil
+}
+
+func (f *TracingFileSystem) Name() string {
+	return "traced " + f.Inner().Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *TracingFileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+func (f *TracingFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	return f.StatContext(context.Background(), filePath)
+}
+
+func (f *TracingFileSystem) StatContext(ctx context.Context, filePath string) (info iofs.FileInfo, err error) {
+	ctx, span := f.start(ctx, "Stat", filePath)
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	if statFS, ok := f.Inner().(fs.StatContextFileSystem); ok {
+		return statFS.StatContext(ctx, filePath)
+	}
+	return f.Inner().Stat(filePath)
+}
+
+func (f *TracingFileSystem) Exists(filePath string) bool {
+	_, span := f.start(context.Background(), "Exists", filePath)
+	defer span.End()
+	exists := f.checkOpen() == nil && f.InnerFile(filePath).Exists()
+	span.SetAttributes(attribute.Bool("fs.exists", exists))
+	return exists
+}
+
+func (f *TracingFileSystem) IsHidden(filePath string) bool {
+	return f.Inner().IsHidden(filePath)
+}
+
+func (f *TracingFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.Inner().IsSymbolicLink(filePath)
+}
+
+func (f *TracingFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) (err error) {
+	ctx, span := f.start(ctx, "ListDirInfo", dirPath, attribute.StringSlice("fs.patterns", patterns))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	count := 0
+	err = f.Inner().ListDirInfo(ctx, dirPath, func(innerInfo *fs.FileInfo) error {
+		count++
+		info := *innerInfo
+		info.File = f.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns)
+	span.SetAttributes(attribute.Int("fs.listed", count))
+	return err
+}
+
+func (f *TracingFileSystem) MakeDir(dirPath string, perm []fs.Permissions) (err error) {
+	_, span := f.start(context.Background(), "MakeDir", dirPath)
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().MakeDir(dirPath, perm)
+}
+
+func (f *TracingFileSystem) ReadAll(ctx context.Context, filePath string) (data []byte, err error) {
+	ctx, span := f.start(ctx, "ReadAll", filePath)
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	data, err = f.InnerFile(filePath).ReadAllContext(ctx)
+	span.SetAttributes(AttrBytesRead.Int(len(data)))
+	return data, err
+}
+
+func (f *TracingFileSystem) OpenReader(filePath string) (reader fs.ReadCloser, err error) {
+	_, span := f.start(context.Background(), "OpenReader", filePath)
+	defer func() {
+		if err != nil {
+			end(span, &err)
+		}
+	}()
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	reader, err = f.Inner().OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedReader{ReadCloser: reader, span: span}, nil
+}
+
+func (f *TracingFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	ctx, span := f.start(ctx, "WriteAll", filePath, AttrBytesWritten.Int(len(data)))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).WriteAllContext(ctx, data, perm...)
+}
+
+func (f *TracingFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) (err error) {
+	ctx, span := f.start(ctx, "Append", filePath, AttrBytesWritten.Int(len(data)))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Append(ctx, data, perm...)
+}
+
+func (f *TracingFileSystem) Touch(filePath string, perm []fs.Permissions) (err error) {
+	_, span := f.start(context.Background(), "Touch", filePath)
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Touch(perm...)
+}
+
+func (f *TracingFileSystem) Truncate(filePath string, size int64) (err error) {
+	_, span := f.start(context.Background(), "Truncate", filePath, attribute.Int64("fs.size", size))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Truncate(size)
+}
+
+func (f *TracingFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (writer fs.WriteCloser, err error) {
+	_, span := f.start(context.Background(), "OpenWriter", filePath)
+	defer func() {
+		if err != nil {
+			end(span, &err)
+		}
+	}()
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	writer, err = f.Inner().OpenWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedWriter{WriteCloser: writer, span: span}, nil
+}
+
+func (f *TracingFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (readWriter fs.ReadWriteSeekCloser, err error) {
+	_, span := f.start(context.Background(), "OpenReadWriter", filePath)
+	defer func() {
+		if err != nil {
+			end(span, &err)
+		}
+	}()
+	if err = f.checkOpen(); err != nil {
+		return nil, err
+	}
+	readWriter, err = f.Inner().OpenReadWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedReadWriter{ReadWriteSeekCloser: readWriter, span: span}, nil
+}
+
+func (f *TracingFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) (err error) {
+	ctx, span := f.start(ctx, "CopyFile", srcFile, AttrDestPath.String(destFile))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return fs.CopyFileBuf(ctx, f.InnerFile(srcFile), f.InnerFile(destFile), buf)
+}
+
+func (f *TracingFileSystem) Rename(filePath string, newName string) (newPath string, err error) {
+	_, span := f.start(context.Background(), "Rename", filePath, attribute.String("fs.new_name", newName))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return "", err
+	}
+	renamed, err := f.InnerFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return renamed.Path(), nil
+}
+
+func (f *TracingFileSystem) Move(filePath string, destPath string) error {
+	return f.MoveContext(context.Background(), filePath, destPath)
+}
+
+func (f *TracingFileSystem) MoveContext(ctx context.Context, filePath string, destPath string) (err error) {
+	ctx, span := f.start(ctx, "Move", filePath, AttrDestPath.String(destPath))
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return fs.Move(ctx, f.InnerFile(filePath), f.InnerFile(destPath))
+}
+
+func (f *TracingFileSystem) Remove(filePath string) (err error) {
+	_, span := f.start(context.Background(), "Remove", filePath)
+	defer end(span, &err)
+	if err = f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().Remove(filePath)
+}
+
+// Close unregisters the file system,
+// the inner file system is not closed.
+func (f *TracingFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// tracedReader counts the read bytes
+// and ends the span when closed
+type tracedReader struct {
+	fs.ReadCloser
+	span trace.Span
+	read atomic.Int64
+}
+
+func (r *tracedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read.Add(int64(n))
+	if err != nil && err != io.EOF {
+		r.span.RecordError(err)
+	}
+	return n, err
+}
+
+func (r *tracedReader) Close() (err error) {
+	r.span.SetAttributes(AttrBytesRead.Int64(r.read.Load()))
+	defer end(r.span, &err)
+	return r.ReadCloser.Close()
+}
+
+// tracedWriter counts the written bytes
+// and ends the span when closed
+type tracedWriter struct {
+	fs.WriteCloser
+	span    trace.Span
+	written atomic.Int64
+}
+
+func (w *tracedWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.written.Add(int64(n))
+	if err != nil {
+		w.span.RecordError(err)
+	}
+	return n, err
+}
+
+func (w *tracedWriter) Close() (err error) {
+	w.span.SetAttributes(AttrBytesWritten.Int64(w.written.Load()))
+	defer end(w.span, &err)
+	return w.WriteCloser.Close()
+}
+
+// tracedReadWriter counts the read and written bytes
+// and ends the span when closed
+type tracedReadWriter struct {
+	fs.ReadWriteSeekCloser
+	span    trace.Span
+	read    atomic.Int64
+	written atomic.Int64
+}
+
+func (rw *tracedReadWriter) Read(p []byte) (int, error) {
+	n, err := rw.ReadWriteSeekCloser.Read(p)
+	rw.read.Add(int64(n))
+	if err != nil && err != io.EOF {
+		rw.span.RecordError(err)
+	}
+	return n, err
+}
+
+func (rw *tracedReadWriter) Write(p []byte) (int, error) {
+	n, err := rw.ReadWriteSeekCloser.Write(p)
+	rw.written.Add(int64(n))
+	if err != nil {
+		rw.span.RecordError(err)
+	}
+	return n, err
+}
+
+func (rw *tracedReadWriter) Close() (err error) {
+	rw.span.SetAttributes(
+		AttrBytesRead.Int64(rw.read.Load()),
+		AttrBytesWritten.Int64(rw.written.Load()),
+	)
+	defer end(rw.span, &err)
+	return rw.ReadWriteSeekCloser.Close()
+}
@@ -0,0 +1,92 @@
+package otelfs
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ungerik/go-fs"
+)
+
+func attrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTracingFileSystem(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracingFS, err := New(fs.Local, provider)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tracingFS.Close() })
+	require.True(t, fs.IsRegistered(tracingFS))
+	dir := tracingFS.JoinCleanFile(t.TempDir())
+	file := dir.Join("file.txt")
+
+	// Spans of methods with context are children of the context span
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	require.NoError(t, file.WriteAllContext(ctx, []byte("Hello")))
+	parent.End()
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	writeAll := spans[0]
+	require.Equal(t, "fs.WriteAll", writeAll.Name())
+	require.Equal(t, parent.SpanContext().SpanID(), writeAll.Parent().SpanID())
+	require.Equal(t, file.Path(), attrs(writeAll)[AttrPath].AsString())
+	require.Equal(t, fs.Local.Name(), attrs(writeAll)[AttrFileSystem].AsString())
+	require.Equal(t, int64(5), attrs(writeAll)[AttrBytesWritten].AsInt64())
+
+	// Stat and Move get the context of InfoContext and fs.Move
+	ctx, parent = provider.Tracer("test").Start(context.Background(), "parent")
+	_, err = file.InfoContext(ctx)
+	require.NoError(t, err)
+	moved := dir.Join("moved.txt")
+	require.NoError(t, fs.Move(ctx, file, moved))
+	require.NoError(t, fs.Move(ctx, moved, file))
+	parent.End()
+	spans = recorder.Ended()
+	for _, name := range []string{"fs.Stat", "fs.Move"} {
+		i := slices.IndexFunc(spans[2:], func(s sdktrace.ReadOnlySpan) bool { return s.Name() == name })
+		require.GreaterOrEqual(t, i, 0, name)
+		require.Equal(t, parent.SpanContext().SpanID(), spans[2+i].Parent().SpanID(), name)
+	}
+	recorder.Reset()
+
+	// Reader spans end when the reader is closed
+	reader, err := file.OpenReader()
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Empty(t, recorder.Ended())
+	require.NoError(t, reader.Close())
+	spans = recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "fs.OpenReader", spans[0].Name())
+	require.Equal(t, int64(5), attrs(spans[0])[AttrBytesRead].AsInt64())
+
+	// Errors are recorded
+	_, err = dir.Join("missing.txt").ReadAll()
+	require.Error(t, err)
+	spans = recorder.Ended()
+	readAll := spans[len(spans)-1]
+	require.Equal(t, "fs.ReadAll", readAll.Name())
+	require.Equal(t, codes.Error, readAll.Status().Code)
+	require.NotEmpty(t, readAll.Events(), "error event")
+
+	files, err := dir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []fs.File{file}, files)
+	spans = recorder.Ended()
+	require.Equal(t, "fs.ListDirInfo", spans[len(spans)-1].Name())
+	require.Equal(t, int64(1), attrs(spans[len(spans)-1])["fs.listed"].AsInt64())
+}
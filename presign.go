@@ -0,0 +1,26 @@
+package fs
+
+import (
+	"context"
+	"time"
+)
+
+// PresignURL returns a URL that allows reading the file
+// with a HTTP GET request without credentials
+// until the expires duration has passed.
+// Returns an ErrUnsupported error if the file system
+// does not implement PresignFileSystem.
+func (file File) PresignURL(ctx context.Context, expires time.Duration) (string, error) {
+	if file == "" {
+		return "", ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	fileSystem, path := file.ParseRawURI()
+	presignFS, ok := fileSystem.(PresignFileSystem)
+	if !ok {
+		return "", NewErrUnsupported(fileSystem, "PresignURL")
+	}
+	return presignFS.PresignURL(ctx, path, expires)
+}
@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"context"
+	"io"
+)
+
+// Progress of a file transfer passed to a ProgressFunc
+type Progress struct {
+	// Src is the file that is read or copied
+	Src FileReader
+	// Dest is the destination of a copied file
+	// or empty if Src is only read
+	Dest File
+	// BytesDone is the number of bytes transferred so far
+	BytesDone int64
+	// BytesTotal is the size of Src or -1 if unknown
+	BytesTotal int64
+}
+
+// Done returns if the transfer of the file is complete
+func (p Progress) Done() bool {
+	return p.BytesTotal >= 0 && p.BytesDone >= p.BytesTotal
+}
+
+// ProgressFunc is called with the progress of file transfers,
+// see ContextWithProgress.
+type ProgressFunc func(Progress)
+
+type progressCtxKey struct{}
+
+// ContextWithProgress returns a context that makes
+// CopyFile, CopyFileBuf, CopyRecursive, CopyTree, Move between
+// different file systems, and File.ReadAllContext
+// call onProgress while transferring file data.
+//
+// onProgress is called after every chunk of data
+// that was read and once more when a file is complete.
+// Transfers done by a file system internally, like copying
+// within the same file system, only report their completion.
+// Moves within the same file system don't transfer data
+// and are not reported.
+func ContextWithProgress(ctx context.Context, onProgress ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressCtxKey{}, onProgress)
+}
+
+// ProgressFromContext returns the ProgressFunc
+// set with ContextWithProgress or nil.
+func ProgressFromContext(ctx context.Context) ProgressFunc {
+	onProgress, _ := ctx.Value(progressCtxKey{}).(ProgressFunc)
+	return onProgress
+}
+
+// progressReader calls onProgress for every Read
+type progressReader struct {
+	io.Reader
+	progress   Progress
+	onProgress ProgressFunc
+	reported   bool
+}
+
+func newProgressReader(r io.Reader, onProgress ProgressFunc, src FileReader, dest File, total int64) *progressReader {
+	return &progressReader{
+		Reader:     r,
+		progress:   Progress{Src: src, Dest: dest, BytesTotal: total},
+		onProgress: onProgress,
+	}
+}
+
+func (r *progressReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	if n > 0 {
+		r.progress.BytesDone += int64(n)
+		r.onProgress(r.progress)
+		r.reported = true
+	}
+	return n, err
+}
+
+// done reports the completion of the transfer
+// with the actually transferred number of bytes as total
+// if the last reported progress was not complete
+func (r *progressReader) done() {
+	if !r.reported || r.progress.BytesTotal != r.progress.BytesDone {
+		r.progress.BytesTotal = r.progress.BytesDone
+		r.onProgress(r.progress)
+	}
+}
+
+// reportProgressDone reports the completion of a transfer
+// done without a progressReader if ctx has a ProgressFunc.
+func reportProgressDone(ctx context.Context, src FileReader, dest File, size int64) {
+	if onProgress := ProgressFromContext(ctx); onProgress != nil {
+		onProgress(Progress{Src: src, Dest: dest, BytesDone: size, BytesTotal: size})
+	}
+}
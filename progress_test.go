@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWithProgress(t *testing.T) {
+	dir := File(t.TempDir())
+	src := dir.Join("src.txt")
+	content := strings.Repeat("x", 1000)
+	require.NoError(t, src.WriteAllString(content))
+
+	var progress []Progress
+	ctx := ContextWithProgress(context.Background(), func(p Progress) {
+		progress = append(progress, p)
+	})
+	require.NotNil(t, ProgressFromContext(ctx))
+	require.Nil(t, ProgressFromContext(context.Background()))
+
+	data, err := src.ReadAllContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+	require.NotEmpty(t, progress)
+	last := progress[len(progress)-1]
+	require.Equal(t, src, last.Src)
+	require.Equal(t, File(""), last.Dest)
+	require.Equal(t, int64(1000), last.BytesDone)
+	require.Equal(t, int64(1000), last.BytesTotal)
+	require.True(t, last.Done())
+
+	// Copy to a different file system
+	progress = nil
+	sub, err := NewSubFileSystem(File(t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { sub.Close() })
+	dest := sub.RootDir().Join("dest.txt")
+	require.NoError(t, CopyFile(ctx, src, dest))
+	require.NotEmpty(t, progress)
+	for i := 1; i < len(progress); i++ {
+		require.GreaterOrEqual(t, progress[i].BytesDone, progress[i-1].BytesDone)
+	}
+	last = progress[len(progress)-1]
+	require.Equal(t, src, last.Src)
+	require.Equal(t, dest, last.Dest)
+	require.True(t, last.Done())
+	require.Equal(t, int64(1000), last.BytesDone)
+
+	// Empty files are reported once
+	progress = nil
+	empty := dir.Join("empty.txt")
+	require.NoError(t, empty.Touch())
+	require.NoError(t, CopyFile(ctx, empty, sub.RootDir().Join("empty.txt")))
+	require.Equal(t, []Progress{{Src: empty, Dest: sub.RootDir().Join("empty.txt")}}, progress)
+
+	// Move between file systems
+	progress = nil
+	require.NoError(t, Move(ctx, src, sub.RootDir().Join("moved.txt")))
+	require.False(t, src.Exists())
+	require.NotEmpty(t, progress)
+	require.True(t, progress[len(progress)-1].Done())
+}
@@ -0,0 +1,81 @@
+// Package protofile reads and writes protocol buffer messages
+// from and to files of any go-fs file system.
+//
+// It is a separate module so that the core go-fs package
+// does not depend on google.golang.org/protobuf.
+package protofile
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+const (
+	// ErrUnmarshalProto is returned wrapped when unmarshalling a protobuf message failed
+	ErrUnmarshalProto fs.SentinelError = "can't unmarshal protobuf message"
+
+	// ErrMarshalProto is returned wrapped when marshalling a protobuf message failed
+	ErrMarshalProto fs.SentinelError = "can't marshal protobuf message"
+)
+
+// Read reads and unmarshalles the binary protobuf content
+// of fileReader to msg.
+//
+// Returns a wrapped ErrUnmarshalProto when the unmarshalling failed.
+func Read(ctx context.Context, fileReader fs.FileReader, msg proto.Message) error {
+	data, err := fileReader.ReadAllContext(ctx)
+	if err != nil {
+		return err
+	}
+	err = proto.Unmarshal(data, msg)
+	if err != nil {
+		return fmt.Errorf("%w because: %w", ErrUnmarshalProto, err)
+	}
+	return nil
+}
+
+// Write marshalles msg to the binary protobuf format and writes it as the file.
+//
+// Returns a wrapped ErrMarshalProto when the marshalling failed.
+func Write(ctx context.Context, file fs.File, msg proto.Message, perm ...fs.Permissions) error {
+	if file == "" {
+		return fs.ErrEmptyPath
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("%w because: %w", ErrMarshalProto, err)
+	}
+	return file.WriteAllContext(ctx, data, perm...)
+}
+
+// ReadMemFile reads and unmarshalles the binary protobuf content
+// of fileReader to msg and returns the file content as MemFile.
+//
+// Returns a wrapped ErrUnmarshalProto when the unmarshalling failed.
+func ReadMemFile(ctx context.Context, fileReader fs.FileReader, msg proto.Message) (fs.MemFile, error) {
+	memFile, err := fs.ReadMemFile(ctx, fileReader)
+	if err != nil {
+		return fs.MemFile{}, err
+	}
+	err = proto.Unmarshal(memFile.FileData, msg)
+	if err != nil {
+		return fs.MemFile{}, fmt.Errorf("%w because: %w", ErrUnmarshalProto, err)
+	}
+	return memFile, nil
+}
+
+// NewMemFile returns a new MemFile with msg mashalled
+// to the binary protobuf format as FileData.
+//
+// Returns a wrapped ErrMarshalProto when the marshalling failed.
+func NewMemFile(name string, msg proto.Message) (fs.MemFile, error) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fs.MemFile{}, fmt.Errorf("%w because: %w", ErrMarshalProto, err)
+	}
+	return fs.NewMemFile(name, data), nil
+}
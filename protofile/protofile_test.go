@@ -0,0 +1,40 @@
+package protofile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+func TestReadWrite(t *testing.T) {
+	ctx := context.Background()
+	file := fs.File(t.TempDir()).Join("message.pb")
+
+	err := Write(ctx, file, wrapperspb.String("Hello World!"))
+	require.NoError(t, err)
+
+	var msg wrapperspb.StringValue
+	err = Read(ctx, file, &msg)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World!", msg.GetValue())
+
+	err = Read(ctx, fs.NewMemFile("invalid.pb", []byte{0xFF}), &msg)
+	require.ErrorIs(t, err, ErrUnmarshalProto)
+}
+
+func TestMemFile(t *testing.T) {
+	ctx := context.Background()
+	memFile, err := NewMemFile("value.pb", wrapperspb.Int64(42))
+	require.NoError(t, err)
+	require.Equal(t, "value.pb", memFile.Name())
+
+	var msg wrapperspb.Int64Value
+	read, err := ReadMemFile(ctx, memFile, &msg)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), msg.GetValue())
+	require.Equal(t, memFile.FileData, read.FileData)
+}
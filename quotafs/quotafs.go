@@ -0,0 +1,460 @@
+// Package quotafs implements a file system that enforces
+// limits for the stored bytes and number of files of another file system.
+package quotafs
+
+import (
+	"context"
+	"fmt"
+	iofs "io/fs"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of QuotaFileSystem URIs
+	Prefix = "quota://"
+)
+
+var (
+	// Make sure QuotaFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(QuotaFileSystem)
+	_ fs.ReadAllFileSystem  = new(QuotaFileSystem)
+	_ fs.WriteAllFileSystem = new(QuotaFileSystem)
+	_ fs.AppendFileSystem   = new(QuotaFileSystem)
+	_ fs.TouchFileSystem    = new(QuotaFileSystem)
+	_ fs.TruncateFileSystem = new(QuotaFileSystem)
+	_ fs.CopyFileSystem     = new(QuotaFileSystem)
+	_ fs.RenameFileSystem   = new(QuotaFileSystem)
+	_ fs.MoveFileSystem     = new(QuotaFileSystem)
+	_ fs.ExistsFileSystem   = new(QuotaFileSystem)
+)
+
+// Limits of a QuotaFileSystem,
+// zero values mean no limit
+type Limits struct {
+	// MaxBytes is the maximum total size of all files
+	MaxBytes int64
+	// MaxFiles is the maximum number of files,
+	// directories are not counted
+	MaxFiles int64
+}
+
+// Usage is the total size and number of stored files
+type Usage struct {
+	Bytes int64
+	Files int64
+}
+
+func (u Usage) add(other Usage) Usage {
+	return Usage{Bytes: u.Bytes + other.Bytes, Files: u.Files + other.Files}
+}
+
+func (u Usage) sub(other Usage) Usage {
+	return Usage{Bytes: u.Bytes - other.Bytes, Files: u.Files - other.Files}
+}
+
+// ScanUsage returns the Usage of all files in dir
+// and its sub-directories.
+func ScanUsage(ctx context.Context, dir fs.File) (usage Usage, err error) {
+	err = dir.ListDirInfoRecursiveContext(ctx, func(info *fs.FileInfo) error {
+		usage.Bytes += info.Size
+		usage.Files++
+		return nil
+	})
+	if err != nil {
+		return Usage{}, err
+	}
+	return usage, nil
+}
+
+// QuotaFileSystem wraps a file system, tracks the total size
+// and number of the stored files and rejects writes that would
+// exceed its Limits with an fs.ErrQuotaExceeded error.
+//
+// The usage is only tracked for changes made through the QuotaFileSystem,
+// the initial usage of the wrapped file system has to be passed to New,
+// for example by calling ScanUsage.
+// The usage is corrected with the actual file sizes after every operation,
+// so it is approximate while writers are open
+// or the same file is written concurrently.
+//
+// It is registered with the prefix "quota://" followed by a random ID.
+// Use it with a fs.SubFileSystem to enforce a quota for a directory.
+type QuotaFileSystem struct {
+	fs.WrapperBase
+
+	limits Limits
+	mtx    sync.Mutex
+	usage  Usage
+	closed atomic.Bool
+}
+
+// New returns a new QuotaFileSystem for inner
+// enforcing limits starting with the passed usage
+// and registers it.
+func New(inner fs.FileSystem, limits Limits, usage Usage) (*QuotaFileSystem, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("nil inner file system")
+	}
+	if limits.MaxBytes < 0 || limits.MaxFiles < 0 {
+		return nil, fmt.Errorf("negative quota limits: %+v", limits)
+	}
+	f := &QuotaFileSystem{
+		WrapperBase: fs.NewWrapperBase(Prefix+fsimpl.RandomString(), inner),
+		limits:      limits,
+		usage:       usage,
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Limits returns the limits passed to New
+func (f *QuotaFileSystem) Limits() Limits {
+	return f.limits
+}
+
+// Usage returns the current usage
+func (f *QuotaFileSystem) Usage() Usage {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return f.usage
+}
+
+// fileUsage returns the usage of a single file
+// or zero if it does not exist or is a directory
+func (f *QuotaFileSystem) fileUsage(filePath string) Usage {
+	info, err := f.Inner().Stat(filePath)
+	if err != nil || info.IsDir() {
+		return Usage{}
+	}
+	return Usage{Bytes: info.Size(), Files: 1}
+}
+
+// reserve adds delta to the usage or returns fs.ErrQuotaExceeded
+// if increasing the usage by delta would exceed the limits
+func (f *QuotaFileSystem) reserve(filePath string, delta Usage) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if delta.Bytes > 0 && f.limits.MaxBytes > 0 && f.usage.Bytes+delta.Bytes > f.limits.MaxBytes {
+		return fs.NewErrQuotaExceeded(f.JoinCleanFile(filePath), "bytes", f.limits.MaxBytes)
+	}
+	if delta.Files > 0 && f.limits.MaxFiles > 0 && f.usage.Files+delta.Files > f.limits.MaxFiles {
+		return fs.NewErrQuotaExceeded(f.JoinCleanFile(filePath), "files", f.limits.MaxFiles)
+	}
+	f.usage = f.usage.add(delta)
+	return nil
+}
+
+// settle corrects the usage after an operation
+// by the difference of the actual usage change of filePaths
+// compared to before and the reserved usage
+func (f *QuotaFileSystem) settle(before, reserved Usage, filePaths ...string) {
+	var after Usage
+	for _, filePath := range filePaths {
+		after = after.add(f.fileUsage(filePath))
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.usage = f.usage.add(after.sub(before).sub(reserved))
+}
+
+func (f *QuotaFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *QuotaFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.Inner().ReadableWritable()
+}
+
+func (f *QuotaFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.Prefix(), Prefix), nil
+}
+
+func (f *QuotaFileSystem) Name() string {
+	return "quota limited " + f.Inner().Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *QuotaFileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+func (f *QuotaFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.Inner().Stat(filePath)
+}
+
+func (f *QuotaFileSystem) Exists(filePath string) bool {
+	return f.checkOpen() == nil && f.InnerFile(filePath).Exists()
+}
+
+func (f *QuotaFileSystem) IsHidden(filePath string) bool {
+	return f.Inner().IsHidden(filePath)
+}
+
+func (f *QuotaFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.Inner().IsSymbolicLink(filePath)
+}
+
+func (f *QuotaFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().ListDirInfo(ctx, dirPath, func(innerInfo *fs.FileInfo) error {
+		info := *innerInfo
+		info.File = f.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns)
+}
+
+func (f *QuotaFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.Inner().MakeDir(dirPath, perm)
+}
+
+func (f *QuotaFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.InnerFile(filePath).ReadAllContext(ctx)
+}
+
+func (f *QuotaFileSystem) OpenReader(filePath string) (fs.ReadCloser, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	return f.Inner().OpenReader(filePath)
+}
+
+func (f *QuotaFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	before := f.fileUsage(filePath)
+	delta := Usage{Bytes: int64(len(data)) - before.Bytes, Files: 1 - before.Files}
+	if err := f.reserve(filePath, delta); err != nil {
+		return err
+	}
+	defer f.settle(before, delta, filePath)
+	return f.InnerFile(filePath).WriteAllContext(ctx, data, perm...)
+}
+
+func (f *QuotaFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	before := f.fileUsage(filePath)
+	delta := Usage{Bytes: int64(len(data)), Files: 1 - before.Files}
+	if err := f.reserve(filePath, delta); err != nil {
+		return err
+	}
+	defer f.settle(before, delta, filePath)
+	return f.InnerFile(filePath).Append(ctx, data, perm...)
+}
+
+func (f *QuotaFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	before := f.fileUsage(filePath)
+	delta := Usage{Files: 1 - before.Files}
+	if err := f.reserve(filePath, delta); err != nil {
+		return err
+	}
+	defer f.settle(before, delta, filePath)
+	return f.InnerFile(filePath).Touch(perm...)
+}
+
+func (f *QuotaFileSystem) Truncate(filePath string, size int64) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	before := f.fileUsage(filePath)
+	delta := Usage{Bytes: size - before.Bytes}
+	if err := f.reserve(filePath, delta); err != nil {
+		return err
+	}
+	defer f.settle(before, delta, filePath)
+	return f.InnerFile(filePath).Truncate(size)
+}
+
+func (f *QuotaFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	before := f.fileUsage(filePath)
+	// OpenWriter truncates existing files
+	delta := Usage{Bytes: -before.Bytes, Files: 1 - before.Files}
+	if err := f.reserve(filePath, delta); err != nil {
+		return nil, err
+	}
+	writer, err := f.Inner().OpenWriter(filePath, perm)
+	if err != nil {
+		f.settle(before, delta, filePath)
+		return nil, err
+	}
+	return &quotaWriter{WriteCloser: writer, quota: quota{f: f, path: filePath, before: before, reserved: delta}}, nil
+}
+
+func (f *QuotaFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	before := f.fileUsage(filePath)
+	delta := Usage{Files: 1 - before.Files}
+	if err := f.reserve(filePath, delta); err != nil {
+		return nil, err
+	}
+	readWriter, err := f.Inner().OpenReadWriter(filePath, perm)
+	if err != nil {
+		f.settle(before, delta, filePath)
+		return nil, err
+	}
+	return &quotaReadWriter{ReadWriteSeekCloser: readWriter, quota: quota{f: f, path: filePath, before: before, reserved: delta}}, nil
+}
+
+func (f *QuotaFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	src := f.fileUsage(srcFile)
+	before := f.fileUsage(destFile)
+	delta := Usage{Bytes: src.Bytes - before.Bytes, Files: 1 - before.Files}
+	if err := f.reserve(destFile, delta); err != nil {
+		return err
+	}
+	defer f.settle(before, delta, destFile)
+	return fs.CopyFileBuf(ctx, f.InnerFile(srcFile), f.InnerFile(destFile), buf)
+}
+
+func (f *QuotaFileSystem) Rename(filePath string, newName string) (string, error) {
+	if err := f.checkOpen(); err != nil {
+		return "", err
+	}
+	dir, _ := f.Inner().SplitDirAndName(filePath)
+	destPath := f.Inner().JoinCleanPath(dir, newName)
+	// Renaming can replace an existing file
+	before := f.fileUsage(filePath).add(f.fileUsage(destPath))
+	defer f.settle(before, Usage{}, filePath, destPath)
+	renamed, err := f.InnerFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return renamed.Path(), nil
+}
+
+func (f *QuotaFileSystem) Move(filePath string, destPath string) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	// Moving can replace an existing file
+	before := f.fileUsage(filePath).add(f.fileUsage(destPath))
+	defer f.settle(before, Usage{}, filePath, destPath)
+	return f.InnerFile(filePath).MoveTo(f.InnerFile(destPath))
+}
+
+func (f *QuotaFileSystem) Remove(filePath string) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	before := f.fileUsage(filePath)
+	defer f.settle(before, Usage{}, filePath)
+	return f.Inner().Remove(filePath)
+}
+
+// Close unregisters the file system,
+// the inner file system is not closed.
+func (f *QuotaFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// quota reserves the bytes of writes
+// and settles the usage of a file when closed
+type quota struct {
+	f        *QuotaFileSystem
+	path     string
+	before   Usage
+	mtx      sync.Mutex
+	reserved Usage
+	closed   bool
+}
+
+func (q *quota) reserveWrite(n int) error {
+	delta := Usage{Bytes: int64(n)}
+	if err := q.f.reserve(q.path, delta); err != nil {
+		return err
+	}
+	q.mtx.Lock()
+	q.reserved = q.reserved.add(delta)
+	q.mtx.Unlock()
+	return nil
+}
+
+func (q *quota) settle() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		q.f.settle(q.before, q.reserved, q.path)
+	}
+}
+
+type quotaWriter struct {
+	fs.WriteCloser
+	quota
+}
+
+func (w *quotaWriter) Write(p []byte) (int, error) {
+	if err := w.reserveWrite(len(p)); err != nil {
+		return 0, err
+	}
+	return w.WriteCloser.Write(p)
+}
+
+func (w *quotaWriter) Close() error {
+	defer w.settle()
+	return w.WriteCloser.Close()
+}
+
+// quotaReadWriter reserves the bytes of all writes
+// because it can't know if they overwrite existing data,
+// the usage is corrected when closed
+type quotaReadWriter struct {
+	fs.ReadWriteSeekCloser
+	quota
+}
+
+func (rw *quotaReadWriter) Write(p []byte) (int, error) {
+	if err := rw.reserveWrite(len(p)); err != nil {
+		return 0, err
+	}
+	return rw.ReadWriteSeekCloser.Write(p)
+}
+
+func (rw *quotaReadWriter) Close() error {
+	defer rw.settle()
+	return rw.ReadWriteSeekCloser.Close()
+}
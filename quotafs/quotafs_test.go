@@ -0,0 +1,76 @@
+package quotafs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func requireQuotaExceeded(t *testing.T, err error, quota string) {
+	t.Helper()
+	var errQuota fs.ErrQuotaExceeded
+	require.True(t, errors.As(err, &errQuota), "ErrQuotaExceeded expected, got %v", err)
+	require.Equal(t, quota, errQuota.Quota())
+}
+
+func TestQuotaFileSystem(t *testing.T) {
+	baseDir := fs.File(t.TempDir())
+	usage, err := ScanUsage(context.Background(), baseDir)
+	require.NoError(t, err)
+	quotaFS, err := New(fs.Local, Limits{MaxBytes: 10, MaxFiles: 2}, usage)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = quotaFS.Close() })
+	dir := quotaFS.JoinCleanFile(baseDir.LocalPath())
+	require.True(t, fs.IsRegistered(quotaFS))
+	require.Equal(t, Usage{}, quotaFS.Usage())
+
+	a := dir.Join("a.txt")
+	require.NoError(t, a.WriteAllString("12345"))
+	require.Equal(t, Usage{Bytes: 5, Files: 1}, quotaFS.Usage())
+
+	// Overwriting only counts the difference
+	require.NoError(t, a.WriteAllString("1234567"))
+	require.Equal(t, Usage{Bytes: 7, Files: 1}, quotaFS.Usage())
+
+	err = a.AppendString(context.Background(), "abcd")
+	requireQuotaExceeded(t, err, "bytes")
+	content, err := a.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "1234567", content)
+	require.Equal(t, Usage{Bytes: 7, Files: 1}, quotaFS.Usage())
+
+	require.NoError(t, dir.Join("b.txt").Touch())
+	err = dir.Join("c.txt").Touch()
+	requireQuotaExceeded(t, err, "files")
+	require.False(t, dir.Join("c.txt").Exists())
+	require.Equal(t, Usage{Bytes: 7, Files: 2}, quotaFS.Usage())
+
+	// Writers are rejected when a write exceeds the quota
+	writer, err := dir.Join("b.txt").OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("abc"))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("def"))
+	requireQuotaExceeded(t, err, "bytes")
+	require.NoError(t, writer.Close())
+	require.Equal(t, Usage{Bytes: 10, Files: 2}, quotaFS.Usage())
+
+	// Freeing space allows writes again
+	require.NoError(t, a.Remove())
+	require.Equal(t, Usage{Bytes: 3, Files: 1}, quotaFS.Usage())
+	require.NoError(t, dir.Join("c.txt").WriteAllString("1234567"))
+	require.NoError(t, dir.Join("c.txt").Truncate(2))
+	require.Equal(t, Usage{Bytes: 5, Files: 2}, quotaFS.Usage())
+
+	// Moving over an existing file frees its space
+	require.NoError(t, dir.Join("c.txt").MoveTo(dir.Join("b.txt")))
+	require.Equal(t, Usage{Bytes: 2, Files: 1}, quotaFS.Usage())
+
+	usage, err = ScanUsage(context.Background(), dir)
+	require.NoError(t, err)
+	require.Equal(t, usage, quotaFS.Usage())
+}
@@ -0,0 +1,108 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReadFirst returns the first n bytes of the file
+// or all bytes if the file is smaller than n.
+// Uses range reads if the file system implements ReadRangeFileSystem,
+// else only the first n bytes of the file are read.
+// Useful for previews and content sniffing of remote files.
+func (file File) ReadFirst(ctx context.Context, n int) ([]byte, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative number of bytes to read: %d", n)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	fileSystem, path := file.ParseRawURI()
+	if fs, ok := fileSystem.(ReadRangeFileSystem); ok {
+		return fs.ReadRange(ctx, path, 0, int64(n))
+	}
+	r, err := fileSystem.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data := make([]byte, n)
+	n, err = io.ReadFull(r, data)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// ReadLast returns the last n bytes of the file
+// or all bytes if the file is smaller than n.
+// Uses range reads if the file system implements ReadRangeFileSystem,
+// else seeks to the last n bytes if the file reader implements io.Seeker,
+// else the whole file is streamed without keeping more than n bytes in memory.
+// Useful for log file previews of remote files.
+func (file File) ReadLast(ctx context.Context, n int) ([]byte, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative number of bytes to read: %d", n)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if n == 0 {
+		return []byte{}, nil
+	}
+	fileSystem, path := file.ParseRawURI()
+	if fs, ok := fileSystem.(ReadRangeFileSystem); ok {
+		return fs.ReadRange(ctx, path, -int64(n), 0)
+	}
+	r, err := fileSystem.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if seeker, ok := r.(io.Seeker); ok {
+		info, err := r.Stat()
+		if err != nil {
+			return nil, err
+		}
+		_, err = seeker.Seek(max(info.Size()-int64(n), 0), io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		return ReadAllContext(ctx, r)
+	}
+	return readLast(ctx, r, n)
+}
+
+// readLast reads r until io.EOF and returns the last n bytes
+func readLast(ctx context.Context, r io.Reader, n int) ([]byte, error) {
+	// Read into the second half of a buffer with twice the size
+	// and move the last n bytes to the first half when it's full
+	buf := make([]byte, 2*n)
+	end := 0
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if end == len(buf) {
+			end = copy(buf, buf[end-n:end])
+		}
+		read, err := r.Read(buf[end:])
+		end += read
+		if errors.Is(err, io.EOF) {
+			return buf[max(end-n, 0):end], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_ReadFirst_ReadLast(t *testing.T) {
+	ctx := context.Background()
+	file := File(t.TempDir()).Join("file.txt")
+	require.NoError(t, file.WriteAllString("0123456789"))
+
+	for _, tt := range []struct {
+		n           int
+		first, last string
+	}{
+		{n: 0, first: "", last: ""},
+		{n: 3, first: "012", last: "789"},
+		{n: 10, first: "0123456789", last: "0123456789"},
+		{n: 100, first: "0123456789", last: "0123456789"},
+	} {
+		first, err := file.ReadFirst(ctx, tt.n)
+		require.NoError(t, err)
+		require.Equal(t, tt.first, string(first))
+
+		last, err := file.ReadLast(ctx, tt.n)
+		require.NoError(t, err)
+		require.Equal(t, tt.last, string(last))
+	}
+
+	_, err := file.ReadFirst(ctx, -1)
+	require.Error(t, err)
+	_, err = File(t.TempDir()).Join("missing").ReadLast(ctx, 1)
+	require.Error(t, err)
+}
+
+func TestReadLast(t *testing.T) {
+	ctx := context.Background()
+	str := "0123456789abcdefghijklmnopqrstuvwxyz"
+	for _, n := range []int{1, 2, 5, 7, 36, 50} {
+		// OneByteReader and HalfReader stress the buffer shifting
+		last, err := readLast(ctx, iotest.OneByteReader(strings.NewReader(str)), n)
+		require.NoError(t, err)
+		require.Equal(t, str[max(len(str)-n, 0):], string(last), "n=%d", n)
+
+		last, err = readLast(ctx, iotest.HalfReader(strings.NewReader(str)), n)
+		require.NoError(t, err)
+		require.Equal(t, str[max(len(str)-n, 0):], string(last), "n=%d", n)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 const PrefixSeparator = "://"
@@ -29,8 +30,67 @@ var (
 	registry       = make(map[string]*fsCount, 2)
 	registrySorted = make([]FileSystem, 0, 2)
 	registryMtx    sync.RWMutex
+
+	// registryIdx is rebuilt from registrySorted on every change
+	// so that ParseRawURI can read it without locking
+	registryIdx atomic.Pointer[registryIndex]
 )
 
+// registryIndex is an immutable index of the registered file systems
+// for resolving URIs without iterating over all of them.
+type registryIndex struct {
+	// byScheme holds the file systems by the scheme
+	// of their prefix including PrefixSeparator
+	// in descending order of their prefixes
+	byScheme map[string][]registryEntry
+	// noScheme holds file systems with prefixes
+	// without PrefixSeparator in descending order
+	noScheme []registryEntry
+}
+
+type registryEntry struct {
+	prefix    string
+	separator string
+	fs        FileSystem
+}
+
+// updateRegistryIndex has to be called with registryMtx locked
+// after every change of registrySorted.
+func updateRegistryIndex() {
+	idx := &registryIndex{byScheme: make(map[string][]registryEntry)}
+	// Iterate in reverse order of sorted registry
+	// so that longer prefixes come first
+	for i := len(registrySorted) - 1; i >= 0; i-- {
+		fs := registrySorted[i]
+		entry := registryEntry{prefix: fs.Prefix(), separator: fs.Separator(), fs: fs}
+		if scheme, ok := uriScheme(entry.prefix); ok {
+			idx.byScheme[scheme] = append(idx.byScheme[scheme], entry)
+		} else {
+			idx.noScheme = append(idx.noScheme, entry)
+		}
+	}
+	registryIdx.Store(idx)
+}
+
+// uriScheme returns the scheme of uri including PrefixSeparator
+func uriScheme(uri string) (scheme string, ok bool) {
+	i := strings.Index(uri, PrefixSeparator)
+	if i < 0 {
+		return "", false
+	}
+	return uri[:i+len(PrefixSeparator)], true
+}
+
+// matchRegistryEntry returns the first entry of entries matching uri
+func matchRegistryEntry(entries []registryEntry, uri string) (entry registryEntry, ok bool) {
+	for _, entry = range entries {
+		if hasPrefixAtBoundary(uri, entry.prefix, entry.separator) {
+			return entry, true
+		}
+	}
+	return registryEntry{}, false
+}
+
 func init() {
 	Register(Local)
 	Register(Invalid)
@@ -39,7 +99,29 @@ func init() {
 // Register adds a file system or increments its reference count
 // if it is already registered.
 // The function returns the reference file system's reference count.
+//
+// Register panics if a different file system is already
+// registered with the same prefix, use TryRegister
+// to handle such a collision as error.
+//
+// File systems may be registered with nested prefixes
+// like "sftp://" and "sftp://user@host",
+// URIs are resolved to the file system
+// with the longest matching prefix.
 func Register(fs FileSystem) int {
+	count, err := TryRegister(fs)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+// TryRegister adds a file system or increments its reference count
+// if it is already registered like Register, but returns an
+// ErrPrefixCollision instead of panicking if a different file system
+// is already registered with the same prefix.
+// The registry is not changed in case of an error.
+func TryRegister(fs FileSystem) (int, error) {
 	prefix := fs.Prefix()
 	if prefix == "" {
 		panic(fmt.Sprintf("file system with empty prefix: %#v", fs))
@@ -49,19 +131,25 @@ func Register(fs FileSystem) int {
 	defer registryMtx.Unlock()
 
 	if regFS, ok := registry[prefix]; ok {
+		if regFS.fs != fs {
+			return regFS.count, NewErrPrefixCollision(prefix, regFS.fs, fs)
+		}
 		regFS.count++
-		return regFS.count
+		return regFS.count, nil
 	}
 
 	registry[prefix] = &fsCount{fs, 1}
 	registrySorted = append(registrySorted, fs)
 	slices.SortFunc(registrySorted, func(a, b FileSystem) int { return cmp.Compare(a.Prefix(), b.Prefix()) })
-	return 1
+	updateRegistryIndex()
+	return 1, nil
 }
 
 // Unregister a file system decrements its reference count
 // and removes it when the reference count reaches 0.
 // If the file system is not registered, -1 is returned.
+// This is also the case if a different file system
+// is registered with the same prefix.
 func Unregister(fs FileSystem) int {
 	prefix := fs.Prefix()
 	if prefix == "" {
@@ -72,12 +160,14 @@ func Unregister(fs FileSystem) int {
 	defer registryMtx.Unlock()
 
 	regFS, ok := registry[prefix]
-	if !ok {
+	if !ok || regFS.fs != fs {
 		return -1
 	}
 	if regFS.count <= 1 {
 		delete(registry, prefix)
 		registrySorted = slices.DeleteFunc(registrySorted, func(f FileSystem) bool { return f == regFS.fs })
+		updateRegistryIndex()
+		clearPrefixSettings(prefix)
 		return 0
 	}
 
@@ -85,6 +175,53 @@ func Unregister(fs FileSystem) int {
 	return regFS.count
 }
 
+// Replace atomically swaps the registered file system oldFS
+// with newFS so that existing File values with the prefix
+// of oldFS are served by newFS from then on.
+// This can be used to replace the client of a file system
+// for rotated credentials or a different endpoint.
+//
+// newFS must have the same prefix as oldFS and takes over
+// its reference count. oldFS is not closed by Replace,
+// but closing it after the swap will not unregister newFS.
+// Settings bound to the prefix like the strict mode
+// are cleared and have to be set for newFS again.
+func Replace(oldFS, newFS FileSystem) error {
+	prefix := oldFS.Prefix()
+	if newFS.Prefix() != prefix {
+		return fmt.Errorf("can't replace file system with prefix %q by one with prefix %q", prefix, newFS.Prefix())
+	}
+
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+
+	regFS, ok := registry[prefix]
+	if !ok || regFS.fs != oldFS {
+		return fmt.Errorf("can't replace file system that is not registered: %s", oldFS)
+	}
+	regFS.fs = newFS
+	i := slices.Index(registrySorted, oldFS)
+	registrySorted[i] = newFS
+	updateRegistryIndex()
+	clearPrefixSettings(prefix)
+	return nil
+}
+
+// clearPrefixSettings removes the settings bound to prefix
+// so that they don't apply to another file system
+// registered later with the same prefix.
+func clearPrefixSettings(prefix string) {
+	strictFileSystems.Delete(prefix)
+
+	hiddenFuncsMtx.Lock()
+	delete(hiddenFuncs, prefix)
+	hiddenFuncsMtx.Unlock()
+
+	opLimitsMtx.Lock()
+	setOpLimit(prefix, opLimit{})
+	opLimitsMtx.Unlock()
+}
+
 // RegisteredFileSystems returns the registered file systems
 // sorted by their prefix.
 func RegisteredFileSystems() []FileSystem {
@@ -134,22 +271,50 @@ func GetFileSystem(uriParts ...string) FileSystem {
 
 // ParseRawURI returns a FileSystem for the passed URI and the path component within that file system.
 // Returns the local file system if no other file system could be identified.
+//
+// The URI is resolved without locking using an index of
+// the registered file systems by the scheme of their prefixes,
+// so only file systems with the scheme of the URI are compared.
 func ParseRawURI(uri string) (fs FileSystem, fsPath string) {
 	if uri == "" {
 		return Invalid, ""
 	}
-	registryMtx.RLock()
-	defer registryMtx.RUnlock()
+	idx := registryIdx.Load()
+	if idx == nil {
+		// Called during package initialization
+		return Local, uri
+	}
 
-	// Find fs with longest matching prefix
-	// by iterating in reverse order of sorted registry
-	for i := len(registrySorted) - 1; i >= 0; i-- {
-		fs = registrySorted[i]
-		if strings.HasPrefix(uri, fs.Prefix()) {
-			return fs, fs.CleanPathFromURI(uri)
+	// Find fs with longest matching prefix.
+	// The entries are in descending order of their prefixes
+	// and all prefixes matching uri are prefixes of each other,
+	// so the first matching one is the longest.
+	entry, ok := matchRegistryEntry(idx.noScheme, uri)
+	if scheme, hasScheme := uriScheme(uri); hasScheme {
+		schemeEntry, schemeOK := matchRegistryEntry(idx.byScheme[scheme], uri)
+		if schemeOK && (!ok || len(schemeEntry.prefix) > len(entry.prefix)) {
+			entry, ok = schemeEntry, true
 		}
 	}
+	if ok {
+		return entry.fs, entry.fs.CleanPathFromURI(uri)
+	}
 
 	// No file system found, assume uri is for the local file system
 	return Local, uri
 }
+
+// hasPrefixAtBoundary returns true if uri starts with prefix
+// and the prefix ends at a path boundary of uri,
+// so that the prefix "s3://bucket" matches "s3://bucket/file"
+// but not "s3://bucket2/file".
+func hasPrefixAtBoundary(uri, prefix, separator string) bool {
+	if !strings.HasPrefix(uri, prefix) {
+		return false
+	}
+	if len(uri) == len(prefix) || strings.HasSuffix(prefix, "/") || strings.HasSuffix(prefix, separator) {
+		return true
+	}
+	rest := uri[len(prefix):]
+	return strings.HasPrefix(rest, "/") || strings.HasPrefix(rest, separator)
+}
@@ -1,10 +1,14 @@
 package fs
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseRawURI(t *testing.T) {
@@ -44,3 +48,138 @@ func TestParseRawURI(t *testing.T) {
 	assert.Equal(t, longerTestFS, fs)
 	assert.Equal(t, "file", fsPath)
 }
+
+// prefixFileSystem is an InvalidFileSystem with an arbitrary prefix
+type prefixFileSystem struct {
+	InvalidFileSystem
+	prefix string
+}
+
+func (f *prefixFileSystem) Prefix() string { return f.prefix }
+
+func (f *prefixFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func TestRegister_PrefixCollision(t *testing.T) {
+	a := &prefixFileSystem{prefix: "collision://host"}
+	b := &prefixFileSystem{prefix: "collision://host"}
+
+	count, err := TryRegister(a)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	t.Cleanup(func() { Unregister(a) })
+
+	_, err = TryRegister(b)
+	var collision ErrPrefixCollision
+	require.True(t, errors.As(err, &collision), "ErrPrefixCollision")
+	require.Equal(t, "collision://host", collision.Prefix())
+	require.Same(t, a, collision.Registered())
+	require.Same(t, b, collision.Colliding())
+	require.Panics(t, func() { Register(b) }, "Register panics on collision")
+
+	require.Equal(t, -1, Unregister(b), "can't unregister colliding file system")
+	require.Same(t, a, GetFileSystemByPrefixOrNil("collision://host"))
+
+	count, err = TryRegister(a)
+	require.NoError(t, err)
+	require.Equal(t, 2, count, "registering the same file system again increments the count")
+	require.Equal(t, 1, Unregister(a))
+}
+
+func TestParseRawURI_NestedPrefixes(t *testing.T) {
+	scheme := &prefixFileSystem{prefix: "nested://"}
+	host := &prefixFileSystem{prefix: "nested://host"}
+	hostPort := &prefixFileSystem{prefix: "nested://host:2222"}
+	for _, f := range []FileSystem{scheme, host, hostPort} {
+		Register(f)
+	}
+	t.Cleanup(func() {
+		Unregister(scheme)
+		Unregister(host)
+		Unregister(hostPort)
+	})
+
+	fs, fsPath := ParseRawURI("nested://host/file")
+	assert.Same(t, host, fs)
+	assert.Equal(t, "/file", fsPath)
+
+	fs, fsPath = ParseRawURI("nested://host:2222/file")
+	assert.Same(t, hostPort, fs)
+	assert.Equal(t, "/file", fsPath)
+
+	fs, _ = ParseRawURI("nested://host")
+	assert.Same(t, host, fs)
+
+	// "nested://host" must not match another host
+	// that only starts with the same characters
+	fs, fsPath = ParseRawURI("nested://hostname/file")
+	assert.Same(t, scheme, fs)
+	assert.Equal(t, "hostname/file", fsPath)
+
+	fs, _ = ParseRawURI("nested://host:22/file")
+	assert.Same(t, scheme, fs)
+}
+
+func TestReplace(t *testing.T) {
+	oldFS := &prefixFileSystem{prefix: "replace://host"}
+	newFS := &prefixFileSystem{prefix: "replace://host"}
+	otherFS := &prefixFileSystem{prefix: "replace://other"}
+
+	require.Error(t, Replace(oldFS, newFS), "oldFS not registered")
+
+	Register(oldFS)
+	Register(oldFS)
+	t.Cleanup(func() {
+		Unregister(oldFS)
+		Unregister(newFS)
+	})
+
+	file := File("replace://host/file")
+	require.Same(t, oldFS, file.FileSystem())
+
+	require.Error(t, Replace(oldFS, otherFS), "different prefix")
+	require.NoError(t, Replace(oldFS, newFS))
+	require.Same(t, newFS, file.FileSystem())
+	require.Same(t, newFS, GetFileSystemByPrefixOrNil("replace://host"))
+
+	require.Equal(t, -1, Unregister(oldFS), "replaced file system is not registered")
+	require.Equal(t, 1, Unregister(newFS), "reference count was taken over")
+}
+
+func benchmarkParseRawURI(b *testing.B, uri string) {
+	// Register some file systems to have a realistic registry
+	for i := range 20 {
+		f := &prefixFileSystem{prefix: fmt.Sprintf("bench%d://host", i)}
+		Register(f)
+		b.Cleanup(func() { Unregister(f) })
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		ParseRawURI(uri)
+	}
+}
+
+func BenchmarkParseRawURI_Local(b *testing.B) {
+	benchmarkParseRawURI(b, "/home/user/file.txt")
+}
+
+func BenchmarkParseRawURI_Registered(b *testing.B) {
+	benchmarkParseRawURI(b, "bench10://host/dir/file.txt")
+}
+
+func BenchmarkParseRawURI_Parallel(b *testing.B) {
+	for i := range 20 {
+		f := &prefixFileSystem{prefix: fmt.Sprintf("bench%d://host", i)}
+		Register(f)
+		b.Cleanup(func() { Unregister(f) })
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ParseRawURI("bench10://host/dir/file.txt")
+		}
+	})
+}
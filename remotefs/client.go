@@ -0,0 +1,473 @@
+// Package remotefs implements a client and server pair
+// to access the files of another process over HTTP.
+//
+// A Handler exposes a directory of any registered file system,
+// for example in a sidecar process that holds cloud credentials,
+// and RemoteFileSystem mounts it as a local file system.
+// File contents and directory listings are streamed
+// without buffering them in memory.
+//
+// The protocol uses JSON for metadata and errors
+// and raw request and response bodies for file contents.
+// Authentication and TLS are left to the http.Client
+// passed to Dial and to middleware wrapping the Handler.
+package remotefs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of RemoteFileSystem URIs
+	Prefix = "remote://"
+
+	// Separator used in RemoteFileSystem paths
+	Separator = "/"
+)
+
+var (
+	// Make sure RemoteFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(RemoteFileSystem)
+	_ fs.AppendFileSystem   = new(RemoteFileSystem)
+	_ fs.TouchFileSystem    = new(RemoteFileSystem)
+	_ fs.TruncateFileSystem = new(RemoteFileSystem)
+	_ fs.CopyFileSystem     = new(RemoteFileSystem)
+	_ fs.RenameFileSystem   = new(RemoteFileSystem)
+	_ fs.MoveFileSystem     = new(RemoteFileSystem)
+)
+
+// RemoteFileSystem accesses the files served
+// by a Handler in another process.
+//
+// It is registered with the prefix "remote://" followed by a random ID,
+// so the served root directory is accessed as "remote://<id>/".
+type RemoteFileSystem struct {
+	prefix   string
+	baseURL  string
+	client   *http.Client
+	name     string
+	readable bool
+	writable bool
+	closed   atomic.Bool
+}
+
+// Dial connects to the Handler served at baseURL,
+// registers and returns a RemoteFileSystem for it.
+// If client is nil, then http.DefaultClient is used.
+func Dial(ctx context.Context, baseURL string, client *http.Client) (*RemoteFileSystem, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	f := &RemoteFileSystem{
+		prefix:  Prefix + fsimpl.RandomString(),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+	response, err := f.do(ctx, http.MethodGet, "info", "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't dial remote file system %s: %w", baseURL, err)
+	}
+	defer response.Body.Close()
+	var info infoResponse
+	err = json.NewDecoder(response.Body).Decode(&info)
+	if err != nil {
+		return nil, fmt.Errorf("can't dial remote file system %s: %w", baseURL, err)
+	}
+	f.name = info.Name
+	f.readable = info.Readable
+	f.writable = info.Writable
+	_, err = fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// BaseURL returns the URL of the Handler
+func (f *RemoteFileSystem) BaseURL() string {
+	return f.baseURL
+}
+
+// do sends a request for the operation op on filePath.
+// Non 2xx responses are returned as errors of the fs package.
+func (f *RemoteFileSystem) do(ctx context.Context, method, op, filePath string, query url.Values, body io.Reader) (*http.Response, error) {
+	if query == nil {
+		query = make(url.Values)
+	}
+	if filePath != "" {
+		query.Set("path", filePath)
+	}
+	u := f.baseURL + "/" + op
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return response, nil
+	}
+	defer response.Body.Close()
+	return nil, f.responseError(response, op, filePath)
+}
+
+func (f *RemoteFileSystem) responseError(response *http.Response, op, filePath string) error {
+	var body errorBody
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") ||
+		json.NewDecoder(response.Body).Decode(&body) != nil {
+		return fmt.Errorf("remote %s error: %s", op, response.Status)
+	}
+	return body.toError(f, f.File(filePath), op)
+}
+
+// call sends a POST request for an operation without response body
+func (f *RemoteFileSystem) call(ctx context.Context, op, filePath string, query url.Values, body io.Reader) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	response, err := f.do(ctx, http.MethodPost, op, filePath, query, body)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// remotePath returns the path relative to the served root directory
+func (f *RemoteFileSystem) remotePath(filePath string) string {
+	return strings.TrimPrefix(f.AbsPath(filePath), Separator)
+}
+
+func permQuery(perm []fs.Permissions) url.Values {
+	query := make(url.Values)
+	if len(perm) > 0 {
+		query.Set("perm", strconv.FormatUint(uint64(fs.JoinPermissions(perm, 0)), 8))
+	}
+	return query
+}
+
+func (f *RemoteFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *RemoteFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.readable, f.writable
+}
+
+func (f *RemoteFileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+func (f *RemoteFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *RemoteFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *RemoteFileSystem) Name() string {
+	return "remote " + f.name
+}
+
+// String implements the fmt.Stringer interface.
+func (f *RemoteFileSystem) String() string {
+	return fmt.Sprintf("%s with prefix %s at %s", f.Name(), f.prefix, f.baseURL)
+}
+
+func (f *RemoteFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *RemoteFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *RemoteFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *RemoteFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *RemoteFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *RemoteFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*RemoteFileSystem) Separator() string {
+	return Separator
+}
+
+func (*RemoteFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*RemoteFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (*RemoteFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (*RemoteFileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *RemoteFileSystem) stat(ctx context.Context, filePath string) (*fs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	response, err := f.do(ctx, http.MethodGet, "stat", f.remotePath(filePath), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	var info fileInfo
+	err = json.NewDecoder(response.Body).Decode(&info)
+	if err != nil {
+		return nil, err
+	}
+	return info.toFileInfo(f.File(filePath)), nil
+}
+
+func (f *RemoteFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	info, err := f.stat(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	return info.StdFileInfo(), nil
+}
+
+func (f *RemoteFileSystem) Exists(filePath string) bool {
+	_, err := f.stat(context.Background(), filePath)
+	return err == nil
+}
+
+func (f *RemoteFileSystem) IsHidden(filePath string) bool {
+	_, name := f.SplitDirAndName(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (f *RemoteFileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+// ListDirInfo streams the directory listing from the Handler,
+// callback is called for every entry as soon as it is received.
+func (f *RemoteFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	response, err := f.do(ctx, http.MethodGet, "list", f.remotePath(dirPath), url.Values{"pattern": patterns}, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry listEntry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return err
+		}
+		if entry.Error != nil {
+			return entry.Error.toError(f, f.File(dirPath), "ListDirInfo")
+		}
+		if entry.Info == nil {
+			continue
+		}
+		err = callback(entry.Info.toFileInfo(f.JoinCleanFile(dirPath, entry.Info.Name)))
+		if err != nil {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (f *RemoteFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.call(context.Background(), "mkdir", f.remotePath(dirPath), permQuery(perm), nil)
+}
+
+func (f *RemoteFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.call(context.Background(), "touch", f.remotePath(filePath), permQuery(perm), nil)
+}
+
+func (f *RemoteFileSystem) Truncate(filePath string, newSize int64) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	query := url.Values{"size": {strconv.FormatInt(newSize, 10)}}
+	return f.call(context.Background(), "truncate", f.remotePath(filePath), query, nil)
+}
+
+func (f *RemoteFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.call(ctx, "append", f.remotePath(filePath), permQuery(perm), bytes.NewReader(data))
+}
+
+// OpenReader streams the file content from the Handler.
+func (f *RemoteFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	info, err := f.stat(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	response, err := f.do(context.Background(), http.MethodGet, "read", f.remotePath(filePath), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{ReadCloser: response.Body, info: info.StdFileInfo()}, nil
+}
+
+// OpenWriter streams the written data to the Handler.
+// The file is complete on the remote side
+// when Close of the writer returned without error.
+func (f *RemoteFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	w := &writer{PipeWriter: pipeWriter, done: make(chan error, 1)}
+	go func() {
+		err := f.call(context.Background(), "write", f.remotePath(filePath), permQuery(perm), pipeReader)
+		// Unblock writes if the request failed before reading the whole body
+		pipeReader.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+func (f *RemoteFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	return nil, fs.NewErrUnsupported(f, "OpenReadWriter")
+}
+
+func (f *RemoteFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	query := url.Values{"dest": {f.remotePath(destFile)}}
+	return f.call(ctx, "copy", f.remotePath(srcFile), query, nil)
+}
+
+func (f *RemoteFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	query := url.Values{"newName": {newName}}
+	err := f.call(context.Background(), "rename", f.remotePath(filePath), query, nil)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(f.AbsPath(filePath)), newName), nil
+}
+
+func (f *RemoteFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	query := url.Values{"dest": {f.remotePath(destPath)}}
+	return f.call(context.Background(), "move", f.remotePath(filePath), query, nil)
+}
+
+func (f *RemoteFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.call(context.Background(), "remove", f.remotePath(filePath), nil, nil)
+}
+
+// Close unregisters the file system,
+// the remote file system is not closed.
+func (f *RemoteFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// reader implements iofs.File for a streamed response body
+type reader struct {
+	io.ReadCloser
+	info iofs.FileInfo
+}
+
+func (r *reader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+// writer streams written data as request body
+// and waits for the response on Close
+type writer struct {
+	*io.PipeWriter
+	done   chan error
+	closed bool
+	err    error
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	w.err = errors.Join(w.PipeWriter.Close(), <-w.done)
+	return w.err
+}
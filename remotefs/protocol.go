@@ -0,0 +1,132 @@
+package remotefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// infoResponse is returned by the /info endpoint
+type infoResponse struct {
+	Name     string `json:"name"`
+	Readable bool   `json:"readable"`
+	Writable bool   `json:"writable"`
+}
+
+// fileInfo is the JSON representation of a fs.FileInfo
+type fileInfo struct {
+	Name        string         `json:"name"`
+	IsDir       bool           `json:"isDir,omitempty"`
+	IsRegular   bool           `json:"isRegular,omitempty"`
+	IsHidden    bool           `json:"isHidden,omitempty"`
+	Size        int64          `json:"size"`
+	Modified    time.Time      `json:"modified"`
+	Permissions fs.Permissions `json:"permissions"`
+}
+
+func fileInfoFrom(info *fs.FileInfo) *fileInfo {
+	return &fileInfo{
+		Name:        info.Name,
+		IsDir:       info.IsDir,
+		IsRegular:   info.IsRegular,
+		IsHidden:    info.IsHidden,
+		Size:        info.Size,
+		Modified:    info.Modified,
+		Permissions: info.Permissions,
+	}
+}
+
+func (i *fileInfo) toFileInfo(file fs.File) *fs.FileInfo {
+	return &fs.FileInfo{
+		File:        file,
+		Name:        i.Name,
+		Exists:      true,
+		IsDir:       i.IsDir,
+		IsRegular:   i.IsRegular,
+		IsHidden:    i.IsHidden,
+		Size:        i.Size,
+		Modified:    i.Modified,
+		Permissions: i.Permissions,
+	}
+}
+
+// listEntry is a JSON line of the /list endpoint,
+// an error while listing is sent as last line
+type listEntry struct {
+	Info  *fileInfo  `json:"info,omitempty"`
+	Error *errorBody `json:"error,omitempty"`
+}
+
+// Error kinds that are mapped to the error types of the fs package
+const (
+	errKindDoesNotExist   = "does-not-exist"
+	errKindAlreadyExists  = "already-exists"
+	errKindPermission     = "permission"
+	errKindIsDirectory    = "is-directory"
+	errKindIsNotDirectory = "is-not-directory"
+	errKindUnsupported    = "unsupported"
+	errKindClosed         = "closed"
+	errKindOther          = "other"
+)
+
+// errorBody is the JSON response body of a failed request
+type errorBody struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func errorBodyFrom(err error) (body *errorBody, status int) {
+	body = &errorBody{Kind: errKindOther, Message: err.Error()}
+	status = http.StatusInternalServerError
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		body.Kind, status = errKindDoesNotExist, http.StatusNotFound
+	case errors.Is(err, os.ErrExist):
+		body.Kind, status = errKindAlreadyExists, http.StatusConflict
+	case errors.Is(err, os.ErrPermission), errors.Is(err, fs.ErrReadOnlyFileSystem):
+		body.Kind, status = errKindPermission, http.StatusForbidden
+	case errors.As(err, new(fs.ErrIsDirectory)):
+		body.Kind, status = errKindIsDirectory, http.StatusBadRequest
+	case errors.As(err, new(fs.ErrIsNotDirectory)):
+		body.Kind, status = errKindIsNotDirectory, http.StatusBadRequest
+	case errors.Is(err, errors.ErrUnsupported):
+		body.Kind, status = errKindUnsupported, http.StatusNotImplemented
+	case errors.Is(err, fs.ErrFileSystemClosed):
+		body.Kind, status = errKindClosed, http.StatusServiceUnavailable
+	}
+	return body, status
+}
+
+// toError returns an error of the fs package for file
+func (e *errorBody) toError(fileSystem fs.FileSystem, file fs.File, op string) error {
+	switch e.Kind {
+	case errKindDoesNotExist:
+		return fs.NewErrDoesNotExist(file)
+	case errKindAlreadyExists:
+		return fs.NewErrAlreadyExists(file)
+	case errKindPermission:
+		return fs.NewErrPermission(file)
+	case errKindIsDirectory:
+		return fs.NewErrIsDirectory(file)
+	case errKindIsNotDirectory:
+		return fs.NewErrIsNotDirectory(file)
+	case errKindUnsupported:
+		return fs.NewErrUnsupported(fileSystem, op)
+	case errKindClosed:
+		return fmt.Errorf("remote %w: %s", fs.ErrFileSystemClosed, e.Message)
+	default:
+		return fmt.Errorf("remote %s error: %s", op, e.Message)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	body, status := errorBodyFrom(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
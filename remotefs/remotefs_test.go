@@ -0,0 +1,163 @@
+package remotefs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestRemoteFileSystem(t *testing.T) {
+	root := fs.File(t.TempDir())
+	handler, err := NewHandler(root, false)
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	remote, err := Dial(context.Background(), server.URL, server.Client())
+	require.NoError(t, err)
+	t.Cleanup(func() { remote.Close() })
+	ctx := context.Background()
+
+	readable, writable := remote.ReadableWritable()
+	require.True(t, readable)
+	require.True(t, writable)
+
+	dir := remote.JoinCleanFile("dir")
+	require.NoError(t, dir.MakeDir())
+	require.True(t, root.Join("dir").IsDir())
+	require.True(t, dir.IsDir())
+
+	file := dir.Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+	require.NoError(t, file.Append(ctx, []byte(" World")))
+	content, err := root.Join("dir", "file.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", content)
+
+	content, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", content)
+	require.Equal(t, int64(11), file.Size())
+
+	// Streamed writer
+	writer, err := dir.Join("stream.txt").OpenWriter()
+	require.NoError(t, err)
+	for range 100 {
+		_, err = io.WriteString(writer, "0123456789")
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.Equal(t, int64(1000), root.Join("dir", "stream.txt").Size())
+
+	// Streamed reader
+	reader, err := dir.Join("stream.txt").OpenReader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, strings.Repeat("0123456789", 100), string(data))
+
+	var names []string
+	err = dir.ListDirInfo(func(info *fs.FileInfo) error {
+		require.Equal(t, dir.Join(info.Name), info.File)
+		names = append(names, info.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"file.txt", "stream.txt"}, names)
+
+	names = nil
+	err = dir.ListDirInfo(func(info *fs.FileInfo) error {
+		names = append(names, info.Name)
+		return nil
+	}, "s*")
+	require.NoError(t, err)
+	require.Equal(t, []string{"stream.txt"}, names)
+
+	renamed, err := file.Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, dir.Join("renamed.txt"), renamed)
+	require.True(t, root.Join("dir", "renamed.txt").Exists())
+
+	require.NoError(t, fs.CopyFile(ctx, renamed, remote.JoinCleanFile("copy.txt")))
+	require.True(t, root.Join("copy.txt").Exists())
+
+	require.NoError(t, remote.JoinCleanFile("copy.txt").Truncate(5))
+	content, err = root.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", content)
+
+	require.NoError(t, remote.JoinCleanFile("copy.txt").Remove())
+	require.False(t, root.Join("copy.txt").Exists())
+}
+
+func TestRemoteFileSystem_Errors(t *testing.T) {
+	handler, err := NewHandler(fs.File(t.TempDir()), false)
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	remote, err := Dial(context.Background(), server.URL, server.Client())
+	require.NoError(t, err)
+	t.Cleanup(func() { remote.Close() })
+
+	_, err = remote.JoinCleanFile("missing.txt").ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorAs(t, err, new(fs.ErrDoesNotExist))
+
+	require.ErrorIs(t, remote.JoinCleanFile("missing").ListDirInfo(func(*fs.FileInfo) error { return nil }), os.ErrNotExist)
+
+	_, err = remote.JoinCleanFile("file.txt").OpenReadWriter()
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestRemoteFileSystem_OutsideRoot(t *testing.T) {
+	root := fs.File(t.TempDir())
+	handler, err := NewHandler(root, false)
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	remote, err := Dial(context.Background(), server.URL, server.Client())
+	require.NoError(t, err)
+	t.Cleanup(func() { remote.Close() })
+
+	outside := root.Dir().Join(root.Name() + "-outside.txt")
+	t.Cleanup(func() { outside.Remove() })
+
+	// Send the unclean path directly to the handler
+	err = remote.call(context.Background(), "write", "../"+outside.Name(), nil, strings.NewReader("x"))
+	require.NoError(t, err)
+	require.False(t, outside.Exists())
+	require.True(t, root.Join(outside.Name()).Exists())
+}
+
+func TestRemoteFileSystem_ReadOnly(t *testing.T) {
+	root := fs.File(t.TempDir())
+	handler, err := NewHandler(root, true)
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	remote, err := Dial(context.Background(), server.URL, server.Client())
+	require.NoError(t, err)
+	t.Cleanup(func() { remote.Close() })
+
+	require.NoError(t, root.Join("file.txt").WriteAllString("Hello"))
+
+	_, writable := remote.ReadableWritable()
+	require.False(t, writable)
+
+	content, err := remote.JoinCleanFile("file.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", content)
+
+	err = remote.JoinCleanFile("file.txt").WriteAllString("changed")
+	require.ErrorIs(t, err, os.ErrPermission)
+	require.ErrorIs(t, remote.JoinCleanFile("file.txt").Remove(), os.ErrPermission)
+	require.True(t, root.Join("file.txt").Exists())
+}
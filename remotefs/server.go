@@ -0,0 +1,203 @@
+package remotefs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Handler is an http.Handler that serves the files
+// of a root directory to RemoteFileSystem clients.
+//
+// Requests are not authenticated, wrap the Handler with an
+// authenticating middleware if it is reachable by untrusted clients.
+// Mount it under a path with http.StripPrefix.
+type Handler struct {
+	root     fs.File
+	readOnly bool
+}
+
+var _ http.Handler = new(Handler)
+
+// NewHandler returns a Handler serving the files of root,
+// for example fileSystem.RootDir() to serve a whole file system.
+// Clients can't access files outside of root.
+// If readOnly is true, then all modifying requests are rejected.
+func NewHandler(root fs.File, readOnly bool) (*Handler, error) {
+	if err := root.CheckIsDir(); err != nil {
+		return nil, err
+	}
+	return &Handler{root: root, readOnly: readOnly}, nil
+}
+
+// file returns the file for the slash separated path
+// relative to the root directory of the handler
+func (h *Handler) file(filePath string) fs.File {
+	filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	if filePath == "" {
+		return h.root
+	}
+	return h.root.Join(strings.Split(filePath, "/")...)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	op := strings.Trim(r.URL.Path, "/")
+	query := r.URL.Query()
+	file := h.file(query.Get("path"))
+	ctx := r.Context()
+
+	switch op {
+	case "info":
+		readable, writable := h.root.FileSystem().ReadableWritable()
+		writeJSON(w, &infoResponse{
+			Name:     h.root.FileSystem().Name(),
+			Readable: readable,
+			Writable: writable && !h.readOnly,
+		})
+		return
+	case "stat", "list", "read":
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+	default:
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if h.readOnly {
+			writeError(w, fs.ErrReadOnlyFileSystem)
+			return
+		}
+	}
+
+	perm, err := permissionsFromQuery(query.Get("perm"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch op {
+	case "stat":
+		info, err := file.InfoContext(ctx)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, fileInfoFrom(info))
+	case "list":
+		h.serveList(ctx, w, file, query["pattern"])
+	case "read":
+		h.serveRead(w, file)
+	case "write":
+		err = h.write(file, r.Body, perm)
+	case "append":
+		var data []byte
+		data, err = io.ReadAll(r.Body)
+		if err == nil {
+			err = file.Append(ctx, data, perm...)
+		}
+	case "mkdir":
+		err = file.MakeDir(perm...)
+	case "touch":
+		err = file.Touch(perm...)
+	case "truncate":
+		var size int64
+		size, err = strconv.ParseInt(query.Get("size"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = file.Truncate(size)
+	case "remove":
+		err = file.Remove()
+	case "rename":
+		newName := query.Get("newName")
+		if newName == "" || strings.ContainsAny(newName, `/\`) || newName == "." || newName == ".." {
+			http.Error(w, fmt.Sprintf("invalid new name %q", newName), http.StatusBadRequest)
+			return
+		}
+		_, err = file.Rename(newName)
+	case "move":
+		err = file.MoveTo(h.file(query.Get("dest")))
+	case "copy":
+		err = fs.CopyFile(ctx, file, h.file(query.Get("dest")))
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if op != "stat" && op != "list" && op != "read" {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) serveList(ctx context.Context, w http.ResponseWriter, dir fs.File, patterns []string) {
+	if err := dir.CheckIsDir(); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/jsonl")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	err := dir.ListDirInfoContext(ctx, func(info *fs.FileInfo) error {
+		err := enc.Encode(listEntry{Info: fileInfoFrom(info)})
+		if err == nil && flusher != nil {
+			flusher.Flush()
+		}
+		return err
+	}, patterns...)
+	if err != nil {
+		body, _ := errorBodyFrom(err)
+		_ = enc.Encode(listEntry{Error: body})
+	}
+}
+
+func (h *Handler) serveRead(w http.ResponseWriter, file fs.File) {
+	reader, err := file.OpenReader()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer reader.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if size := file.Size(); size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	_, _ = io.Copy(w, reader)
+}
+
+func (h *Handler) write(file fs.File, body io.Reader, perm []fs.Permissions) (err error) {
+	writer, err := file.OpenWriter(perm...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, body)
+	return errors.Join(err, writer.Close())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func permissionsFromQuery(s string) ([]fs.Permissions, error) {
+	if s == "" {
+		return nil, nil
+	}
+	perm, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permissions %q: %w", s, err)
+	}
+	return []fs.Permissions{fs.Permissions(perm)}, nil
+}
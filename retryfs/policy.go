@@ -0,0 +1,170 @@
+package retryfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// RetryPolicy configures how failed operations are retried.
+// Zero values are replaced by the values of DefaultRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts
+	// including the first one
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry
+	InitialDelay time.Duration
+	// MaxDelay limits the exponentially growing delay between retries
+	MaxDelay time.Duration
+	// Multiplier is applied to the delay after every retry
+	Multiplier float64
+	// Jitter is the fraction of the delay that is randomized
+	// to prevent many clients from retrying at the same time.
+	// Use a negative value to disable jitter.
+	Jitter float64
+	// IsTransient decides if an error is worth retrying,
+	// IsTransientError is used if nil.
+	IsTransient func(error) bool
+}
+
+// DefaultRetryPolicy is used for zero values of a RetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  4,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     5 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+	IsTransient:  IsTransientError,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultRetryPolicy.InitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = DefaultRetryPolicy.Jitter
+	}
+	if p.IsTransient == nil {
+		p.IsTransient = DefaultRetryPolicy.IsTransient
+	}
+	return p
+}
+
+// delay returns the delay before the retry after attempt
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := float64(p.InitialDelay)
+	for range attempt - 1 {
+		d *= p.Multiplier
+		if d >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	d = min(d, float64(p.MaxDelay))
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1) //#nosec G404
+	}
+	return time.Duration(d)
+}
+
+// Do calls op until it succeeds, returns an error
+// that is not transient, the maximum number of attempts is reached,
+// or ctx is canceled.
+// The delay between attempts grows exponentially.
+func (p RetryPolicy) Do(ctx context.Context, op func() error) error {
+	p = p.withDefaults()
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.MaxAttempts || !p.IsTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// IsTransientError returns true for errors that are likely
+// to go away when the operation is retried:
+// network timeouts, connection resets, unexpected EOFs,
+// HTTP 5xx status codes, HTTP 429 too many requests,
+// and throttling errors of cloud APIs.
+//
+// HTTP status codes are detected by errors in the chain
+// that implement a StatusCode() int or HTTPStatusCode() int method,
+// throttling errors by an ErrorCode() string method.
+//
+// Errors like fs.ErrDoesNotExist, fs.ErrPermission,
+// and context errors are never transient.
+func IsTransientError(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, new(fs.ErrDoesNotExist)),
+		errors.As(err, new(fs.ErrAlreadyExists)),
+		errors.As(err, new(fs.ErrPermission)),
+		errors.Is(err, os.ErrNotExist),
+		errors.Is(err, os.ErrExist),
+		errors.Is(err, os.ErrPermission),
+		errors.Is(err, errors.ErrUnsupported):
+		return false
+	case errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.ETIMEDOUT),
+		errors.Is(err, os.ErrDeadlineExceeded):
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) {
+		return isTransientStatus(statusErr.StatusCode())
+	}
+	var httpStatusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpStatusErr) {
+		return isTransientStatus(httpStatusErr.HTTPStatusCode())
+	}
+	var codeErr interface{ ErrorCode() string }
+	if errors.As(err, &codeErr) {
+		switch codeErr.ErrorCode() {
+		case "Throttling", "ThrottlingException", "ThrottledException",
+			"RequestThrottled", "RequestThrottledException",
+			"TooManyRequests", "TooManyRequestsException",
+			"RequestLimitExceeded", "SlowDown", "ServerBusy",
+			"InternalError", "ServiceUnavailable":
+			return true
+		}
+	}
+	return false
+}
+
+func isTransientStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+}
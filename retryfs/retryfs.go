@@ -0,0 +1,325 @@
+// Package retryfs implements a file system that retries
+// transient failures of another file system.
+package retryfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of RetryFileSystem URIs
+	Prefix = "retry://"
+)
+
+var (
+	// Make sure RetryFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(RetryFileSystem)
+	_ fs.ReadAllFileSystem  = new(RetryFileSystem)
+	_ fs.WriteAllFileSystem = new(RetryFileSystem)
+	_ fs.AppendFileSystem   = new(RetryFileSystem)
+	_ fs.TouchFileSystem    = new(RetryFileSystem)
+	_ fs.TruncateFileSystem = new(RetryFileSystem)
+	_ fs.CopyFileSystem     = new(RetryFileSystem)
+	_ fs.RenameFileSystem   = new(RetryFileSystem)
+	_ fs.MoveFileSystem     = new(RetryFileSystem)
+	_ fs.ExistsFileSystem   = new(RetryFileSystem)
+)
+
+// RetryFileSystem wraps a file system and retries operations
+// that failed with a transient error like a connection reset,
+// an HTTP 5xx status, or a throttling error of a cloud API
+// with exponential backoff according to a RetryPolicy.
+//
+// It is registered with the prefix "retry://" followed by a random ID.
+//
+// Only operations that can be repeated safely are retried:
+//   - Append, Rename, and Move are not retried
+//   - ListDirInfo is only retried before the first callback call
+//   - OpenReader, OpenWriter, and OpenReadWriter only retry
+//     opening the file, not reading or writing it
+//   - Remove treats a non existing file and MakeDir an already existing
+//     directory as success if a previous attempt failed transiently
+type RetryFileSystem struct {
+	fs.WrapperBase
+
+	policy RetryPolicy
+	closed atomic.Bool
+}
+
+// Wrap returns a new RetryFileSystem for inner using policy
+// and registers it.
+// Zero values of policy are replaced by the values of DefaultRetryPolicy.
+func Wrap(inner fs.FileSystem, policy RetryPolicy) (*RetryFileSystem, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("nil inner file system")
+	}
+	f := &RetryFileSystem{
+		WrapperBase: fs.NewWrapperBase(Prefix+fsimpl.RandomString(), inner),
+		policy:      policy.withDefaults(),
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Policy returns the RetryPolicy with defaults applied
+func (f *RetryFileSystem) Policy() RetryPolicy {
+	return f.policy
+}
+
+func (f *RetryFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+// retry calls op according to the policy if the file system is open
+func (f *RetryFileSystem) retry(ctx context.Context, op func() error) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.policy.Do(ctx, op)
+}
+
+// retryValue calls op according to the policy if the file system is open
+// and returns the value of the last attempt
+func retryValue[T any](ctx context.Context, f *RetryFileSystem, op func() (T, error)) (val T, err error) {
+	err = f.retry(ctx, func() (e error) {
+		val, e = op()
+		return e
+	})
+	return val, err
+}
+
+func (f *RetryFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.Inner().ReadableWritable()
+}
+
+func (f *RetryFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.Prefix(), Prefix), nil
+}
+
+func (f *RetryFileSystem) Name() string {
+	return "retrying " + f.Inner().Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *RetryFileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+func (f *RetryFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	return retryValue(context.Background(), f, func() (iofs.FileInfo, error) {
+		return f.Inner().Stat(filePath)
+	})
+}
+
+// Exists uses Stat to be able to retry transient errors
+func (f *RetryFileSystem) Exists(filePath string) bool {
+	_, err := f.Stat(filePath)
+	return err == nil
+}
+
+func (f *RetryFileSystem) IsHidden(filePath string) bool {
+	return f.Inner().IsHidden(filePath)
+}
+
+func (f *RetryFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.Inner().IsSymbolicLink(filePath)
+}
+
+func (f *RetryFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	var (
+		called       bool
+		afterCallErr error
+	)
+	err := f.retry(ctx, func() error {
+		err := f.Inner().ListDirInfo(ctx, dirPath, func(innerInfo *fs.FileInfo) error {
+			called = true
+			info := *innerInfo
+			info.File = f.JoinCleanFile(dirPath, info.Name)
+			return callback(&info)
+		}, patterns)
+		if called {
+			// Don't retry to not call callback again for the same files
+			afterCallErr = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return afterCallErr
+}
+
+func (f *RetryFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	failed := false
+	return f.retry(context.Background(), func() error {
+		err := f.Inner().MakeDir(dirPath, perm)
+		if failed && errors.As(err, new(fs.ErrAlreadyExists)) {
+			// Created by the failed attempt
+			return nil
+		}
+		failed = err != nil
+		return err
+	})
+}
+
+func (f *RetryFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	return retryValue(ctx, f, func() ([]byte, error) {
+		return f.InnerFile(filePath).ReadAllContext(ctx)
+	})
+}
+
+func (f *RetryFileSystem) OpenReader(filePath string) (fs.ReadCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	return retryValue(context.Background(), f, func() (fs.ReadCloser, error) {
+		return f.Inner().OpenReader(filePath)
+	})
+}
+
+func (f *RetryFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.retry(ctx, func() error {
+		return f.InnerFile(filePath).WriteAllContext(ctx, data, perm...)
+	})
+}
+
+// Append is not retried because a failed attempt
+// could have appended some of the data.
+func (f *RetryFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).Append(ctx, data, perm...)
+}
+
+func (f *RetryFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.retry(context.Background(), func() error {
+		return f.InnerFile(filePath).Touch(perm...)
+	})
+}
+
+func (f *RetryFileSystem) Truncate(filePath string, size int64) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.retry(context.Background(), func() error {
+		return f.InnerFile(filePath).Truncate(size)
+	})
+}
+
+func (f *RetryFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	return retryValue(context.Background(), f, func() (fs.WriteCloser, error) {
+		return f.Inner().OpenWriter(filePath, perm)
+	})
+}
+
+func (f *RetryFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	return retryValue(context.Background(), f, func() (fs.ReadWriteSeekCloser, error) {
+		return f.Inner().OpenReadWriter(filePath, perm)
+	})
+}
+
+func (f *RetryFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	return f.retry(ctx, func() error {
+		return fs.CopyFileBuf(ctx, f.InnerFile(srcFile), f.InnerFile(destFile), buf)
+	})
+}
+
+// Rename is not retried because a failed attempt
+// could have renamed the file.
+func (f *RetryFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return "", err
+	}
+	renamed, err := f.InnerFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return renamed.Path(), nil
+}
+
+// Move is not retried because a failed attempt
+// could have moved some of the files.
+func (f *RetryFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.InnerFile(filePath).MoveTo(f.InnerFile(destPath))
+}
+
+func (f *RetryFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	failed := false
+	return f.retry(context.Background(), func() error {
+		err := f.Inner().Remove(filePath)
+		if failed && errors.As(err, new(fs.ErrDoesNotExist)) {
+			// Removed by the failed attempt
+			return nil
+		}
+		failed = err != nil
+		return err
+	})
+}
+
+// Close unregisters the file system,
+// the inner file system is not closed.
+func (f *RetryFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
@@ -0,0 +1,136 @@
+package retryfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+// flakyFileSystem is the local file system
+// with Stat and ReadAll failing failures times
+type flakyFileSystem struct {
+	*fs.LocalFileSystem
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyFileSystem) Prefix() string { return "flaky://" }
+
+func (f *flakyFileSystem) URL(cleanPath string) string { return f.Prefix() + cleanPath }
+
+func (f *flakyFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.Prefix())
+}
+
+func (f *flakyFileSystem) fail() error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func (f *flakyFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.LocalFileSystem.Stat(filePath)
+}
+
+func (f *flakyFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.LocalFileSystem.ReadAll(ctx, filePath)
+}
+
+func TestRetryFileSystem(t *testing.T) {
+	flaky := &flakyFileSystem{LocalFileSystem: fs.Local, failures: 2, err: syscall.ECONNRESET}
+	fs.Register(flaky)
+	t.Cleanup(func() { fs.Unregister(flaky) })
+	retryFS, err := Wrap(flaky, RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = retryFS.Close() })
+	dir := retryFS.JoinCleanFile(t.TempDir())
+
+	require.True(t, fs.IsRegistered(retryFS))
+	file := dir.Join("file.txt")
+	require.NoError(t, fs.File(file.Path()).WriteAllString("Hello"))
+
+	// Two transient failures are retried
+	data, err := file.ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", string(data))
+	require.Equal(t, 3, flaky.calls)
+
+	// Three transient failures exceed MaxAttempts
+	flaky.calls, flaky.failures = 0, 3
+	_, err = file.Stat()
+	require.ErrorIs(t, err, syscall.ECONNRESET)
+	require.Equal(t, 3, flaky.calls)
+
+	// Permanent errors are not retried
+	flaky.calls, flaky.failures = 0, 0
+	_, err = dir.Join("missing.txt").ReadAll()
+	require.True(t, errors.As(err, new(fs.ErrDoesNotExist)))
+	require.Equal(t, 1, flaky.calls)
+
+	// Canceling the context stops retrying
+	flaky.calls, flaky.failures = 0, 10
+	slowFS, err := Wrap(flaky, RetryPolicy{MaxAttempts: 10, InitialDelay: time.Hour})
+	require.NoError(t, err)
+	defer slowFS.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = slowFS.ReadAll(ctx, file.Path())
+	require.ErrorIs(t, err, syscall.ECONNRESET)
+	require.Equal(t, 1, flaky.calls)
+
+	require.NoError(t, retryFS.Close())
+	_, err = retryFS.Stat(file.Path())
+	require.ErrorIs(t, err, fs.ErrFileSystemClosed)
+}
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("HTTP status %d", int(e)) }
+func (e statusError) StatusCode() int { return int(e) }
+
+type codeError string
+
+func (e codeError) Error() string     { return string(e) }
+func (e codeError) ErrorCode() string { return string(e) }
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("other"), want: false},
+		{err: context.Canceled, want: false},
+		{err: fs.NewErrDoesNotExist(fs.File("/missing")), want: false},
+		{err: fs.NewErrPermission(fs.File("/secret")), want: false},
+		{err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{err: syscall.EPIPE, want: true},
+		{err: statusError(http.StatusServiceUnavailable), want: true},
+		{err: fmt.Errorf("wrapped: %w", statusError(http.StatusTooManyRequests)), want: true},
+		{err: statusError(http.StatusBadRequest), want: false},
+		{err: codeError("SlowDown"), want: true},
+		{err: codeError("NoSuchKey"), want: false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, IsTransientError(tt.err), "%v", tt.err)
+	}
+}
@@ -0,0 +1,448 @@
+package s3fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	fs "github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+// AccountPrefix of AccountFileSystem URLs
+const AccountPrefix = "s3all://"
+
+var (
+	// Make sure AccountFileSystem implements the following interfaces
+	_ fs.FileSystem            = new(AccountFileSystem)
+	_ fs.ReadAllFileSystem     = new(AccountFileSystem)
+	_ fs.WriteAllFileSystem    = new(AccountFileSystem)
+	_ fs.ReadRangeFileSystem   = new(AccountFileSystem)
+	_ fs.HashFileSystem        = new(AccountFileSystem)
+	_ fs.ContentTypeFileSystem = new(AccountFileSystem)
+	_ fs.CopyFileSystem        = new(AccountFileSystem)
+	_ fs.PresignFileSystem     = new(AccountFileSystem)
+)
+
+// AccountFileSystem is a meta file system for all buckets
+// of an S3 account with the prefix "s3all://".
+//
+// The root directory lists the buckets of the account as directories
+// and the path "s3all://<bucket>/<key>" is routed to the same object
+// as "s3://<bucket>/<key>" of a bucket file system.
+// The bucket file systems are created on first access
+// and are not registered, so buckets can be browsed
+// without registering them first.
+//
+// Buckets can't be created or removed with the file system,
+// MakeDir and Remove at the root return an ErrUnsupported error.
+type AccountFileSystem struct {
+	client   *s3.Client
+	readOnly bool
+
+	stageWritesInTempFile bool
+
+	bucketsMtx sync.Mutex
+	buckets    map[string]*fileSystem
+}
+
+// NewAccountAndRegister returns a new registered AccountFileSystem
+// for all buckets that can be listed with client.
+//
+// Passed options are applied to a copy of client
+// to use S3-compatible services, see Options.
+func NewAccountAndRegister(client *s3.Client, readOnly bool, options ...Options) *AccountFileSystem {
+	f := &AccountFileSystem{
+		client:   clientWithOptions(client, options),
+		readOnly: readOnly,
+		buckets:  make(map[string]*fileSystem),
+
+		stageWritesInTempFile: stageWritesInTempFile(options),
+	}
+	fs.Register(f)
+	return f
+}
+
+// bucketFileSystem returns the unregistered file system for bucketName
+func (f *AccountFileSystem) bucketFileSystem(bucketName string) *fileSystem {
+	f.bucketsMtx.Lock()
+	defer f.bucketsMtx.Unlock()
+
+	bucketFS, ok := f.buckets[bucketName]
+	if !ok {
+		bucketFS = &fileSystem{
+			client:     f.client,
+			bucketName: bucketName,
+			prefix:     Prefix + bucketName,
+			readOnly:   f.readOnly,
+
+			stageWritesInTempFile: f.stageWritesInTempFile,
+		}
+		f.buckets[bucketName] = bucketFS
+	}
+	return bucketFS
+}
+
+// route returns the bucket file system and its path for filePath.
+// bucketFS is nil for the root directory.
+func (f *AccountFileSystem) route(filePath string) (bucketFS *fileSystem, bucketPath string, err error) {
+	if filePath == "" {
+		return nil, "", fs.ErrEmptyPath
+	}
+	if f.client == nil {
+		return nil, "", fs.ErrFileSystemClosed
+	}
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(f.AbsPath(filePath), Separator), Separator)
+	if bucketName == "" {
+		return nil, "", nil
+	}
+	return f.bucketFileSystem(bucketName), Separator + key, nil
+}
+
+// accountFileInfo returns a copy of info
+// with its File in the account file system
+func (f *AccountFileSystem) accountFileInfo(bucketFS *fileSystem, info *fs.FileInfo) *fs.FileInfo {
+	accountInfo := *info
+	accountInfo.File = f.JoinCleanFile(bucketFS.bucketName, strings.TrimPrefix(string(info.File), bucketFS.prefix))
+	return &accountInfo
+}
+
+func (f *AccountFileSystem) ReadableWritable() (readable, writable bool) {
+	return true, !f.readOnly
+}
+
+func (f *AccountFileSystem) RootDir() fs.File {
+	return fs.File(AccountPrefix + Separator)
+}
+
+func (f *AccountFileSystem) ID() (string, error) {
+	return "s3all", nil
+}
+
+func (f *AccountFileSystem) Prefix() string {
+	return AccountPrefix
+}
+
+func (f *AccountFileSystem) Name() string {
+	return "S3 file system for all buckets"
+}
+
+func (f *AccountFileSystem) String() string {
+	return f.Name() + " with prefix " + AccountPrefix
+}
+
+func (f *AccountFileSystem) URL(cleanPath string) string {
+	return AccountPrefix + cleanPath
+}
+
+func (f *AccountFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, AccountPrefix)
+}
+
+func (f *AccountFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(AccountPrefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *AccountFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, AccountPrefix, Separator)
+}
+
+func (f *AccountFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, AccountPrefix, Separator)
+}
+
+func (f *AccountFileSystem) Separator() string {
+	return Separator
+}
+
+func (f *AccountFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (f *AccountFileSystem) AbsPath(filePath string) string {
+	if path.IsAbs(filePath) {
+		return filePath
+	}
+	return Separator + filePath
+}
+
+func (f *AccountFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*AccountFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+// VolumeName returns the bucket name of filePath
+func (f *AccountFileSystem) VolumeName(filePath string) string {
+	bucketName, _, _ := strings.Cut(strings.TrimPrefix(f.AbsPath(filePath), Separator), Separator)
+	return bucketName
+}
+
+// bucketInfo returns the info of a bucket as directory
+func (f *AccountFileSystem) bucketInfo(bucketName string, created time.Time) *fs.FileInfo {
+	return &fs.FileInfo{
+		File:        f.JoinCleanFile(bucketName),
+		Name:        bucketName,
+		Exists:      true,
+		IsDir:       true,
+		IsHidden:    strings.HasPrefix(bucketName, "."),
+		Modified:    created,
+		Permissions: DefaultDirPermissions,
+	}
+}
+
+func (f *AccountFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	bucketFS, bucketPath, err := f.route(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if bucketFS == nil {
+		return f.bucketInfo(Separator, time.Time{}).StdFileInfo(), nil
+	}
+	if bucketPath == Separator {
+		_, err = f.client.HeadBucket(
+			context.Background(),
+			&s3.HeadBucketInput{Bucket: &bucketFS.bucketName},
+		)
+		if err != nil {
+			var notFound *types.NotFound
+			if errors.As(err, &notFound) {
+				return nil, fs.NewErrDoesNotExist(f.File(filePath))
+			}
+			return nil, err
+		}
+		return f.bucketInfo(bucketFS.bucketName, time.Time{}).StdFileInfo(), nil
+	}
+	return bucketFS.Stat(bucketPath)
+}
+
+func (f *AccountFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *AccountFileSystem) Exists(filePath string) bool {
+	_, err := f.Stat(filePath)
+	return err == nil
+}
+
+func (f *AccountFileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (f *AccountFileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+// ListDirInfo lists the buckets of the account as directories
+// for the root directory, else the directory of the bucket.
+func (f *AccountFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	bucketFS, bucketPath, err := f.route(dirPath)
+	if err != nil {
+		return err
+	}
+	if bucketFS != nil {
+		return bucketFS.ListDirInfo(ctx, bucketPath, func(info *fs.FileInfo) error {
+			return callback(f.accountFileInfo(bucketFS, info))
+		}, patterns)
+	}
+
+	paginator := s3.NewListBucketsPaginator(f.client, &s3.ListBucketsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, bucket := range page.Buckets {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if bucket.Name == nil {
+				continue
+			}
+			match, err := f.MatchAnyPattern(*bucket.Name, patterns)
+			if err != nil {
+				return err
+			}
+			if !match {
+				continue
+			}
+			var created time.Time
+			if bucket.CreationDate != nil {
+				created = *bucket.CreationDate
+			}
+			err = callback(f.bucketInfo(*bucket.Name, created))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// routeObject routes filePath to an object of a bucket
+// and returns an ErrUnsupported error for buckets
+// and the root directory
+func (f *AccountFileSystem) routeObject(filePath, op string) (bucketFS *fileSystem, bucketPath string, err error) {
+	bucketFS, bucketPath, err = f.route(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if bucketFS == nil || bucketPath == Separator {
+		return nil, "", fs.NewErrUnsupported(f, op+" of buckets")
+	}
+	return bucketFS, bucketPath, nil
+}
+
+func (f *AccountFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "Touch")
+	if err != nil {
+		return err
+	}
+	return bucketFS.Touch(bucketPath, perm)
+}
+
+func (f *AccountFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	bucketFS, bucketPath, err := f.routeObject(dirPath, "MakeDir")
+	if err != nil {
+		return err
+	}
+	return bucketFS.MakeDir(bucketPath, perm)
+}
+
+func (f *AccountFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "ReadAll")
+	if err != nil {
+		return nil, err
+	}
+	return bucketFS.ReadAll(ctx, bucketPath)
+}
+
+func (f *AccountFileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "ReadRange")
+	if err != nil {
+		return nil, err
+	}
+	return bucketFS.ReadRange(ctx, bucketPath, offset, length)
+}
+
+func (f *AccountFileSystem) Hash(ctx context.Context, filePath string, algo fs.HashAlgorithm) (string, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "Hash")
+	if err != nil {
+		return "", err
+	}
+	return bucketFS.Hash(ctx, bucketPath, algo)
+}
+
+func (f *AccountFileSystem) ContentType(ctx context.Context, filePath string) (string, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "ContentType")
+	if err != nil {
+		return "", err
+	}
+	return bucketFS.ContentType(ctx, bucketPath)
+}
+
+func (f *AccountFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "WriteAll")
+	if err != nil {
+		return err
+	}
+	return bucketFS.WriteAll(ctx, bucketPath, data, perm)
+}
+
+func (f *AccountFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "OpenReader")
+	if err != nil {
+		return nil, err
+	}
+	return bucketFS.OpenReader(bucketPath)
+}
+
+func (f *AccountFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "OpenWriter")
+	if err != nil {
+		return nil, err
+	}
+	return bucketFS.OpenWriter(bucketPath, perm)
+}
+
+func (f *AccountFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "OpenReadWriter")
+	if err != nil {
+		return nil, err
+	}
+	return bucketFS.OpenReadWriter(bucketPath, perm)
+}
+
+// CopyFile copies within a bucket with a server side copy
+// and streams the data between different buckets.
+func (f *AccountFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	srcFS, srcPath, err := f.routeObject(srcFile, "CopyFile")
+	if err != nil {
+		return err
+	}
+	destFS, destPath, err := f.routeObject(destFile, "CopyFile")
+	if err != nil {
+		return err
+	}
+	if srcFS == destFS {
+		return srcFS.CopyFile(ctx, srcPath, destPath, buf)
+	}
+	r, err := srcFS.OpenReader(srcPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := destFS.OpenWriter(destPath, nil)
+	if err != nil {
+		return err
+	}
+	if len(*buf) == 0 {
+		*buf = make([]byte, 1024*1024*4)
+	}
+	_, err = io.CopyBuffer(w, r, *buf)
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("can't copy %s to %s: %w", f.File(srcFile), f.File(destFile), err)
+	}
+	return w.Close()
+}
+
+func (f *AccountFileSystem) PresignURL(ctx context.Context, filePath string, expires time.Duration) (string, error) {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "PresignURL")
+	if err != nil {
+		return "", err
+	}
+	return bucketFS.PresignURL(ctx, bucketPath, expires)
+}
+
+func (f *AccountFileSystem) Remove(filePath string) error {
+	bucketFS, bucketPath, err := f.routeObject(filePath, "Remove")
+	if err != nil {
+		return err
+	}
+	return bucketFS.Remove(bucketPath)
+}
+
+func (f *AccountFileSystem) Close() error {
+	if f.client == nil {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	f.client = nil
+	f.bucketsMtx.Lock()
+	clear(f.buckets)
+	f.bucketsMtx.Unlock()
+	return nil
+}
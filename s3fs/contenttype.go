@@ -0,0 +1,49 @@
+package s3fs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+// Make sure S3FileSystem implements fs.ContentTypeFileSystem
+var _ fs.ContentTypeFileSystem = new(fileSystem)
+
+// defaultContentType is set by S3 for objects
+// that were uploaded without a Content-Type
+const defaultContentType = "binary/octet-stream"
+
+// ContentType returns the Content-Type of an object without downloading it.
+// An ErrUnsupported error is returned for objects
+// that were uploaded without a Content-Type
+// and for keys without an object.
+func (s *fileSystem) ContentType(ctx context.Context, filePath string) (string, error) {
+	if filePath == "" {
+		return "", fs.ErrEmptyPath
+	}
+	out, err := s.client.HeadObject(
+		ctx,
+		&s3.HeadObjectInput{
+			Bucket: &s.bucketName,
+			Key:    &filePath,
+		},
+	)
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			// Could be a directory implied by the prefix of other objects
+			return "", fs.NewErrUnsupported(s, "ContentType")
+		}
+		return "", err
+	}
+	contentType := aws.ToString(out.ContentType)
+	if contentType == "" || contentType == defaultContentType {
+		return "", fs.NewErrUnsupported(s, "ContentType")
+	}
+	return contentType, nil
+}
@@ -3,14 +3,30 @@ package s3fs
 import (
 	iofs "io/fs"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 var _ iofs.FileInfo = new(fileInfo)
 
+// ObjectInfo is the S3 specific data of an object
+// returned as io/fs.FileInfo.Sys() and as fs.FileInfo.Extra.
+// Get it with:
+//
+//	objectInfo, ok := fs.FileInfoExtra[*s3fs.ObjectInfo](info)
+type ObjectInfo struct {
+	ETag         string
+	VersionID    string
+	StorageClass string
+	ContentType  string
+}
+
 type fileInfo struct {
-	name string
-	size int64
-	time time.Time
+	name  string
+	size  int64
+	time  time.Time
+	extra *ObjectInfo
 }
 
 func (i *fileInfo) Name() string        { return i.name } // base name of the file
@@ -18,4 +34,20 @@ func (i *fileInfo) Size() int64         { return i.size } // length in bytes for
 func (i *fileInfo) Mode() iofs.FileMode { return 0600 }   // file mode bits
 func (i *fileInfo) ModTime() time.Time  { return i.time } // modification time
 func (i *fileInfo) IsDir() bool         { return false }  // abbreviation for Mode().IsDir()
-func (i *fileInfo) Sys() any            { return nil }    // underlying data source (can return nil)
+
+// Sys returns the *ObjectInfo of the file or nil
+func (i *fileInfo) Sys() any {
+	if i.extra == nil {
+		return nil
+	}
+	return i.extra
+}
+
+func newObjectInfo(eTag, versionID *string, storageClass types.StorageClass, contentType *string) *ObjectInfo {
+	return &ObjectInfo{
+		ETag:         aws.ToString(eTag),
+		VersionID:    aws.ToString(versionID),
+		StorageClass: string(storageClass),
+		ContentType:  aws.ToString(contentType),
+	}
+}
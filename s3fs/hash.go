@@ -0,0 +1,80 @@
+package s3fs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+// Make sure S3FileSystem implements fs.HashFileSystem
+var _ fs.HashFileSystem = new(fileSystem)
+
+// Hash returns the hash of an object without downloading it.
+//
+// HashMD5 is taken from the ETag of objects that were
+// not uploaded in multiple parts.
+// HashSHA1, HashSHA256, and HashCRC32C are only known
+// if the object was uploaded with a checksum of that algorithm.
+// An ErrUnsupported error is returned for unknown hashes.
+func (s *fileSystem) Hash(ctx context.Context, filePath string, algo fs.HashAlgorithm) (string, error) {
+	if filePath == "" {
+		return "", fs.ErrEmptyPath
+	}
+	switch algo {
+	case fs.HashMD5, fs.HashSHA1, fs.HashSHA256, fs.HashCRC32C:
+	default:
+		return "", fs.NewErrUnsupported(s, algo.String()+" hash of "+filePath)
+	}
+	input := &s3.HeadObjectInput{
+		Bucket: &s.bucketName,
+		Key:    &filePath,
+	}
+	if algo != fs.HashMD5 {
+		input.ChecksumMode = types.ChecksumModeEnabled
+	}
+	out, err := s.client.HeadObject(ctx, input)
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return "", fs.NewErrDoesNotExist(fs.File(s.prefix + filePath))
+		}
+		return "", err
+	}
+
+	var checksum *string
+	switch algo {
+	case fs.HashMD5:
+		// The ETag of objects uploaded in multiple parts
+		// or encrypted with SSE-KMS is not the MD5 of the content,
+		// multipart ETags have a "-<number of parts>" suffix
+		eTag := strings.Trim(aws.ToString(out.ETag), `"`)
+		if len(eTag) == 32 && out.ServerSideEncryption != types.ServerSideEncryptionAwsKms {
+			if _, err := hex.DecodeString(eTag); err == nil {
+				return eTag, nil
+			}
+		}
+	case fs.HashSHA1:
+		checksum = out.ChecksumSHA1
+	case fs.HashSHA256:
+		checksum = out.ChecksumSHA256
+	case fs.HashCRC32C:
+		checksum = out.ChecksumCRC32C
+	}
+	// Checksums of multipart uploads are checksums
+	// of the part checksums with a "-<number of parts>" suffix
+	if c := aws.ToString(checksum); c != "" && !strings.Contains(c, "-") {
+		sum, err := base64.StdEncoding.DecodeString(c)
+		if err == nil {
+			return hex.EncodeToString(sum), nil
+		}
+	}
+	return "", fs.NewErrUnsupported(s, algo.String()+" hash of "+filePath)
+}
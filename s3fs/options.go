@@ -0,0 +1,88 @@
+package s3fs
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Options for S3-compatible services like MinIO,
+// Backblaze B2, Wasabi, or Ceph RGW,
+// and for the file system itself.
+// Zero values keep the settings of the S3 client
+// and the defaults of the file system.
+type Options struct {
+	// EndpointURL of the S3-compatible service,
+	// for example "https://s3.us-west-004.backblazeb2.com"
+	// or "http://localhost:9000" for a local MinIO.
+	EndpointURL string
+
+	// UsePathStyle addresses buckets as part of the URL path
+	// instead of the host name which is required by
+	// most self-hosted services like MinIO and Ceph RGW.
+	UsePathStyle bool
+
+	// Region of the bucket.
+	// Many S3-compatible services accept any region
+	// but require it to be set for request signing.
+	Region string
+
+	// InsecureSkipVerify disables the verification
+	// of TLS certificates of the endpoint.
+	// Only use for testing with self-signed certificates.
+	InsecureSkipVerify bool
+
+	// StageWritesInTempFile configures OpenWriter to stage written data
+	// in a local temp file instead of a memory buffer.
+	// The temp file is uploaded on Close and then removed
+	// which bounds the memory usage regardless of the upload size.
+	StageWritesInTempFile bool
+}
+
+// apply sets the options for a S3 client
+func (o *Options) apply(clientOptions *s3.Options) {
+	if o.EndpointURL != "" {
+		clientOptions.BaseEndpoint = aws.String(o.EndpointURL)
+	}
+	if o.UsePathStyle {
+		clientOptions.UsePathStyle = true
+	}
+	if o.Region != "" {
+		clientOptions.Region = o.Region
+	}
+	if o.InsecureSkipVerify {
+		clientOptions.HTTPClient = awshttp.NewBuildableClient().WithTransportOptions(func(transport *http.Transport) {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.InsecureSkipVerify = true
+		})
+	}
+}
+
+// stageWritesInTempFile returns if any of options
+// has StageWritesInTempFile set
+func stageWritesInTempFile(options []Options) bool {
+	for i := range options {
+		if options[i].StageWritesInTempFile {
+			return true
+		}
+	}
+	return false
+}
+
+// clientWithOptions returns a copy of client with options applied
+// or client if there are no options.
+func clientWithOptions(client *s3.Client, options []Options) *s3.Client {
+	if len(options) == 0 {
+		return client
+	}
+	optFns := make([]func(*s3.Options), len(options))
+	for i := range options {
+		optFns[i] = options[i].apply
+	}
+	return s3.New(client.Options(), optFns...)
+}
@@ -0,0 +1,34 @@
+package s3fs
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+// Make sure S3FileSystem implements fs.PresignFileSystem
+var _ fs.PresignFileSystem = new(fileSystem)
+
+// PresignURL returns a presigned URL for a GET request of the object.
+// The URL is signed with the credentials of the S3 client
+// and can't be valid longer than these credentials.
+func (s *fileSystem) PresignURL(ctx context.Context, filePath string, expires time.Duration) (string, error) {
+	if filePath == "" {
+		return "", fs.ErrEmptyPath
+	}
+	request, err := s3.NewPresignClient(s.client).PresignGetObject(
+		ctx,
+		&s3.GetObjectInput{
+			Bucket: &s.bucketName,
+			Key:    &filePath,
+		},
+		s3.WithPresignExpires(expires),
+	)
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
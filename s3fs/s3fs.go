@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	iofs "io/fs"
 	"path"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 
 	fs "github.com/ungerik/go-fs"
 	"github.com/ungerik/go-fs/fsimpl"
@@ -42,16 +44,23 @@ type fileSystem struct {
 	bucketName string
 	prefix     string
 	readOnly   bool
+
+	stageWritesInTempFile bool
 }
 
 // NewAndRegister initializes a new S3 instance + session and returns a fs.FileSystem
 // implementation that contains the required settings to work with an S3 bucket.
-func NewAndRegister(client *s3.Client, bucketName string, readOnly bool) fs.FileSystem {
+//
+// Passed options are applied to a copy of client
+// to use S3-compatible services, see Options.
+func NewAndRegister(client *s3.Client, bucketName string, readOnly bool, options ...Options) fs.FileSystem {
 	s3fs := &fileSystem{
-		client:     client,
+		client:     clientWithOptions(client, options),
 		bucketName: bucketName,
 		prefix:     Prefix + bucketName,
 		readOnly:   readOnly,
+
+		stageWritesInTempFile: stageWritesInTempFile(options),
 	}
 	fs.Register(s3fs)
 	return s3fs
@@ -66,6 +75,23 @@ func NewLoadDefaultConfig(ctx context.Context, bucketName string, readOnly bool)
 	return NewAndRegister(client, bucketName, readOnly), nil
 }
 
+// NewWithEndpoint loads the default AWS config for the credentials
+// and returns a registered fs.FileSystem for a bucket
+// of the S3-compatible service at endpointURL.
+// The EndpointURL of options is overwritten by endpointURL.
+func NewWithEndpoint(ctx context.Context, endpointURL, bucketName string, readOnly bool, options Options) (fs.FileSystem, error) {
+	var loadOptions []func(*config.LoadOptions) error
+	if options.Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(options.Region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, err
+	}
+	options.EndpointURL = endpointURL
+	return NewAndRegister(s3.NewFromConfig(cfg), bucketName, readOnly, options), nil
+}
+
 func (s *fileSystem) ReadableWritable() (readable, writable bool) {
 	return true, !s.readOnly
 }
@@ -156,9 +182,10 @@ func (s *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
 		return nil, err
 	}
 	return &fileInfo{
-		name: path.Base(filePath),
-		size: *out.ContentLength,
-		time: *out.LastModified,
+		name:  path.Base(filePath),
+		size:  *out.ContentLength,
+		time:  *out.LastModified,
+		extra: newObjectInfo(out.ETag, out.VersionId, out.StorageClass, out.ContentType),
 	}, nil
 }
 
@@ -310,11 +337,7 @@ func (s *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, erro
 		},
 	)
 	if err != nil {
-		var notFound *types.NotFound
-		if errors.As(err, &notFound) {
-			return nil, fs.NewErrDoesNotExist(fs.File(s.prefix + filePath))
-		}
-		return nil, err
+		return nil, s.getObjectErr(ctx, filePath, err)
 	}
 	defer out.Body.Close()
 
@@ -329,6 +352,73 @@ func (s *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, erro
 	return data, nil
 }
 
+// getObjectErr maps an error from GetObject to the errors of the fs package.
+// A missing object is reported as fs.ErrIsDirectory if filePath
+// is the prefix of other objects, meaning it is a directory.
+func (s *fileSystem) getObjectErr(ctx context.Context, filePath string, err error) error {
+	var (
+		notFound  *types.NotFound
+		noSuchKey *types.NoSuchKey
+	)
+	if !errors.As(err, &notFound) && !errors.As(err, &noSuchKey) {
+		return err
+	}
+	dirPrefix := strings.TrimSuffix(filePath, Separator) + Separator
+	maxKeys := int32(1)
+	out, listErr := s.client.ListObjectsV2(
+		ctx,
+		&s3.ListObjectsV2Input{
+			Bucket:  &s.bucketName,
+			Prefix:  &dirPrefix,
+			MaxKeys: &maxKeys,
+		},
+	)
+	if listErr == nil && len(out.Contents) > 0 {
+		return fs.NewErrIsDirectory(fs.File(s.prefix + filePath))
+	}
+	return fs.NewErrDoesNotExist(fs.File(s.prefix + filePath))
+}
+
+// ReadRange reads a byte range of a file with a HTTP range request.
+// A negative offset reads the last -offset bytes of the file.
+func (s *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	var byteRange string
+	switch {
+	case offset < 0:
+		byteRange = fmt.Sprintf("bytes=%d", offset)
+	case length <= 0:
+		return []byte{}, nil
+	default:
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	out, err := s.client.GetObject(
+		ctx,
+		&s3.GetObjectInput{
+			Bucket: &s.bucketName,
+			Key:    &filePath,
+			Range:  &byteRange,
+		},
+	)
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, fs.NewErrDoesNotExist(fs.File(s.prefix + filePath))
+		}
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+			// Range starts after the end of the file
+			return []byte{}, nil
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return fs.ReadAllContext(ctx, out.Body)
+}
+
 func (s *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
 	if filePath == "" {
 		return fs.ErrEmptyPath
@@ -359,11 +449,7 @@ func (s *fileSystem) OpenReader(filePath string) (iofs.File, error) {
 		},
 	)
 	if err != nil {
-		var notFound *types.NotFound
-		if errors.As(err, &notFound) {
-			return nil, fs.NewErrDoesNotExist(fs.File(s.prefix + filePath))
-		}
-		return nil, err
+		return nil, s.getObjectErr(context.Background(), filePath, err)
 	}
 	defer out.Body.Close()
 
@@ -377,9 +463,10 @@ func (s *fileSystem) OpenReader(filePath string) (iofs.File, error) {
 	}
 
 	info := &fileInfo{
-		name: path.Base(filePath),
-		size: *out.ContentLength,
-		time: *out.LastModified,
+		name:  path.Base(filePath),
+		size:  *out.ContentLength,
+		time:  *out.LastModified,
+		extra: newObjectInfo(out.ETag, out.VersionId, out.StorageClass, out.ContentType),
 	}
 	return fsimpl.NewReadonlyFileBuffer(data, info), nil
 }
@@ -391,6 +478,20 @@ func (s *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.Writ
 	if s.readOnly {
 		return nil, fs.ErrReadOnlyFileSystem
 	}
+	if s.stageWritesInTempFile {
+		return fsimpl.NewTempFileWriter(func(r io.ReadSeeker, size int64) error {
+			_, err := s.client.PutObject(
+				context.Background(),
+				&s3.PutObjectInput{
+					Bucket:        &s.bucketName,
+					Key:           &filePath,
+					Body:          r,
+					ContentLength: &size,
+				},
+			)
+			return err
+		})
+	}
 	var fileBuffer *fsimpl.FileBuffer
 	fileBuffer = fsimpl.NewFileBufferWithClose(nil, func() error {
 		return s.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
@@ -0,0 +1,125 @@
+package s3fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+// Make sure S3FileSystem implements fs.XAttrFileSystem
+var _ fs.XAttrFileSystem = new(fileSystem)
+
+// metadata returns the user-defined metadata of an object
+func (s *fileSystem) metadata(ctx context.Context, filePath string) (*s3.HeadObjectOutput, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	out, err := s.client.HeadObject(
+		ctx,
+		&s3.HeadObjectInput{
+			Bucket: &s.bucketName,
+			Key:    &filePath,
+		},
+	)
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, fs.NewErrDoesNotExist(fs.File(s.prefix + filePath))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// replaceMetadata replaces the user-defined metadata of an object
+// by copying the object onto itself because S3 objects are immutable.
+// The other metadata like the content type is kept.
+func (s *fileSystem) replaceMetadata(ctx context.Context, filePath string, head *s3.HeadObjectOutput, metadata map[string]string) error {
+	if s.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	copySource := s.bucketName + "/" + filePath
+	_, err := s.client.CopyObject(
+		ctx,
+		&s3.CopyObjectInput{
+			Bucket:             &s.bucketName,
+			Key:                &filePath,
+			CopySource:         &copySource,
+			Metadata:           metadata,
+			MetadataDirective:  types.MetadataDirectiveReplace,
+			ContentType:        head.ContentType,
+			ContentEncoding:    head.ContentEncoding,
+			ContentDisposition: head.ContentDisposition,
+			ContentLanguage:    head.ContentLanguage,
+			CacheControl:       head.CacheControl,
+			StorageClass:       types.StorageClass(head.StorageClass),
+		},
+	)
+	return err
+}
+
+// GetXAttr returns the value of the user-defined object metadata name.
+// S3 returns metadata names in lower case.
+func (s *fileSystem) GetXAttr(filePath, name string) ([]byte, error) {
+	head, err := s.metadata(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := head.Metadata[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", fs.ErrXAttrDoesNotExist, name)
+	}
+	return []byte(value), nil
+}
+
+// SetXAttr sets the user-defined object metadata name
+// by replacing the object with a copy of itself.
+// Values must be valid HTTP header values
+// and all metadata of an object is limited to 2 KB.
+func (s *fileSystem) SetXAttr(filePath, name string, value []byte) error {
+	ctx := context.Background()
+	head, err := s.metadata(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	metadata := maps.Clone(head.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata[strings.ToLower(name)] = string(value)
+	return s.replaceMetadata(ctx, filePath, head, metadata)
+}
+
+// ListXAttrs returns the names of the user-defined object metadata
+func (s *fileSystem) ListXAttrs(filePath string) ([]string, error) {
+	head, err := s.metadata(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(head.Metadata)), nil
+}
+
+// RemoveXAttr removes the user-defined object metadata name
+// by replacing the object with a copy of itself.
+func (s *fileSystem) RemoveXAttr(filePath, name string) error {
+	ctx := context.Background()
+	head, err := s.metadata(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	name = strings.ToLower(name)
+	if _, ok := head.Metadata[name]; !ok {
+		return fmt.Errorf("%w: %s", fs.ErrXAttrDoesNotExist, name)
+	}
+	metadata := maps.Clone(head.Metadata)
+	delete(metadata, name)
+	return s.replaceMetadata(ctx, filePath, head, metadata)
+}
@@ -0,0 +1,138 @@
+package fs
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// SendTree writes all files and sub-directories of dir
+// as uncompressed tar stream to w.
+// The paths in the tar stream are slash separated
+// and relative to dir, directories end with a slash.
+// Use ReceiveTree to reconstruct the tree on another file system.
+func SendTree(ctx context.Context, dir File, w io.Writer) error {
+	if err := dir.CheckIsDir(); err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	buf := make([]byte, copyBufferSize)
+	err := sendTree(ctx, tw, dir, "", buf, &archiveConfig{})
+	if err != nil {
+		return fmt.Errorf("SendTree: %w", err)
+	}
+	return tw.Close()
+}
+
+func sendTree(ctx context.Context, tw *tar.Writer, dir File, dirPath string, buf []byte, config *archiveConfig) error {
+	return dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if skip, err := config.skip(info); skip || err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:    dirPath + info.Name,
+			Mode:    int64(info.Permissions.FileMode(false)),
+			ModTime: info.Modified,
+		}
+		if info.IsDir {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			return sendTree(ctx, tw, info.File, header.Name, buf, config)
+		}
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		r, err := info.File.OpenReader()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return copyBuffer(ctx, tw, r, buf)
+	})
+}
+
+// ReceiveTree reads a tar stream written by SendTree or any other
+// tar writer from r and writes its directories and files
+// with their permissions into destDir.
+// destDir is created if it does not exist,
+// existing files are overwritten.
+//
+// Entries with paths outside of destDir result in an error
+// wrapping ErrPathOutsideRoot.
+// Entries other than directories and regular files,
+// like symbolic links, are skipped.
+func ReceiveTree(ctx context.Context, r io.Reader, destDir File) error {
+	err := destDir.MakeAllDirs()
+	if err != nil {
+		return fmt.Errorf("ReceiveTree: %w", err)
+	}
+	tr := tar.NewReader(r)
+	buf := make([]byte, copyBufferSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ReceiveTree: %w", err)
+		}
+		name, err := cleanArchivePath(header.Name)
+		if err != nil {
+			return fmt.Errorf("ReceiveTree: %w", err)
+		}
+		if name == "." {
+			continue
+		}
+		dest := destDir.Join(strings.Split(name, "/")...)
+		perm := Permissions(header.FileInfo().Mode().Perm())
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = dest.MakeAllDirs(perm)
+		case tar.TypeReg:
+			err = receiveTreeFile(ctx, tr, dest, perm, buf)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("ReceiveTree: %w", err)
+		}
+	}
+}
+
+func receiveTreeFile(ctx context.Context, r io.Reader, dest File, perm Permissions, buf []byte) (err error) {
+	err = dest.Dir().MakeAllDirs()
+	if err != nil {
+		return err
+	}
+	w, err := dest.OpenWriter(perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, w.Close())
+	}()
+	return copyBuffer(ctx, w, r, buf)
+}
+
+// cleanArchivePath returns the cleaned slash separated
+// path of an archive entry or an error wrapping
+// ErrPathOutsideRoot if it escapes the extraction directory.
+func cleanArchivePath(name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+		return "", fmt.Errorf("%w: %s", ErrPathOutsideRoot, name)
+	}
+	return clean, nil
+}
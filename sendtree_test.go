@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendReceiveTree(t *testing.T) {
+	ctx := context.Background()
+	src := File(t.TempDir())
+	require.NoError(t, src.Join("sub", "empty").MakeAllDirs())
+	require.NoError(t, src.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, src.Join("sub", "b.txt").WriteAllString("BB"))
+
+	var stream bytes.Buffer
+	require.NoError(t, SendTree(ctx, src, &stream))
+
+	dest := File(t.TempDir()).Join("dest")
+	require.NoError(t, ReceiveTree(ctx, bytes.NewReader(stream.Bytes()), dest))
+	requireFileContent(t, dest.Join("a.txt"), "A")
+	requireFileContent(t, dest.Join("sub", "b.txt"), "BB")
+	require.True(t, dest.Join("sub", "empty").IsDir())
+	require.Equal(t, src.Join("a.txt").Permissions(), dest.Join("a.txt").Permissions())
+
+	// Paths escaping destDir are rejected
+	var evil bytes.Buffer
+	tw := tar.NewWriter(&evil)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0600}))
+	_, err := io.WriteString(tw, "x")
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	err = ReceiveTree(ctx, &evil, dest)
+	require.ErrorIs(t, err, ErrPathOutsideRoot)
+	require.False(t, dest.Dir().Join("evil.txt").Exists())
+}
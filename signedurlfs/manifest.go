@@ -0,0 +1,114 @@
+package signedurlfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Manifest lists the files of an exported directory tree
+// with signed URLs to read them.
+// It's serializable as JSON and contains no credentials,
+// so it can be shared with other organizations
+// which mount it with New.
+type Manifest struct {
+	// Expires is the time when the first signed URL expires
+	Expires time.Time `json:"expires"`
+	// Files of the exported tree sorted by path
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file of a Manifest
+type ManifestFile struct {
+	// Path is the slash separated path
+	// relative to the exported directory
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// URL is the signed URL to read the file
+	URL string `json:"url"`
+}
+
+// Expired returns if the signed URLs
+// of the manifest are expired at time now.
+func (m *Manifest) Expired(now time.Time) bool {
+	return !now.Before(m.Expires)
+}
+
+// WriteTo writes the manifest as JSON to file
+func (m *Manifest) WriteTo(ctx context.Context, file fs.File) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return file.WriteAllContext(ctx, data)
+}
+
+// ReadManifest reads a manifest written with Manifest.WriteTo
+func ReadManifest(ctx context.Context, file fs.File) (*Manifest, error) {
+	data, err := file.ReadAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, fmt.Errorf("can't unmarshal signed URL manifest %s: %w", file, err)
+	}
+	return m, nil
+}
+
+// PresignFunc returns a URL that allows reading file
+// until the expires duration has passed.
+// Use Signer.PresignURL for file systems
+// that don't implement fs.PresignFileSystem.
+type PresignFunc func(ctx context.Context, file fs.File, expires time.Duration) (string, error)
+
+// Export returns a Manifest with signed URLs
+// for all files in the directory tree of dir
+// that are valid until the expires duration has passed.
+//
+// If presign is nil, then fs.File.PresignURL is used
+// which requires that the file system of dir
+// implements fs.PresignFileSystem.
+// Empty directories are not exported.
+func Export(ctx context.Context, dir fs.File, expires time.Duration, presign PresignFunc) (*Manifest, error) {
+	if err := dir.CheckIsDir(); err != nil {
+		return nil, err
+	}
+	if presign == nil {
+		presign = func(ctx context.Context, file fs.File, expires time.Duration) (string, error) {
+			return file.PresignURL(ctx, expires)
+		}
+	}
+	m := &Manifest{Expires: time.Now().Add(expires)}
+	err := exportDir(ctx, dir, "", expires, presign, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func exportDir(ctx context.Context, dir fs.File, dirPath string, expires time.Duration, presign PresignFunc, m *Manifest) error {
+	return dir.ListDirInfoContext(ctx, func(info *fs.FileInfo) error {
+		filePath := path.Join(dirPath, info.Name)
+		if info.IsDir {
+			return exportDir(ctx, info.File, filePath, expires, presign, m)
+		}
+		url, err := presign(ctx, info.File, expires)
+		if err != nil {
+			return fmt.Errorf("can't presign %s: %w", info.File, err)
+		}
+		m.Files = append(m.Files, ManifestFile{
+			Path:     filePath,
+			Size:     info.Size,
+			Modified: info.Modified,
+			URL:      url,
+		})
+		return nil
+	})
+}
@@ -0,0 +1,369 @@
+// Package signedurlfs shares directory trees
+// with time-limited signed URLs.
+//
+// Export creates a Manifest with a signed URL for every file
+// of a directory tree, either presigned by the file system
+// like S3 or signed by a Signer that serves the files.
+// The receiver of the manifest mounts it with New
+// as read-only file system without needing any credentials.
+package signedurlfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of SignedURLFileSystem URIs
+	Prefix = "signedurl://"
+
+	// Separator used in SignedURLFileSystem paths
+	Separator = "/"
+
+	// ErrExpired is returned when the signed URLs of a Manifest are expired
+	ErrExpired fs.SentinelError = "signed URL expired"
+)
+
+var (
+	// Make sure SignedURLFileSystem implements the following interfaces
+	_ fs.FileSystem        = new(SignedURLFileSystem)
+	_ fs.ReadAllFileSystem = new(SignedURLFileSystem)
+	_ fs.ExistsFileSystem  = new(SignedURLFileSystem)
+)
+
+// SignedURLFileSystem is a read-only file system
+// with the files of a Manifest that are read from their signed URLs.
+//
+// It is registered with the prefix "signedurl://" followed by a random ID,
+// so the exported directory is accessed as "signedurl://<id>/".
+type SignedURLFileSystem struct {
+	prefix   string
+	manifest *Manifest
+	client   *http.Client
+	// files maps absolute paths to manifest files
+	files map[string]*ManifestFile
+	// dirs maps absolute directory paths to the sorted names of their entries
+	dirs   map[string][]string
+	closed atomic.Bool
+}
+
+// New returns a registered SignedURLFileSystem
+// for the files of manifest.
+// If client is nil, then http.DefaultClient is used.
+func New(manifest *Manifest, client *http.Client) (*SignedURLFileSystem, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	f := &SignedURLFileSystem{
+		prefix:   Prefix + fsimpl.RandomString(),
+		manifest: manifest,
+		client:   client,
+		files:    make(map[string]*ManifestFile, len(manifest.Files)),
+		dirs:     map[string][]string{Separator: nil},
+	}
+	for i := range manifest.Files {
+		file := &manifest.Files[i]
+		filePath := path.Clean(Separator + file.Path)
+		if filePath == Separator || file.URL == "" {
+			return nil, fmt.Errorf("invalid signed URL manifest file %q", file.Path)
+		}
+		if _, exists := f.files[filePath]; exists {
+			return nil, fmt.Errorf("duplicate signed URL manifest file %q", file.Path)
+		}
+		f.files[filePath] = file
+		// Add the file and all its parent directories
+		for filePath != Separator {
+			dir, name := path.Dir(filePath), path.Base(filePath)
+			names, dirExists := f.dirs[dir]
+			if !slices.Contains(names, name) {
+				f.dirs[dir] = append(names, name)
+			}
+			if dirExists {
+				break
+			}
+			filePath = dir
+		}
+	}
+	for dir, names := range f.dirs {
+		if _, isFile := f.files[dir]; isFile {
+			return nil, fmt.Errorf("signed URL manifest file %q is also a directory", strings.TrimPrefix(dir, Separator))
+		}
+		slices.Sort(names)
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Manifest returns the manifest of the file system
+func (f *SignedURLFileSystem) Manifest() *Manifest {
+	return f.manifest
+}
+
+func (f *SignedURLFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *SignedURLFileSystem) fileInfo(filePath string) (*fs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	filePath = f.AbsPath(filePath)
+	file := f.File(filePath)
+	if manifestFile, ok := f.files[filePath]; ok {
+		return &fs.FileInfo{
+			File:        file,
+			Name:        path.Base(filePath),
+			Exists:      true,
+			IsRegular:   true,
+			IsHidden:    f.IsHidden(filePath),
+			Size:        manifestFile.Size,
+			Modified:    manifestFile.Modified,
+			Permissions: fs.AllRead,
+		}, nil
+	}
+	if _, ok := f.dirs[filePath]; ok {
+		return &fs.FileInfo{
+			File:        file,
+			Name:        path.Base(filePath),
+			Exists:      true,
+			IsDir:       true,
+			IsHidden:    f.IsHidden(filePath),
+			Permissions: fs.AllRead,
+		}, nil
+	}
+	return nil, fs.NewErrDoesNotExist(file)
+}
+
+func (f *SignedURLFileSystem) ReadableWritable() (readable, writable bool) {
+	return true, false
+}
+
+func (f *SignedURLFileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+func (f *SignedURLFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *SignedURLFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *SignedURLFileSystem) Name() string {
+	return "signed URL file system"
+}
+
+// String implements the fmt.Stringer interface.
+func (f *SignedURLFileSystem) String() string {
+	return fmt.Sprintf("%s with prefix %s and %d files", f.Name(), f.prefix, len(f.files))
+}
+
+func (f *SignedURLFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *SignedURLFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *SignedURLFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *SignedURLFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *SignedURLFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *SignedURLFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*SignedURLFileSystem) Separator() string {
+	return Separator
+}
+
+func (*SignedURLFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*SignedURLFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (*SignedURLFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (*SignedURLFileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *SignedURLFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	info, err := f.fileInfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return info.StdFileInfo(), nil
+}
+
+func (f *SignedURLFileSystem) Exists(filePath string) bool {
+	_, err := f.fileInfo(filePath)
+	return err == nil
+}
+
+func (f *SignedURLFileSystem) IsHidden(filePath string) bool {
+	_, name := f.SplitDirAndName(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (f *SignedURLFileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+func (f *SignedURLFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	info, err := f.fileInfo(dirPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir {
+		return fs.NewErrIsNotDirectory(info.File)
+	}
+	dirPath = f.AbsPath(dirPath)
+	for _, name := range f.dirs[dirPath] {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		match, err := f.MatchAnyPattern(name, patterns)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		info, err := f.fileInfo(path.Join(dirPath, name))
+		if err != nil {
+			return err
+		}
+		err = callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get sends a GET request for the signed URL of filePath
+func (f *SignedURLFileSystem) get(ctx context.Context, filePath string) (*fs.FileInfo, io.ReadCloser, error) {
+	info, err := f.fileInfo(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir {
+		return nil, nil, fs.NewErrIsDirectory(info.File)
+	}
+	if f.manifest.Expired(time.Now()) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrExpired, info.File)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, f.files[f.AbsPath(filePath)].URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch response.StatusCode {
+	case http.StatusOK:
+		return info, response.Body, nil
+	case http.StatusNotFound:
+		response.Body.Close()
+		return nil, nil, fs.NewErrDoesNotExist(info.File)
+	case http.StatusForbidden:
+		response.Body.Close()
+		return nil, nil, fs.NewErrPermission(info.File)
+	default:
+		response.Body.Close()
+		return nil, nil, fmt.Errorf("can't read %s: %s", info.File, response.Status)
+	}
+}
+
+func (f *SignedURLFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	_, body, err := f.get(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return fs.ReadAllContext(ctx, body)
+}
+
+// OpenReader streams the file from its signed URL
+func (f *SignedURLFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	info, body, err := f.get(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{ReadCloser: body, info: info.StdFileInfo()}, nil
+}
+
+func (f *SignedURLFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	return fs.ErrReadOnlyFileSystem
+}
+
+func (f *SignedURLFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	return nil, fs.ErrReadOnlyFileSystem
+}
+
+func (f *SignedURLFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	return nil, fs.ErrReadOnlyFileSystem
+}
+
+func (f *SignedURLFileSystem) Remove(filePath string) error {
+	return fs.ErrReadOnlyFileSystem
+}
+
+// Close unregisters the file system
+func (f *SignedURLFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// reader implements iofs.File for a streamed response body
+type reader struct {
+	io.ReadCloser
+	info iofs.FileInfo
+}
+
+func (r *reader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
@@ -0,0 +1,145 @@
+package signedurlfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestExportAndNew(t *testing.T) {
+	ctx := context.Background()
+	root := fs.File(t.TempDir())
+	require.NoError(t, root.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, root.Join("sub", "dir").MakeAllDirs())
+	require.NoError(t, root.Join("sub", "b.txt").WriteAllString("BB"))
+	require.NoError(t, root.Join("sub", "dir", "c.txt").WriteAllString("CCC"))
+	server := httptest.NewUnstartedServer(nil)
+	signer, err := NewSigner(root, "http://"+server.Listener.Addr().String(), []byte("secret"))
+	require.NoError(t, err)
+	server.Config.Handler = signer
+	server.Start()
+	t.Cleanup(server.Close)
+
+	manifest, err := Export(ctx, root.Join("sub"), time.Hour, signer.PresignURL)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 2)
+	require.False(t, manifest.Expired(time.Now()))
+
+	// Share the manifest as JSON
+	manifestFile := fs.File(t.TempDir()).Join("manifest.json")
+	require.NoError(t, manifest.WriteTo(ctx, manifestFile))
+	manifest, err = ReadManifest(ctx, manifestFile)
+	require.NoError(t, err)
+
+	signedFS, err := New(manifest, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { signedFS.Close() })
+
+	readable, writable := signedFS.ReadableWritable()
+	require.True(t, readable)
+	require.False(t, writable)
+
+	content, err := signedFS.JoinCleanFile("b.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "BB", content)
+	content, err = signedFS.JoinCleanFile("dir", "c.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "CCC", content)
+
+	reader, err := signedFS.JoinCleanFile("dir", "c.txt").OpenReader()
+	require.NoError(t, err)
+	info, err := reader.Stat()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), info.Size())
+	require.NoError(t, reader.Close())
+
+	require.True(t, signedFS.JoinCleanFile("dir").IsDir())
+	require.Equal(t, int64(2), signedFS.JoinCleanFile("b.txt").Size())
+	require.False(t, signedFS.JoinCleanFile("a.txt").Exists())
+
+	var names []string
+	err = signedFS.RootDir().ListDirInfo(func(info *fs.FileInfo) error {
+		names = append(names, info.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b.txt", "dir"}, names)
+
+	err = signedFS.JoinCleanFile("new.txt").WriteAllString("x")
+	require.ErrorIs(t, err, fs.ErrReadOnlyFileSystem)
+}
+
+func TestSigner(t *testing.T) {
+	ctx := context.Background()
+	root := fs.File(t.TempDir())
+	require.NoError(t, root.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, root.Join("sub", "dir").MakeAllDirs())
+	require.NoError(t, root.Join("sub", "b.txt").WriteAllString("BB"))
+	require.NoError(t, root.Join("sub", "dir", "c.txt").WriteAllString("CCC"))
+	server := httptest.NewUnstartedServer(nil)
+	signer, err := NewSigner(root, "http://"+server.Listener.Addr().String(), []byte("secret"))
+	require.NoError(t, err)
+	server.Config.Handler = signer
+	server.Start()
+	t.Cleanup(server.Close)
+
+	url, err := signer.PresignURL(ctx, root.Join("a.txt"), time.Hour)
+	require.NoError(t, err)
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	// Tampered signature
+	response, err = http.Get(url + "x")
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	// Expired URL
+	url, err = signer.PresignURL(ctx, root.Join("a.txt"), -time.Second)
+	require.NoError(t, err)
+	response, err = http.Get(url)
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	_, err = signer.PresignURL(ctx, root.Dir().Join("outside.txt"), time.Hour)
+	require.ErrorIs(t, err, fs.ErrPathOutsideRoot)
+
+	// Local files can't be presigned without a Signer
+	_, err = Export(ctx, root, time.Hour, nil)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestExpiredManifest(t *testing.T) {
+	ctx := context.Background()
+	root := fs.File(t.TempDir())
+	require.NoError(t, root.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, root.Join("sub", "dir").MakeAllDirs())
+	require.NoError(t, root.Join("sub", "b.txt").WriteAllString("BB"))
+	require.NoError(t, root.Join("sub", "dir", "c.txt").WriteAllString("CCC"))
+	server := httptest.NewUnstartedServer(nil)
+	signer, err := NewSigner(root, "http://"+server.Listener.Addr().String(), []byte("secret"))
+	require.NoError(t, err)
+	server.Config.Handler = signer
+	server.Start()
+	t.Cleanup(server.Close)
+
+	manifest, err := Export(ctx, root, time.Hour, signer.PresignURL)
+	require.NoError(t, err)
+	manifest.Expires = time.Now().Add(-time.Minute)
+	signedFS, err := New(manifest, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { signedFS.Close() })
+
+	_, err = signedFS.JoinCleanFile("a.txt").ReadAll()
+	require.ErrorIs(t, err, ErrExpired)
+}
@@ -0,0 +1,118 @@
+package signedurlfs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Signer signs URLs with a HMAC key and serves the files
+// of a root directory for requests with valid signatures.
+// Use it to share files of file systems
+// that don't implement fs.PresignFileSystem.
+//
+// Signed URLs have the form
+// baseURL/path?expires=<unix seconds>&signature=<HMAC-SHA256>.
+type Signer struct {
+	root    fs.File
+	baseURL string
+	key     []byte
+	now     func() time.Time
+}
+
+var _ http.Handler = new(Signer)
+
+// NewSigner returns a Signer for the files in root
+// that is served at baseURL with the secret key.
+func NewSigner(root fs.File, baseURL string, key []byte) (*Signer, error) {
+	if err := root.CheckIsDir(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, errors.New("empty signing key")
+	}
+	return &Signer{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		key:     key,
+		now:     time.Now,
+	}, nil
+}
+
+// PresignURL returns a signed URL for file which must be
+// in the root directory of the Signer.
+// The method can be passed as PresignFunc to Export.
+func (s *Signer) PresignURL(ctx context.Context, file fs.File, expires time.Duration) (string, error) {
+	relPath, err := s.relPath(file)
+	if err != nil {
+		return "", err
+	}
+	expiresUnix := strconv.FormatInt(s.now().Add(expires).Unix(), 10)
+	query := url.Values{
+		"expires":   {expiresUnix},
+		"signature": {s.signature(relPath, expiresUnix)},
+	}
+	return s.baseURL + "/" + (&url.URL{Path: relPath}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// relPath returns the slash separated path of file relative to the root
+func (s *Signer) relPath(file fs.File) (string, error) {
+	rootFS, rootPath := s.root.ParseRawURI()
+	fileFS, filePath := file.ParseRawURI()
+	sep := rootFS.Separator()
+	rootPath = strings.TrimSuffix(rootPath, sep) + sep
+	if fileFS != rootFS || !strings.HasPrefix(filePath, rootPath) {
+		return "", fmt.Errorf("%w: %s is not in %s", fs.ErrPathOutsideRoot, file, s.root)
+	}
+	return strings.ReplaceAll(strings.TrimPrefix(filePath, rootPath), sep, "/"), nil
+}
+
+func (s *Signer) signature(relPath, expiresUnix string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(relPath))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expiresUnix))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves GET requests of signed URLs
+func (s *Signer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	relPath := strings.TrimPrefix(r.URL.Path, "/")
+	if relPath == "" || path.Clean("/"+relPath) != "/"+relPath {
+		http.NotFound(w, r)
+		return
+	}
+	expiresUnix := r.URL.Query().Get("expires")
+	signature := r.URL.Query().Get("signature")
+	if !hmac.Equal([]byte(signature), []byte(s.signature(relPath, expiresUnix))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	expires, err := strconv.ParseInt(expiresUnix, 10, 64)
+	if err != nil || !s.now().Before(time.Unix(expires, 0)) {
+		http.Error(w, "signed URL expired", http.StatusForbidden)
+		return
+	}
+
+	file := s.root.Join(strings.Split(relPath, "/")...)
+	if file.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	fs.ServeFileHTTP(w, r, file, "application/octet-stream")
+}
@@ -0,0 +1,555 @@
+// Package smbfs implements a file system for SMB/CIFS network shares
+// of Windows or Samba servers using the SMB2/3 protocol.
+package smbfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hirochachacha/go-smb2"
+
+	fs "github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of SMB shares
+	Prefix = "smb://"
+
+	// Separator used in SMB paths
+	Separator = "/"
+
+	// DefaultPort of SMB servers
+	DefaultPort = "445"
+
+	// fileAttributeHidden is FILE_ATTRIBUTE_HIDDEN from [MS-FSCC]
+	fileAttributeHidden = 0x2
+)
+
+var (
+	// DefaultPermissions used for SMB files
+	DefaultPermissions = fs.UserAndGroupReadWrite
+	// DefaultDirPermissions used for SMB directories
+	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllReadWrite
+
+	// Make sure fileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(fileSystem)
+)
+
+// InitiatorFunc is called by Dial to get the smb2.Initiator
+// that authenticates the session with the server.
+// The function can be used to look up or prompt for credentials.
+//
+// Use NTLM for NTLMv2 authentication with user name and password.
+// Kerberos authentication needs an smb2.Initiator implementation
+// for the SPNEGO Kerberos mechanism.
+type InitiatorFunc func(ctx context.Context, server string) (smb2.Initiator, error)
+
+// NTLM returns an InitiatorFunc for NTLMv2 authentication.
+// domain can be empty.
+func NTLM(user, password, domain string) InitiatorFunc {
+	return func(context.Context, string) (smb2.Initiator, error) {
+		return &smb2.NTLMInitiator{
+			User:     user,
+			Password: password,
+			Domain:   domain,
+		}, nil
+	}
+}
+
+type fileSystem struct {
+	share    *smb2.Share
+	session  *smb2.Session
+	conn     net.Conn
+	prefix   string
+	readOnly bool
+}
+
+// DialAndRegister connects to the share of shareURL
+// in the form smb://server[:port]/share
+// using initiator for authentication
+// and registers the file system with the shareURL as prefix.
+//
+// The file /dir/file.txt of the share is addressed as
+// smb://server/share/dir/file.txt
+func DialAndRegister(ctx context.Context, shareURL string, initiator InitiatorFunc, readOnly bool) (fs.FileSystem, error) {
+	server, shareName, err := ParseShareURL(shareURL)
+	if err != nil {
+		return nil, err
+	}
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, DefaultPort)
+	}
+	conn, err := new(net.Dialer).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to SMB server %s: %w", addr, err)
+	}
+	init, err := initiator(ctx, server)
+	if err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+	session, err := (&smb2.Dialer{Initiator: init}).DialContext(ctx, conn)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("can't authenticate with SMB server %s: %w", server, err), conn.Close())
+	}
+	share, err := session.Mount(shareName)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("can't mount SMB share %s: %w", shareName, err), session.Logoff(), conn.Close())
+	}
+	smbFS := &fileSystem{
+		share:    share,
+		session:  session,
+		conn:     conn,
+		prefix:   Prefix + server + Separator + shareName,
+		readOnly: readOnly,
+	}
+	_, err = fs.TryRegister(smbFS)
+	if err != nil {
+		return nil, errors.Join(err, smbFS.Close())
+	}
+	return smbFS, nil
+}
+
+// ParseShareURL returns the server with optional port
+// and the share name of a shareURL in the form smb://server[:port]/share.
+// User info, query and fragment are not allowed.
+func ParseShareURL(shareURL string) (server, share string, err error) {
+	u, err := url.Parse(shareURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "smb" {
+		return "", "", fmt.Errorf("not a SMB share URL: %s", shareURL)
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("missing server in SMB share URL: %s", shareURL)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", fmt.Errorf("SMB share URL must not have user info, query or fragment: %s", shareURL)
+	}
+	share = strings.Trim(u.Path, Separator)
+	if share == "" || strings.Contains(share, Separator) {
+		return "", "", fmt.Errorf("SMB share URL must have exactly one share name path element: %s", shareURL)
+	}
+	return u.Host, share, nil
+}
+
+// sharePath returns filePath relative to the share root
+// as expected by go-smb2 that converts slashes to backslashes
+func sharePath(filePath string) string {
+	return strings.TrimPrefix(path.Clean(Separator+filePath), Separator)
+}
+
+func (s *fileSystem) wrapErrNotExist(filePath string, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fs.NewErrDoesNotExist(s.File(filePath))
+	}
+	return err
+}
+
+func (s *fileSystem) checkWritable() error {
+	if s.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if s.share == nil {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (s *fileSystem) ReadableWritable() (readable, writable bool) {
+	return true, !s.readOnly
+}
+
+func (s *fileSystem) RootDir() fs.File {
+	return fs.File(s.prefix + Separator)
+}
+
+func (s *fileSystem) ID() (string, error) {
+	return s.prefix, nil
+}
+
+func (s *fileSystem) Prefix() string {
+	return s.prefix
+}
+
+func (s *fileSystem) Name() string {
+	return "SMB file system for share: " + strings.TrimPrefix(s.prefix, Prefix)
+}
+
+func (s *fileSystem) String() string {
+	return s.Name() + " with prefix " + s.prefix
+}
+
+func (s *fileSystem) File(filePath string) fs.File {
+	return s.JoinCleanFile(filePath)
+}
+
+func (s *fileSystem) URL(cleanPath string) string {
+	return s.prefix + cleanPath
+}
+
+func (s *fileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, s.prefix)
+}
+
+func (s *fileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(s.prefix + s.JoinCleanPath(uriParts...))
+}
+
+func (s *fileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, s.prefix, Separator)
+}
+
+func (s *fileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, s.prefix, Separator)
+}
+
+func (s *fileSystem) Separator() string {
+	return Separator
+}
+
+func (s *fileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (s *fileSystem) AbsPath(filePath string) string {
+	if path.IsAbs(filePath) {
+		return filePath
+	}
+	return Separator + filePath
+}
+
+// MatchAnyPattern matches case sensitive
+// even if the server treats names case insensitive.
+func (s *fileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (s *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if s.share == nil {
+		return nil, fs.ErrFileSystemClosed
+	}
+	info, err := s.share.Stat(sharePath(filePath))
+	if err != nil {
+		return nil, s.wrapErrNotExist(filePath, err)
+	}
+	return info, nil
+}
+
+func (s *fileSystem) Exists(filePath string) bool {
+	_, err := s.Stat(filePath)
+	return err == nil
+}
+
+// IsHidden returns true if the name of the file starts with a dot
+// or if the file has the hidden attribute.
+func (s *fileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	if len(name) > 0 && name[0] == '.' {
+		return true
+	}
+	info, err := s.Stat(filePath)
+	return err == nil && hasAttributeHidden(info)
+}
+
+func hasAttributeHidden(info iofs.FileInfo) bool {
+	stat, ok := info.Sys().(*smb2.FileStat)
+	return ok && stat.FileAttributes&fileAttributeHidden != 0
+}
+
+// IsSymbolicLink returns true for reparse points
+// which includes symbolic links and junctions.
+func (s *fileSystem) IsSymbolicLink(filePath string) bool {
+	if filePath == "" || s.share == nil {
+		return false
+	}
+	info, err := s.share.Lstat(sharePath(filePath))
+	return err == nil && info.Mode()&iofs.ModeSymlink != 0
+}
+
+func (s *fileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if s.share == nil {
+		return fs.ErrFileSystemClosed
+	}
+	infos, err := s.share.WithContext(ctx).ReadDir(sharePath(dirPath))
+	if err != nil {
+		return s.wrapErrNotExist(dirPath, err)
+	}
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		match, err := s.MatchAnyPattern(info.Name(), patterns)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		filePath := path.Join(dirPath, info.Name())
+		isHidden := info.Name()[0] == '.' || hasAttributeHidden(info)
+		err = callback(fs.NewFileInfo(s.File(filePath), info, isHidden))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Touch creates an empty file if filePath does not exist
+// or sets the modified time of an existing file to now.
+func (s *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if s.Exists(filePath) {
+		now := time.Now()
+		return s.share.Chtimes(sharePath(filePath), now, now)
+	}
+	return s.WriteAll(context.Background(), filePath, nil, perm)
+}
+
+func (s *fileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	err := s.share.Mkdir(sharePath(dirPath), fs.JoinPermissions(perm, DefaultDirPermissions).FileMode(true))
+	if errors.Is(err, os.ErrExist) {
+		return fs.NewErrAlreadyExists(s.File(dirPath))
+	}
+	return err
+}
+
+func (s *fileSystem) MakeAllDirs(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	return s.share.MkdirAll(sharePath(dirPath), fs.JoinPermissions(perm, DefaultDirPermissions).FileMode(true))
+}
+
+func (s *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	reader, err := s.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return fs.ReadAllContext(ctx, reader)
+}
+
+// ReadRange reads a byte range of a file
+// without transferring the rest of the file.
+// A negative offset reads the last -offset bytes of the file.
+func (s *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset >= 0 && length <= 0 {
+		return []byte{}, nil
+	}
+	reader, err := s.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	file := reader.(*smb2.File)
+	if offset < 0 {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		length = min(-offset, info.Size())
+		offset = info.Size() - length
+	}
+	return fs.ReadAllContext(ctx, io.NewSectionReader(file, offset, length))
+}
+
+// WriteAll writes data in chunks so that the write can be canceled
+// by ctx and removes the partially written file in that case
+// or if any other error occurs while writing.
+func (s *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	writer, err := s.OpenWriter(filePath, perm)
+	if err != nil {
+		return err
+	}
+	err = errors.Join(fs.WriteAllContext(ctx, writer, data), writer.Close())
+	if err != nil {
+		_ = s.share.Remove(sharePath(filePath))
+		return err
+	}
+	return nil
+}
+
+func (s *fileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	writer, err := s.OpenAppendWriter(filePath, perm)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return errors.Join(err, writer.Close())
+}
+
+// OpenReader returns the remote file that implements
+// io.ReaderAt and io.Seeker in addition to iofs.File
+func (s *fileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if s.share == nil {
+		return nil, fs.ErrFileSystemClosed
+	}
+	file, err := s.share.Open(sharePath(filePath))
+	if err != nil {
+		return nil, s.wrapErrNotExist(filePath, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	if info.IsDir() {
+		return nil, errors.Join(fs.NewErrIsDirectory(s.File(filePath)), file.Close())
+	}
+	return file, nil
+}
+
+// openFile opens the remote file with flag
+// after checking that the file system is writable
+func (s *fileSystem) openFile(filePath string, flag int, perm []fs.Permissions) (*smb2.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	file, err := s.share.OpenFile(sharePath(filePath), flag, fs.JoinPermissions(perm, DefaultPermissions).FileMode(false))
+	if err != nil {
+		return nil, s.wrapErrNotExist(filePath, err)
+	}
+	return file, nil
+}
+
+func (s *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	return s.openFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (s *fileSystem) OpenAppendWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	return s.openFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+}
+
+func (s *fileSystem) OpenWriteSeeker(filePath string, perm []fs.Permissions) (fs.WriteSeekCloser, error) {
+	return s.openFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+func (s *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	return s.openFile(filePath, os.O_RDWR|os.O_CREATE, perm)
+}
+
+func (s *fileSystem) Truncate(filePath string, size int64) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	err := s.share.Truncate(sharePath(filePath), size)
+	return s.wrapErrNotExist(filePath, err)
+}
+
+func (s *fileSystem) Rename(filePath string, newName string) (newPath string, err error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return "", err
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	newPath = path.Join(path.Dir(filePath), newName)
+	err = s.share.Rename(sharePath(filePath), sharePath(newPath))
+	if err != nil {
+		return "", s.wrapErrNotExist(filePath, err)
+	}
+	return newPath, nil
+}
+
+// Move renames the file on the server side.
+// If destPath is an existing directory, then the file is moved into it.
+func (s *fileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if info, err := s.Stat(destPath); err == nil && info.IsDir() {
+		destPath = path.Join(destPath, path.Base(filePath))
+	}
+	err := s.share.Rename(sharePath(filePath), sharePath(destPath))
+	return s.wrapErrNotExist(filePath, err)
+}
+
+// Remove removes a file or an empty directory
+func (s *fileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	err := s.share.Remove(sharePath(filePath))
+	return s.wrapErrNotExist(filePath, err)
+}
+
+func (s *fileSystem) Watch(filePath string, onEvent func(fs.File, fs.Event)) (cancel func() error, err error) {
+	return nil, errors.ErrUnsupported
+}
+
+// Close unmounts the share, logs off the session
+// and closes the connection to the server.
+func (s *fileSystem) Close() error {
+	if s.share == nil {
+		return nil // already closed
+	}
+	fs.Unregister(s)
+	err := errors.Join(s.share.Umount(), s.session.Logoff(), s.conn.Close())
+	s.share = nil
+	return err
+}
@@ -0,0 +1,880 @@
+// Package sqlitefs implements a file system that stores
+// file paths, metadata and contents in a SQLite database.
+package sqlitefs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+
+	fs "github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of SQLite file systems
+	Prefix = "sqlite://"
+
+	// Separator used in SQLite file system paths
+	Separator = "/"
+)
+
+var (
+	// DefaultPermissions used for files
+	DefaultPermissions = fs.UserAndGroupReadWrite
+	// DefaultDirPermissions used for directories
+	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllExecute
+
+	// Make sure fileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(fileSystem)
+)
+
+// schema of the files table.
+// path is the clean absolute path of a file,
+// parent the path of its directory, empty for the root.
+// modified is stored as Unix time in nanoseconds.
+const schema = `
+CREATE TABLE IF NOT EXISTS files (
+	path        TEXT PRIMARY KEY NOT NULL,
+	parent      TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	is_dir      INTEGER NOT NULL,
+	size        INTEGER NOT NULL,
+	modified    INTEGER NOT NULL,
+	permissions INTEGER NOT NULL,
+	data        BLOB
+);
+CREATE INDEX IF NOT EXISTS files_parent_name ON files (parent, name);
+`
+
+type fileSystem struct {
+	db       *sql.DB
+	dbFile   string
+	prefix   string
+	readOnly bool
+	closed   atomic.Bool
+}
+
+// NewAndRegister opens or creates the SQLite database dbFile
+// and returns a registered fs.FileSystem for the files stored in it.
+//
+// The prefix of the file system is "sqlite://" followed by
+// the absolute path of dbFile, so the root directory
+// of the database "/data/files.db" is "sqlite:///data/files.db/".
+//
+// Every write operation is executed in a transaction,
+// so the database is always in a consistent state.
+func NewAndRegister(dbFile string, readOnly bool) (fs.FileSystem, error) {
+	absFile, err := filepath.Abs(dbFile)
+	if err != nil {
+		return nil, err
+	}
+	dsn := "file:" + absFile + "?_busy_timeout=5000&_foreign_keys=on"
+	if readOnly {
+		dsn += "&mode=ro"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection serializes all access
+	// and avoids SQLITE_BUSY errors between connections
+	db.SetMaxOpenConns(1)
+
+	f := &fileSystem{
+		db:       db,
+		dbFile:   absFile,
+		prefix:   Prefix + filepath.ToSlash(absFile),
+		readOnly: readOnly,
+	}
+	if !readOnly {
+		err = f.initSchema(context.Background())
+		if err != nil {
+			return nil, errors.Join(err, db.Close())
+		}
+	}
+	_, err = fs.TryRegister(f)
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return f, nil
+}
+
+func (f *fileSystem) initSchema(ctx context.Context) error {
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, schema)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO files (path, parent, name, is_dir, size, modified, permissions) VALUES (?, '', '', 1, 0, ?, ?)`,
+			Separator, time.Now().UnixNano(), DefaultDirPermissions,
+		)
+		return err
+	})
+}
+
+// transaction calls fn with a transaction
+// that is committed if fn returns nil
+// or else rolled back.
+func (f *fileSystem) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (f *fileSystem) checkReadable() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *fileSystem) checkWritable() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	if f.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	return nil
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// entry is a row of the files table without data
+type entry struct {
+	path        string
+	name        string
+	isDir       bool
+	size        int64
+	modified    int64
+	permissions fs.Permissions
+}
+
+const entryColumns = `path, name, is_dir, size, modified, permissions`
+
+func (e *entry) scan(row interface{ Scan(...any) error }) error {
+	return row.Scan(&e.path, &e.name, &e.isDir, &e.size, &e.modified, &e.permissions)
+}
+
+// getEntry returns the entry for filePath
+// or an fs.ErrDoesNotExist error.
+func (f *fileSystem) getEntry(ctx context.Context, q querier, filePath string) (*entry, error) {
+	var e entry
+	err := e.scan(q.QueryRowContext(ctx, `SELECT `+entryColumns+` FROM files WHERE path = ?`, filePath))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// getDirEntry returns the entry for dirPath
+// or an error if it does not exist or is not a directory.
+func (f *fileSystem) getDirEntry(ctx context.Context, q querier, dirPath string) (*entry, error) {
+	e, err := f.getEntry(ctx, q, dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if !e.isDir {
+		return nil, fs.NewErrIsNotDirectory(f.File(dirPath))
+	}
+	return e, nil
+}
+
+func (f *fileSystem) fileInfo(e *entry) *fs.FileInfo {
+	return &fs.FileInfo{
+		File:        f.File(e.path),
+		Name:        e.name,
+		Exists:      true,
+		IsDir:       e.isDir,
+		IsRegular:   true,
+		IsHidden:    len(e.name) > 0 && e.name[0] == '.',
+		Size:        e.size,
+		Modified:    time.Unix(0, e.modified),
+		Permissions: e.permissions,
+	}
+}
+
+// descendantsRange returns the bounds of the paths
+// below dirPath for the condition path > lower AND path < upper.
+// The upper bound uses '0' as the next character after '/'.
+func descendantsRange(dirPath string) (lower, upper string) {
+	if dirPath == Separator {
+		return Separator, "0"
+	}
+	return dirPath + Separator, dirPath + "0"
+}
+
+func (f *fileSystem) ReadableWritable() (readable, writable bool) {
+	return true, !f.readOnly
+}
+
+func (f *fileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+// ID returns the absolute path of the database file
+func (f *fileSystem) ID() (string, error) {
+	return f.dbFile, nil
+}
+
+func (f *fileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *fileSystem) Name() string {
+	return "SQLite file system"
+}
+
+// String implements the fmt.Stringer interface.
+func (f *fileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+func (f *fileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *fileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *fileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *fileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *fileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *fileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*fileSystem) Separator() string {
+	return Separator
+}
+
+func (*fileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (*fileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (*fileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkReadable(); err != nil {
+		return nil, err
+	}
+	e, err := f.getEntry(context.Background(), f.db, f.AbsPath(filePath))
+	if err != nil {
+		return nil, err
+	}
+	return f.fileInfo(e).StdFileInfo(), nil
+}
+
+func (f *fileSystem) Exists(filePath string) bool {
+	if filePath == "" || f.checkReadable() != nil {
+		return false
+	}
+	_, err := f.getEntry(context.Background(), f.db, f.AbsPath(filePath))
+	return err == nil
+}
+
+func (*fileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (*fileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+// listEntries queries the entries for condition
+// and returns the ones with names matching patterns.
+// The rows are read completely before returning,
+// so callbacks can access the file system.
+func (f *fileSystem) listEntries(ctx context.Context, patterns []string, condition string, args ...any) ([]*entry, error) {
+	rows, err := f.db.QueryContext(ctx, `SELECT `+entryColumns+` FROM files WHERE `+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*entry
+	for rows.Next() {
+		e := new(entry)
+		err = e.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		match, err := f.MatchAnyPattern(e.name, patterns)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			entries = append(entries, e)
+		}
+	}
+	return entries, rows.Err()
+}
+
+func (f *fileSystem) callbackEntries(ctx context.Context, entries []*entry, callback func(*fs.FileInfo) error) error {
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := callback(f.fileInfo(e))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListDirInfo lists the files and directories sorted by name.
+func (f *fileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkReadable(); err != nil {
+		return err
+	}
+	dirPath = f.AbsPath(dirPath)
+	_, err := f.getDirEntry(ctx, f.db, dirPath)
+	if err != nil {
+		return err
+	}
+	entries, err := f.listEntries(ctx, patterns, `parent = ? ORDER BY name`, dirPath)
+	if err != nil {
+		return err
+	}
+	return f.callbackEntries(ctx, entries, callback)
+}
+
+// ListDirInfoRecursive lists all files (not directories)
+// below dirPath sorted by path with a single query.
+func (f *fileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkReadable(); err != nil {
+		return err
+	}
+	dirPath = f.AbsPath(dirPath)
+	_, err := f.getDirEntry(ctx, f.db, dirPath)
+	if err != nil {
+		return err
+	}
+	lower, upper := descendantsRange(dirPath)
+	entries, err := f.listEntries(ctx, patterns, `path > ? AND path < ? AND is_dir = 0 ORDER BY path`, lower, upper)
+	if err != nil {
+		return err
+	}
+	return f.callbackEntries(ctx, entries, callback)
+}
+
+// insert inserts a new file or directory
+// after checking that the parent directory exists.
+func (f *fileSystem) insert(ctx context.Context, tx *sql.Tx, filePath string, isDir bool, data []byte, perm []fs.Permissions) error {
+	parent, name := path.Split(filePath)
+	parent = f.AbsPath(parent)
+	_, err := f.getDirEntry(ctx, tx, parent)
+	if err != nil {
+		return err
+	}
+	permissions := fs.JoinPermissions(perm, DefaultPermissions)
+	if isDir {
+		permissions = fs.JoinPermissions(perm, DefaultDirPermissions)
+		data = nil
+	} else if data == nil {
+		data = []byte{} // Store empty files as empty blob instead of NULL
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO files (path, parent, name, is_dir, size, modified, permissions, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		filePath, parent, name, isDir, len(data), time.Now().UnixNano(), permissions, data,
+	)
+	return err
+}
+
+// writeData replaces the data of the file at filePath
+// or creates it if it does not exist.
+func (f *fileSystem) writeData(ctx context.Context, tx *sql.Tx, filePath string, data []byte, perm []fs.Permissions) error {
+	e, err := f.getEntry(ctx, tx, filePath)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return f.insert(ctx, tx, filePath, false, data, perm)
+	}
+	if err != nil {
+		return err
+	}
+	if e.isDir {
+		return fs.NewErrIsDirectory(f.File(filePath))
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE files SET data = ?, size = ?, modified = ? WHERE path = ?`,
+		data, len(data), time.Now().UnixNano(), filePath,
+	)
+	return err
+}
+
+// readData returns the data of the file at filePath
+func (f *fileSystem) readData(ctx context.Context, q querier, filePath string) ([]byte, error) {
+	var (
+		isDir bool
+		data  []byte
+	)
+	err := q.QueryRowContext(ctx, `SELECT is_dir, data FROM files WHERE path = ?`, filePath).Scan(&isDir, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isDir {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+func (f *fileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	dirPath = f.AbsPath(dirPath)
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		if _, err := f.getEntry(ctx, tx, dirPath); err == nil {
+			return fs.NewErrAlreadyExists(f.File(dirPath))
+		}
+		return f.insert(ctx, tx, dirPath, true, nil, perm)
+	})
+}
+
+func (f *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	filePath = f.AbsPath(filePath)
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE files SET modified = ? WHERE path = ?`, time.Now().UnixNano(), filePath)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+		return f.insert(ctx, tx, filePath, false, nil, perm)
+	})
+}
+
+func (f *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkReadable(); err != nil {
+		return nil, err
+	}
+	return f.readData(ctx, f.db, f.AbsPath(filePath))
+}
+
+// ReadRange reads the byte range with the SQL substr function
+// without reading the rest of the file.
+func (f *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkReadable(); err != nil {
+		return nil, err
+	}
+	filePath = f.AbsPath(filePath)
+	e, err := f.getEntry(ctx, f.db, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if e.isDir {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	if offset < 0 {
+		// substr counts negative start positions from the end
+		offset = max(e.size+offset, 0)
+		length = e.size - offset
+	}
+	if length <= 0 || offset >= e.size {
+		return []byte{}, nil
+	}
+	var data []byte
+	err = f.db.QueryRowContext(ctx, `SELECT substr(data, ?, ?) FROM files WHERE path = ?`, offset+1, length, filePath).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+func (f *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	filePath = f.AbsPath(filePath)
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		return f.writeData(ctx, tx, filePath, data, perm)
+	})
+}
+
+func (f *fileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	filePath = f.AbsPath(filePath)
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		existing, err := f.readData(ctx, tx, filePath)
+		if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+			return err
+		}
+		return f.writeData(ctx, tx, filePath, append(existing, data...), perm)
+	})
+}
+
+func (f *fileSystem) Truncate(filePath string, newSize int64) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if newSize < 0 {
+		return errors.New("negative newSize for Truncate")
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	filePath = f.AbsPath(filePath)
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		data, err := f.readData(ctx, tx, filePath)
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) == newSize {
+			return nil
+		}
+		if int64(len(data)) > newSize {
+			data = data[:newSize]
+		} else {
+			data = append(data, make([]byte, newSize-int64(len(data)))...)
+		}
+		return f.writeData(ctx, tx, filePath, data, nil)
+	})
+}
+
+func (f *fileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkReadable(); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	filePath = f.AbsPath(filePath)
+	var (
+		info *fs.FileInfo
+		data []byte
+	)
+	// Read metadata and data in one transaction for a consistent snapshot
+	err := f.transaction(ctx, func(tx *sql.Tx) error {
+		e, err := f.getEntry(ctx, tx, filePath)
+		if err != nil {
+			return err
+		}
+		info = f.fileInfo(e)
+		data, err = f.readData(ctx, tx, filePath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fsimpl.NewReadonlyFileBuffer(data, info.StdFileInfo()), nil
+}
+
+// OpenWriter buffers the written data in memory
+// and writes the file when the writer is closed.
+func (f *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(nil, func() error {
+		return f.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// OpenReadWriter buffers the file in memory
+// and writes it back when closed.
+func (f *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return nil, err
+	}
+	data, err := f.ReadAll(context.Background(), filePath)
+	if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(data, func() error {
+		return f.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// CopyFile copies the data within the database
+func (f *fileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	srcFile = f.AbsPath(srcFile)
+	destFile = f.AbsPath(destFile)
+	if srcFile == destFile {
+		return nil
+	}
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		src, err := f.getEntry(ctx, tx, srcFile)
+		if err != nil {
+			return err
+		}
+		if src.isDir {
+			return fs.NewErrIsDirectory(f.File(srcFile))
+		}
+		err = f.writeData(ctx, tx, destFile, nil, []fs.Permissions{src.permissions})
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE files SET data = (SELECT data FROM files WHERE path = ?), size = ? WHERE path = ?`,
+			srcFile, src.size, destFile,
+		)
+		return err
+	})
+}
+
+// move moves the file or directory with all its descendants
+// from filePath to destPath in one transaction.
+// An existing file at destPath is replaced.
+func (f *fileSystem) move(ctx context.Context, filePath, destPath string) error {
+	if filePath == Separator {
+		return fmt.Errorf("can't move root directory of %s", f)
+	}
+	if destPath == filePath {
+		return nil
+	}
+	if strings.HasPrefix(destPath, filePath+Separator) {
+		return fmt.Errorf("can't move %s into itself", f.File(filePath))
+	}
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		e, err := f.getEntry(ctx, tx, filePath)
+		if err != nil {
+			return err
+		}
+		dest, err := f.getEntry(ctx, tx, destPath)
+		switch {
+		case err == nil && (dest.isDir || e.isDir):
+			return fs.NewErrAlreadyExists(f.File(destPath))
+		case err == nil:
+			_, err = tx.ExecContext(ctx, `DELETE FROM files WHERE path = ?`, destPath)
+			if err != nil {
+				return err
+			}
+		case !errors.Is(err, iofs.ErrNotExist):
+			return err
+		}
+		destParent, destName := path.Split(destPath)
+		destParent = f.AbsPath(destParent)
+		_, err = f.getDirEntry(ctx, tx, destParent)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE files SET path = ?, parent = ?, name = ?, modified = ? WHERE path = ?`,
+			destPath, destParent, destName, time.Now().UnixNano(), filePath,
+		)
+		if err != nil || !e.isDir {
+			return err
+		}
+		// Replace the path prefix of all descendants
+		lower, upper := descendantsRange(filePath)
+		_, err = tx.ExecContext(ctx,
+			`UPDATE files SET path = ?1 || substr(path, ?2), parent = ?1 || substr(parent, ?2) WHERE path > ?3 AND path < ?4`,
+			destPath, len(filePath)+1, lower, upper,
+		)
+		return err
+	})
+}
+
+func (f *fileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	if err := f.checkWritable(); err != nil {
+		return "", err
+	}
+	filePath = f.AbsPath(filePath)
+	newPath := path.Join(path.Dir(filePath), newName)
+	err := f.move(context.Background(), filePath, newPath)
+	if err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+// Move moves a file or directory.
+// If destPath is an existing directory, then the file is moved into it,
+// else an existing file at destPath is replaced.
+func (f *fileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	filePath = f.AbsPath(filePath)
+	destPath = f.AbsPath(destPath)
+	if _, err := f.getDirEntry(ctx, f.db, destPath); err == nil {
+		destPath = path.Join(destPath, path.Base(filePath))
+	}
+	return f.move(ctx, filePath, destPath)
+}
+
+// Remove deletes a file or an empty directory.
+func (f *fileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	filePath = f.AbsPath(filePath)
+	if filePath == Separator {
+		return fmt.Errorf("can't remove root directory of %s", f)
+	}
+	return f.transaction(ctx, func(tx *sql.Tx) error {
+		e, err := f.getEntry(ctx, tx, filePath)
+		if err != nil {
+			return err
+		}
+		if e.isDir {
+			var hasChildren bool
+			err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM files WHERE parent = ?)`, filePath).Scan(&hasChildren)
+			if err != nil {
+				return err
+			}
+			if hasChildren {
+				return fmt.Errorf("can't remove non-empty directory %s", f.File(filePath))
+			}
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM files WHERE path = ?`, filePath)
+		return err
+	})
+}
+
+// Close unregisters the file system and closes the database.
+func (f *fileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return f.db.Close()
+}
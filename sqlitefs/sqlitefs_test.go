@@ -0,0 +1,120 @@
+package sqlitefs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	fs "github.com/ungerik/go-fs"
+)
+
+func TestNewAndRegister(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "files.db")
+	sqliteFS, err := NewAndRegister(dbFile, false)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqliteFS.Close() })
+	require.Equal(t, "sqlite://"+filepath.ToSlash(dbFile), sqliteFS.Prefix())
+	require.Equal(t, fs.File("sqlite://"+filepath.ToSlash(dbFile)+"/"), sqliteFS.RootDir())
+	require.True(t, fs.IsRegistered(sqliteFS))
+	require.True(t, sqliteFS.RootDir().IsDir())
+
+	file := sqliteFS.RootDir().Join("dir", "file.txt")
+	require.Equal(t, sqliteFS, file.FileSystem())
+	require.Equal(t, "/dir/file.txt", file.Path())
+}
+
+func TestFileSystem(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "files.db")
+	sqliteFS, err := NewAndRegister(dbFile, false)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqliteFS.Close() })
+	ctx := context.Background()
+	root := sqliteFS.RootDir()
+
+	require.NoError(t, root.Join("dir", "sub").MakeAllDirs())
+	require.ErrorAs(t, sqliteFS.MakeDir("/dir", nil), new(fs.ErrAlreadyExists))
+	require.NoError(t, root.Join("dir", "b.txt").WriteAllString("Hello World!"))
+	require.NoError(t, root.Join("dir", "a.txt").Touch())
+	require.NoError(t, root.Join("dir", "sub", "c.txt").WriteAllString("c"))
+	require.ErrorIs(t, root.Join("missing", "file.txt").WriteAllString("x"), os.ErrNotExist)
+
+	files, err := root.Join("dir").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "b.txt", "sub"}, fs.FileNames(files), "sorted by name")
+	files, err = root.ListDirRecursiveMax(-1, "*.txt")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "b.txt", "c.txt"}, fs.FileNames(files))
+
+	file := root.Join("dir", "b.txt")
+	require.Equal(t, int64(12), file.Size())
+	require.False(t, file.IsDir())
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World!", str)
+	data, err := root.Join("dir", "a.txt").ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, data)
+	data, err = sqliteFS.(fs.ReadRangeFileSystem).ReadRange(ctx, "/dir/b.txt", 6, 5)
+	require.NoError(t, err)
+	require.Equal(t, []byte("World"), data)
+	data, err = sqliteFS.(fs.ReadRangeFileSystem).ReadRange(ctx, "/dir/b.txt", -2, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("d!"), data)
+	data, err = sqliteFS.(fs.ReadRangeFileSystem).ReadRange(ctx, "/dir/b.txt", 100, 5)
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, data)
+
+	require.NoError(t, file.AppendString(ctx, " Again"))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World! Again", str)
+	require.NoError(t, file.Truncate(5))
+	str, err = file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+
+	reader, err := file.OpenReader()
+	require.NoError(t, err)
+	info, err := reader.Stat()
+	require.NoError(t, err)
+	require.Equal(t, "b.txt", info.Name())
+	require.NoError(t, reader.Close())
+
+	require.NoError(t, fs.CopyFile(ctx, file, root.Join("copy.txt")))
+	str, err = root.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+
+	// Moving a directory moves all descendants
+	moved, err := root.Join("dir").Rename("moved")
+	require.NoError(t, err)
+	require.False(t, root.Join("dir").Exists())
+	str, err = moved.Join("sub", "c.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "c", str)
+	files, err = moved.Join("sub").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c.txt"}, fs.FileNames(files))
+	require.NoError(t, root.Join("copy.txt").MoveTo(moved.Join("sub")))
+	require.True(t, moved.Join("sub", "copy.txt").Exists())
+	require.Error(t, sqliteFS.(fs.MoveFileSystem).Move("/moved", "/moved/sub/moved"), "can't move into itself")
+
+	require.Error(t, moved.Join("sub").Remove(), "directory is not empty")
+	require.NoError(t, moved.Join("sub", "c.txt").Remove())
+	require.NoError(t, moved.Join("sub", "copy.txt").Remove())
+	require.NoError(t, moved.Join("sub").Remove())
+	require.False(t, moved.Join("sub").Exists())
+
+	// Files are persisted in the database
+	require.NoError(t, sqliteFS.Close())
+	readOnlyFS, err := NewAndRegister(dbFile, true)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = readOnlyFS.Close() })
+	str, err = readOnlyFS.RootDir().Join("moved", "b.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+	require.ErrorIs(t, readOnlyFS.RootDir().Join("new.txt").Touch(), fs.ErrReadOnlyFileSystem)
+}
@@ -3,6 +3,7 @@ package fs
 import (
 	"errors"
 	"fmt"
+	"io"
 	iofs "io/fs"
 	"sort"
 	"strings"
@@ -46,13 +47,22 @@ func (f StdFS) Sub(dir string) (iofs.FS, error) {
 }
 
 // Open opens the named file.
+// Directories are returned as io/fs.ReadDirFile.
 //
 // This method implements the io/fs.FS interface.
 func (f StdFS) Open(name string) (iofs.File, error) {
 	if err := checkStdFSName(name); err != nil {
 		return nil, err
 	}
-	return f.File.Join(name).OpenReader()
+	file := f.File.Join(name)
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &stdDir{stdFS: f, name: name, info: info}, nil
+	}
+	return file.OpenReader()
 }
 
 // ReadFile reads the named file and returns its contents.
@@ -125,6 +135,48 @@ func (f StdFS) ReadDir(name string) ([]iofs.DirEntry, error) {
 // 	return names, nil
 // }
 
+// stdDir implements io/fs.ReadDirFile for a directory of a StdFS
+type stdDir struct {
+	stdFS   StdFS
+	name    string
+	info    iofs.FileInfo
+	entries []iofs.DirEntry // nil until read by ReadDir
+	offset  int
+}
+
+func (d *stdDir) Stat() (iofs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *stdDir) Read([]byte) (int, error) {
+	return 0, &iofs.PathError{Op: "read", Path: d.name, Err: NewErrIsDirectory(d.stdFS.File.Join(d.name))}
+}
+
+func (d *stdDir) Close() error {
+	return nil
+}
+
+func (d *stdDir) ReadDir(n int) ([]iofs.DirEntry, error) {
+	if d.entries == nil {
+		entries, err := d.stdFS.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = append(make([]iofs.DirEntry, 0, len(entries)), entries...)
+	}
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
+
 func checkStdFSName(name string) error {
 	if name == "" {
 		return errors.New("empty filename")
@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"context"
+	"sync"
+)
+
+// strictFileSystems holds the prefixes
+// of file systems set to strict mode.
+// Entries are removed when the file system is unregistered.
+var strictFileSystems sync.Map
+
+type strictCtxKey struct{}
+
+// SetStrict sets the strict mode of fileSystem.
+//
+// File methods emulate operations that a file system
+// doesn't implement natively, like Append, Truncate, Rename,
+// OpenAppendWriter, and OpenWriteSeeker by reading and rewriting
+// whole files or Move within the file system by copying and deleting.
+// Such emulations can be surprisingly expensive
+// and are not atomic on remote file systems.
+// In strict mode these methods return an ErrUnsupported error
+// instead of emulating the operation, so that performance
+// sensitive code can detect the missing support and adapt.
+//
+// The strict mode can be overridden per call with ContextWithStrict
+// for Append and Move which have a context argument.
+// Truncate, Rename, OpenAppendWriter, and OpenWriteSeeker
+// have no context argument and only use the mode set with SetStrict.
+//
+// The strict mode is bound to the prefix of the registered
+// fileSystem and cleared by Unregister and Replace.
+func SetStrict(fileSystem FileSystem, strict bool) {
+	if strict {
+		strictFileSystems.Store(fileSystem.Prefix(), struct{}{})
+	} else {
+		strictFileSystems.Delete(fileSystem.Prefix())
+	}
+}
+
+// IsStrict returns if fileSystem was set
+// to strict mode with SetStrict.
+func IsStrict(fileSystem FileSystem) bool {
+	_, strict := strictFileSystems.Load(fileSystem.Prefix())
+	return strict
+}
+
+// ContextWithStrict returns a context that makes File methods
+// called with it use strict mode or not, independent of the
+// strict mode set for the file system with SetStrict.
+// See SetStrict for the methods that don't have a context argument.
+func ContextWithStrict(ctx context.Context, strict bool) context.Context {
+	return context.WithValue(ctx, strictCtxKey{}, strict)
+}
+
+// StrictFromContext returns the strict mode set with ContextWithStrict
+// and ok as false if ctx has no strict mode.
+func StrictFromContext(ctx context.Context) (strict, ok bool) {
+	strict, ok = ctx.Value(strictCtxKey{}).(bool)
+	return strict, ok
+}
+
+// isStrict returns if an operation on fileSystem
+// must not be emulated according to ctx or
+// the strict mode of fileSystem.
+func isStrict(ctx context.Context, fileSystem FileSystem) bool {
+	if strict, ok := StrictFromContext(ctx); ok {
+		return strict
+	}
+	return IsStrict(fileSystem)
+}
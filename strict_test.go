@@ -0,0 +1,97 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// basicFileSystem hides the optional interfaces
+// of the wrapped LocalFileSystem
+type basicFileSystem struct {
+	FileSystem
+}
+
+func (*basicFileSystem) Prefix() string { return "basic://" }
+
+func (b *basicFileSystem) RootDir() File { return File(b.Prefix() + "/") }
+
+func (b *basicFileSystem) URL(cleanPath string) string { return b.Prefix() + cleanPath }
+
+func (b *basicFileSystem) CleanPathFromURI(uri string) string {
+	return b.FileSystem.CleanPathFromURI(strings.TrimPrefix(uri, b.Prefix()))
+}
+
+func (b *basicFileSystem) JoinCleanFile(uriParts ...string) File {
+	return File(b.Prefix() + b.FileSystem.JoinCleanPath(uriParts...))
+}
+
+func TestSetStrict(t *testing.T) {
+	basicFS := &basicFileSystem{FileSystem: Local}
+	Register(basicFS)
+	t.Cleanup(func() { Unregister(basicFS) })
+	ctx := context.Background()
+
+	file := basicFS.JoinCleanFile(t.TempDir(), "file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+
+	// Emulated without strict mode
+	require.False(t, IsStrict(basicFS))
+	require.NoError(t, file.Append(ctx, []byte(" World")))
+	requireFileContent(t, file, "Hello World")
+
+	SetStrict(basicFS, true)
+	t.Cleanup(func() { SetStrict(basicFS, false) })
+	require.True(t, IsStrict(basicFS))
+	require.False(t, IsStrict(Local), "strict mode is per file system")
+
+	requireUnsupported := func(t *testing.T, err error) {
+		t.Helper()
+		require.True(t, errors.Is(err, errors.ErrUnsupported), "expected ErrUnsupported, got: %v", err)
+	}
+	requireUnsupported(t, file.Append(ctx, []byte("!")))
+	requireUnsupported(t, file.Truncate(5))
+	_, err := file.OpenAppendWriter()
+	requireUnsupported(t, err)
+	_, err = file.OpenWriteSeeker()
+	requireUnsupported(t, err)
+	_, err = file.Rename("renamed.txt")
+	requireUnsupported(t, err)
+	requireUnsupported(t, Move(ctx, file, file.Dir().Join("moved.txt")))
+	requireFileContent(t, file, "Hello World")
+
+	// Native operations are not affected
+	require.NoError(t, File(basicFS.CleanPathFromURI(string(file))).Append(ctx, []byte("!")))
+	requireFileContent(t, file, "Hello World!")
+
+	// Context overrides strict mode of file system
+	require.NoError(t, file.Append(ContextWithStrict(ctx, false), []byte("?")))
+	requireFileContent(t, file, "Hello World!?")
+	SetStrict(basicFS, false)
+	requireUnsupported(t, file.Append(ContextWithStrict(ctx, true), []byte("!")))
+	strict, ok := StrictFromContext(ContextWithStrict(ctx, true))
+	require.True(t, strict && ok)
+	_, ok = StrictFromContext(ctx)
+	require.False(t, ok)
+}
+
+func TestSetStrict_Unregister(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	SetStrict(memFS, true)
+	require.True(t, IsStrict(memFS))
+	require.NoError(t, memFS.Close())
+	require.False(t, IsStrict(memFS), "cleared by Unregister")
+
+	basicFS := &basicFileSystem{FileSystem: Local}
+	Register(basicFS)
+	t.Cleanup(func() { Unregister(basicFS) })
+	SetStrict(basicFS, true)
+	replacement := &basicFileSystem{FileSystem: Local}
+	require.NoError(t, Replace(basicFS, replacement))
+	basicFS = replacement
+	require.False(t, IsStrict(replacement), "cleared by Replace")
+}
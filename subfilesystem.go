@@ -2,215 +2,430 @@ package fs
 
 import (
 	"context"
+	"fmt"
 	iofs "io/fs"
-	"path/filepath"
+	"path"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/ungerik/go-fs/fsimpl"
 )
 
-// todoSubFileSystemPrefix is the URI prefix used to identify SubFileSystem files
-const todoSubFileSystemPrefix = "sub://"
+// SubFileSystemPrefix is the URI prefix used to identify SubFileSystem files
+const SubFileSystemPrefix = "sub://"
+
+var (
+	_ FileSystem                 = new(SubFileSystem)
+	_ ExistsFileSystem           = new(SubFileSystem)
+	_ ReadAllFileSystem          = new(SubFileSystem)
+	_ WriteAllFileSystem         = new(SubFileSystem)
+	_ AtomicWriteFileSystem      = new(SubFileSystem)
+	_ HardLinkFileSystem         = new(SubFileSystem)
+	_ AppendFileSystem           = new(SubFileSystem)
+	_ AppendWriterFileSystem     = new(SubFileSystem)
+	_ TouchFileSystem            = new(SubFileSystem)
+	_ TruncateFileSystem         = new(SubFileSystem)
+	_ MakeAllDirsFileSystem      = new(SubFileSystem)
+	_ CopyFileSystem             = new(SubFileSystem)
+	_ RenameFileSystem           = new(SubFileSystem)
+	_ MoveFileSystem             = new(SubFileSystem)
+	_ ListDirRecursiveFileSystem = new(SubFileSystem)
+	_ PermissionsFileSystem      = new(SubFileSystem)
+	_ UserFileSystem             = new(SubFileSystem)
+	_ GroupFileSystem            = new(SubFileSystem)
+)
+
+// SubFileSystem is a file system rooted at a directory
+// of any other file system, similar to io/fs.Sub
+// but for the full read-write FileSystem interface.
+//
+// It is registered with the prefix "sub://" followed by a random ID,
+// so the base directory is accessed as "sub://<id>/".
+// Paths use "/" as separator independent of the base file system.
+//
+// Paths that use ".." to escape the root directory are rejected
+// with ErrPathOutsideRoot, but symbolic links
+// in the base file system are followed.
+type SubFileSystem struct {
+	prefix string
+	base   File
+	closed atomic.Bool
+}
+
+// NewSubFileSystem returns a new registered SubFileSystem
+// rooted at the directory base.
+func NewSubFileSystem(base File) (*SubFileSystem, error) {
+	if base == "" {
+		return nil, ErrEmptyPath
+	}
+	err := base.CheckIsDir()
+	if err != nil {
+		return nil, err
+	}
+	subfs := &SubFileSystem{
+		prefix: SubFileSystemPrefix + fsimpl.RandomString(),
+		base:   base,
+	}
+	_, err = TryRegister(subfs)
+	if err != nil {
+		return nil, err
+	}
+	return subfs, nil
+}
 
-type todoSubFileSystem struct {
-	prefix   string
-	Parent   FileSystem
-	BasePath string
+// Base returns the root directory of the SubFileSystem
+// in the base file system.
+func (subfs *SubFileSystem) Base() File {
+	return subfs.base
 }
 
-func todoNewSubFileSystem(parent FileSystem, basePath string) *todoSubFileSystem {
-	subfs := &todoSubFileSystem{
-		prefix:   todoSubFileSystemPrefix + fsimpl.RandomString(),
-		Parent:   parent,
-		BasePath: basePath,
+// baseFile returns the file in the base file system for filePath
+// or ErrPathOutsideRoot if filePath escapes the root.
+func (subfs *SubFileSystem) baseFile(filePath string) (File, error) {
+	if filePath == "" {
+		return "", ErrEmptyPath
 	}
-	Register(subfs)
-	return subfs
+	if subfs.closed.Load() {
+		return "", ErrFileSystemClosed
+	}
+	relPath := path.Clean(strings.TrimLeft(filePath, "/"))
+	// Also split at backslashes because they are
+	// path separators of base file systems on Windows
+	segments := strings.FieldsFunc(relPath, func(r rune) bool { return r == '/' || r == '\\' })
+	if slices.Contains(segments, "..") {
+		return "", fmt.Errorf("%w: %s", ErrPathOutsideRoot, filePath)
+	}
+	return subfs.base.Join(subfs.SplitPath(relPath)...), nil
 }
 
-func (subfs *todoSubFileSystem) ReadableWritable() (readable, writable bool) {
-	return subfs.Parent.ReadableWritable()
+// subFile returns the File of the SubFileSystem
+// for a file of the base file system.
+func (subfs *SubFileSystem) subFile(baseFile File) File {
+	baseFS, basePath := subfs.base.ParseRawURI()
+	_, filePath := baseFile.ParseRawURI()
+	baseParts := baseFS.SplitPath(basePath)
+	fileParts := baseFS.SplitPath(filePath)
+	return subfs.JoinCleanFile(fileParts[min(len(baseParts), len(fileParts)):]...)
 }
 
-func (subfs *todoSubFileSystem) RootDir() File {
-	return File(subfs.Parent.Separator())
+func (subfs *SubFileSystem) ReadableWritable() (readable, writable bool) {
+	return subfs.base.FileSystem().ReadableWritable()
 }
 
-func (subfs *todoSubFileSystem) ID() (string, error) {
-	parentID, err := subfs.Parent.ID()
-	if err != nil {
-		return "", err
-	}
-	return parentID + "/" + subfs.BasePath, nil
+func (subfs *SubFileSystem) RootDir() File {
+	return File(subfs.prefix + "/")
+}
+
+func (subfs *SubFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(subfs.prefix, SubFileSystemPrefix), nil
 }
 
-func (subfs *todoSubFileSystem) Prefix() string {
+func (subfs *SubFileSystem) Prefix() string {
 	return subfs.prefix
 }
 
-func (subfs *todoSubFileSystem) Name() string {
-	return "Sub file system of " + subfs.Parent.Name()
+func (subfs *SubFileSystem) Name() string {
+	return "sub file system"
 }
 
 // String implements the fmt.Stringer interface.
-func (subfs *todoSubFileSystem) String() string {
-	return subfs.Name() + " with prefix " + subfs.Prefix()
+func (subfs *SubFileSystem) String() string {
+	return fmt.Sprintf("%s with prefix %s of %s", subfs.Name(), subfs.prefix, subfs.base)
 }
 
-///////////////////////////////////////////////////
-// TODO Replace implementation with real SubFileSystem from here on:
-///////////////////////////////////////////////////
-
-func (subfs *todoSubFileSystem) JoinCleanFile(uri ...string) File {
-	if len(uri) == 0 {
-		panic("SubFileSystem uri must not be empty")
-	}
+func (subfs *SubFileSystem) JoinCleanFile(uriParts ...string) File {
+	return File(subfs.prefix + subfs.JoinCleanPath(uriParts...))
+}
 
-	return File(filepath.Clean(filepath.Join(uri...)))
+func (subfs *SubFileSystem) URL(cleanPath string) string {
+	return subfs.prefix + cleanPath
 }
 
-func (subfs *todoSubFileSystem) URN(filePath string) string {
-	return filepath.ToSlash(filePath)
+func (subfs *SubFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, subfs.prefix)
 }
 
-func (subfs *todoSubFileSystem) URL(filePath string) string {
-	return subfs.prefix + subfs.URN(filePath)
+func (subfs *SubFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(append([]string{"/"}, uriParts...), subfs.prefix, "/")
 }
 
-func (subfs *todoSubFileSystem) CleanPathFromURI(uri string) string {
-	return strings.TrimPrefix(uri, subfs.prefix)
+func (subfs *SubFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, subfs.prefix, "/")
 }
 
-func (subfs *todoSubFileSystem) JoinCleanPath(uri ...string) string {
-	return subfs.prefix + subfs.Parent.JoinCleanPath(uri...)
+func (*SubFileSystem) Separator() string {
+	return "/"
 }
 
-func (subfs *todoSubFileSystem) SplitPath(filePath string) []string {
-	return subfs.Parent.SplitPath(filePath)
+func (subfs *SubFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return subfs.base.FileSystem().MatchAnyPattern(name, patterns)
 }
 
-func (subfs *todoSubFileSystem) Separator() string {
-	return subfs.Parent.Separator()
+func (*SubFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, "/")
 }
 
-// MatchAnyPattern returns true if name matches any of patterns,
-// or if len(patterns) == 0.
-// The match per pattern works like path.Match or filepath.Match
-func (subfs *todoSubFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
-	return subfs.Parent.MatchAnyPattern(name, patterns)
+func (*SubFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
 }
 
-func (subfs *todoSubFileSystem) SplitDirAndName(filePath string) (dir, name string) {
-	panic("TODO")
+func (*SubFileSystem) AbsPath(filePath string) string {
+	return fsimpl.CleanPath(filePath, "/")
 }
 
-func (subfs *todoSubFileSystem) VolumeName(filePath string) string {
-	if fs, ok := subfs.Parent.(VolumeNameFileSystem); ok {
-		return fs.VolumeName(filePath)
+func (subfs *SubFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return nil, err
 	}
-	return ""
+	return file.Stat()
 }
 
-func (subfs *todoSubFileSystem) IsAbsPath(filePath string) bool {
-	return subfs.Parent.IsAbsPath(filePath)
+func (subfs *SubFileSystem) Exists(filePath string) bool {
+	file, err := subfs.baseFile(filePath)
+	return err == nil && file.Exists()
 }
 
-func (subfs *todoSubFileSystem) AbsPath(filePath string) string {
-	return subfs.Parent.AbsPath(filePath)
+func (subfs *SubFileSystem) IsHidden(filePath string) bool {
+	file, err := subfs.baseFile(filePath)
+	return err == nil && file.IsHidden()
 }
 
-func (subfs *todoSubFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
-	return subfs.Parent.Stat(filePath)
+func (subfs *SubFileSystem) IsSymbolicLink(filePath string) bool {
+	file, err := subfs.baseFile(filePath)
+	return err == nil && file.IsSymbolicLink()
 }
 
-func (subfs *todoSubFileSystem) Exists(filePath string) bool {
-	if fs, ok := subfs.Parent.(ExistsFileSystem); ok {
-		return fs.Exists(filePath)
+func (subfs *SubFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
+	dir, err := subfs.baseFile(dirPath)
+	if err != nil {
+		return err
 	}
-	_, err := subfs.Parent.Stat(filePath)
-	return err != nil
+	return dir.ListDirInfoContext(ctx, func(baseInfo *FileInfo) error {
+		info := *baseInfo
+		info.File = subfs.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns...)
 }
 
-func (subfs *todoSubFileSystem) IsHidden(filePath string) bool {
-	return subfs.Parent.IsHidden(filePath)
+func (subfs *SubFileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
+	dir, err := subfs.baseFile(dirPath)
+	if err != nil {
+		return err
+	}
+	return dir.ListDirInfoRecursiveContext(ctx, func(baseInfo *FileInfo) error {
+		info := *baseInfo
+		info.File = subfs.subFile(baseInfo.File)
+		return callback(&info)
+	}, patterns...)
+}
+
+func (subfs *SubFileSystem) SetPermissions(filePath string, perm Permissions) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.SetPermissions(perm)
+}
+
+func (subfs *SubFileSystem) User(filePath string) (string, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return file.User()
 }
 
-func (subfs *todoSubFileSystem) IsSymbolicLink(filePath string) bool {
-	return subfs.Parent.IsSymbolicLink(filePath)
+func (subfs *SubFileSystem) SetUser(filePath string, user string) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.SetUser(user)
 }
 
-func (subfs *todoSubFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
-	panic("TODO")
+func (subfs *SubFileSystem) Group(filePath string) (string, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return file.Group()
 }
 
-func (subfs *todoSubFileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*FileInfo) error, patterns []string) error {
-	panic("TODO")
+func (subfs *SubFileSystem) SetGroup(filePath string, group string) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.SetGroup(group)
+}
+
+func (subfs *SubFileSystem) Touch(filePath string, perm []Permissions) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.Touch(perm...)
 }
 
-func (subfs *todoSubFileSystem) ListDirMax(ctx context.Context, dirPath string, max int, patterns []string) (files []File, err error) {
-	panic("TODO")
+func (subfs *SubFileSystem) MakeDir(dirPath string, perm []Permissions) error {
+	dir, err := subfs.baseFile(dirPath)
+	if err != nil {
+		return err
+	}
+	fileSystem, basePath := dir.ParseRawURI()
+	return fileSystem.MakeDir(basePath, perm)
 }
 
-func (subfs *todoSubFileSystem) SetPermissions(filePath string, perm Permissions) error {
-	panic("TODO")
+func (subfs *SubFileSystem) MakeAllDirs(dirPath string, perm []Permissions) error {
+	dir, err := subfs.baseFile(dirPath)
+	if err != nil {
+		return err
+	}
+	return dir.MakeAllDirs(perm...)
 }
 
-func (subfs *todoSubFileSystem) User(filePath string) (string, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.ReadAllContext(ctx)
 }
 
-func (subfs *todoSubFileSystem) SetUser(filePath string, user string) error {
-	panic("TODO")
+func (subfs *SubFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.WriteAllContext(ctx, data, perm...)
 }
 
-func (subfs *todoSubFileSystem) Group(filePath string) (string, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) WriteAllAtomic(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.WriteAllAtomic(ctx, data, perm...)
 }
 
-func (subfs *todoSubFileSystem) SetGroup(filePath string, group string) error {
-	panic("TODO")
+func (subfs *SubFileSystem) CreateHardLink(existingPath, newPath string) error {
+	existingFile, err := subfs.baseFile(existingPath)
+	if err != nil {
+		return err
+	}
+	newFile, err := subfs.baseFile(newPath)
+	if err != nil {
+		return err
+	}
+	return existingFile.LinkTo(newFile)
 }
 
-func (subfs *todoSubFileSystem) Touch(filePath string, perm []Permissions) error {
-	panic("TODO")
+func (subfs *SubFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.Append(ctx, data, perm...)
 }
 
-func (subfs *todoSubFileSystem) MakeDir(filePath string, perm []Permissions) error {
-	panic("TODO")
+func (subfs *SubFileSystem) OpenReader(filePath string) (ReadCloser, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.OpenReader()
 }
 
-func (subfs *todoSubFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) OpenWriter(filePath string, perm []Permissions) (WriteCloser, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.OpenWriter(perm...)
 }
 
-func (subfs *todoSubFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []Permissions) error {
-	panic("TODO")
+func (subfs *SubFileSystem) OpenAppendWriter(filePath string, perm []Permissions) (WriteCloser, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.OpenAppendWriter(perm...)
 }
 
-func (subfs *todoSubFileSystem) OpenReader(filePath string) (ReadCloser, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) OpenReadWriter(filePath string, perm []Permissions) (ReadWriteSeekCloser, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.OpenReadWriter(perm...)
 }
 
-func (subfs *todoSubFileSystem) OpenWriter(filePath string, perm []Permissions) (WriteCloser, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) Truncate(filePath string, size int64) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	return file.Truncate(size)
 }
 
-func (subfs *todoSubFileSystem) OpenAppendWriter(filePath string, perm []Permissions) (WriteCloser, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	src, err := subfs.baseFile(srcFile)
+	if err != nil {
+		return err
+	}
+	dest, err := subfs.baseFile(destFile)
+	if err != nil {
+		return err
+	}
+	return CopyFileBuf(ctx, src, dest, buf)
 }
 
-func (subfs *todoSubFileSystem) OpenReadWriter(filePath string, perm []Permissions) (ReadWriteSeekCloser, error) {
-	panic("TODO")
+func (subfs *SubFileSystem) Rename(filePath string, newName string) (string, error) {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(newName, "/") {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	_, err = file.Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(subfs.AbsPath(filePath)), newName), nil
 }
 
-func (subfs *todoSubFileSystem) Truncate(filePath string, size int64) error {
-	panic("TODO")
+func (subfs *SubFileSystem) Move(filePath string, destPath string) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	dest, err := subfs.baseFile(destPath)
+	if err != nil {
+		return err
+	}
+	return file.MoveTo(dest)
 }
 
-func (subfs *todoSubFileSystem) Remove(filePath string) error {
-	panic("TODO")
+func (subfs *SubFileSystem) Remove(filePath string) error {
+	file, err := subfs.baseFile(filePath)
+	if err != nil {
+		return err
+	}
+	if file == subfs.base {
+		return fmt.Errorf("can't remove root directory of %s", subfs)
+	}
+	return file.Remove()
 }
 
-func (subfs *todoSubFileSystem) Close() error {
-	panic("TODO")
+// Close unregisters the file system,
+// the base file system is not closed.
+func (subfs *SubFileSystem) Close() error {
+	if subfs.closed.Swap(true) {
+		return nil // already closed
+	}
+	Unregister(subfs)
+	return nil
 }
@@ -0,0 +1,80 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubFileSystem(t *testing.T) {
+	baseDir := File(t.TempDir())
+	require.NoError(t, baseDir.Join("outside.txt").WriteAllString("outside"))
+	require.NoError(t, baseDir.Join("sub", "dir").MakeAllDirs())
+	require.NoError(t, baseDir.Join("sub", "dir", "file.txt").WriteAllString("Hello"))
+
+	_, err := NewSubFileSystem(baseDir.Join("outside.txt"))
+	require.Error(t, err, "base is not a directory")
+
+	subFS, err := NewSubFileSystem(baseDir.Join("sub"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = subFS.Close() })
+	require.True(t, IsRegistered(subFS))
+	root := subFS.RootDir()
+	require.True(t, root.IsDir())
+
+	file := root.Join("dir", "file.txt")
+	require.Equal(t, "/dir/file.txt", file.Path())
+	requireFileContent(t, file, "Hello")
+	require.NoError(t, file.AppendString(context.Background(), " World"))
+	requireFileContent(t, baseDir.Join("sub", "dir", "file.txt"), "Hello World")
+
+	require.NoError(t, root.Join("new.txt").WriteAllString("new"))
+	requireFileContent(t, baseDir.Join("sub", "new.txt"), "new")
+
+	files, err := root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []File{root.Join("dir"), root.Join("new.txt")}, files)
+	var recursive []File
+	err = root.ListDirRecursive(func(f File) error {
+		recursive = append(recursive, f)
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []File{file, root.Join("new.txt")}, recursive)
+
+	renamed, err := root.Join("new.txt").Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, root.Join("renamed.txt"), renamed)
+	require.True(t, baseDir.Join("sub", "renamed.txt").Exists())
+	require.NoError(t, renamed.Remove())
+	require.False(t, baseDir.Join("sub", "renamed.txt").Exists())
+	require.Error(t, subFS.Remove("/"), "can't remove root")
+
+	// Paths escaping the root are rejected
+	for _, escape := range []string{"..", "/../outside.txt", "dir/../../outside.txt", `..\outside.txt`, `dir\..\..\outside.txt`} {
+		_, err = subFS.ReadAll(context.Background(), escape)
+		require.ErrorIs(t, err, ErrPathOutsideRoot, escape)
+		require.ErrorIs(t, subFS.WriteAll(context.Background(), escape, []byte("x"), nil), ErrPathOutsideRoot, escape)
+		require.False(t, subFS.Exists(escape), escape)
+	}
+	requireFileContent(t, baseDir.Join("outside.txt"), "outside")
+	data, err := subFS.ReadAll(context.Background(), "dir/../dir/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(data))
+
+	require.NoError(t, subFS.Close())
+	require.False(t, IsRegistered(subFS))
+	_, err = subFS.Stat("/dir")
+	require.ErrorIs(t, err, ErrFileSystemClosed)
+	_, err = os.Stat(baseDir.Join("sub").LocalPath())
+	require.NoError(t, err, "base not removed")
+}
+
+func requireFileContent(t *testing.T, file File, expected string) {
+	t.Helper()
+	content, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, expected, content)
+}
@@ -0,0 +1,53 @@
+package fs
+
+import "fmt"
+
+// CreateSymbolicLinkTo creates the file as symbolic link to target.
+// The path of target is stored as target of the link.
+//
+// Both files must be on the same file system
+// that has to implement SymlinkFileSystem,
+// else an ErrUnsupported error is returned.
+func (file File) CreateSymbolicLinkTo(target File) error {
+	if file == "" || target == "" {
+		return ErrEmptyPath
+	}
+	fileSystem, linkPath := file.ParseRawURI()
+	targetFileSystem, targetPath := target.ParseRawURI()
+	if fileSystem != targetFileSystem {
+		return fmt.Errorf("can't create symbolic link %s to %s on a different file system", file, target)
+	}
+	symlinkFS, ok := fileSystem.(SymlinkFileSystem)
+	if !ok {
+		return NewErrUnsupported(fileSystem, "CreateSymlink")
+	}
+	defer beginOp(fileSystem)()
+	return symlinkFS.CreateSymlink(targetPath, linkPath)
+}
+
+// ReadSymbolicLink returns the target of the symbolic link file.
+// A relative target path is joined to the directory of the file.
+//
+// Returns an ErrUnsupported error if the file system
+// does not implement SymlinkFileSystem.
+func (file File) ReadSymbolicLink() (target File, err error) {
+	if file == "" {
+		return "", ErrEmptyPath
+	}
+	fileSystem, linkPath := file.ParseRawURI()
+	symlinkFS, ok := fileSystem.(SymlinkFileSystem)
+	if !ok {
+		return "", NewErrUnsupported(fileSystem, "ReadSymlink")
+	}
+	endOp := beginOp(fileSystem)
+	targetPath, err := symlinkFS.ReadSymlink(linkPath)
+	endOp()
+	if err != nil {
+		return "", err
+	}
+	if !fileSystem.IsAbsPath(targetPath) {
+		dir, _ := fileSystem.SplitDirAndName(linkPath)
+		return fileSystem.JoinCleanFile(dir, targetPath), nil
+	}
+	return fileSystem.JoinCleanFile(targetPath), nil
+}
@@ -0,0 +1,62 @@
+package fs
+
+import (
+	iofs "io/fs"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSymbolicLinks(t *testing.T, dir File) {
+	t.Helper()
+
+	target := dir.Join("target.txt")
+	require.NoError(t, target.WriteAllString("content"))
+
+	link := dir.Join("link.txt")
+	require.NoError(t, link.CreateSymbolicLinkTo(target))
+	require.True(t, link.IsSymbolicLink())
+	require.False(t, target.IsSymbolicLink())
+
+	linked, err := link.ReadSymbolicLink()
+	require.NoError(t, err)
+	require.Equal(t, target, linked)
+
+	_, err = target.ReadSymbolicLink()
+	require.Error(t, err, "not a symbolic link")
+	require.Error(t, link.CreateSymbolicLinkTo(target), "link exists")
+}
+
+func TestFile_SymbolicLink_LocalFileSystem(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links needs privileges on Windows")
+	}
+	testSymbolicLinks(t, File(t.TempDir()))
+
+	// Relative targets are resolved to the directory of the link
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("sub").MakeDir())
+	require.NoError(t, Local.CreateSymlink("../target.txt", dir.Join("sub", "link.txt").Path()))
+	linked, err := dir.Join("sub", "link.txt").ReadSymbolicLink()
+	require.NoError(t, err)
+	require.Equal(t, dir.Join("target.txt"), linked)
+
+	// Deprecated File based methods
+	require.NoError(t, Local.CreateSymbolicLink(dir.Join("target.txt"), dir.Join("old-link.txt")))
+	linked, err = Local.ReadSymbolicLink(dir.Join("old-link.txt"))
+	require.NoError(t, err)
+	require.Equal(t, File(dir.Join("target.txt").Path()), linked)
+}
+
+func TestFile_SymbolicLink_MemFileSystem(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+
+	testSymbolicLinks(t, memFS.RootDir())
+
+	info, err := memFS.RootDir().Join("link.txt").Stat()
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode()&iofs.ModeSymlink)
+}
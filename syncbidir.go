@@ -0,0 +1,434 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SyncManifest is the state of two directories
+// after their last synchronization with SyncBidirectional.
+type SyncManifest struct {
+	// Files maps the slash separated paths of the
+	// synchronized files relative to the directories to their state
+	Files map[string]SyncManifestEntry `json:"files"`
+}
+
+// SyncManifestEntry is the state of a synchronized file
+type SyncManifestEntry struct {
+	Size      int64     `json:"size"`
+	ModifiedA time.Time `json:"modifiedA"`
+	ModifiedB time.Time `json:"modifiedB"`
+}
+
+// SyncConflict is a file that was changed in both directories
+// since the last synchronization or changed in one
+// and deleted in the other directory.
+type SyncConflict struct {
+	// Path is slash separated and relative to the directories
+	Path string
+	// A is the file in directory a or nil if it was deleted
+	A *FileInfo
+	// B is the file in directory b or nil if it was deleted
+	B *FileInfo
+	// ModTime is the comparison of modification times
+	// configured with BidirectionalSyncOptions.ModTime
+	ModTime ModTimeComparison
+}
+
+// SyncResolution of a SyncConflict
+type SyncResolution int
+
+const (
+	// SyncKeepA keeps the version of directory a
+	// which can also be a deletion
+	SyncKeepA SyncResolution = iota
+	// SyncKeepB keeps the version of directory b
+	// which can also be a deletion
+	SyncKeepB
+	// SyncKeepBoth keeps the version of directory a at the path
+	// and saves the version of directory b in both directories
+	// with " (conflict)" inserted before the extension of the file name.
+	// If a version was deleted, then the other one is kept.
+	SyncKeepBoth
+	// SyncSkip leaves both versions unchanged so that
+	// the conflict will be reported again by the next synchronization
+	SyncSkip
+)
+
+// SyncConflictResolver decides how a SyncConflict is resolved
+type SyncConflictResolver func(ctx context.Context, conflict SyncConflict) (SyncResolution, error)
+
+// SyncNewerWins is a SyncConflictResolver that keeps the
+// version with the newer modification time according to
+// SyncConflict.ModTime and prefers existing over deleted versions.
+// The version of directory a is kept if the modification
+// times are considered equal.
+func SyncNewerWins(ctx context.Context, conflict SyncConflict) (SyncResolution, error) {
+	switch {
+	case conflict.B == nil:
+		return SyncKeepA, nil
+	case conflict.A == nil:
+		return SyncKeepB, nil
+	case conflict.ModTime.After(conflict.B.Modified, conflict.A.Modified):
+		return SyncKeepB, nil
+	default:
+		return SyncKeepA, nil
+	}
+}
+
+// SyncKeepBothVersions is a SyncConflictResolver that always returns SyncKeepBoth
+func SyncKeepBothVersions(ctx context.Context, conflict SyncConflict) (SyncResolution, error) {
+	return SyncKeepBoth, nil
+}
+
+// BidirectionalSyncOptions configure SyncBidirectional
+type BidirectionalSyncOptions struct {
+	// Manifest is the JSON file that stores the SyncManifest
+	// between synchronizations. It is required and should be
+	// located outside of the synchronized directories.
+	Manifest File
+
+	// Resolve decides how conflicts are resolved,
+	// SyncNewerWins is used if nil.
+	Resolve SyncConflictResolver
+
+	// Exclude contains patterns of file and directory names
+	// that are not synchronized.
+	Exclude []string
+
+	// ModTime compares the modification times of the files
+	// with the ones stored in the manifest to detect changes
+	// and is passed to Resolve as SyncConflict.ModTime.
+	// The zero value compares the exact times.
+	ModTime ModTimeComparison
+}
+
+// BidirectionalSyncReport lists the actions taken by SyncBidirectional.
+// Paths are slash separated and relative to the synchronized directories.
+type BidirectionalSyncReport struct {
+	CopiedToA    []string
+	CopiedToB    []string
+	DeletedFromA []string
+	DeletedFromB []string
+	// Conflicts lists all conflicts including skipped ones
+	Conflicts []string
+}
+
+// SyncBidirectional synchronizes the files of the directories a and b
+// which can be on different file systems.
+//
+// Changes since the last synchronization are detected by comparing
+// the sizes and modification times of the files with the state
+// stored in the SyncManifest file of the options.
+// New and changed files are copied to the other directory
+// and deleted files are deleted in the other directory.
+// Moved files are propagated as deletion and creation.
+// Files that changed in both directories are passed
+// to the SyncConflictResolver of the options,
+// except if they have the same content.
+//
+// File paths of a and b and of the manifest are matched after
+// normalization with NormalizeName if enabled with SetNormalizeNames,
+// the listed names are kept for existing files and used for copies.
+//
+// Only files are synchronized, empty directories are ignored.
+// Without a manifest file, files that exist in both directories
+// with different content are conflicts.
+// The manifest is saved after synchronizing all files,
+// also if an error occurred for a file.
+func SyncBidirectional(ctx context.Context, a, b File, opts BidirectionalSyncOptions) (report *BidirectionalSyncReport, err error) {
+	if opts.Manifest == "" {
+		return nil, fmt.Errorf("SyncBidirectional: no manifest file")
+	}
+	if opts.Resolve == nil {
+		opts.Resolve = SyncNewerWins
+	}
+	var manifest SyncManifest
+	if opts.Manifest.Exists() {
+		err = opts.Manifest.ReadJSON(ctx, &manifest)
+		if err != nil {
+			return nil, fmt.Errorf("SyncBidirectional: can't read manifest: %w", err)
+		}
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]SyncManifestEntry)
+	}
+	for relPath, entry := range manifest.Files {
+		if key := matchName(relPath); key != relPath {
+			delete(manifest.Files, relPath)
+			manifest.Files[key] = entry
+		}
+	}
+	listed := make(map[string]string)
+	filesA, err := syncScanFiles(ctx, a, opts.Exclude, listed)
+	if err != nil {
+		return nil, fmt.Errorf("SyncBidirectional: %w", err)
+	}
+	filesB, err := syncScanFiles(ctx, b, opts.Exclude, listed)
+	if err != nil {
+		return nil, fmt.Errorf("SyncBidirectional: %w", err)
+	}
+
+	s := &bidirectionalSync{
+		a:        a,
+		b:        b,
+		filesA:   filesA,
+		filesB:   filesB,
+		listed:   listed,
+		opts:     &opts,
+		manifest: &manifest,
+		report:   new(BidirectionalSyncReport),
+	}
+	relPaths := slices.Collect(maps.Keys(filesA))
+	relPaths = slices.AppendSeq(relPaths, maps.Keys(filesB))
+	relPaths = slices.AppendSeq(relPaths, maps.Keys(manifest.Files))
+	slices.Sort(relPaths)
+	for _, relPath := range slices.Compact(relPaths) {
+		err = s.syncFile(ctx, relPath, filesA[relPath], filesB[relPath])
+		if err != nil {
+			err = fmt.Errorf("SyncBidirectional: %s: %w", s.listedPath(relPath), err)
+			break
+		}
+	}
+
+	if e := opts.Manifest.WriteJSON(ctx, &manifest, "  "); e != nil && err == nil {
+		err = fmt.Errorf("SyncBidirectional: can't write manifest: %w", e)
+	}
+	return s.report, err
+}
+
+// syncScanFiles returns the FileInfo of all files in dir
+// and its sub-directories by slash separated relative path
+// normalized with matchName.
+// The first listed relative path per normalized path
+// is added to listed.
+func syncScanFiles(ctx context.Context, dir File, excludePatterns []string, listed map[string]string) (map[string]*FileInfo, error) {
+	files := make(map[string]*FileInfo)
+	if !dir.Exists() {
+		return files, nil
+	}
+	fileSystem := dir.FileSystem()
+	dirPrefix := strings.TrimSuffix(dir.PathWithSlashes(), "/") + "/"
+	err := dir.ListDirInfoRecursiveContext(ctx, func(info *FileInfo) error {
+		relPath := strings.TrimPrefix(info.File.PathWithSlashes(), dirPrefix)
+		if len(excludePatterns) > 0 {
+			for _, name := range strings.Split(relPath, "/") {
+				excluded, err := fileSystem.MatchAnyPattern(name, excludePatterns)
+				if excluded || err != nil {
+					return err
+				}
+			}
+		}
+		key := matchName(relPath)
+		if _, ok := listed[key]; !ok {
+			listed[key] = relPath
+		}
+		infoCopy := *info
+		files[key] = &infoCopy
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+type bidirectionalSync struct {
+	a, b           File
+	filesA, filesB map[string]*FileInfo
+	listed         map[string]string
+	opts           *BidirectionalSyncOptions
+	manifest       *SyncManifest
+	report         *BidirectionalSyncReport
+}
+
+// listedPath returns the listed relative path
+// for the normalized relPath
+func (s *bidirectionalSync) listedPath(relPath string) string {
+	if listed, ok := s.listed[relPath]; ok {
+		return listed
+	}
+	return relPath
+}
+
+// file returns the listed file of dir for the normalized relPath
+// or the file with the listed path of the other directory
+func (s *bidirectionalSync) file(dir File, relPath string) File {
+	files := s.filesA
+	if dir == s.b {
+		files = s.filesB
+	}
+	if info, ok := files[relPath]; ok {
+		return info.File
+	}
+	return syncJoin(dir, s.listedPath(relPath))
+}
+
+func (s *bidirectionalSync) syncFile(ctx context.Context, relPath string, infoA, infoB *FileInfo) error {
+	entry, synced := s.manifest.Files[relPath]
+	changedA := infoA != nil && (!synced || infoA.Size != entry.Size || !s.opts.ModTime.Equal(infoA.Modified, entry.ModifiedA))
+	changedB := infoB != nil && (!synced || infoB.Size != entry.Size || !s.opts.ModTime.Equal(infoB.Modified, entry.ModifiedB))
+	deletedA := infoA == nil && synced
+	deletedB := infoB == nil && synced
+
+	switch {
+	case !changedA && !changedB && !deletedA && !deletedB:
+		return nil // unchanged
+
+	case deletedA && deletedB:
+		delete(s.manifest.Files, relPath)
+		return nil
+
+	case changedA && !changedB && !deletedB:
+		return s.keepA(ctx, relPath)
+
+	case changedB && !changedA && !deletedA:
+		return s.keepB(ctx, relPath)
+
+	case deletedA && !changedB:
+		return s.keepA(ctx, relPath)
+
+	case deletedB && !changedA:
+		return s.keepB(ctx, relPath)
+	}
+
+	// Changed in both directories or changed in one and deleted in the other
+	if infoA != nil && infoB != nil && infoA.Size == infoB.Size {
+		equal, err := syncSameContent(ctx, infoA.File, infoB.File)
+		if err != nil {
+			return err
+		}
+		if equal {
+			return s.updateManifest(relPath)
+		}
+	}
+	s.report.Conflicts = append(s.report.Conflicts, s.listedPath(relPath))
+	resolution, err := s.opts.Resolve(ctx, SyncConflict{
+		Path:    s.listedPath(relPath),
+		A:       infoA,
+		B:       infoB,
+		ModTime: s.opts.ModTime,
+	})
+	if err != nil {
+		return err
+	}
+	switch resolution {
+	case SyncKeepA:
+		return s.keepA(ctx, relPath)
+	case SyncKeepB:
+		return s.keepB(ctx, relPath)
+	case SyncKeepBoth:
+		switch {
+		case infoA == nil:
+			return s.keepB(ctx, relPath)
+		case infoB == nil:
+			return s.keepA(ctx, relPath)
+		}
+		conflictPath := syncConflictPath(relPath)
+		s.listed[conflictPath] = syncConflictPath(s.listedPath(relPath))
+		err = s.copyFile(ctx, s.b, s.a, conflictPath, relPath, &s.report.CopiedToA)
+		if err != nil {
+			return err
+		}
+		err = s.copyFile(ctx, s.b, s.b, conflictPath, relPath, nil)
+		if err != nil {
+			return err
+		}
+		err = s.updateManifest(conflictPath)
+		if err != nil {
+			return err
+		}
+		return s.keepA(ctx, relPath)
+	case SyncSkip:
+		return nil
+	default:
+		return fmt.Errorf("invalid SyncResolution %d", resolution)
+	}
+}
+
+// keepA makes the file in b identical to the file in a
+func (s *bidirectionalSync) keepA(ctx context.Context, relPath string) error {
+	return s.keep(ctx, s.a, s.b, relPath, &s.report.CopiedToB, &s.report.DeletedFromB)
+}
+
+// keepB makes the file in a identical to the file in b
+func (s *bidirectionalSync) keepB(ctx context.Context, relPath string) error {
+	return s.keep(ctx, s.b, s.a, relPath, &s.report.CopiedToA, &s.report.DeletedFromA)
+}
+
+func (s *bidirectionalSync) keep(ctx context.Context, from, to File, relPath string, copied, deleted *[]string) error {
+	src := s.file(from, relPath)
+	if !src.Exists() {
+		dest := s.file(to, relPath)
+		if dest.Exists() {
+			err := dest.Remove()
+			if err != nil {
+				return err
+			}
+			*deleted = append(*deleted, s.listedPath(relPath))
+		}
+		delete(s.manifest.Files, relPath)
+		return nil
+	}
+	err := s.copyFile(ctx, from, to, relPath, relPath, copied)
+	if err != nil {
+		return err
+	}
+	return s.updateManifest(relPath)
+}
+
+func (s *bidirectionalSync) copyFile(ctx context.Context, from, to File, destPath, srcPath string, copied *[]string) error {
+	err := CopyFile(ctx, s.file(from, srcPath), s.file(to, destPath))
+	if err != nil {
+		return err
+	}
+	if copied != nil {
+		*copied = append(*copied, s.listedPath(destPath))
+	}
+	return nil
+}
+
+// updateManifest saves the current state of the file in both directories
+func (s *bidirectionalSync) updateManifest(relPath string) error {
+	infoA, err := s.file(s.a, relPath).InfoContext(context.Background())
+	if err != nil {
+		return err
+	}
+	infoB, err := s.file(s.b, relPath).InfoContext(context.Background())
+	if err != nil {
+		return err
+	}
+	s.manifest.Files[relPath] = SyncManifestEntry{
+		Size:      infoA.Size,
+		ModifiedA: infoA.Modified,
+		ModifiedB: infoB.Modified,
+	}
+	return nil
+}
+
+func syncJoin(dir File, relPath string) File {
+	return dir.Join(strings.Split(relPath, "/")...)
+}
+
+// syncConflictPath inserts " (conflict)" before the extension of the file name
+func syncConflictPath(relPath string) string {
+	ext := path.Ext(relPath)
+	return strings.TrimSuffix(relPath, ext) + " (conflict)" + ext
+}
+
+func syncSameContent(ctx context.Context, a, b File) (bool, error) {
+	hashA, err := FileContentHash(ctx, a, DefaultContentHash)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := FileContentHash(ctx, b, DefaultContentHash)
+	if err != nil {
+		return false, err
+	}
+	return hashA == hashB, nil
+}
@@ -0,0 +1,127 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncBidirectional(t *testing.T) {
+	ctx := context.Background()
+	a := File(t.TempDir())
+	b := File(t.TempDir())
+	opts := BidirectionalSyncOptions{Manifest: File(t.TempDir()).Join("manifest.json")}
+
+	require.NoError(t, a.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, b.Join("sub").MakeDir())
+	require.NoError(t, b.Join("sub", "b.txt").WriteAllString("B"))
+	require.NoError(t, a.Join("same.txt").WriteAllString("same"))
+	require.NoError(t, b.Join("same.txt").WriteAllString("same"))
+
+	report, err := SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sub/b.txt"}, report.CopiedToA)
+	require.Equal(t, []string{"a.txt"}, report.CopiedToB)
+	require.Empty(t, report.Conflicts)
+	requireFileContent(t, a.Join("sub", "b.txt"), "B")
+	requireFileContent(t, b.Join("a.txt"), "A")
+	require.True(t, opts.Manifest.Exists())
+
+	// Unchanged
+	report, err = SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, &BidirectionalSyncReport{}, report)
+
+	// Moves and deletes are propagated
+	require.NoError(t, a.Join("a.txt").MoveTo(a.Join("moved.txt")))
+	require.NoError(t, b.Join("same.txt").Remove())
+	report, err = SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"moved.txt"}, report.CopiedToB)
+	require.Equal(t, []string{"a.txt"}, report.DeletedFromB)
+	require.Equal(t, []string{"same.txt"}, report.DeletedFromA)
+	require.False(t, b.Join("a.txt").Exists())
+	require.False(t, a.Join("same.txt").Exists())
+	requireFileContent(t, b.Join("moved.txt"), "A")
+
+	// Conflict resolved by newer modification time
+	require.NoError(t, a.Join("moved.txt").WriteAllString("from a"))
+	require.NoError(t, b.Join("moved.txt").WriteAllString("from b"))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(a.Join("moved.txt").LocalPath(), past, past))
+	report, err = SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"moved.txt"}, report.Conflicts)
+	requireFileContent(t, a.Join("moved.txt"), "from b")
+
+	// Keep both versions
+	require.NoError(t, a.Join("sub", "b.txt").WriteAllString("BA"))
+	require.NoError(t, b.Join("sub", "b.txt").WriteAllString("BB"))
+	opts.Resolve = SyncKeepBothVersions
+	report, err = SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sub/b.txt"}, report.Conflicts)
+	requireFileContent(t, a.Join("sub", "b.txt"), "BA")
+	requireFileContent(t, b.Join("sub", "b.txt"), "BA")
+	requireFileContent(t, a.Join("sub", "b (conflict).txt"), "BB")
+	requireFileContent(t, b.Join("sub", "b (conflict).txt"), "BB")
+
+	// Callback resolution
+	require.NoError(t, a.Join("moved.txt").Remove())
+	require.NoError(t, b.Join("moved.txt").WriteAllString("changed"))
+	var conflict SyncConflict
+	opts.Resolve = func(ctx context.Context, c SyncConflict) (SyncResolution, error) {
+		conflict = c
+		return SyncKeepA, nil
+	}
+	_, err = SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, "moved.txt", conflict.Path)
+	require.Nil(t, conflict.A)
+	require.False(t, b.Join("moved.txt").Exists(), "deletion in a kept")
+}
+
+func TestSyncBidirectional_NormalizeNames(t *testing.T) {
+	t.Cleanup(func() { SetNormalizeNames(false) })
+	ctx := context.Background()
+	a := File(t.TempDir())
+	b := File(t.TempDir())
+	opts := BidirectionalSyncOptions{Manifest: File(t.TempDir()).Join("manifest.json")}
+	require.NoError(t, a.Join(nameNFC).WriteAllString("content"))
+	require.NoError(t, b.Join(nameNFD).WriteAllString("content"))
+
+	SetNormalizeNames(true)
+	report, err := SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, &BidirectionalSyncReport{}, report)
+	require.False(t, a.Join(nameNFD).Exists())
+	require.False(t, b.Join(nameNFC).Exists())
+
+	// Changes are copied to the listed name
+	require.NoError(t, b.Join(nameNFD).WriteAllString("changed"))
+	report, err = SyncBidirectional(ctx, a, b, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{nameNFC}, report.CopiedToA)
+	requireFileContent(t, a.Join(nameNFC), "changed")
+	require.False(t, a.Join(nameNFD).Exists())
+}
+
+func TestSyncNewerWins_ModTime(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	conflict := SyncConflict{
+		A: &FileInfo{Modified: now},
+		B: &FileInfo{Modified: now.Add(time.Second)},
+	}
+	resolution, err := SyncNewerWins(ctx, conflict)
+	require.NoError(t, err)
+	require.Equal(t, SyncKeepB, resolution)
+
+	conflict.ModTime = ModTimeComparison{Tolerance: 2 * time.Second}
+	resolution, err = SyncNewerWins(ctx, conflict)
+	require.NoError(t, err)
+	require.Equal(t, SyncKeepA, resolution, "within tolerance")
+}
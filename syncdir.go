@@ -0,0 +1,225 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+)
+
+// SyncOptions configure SyncDir
+type SyncOptions struct {
+	// CompareContentHash compares existing files by their content hash
+	// instead of their size and modification time.
+	// If the file system of dest implements HashFileSystem
+	// then its hashes are used without reading dest files.
+	CompareContentHash bool
+
+	// ContentHash is used to hash files if CompareContentHash is true,
+	// DefaultContentHash is used if nil.
+	ContentHash ContentHashFunc
+
+	// Delete removes files and directories from dest
+	// that don't exist in src.
+	Delete bool
+
+	// Exclude contains patterns of file and directory names
+	// that are neither copied from src nor deleted from dest.
+	Exclude []string
+
+	// DryRun only reports the actions without changing dest.
+	DryRun bool
+
+	// ModTime compares the modification times of the files
+	// if CompareContentHash is false.
+	// The zero value compares the exact times.
+	ModTime ModTimeComparison
+}
+
+// SyncReport lists the actions taken by SyncDir.
+// Paths are slash separated and relative to the synchronized directories.
+type SyncReport struct {
+	Created   []string
+	Updated   []string
+	Deleted   []string
+	Unchanged int
+	// BytesCopied is the total size of the created and updated files
+	BytesCopied int64
+}
+
+// SyncDir makes the directory dest identical to the directory src,
+// which can be on another file system, by copying new and changed files
+// and deleting extraneous files if SyncOptions.Delete is true.
+// dest is created if it does not exist.
+//
+// A file is changed if its size differs or if the src file
+// was modified after the dest file according to SyncOptions.ModTime,
+// or if SyncOptions.CompareContentHash
+// is true, if its content hash differs.
+// Note that file modification times are not copied.
+//
+// A file in dest where src has a directory or the other way around
+// is only replaced if SyncOptions.Delete is true,
+// else an ErrIsNotDirectory or ErrIsDirectory error is returned.
+//
+// File names of src and dest are matched after normalization
+// with NormalizeName if enabled with SetNormalizeNames,
+// the names listed in dest are kept for updated files.
+//
+// The returned report lists the actions taken
+// and is also returned together with an error.
+func SyncDir(ctx context.Context, src, dest File, opts SyncOptions) (*SyncReport, error) {
+	if opts.CompareContentHash && opts.ContentHash == nil {
+		opts.ContentHash = DefaultContentHash
+	}
+	report := new(SyncReport)
+	if err := src.CheckIsDir(); err != nil {
+		return report, err
+	}
+	var buf []byte
+	err := syncDir(ctx, src, dest, "", &opts, report, &buf)
+	if err != nil {
+		return report, fmt.Errorf("SyncDir: %w", err)
+	}
+	return report, nil
+}
+
+// listDirInfoMap returns the FileInfo of the files in dir
+// by their name normalized with matchName
+// without files matching excludePatterns
+func listDirInfoMap(ctx context.Context, dir File, excludePatterns []string) (map[string]*FileInfo, error) {
+	fileSystem := dir.FileSystem()
+	infos := make(map[string]*FileInfo)
+	err := dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if len(excludePatterns) > 0 {
+			excluded, err := fileSystem.MatchAnyPattern(info.Name, excludePatterns)
+			if excluded || err != nil {
+				return err
+			}
+		}
+		infoCopy := *info
+		infos[matchName(info.Name)] = &infoCopy
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func syncDir(ctx context.Context, srcDir, destDir File, relDir string, opts *SyncOptions, report *SyncReport, buf *[]byte) error {
+	srcInfos, err := listDirInfoMap(ctx, srcDir, opts.Exclude)
+	if err != nil {
+		return err
+	}
+	destInfos := make(map[string]*FileInfo)
+	if destDir.Exists() {
+		destInfos, err = listDirInfoMap(ctx, destDir, opts.Exclude)
+		if err != nil {
+			return err
+		}
+	} else if !opts.DryRun {
+		err = destDir.MakeAllDirs()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(srcInfos)) {
+		srcInfo := srcInfos[key]
+		destInfo := destInfos[key]
+		dest := destDir.Join(srcInfo.Name)
+		if destInfo != nil {
+			dest = destInfo.File
+		}
+		relPath := path.Join(relDir, srcInfo.Name)
+
+		if destInfo != nil && destInfo.IsDir != srcInfo.IsDir {
+			if !opts.Delete {
+				if destInfo.IsDir {
+					return NewErrIsDirectory(dest)
+				}
+				return NewErrIsNotDirectory(dest)
+			}
+			if !opts.DryRun {
+				err = dest.RemoveRecursiveContext(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			report.Deleted = append(report.Deleted, relPath)
+			destInfo = nil
+		}
+
+		if srcInfo.IsDir {
+			err = syncDir(ctx, srcInfo.File, dest, relPath, opts, report, buf)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if destInfo != nil {
+			changed, err := syncFileChanged(ctx, srcInfo, destInfo, opts)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				report.Unchanged++
+				continue
+			}
+		}
+		if !opts.DryRun {
+			err = CopyFileBuf(ctx, srcInfo.File, dest, buf)
+			if err != nil {
+				return err
+			}
+		}
+		if destInfo == nil {
+			report.Created = append(report.Created, relPath)
+		} else {
+			report.Updated = append(report.Updated, relPath)
+		}
+		report.BytesCopied += srcInfo.Size
+	}
+
+	if opts.Delete {
+		for _, key := range slices.Sorted(maps.Keys(destInfos)) {
+			if _, ok := srcInfos[key]; ok {
+				continue
+			}
+			destInfo := destInfos[key]
+			if !opts.DryRun {
+				err = destInfo.File.RemoveRecursiveContext(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			report.Deleted = append(report.Deleted, path.Join(relDir, destInfo.Name))
+		}
+	}
+	return nil
+}
+
+func syncFileChanged(ctx context.Context, src, dest *FileInfo, opts *SyncOptions) (bool, error) {
+	if src.Size != dest.Size {
+		return true, nil
+	}
+	if !opts.CompareContentHash {
+		return opts.ModTime.After(src.Modified, dest.Modified), nil
+	}
+	if _, ok := dest.File.FileSystem().(HashFileSystem); ok {
+		identical, err := HasIdenticalContentHash(ctx, src.File, dest.File)
+		return !identical, err
+	}
+	srcHash, err := FileContentHash(ctx, src.File, opts.ContentHash)
+	if err != nil {
+		return false, err
+	}
+	destHash, err := FileContentHash(ctx, dest.File, opts.ContentHash)
+	if err != nil {
+		return false, err
+	}
+	return srcHash != destHash, nil
+}
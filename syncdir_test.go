@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncDir(t *testing.T) {
+	ctx := context.Background()
+	src := File(t.TempDir())
+	dest := File(t.TempDir()).Join("dest")
+	require.NoError(t, src.Join("sub").MakeDir())
+	require.NoError(t, src.Join("a.txt").WriteAllString("A"))
+	require.NoError(t, src.Join("sub", "b.txt").WriteAllString("B"))
+	require.NoError(t, src.Join("skip.tmp").WriteAllString("tmp"))
+
+	opts := SyncOptions{Delete: true, Exclude: []string{"*.tmp"}}
+	report, err := SyncDir(ctx, src, dest, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "sub/b.txt"}, report.Created)
+	require.Equal(t, int64(2), report.BytesCopied)
+	requireFileContent(t, dest.Join("sub", "b.txt"), "B")
+	require.False(t, dest.Join("skip.tmp").Exists())
+
+	// Nothing changed
+	report, err = SyncDir(ctx, src, dest, opts)
+	require.NoError(t, err)
+	require.Empty(t, report.Created)
+	require.Empty(t, report.Updated)
+	require.Equal(t, 2, report.Unchanged)
+
+	// Change, add, and remove files
+	require.NoError(t, src.Join("a.txt").WriteAllString("AA"))
+	require.NoError(t, src.Join("sub", "b.txt").Remove())
+	require.NoError(t, dest.Join("extra.txt").WriteAllString("extra"))
+	require.NoError(t, dest.Join("keep.tmp").WriteAllString("excluded"))
+
+	dryReport, err := SyncDir(ctx, src, dest, SyncOptions{Delete: true, Exclude: opts.Exclude, DryRun: true})
+	require.NoError(t, err)
+	require.True(t, dest.Join("extra.txt").Exists(), "dry run")
+
+	report, err = SyncDir(ctx, src, dest, opts)
+	require.NoError(t, err)
+	require.Equal(t, dryReport, report)
+	require.Equal(t, []string{"a.txt"}, report.Updated)
+	require.Equal(t, []string{"sub/b.txt", "extra.txt"}, report.Deleted)
+	requireFileContent(t, dest.Join("a.txt"), "AA")
+	require.False(t, dest.Join("extra.txt").Exists())
+	require.True(t, dest.Join("keep.tmp").Exists(), "excluded files are not deleted")
+
+	// Same size but different content, dest newer than src
+	require.NoError(t, dest.Join("a.txt").WriteAllString("XX"))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(src.Join("a.txt").LocalPath(), past, past))
+	report, err = SyncDir(ctx, src, dest, SyncOptions{})
+	require.NoError(t, err)
+	require.Empty(t, report.Updated, "size and modification time don't detect the change")
+	report, err = SyncDir(ctx, src, dest, SyncOptions{CompareContentHash: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt"}, report.Updated)
+	requireFileContent(t, dest.Join("a.txt"), "AA")
+}
+
+func TestSyncDir_NormalizeNames(t *testing.T) {
+	t.Cleanup(func() { SetNormalizeNames(false) })
+	ctx := context.Background()
+	src := File(t.TempDir())
+	dest := File(t.TempDir())
+	require.NoError(t, src.Join(nameNFC).WriteAllString("content"))
+	require.NoError(t, dest.Join(nameNFD).WriteAllString("content"))
+
+	SetNormalizeNames(true)
+	report, err := SyncDir(ctx, src, dest, SyncOptions{Delete: true})
+	require.NoError(t, err)
+	require.Empty(t, report.Created)
+	require.Empty(t, report.Deleted)
+	require.Equal(t, 1, report.Unchanged)
+	require.True(t, dest.Join(nameNFD).Exists(), "listed name is kept")
+	require.False(t, dest.Join(nameNFC).Exists())
+
+	SetNormalizeNames(false)
+	report, err = SyncDir(ctx, src, dest, SyncOptions{Delete: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{nameNFC}, report.Created)
+	require.Equal(t, []string{nameNFD}, report.Deleted)
+}
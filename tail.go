@@ -0,0 +1,137 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"time"
+)
+
+// TailPollInterval is the interval in which File.Tail
+// checks the size of a followed file.
+// File systems that support watching files notify
+// Tail about changes without waiting for the interval.
+var TailPollInterval = time.Second
+
+// tailChunkSize is the maximum number of bytes yielded at once by File.Tail
+const tailChunkSize = 64 * 1024
+
+// Tail returns an iterator that yields data appended to the file
+// as it is written, like the command tail -F.
+// If fromEnd is true then only data written after the call is yielded,
+// else the existing content of the file is yielded first.
+//
+// Changes are detected with Watch on file systems that support it
+// and by checking the file size every TailPollInterval.
+// If the file becomes smaller because it was truncated or replaced
+// like by log rotation, then it is followed again from its beginning.
+// A temporarily removed file is waited for.
+//
+// Following ends when ctx is canceled or the loop over the iterator is exited.
+// An error is returned if the file does not exist at the start,
+// later errors are yielded without ending the iteration.
+func (file File) Tail(ctx context.Context, fromEnd bool) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		info, err := file.Stat()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if info.IsDir() {
+			yield(nil, NewErrIsDirectory(file))
+			return
+		}
+		var offset int64
+		if fromEnd {
+			offset = info.Size()
+		}
+
+		changed := make(chan struct{}, 1)
+		cancelWatch, err := file.Watch(func(File, Event) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+		if err == nil && cancelWatch != nil {
+			defer cancelWatch()
+		}
+		ticker := time.NewTicker(TailPollInterval)
+		defer ticker.Stop()
+
+		for {
+			info, err := file.Stat()
+			switch {
+			case err == nil:
+				if info.Size() < offset {
+					offset = 0
+				}
+				for offset < info.Size() {
+					data, err := file.readAt(ctx, offset, min(info.Size()-offset, tailChunkSize))
+					if ctx.Err() != nil {
+						return
+					}
+					if err != nil {
+						if !yield(nil, err) {
+							return
+						}
+						break
+					}
+					if len(data) == 0 {
+						break
+					}
+					offset += int64(len(data))
+					if !yield(data, nil) {
+						return
+					}
+				}
+			case errors.Is(err, os.ErrNotExist):
+				// Wait for a rotated file to be created again
+			default:
+				if !yield(nil, err) {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// readAt reads up to length bytes of the file starting at offset.
+// Uses range reads if the file system implements ReadRangeFileSystem,
+// else seeks to offset if the file reader implements io.Seeker,
+// else the file is read from the beginning discarding the bytes before offset.
+func (file File) readAt(ctx context.Context, offset, length int64) ([]byte, error) {
+	fileSystem, path := file.ParseRawURI()
+	if fs, ok := fileSystem.(ReadRangeFileSystem); ok {
+		return fs.ReadRange(ctx, path, offset, length)
+	}
+	r, err := fileSystem.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if seeker, ok := r.(io.Seeker); ok {
+		_, err = seeker.Seek(offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, r, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	n, err := io.ReadFull(r, data)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return data[:n], nil
+}
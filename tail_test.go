@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Tail(t *testing.T) {
+	pollInterval := TailPollInterval
+	TailPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { TailPollInterval = pollInterval })
+
+	file := File(t.TempDir()).Join("log.txt")
+	require.NoError(t, file.WriteAllString("existing\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan string)
+	go func() {
+		defer close(received)
+		for data, err := range file.Tail(ctx, false) {
+			if err != nil {
+				received <- "error: " + err.Error()
+				return
+			}
+			received <- string(data)
+		}
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case data := <-received:
+			return data
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for tailed data")
+			return ""
+		}
+	}
+
+	require.Equal(t, "existing\n", next())
+	require.NoError(t, file.AppendString(ctx, "appended\n"))
+	require.Equal(t, "appended\n", next())
+
+	// Follows a truncated file from the beginning
+	require.NoError(t, file.WriteAllString("new\n"))
+	require.Equal(t, "new\n", next())
+
+	cancel()
+	for range received {
+		// Drain until the iterator stops because of canceled ctx
+	}
+}
+
+func TestFile_Tail_fromEnd(t *testing.T) {
+	pollInterval := TailPollInterval
+	TailPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { TailPollInterval = pollInterval })
+
+	file := File(t.TempDir()).Join("log.txt")
+	require.NoError(t, file.WriteAllString("existing\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = file.AppendString(ctx, "appended\n")
+	}()
+	for data, err := range file.Tail(ctx, true) {
+		require.NoError(t, err)
+		require.Equal(t, "appended\n", string(data))
+		break
+	}
+	require.NoError(t, ctx.Err(), "received data before timeout")
+
+	for _, err := range File(t.TempDir()).Join("missing.txt").Tail(ctx, true) {
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,122 @@
+package tarfs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Compression of a tar archive
+type Compression struct {
+	// Name of the compression format
+	Name string
+	// Magic bytes at the start of a compressed archive
+	Magic []byte
+	// Exts are the file name extensions of compressed archives
+	// including the ".tar" part, like ".tar.gz" or ".tgz"
+	Exts []string
+	// NewReader returns a reader that decompresses r
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	// NewWriter returns a writer that compresses to w
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+func (c *Compression) String() string {
+	return c.Name
+}
+
+func (c *Compression) newReader(r io.Reader) (io.ReadCloser, error) {
+	if c.NewReader == nil {
+		return nil, fmt.Errorf("tar archive %s decompression: %w", c.Name, errors.ErrUnsupported)
+	}
+	return c.NewReader(r)
+}
+
+func (c *Compression) newWriter(w io.Writer) (io.WriteCloser, error) {
+	if c.NewWriter == nil {
+		return nil, fmt.Errorf("tar archive %s compression: %w", c.Name, errors.ErrUnsupported)
+	}
+	return c.NewWriter(w)
+}
+
+var (
+	// Gzip compression of tar archives
+	Gzip = &Compression{
+		Name:  "gzip",
+		Magic: []byte{0x1f, 0x8b},
+		Exts:  []string{".tar.gz", ".tgz"},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+	}
+
+	// Zstd compression of tar archives.
+	//
+	// Zstd archives are detected, but the standard library has no
+	// zstd implementation, so NewReader and NewWriter have to be set
+	// to support reading and writing, for example with
+	// github.com/klauspost/compress/zstd:
+	//
+	//	tarfs.Zstd.NewReader = func(r io.Reader) (io.ReadCloser, error) {
+	//		d, err := zstd.NewReader(r)
+	//		if err != nil {
+	//			return nil, err
+	//		}
+	//		return d.IOReadCloser(), nil
+	//	}
+	//	tarfs.Zstd.NewWriter = func(w io.Writer) (io.WriteCloser, error) {
+	//		return zstd.NewWriter(w)
+	//	}
+	Zstd = &Compression{
+		Name:  "zstd",
+		Magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		Exts:  []string{".tar.zst", ".tzst"},
+	}
+
+	// Compressions are the compression formats
+	// detected by their magic bytes when reading archives.
+	Compressions = []*Compression{Gzip, Zstd}
+)
+
+// CompressionFromName returns the compression
+// for the file name extension of an archive
+// or nil for uncompressed archives.
+func CompressionFromName(name string) *Compression {
+	name = strings.ToLower(name)
+	for _, c := range Compressions {
+		for _, ext := range c.Exts {
+			if strings.HasSuffix(name, ext) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// decompress detects the compression of an archive by its magic bytes
+// and returns a reader for the uncompressed archive.
+// The returned compression is nil for uncompressed archives.
+func decompress(r io.Reader) (io.Reader, *Compression, error) {
+	br := bufio.NewReader(r)
+	for _, c := range Compressions {
+		magic, err := br.Peek(len(c.Magic))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, err
+		}
+		if bytes.Equal(magic, c.Magic) {
+			dr, err := c.newReader(br)
+			if err != nil {
+				return nil, nil, err
+			}
+			return dr, c, nil
+		}
+	}
+	return br, nil, nil
+}
@@ -0,0 +1,81 @@
+package tarfs
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+type node struct {
+	*fs.FileInfo
+	children map[string]*node
+
+	// offset of the file data in the uncompressed archive
+	offset int64
+	// data of files that can't be read directly at offset,
+	// like sparse files
+	data []byte
+}
+
+func newDirNode(file fs.File, name string, modTime time.Time, perm fs.Permissions) *node {
+	return &node{
+		FileInfo: &fs.FileInfo{
+			File:        file,
+			Name:        name,
+			Exists:      true,
+			IsDir:       true,
+			IsRegular:   true,
+			IsHidden:    len(name) > 0 && name[0] == '.',
+			Modified:    modTime,
+			Permissions: perm,
+		},
+		children: make(map[string]*node),
+	}
+}
+
+func newFileNode(file fs.File, name string, modTime time.Time, perm fs.Permissions, size, offset int64) *node {
+	return &node{
+		FileInfo: &fs.FileInfo{
+			File:        file,
+			Name:        name,
+			Exists:      true,
+			IsDir:       false,
+			IsRegular:   true,
+			IsHidden:    len(name) > 0 && name[0] == '.',
+			Size:        size,
+			Modified:    modTime,
+			Permissions: perm,
+		},
+		offset: offset,
+	}
+}
+
+// info returns a copy of the node's FileInfo
+// that can be passed to callbacks
+func (n *node) info() *fs.FileInfo {
+	info := *n.FileInfo
+	return &info
+}
+
+// sortedChildren returns the children sorted with directories first
+// and directories and files sorted by name
+func (n *node) sortedChildren() []*node {
+	l := len(n.children)
+	if l == 0 {
+		return nil
+	}
+	s := make([]*node, l)
+	i := 0
+	for _, n := range n.children {
+		s[i] = n
+		i++
+	}
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].IsDir != s[j].IsDir {
+			return s[i].IsDir
+		}
+		return s[i].Name < s[j].Name
+	})
+	return s
+}
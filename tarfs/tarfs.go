@@ -0,0 +1,764 @@
+// Package tarfs implements a file system for tar archives,
+// optionally gzip or zstd compressed,
+// that are mounted from any fs.FileReader.
+package tarfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix for the TarFileSystem
+	Prefix = "tar://"
+
+	// Separator used in TarFileSystem paths
+	Separator = "/"
+)
+
+var (
+	// DefaultPermissions used for written files
+	DefaultPermissions = fs.UserReadWrite | fs.GroupRead | fs.OthersRead
+	// DefaultDirPermissions used for written directories
+	// and for directories without an archive entry
+	DefaultDirPermissions = fs.UserReadWriteExecute | fs.GroupRead | fs.GroupExecute | fs.OthersRead | fs.OthersExecute
+
+	// Make sure TarFileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(TarFileSystem)
+)
+
+// TarFileSystem mounts a tar archive as file system
+// registered with the prefix "tar://" followed by a random ID,
+// so the root directory of the archive is "tar://<id>/".
+//
+// There are two modes:
+//   - NewReaderFileSystem returns a read-only file system
+//   - NewWriterFileSystem returns a write-only file system that streams new archive entries
+//
+// Tar archives can't be read at random positions,
+// so NewReaderFileSystem scans the archive once to build
+// the directory tree and remembers the position of every file.
+// Files of uncompressed archives are read by seeking to that position,
+// files of compressed archives by decompressing the archive up to it.
+type TarFileSystem struct {
+	prefix string
+	closed bool
+
+	// archive is read for every opened file in read-only mode
+	archive fs.FileReader
+	// compression of archive, nil if uncompressed
+	compression *Compression
+	// root is the directory tree of the archive, nil for write-only mode
+	root *node
+
+	// tarWriter is used in write-only mode
+	tarWriter *tar.Writer
+	closer    io.Closer
+
+	mtx sync.RWMutex
+}
+
+// NewReaderFileSystem mounts the tar archive file
+// as read-only file system and registers it.
+// Gzip and zstd compressed archives are detected by their magic bytes,
+// see Compressions.
+func NewReaderFileSystem(file fs.FileReader) (tarfs *TarFileSystem, err error) {
+	reader, err := file.OpenReader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, reader.Close())
+		if err != nil {
+			tarfs = nil
+		}
+	}()
+
+	tarfs = &TarFileSystem{
+		prefix:  Prefix + fsimpl.RandomString(),
+		archive: file,
+	}
+	err = tarfs.buildTree(reader)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fs.TryRegister(tarfs)
+	if err != nil {
+		return nil, err
+	}
+	return tarfs, nil
+}
+
+// NewWriterFileSystem returns a write-only file system
+// that streams all written files as entries of a new tar archive to file.
+// The archive is compressed if compression is not nil,
+// see also CompressionFromName.
+// The archive is finished by Close.
+//
+// Tar entries need the file size in front of the data,
+// so every written file is buffered until its writer is closed.
+// Use WriteDir to stream existing files without buffering.
+func NewWriterFileSystem(file fs.File, compression *Compression) (tarfs *TarFileSystem, err error) {
+	fileWriter, err := file.OpenWriter()
+	if err != nil {
+		return nil, err
+	}
+	var writer io.WriteCloser = fileWriter
+	if compression != nil {
+		writer, err = compression.newWriter(fileWriter)
+		if err != nil {
+			return nil, errors.Join(err, fileWriter.Close())
+		}
+	}
+	tarWriter := tar.NewWriter(writer)
+	tarfs = &TarFileSystem{
+		prefix:      Prefix + fsimpl.RandomString(),
+		compression: compression,
+		tarWriter:   tarWriter,
+		closer: closerFunc(func() error {
+			err := tarWriter.Close()
+			if compression != nil {
+				err = errors.Join(err, writer.Close())
+			}
+			return errors.Join(err, fileWriter.Close())
+		}),
+	}
+	_, err = fs.TryRegister(tarfs)
+	if err != nil {
+		return nil, errors.Join(err, tarfs.closer.Close())
+	}
+	return tarfs, nil
+}
+
+// WriteDir writes all files and sub-directories of dir
+// to a new tar archive file that is compressed
+// if compression is not nil.
+// The data of the files is streamed without buffering.
+func WriteDir(ctx context.Context, archive, dir fs.File, compression *Compression) (err error) {
+	if !dir.IsDir() {
+		return fs.NewErrIsNotDirectory(dir)
+	}
+	tarfs, err := NewWriterFileSystem(archive, compression)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, tarfs.Close())
+	}()
+
+	var writeDir func(dir fs.File, dirName string) error
+	writeDir = func(dir fs.File, dirName string) error {
+		return dir.ListDirInfoContext(ctx, func(info *fs.FileInfo) error {
+			header := &tar.Header{
+				Name:    dirName + info.Name,
+				Mode:    int64(info.Permissions),
+				ModTime: info.Modified,
+			}
+			if info.IsDir {
+				if info.Permissions == fs.NoPermissions {
+					header.Mode = int64(DefaultDirPermissions)
+				}
+				header.Typeflag = tar.TypeDir
+				header.Name += Separator
+				err := tarfs.writeEntry(header, nil)
+				if err != nil {
+					return err
+				}
+				return writeDir(info.File, header.Name)
+			}
+			if info.Permissions == fs.NoPermissions {
+				header.Mode = int64(DefaultPermissions)
+			}
+			header.Typeflag = tar.TypeReg
+			header.Size = info.Size
+			reader, err := info.File.OpenReader()
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			return tarfs.writeEntry(header, reader)
+		})
+	}
+	return writeDir(dir, "")
+}
+
+// buildTree creates the directory tree
+// from the entries of the archive read from reader
+func (f *TarFileSystem) buildTree(reader io.Reader) error {
+	uncompressed, compression, err := decompress(reader)
+	if err != nil {
+		return err
+	}
+	f.compression = compression
+	f.root = newDirNode(f.RootDir(), "", time.Time{}, DefaultDirPermissions)
+
+	counter := &countingReader{Reader: uncompressed}
+	tarReader := tar.NewReader(counter)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entryPath := path.Clean(Separator + header.Name)
+		if entryPath == Separator {
+			continue
+		}
+		dirPath, name := path.Split(entryPath)
+		perm := fs.Permissions(iofs.FileMode(header.Mode).Perm())
+		switch header.Typeflag {
+		case tar.TypeDir:
+			dir, err := f.makeDirNodes(entryPath, header.ModTime)
+			if err != nil {
+				return err
+			}
+			dir.Modified = header.ModTime
+			dir.Permissions = perm
+			continue
+
+		case tar.TypeReg, tar.TypeGNUSparse, tar.TypeLink:
+			// Handled below
+
+		default:
+			// Symbolic links, devices and FIFOs are not supported
+			continue
+		}
+
+		dir, err := f.makeDirNodes(dirPath, header.ModTime)
+		if err != nil {
+			return err
+		}
+		if child := dir.children[name]; child != nil && child.IsDir {
+			return fmt.Errorf("tar entry %s conflicts with directory", header.Name)
+		}
+		child := newFileNode(f.File(entryPath), name, header.ModTime, perm, header.Size, counter.n)
+		switch {
+		case header.Typeflag == tar.TypeLink:
+			target := f.findNode(path.Clean(Separator + header.Linkname))
+			if target == nil || target.IsDir {
+				return fmt.Errorf("tar hard link %s has invalid target %s", header.Name, header.Linkname)
+			}
+			child.Size = target.Size
+			child.offset = target.offset
+			child.data = target.data
+
+		case isSparse(header):
+			// The data of sparse files is not stored contiguously
+			// in the archive, so it is kept in memory
+			child.data, err = io.ReadAll(tarReader)
+			if err != nil {
+				return err
+			}
+		}
+		// Later entries replace earlier ones like when extracting
+		dir.children[name] = child
+	}
+}
+
+// isSparse returns if the header is for a GNU or PAX sparse file
+func isSparse(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range header.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// makeDirNodes returns the directory node for dirPath
+// and creates all missing nodes up to it.
+func (f *TarFileSystem) makeDirNodes(dirPath string, modTime time.Time) (*node, error) {
+	dir := f.root
+	currentPath := Separator
+	for _, name := range fsimpl.SplitPath(dirPath, "", Separator) {
+		currentPath = path.Join(currentPath, name)
+		child := dir.children[name]
+		if child == nil {
+			child = newDirNode(f.File(currentPath), name, modTime, DefaultDirPermissions)
+			dir.children[name] = child
+		} else if !child.IsDir {
+			return nil, fs.NewErrIsNotDirectory(child.File)
+		}
+		dir = child
+	}
+	return dir, nil
+}
+
+// findNode returns the node for filePath or nil
+func (f *TarFileSystem) findNode(filePath string) *node {
+	n := f.root
+	for _, name := range fsimpl.SplitPath(filePath, f.prefix, Separator) {
+		if n.children == nil {
+			return nil
+		}
+		n = n.children[name]
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// checkReadable returns an error if the file system is
+// closed or can't be read, else it locks the file system for reading.
+func (f *TarFileSystem) checkReadable() error {
+	if f.root == nil {
+		return fs.ErrWriteOnlyFileSystem
+	}
+	f.mtx.RLock()
+	if f.closed {
+		f.mtx.RUnlock()
+		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	}
+	return nil
+}
+
+// checkWritable returns an error if the file system is
+// closed or can't be written, else it locks the file system for writing.
+func (f *TarFileSystem) checkWritable() error {
+	if f.tarWriter == nil {
+		return fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+	}
+	f.mtx.Lock()
+	if f.closed {
+		f.mtx.Unlock()
+		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	}
+	return nil
+}
+
+func (f *TarFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.root != nil, f.tarWriter != nil
+}
+
+func (f *TarFileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+func (f *TarFileSystem) ID() (string, error) {
+	return f.prefix, nil
+}
+
+// Prefix for the TarFileSystem
+func (f *TarFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *TarFileSystem) Name() string {
+	return "Tar filesystem " + path.Base(f.prefix)
+}
+
+// String implements the fmt.Stringer interface.
+func (f *TarFileSystem) String() string {
+	return f.Name() + " with prefix " + f.Prefix()
+}
+
+// Compression returns the compression of the archive
+// or nil if it is not compressed.
+func (f *TarFileSystem) Compression() *Compression {
+	return f.compression
+}
+
+func (f *TarFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *TarFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *TarFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *TarFileSystem) CleanPathFromURI(uri string) string {
+	return path.Clean(strings.TrimPrefix(uri, f.prefix))
+}
+
+func (f *TarFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *TarFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*TarFileSystem) Separator() string {
+	return Separator
+}
+
+// MatchAnyPattern returns true if name matches any of patterns,
+// or if len(patterns) == 0.
+// The match per pattern works like path.Match or filepath.Match
+func (*TarFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*TarFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (f *TarFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (f *TarFileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *TarFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if err := f.checkReadable(); err != nil {
+		return nil, err
+	}
+	defer f.mtx.RUnlock()
+
+	n := f.findNode(filePath)
+	if n == nil {
+		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	return n.info().StdFileInfo(), nil
+}
+
+func (f *TarFileSystem) Exists(filePath string) bool {
+	if err := f.checkReadable(); err != nil {
+		return false
+	}
+	defer f.mtx.RUnlock()
+
+	return f.findNode(filePath) != nil
+}
+
+func (f *TarFileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (f *TarFileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+// dirNode returns the directory node for dirPath
+// or an error if it does not exist or is not a directory.
+func (f *TarFileSystem) dirNode(dirPath string) (*node, error) {
+	dir := f.findNode(dirPath)
+	if dir == nil {
+		return nil, fs.NewErrDoesNotExist(f.File(dirPath))
+	}
+	if !dir.IsDir {
+		return nil, fs.NewErrIsNotDirectory(f.File(dirPath))
+	}
+	return dir, nil
+}
+
+// ListDirInfo lists the directories first
+// and then the files sorted by name.
+func (f *TarFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := f.checkReadable(); err != nil {
+		return err
+	}
+	// The directory tree is never modified in read-only mode,
+	// so it can be iterated without holding the lock
+	f.mtx.RUnlock()
+	dir, err := f.dirNode(dirPath)
+	if err != nil {
+		return err
+	}
+	for _, child := range dir.sortedChildren() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		match, err := f.MatchAnyPattern(child.Name, patterns)
+		if !match || err != nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err = callback(child.info())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListDirInfoRecursive lists all files (not directories)
+// in dirPath and its sub-directories.
+func (f *TarFileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := f.checkReadable(); err != nil {
+		return err
+	}
+	f.mtx.RUnlock()
+	dir, err := f.dirNode(dirPath)
+	if err != nil {
+		return err
+	}
+	var listRecursive func(parent *node) error
+	listRecursive = func(parent *node) error {
+		for _, child := range parent.sortedChildren() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if child.IsDir {
+				err := listRecursive(child)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			match, err := f.MatchAnyPattern(child.Name, patterns)
+			if err != nil {
+				return err
+			}
+			if match {
+				err = callback(child.info())
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return listRecursive(dir)
+}
+
+// OpenReader opens the archive and positions the returned reader
+// at the data of the file, see TarFileSystem.
+func (f *TarFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if err := f.checkReadable(); err != nil {
+		return nil, err
+	}
+	defer f.mtx.RUnlock()
+
+	n := f.findNode(filePath)
+	if n == nil {
+		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if n.IsDir {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	info := n.info().StdFileInfo()
+	if n.data != nil || n.Size == 0 {
+		return fsimpl.NewReadonlyFileBuffer(n.data, info), nil
+	}
+
+	if f.compression == nil {
+		reader, err := f.archive.OpenReadSeeker()
+		if err != nil {
+			return nil, err
+		}
+		_, err = reader.Seek(n.offset, io.SeekStart)
+		if err != nil {
+			return nil, errors.Join(err, reader.Close())
+		}
+		return &entryReader{Reader: io.LimitReader(reader, n.Size), closer: reader, info: info}, nil
+	}
+
+	reader, err := f.archive.OpenReader()
+	if err != nil {
+		return nil, err
+	}
+	uncompressed, err := f.compression.newReader(reader)
+	if err != nil {
+		return nil, errors.Join(err, reader.Close())
+	}
+	closer := closerFunc(func() error {
+		return errors.Join(uncompressed.Close(), reader.Close())
+	})
+	_, err = io.CopyN(io.Discard, uncompressed, n.offset)
+	if err != nil {
+		return nil, errors.Join(err, closer.Close())
+	}
+	return &entryReader{Reader: io.LimitReader(uncompressed, n.Size), closer: closer, info: info}, nil
+}
+
+func (f *TarFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	reader, err := f.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return fs.ReadAllContext(ctx, reader)
+}
+
+// writeEntry writes an entry with header to the archive
+// and copies the data from reader if not nil.
+func (f *TarFileSystem) writeEntry(header *tar.Header, reader io.Reader) error {
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	defer f.mtx.Unlock()
+
+	err := f.tarWriter.WriteHeader(header)
+	if err != nil || reader == nil {
+		return err
+	}
+	n, err := io.Copy(f.tarWriter, reader)
+	if err != nil {
+		return err
+	}
+	if n != header.Size {
+		return fmt.Errorf("tar entry %s has size %d but %d bytes were written", header.Name, header.Size, n)
+	}
+	return nil
+}
+
+// Touch writes an empty file entry
+func (f *TarFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	return f.writeEntry(
+		&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entryName(filePath),
+			Mode:     int64(fs.JoinPermissions(perm, DefaultPermissions)),
+			ModTime:  time.Now(),
+		},
+		nil,
+	)
+}
+
+// MakeDir writes a directory entry
+func (f *TarFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	return f.writeEntry(
+		&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     entryName(dirPath) + Separator,
+			Mode:     int64(fs.JoinPermissions(perm, DefaultDirPermissions)),
+			ModTime:  time.Now(),
+		},
+		nil,
+	)
+}
+
+// WriteAll writes data as file entry
+func (f *TarFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return f.writeEntry(
+		&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entryName(filePath),
+			Mode:     int64(fs.JoinPermissions(perm, DefaultPermissions)),
+			Size:     int64(len(data)),
+			ModTime:  time.Now(),
+		},
+		bytes.NewReader(data),
+	)
+}
+
+// OpenWriter returns a writer for a new file entry
+// that buffers the data until it is closed,
+// because the size of the entry has to be written first.
+func (f *TarFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if f.tarWriter == nil {
+		return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+	}
+	return &bufferWriter{close: func(data []byte) error {
+		return f.WriteAll(context.Background(), filePath, data, perm)
+	}}, nil
+}
+
+func (f *TarFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	return nil, fs.NewErrUnsupported(f, "OpenReadWriter")
+}
+
+func (f *TarFileSystem) Remove(filePath string) error {
+	return fs.NewErrUnsupported(f, "Remove")
+}
+
+// Close finishes the archive in write-only mode
+func (f *TarFileSystem) Close() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	fs.Unregister(f)
+	f.closed = true
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+// entryName returns the name of the archive entry for filePath
+func entryName(filePath string) string {
+	return strings.TrimPrefix(path.Clean(Separator+filePath), Separator)
+}
+
+// countingReader counts the bytes read
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// entryReader implements iofs.File for a tar archive entry
+type entryReader struct {
+	io.Reader
+	closer io.Closer
+	info   iofs.FileInfo
+}
+
+func (r *entryReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+func (r *entryReader) Close() error {
+	return r.closer.Close()
+}
+
+// bufferWriter buffers all written data
+// and passes it to close when closed
+type bufferWriter struct {
+	bytes.Buffer
+	close func([]byte) error
+}
+
+func (w *bufferWriter) Close() error {
+	if w.close == nil {
+		return fs.ErrFileSystemClosed
+	}
+	err := w.close(w.Bytes())
+	w.close = nil
+	return err
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
@@ -0,0 +1,209 @@
+package tarfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+type testEntry struct {
+	name    string
+	content string
+	link    string
+}
+
+func newTestArchive(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry.name,
+			Mode:     0640,
+			Size:     int64(len(entry.content)),
+		}
+		switch {
+		case entry.link != "":
+			header.Typeflag = tar.TypeLink
+			header.Linkname = entry.link
+			header.Size = 0
+		case entry.name[len(entry.name)-1] == '/':
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0750
+		}
+		require.NoError(t, w.WriteHeader(header))
+		_, err := w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+var testEntries = []testEntry{
+	{name: "file.txt", content: "file"},
+	{name: "dir/a.txt", content: "a"},
+	{name: "dir/sub/b.txt", content: "b"},
+	{name: "empty/"},
+	{name: "../escaped/c.txt", content: "c"},
+	{name: "dir/sub/.hidden.txt", content: "hidden"},
+	{name: "link.txt", link: "dir/a.txt"},
+}
+
+func TestNewReaderFileSystem(t *testing.T) {
+	data := newTestArchive(t, testEntries)
+	for _, tt := range []struct {
+		name        string
+		archive     fs.MemFile
+		compression *Compression
+	}{
+		{name: "uncompressed", archive: fs.NewMemFile("test.tar", data)},
+		{name: "gzip", archive: fs.NewMemFile("test.tar.gz", gzipData(t, data)), compression: Gzip},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tarFS, err := NewReaderFileSystem(tt.archive)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = tarFS.Close() })
+			require.Equal(t, tt.compression, tarFS.Compression())
+
+			ctx := context.Background()
+			root := tarFS.RootDir()
+			require.Equal(t, fs.File(tarFS.Prefix()+"/"), root)
+			require.True(t, fs.IsRegistered(tarFS))
+			readable, writable := tarFS.ReadableWritable()
+			require.True(t, readable)
+			require.False(t, writable)
+
+			files, err := root.ListDirMax(-1)
+			require.NoError(t, err)
+			require.Equal(t, []string{"dir", "empty", "escaped", "file.txt", "link.txt"}, fs.FileNames(files), "dirs first sorted by name")
+			require.True(t, root.Join("empty").IsDir())
+			require.Equal(t, fs.Permissions(0750), root.Join("empty").Permissions())
+			require.True(t, root.Join("escaped", "c.txt").Exists(), "paths are confined to the root")
+
+			files, err = root.Join("dir").ListDirRecursiveMax(-1)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"a.txt", "b.txt", ".hidden.txt"}, fs.FileNames(files), "only files are listed recursively")
+			require.True(t, root.Join("dir", "sub", ".hidden.txt").IsHidden())
+
+			for file, want := range map[fs.File]string{
+				root.Join("file.txt"):                  "file",
+				root.Join("dir", "a.txt"):              "a",
+				root.Join("dir", "sub", "b.txt"):       "b",
+				root.Join("dir", "sub", ".hidden.txt"): "hidden",
+				root.Join("escaped", "c.txt"):          "c",
+				root.Join("link.txt"):                  "a",
+			} {
+				str, err := file.ReadAllString()
+				require.NoError(t, err)
+				require.Equal(t, want, str, file)
+			}
+			require.Equal(t, int64(4), root.Join("file.txt").Size())
+			require.Equal(t, fs.Permissions(0640), root.Join("file.txt").Permissions())
+
+			_, err = root.Join("missing.txt").ReadAll()
+			require.ErrorIs(t, err, os.ErrNotExist)
+			_, err = root.Join("dir").OpenReader()
+			require.ErrorAs(t, err, new(fs.ErrIsDirectory))
+			err = root.Join("new.txt").WriteAll(nil)
+			require.ErrorIs(t, err, fs.ErrReadOnlyFileSystem)
+
+			require.NoError(t, tarFS.Close())
+			require.False(t, fs.IsRegistered(tarFS))
+			_, err = tarFS.ReadAll(ctx, "/file.txt")
+			require.ErrorIs(t, err, fs.ErrFileSystemClosed)
+		})
+	}
+}
+
+func TestNewReaderFileSystem_Zstd(t *testing.T) {
+	archive := fs.NewMemFile("test.tar.zst", append(Zstd.Magic, 0, 0, 0, 0))
+	_, err := NewReaderFileSystem(archive)
+	require.True(t, errors.Is(err, errors.ErrUnsupported), "zstd needs NewReader")
+}
+
+func TestNewWriterFileSystem(t *testing.T) {
+	archive := fs.File(t.TempDir()).Join("test.tar.gz")
+	tarFS, err := NewWriterFileSystem(archive, CompressionFromName(archive.Name()))
+	require.NoError(t, err)
+
+	readable, writable := tarFS.ReadableWritable()
+	require.False(t, readable)
+	require.True(t, writable)
+	root := tarFS.RootDir()
+	require.NoError(t, root.Join("dir").MakeDir())
+	require.NoError(t, root.Join("dir", "file.txt").WriteAllString("Hello"))
+	writer, err := root.Join("streamed.txt").OpenWriter()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("streamed"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, tarFS.Close())
+	require.ErrorIs(t, tarFS.Touch("/closed.txt", nil), fs.ErrFileSystemClosed)
+
+	tarFS, err = NewReaderFileSystem(archive)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tarFS.Close() })
+	require.Equal(t, Gzip, tarFS.Compression())
+	root = tarFS.RootDir()
+	str, err := root.Join("dir", "file.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+	str, err = root.Join("streamed.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "streamed", str)
+}
+
+func TestWriteDir(t *testing.T) {
+	dir := fs.File(t.TempDir())
+	require.NoError(t, dir.Join("src", "sub").MakeAllDirs())
+	require.NoError(t, dir.Join("src", "a.txt").WriteAllString("a"))
+	require.NoError(t, dir.Join("src", "sub", "b.txt").WriteAllString("b"))
+	require.NoError(t, dir.Join("src", "empty").MakeDir())
+
+	archive := dir.Join("src.tar")
+	err := WriteDir(context.Background(), archive, dir.Join("src"), nil)
+	require.NoError(t, err)
+
+	tarFS, err := NewReaderFileSystem(archive)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tarFS.Close() })
+	root := tarFS.RootDir()
+	files, err := root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"empty", "sub", "a.txt"}, fs.FileNames(files))
+	str, err := root.Join("a.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "a", str)
+	str, err = root.Join("sub", "b.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "b", str)
+
+	err = WriteDir(context.Background(), archive, dir.Join("src", "a.txt"), nil)
+	require.ErrorAs(t, err, new(fs.ErrIsNotDirectory))
+}
+
+func TestCompressionFromName(t *testing.T) {
+	require.Equal(t, Gzip, CompressionFromName("archive.tar.gz"))
+	require.Equal(t, Gzip, CompressionFromName("ARCHIVE.TGZ"))
+	require.Equal(t, Zstd, CompressionFromName("archive.tar.zst"))
+	require.Nil(t, CompressionFromName("archive.tar"))
+}
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -80,3 +81,122 @@ func TempFileCopy(source FileReader) (File, error) {
 	f := TempFile(path.Ext(source.Name()))
 	return f, f.WriteAll(data)
 }
+
+// maxTempNameTries is the number of random names
+// tried to create a temp file or directory
+const maxTempNameTries = 100
+
+// MakeTempFileIn creates a new empty file with a unique name
+// on fileSystem and returns it together with a function
+// that removes the file for deferred cleanup.
+// The file is created in TempDir() for the Local file system
+// and in the root directory for all other file systems.
+// See File.MakeTempChild for the pattern format.
+func MakeTempFileIn(fileSystem FileSystem, pattern string) (file File, remove func() error, err error) {
+	return tempRoot(fileSystem).MakeTempChild(pattern)
+}
+
+// MakeTempDirIn creates a new directory with a unique name
+// on fileSystem and returns it together with a function
+// that removes the directory recursively for deferred cleanup.
+// The directory is created in TempDir() for the Local file system
+// and in the root directory for all other file systems.
+// See File.MakeTempChild for the pattern format.
+//
+// Example:
+//
+//	tempDir, removeTempDir, err := fs.MakeTempDirIn(s3FS, "upload-*")
+//	if err != nil {
+//	    return err
+//	}
+//	defer removeTempDir()
+//	doThingsWith(tempDir)
+func MakeTempDirIn(fileSystem FileSystem, pattern string) (dir File, remove func() error, err error) {
+	return tempRoot(fileSystem).MakeTempChildDir(pattern)
+}
+
+func tempRoot(fileSystem FileSystem) File {
+	if fileSystem == Local {
+		return TempDir()
+	}
+	return fileSystem.RootDir()
+}
+
+// MakeTempChild creates a new empty file with a unique name
+// in the directory and returns it together with a function
+// that removes the file for deferred cleanup.
+//
+// The name is generated like with os.CreateTemp by replacing
+// the last "*" of pattern with a random string
+// or by appending a random string if pattern contains no "*".
+// The pattern must not contain a path separator.
+func (file File) MakeTempChild(pattern string) (child File, remove func() error, err error) {
+	child, err = file.makeTempChild(pattern, createExclusive)
+	if err != nil {
+		return "", nil, err
+	}
+	return child, child.Remove, nil
+}
+
+// createExclusive creates an empty file or returns
+// an ErrAlreadyExists error if the file already exists.
+// Local files are created atomically with O_EXCL,
+// other file systems have no exclusive create
+// so the file is only touched if it does not exist yet.
+func createExclusive(file File) error {
+	if localPath := file.LocalPath(); localPath != "" {
+		perm := Local.DefaultCreatePermissions.FileMode(false)
+		f, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm) //#nosec G304
+		if err != nil {
+			return wrapOSErr(localPath, err)
+		}
+		return f.Close()
+	}
+	if file.Exists() {
+		return NewErrAlreadyExists(file)
+	}
+	return file.Touch()
+}
+
+// MakeTempChildDir creates a new directory with a unique name
+// in the directory and returns it together with a function
+// that removes the directory recursively for deferred cleanup.
+// See MakeTempChild for the pattern format.
+func (file File) MakeTempChildDir(pattern string) (child File, remove func() error, err error) {
+	child, err = file.makeTempChild(pattern, func(child File) error {
+		return child.MakeDir()
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return child, child.RemoveRecursive, nil
+}
+
+// makeTempChild calls create with randomly named children of the directory
+// until create does not return an error wrapping os.ErrExist.
+func (file File) makeTempChild(pattern string, create func(File) error) (File, error) {
+	if file == "" {
+		return "", ErrEmptyPath
+	}
+	if strings.Contains(pattern, file.FileSystem().Separator()) {
+		return "", fmt.Errorf("pattern %q contains path separator %s", pattern, file.FileSystem().Separator())
+	}
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	if err := file.CheckIsDir(); err != nil {
+		return "", err
+	}
+	for range maxTempNameTries {
+		child := file.Join(prefix + fsimpl.RandomString() + suffix)
+		err := create(child)
+		if err == nil {
+			return child, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("can't create unique temp file in %s with pattern %q", file, pattern)
+}
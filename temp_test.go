@@ -1,6 +1,8 @@
 package fs
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,3 +11,69 @@ import (
 func TestTempDir(t *testing.T) {
 	require.True(t, TempDir().IsDir(), "temp directory exists")
 }
+
+func TestMakeTempFileIn(t *testing.T) {
+	subFS, err := NewSubFileSystem(File(t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = subFS.Close() })
+
+	for _, fileSystem := range []FileSystem{Local, subFS} {
+		t.Run(fileSystem.Name(), func(t *testing.T) {
+			file, remove, err := MakeTempFileIn(fileSystem, "test-*.txt")
+			require.NoError(t, err)
+			require.Equal(t, fileSystem, file.FileSystem())
+			require.True(t, file.Exists(), "temp file exists")
+			require.False(t, file.IsDir())
+			require.True(t, strings.HasPrefix(file.Name(), "test-"), file.Name())
+			require.True(t, strings.HasSuffix(file.Name(), ".txt"), file.Name())
+			require.NoError(t, remove())
+			require.False(t, file.Exists(), "temp file removed")
+
+			dir, remove, err := MakeTempDirIn(fileSystem, "test-dir")
+			require.NoError(t, err)
+			require.True(t, dir.IsDir(), "temp dir exists")
+			require.True(t, strings.HasPrefix(dir.Name(), "test-dir"), dir.Name())
+			require.NoError(t, dir.Join("file.txt").Touch())
+			require.NoError(t, remove())
+			require.False(t, dir.Exists(), "temp dir removed recursively")
+		})
+	}
+}
+
+func TestFile_MakeTempChild(t *testing.T) {
+	dir := File(t.TempDir())
+
+	a, _, err := dir.MakeTempChild("*")
+	require.NoError(t, err)
+	b, _, err := dir.MakeTempChild("*")
+	require.NoError(t, err)
+	require.NotEqual(t, a, b, "unique names")
+	require.Equal(t, dir, a.Dir())
+
+	child, _, err := dir.MakeTempChildDir("sub-*-dir")
+	require.NoError(t, err)
+	require.True(t, child.IsDir())
+	require.Regexp(t, `^sub-.+-dir$`, child.Name())
+
+	_, _, err = dir.MakeTempChild("invalid" + dir.FileSystem().Separator() + "*")
+	require.Error(t, err, "pattern with separator")
+	_, _, err = a.MakeTempChild("*")
+	require.Error(t, err, "parent is not a directory")
+}
+
+func TestCreateExclusive(t *testing.T) {
+	dir := File(t.TempDir())
+	file := dir.Join("file.txt")
+	require.NoError(t, createExclusive(file))
+	require.True(t, file.Exists())
+	err := createExclusive(file)
+	require.ErrorIs(t, err, os.ErrExist)
+	require.ErrorAs(t, err, new(ErrAlreadyExists))
+
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+	file = memFS.RootDir().Join("file.txt")
+	require.NoError(t, createExclusive(file))
+	require.ErrorIs(t, createExclusive(file), os.ErrExist)
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ungerik/go-fs"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDirectoryErrors checks that a file system returns fs.ErrIsDirectory
+// when the directory dir is used as a file and fs.ErrIsNotDirectory
+// when the file named "file" in dir is used as a directory.
+func TestDirectoryErrors(t *testing.T, dir fs.File) {
+	t.Helper()
+
+	file := dir.Join("file")
+	require.NoError(t, file.WriteAllString("content"))
+
+	requireIsDirectory := func(t *testing.T, err error) {
+		t.Helper()
+		require.True(t, errors.As(err, new(fs.ErrIsDirectory)), "expected ErrIsDirectory, got: %v", err)
+	}
+	requireIsNotDirectory := func(t *testing.T, err error) {
+		t.Helper()
+		require.True(t, errors.As(err, new(fs.ErrIsNotDirectory)), "expected ErrIsNotDirectory, got: %v", err)
+	}
+
+	// Directory used as file
+	{
+		_, err := dir.ReadAll()
+		requireIsDirectory(t, err)
+
+		err = dir.WriteAll([]byte("content"))
+		requireIsDirectory(t, err)
+
+		err = dir.Append(context.Background(), []byte("content"))
+		requireIsDirectory(t, err)
+
+		require.True(t, dir.IsDir(), "dir is still a directory")
+	}
+
+	// File used as directory
+	{
+		err := file.ListDir(func(fs.File) error { return nil })
+		requireIsNotDirectory(t, err)
+
+		err = file.ListDirInfo(func(*fs.FileInfo) error { return nil })
+		requireIsNotDirectory(t, err)
+
+		err = file.ListDirRecursive(func(fs.File) error { return nil })
+		requireIsNotDirectory(t, err)
+
+		_, err = file.ListDirMax(-1)
+		requireIsNotDirectory(t, err)
+
+		content, err := file.ReadAllString()
+		require.NoError(t, err)
+		require.Equal(t, "content", content, "file content unchanged")
+	}
+}
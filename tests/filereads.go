@@ -153,9 +153,10 @@ func TestFileMetadata(t *testing.T, expected fs.FileInfo, file fs.File) {
 
 	assert.Equal(t, expected.File, file)
 
-	// Info
-	info := file.Info()
-	require.Equal(t, expected, *info)
+	// Info, Extra is backend specific and may differ between calls
+	info := *file.Info()
+	expected.Extra, info.Extra = nil, nil
+	require.Equal(t, expected, info)
 
 	// Stat
 	stat, err := file.Stat()
@@ -34,3 +34,7 @@ func TestLocalFileSystem(t *testing.T) {
 	}
 	TestFileMetadata(t, info, file)
 }
+
+func TestLocalFileSystemDirectoryErrors(t *testing.T) {
+	TestDirectoryErrors(t, fs.File(t.TempDir()))
+}
@@ -1,5 +1,24 @@
 package tests
 
+import (
+	"testing"
+
+	"github.com/ungerik/go-fs"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFileSystemDirectoryErrors(t *testing.T) {
+	memFS, err := fs.NewMemFileSystem("/")
+	require.NoError(t, err)
+	defer memFS.Close()
+
+	dir := memFS.RootDir().Join("dir")
+	require.NoError(t, dir.MakeDir())
+
+	TestDirectoryErrors(t, dir)
+}
+
 // import (
 // 	"testing"
 // 	"time"
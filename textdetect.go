@@ -0,0 +1,71 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"unicode/utf8"
+)
+
+// TextDetectionSampleSize is the number of bytes
+// read from the beginning of a file by IsText and IsBinary.
+var TextDetectionSampleSize = 8 * 1024
+
+// IsTextData returns true if data looks like text.
+// Data with a UTF-8 or UTF-16 byte order mark (BOM) is always text.
+// Else data is considered binary if it contains a zero byte
+// or is not valid UTF-8. An incomplete UTF-8 sequence
+// at the end of data is ignored so that data can be
+// a truncated sample of a bigger file.
+// Empty data is considered text.
+func IsTextData(data []byte) bool {
+	if bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}) ||
+		bytes.HasPrefix(data, []byte{0xFF, 0xFE}) ||
+		bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		return true
+	}
+	if bytes.IndexByte(data, 0) != -1 {
+		return false
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			// Allow truncated sequence at the end of the sample
+			return !utf8.FullRune(data) && len(data) < utf8.UTFMax
+		}
+		data = data[size:]
+	}
+	return true
+}
+
+// IsText reads up to TextDetectionSampleSize bytes
+// from the beginning of the file and returns
+// if they look like text according to IsTextData.
+// The file does not have to be read completely.
+func (file File) IsText(ctx context.Context) (bool, error) {
+	sample, err := file.ReadFirst(ctx, TextDetectionSampleSize)
+	if err != nil {
+		return false, err
+	}
+	return IsTextData(sample), nil
+}
+
+// IsBinary returns the negation of IsText
+// if there was no error.
+func (file File) IsBinary(ctx context.Context) (bool, error) {
+	isText, err := file.IsText(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !isText, nil
+}
+
+// IsText returns if the first TextDetectionSampleSize bytes
+// of the FileData look like text according to IsTextData.
+func (f MemFile) IsText() bool {
+	return IsTextData(f.FileData[:min(len(f.FileData), TextDetectionSampleSize)])
+}
+
+// IsBinary returns the negation of IsText.
+func (f MemFile) IsBinary() bool {
+	return !f.IsText()
+}
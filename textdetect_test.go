@@ -0,0 +1,58 @@
+package fs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTextData(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "empty", data: nil, want: true},
+		{name: "ASCII", data: []byte("Hello World\n"), want: true},
+		{name: "UTF-8", data: []byte("Grüße 世界"), want: true},
+		{name: "truncated UTF-8", data: []byte("世界")[:4], want: true},
+		{name: "zero byte", data: []byte("Hello\x00World"), want: false},
+		{name: "invalid UTF-8", data: []byte("Gr\xfc\xdfe"), want: false},
+		{name: "invalid UTF-8 at end", data: []byte("Hello\xff"), want: false},
+		{name: "UTF-16LE BOM", data: []byte{0xFF, 0xFE, 'H', 0, 'i', 0}, want: true},
+		{name: "PNG header", data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, IsTextData(tt.data))
+		})
+	}
+}
+
+func TestFile_IsText(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+
+	textFile := dir.Join("text.txt")
+	// Binary data after the sample size is not read
+	err := textFile.WriteAllString(strings.Repeat("a", TextDetectionSampleSize) + "\x00")
+	require.NoError(t, err)
+	isText, err := textFile.IsText(ctx)
+	require.NoError(t, err)
+	require.True(t, isText)
+
+	binFile := dir.Join("bin.dat")
+	err = binFile.WriteAll([]byte{1, 2, 0, 3})
+	require.NoError(t, err)
+	isBinary, err := binFile.IsBinary(ctx)
+	require.NoError(t, err)
+	require.True(t, isBinary)
+
+	_, err = dir.Join("missing").IsText(ctx)
+	require.Error(t, err)
+
+	require.True(t, NewMemFile("a.txt", []byte("Hello")).IsText())
+	require.True(t, NewMemFile("a.bin", []byte{0}).IsBinary())
+}
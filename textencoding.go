@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// TextEncoding is the name of a text encoding
+// that can be decoded to a UTF-8 Go string.
+type TextEncoding string
+
+const (
+	// TextEncodingUTF8 is the default encoding of Go strings.
+	TextEncodingUTF8 TextEncoding = "UTF-8"
+
+	// TextEncodingUTF16LE is little endian UTF-16 as used by Windows.
+	TextEncodingUTF16LE TextEncoding = "UTF-16LE"
+
+	// TextEncodingUTF16BE is big endian UTF-16.
+	TextEncodingUTF16BE TextEncoding = "UTF-16BE"
+
+	// TextEncodingLatin1 is ISO 8859-1, aka Latin-1.
+	TextEncodingLatin1 TextEncoding = "ISO-8859-1"
+)
+
+func (enc TextEncoding) encoding() (encoding.Encoding, error) {
+	switch enc {
+	case "", TextEncodingUTF8:
+		return unicode.UTF8, nil
+	case TextEncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+	case TextEncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+	case TextEncodingLatin1:
+		return charmap.ISO8859_1, nil
+	default:
+		return nil, fmt.Errorf("unsupported text encoding %q", string(enc))
+	}
+}
+
+// DecodeText returns data decoded from textEncoding as UTF-8 string.
+// A UTF-8 or UTF-16 byte order mark (BOM) at the beginning of data
+// is stripped and overrides the passed textEncoding.
+// An empty textEncoding is interpreted as TextEncodingUTF8.
+func DecodeText(data []byte, textEncoding TextEncoding) (string, error) {
+	enc, err := textEncoding.encoding()
+	if err != nil {
+		return "", err
+	}
+	decoded, _, err := transform.Bytes(unicode.BOMOverride(enc.NewDecoder()), data)
+	if err != nil {
+		return "", fmt.Errorf("can't decode %s text: %w", textEncoding, err)
+	}
+	return string(decoded), nil
+}
+
+// ReadAllText reads the complete file and returns the content as UTF-8 string.
+// In contrast to ReadAllString, a byte order mark (BOM) is stripped
+// and the content is decoded from the optional textEncoding.
+// Without a textEncoding UTF-8 is assumed
+// unless a UTF-16 byte order mark is found.
+func (file File) ReadAllText(ctx context.Context, textEncoding ...TextEncoding) (string, error) {
+	data, err := file.ReadAllContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return DecodeText(data, firstTextEncoding(textEncoding))
+}
+
+// ReadAllText returns the FileData as UTF-8 string.
+// In contrast to ReadAllString, a byte order mark (BOM) is stripped
+// and the content is decoded from the optional textEncoding.
+// Without a textEncoding UTF-8 is assumed
+// unless a UTF-16 byte order mark is found.
+func (f MemFile) ReadAllText(ctx context.Context, textEncoding ...TextEncoding) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return DecodeText(f.FileData, firstTextEncoding(textEncoding))
+}
+
+func firstTextEncoding(textEncoding []TextEncoding) TextEncoding {
+	if len(textEncoding) == 0 {
+		return TextEncodingUTF8
+	}
+	return textEncoding[0]
+}
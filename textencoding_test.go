@@ -0,0 +1,51 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding TextEncoding
+		want     string
+		wantErr  bool
+	}{
+		{name: "empty", data: nil, encoding: "", want: ""},
+		{name: "UTF-8", data: []byte("Hello ä"), encoding: TextEncodingUTF8, want: "Hello ä"},
+		{name: "UTF-8 BOM", data: []byte("\xEF\xBB\xBFHello"), encoding: TextEncodingUTF8, want: "Hello"},
+		{name: "UTF-16LE BOM", data: []byte("\xFF\xFEH\x00i\x00"), encoding: "", want: "Hi"},
+		{name: "UTF-16BE BOM", data: []byte("\xFE\xFF\x00H\x00i"), encoding: "", want: "Hi"},
+		{name: "UTF-16LE", data: []byte("H\x00i\x00"), encoding: TextEncodingUTF16LE, want: "Hi"},
+		{name: "UTF-16BE", data: []byte("\x00H\x00i"), encoding: TextEncodingUTF16BE, want: "Hi"},
+		{name: "Latin-1", data: []byte("Gr\xFC\xDFe"), encoding: TextEncodingLatin1, want: "Grüße"},
+		{name: "unsupported", data: []byte("x"), encoding: "EBCDIC", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeText(tt.data, tt.encoding)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemFile_ReadAllText(t *testing.T) {
+	f := NewMemFile("windows.ini", []byte("\xFF\xFEa\x00=\x001\x00"))
+	str, err := f.ReadAllText(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "a=1", str)
+
+	f = NewMemFile("latin1.txt", []byte("\xE4"))
+	str, err = f.ReadAllText(context.Background(), TextEncodingLatin1)
+	require.NoError(t, err)
+	require.Equal(t, "ä", str)
+}
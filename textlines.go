@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"context"
+	"strings"
+)
+
+// EOL is an end of line character sequence.
+type EOL string
+
+const (
+	// EOLLF is the line feed used as end of line on Unix systems.
+	EOLLF EOL = "\n"
+
+	// EOLCRLF is the carriage return line feed sequence
+	// used as end of line on Windows.
+	EOLCRLF EOL = "\r\n"
+
+	// EOLNative is the end of line sequence
+	// of the operating system of the current process.
+	EOLNative EOL = localEOL
+)
+
+// SplitLines splits str into lines separated by
+// LF or CRLF end of line sequences.
+// A final end of line sequence does not result
+// in an additional empty line.
+func SplitLines(str string) []string {
+	if str == "" {
+		return nil
+	}
+	str = strings.TrimSuffix(str, "\n")
+	lines := strings.Split(str, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// JoinLines joins lines by terminating every line with eol.
+// LF or CRLF end of line sequences within lines
+// are normalized to eol.
+// An empty eol is interpreted as EOLLF.
+func JoinLines(lines []string, eol EOL) string {
+	if eol == "" {
+		eol = EOLLF
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if strings.ContainsRune(line, '\n') {
+			line = strings.Join(SplitLines(line), string(eol))
+		}
+		b.WriteString(line)
+		b.WriteString(string(eol))
+	}
+	return b.String()
+}
+
+// ReadAllLines reads the complete file and returns
+// its lines separated by LF or CRLF end of line sequences.
+// The returned lines don't contain the end of line characters.
+func (file File) ReadAllLines(ctx context.Context) ([]string, error) {
+	str, err := file.ReadAllStringContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return SplitLines(str), nil
+}
+
+// WriteAllLines writes lines to the file terminating every line with eol.
+// LF or CRLF end of line sequences within lines
+// are normalized to eol.
+// An empty eol is interpreted as EOLLF.
+func (file File) WriteAllLines(ctx context.Context, lines []string, eol EOL, perm ...Permissions) error {
+	if file == "" {
+		return ErrEmptyPath
+	}
+	return file.WriteAllStringContext(ctx, JoinLines(lines, eol), perm...)
+}
@@ -0,0 +1,50 @@
+package fs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []string
+	}{
+		{str: "", want: nil},
+		{str: "a", want: []string{"a"}},
+		{str: "a\n", want: []string{"a"}},
+		{str: "a\r\n", want: []string{"a"}},
+		{str: "a\nb", want: []string{"a", "b"}},
+		{str: "a\r\nb\r\n", want: []string{"a", "b"}},
+		{str: "a\r\n\nb\n\n", want: []string{"a", "", "b", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			require.Equal(t, tt.want, SplitLines(tt.str))
+		})
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	require.Equal(t, "", JoinLines(nil, EOLCRLF))
+	require.Equal(t, "a\nb\n", JoinLines([]string{"a", "b"}, ""))
+	require.Equal(t, "a\r\nb\r\n", JoinLines([]string{"a", "b"}, EOLCRLF))
+	require.Equal(t, "a\r\nb\r\nc\r\n", JoinLines([]string{"a\nb", "c"}, EOLCRLF))
+	require.Equal(t, "a\nb\nc\n", JoinLines([]string{"a\r\nb", "c"}, EOLLF))
+}
+
+func TestFile_WriteAllLines(t *testing.T) {
+	file := File(t.TempDir()).Join("lines.txt")
+
+	err := file.WriteAllLines(context.Background(), []string{"one", "two\r\nthree"}, EOLCRLF)
+	require.NoError(t, err)
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "one\r\ntwo\r\nthree\r\n", str)
+
+	lines, err := file.ReadAllLines(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "two", "three"}, lines)
+}
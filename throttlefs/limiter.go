@@ -0,0 +1,60 @@
+package throttlefs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket that refills with rate tokens per second
+// up to a burst of one second worth of tokens.
+// A nil limiter does not limit anything.
+type limiter struct {
+	mtx    sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter returns a limiter with a full bucket
+// or nil if rate is not positive.
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &limiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// waitN takes n tokens from the bucket and waits until
+// the bucket has been refilled enough to pay for them.
+// n may be larger than the burst, in which case the bucket
+// goes into debt that has to be paid by the following callers.
+func (l *limiter) waitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return ctx.Err()
+	}
+
+	l.mtx.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mtx.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the tokens that were not waited for
+		l.mtx.Lock()
+		l.tokens += float64(n)
+		l.mtx.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,491 @@
+// Package throttlefs implements a file system that limits
+// the throughput and operation rate of another file system.
+package throttlefs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of ThrottleFileSystem URIs
+	Prefix = "throttle://"
+
+	copyBufferSize = 32 * 1024
+)
+
+var (
+	// Make sure ThrottleFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(ThrottleFileSystem)
+	_ fs.ReadAllFileSystem  = new(ThrottleFileSystem)
+	_ fs.WriteAllFileSystem = new(ThrottleFileSystem)
+	_ fs.AppendFileSystem   = new(ThrottleFileSystem)
+	_ fs.TouchFileSystem    = new(ThrottleFileSystem)
+	_ fs.TruncateFileSystem = new(ThrottleFileSystem)
+	_ fs.CopyFileSystem     = new(ThrottleFileSystem)
+	_ fs.RenameFileSystem   = new(ThrottleFileSystem)
+	_ fs.MoveFileSystem     = new(ThrottleFileSystem)
+	_ fs.ExistsFileSystem   = new(ThrottleFileSystem)
+)
+
+// Limits for a ThrottleFileSystem.
+// Zero or negative values mean no limit.
+//
+// Bursts of up to one second worth of the limits are allowed,
+// larger reads and writes are paid for by waiting
+// before the following operations.
+type Limits struct {
+	// ReadBytesPerSecond limits the bytes read from files
+	ReadBytesPerSecond int64
+	// WriteBytesPerSecond limits the bytes written to files
+	WriteBytesPerSecond int64
+	// ReadOpsPerSecond limits operations that read files or meta data
+	// like Stat, Exists, ListDirInfo, ReadAll, and OpenReader
+	ReadOpsPerSecond float64
+	// WriteOpsPerSecond limits operations that modify the file system
+	// like WriteAll, Append, Touch, MakeDir, OpenWriter,
+	// CopyFile, Rename, Move, and Remove
+	WriteOpsPerSecond float64
+}
+
+// ThrottleFileSystem wraps a file system and limits
+// the throughput and rate of its operations.
+// Use it to keep background jobs like synchronizations
+// from saturating the network link of SFTP or S3 file systems.
+//
+// It is registered with the prefix "throttle://" followed by a random ID
+// and uses the paths of the wrapped file system.
+//
+// Readers and writers returned by OpenReader and OpenWriter
+// are throttled while streaming, ReadAll, WriteAll, and Append
+// are throttled by the size of the data.
+// Server side operations like Rename, Move, and CopyFile
+// of file systems implementing fs.CopyFileSystem
+// only count as operations without transferred bytes.
+type ThrottleFileSystem struct {
+	prefix string
+	base   fs.FileSystem
+	closed atomic.Bool
+
+	readBytes  *limiter
+	writeBytes *limiter
+	readOps    *limiter
+	writeOps   *limiter
+}
+
+// New returns a new ThrottleFileSystem for base with limits
+// and registers it.
+func New(base fs.FileSystem, limits Limits) (*ThrottleFileSystem, error) {
+	if base == nil {
+		return nil, fmt.Errorf("nil base file system")
+	}
+	f := &ThrottleFileSystem{
+		prefix:     Prefix + fsimpl.RandomString(),
+		base:       base,
+		readBytes:  newLimiter(float64(limits.ReadBytesPerSecond)),
+		writeBytes: newLimiter(float64(limits.WriteBytesPerSecond)),
+		readOps:    newLimiter(limits.ReadOpsPerSecond),
+		writeOps:   newLimiter(limits.WriteOpsPerSecond),
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Base returns the throttled file system
+func (f *ThrottleFileSystem) Base() fs.FileSystem {
+	return f.base
+}
+
+// baseFile returns the file of the base file system for filePath
+func (f *ThrottleFileSystem) baseFile(filePath string) fs.File {
+	return fs.File(f.base.URL(filePath))
+}
+
+func (f *ThrottleFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+// readOp checks if the file system is open and waits for a read operation
+func (f *ThrottleFileSystem) readOp(ctx context.Context) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.readOps.waitN(ctx, 1)
+}
+
+// writeOp checks if the file system is open and waits for a write operation
+func (f *ThrottleFileSystem) writeOp(ctx context.Context) error {
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	return f.writeOps.waitN(ctx, 1)
+}
+
+func (f *ThrottleFileSystem) ReadableWritable() (readable, writable bool) {
+	return f.base.ReadableWritable()
+}
+
+func (f *ThrottleFileSystem) RootDir() fs.File {
+	return f.JoinCleanFile(f.base.Separator())
+}
+
+func (f *ThrottleFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *ThrottleFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *ThrottleFileSystem) Name() string {
+	return "throttled " + f.base.Name()
+}
+
+// String implements the fmt.Stringer interface.
+func (f *ThrottleFileSystem) String() string {
+	return f.Name() + " with prefix " + f.prefix
+}
+
+func (f *ThrottleFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *ThrottleFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *ThrottleFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *ThrottleFileSystem) JoinCleanPath(uriParts ...string) string {
+	return f.base.JoinCleanPath(uriParts...)
+}
+
+func (f *ThrottleFileSystem) SplitPath(filePath string) []string {
+	return f.base.SplitPath(filePath)
+}
+
+func (f *ThrottleFileSystem) Separator() string {
+	return f.base.Separator()
+}
+
+func (f *ThrottleFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return f.base.MatchAnyPattern(name, patterns)
+}
+
+func (f *ThrottleFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return f.base.SplitDirAndName(filePath)
+}
+
+func (f *ThrottleFileSystem) IsAbsPath(filePath string) bool {
+	return f.base.IsAbsPath(filePath)
+}
+
+func (f *ThrottleFileSystem) AbsPath(filePath string) string {
+	return f.base.AbsPath(filePath)
+}
+
+func (f *ThrottleFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.readOp(context.Background()); err != nil {
+		return nil, err
+	}
+	return f.base.Stat(filePath)
+}
+
+func (f *ThrottleFileSystem) Exists(filePath string) bool {
+	return filePath != "" && f.readOp(context.Background()) == nil && f.baseFile(filePath).Exists()
+}
+
+func (f *ThrottleFileSystem) IsHidden(filePath string) bool {
+	return f.base.IsHidden(filePath)
+}
+
+func (f *ThrottleFileSystem) IsSymbolicLink(filePath string) bool {
+	return f.base.IsSymbolicLink(filePath)
+}
+
+func (f *ThrottleFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.readOp(ctx); err != nil {
+		return err
+	}
+	return f.base.ListDirInfo(ctx, dirPath, func(baseInfo *fs.FileInfo) error {
+		info := *baseInfo
+		info.File = f.JoinCleanFile(dirPath, info.Name)
+		return callback(&info)
+	}, patterns)
+}
+
+func (f *ThrottleFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return err
+	}
+	return f.base.MakeDir(dirPath, perm)
+}
+
+func (f *ThrottleFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.readOp(ctx); err != nil {
+		return nil, err
+	}
+	data, err := f.baseFile(filePath).ReadAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = f.readBytes.waitN(ctx, len(data))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (f *ThrottleFileSystem) OpenReader(filePath string) (fs.ReadCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.readOp(context.Background()); err != nil {
+		return nil, err
+	}
+	reader, err := f.base.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &throttledReader{ReadCloser: reader, limiter: f.readBytes}, nil
+}
+
+func (f *ThrottleFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(ctx); err != nil {
+		return err
+	}
+	if err := f.writeBytes.waitN(ctx, len(data)); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).WriteAllContext(ctx, data, perm...)
+}
+
+func (f *ThrottleFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(ctx); err != nil {
+		return err
+	}
+	if err := f.writeBytes.waitN(ctx, len(data)); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).Append(ctx, data, perm...)
+}
+
+func (f *ThrottleFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).Touch(perm...)
+}
+
+func (f *ThrottleFileSystem) Truncate(filePath string, size int64) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).Truncate(size)
+}
+
+func (f *ThrottleFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return nil, err
+	}
+	writer, err := f.base.OpenWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &throttledWriter{WriteCloser: writer, limiter: f.writeBytes}, nil
+}
+
+func (f *ThrottleFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return nil, err
+	}
+	readWriter, err := f.base.OpenReadWriter(filePath, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &throttledReadWriter{ReadWriteSeekCloser: readWriter, readLimiter: f.readBytes, writeLimiter: f.writeBytes}, nil
+}
+
+// CopyFile uses the server side copy of the base file system if available
+// or else streams the file through a throttled reader and writer.
+func (f *ThrottleFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(ctx); err != nil {
+		return err
+	}
+	if copyFS, ok := f.base.(fs.CopyFileSystem); ok {
+		return copyFS.CopyFile(ctx, srcFile, destFile, buf)
+	}
+
+	src, err := f.base.OpenReader(srcFile)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := f.base.OpenWriter(destFile, []fs.Permissions{f.baseFile(srcFile).Permissions()})
+	if err != nil {
+		return err
+	}
+	if len(*buf) == 0 {
+		*buf = make([]byte, copyBufferSize)
+	}
+	r := &throttledReader{ReadCloser: src, limiter: f.readBytes, ctx: ctx}
+	w := &throttledWriter{WriteCloser: dest, limiter: f.writeBytes, ctx: ctx}
+	_, err = io.CopyBuffer(w, r, *buf)
+	if err != nil {
+		_ = dest.Close()
+		return err
+	}
+	return dest.Close()
+}
+
+func (f *ThrottleFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return "", err
+	}
+	renamed, err := f.baseFile(filePath).Rename(newName)
+	if err != nil {
+		return "", err
+	}
+	return renamed.Path(), nil
+}
+
+func (f *ThrottleFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return err
+	}
+	return f.baseFile(filePath).MoveTo(f.baseFile(destPath))
+}
+
+func (f *ThrottleFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.writeOp(context.Background()); err != nil {
+		return err
+	}
+	return f.base.Remove(filePath)
+}
+
+// Close unregisters the file system,
+// the base file system is not closed.
+func (f *ThrottleFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
+
+// throttledReader waits after every read
+// until the read bytes are paid for
+type throttledReader struct {
+	fs.ReadCloser
+	limiter *limiter
+	ctx     context.Context // nil for context.Background()
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if waitErr := r.limiter.waitN(contextOrBackground(r.ctx), n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
+
+// throttledWriter waits before every write
+// until the bytes to write are paid for
+type throttledWriter struct {
+	fs.WriteCloser
+	limiter *limiter
+	ctx     context.Context // nil for context.Background()
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	if err := w.limiter.waitN(contextOrBackground(w.ctx), len(p)); err != nil {
+		return 0, err
+	}
+	return w.WriteCloser.Write(p)
+}
+
+// throttledReadWriter throttles reads and writes
+type throttledReadWriter struct {
+	fs.ReadWriteSeekCloser
+	readLimiter  *limiter
+	writeLimiter *limiter
+}
+
+func (rw *throttledReadWriter) Read(p []byte) (int, error) {
+	n, err := rw.ReadWriteSeekCloser.Read(p)
+	if waitErr := rw.readLimiter.waitN(context.Background(), n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	return n, err
+}
+
+func (rw *throttledReadWriter) Write(p []byte) (int, error) {
+	if err := rw.writeLimiter.waitN(context.Background(), len(p)); err != nil {
+		return 0, err
+	}
+	return rw.ReadWriteSeekCloser.Write(p)
+}
+
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
@@ -0,0 +1,100 @@
+package throttlefs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestThrottleFileSystem(t *testing.T) {
+	throttleFS, err := New(fs.Local, Limits{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = throttleFS.Close() })
+	dir := throttleFS.JoinCleanFile(t.TempDir())
+	require.True(t, fs.IsRegistered(throttleFS))
+	require.True(t, dir.IsDir())
+
+	file := dir.Join("file.txt")
+	require.NoError(t, file.WriteAllString("Hello"))
+	require.NoError(t, file.AppendString(context.Background(), " World"))
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", str)
+	str, err = fs.File(file.Path()).ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", str, "written to base")
+
+	reader, err := file.OpenReader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(data))
+	require.NoError(t, reader.Close())
+
+	require.NoError(t, fs.CopyFile(context.Background(), file, dir.Join("copy.txt")))
+	str, err = dir.Join("copy.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", str)
+	renamed, err := dir.Join("copy.txt").Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, dir.Join("renamed.txt"), renamed)
+	files, err := dir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []fs.File{file, renamed}, files)
+	require.NoError(t, renamed.Remove())
+	require.False(t, renamed.Exists())
+
+	require.NoError(t, throttleFS.Close())
+	_, err = throttleFS.Stat(file.Path())
+	require.ErrorIs(t, err, fs.ErrFileSystemClosed)
+}
+
+func TestThrottleFileSystem_Limits(t *testing.T) {
+	throttleFS, err := New(fs.Local, Limits{
+		ReadBytesPerSecond:  100,
+		WriteBytesPerSecond: 100,
+		WriteOpsPerSecond:   20,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = throttleFS.Close() })
+	dir := throttleFS.JoinCleanFile(t.TempDir())
+	file := dir.Join("file.txt")
+
+	// The first second of bytes is a burst,
+	// the next 50 bytes have to be waited for
+	start := time.Now()
+	require.NoError(t, file.WriteAll(make([]byte, 150)))
+	require.NoError(t, file.Append(context.Background(), make([]byte, 1)))
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond, "write bytes")
+
+	start = time.Now()
+	data, err := file.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, data, 151)
+	reader, err := file.OpenReader()
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Len(t, data, 151)
+	require.NoError(t, reader.Close())
+	require.GreaterOrEqual(t, time.Since(start), 1500*time.Millisecond, "read bytes")
+
+	// 20 ops burst plus 10 ops to wait for
+	start = time.Now()
+	for range 30 {
+		require.NoError(t, throttleFS.Touch(file.Path(), nil))
+	}
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond, "write ops")
+
+	// Canceled waits return the context error
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = file.WriteAllContext(ctx, make([]byte, 1000))
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "canceled write")
+}
@@ -0,0 +1,147 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TreeOptions are the options for File.Tree and File.TreeString
+type TreeOptions struct {
+	// MaxDepth limits the depth of listed sub-directories
+	// if greater than zero, where 1 lists only the
+	// direct content of the root directory.
+	MaxDepth int
+
+	// ShowHidden includes hidden files and directories
+	ShowHidden bool
+
+	// DirsOnly lists only directories
+	DirsOnly bool
+
+	// ShowSize renders the size of files by TreeString
+	ShowSize bool
+}
+
+// TreeNode is a file or directory of a tree returned by File.Tree.
+// Children are sorted by name.
+type TreeNode struct {
+	Name     string      `json:"name"`
+	IsDir    bool        `json:"isDir"`
+	Size     int64       `json:"size"`
+	Modified time.Time   `json:"modified"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// Tree returns the file as root of a tree of
+// all files and sub-directories as TreeNode.
+// Symbolic links to directories are not followed.
+func (file File) Tree(ctx context.Context, opts TreeOptions) (*TreeNode, error) {
+	info, err := file.InfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root := &TreeNode{
+		Name:     info.Name,
+		IsDir:    info.IsDir,
+		Size:     info.Size,
+		Modified: info.Modified,
+	}
+	if info.IsDir {
+		err = file.treeChildren(ctx, root, opts, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func (file File) treeChildren(ctx context.Context, node *TreeNode, opts TreeOptions, depth int) error {
+	if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+		return nil
+	}
+	var infos []*FileInfo
+	err := file.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		if info.IsHidden && !opts.ShowHidden || !info.IsDir && opts.DirsOnly {
+			return nil
+		}
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(infos, func(a, b *FileInfo) int { return strings.Compare(a.Name, b.Name) })
+	for _, info := range infos {
+		child := &TreeNode{
+			Name:     info.Name,
+			IsDir:    info.IsDir,
+			Size:     info.Size,
+			Modified: info.Modified,
+		}
+		node.Children = append(node.Children, child)
+		if info.IsDir && !info.File.IsSymbolicLink() {
+			err = info.File.treeChildren(ctx, child, opts, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TreeString returns a textual rendering of the directory tree
+// of the file like the output of the tree command:
+//
+//	dir
+//	├── sub
+//	│   └── b.txt
+//	└── c.txt
+func (file File) TreeString(ctx context.Context, opts TreeOptions) (string, error) {
+	root, err := file.Tree(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeTreeNodeName(&b, root, opts)
+	writeTreeChildren(&b, root, "", opts)
+	return b.String(), nil
+}
+
+func writeTreeChildren(b *strings.Builder, node *TreeNode, indent string, opts TreeOptions) {
+	for i, child := range node.Children {
+		last := i == len(node.Children)-1
+		if last {
+			b.WriteString(indent + "└── ")
+		} else {
+			b.WriteString(indent + "├── ")
+		}
+		writeTreeNodeName(b, child, opts)
+		if last {
+			writeTreeChildren(b, child, indent+"    ", opts)
+		} else {
+			writeTreeChildren(b, child, indent+"│   ", opts)
+		}
+	}
+}
+
+func writeTreeNodeName(b *strings.Builder, node *TreeNode, opts TreeOptions) {
+	b.WriteString(node.Name)
+	if opts.ShowSize && !node.IsDir {
+		fmt.Fprintf(b, " (%d bytes)", node.Size)
+	}
+	b.WriteByte('\n')
+}
+
+// TreeJSON returns the directory tree of the file
+// including hidden files as JSON of nested TreeNode objects.
+func (file File) TreeJSON(ctx context.Context) ([]byte, error) {
+	root, err := file.Tree(ctx, TreeOptions{ShowHidden: true})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(root)
+}
@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_TreeString(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir()).Join("root")
+	require.NoError(t, dir.Join("sub", "deeper").MakeAllDirs())
+	require.NoError(t, dir.Join("sub", "b.txt").WriteAllString("bb"))
+	require.NoError(t, dir.Join("sub", "deeper", "d.txt").WriteAllString("d"))
+	require.NoError(t, dir.Join("c.txt").WriteAllString("ccc"))
+	require.NoError(t, dir.Join(".hidden").WriteAllString(""))
+
+	tree, err := dir.TreeString(ctx, TreeOptions{})
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"root\n"+
+		"├── c.txt\n"+
+		"└── sub\n"+
+		"    ├── b.txt\n"+
+		"    └── deeper\n"+
+		"        └── d.txt\n",
+		tree,
+	)
+
+	tree, err = dir.TreeString(ctx, TreeOptions{MaxDepth: 2, ShowHidden: true, ShowSize: true})
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"root\n"+
+		"├── .hidden (0 bytes)\n"+
+		"├── c.txt (3 bytes)\n"+
+		"└── sub\n"+
+		"    ├── b.txt (2 bytes)\n"+
+		"    └── deeper\n",
+		tree,
+	)
+
+	tree, err = dir.TreeString(ctx, TreeOptions{DirsOnly: true})
+	require.NoError(t, err)
+	require.Equal(t, "root\n└── sub\n    └── deeper\n", tree)
+
+	_, err = dir.Join("missing").TreeString(ctx, TreeOptions{})
+	require.Error(t, err)
+}
+
+func TestFile_TreeJSON(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir()).Join("root")
+	require.NoError(t, dir.Join("sub").MakeAllDirs())
+	require.NoError(t, dir.Join("sub", "b.txt").WriteAllString("bb"))
+
+	data, err := dir.TreeJSON(ctx)
+	require.NoError(t, err)
+	var root TreeNode
+	require.NoError(t, json.Unmarshal(data, &root))
+	require.Equal(t, "root", root.Name)
+	require.True(t, root.IsDir)
+	require.Len(t, root.Children, 1)
+	sub := root.Children[0]
+	require.Equal(t, "sub", sub.Name)
+	require.True(t, sub.IsDir)
+	require.Len(t, sub.Children, 1)
+	require.Equal(t, "b.txt", sub.Children[0].Name)
+	require.False(t, sub.Children[0].IsDir)
+	require.Equal(t, int64(2), sub.Children[0].Size)
+}
@@ -0,0 +1,105 @@
+package fs
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DiskUsage is the space of a storage device
+// or quota as reported by DiskUsageFileSystem.
+type DiskUsage struct {
+	// Total size of the storage in bytes
+	Total int64
+	// Free bytes of the storage
+	Free int64
+	// Available bytes for the current user
+	// which can be less than Free because of
+	// reserved blocks or quotas
+	Available int64
+}
+
+// Used returns the used bytes of the storage.
+func (u DiskUsage) Used() int64 {
+	return u.Total - u.Free
+}
+
+// DiskUsage returns the space of the storage device
+// containing the file if its file system implements
+// DiskUsageFileSystem, else an ErrUnsupported error is returned.
+func (file File) DiskUsage() (DiskUsage, error) {
+	if file == "" {
+		return DiskUsage{}, ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	duFS, ok := fileSystem.(DiskUsageFileSystem)
+	if !ok {
+		return DiskUsage{}, NewErrUnsupported(fileSystem, "DiskUsage")
+	}
+	defer beginOp(fileSystem)()
+	return duFS.DiskUsage(path)
+}
+
+// TreeSize returns the total size in bytes and the number
+// of all files and sub-directories of the directory
+// and its sub-directories.
+//
+// The directories are listed in parallel
+// using runtime.NumCPU() workers.
+// Symbolic links to directories are counted
+// as directories but not followed.
+// The first error stops the traversal and will be returned.
+func (file File) TreeSize(ctx context.Context) (bytes int64, files, dirs int, err error) {
+	if err = file.CheckIsDir(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		totalBytes atomic.Int64
+		numFiles   atomic.Int64
+		numDirs    atomic.Int64
+		workers    = make(chan struct{}, runtime.NumCPU())
+		listing    sync.WaitGroup
+		listDir    func(dir File)
+	)
+	listDir = func(dir File) {
+		defer listing.Done()
+
+		var subDirs []File
+		workers <- struct{}{}
+		err := dir.ListDirInfoContext(ctx, func(info *FileInfo) error {
+			if !info.IsDir {
+				totalBytes.Add(info.Size)
+				numFiles.Add(1)
+				return nil
+			}
+			numDirs.Add(1)
+			if !info.File.IsSymbolicLink() {
+				subDirs = append(subDirs, info.File)
+			}
+			return nil
+		})
+		<-workers
+		if err != nil {
+			cancel(err)
+			return
+		}
+
+		for _, subDir := range subDirs {
+			listing.Add(1)
+			go listDir(subDir)
+		}
+	}
+	listing.Add(1)
+	listDir(file)
+	listing.Wait()
+
+	if cause := context.Cause(ctx); cause != nil {
+		return 0, 0, 0, cause
+	}
+	return totalBytes.Load(), int(numFiles.Load()), int(numDirs.Load()), nil
+}
@@ -0,0 +1,62 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_TreeSize(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("a", "b").MakeAllDirs())
+	require.NoError(t, dir.Join("c").MakeDir())
+	require.NoError(t, dir.Join("1.txt").WriteAllString("1"))
+	require.NoError(t, dir.Join("a", "22.txt").WriteAllString("22"))
+	require.NoError(t, dir.Join("a", "b", "333.txt").WriteAllString("333"))
+	require.NoError(t, dir.Join("a", "b", "4444.txt").WriteAllString("4444"))
+
+	bytes, files, dirs, err := dir.TreeSize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), bytes)
+	require.Equal(t, 4, files)
+	require.Equal(t, 3, dirs)
+
+	bytes, files, dirs, err = dir.Join("c").TreeSize(ctx)
+	require.NoError(t, err)
+	require.Zero(t, bytes)
+	require.Zero(t, files)
+	require.Zero(t, dirs)
+
+	_, _, _, err = dir.Join("1.txt").TreeSize(ctx)
+	require.Error(t, err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, _, err = dir.TreeSize(canceled)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFile_DiskUsage(t *testing.T) {
+	dir := File(t.TempDir())
+	usage, err := dir.DiskUsage()
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("DiskUsage not supported on this platform")
+	}
+	require.NoError(t, err)
+	require.Positive(t, usage.Total)
+	require.LessOrEqual(t, usage.Free, usage.Total)
+	require.LessOrEqual(t, usage.Available, usage.Free)
+	require.Equal(t, usage.Total-usage.Free, usage.Used())
+
+	_, err = dir.Join("does-not-exist").DiskUsage()
+	require.True(t, errors.As(err, new(ErrDoesNotExist)))
+
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+	_, err = memFS.RootDir().DiskUsage()
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
@@ -0,0 +1,652 @@
+// Package unionfs implements a file system that merges
+// directories of multiple file systems into one layered view.
+package unionfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of UnionFileSystem URIs
+	Prefix = "union://"
+
+	// Separator used in UnionFileSystem paths
+	Separator = "/"
+
+	// WhiteoutPrefix is the name prefix of whiteout files
+	// that hide files with the rest of the name in lower layers.
+	WhiteoutPrefix = ".wh."
+
+	// OpaqueMarker is the name of a file that hides
+	// the contents of its directory in lower layers.
+	OpaqueMarker = WhiteoutPrefix + WhiteoutPrefix + ".opq"
+)
+
+var (
+	// Make sure UnionFileSystem implements the following interfaces
+	_ fs.FileSystem         = new(UnionFileSystem)
+	_ fs.ExistsFileSystem   = new(UnionFileSystem)
+	_ fs.ReadAllFileSystem  = new(UnionFileSystem)
+	_ fs.WriteAllFileSystem = new(UnionFileSystem)
+	_ fs.AppendFileSystem   = new(UnionFileSystem)
+	_ fs.TouchFileSystem    = new(UnionFileSystem)
+	_ fs.TruncateFileSystem = new(UnionFileSystem)
+	_ fs.CopyFileSystem     = new(UnionFileSystem)
+	_ fs.RenameFileSystem   = new(UnionFileSystem)
+	_ fs.MoveFileSystem     = new(UnionFileSystem)
+)
+
+// UnionFileSystem merges layers of directories from any file systems
+// into one view like the overlay file systems used for Docker images.
+//
+// It is registered with the prefix "union://" followed by a random ID,
+// so the merged root directory is accessed as "union://<id>/".
+//
+// Files are read from the topmost layer that contains them.
+// All changes are written to the upper layer,
+// existing files of lower layers are copied to the upper layer
+// before they are modified.
+// Removing a file or directory that exists in a lower layer
+// creates a whiteout file named WhiteoutPrefix + name in the upper layer.
+// A directory created in place of a removed one gets an OpaqueMarker file
+// so that the contents of lower layers don't reappear.
+// Whiteout and opaque marker files are respected in all layers
+// and not listed.
+//
+// Directories that exist in lower layers can't be moved or renamed.
+type UnionFileSystem struct {
+	prefix string
+	// layers from top to bottom, layers[0] is the upper layer
+	layers []fs.File
+	closed atomic.Bool
+}
+
+// NewAndRegister returns a new UnionFileSystem with the directory upper
+// as writable layer on top of the read-only lower layers
+// and registers it.
+// The lower layers are passed from top to bottom.
+func NewAndRegister(upper fs.File, lower ...fs.File) (*UnionFileSystem, error) {
+	layers := append([]fs.File{upper}, lower...)
+	for _, layer := range layers {
+		if layer == "" {
+			return nil, fs.ErrEmptyPath
+		}
+		err := layer.CheckIsDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	f := &UnionFileSystem{
+		prefix: Prefix + fsimpl.RandomString(),
+		layers: layers,
+	}
+	_, err := fs.TryRegister(f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Layers returns the directories of all layers from top to bottom
+func (f *UnionFileSystem) Layers() []fs.File {
+	return slices.Clone(f.layers)
+}
+
+// Upper returns the directory of the upper layer
+func (f *UnionFileSystem) Upper() fs.File {
+	return f.layers[0]
+}
+
+// hides returns if layer contains a whiteout for the path parts,
+// a whiteout for one of its parent directories,
+// or an opaque marker in one of its parent directories.
+func hides(layer fs.File, parts []string) bool {
+	for i := range parts {
+		dir := layer.Join(parts[:i]...)
+		if dir.Join(WhiteoutPrefix + parts[i]).Exists() {
+			return true
+		}
+		if i > 0 && dir.Join(OpaqueMarker).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleLayers returns the layers from top to bottom
+// where the path parts are not hidden by upper layers.
+func (f *UnionFileSystem) visibleLayers(parts []string) []fs.File {
+	for i, layer := range f.layers {
+		if hides(layer, parts) {
+			return f.layers[:i+1]
+		}
+	}
+	return f.layers
+}
+
+// lookup returns the file of the topmost layer
+// where the path parts exist and are not hidden.
+func (f *UnionFileSystem) lookup(parts []string) (fs.File, bool) {
+	for _, layer := range f.visibleLayers(parts) {
+		file := layer.Join(parts...)
+		if file.Exists() {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// existsBelow returns if the path parts exist
+// in a visible lower layer.
+func (f *UnionFileSystem) existsBelow(parts []string) bool {
+	for _, layer := range f.visibleLayers(parts)[1:] {
+		if layer.Join(parts...).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupFile is like lookup but returns an error
+// if filePath does not exist or is a directory.
+func (f *UnionFileSystem) lookupFile(filePath string) (fs.File, error) {
+	if filePath == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return "", err
+	}
+	file, ok := f.lookup(f.SplitPath(filePath))
+	if !ok {
+		return "", fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if file.IsDir() {
+		return "", fs.NewErrIsDirectory(f.File(filePath))
+	}
+	return file, nil
+}
+
+// upperForWrite returns the file in the upper layer for writing filePath
+// after making sure that its parent directory exists in the upper layer.
+// If copyUp is true, then an existing file of a lower layer
+// is copied to the upper layer.
+// A whiteout for filePath in the upper layer is removed
+// and returned as hadWhiteout.
+func (f *UnionFileSystem) upperForWrite(ctx context.Context, filePath string, copyUp bool) (upperFile fs.File, hadWhiteout bool, err error) {
+	if filePath == "" {
+		return "", false, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return "", false, err
+	}
+	parts := f.SplitPath(filePath)
+	if len(parts) == 0 {
+		return "", false, fs.NewErrIsDirectory(f.RootDir())
+	}
+	upperFile = f.layers[0].Join(parts...)
+	if upperFile.Exists() {
+		return upperFile, false, nil
+	}
+	parentParts, name := parts[:len(parts)-1], parts[len(parts)-1]
+	parent, ok := f.lookup(parentParts)
+	if !ok {
+		return "", false, fs.NewErrDoesNotExist(f.JoinCleanFile(parentParts...))
+	}
+	if !parent.IsDir() {
+		return "", false, fs.NewErrIsNotDirectory(f.JoinCleanFile(parentParts...))
+	}
+	upperParent := f.layers[0].Join(parentParts...)
+	err = upperParent.MakeAllDirs()
+	if err != nil {
+		return "", false, err
+	}
+	if copyUp {
+		if existing, ok := f.lookup(parts); ok {
+			if existing.IsDir() {
+				return "", false, fs.NewErrIsDirectory(f.File(filePath))
+			}
+			err = fs.CopyFile(ctx, existing, upperFile)
+			if err != nil {
+				return "", false, err
+			}
+		}
+	}
+	whiteout := upperParent.Join(WhiteoutPrefix + name)
+	if whiteout.Exists() {
+		err = whiteout.Remove()
+		if err != nil {
+			return "", false, err
+		}
+		hadWhiteout = true
+	}
+	return upperFile, hadWhiteout, nil
+}
+
+func (f *UnionFileSystem) checkOpen() error {
+	if f.closed.Load() {
+		return fs.ErrFileSystemClosed
+	}
+	return nil
+}
+
+func (f *UnionFileSystem) ReadableWritable() (readable, writable bool) {
+	_, writable = f.layers[0].FileSystem().ReadableWritable()
+	return true, writable
+}
+
+func (f *UnionFileSystem) RootDir() fs.File {
+	return fs.File(f.prefix + Separator)
+}
+
+func (f *UnionFileSystem) ID() (string, error) {
+	return strings.TrimPrefix(f.prefix, Prefix), nil
+}
+
+func (f *UnionFileSystem) Prefix() string {
+	return f.prefix
+}
+
+func (f *UnionFileSystem) Name() string {
+	return "union file system"
+}
+
+// String implements the fmt.Stringer interface.
+func (f *UnionFileSystem) String() string {
+	return fmt.Sprintf("%s with prefix %s of %v", f.Name(), f.prefix, f.layers)
+}
+
+func (f *UnionFileSystem) File(filePath string) fs.File {
+	return f.JoinCleanFile(filePath)
+}
+
+func (f *UnionFileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(f.prefix + f.JoinCleanPath(uriParts...))
+}
+
+func (f *UnionFileSystem) URL(cleanPath string) string {
+	return f.prefix + cleanPath
+}
+
+func (f *UnionFileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, f.prefix)
+}
+
+func (f *UnionFileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, f.prefix, Separator)
+}
+
+func (f *UnionFileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, f.prefix, Separator)
+}
+
+func (*UnionFileSystem) Separator() string {
+	return Separator
+}
+
+func (*UnionFileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*UnionFileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+func (*UnionFileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (*UnionFileSystem) AbsPath(filePath string) string {
+	if !path.IsAbs(filePath) {
+		filePath = Separator + filePath
+	}
+	return path.Clean(filePath)
+}
+
+func (f *UnionFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+	file, ok := f.lookup(f.SplitPath(filePath))
+	if !ok {
+		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	return file.Stat()
+}
+
+func (f *UnionFileSystem) Exists(filePath string) bool {
+	if filePath == "" || f.checkOpen() != nil {
+		return false
+	}
+	_, ok := f.lookup(f.SplitPath(filePath))
+	return ok
+}
+
+func (*UnionFileSystem) IsHidden(filePath string) bool {
+	_, name := fsimpl.SplitDirAndName(filePath, 0, Separator)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (f *UnionFileSystem) IsSymbolicLink(filePath string) bool {
+	file, ok := f.lookup(f.SplitPath(filePath))
+	return ok && file.IsSymbolicLink()
+}
+
+// ListDirInfo merges the directory listings of all layers sorted by name.
+func (f *UnionFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	parts := f.SplitPath(dirPath)
+	dir, ok := f.lookup(parts)
+	if !ok {
+		return fs.NewErrDoesNotExist(f.File(dirPath))
+	}
+	if !dir.IsDir() {
+		return fs.NewErrIsNotDirectory(f.File(dirPath))
+	}
+
+	var (
+		infos  []*fs.FileInfo
+		listed = make(map[string]bool)
+	)
+	for _, layer := range f.visibleLayers(parts) {
+		layerDir := layer.Join(parts...)
+		if !layerDir.Exists() {
+			continue
+		}
+		if !layerDir.IsDir() {
+			break // A file in a lower layer is hidden by the directory
+		}
+		var (
+			opaque    bool
+			whiteouts []string
+		)
+		err := layerDir.ListDirInfoContext(ctx, func(layerInfo *fs.FileInfo) error {
+			switch {
+			case layerInfo.Name == OpaqueMarker:
+				opaque = true
+			case strings.HasPrefix(layerInfo.Name, WhiteoutPrefix):
+				whiteouts = append(whiteouts, strings.TrimPrefix(layerInfo.Name, WhiteoutPrefix))
+			case !listed[layerInfo.Name]:
+				listed[layerInfo.Name] = true
+				info := *layerInfo
+				info.File = f.JoinCleanFile(dirPath, info.Name)
+				infos = append(infos, &info)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Whiteouts only hide files of lower layers
+		for _, name := range whiteouts {
+			listed[name] = true
+		}
+		if opaque {
+			break
+		}
+	}
+
+	slices.SortFunc(infos, func(a, b *fs.FileInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		match, err := f.MatchAnyPattern(info.Name, patterns)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		err = callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakeDir creates a directory in the upper layer.
+func (f *UnionFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if f.Exists(dirPath) {
+		return fs.NewErrAlreadyExists(f.File(dirPath))
+	}
+	upperDir, hadWhiteout, err := f.upperForWrite(context.Background(), dirPath, false)
+	if err != nil {
+		return err
+	}
+	err = upperDir.MakeDir(perm...)
+	if err != nil || !hadWhiteout {
+		return err
+	}
+	// Don't let the contents of the removed directory reappear
+	return upperDir.Join(OpaqueMarker).Touch()
+}
+
+func (f *UnionFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	file, err := f.lookupFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.ReadAllContext(ctx)
+}
+
+func (f *UnionFileSystem) OpenReader(filePath string) (iofs.File, error) {
+	file, err := f.lookupFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return file.OpenReader()
+}
+
+func (f *UnionFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	upperFile, _, err := f.upperForWrite(ctx, filePath, false)
+	if err != nil {
+		return err
+	}
+	return upperFile.WriteAllContext(ctx, data, perm...)
+}
+
+func (f *UnionFileSystem) Append(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	upperFile, _, err := f.upperForWrite(ctx, filePath, true)
+	if err != nil {
+		return err
+	}
+	return upperFile.Append(ctx, data, perm...)
+}
+
+func (f *UnionFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	upperFile, _, err := f.upperForWrite(context.Background(), filePath, true)
+	if err != nil {
+		return err
+	}
+	return upperFile.Touch(perm...)
+}
+
+func (f *UnionFileSystem) Truncate(filePath string, size int64) error {
+	if _, err := f.lookupFile(filePath); err != nil {
+		return err
+	}
+	upperFile, _, err := f.upperForWrite(context.Background(), filePath, true)
+	if err != nil {
+		return err
+	}
+	return upperFile.Truncate(size)
+}
+
+func (f *UnionFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	upperFile, _, err := f.upperForWrite(context.Background(), filePath, false)
+	if err != nil {
+		return nil, err
+	}
+	return upperFile.OpenWriter(perm...)
+}
+
+func (f *UnionFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	upperFile, _, err := f.upperForWrite(context.Background(), filePath, true)
+	if err != nil {
+		return nil, err
+	}
+	return upperFile.OpenReadWriter(perm...)
+}
+
+func (f *UnionFileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	src, err := f.lookupFile(srcFile)
+	if err != nil {
+		return err
+	}
+	dest, _, err := f.upperForWrite(ctx, destFile, false)
+	if err != nil {
+		return err
+	}
+	return fs.CopyFileBuf(ctx, src, dest, buf)
+}
+
+func (f *UnionFileSystem) Rename(filePath string, newName string) (string, error) {
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	newPath := path.Join(path.Dir(f.AbsPath(filePath)), newName)
+	err := f.Move(filePath, newPath)
+	if err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+// Move copies files to the upper layer and removes the source.
+// If destPath is an existing directory, then the file is moved into it.
+// Directories can only be moved if they only exist in the upper layer.
+func (f *UnionFileSystem) Move(filePath string, destPath string) error {
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	parts := f.SplitPath(filePath)
+	src, ok := f.lookup(parts)
+	if !ok {
+		return fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if dest, ok := f.lookup(f.SplitPath(destPath)); ok && dest.IsDir() {
+		// Move into existing directory
+		destPath = path.Join(f.AbsPath(destPath), parts[len(parts)-1])
+	}
+	if !src.IsDir() {
+		err := f.CopyFile(context.Background(), filePath, destPath, new([]byte))
+		if err != nil {
+			return err
+		}
+		return f.Remove(filePath)
+	}
+
+	if f.existsBelow(parts) {
+		return fs.NewErrUnsupported(f, "Move of a directory from a lower layer")
+	}
+	if f.Exists(destPath) {
+		return fs.NewErrAlreadyExists(f.File(destPath))
+	}
+	dest, hadWhiteout, err := f.upperForWrite(context.Background(), destPath, false)
+	if err != nil {
+		return err
+	}
+	if src.Dir() == dest.Dir() {
+		_, err = src.Rename(dest.Name())
+	} else {
+		// Move implementations differ for directories
+		err = fs.CopyRecursive(context.Background(), src, dest)
+		if err == nil {
+			err = src.RemoveRecursive()
+		}
+	}
+	if err != nil || !hadWhiteout {
+		return err
+	}
+	// Don't let the contents of the removed directory reappear
+	return dest.Join(OpaqueMarker).Touch()
+}
+
+// Remove removes a file or empty directory from the upper layer
+// and creates a whiteout if it exists in a lower layer.
+func (f *UnionFileSystem) Remove(filePath string) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if err := f.checkOpen(); err != nil {
+		return err
+	}
+	parts := f.SplitPath(filePath)
+	if len(parts) == 0 {
+		return errors.New("can't remove root directory")
+	}
+	file, ok := f.lookup(parts)
+	if !ok {
+		return fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if file.IsDir() {
+		errNotEmpty := fmt.Errorf("directory %s is not empty", f.File(filePath))
+		err := f.ListDirInfo(context.Background(), filePath, func(*fs.FileInfo) error { return errNotEmpty }, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	upperFile := f.layers[0].Join(parts...)
+	if upperFile.Exists() {
+		var err error
+		if upperFile.IsDir() {
+			// Can only contain whiteouts and opaque markers
+			err = upperFile.RemoveRecursive()
+		} else {
+			err = upperFile.Remove()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !f.existsBelow(parts) {
+		return nil
+	}
+	upperParent := f.layers[0].Join(parts[:len(parts)-1]...)
+	err := upperParent.MakeAllDirs()
+	if err != nil {
+		return err
+	}
+	return upperParent.Join(WhiteoutPrefix + parts[len(parts)-1]).Touch()
+}
+
+// Close unregisters the file system,
+// the file systems of the layers are not closed.
+func (f *UnionFileSystem) Close() error {
+	if f.closed.Swap(true) {
+		return nil // already closed
+	}
+	fs.Unregister(f)
+	return nil
+}
@@ -0,0 +1,118 @@
+package unionfs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestUnionFileSystem(t *testing.T) {
+	// Empty upper layer on top of a middle and a bottom layer
+	tempDir := fs.File(t.TempDir())
+	upper, middle, bottom := tempDir.Join("upper"), tempDir.Join("middle"), tempDir.Join("bottom")
+	for file, content := range map[fs.File]string{
+		middle.Join("config", "app.conf"):      "middle app",
+		middle.Join("config", "override.conf"): "middle override",
+		middle.Join("data", "a.txt"):           "a",
+		bottom.Join("config", "app.conf"):      "bottom app",
+		bottom.Join("config", "base.conf"):     "bottom base",
+		bottom.Join("data", "b.txt"):           "b",
+		bottom.Join("removed", "c.txt"):        "c",
+	} {
+		require.NoError(t, file.Dir().MakeAllDirs())
+		require.NoError(t, file.WriteAllString(content))
+	}
+	require.NoError(t, upper.MakeDir())
+	unionFS, err := NewAndRegister(upper, middle, bottom)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = unionFS.Close() })
+	ctx := context.Background()
+	root := unionFS.RootDir()
+	require.True(t, fs.IsRegistered(unionFS))
+	require.True(t, root.IsDir())
+
+	// Merged view with upper layers on top
+	files, err := root.ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/config/app.conf", "/config/base.conf", "/config/override.conf", "/data/a.txt", "/data/b.txt", "/removed/c.txt"}, filePaths(files))
+	str, err := root.Join("config", "app.conf").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "middle app", str)
+	str, err = root.Join("config", "base.conf").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "bottom base", str)
+	_, err = root.Join("missing.txt").ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Writes go to the upper layer
+	require.NoError(t, root.Join("config", "app.conf").WriteAllString("upper app"))
+	str, err = root.Join("config", "app.conf").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "upper app", str)
+	str, err = upper.Join("config", "app.conf").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "upper app", str)
+	require.NoError(t, root.Join("config", "base.conf").AppendString(ctx, " appended"))
+	str, err = upper.Join("config", "base.conf").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "bottom base appended", str, "copied up")
+	require.ErrorIs(t, root.Join("missing", "file.txt").WriteAllString("x"), os.ErrNotExist)
+
+	// Removing lower files creates whiteouts
+	require.NoError(t, root.Join("data", "b.txt").Remove())
+	require.False(t, root.Join("data", "b.txt").Exists())
+	require.True(t, upper.Join("data", WhiteoutPrefix+"b.txt").Exists())
+	files, err = root.Join("data").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/data/a.txt"}, filePaths(files), "whiteouts are not listed")
+	require.NoError(t, root.Join("data", "b.txt").WriteAllString("new b"))
+	require.False(t, upper.Join("data", WhiteoutPrefix+"b.txt").Exists(), "whiteout removed")
+	str, err = root.Join("data", "b.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "new b", str)
+
+	// Removed and re-created directories are opaque
+	require.Error(t, root.Join("removed").Remove(), "directory not empty")
+	require.NoError(t, root.Join("removed", "c.txt").Remove())
+	require.NoError(t, root.Join("removed").Remove())
+	require.False(t, root.Join("removed").Exists())
+	require.False(t, root.Join("removed", "c.txt").Exists())
+	require.NoError(t, root.Join("removed").MakeDir())
+	require.True(t, upper.Join("removed", OpaqueMarker).Exists())
+	files, err = root.Join("removed").ListDirMax(-1)
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.ErrorAs(t, unionFS.MakeDir("/removed", nil), new(fs.ErrAlreadyExists))
+
+	// Files are moved by copying them to the upper layer
+	require.NoError(t, root.Join("data", "a.txt").MoveTo(root.Join("removed")))
+	require.False(t, root.Join("data", "a.txt").Exists())
+	str, err = root.Join("removed", "a.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "a", str)
+	renamed, err := root.Join("removed", "a.txt").Rename("renamed.txt")
+	require.NoError(t, err)
+	require.Equal(t, root.Join("removed", "renamed.txt"), renamed)
+	require.Error(t, root.Join("config").MoveTo(root.Join("removed")), "directory in lower layer")
+
+	require.NoError(t, root.Join("upper-only").MakeDir())
+	require.NoError(t, root.Join("upper-only", "file.txt").Touch())
+	moved, err := root.Join("upper-only").Rename("moved")
+	require.NoError(t, err)
+	require.True(t, moved.Join("file.txt").Exists())
+	require.NoError(t, moved.MoveTo(root.Join("removed")))
+	require.True(t, root.Join("removed", "moved", "file.txt").Exists())
+	require.False(t, moved.Exists())
+}
+
+func filePaths(files []fs.File) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path()
+	}
+	return paths
+}
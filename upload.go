@@ -0,0 +1,191 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxSanitizedFileNameLength is the maximum length
+// in bytes of names returned by SanitizeFileName.
+const MaxSanitizedFileNameLength = 255
+
+// UploadOptions are the options for SaveUpload
+type UploadOptions struct {
+	// MaxSize limits the size of an upload in bytes if greater than zero.
+	// Larger uploads are rejected with an error wrapping ErrTooLarge.
+	MaxSize int64
+
+	// AllowedExtensions are the allowed file extensions
+	// including the dot like ".jpg", compared case insensitive.
+	// All extensions are allowed if empty.
+	AllowedExtensions []string
+
+	// Permissions for the saved file,
+	// the file system default is used if zero.
+	Permissions Permissions
+}
+
+// SanitizeFileName returns name with all characters replaced
+// by underscores that are not safe to use in file names
+// on common operating systems, like path separators,
+// control characters and `<>:"|?*`.
+// Only the last element of a path is used as name,
+// leading dots, trailing dots and surrounding spaces are removed,
+// and Windows device names like "CON" or "NUL.txt" get an underscore prefix.
+// Names longer than MaxSanitizedFileNameLength bytes are shortened
+// keeping the extension. An empty result is returned as "_".
+func SanitizeFileName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(
+		func(r rune) rune {
+			if r == utf8.RuneError || unicode.IsControl(r) || strings.ContainsRune(`<>:"|?*`, r) {
+				return '_'
+			}
+			return r
+		},
+		name,
+	)
+	name = strings.TrimSpace(name)
+	name = strings.TrimLeft(name, ".")
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	if base, _, _ := strings.Cut(name, "."); isWindowsDeviceName(base) {
+		name = "_" + name
+	}
+	if len(name) > MaxSanitizedFileNameLength {
+		ext := extFromName(name)
+		if len(ext) > MaxSanitizedFileNameLength/2 {
+			ext = ""
+		}
+		base := name[:MaxSanitizedFileNameLength-len(ext)]
+		// Don't cut in the middle of a multi-byte character
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+	return name
+}
+
+func isWindowsDeviceName(name string) bool {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9":
+		return true
+	}
+	return false
+}
+
+// extFromName returns the extension of name including the dot
+func extFromName(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if i <= 0 {
+		return ""
+	}
+	return name[i:]
+}
+
+// SaveUpload saves the data read from r as new file in dir
+// and returns it. Useful for saving files uploaded by users.
+//
+// The name of the file is preferredName sanitized with SanitizeFileName.
+// If a file with that name already exists, then a number
+// is appended to the name like in "photo-1.jpg".
+// Existing files are never overwritten.
+//
+// The data is first written to a hidden temporary file in dir
+// that is then hard linked to the final name if the file system
+// implements HardLinkFileSystem, which fails atomically
+// for existing files, or renamed otherwise.
+//
+// Returns an error wrapping ErrExtensionNotAllowed if the
+// extension of the name is not in opts.AllowedExtensions
+// and an error wrapping ErrTooLarge if more than opts.MaxSize
+// bytes are read from r. No file is left behind on errors.
+func SaveUpload(ctx context.Context, dir File, preferredName string, r io.Reader, opts UploadOptions) (File, error) {
+	if err := dir.CheckIsDir(); err != nil {
+		return "", err
+	}
+	name := SanitizeFileName(preferredName)
+	ext := extFromName(name)
+	if len(opts.AllowedExtensions) > 0 && !slices.ContainsFunc(opts.AllowedExtensions, func(allowed string) bool { return strings.EqualFold(allowed, ext) }) {
+		return "", fmt.Errorf("%w: %q", ErrExtensionNotAllowed, name)
+	}
+
+	var perm []Permissions
+	if opts.Permissions != 0 {
+		perm = []Permissions{opts.Permissions}
+	}
+	tempFile := dir.Join(atomicWriteTempName(name))
+	err := writeUpload(ctx, tempFile, r, opts.MaxSize, perm)
+	if err != nil {
+		return "", errors.Join(err, RemoveErrDoesNotExist(tempFile.Remove()))
+	}
+
+	file, err := placeUpload(tempFile, dir, name, ext)
+	if err != nil {
+		return "", errors.Join(err, RemoveErrDoesNotExist(tempFile.Remove()))
+	}
+	return file, nil
+}
+
+func writeUpload(ctx context.Context, file File, r io.Reader, maxSize int64, perm []Permissions) error {
+	w, err := file.OpenWriter(perm...)
+	if err != nil {
+		return err
+	}
+	w = GuardWriter(w, maxSize, 0)
+	err = copyBuffer(ctx, w, r, make([]byte, copyBufferSize))
+	return errors.Join(err, w.Close())
+}
+
+// maxUploadNameAttempts limits the number of
+// names tried by SaveUpload to avoid collisions
+const maxUploadNameAttempts = 10000
+
+// placeUpload moves tempFile to the first
+// non existing name in dir derived from name
+func placeUpload(tempFile, dir File, name, ext string) (File, error) {
+	fileSystem := tempFile.FileSystem()
+	_, canLink := fileSystem.(HardLinkFileSystem)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; i < maxUploadNameAttempts; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		file := dir.Join(candidate)
+		if canLink {
+			err := tempFile.LinkTo(file)
+			if errors.As(err, new(ErrAlreadyExists)) {
+				continue
+			}
+			if err == nil {
+				return file, tempFile.Remove()
+			}
+			// The file system might not support hard links
+			// at dir, for example on a FAT mount, so rename
+			canLink = false
+		}
+		if file.Exists() {
+			continue
+		}
+		_, err := tempFile.Rename(candidate)
+		if err != nil {
+			return "", err
+		}
+		return file, nil
+	}
+	return "", fmt.Errorf("no unused name for %q in %s after %d attempts", name, dir, maxUploadNameAttempts)
+}
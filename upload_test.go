@@ -0,0 +1,73 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "photo.jpg", want: "photo.jpg"},
+		{name: "", want: "_"},
+		{name: "..", want: "_"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: `C:\Users\me\report.pdf`, want: "report.pdf"},
+		{name: " .hidden ", want: "hidden"},
+		{name: "a<b>c:d\"e|f?g*h.txt", want: "a_b_c_d_e_f_g_h.txt"},
+		{name: "new\nline.txt", want: "new_line.txt"},
+		{name: "trailing dot. ", want: "trailing dot"},
+		{name: "CON", want: "_CON"},
+		{name: "nul.tar.gz", want: "_nul.tar.gz"},
+		{name: "Grüße.txt", want: "Grüße.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, SanitizeFileName(tt.name))
+		})
+	}
+
+	long := SanitizeFileName(strings.Repeat("ä", 200) + ".txt")
+	require.LessOrEqual(t, len(long), MaxSanitizedFileNameLength)
+	require.True(t, strings.HasSuffix(long, "ä.txt"), "extension kept without cutting a character")
+}
+
+func TestSaveUpload(t *testing.T) {
+	ctx := context.Background()
+	dir := File(t.TempDir())
+
+	file, err := SaveUpload(ctx, dir, "../photo.jpg", strings.NewReader("first"), UploadOptions{})
+	require.NoError(t, err)
+	require.Equal(t, dir.Join("photo.jpg"), file)
+	requireFileContent(t, file, "first")
+
+	file, err = SaveUpload(ctx, dir, "photo.jpg", strings.NewReader("second"), UploadOptions{})
+	require.NoError(t, err)
+	require.Equal(t, dir.Join("photo-1.jpg"), file)
+	requireFileContent(t, file, "second")
+	requireFileContent(t, dir.Join("photo.jpg"), "first")
+
+	_, err = SaveUpload(ctx, dir, "script.sh", strings.NewReader("x"), UploadOptions{AllowedExtensions: []string{".jpg", ".png"}})
+	require.ErrorIs(t, err, ErrExtensionNotAllowed)
+
+	file, err = SaveUpload(ctx, dir, "upper.PNG", strings.NewReader("x"), UploadOptions{AllowedExtensions: []string{".jpg", ".png"}})
+	require.NoError(t, err)
+	require.Equal(t, "upper.PNG", file.Name())
+
+	_, err = SaveUpload(ctx, dir, "large.jpg", strings.NewReader("too large"), UploadOptions{MaxSize: 4})
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	// No temporary or partial files are left behind
+	files, err := dir.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	_, err = SaveUpload(ctx, dir.Join("missing"), "photo.jpg", strings.NewReader("x"), UploadOptions{})
+	require.True(t, errors.As(err, new(ErrDoesNotExist)))
+}
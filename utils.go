@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"time"
 )
 
 // FileURLs returns the URLs of the passed files
@@ -113,23 +114,57 @@ func ReadHeaderString(fr FileReader, maxNumBytes int) (string, error) {
 // another error is returned, or the context got canceled.
 // It is identical to io.ReadAll except that
 // it can be canceled via a context.
+//
+// The context is checked between reads of at most 4MB.
+// If r has a SetReadDeadline method like os.File or net.Conn,
+// then the deadline of the context is used as read deadline
+// and canceling the context interrupts blocked reads.
+// The read deadline is reset before returning.
 func ReadAllContext(ctx context.Context, r io.Reader) ([]byte, error) {
-	b := make([]byte, 0, 512)
-	for ctx.Err() == nil {
+	const chunkSize = 4 * 1024 * 1024 // 4MB
+	return readAllContext(ctx, r, 512, chunkSize)
+}
+
+// readDeadliner is implemented by os.File and net.Conn
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+func readAllContext(ctx context.Context, r io.Reader, sizeHint, chunkSize int) ([]byte, error) {
+	if d, ok := r.(readDeadliner); ok && ctx.Done() != nil {
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = d.SetReadDeadline(deadline)
+		}
+		stop := context.AfterFunc(ctx, func() {
+			// Interrupt a blocked read
+			_ = d.SetReadDeadline(time.Now())
+		})
+		defer func() {
+			stop()
+			_ = d.SetReadDeadline(time.Time{})
+		}()
+	}
+	b := make([]byte, 0, sizeHint)
+	for {
+		if err := ctx.Err(); err != nil {
+			return b, err
+		}
 		if len(b) == cap(b) {
 			// Add more capacity (let append pick how much).
 			b = append(b, 0)[:len(b)]
 		}
-		n, err := r.Read(b[len(b):cap(b)])
+		n, err := r.Read(b[len(b):min(cap(b), len(b)+chunkSize)])
 		b = b[:len(b)+n]
 		if err != nil {
 			if err == io.EOF {
 				return b, nil
 			}
+			if errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() != nil {
+				return b, ctx.Err()
+			}
 			return b, err
 		}
 	}
-	return b, ctx.Err()
 }
 
 // WriteAllContext writes all data wo the to w
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"os"
 	"testing"
 	"time"
 
@@ -71,6 +72,39 @@ func Test_writeAllContext(t *testing.T) {
 	}
 }
 
+// doneFailContext is a failContext that can be canceled
+type doneFailContext struct {
+	failContext
+	done chan struct{}
+}
+
+func (c *doneFailContext) Done() <-chan struct{} { return c.done }
+
+func Test_readAllContext(t *testing.T) {
+	data, err := readAllContext(context.Background(), bytes.NewReader([]byte("12345")), 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, "12345", string(data))
+
+	ctxErr := errors.New("contextError")
+	data, err = readAllContext(&failContext{errAfter: 2, err: ctxErr}, bytes.NewReader([]byte("12345")), 0, 2)
+	require.ErrorIs(t, err, ctxErr)
+	require.Equal(t, "1234", string(data), "context checked between chunks")
+
+	// Canceling interrupts a blocked read
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = r.Close(); _ = w.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = ReadAllContext(ctx, r)
+	require.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ReadAllContext(ctx, r)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 func TestExecutableFile(t *testing.T) {
 	require.True(t, ExecutableFile().Exists(), "executable file for current process exists")
 }
@@ -0,0 +1,121 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	iofs "io/fs"
+	"iter"
+	"slices"
+	"strings"
+)
+
+var (
+	// SkipDir can be returned by the callback of File.Walk
+	// to skip the directory passed to the callback,
+	// or the remaining files of the directory
+	// containing the file passed to the callback.
+	// It's the same error as io/fs.SkipDir.
+	SkipDir = iofs.SkipDir
+
+	// SkipAll can be returned by the callback of File.Walk
+	// to stop walking without returning an error.
+	// It's the same error as io/fs.SkipAll.
+	SkipAll = iofs.SkipAll
+)
+
+// Walk walks the file tree rooted at the file
+// calling callback for every file and directory
+// including the root like filepath.WalkDir.
+// See File.WalkContext.
+func (file File) Walk(callback func(file File, info *FileInfo) error) error {
+	return file.WalkContext(context.Background(), callback)
+}
+
+// WalkContext walks the file tree rooted at the file
+// calling callback for every file and directory
+// including the root like filepath.WalkDir.
+//
+// The files of every directory are walked in lexical order
+// by name after the directory itself.
+// Returning SkipDir from callback for a directory
+// skips its content, for a file the remaining files
+// of its directory are skipped.
+// Returning SkipAll stops walking and nil is returned.
+// Any other error stops walking and is returned.
+//
+// Symbolic links to directories are not followed.
+func (file File) WalkContext(ctx context.Context, callback func(file File, info *FileInfo) error) error {
+	info, err := file.InfoContext(ctx)
+	if err != nil {
+		return err
+	}
+	err = walk(ctx, info, callback)
+	if errors.Is(err, SkipDir) || errors.Is(err, SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func walk(ctx context.Context, info *FileInfo, callback func(File, *FileInfo) error) error {
+	err := callback(info.File, info)
+	if err != nil || !info.IsDir {
+		// SkipDir returned for a directory only skips the directory
+		if info.IsDir && errors.Is(err, SkipDir) {
+			return nil
+		}
+		return err
+	}
+	if info.File.IsSymbolicLink() {
+		return nil
+	}
+	var infos []*FileInfo
+	err = info.File.ListDirInfoContext(ctx, func(info *FileInfo) error {
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(infos, func(a, b *FileInfo) int { return strings.Compare(a.Name, b.Name) })
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = walk(ctx, info, callback)
+		if errors.Is(err, SkipDir) {
+			// SkipDir returned for a file
+			// skips the rest of the directory
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkIter returns an iterator over the FileInfo
+// of all files and directories of the file tree
+// rooted at the file in the order of File.Walk.
+// See File.WalkIterContext.
+func (file File) WalkIter() iter.Seq2[*FileInfo, error] {
+	return file.WalkIterContext(context.Background())
+}
+
+// WalkIterContext returns an iterator over the FileInfo
+// of all files and directories of the file tree
+// rooted at the file in the order of File.WalkContext.
+// An error is yielded as last value if walking fails.
+func (file File) WalkIterContext(ctx context.Context) iter.Seq2[*FileInfo, error] {
+	return func(yield func(*FileInfo, error) bool) {
+		err := file.WalkContext(ctx, func(file File, info *FileInfo) error {
+			if !yield(info, nil) {
+				return SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Walk(t *testing.T) {
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("b", "skip").MakeAllDirs())
+	require.NoError(t, dir.Join("a.txt").Touch())
+	require.NoError(t, dir.Join("b", "c.txt").Touch())
+	require.NoError(t, dir.Join("b", "skip", "d.txt").Touch())
+	require.NoError(t, dir.Join("e").MakeDir())
+	require.NoError(t, dir.Join("e", "f.txt").Touch())
+	require.NoError(t, dir.Join("e", "g.txt").Touch())
+
+	walked := func(callback func(File, *FileInfo) error) (names []string) {
+		t.Helper()
+		err := dir.Walk(func(file File, info *FileInfo) error {
+			require.Equal(t, file, info.File)
+			rel := strings.TrimPrefix(strings.TrimPrefix(file.PathWithSlashes(), dir.PathWithSlashes()), "/")
+			names = append(names, rel)
+			return callback(file, info)
+		})
+		require.NoError(t, err)
+		return names
+	}
+
+	names := walked(func(File, *FileInfo) error { return nil })
+	require.Equal(t, []string{"", "a.txt", "b", "b/c.txt", "b/skip", "b/skip/d.txt", "e", "e/f.txt", "e/g.txt"}, names)
+
+	names = walked(func(file File, info *FileInfo) error {
+		if info.IsDir && info.Name == "skip" {
+			return SkipDir
+		}
+		if info.Name == "f.txt" {
+			return SkipDir // skips g.txt
+		}
+		return nil
+	})
+	require.Equal(t, []string{"", "a.txt", "b", "b/c.txt", "b/skip", "e", "e/f.txt"}, names)
+
+	names = walked(func(file File, info *FileInfo) error {
+		if info.Name == "c.txt" {
+			return SkipAll
+		}
+		return nil
+	})
+	require.Equal(t, []string{"", "a.txt", "b", "b/c.txt"}, names)
+
+	errStop := errors.New("stop")
+	err := dir.Walk(func(File, *FileInfo) error { return errStop })
+	require.ErrorIs(t, err, errStop)
+
+	require.Error(t, dir.Join("missing").Walk(func(File, *FileInfo) error { return nil }))
+}
+
+func TestFile_WalkIter(t *testing.T) {
+	dir := File(t.TempDir())
+	require.NoError(t, dir.Join("a", "b").MakeAllDirs())
+	require.NoError(t, dir.Join("a", "b", "c.txt").Touch())
+
+	var names []string
+	for info, err := range dir.WalkIterContext(context.Background()) {
+		require.NoError(t, err)
+		names = append(names, info.Name)
+		if info.Name == "b" {
+			break
+		}
+	}
+	require.Equal(t, []string{dir.Name(), "a", "b"}, names)
+
+	for info, err := range dir.Join("missing").WalkIter() {
+		require.Nil(t, info)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,41 @@
+package webdavfs
+
+import (
+	iofs "io/fs"
+	"time"
+)
+
+var _ iofs.FileInfo = new(fileInfo)
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// newFileInfo uses name instead of info.Name()
+// because gowebdav returns the display name
+// of the resource for Stat which can differ from the path.
+func newFileInfo(name string, info iofs.FileInfo) *fileInfo {
+	return &fileInfo{
+		name:    name,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		isDir:   info.IsDir(),
+	}
+}
+
+func (i *fileInfo) Name() string       { return i.name }    // base name of the file
+func (i *fileInfo) Size() int64        { return i.size }    // length in bytes for regular files; system-dependent for others
+func (i *fileInfo) ModTime() time.Time { return i.modTime } // modification time
+func (i *fileInfo) IsDir() bool        { return i.isDir }   // abbreviation for Mode().IsDir()
+func (i *fileInfo) Sys() any           { return nil }       // underlying data source (can return nil)
+
+// Mode returns the file mode bits
+func (i *fileInfo) Mode() iofs.FileMode {
+	if i.isDir {
+		return DefaultDirPermissions.FileMode(true)
+	}
+	return DefaultPermissions.FileMode(false)
+}
@@ -0,0 +1,520 @@
+// Package webdavfs implements a WebDAV client file system
+// that can be used for Nextcloud, ownCloud
+// or any other WebDAV server.
+package webdavfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/studio-b12/gowebdav"
+
+	fs "github.com/ungerik/go-fs"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+const (
+	// Prefix of WebDAV servers accessed via HTTP
+	Prefix = "webdav://"
+
+	// PrefixTLS of WebDAV servers accessed via HTTPS
+	PrefixTLS = "webdavs://"
+
+	// Separator used in WebDAV paths
+	Separator = "/"
+)
+
+var (
+	// DefaultPermissions used for WebDAV files
+	DefaultPermissions = fs.UserAndGroupReadWrite
+	// DefaultDirPermissions used for WebDAV collections
+	DefaultDirPermissions = fs.UserAndGroupReadWrite + fs.AllReadWrite
+
+	// Make sure fileSystem implements fs.FileSystem
+	_ fs.FileSystem = new(fileSystem)
+)
+
+type fileSystem struct {
+	client    *gowebdav.Client
+	serverURL string
+	prefix    string
+	readOnly  bool
+}
+
+// NewAndRegister returns a fs.FileSystem for the WebDAV server
+// at serverURL using client and registers it.
+//
+// The prefix of the file system is the serverURL
+// with the scheme `http` replaced by `webdav` and `https` by `webdavs`,
+// so the Nextcloud share https://cloud.example.com/remote.php/dav/files/user
+// is registered with the prefix webdavs://cloud.example.com/remote.php/dav/files/user
+// and the file /Documents/file.txt of the share
+// is addressed as webdavs://cloud.example.com/remote.php/dav/files/user/Documents/file.txt
+//
+// The client must have been created for the same serverURL.
+func NewAndRegister(client *gowebdav.Client, serverURL string, readOnly bool) (fs.FileSystem, error) {
+	serverURL, prefix, err := ServerURLAndPrefix(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	wfs := &fileSystem{
+		client:    client,
+		serverURL: serverURL,
+		prefix:    prefix,
+		readOnly:  readOnly,
+	}
+	_, err = fs.TryRegister(wfs)
+	if err != nil {
+		return nil, err
+	}
+	return wfs, nil
+}
+
+// DialAndRegister creates a client for the WebDAV server at serverURL
+// authenticating with username and password,
+// checks the connection and passes the client to NewAndRegister.
+// The scheme of serverURL can be `http`, `https`, `webdav` or `webdavs`.
+func DialAndRegister(serverURL, username, password string, readOnly bool) (fs.FileSystem, error) {
+	serverURL, _, err := ServerURLAndPrefix(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	client := gowebdav.NewClient(serverURL, username, password)
+	err = client.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to WebDAV server %s: %w", serverURL, err)
+	}
+	return NewAndRegister(client, serverURL, readOnly)
+}
+
+// ServerURLAndPrefix returns the HTTP(S) URL of a WebDAV server
+// and the file system prefix for it.
+// The scheme of the passed serverURL can be `http`, `https`,
+// `webdav` or `webdavs`. User info, query and fragment are not allowed.
+func ServerURLAndPrefix(serverURL string) (httpURL, prefix string, err error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+	switch u.Scheme {
+	case "http", "webdav":
+		u.Scheme = "http"
+		prefix = Prefix
+	case "https", "webdavs":
+		u.Scheme = "https"
+		prefix = PrefixTLS
+	default:
+		return "", "", fmt.Errorf("not a WebDAV server URL scheme: %s", serverURL)
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("missing host in WebDAV server URL: %s", serverURL)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", fmt.Errorf("WebDAV server URL must not have user info, query or fragment: %s", serverURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, Separator)
+	u.RawPath = ""
+	return u.String(), prefix + u.Host + u.Path, nil
+}
+
+func (w *fileSystem) wrapErrNotExist(filePath string, err error) error {
+	if gowebdav.IsErrNotFound(err) {
+		return fs.NewErrDoesNotExist(w.File(filePath))
+	}
+	return err
+}
+
+func (w *fileSystem) ReadableWritable() (readable, writable bool) {
+	return true, !w.readOnly
+}
+
+func (w *fileSystem) RootDir() fs.File {
+	return fs.File(w.prefix + Separator)
+}
+
+func (w *fileSystem) ID() (string, error) {
+	return w.serverURL, nil
+}
+
+func (w *fileSystem) Prefix() string {
+	return w.prefix
+}
+
+func (w *fileSystem) Name() string {
+	return "WebDAV file system for server: " + w.serverURL
+}
+
+func (w *fileSystem) String() string {
+	return w.Name() + " with prefix " + w.prefix
+}
+
+func (w *fileSystem) File(filePath string) fs.File {
+	return w.JoinCleanFile(filePath)
+}
+
+func (w *fileSystem) URL(cleanPath string) string {
+	return w.prefix + cleanPath
+}
+
+func (w *fileSystem) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, w.prefix)
+}
+
+func (w *fileSystem) JoinCleanFile(uriParts ...string) fs.File {
+	return fs.File(w.prefix + w.JoinCleanPath(uriParts...))
+}
+
+func (w *fileSystem) JoinCleanPath(uriParts ...string) string {
+	return fsimpl.JoinCleanPath(uriParts, w.prefix, Separator)
+}
+
+func (w *fileSystem) SplitPath(filePath string) []string {
+	return fsimpl.SplitPath(filePath, w.prefix, Separator)
+}
+
+func (w *fileSystem) Separator() string {
+	return Separator
+}
+
+func (w *fileSystem) IsAbsPath(filePath string) bool {
+	return path.IsAbs(filePath)
+}
+
+func (w *fileSystem) AbsPath(filePath string) string {
+	if path.IsAbs(filePath) {
+		return filePath
+	}
+	return Separator + filePath
+}
+
+func (w *fileSystem) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return fsimpl.MatchAnyPattern(name, patterns)
+}
+
+func (*fileSystem) SplitDirAndName(filePath string) (dir, name string) {
+	return fsimpl.SplitDirAndName(filePath, 0, Separator)
+}
+
+// Stat uses a PROPFIND request with depth 0
+func (w *fileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	info, err := w.client.Stat(filePath)
+	if err != nil {
+		return nil, w.wrapErrNotExist(filePath, err)
+	}
+	return newFileInfo(path.Base(filePath), info), nil
+}
+
+func (w *fileSystem) Exists(filePath string) bool {
+	_, err := w.Stat(filePath)
+	return err == nil
+}
+
+// IsHidden returns true if the given file path is not empty and starts with a dot.
+// WebDAV has no concept of hidden files, but dot prefixes are
+// the general convention to determine which files are hidden.
+func (w *fileSystem) IsHidden(filePath string) bool {
+	name := path.Base(filePath)
+	return len(name) > 0 && name[0] == '.'
+}
+
+func (w *fileSystem) IsSymbolicLink(filePath string) bool {
+	return false
+}
+
+// ListDirInfo uses a PROPFIND request with depth 1
+func (w *fileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	infos, err := w.client.ReadDir(dirPath)
+	if err != nil {
+		return w.wrapErrNotExist(dirPath, err)
+	}
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		match, err := w.MatchAnyPattern(info.Name(), patterns)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		filePath := path.Join(dirPath, info.Name())
+		err = callback(fs.NewFileInfo(w.File(filePath), newFileInfo(info.Name(), info), w.IsHidden(filePath)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *fileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if w.Exists(filePath) {
+		return nil // TODO is there a portable way to change the modified time?
+	}
+	return w.WriteAll(context.Background(), filePath, nil, perm)
+}
+
+// MakeDir uses a MKCOL request
+func (w *fileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if w.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if w.Exists(dirPath) {
+		return fs.NewErrAlreadyExists(w.File(dirPath))
+	}
+	return w.client.Mkdir(dirPath, 0)
+}
+
+func (w *fileSystem) MakeAllDirs(dirPath string, perm []fs.Permissions) error {
+	if dirPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if w.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	return w.client.MkdirAll(dirPath, 0)
+}
+
+func (w *fileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	reader, err := w.client.ReadStream(filePath)
+	if err != nil {
+		return nil, w.wrapErrNotExist(filePath, err)
+	}
+	defer reader.Close()
+
+	return fs.ReadAllContext(ctx, reader)
+}
+
+// ReadRange reads a byte range of a file using a HTTP Range header.
+// A negative offset reads the last -offset bytes of the file.
+func (w *fileSystem) ReadRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if offset >= 0 && length <= 0 {
+		return []byte{}, nil
+	}
+	if offset < 0 {
+		// ReadStreamRange does not support suffix ranges
+		info, err := w.client.Stat(filePath)
+		if err != nil {
+			return nil, w.wrapErrNotExist(filePath, err)
+		}
+		length = min(-offset, info.Size())
+		offset = info.Size() - length
+		if length == 0 {
+			return []byte{}, nil
+		}
+	}
+	reader, err := w.client.ReadStreamRange(filePath, offset, length)
+	if gowebdav.IsErrCode(err, http.StatusRequestedRangeNotSatisfiable) {
+		return []byte{}, nil // offset after the end of the file
+	}
+	if err != nil {
+		return nil, w.wrapErrNotExist(filePath, err)
+	}
+	defer reader.Close()
+
+	return fs.ReadAllContext(ctx, io.LimitReader(reader, length))
+}
+
+// WriteAll uses a PUT request.
+// Missing parent directories are created.
+func (w *fileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if w.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return w.client.Write(filePath, data, 0)
+}
+
+func (w *fileSystem) OpenReader(filePath string) (iofs.File, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	info, err := w.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fs.NewErrIsDirectory(w.File(filePath))
+	}
+	reader, err := w.client.ReadStream(filePath)
+	if err != nil {
+		return nil, w.wrapErrNotExist(filePath, err)
+	}
+	return &fileReader{ReadCloser: reader, info: info}, nil
+}
+
+// OpenWriter returns a writer that streams the written data
+// with a PUT request to the server.
+// Missing parent directories are created.
+func (w *fileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if filePath == "" {
+		return nil, fs.ErrEmptyPath
+	}
+	if w.readOnly {
+		return nil, fs.ErrReadOnlyFileSystem
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	writer := &fileWriter{
+		PipeWriter: pipeWriter,
+		done:       make(chan struct{}),
+	}
+	go func() {
+		defer close(writer.done)
+		writer.err = w.client.WriteStream(filePath, pipeReader, 0)
+		// Unblock writes if the upload failed
+		pipeReader.CloseWithError(writer.err)
+	}()
+	return writer, nil
+}
+
+func (w *fileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if w.readOnly {
+		return nil, fs.ErrReadOnlyFileSystem
+	}
+	current, err := w.ReadAll(context.Background(), filePath)
+	if err != nil {
+		return nil, err
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(current, func() error {
+		return w.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
+}
+
+// CopyFile uses a COPY request to copy the file on the server side.
+// An existing destFile is overwritten.
+func (w *fileSystem) CopyFile(ctx context.Context, srcFile string, destFile string, buf *[]byte) error {
+	if w.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if srcFile == "" || destFile == "" {
+		return fs.ErrEmptyPath
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	err := w.client.Copy(srcFile, destFile, true)
+	return w.wrapErrNotExist(srcFile, err)
+}
+
+// Rename uses a MOVE request to rename the file
+// within its directory on the server side.
+func (w *fileSystem) Rename(filePath string, newName string) (newPath string, err error) {
+	if w.readOnly {
+		return "", fs.ErrReadOnlyFileSystem
+	}
+	if filePath == "" || newName == "" {
+		return "", fs.ErrEmptyPath
+	}
+	if strings.Contains(newName, Separator) {
+		return "", fmt.Errorf("newName for Rename() contains a path separator: %q", newName)
+	}
+	newPath = path.Join(path.Dir(filePath), newName)
+	err = w.client.Rename(filePath, newPath, false)
+	if err != nil {
+		return "", w.wrapErrNotExist(filePath, err)
+	}
+	return newPath, nil
+}
+
+// Move uses a MOVE request to move the file on the server side.
+// If destPath is an existing directory, then the file is moved into it,
+// else an existing file at destPath is overwritten.
+func (w *fileSystem) Move(filePath string, destPath string) error {
+	if w.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if filePath == "" || destPath == "" {
+		return fs.ErrEmptyPath
+	}
+	if info, err := w.Stat(destPath); err == nil && info.IsDir() {
+		destPath = path.Join(destPath, path.Base(filePath))
+	}
+	err := w.client.Rename(filePath, destPath, true)
+	return w.wrapErrNotExist(filePath, err)
+}
+
+// Remove uses a DELETE request.
+// Note that WebDAV servers delete collections recursively.
+func (w *fileSystem) Remove(filePath string) error {
+	if w.readOnly {
+		return fs.ErrReadOnlyFileSystem
+	}
+	if filePath == "" {
+		return fs.ErrEmptyPath
+	}
+	if !w.Exists(filePath) {
+		// gowebdav ignores 404 errors for DELETE
+		return fs.NewErrDoesNotExist(w.File(filePath))
+	}
+	return w.client.Remove(filePath)
+}
+
+func (w *fileSystem) Watch(filePath string, onEvent func(fs.File, fs.Event)) (cancel func() error, err error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (w *fileSystem) Close() error {
+	if w.client == nil {
+		return nil // already closed
+	}
+	fs.Unregister(w)
+	w.client = nil
+	return nil
+}
+
+// fileReader implements iofs.File for a GET response body
+type fileReader struct {
+	io.ReadCloser
+	info iofs.FileInfo
+}
+
+func (r *fileReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+// fileWriter writes into a pipe that is
+// read by a PUT request goroutine
+type fileWriter struct {
+	*io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+// Close finishes the upload and returns its error
+func (w *fileWriter) Close() error {
+	err := w.PipeWriter.Close()
+	<-w.done
+	return errors.Join(err, w.err)
+}
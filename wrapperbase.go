@@ -0,0 +1,78 @@
+package fs
+
+import "strings"
+
+// WrapperBase implements the path methods of the FileSystem interface
+// for file systems that wrap an inner file system
+// and use the paths of the inner file system
+// under their own prefix.
+// Intended to be embedded by wrapping file systems,
+// so that only the methods adding behavior have to be implemented.
+type WrapperBase struct {
+	prefix string
+	inner  FileSystem
+}
+
+// NewWrapperBase returns a WrapperBase for a file system
+// registered with prefix that wraps inner.
+func NewWrapperBase(prefix string, inner FileSystem) WrapperBase {
+	return WrapperBase{prefix: prefix, inner: inner}
+}
+
+// Inner returns the wrapped file system
+func (w *WrapperBase) Inner() FileSystem {
+	return w.inner
+}
+
+// InnerFile returns the file of the inner file system for filePath
+func (w *WrapperBase) InnerFile(filePath string) File {
+	return File(w.inner.URL(filePath))
+}
+
+func (w *WrapperBase) RootDir() File {
+	return w.JoinCleanFile(w.inner.Separator())
+}
+
+func (w *WrapperBase) Prefix() string {
+	return w.prefix
+}
+
+func (w *WrapperBase) JoinCleanFile(uriParts ...string) File {
+	return File(w.prefix + w.JoinCleanPath(uriParts...))
+}
+
+func (w *WrapperBase) URL(cleanPath string) string {
+	return w.prefix + cleanPath
+}
+
+func (w *WrapperBase) CleanPathFromURI(uri string) string {
+	return strings.TrimPrefix(uri, w.prefix)
+}
+
+func (w *WrapperBase) JoinCleanPath(uriParts ...string) string {
+	return w.inner.JoinCleanPath(uriParts...)
+}
+
+func (w *WrapperBase) SplitPath(filePath string) []string {
+	return w.inner.SplitPath(filePath)
+}
+
+func (w *WrapperBase) Separator() string {
+	return w.inner.Separator()
+}
+
+func (w *WrapperBase) MatchAnyPattern(name string, patterns []string) (bool, error) {
+	return w.inner.MatchAnyPattern(name, patterns)
+}
+
+func (w *WrapperBase) SplitDirAndName(filePath string) (dir, name string) {
+	return w.inner.SplitDirAndName(filePath)
+}
+
+func (w *WrapperBase) IsAbsPath(filePath string) bool {
+	return w.inner.IsAbsPath(filePath)
+}
+
+func (w *WrapperBase) AbsPath(filePath string) string {
+	return w.inner.AbsPath(filePath)
+}
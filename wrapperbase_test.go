@@ -0,0 +1,17 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapperBase(t *testing.T) {
+	w := NewWrapperBase("wrapper://id", Local)
+	require.Same(t, Local, w.Inner())
+	require.Equal(t, "wrapper://id", w.Prefix())
+	require.Equal(t, File("wrapper://id/dir/file.txt"), w.JoinCleanFile("/dir", "file.txt"))
+	require.Equal(t, "/dir/file.txt", w.CleanPathFromURI("wrapper://id/dir/file.txt"))
+	require.Equal(t, File("wrapper://id/"), w.RootDir())
+	require.Equal(t, File("file:///dir/file.txt"), w.InnerFile("/dir/file.txt"))
+}
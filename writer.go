@@ -0,0 +1,220 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Flusher is implemented by writers that buffer written data
+// and can write it to the underlying storage before being closed.
+type Flusher interface {
+	Flush() error
+}
+
+var (
+	_ WriteCloser = new(fileWriter)
+	_ Flusher     = new(fileWriter)
+)
+
+// fileWriter wraps the WriteCloser of a file system
+// to make calling Close multiple times safe,
+// to add a Flush method, and to optionally
+// pass close errors to a callback.
+type fileWriter struct {
+	writer       WriteCloser
+	onCloseError func(error)
+	mtx          sync.Mutex
+	closed       bool
+}
+
+// newFileWriter wraps writer in a fileWriter
+// unless it is an *os.File that can already be closed
+// multiple times and must not be hidden behind a wrapper
+// so that callers can use its optional interfaces
+// like io.ReaderFrom, io.Seeker, or Sync.
+func newFileWriter(writer WriteCloser) WriteCloser {
+	if _, ok := writer.(*os.File); ok {
+		return writer
+	}
+	return &fileWriter{writer: writer}
+}
+
+// NotifyCloseError returns a writer that passes
+// a non nil error returned by closing writer to callback
+// before returning it.
+// Use it to not lose errors from deferred Close calls.
+// The returned writer can be closed multiple times
+// and implements Flusher.
+func NotifyCloseError(writer WriteCloser, callback func(error)) WriteCloser {
+	w, ok := writer.(*fileWriter)
+	if !ok {
+		w = &fileWriter{writer: writer}
+	}
+	w.mtx.Lock()
+	w.onCloseError = callback
+	w.mtx.Unlock()
+	return w
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return 0, iofs.ErrClosed
+	}
+	return w.writer.Write(p)
+}
+
+// Flush writes buffered data to the underlying storage
+// if the writer of the file system implementation buffers data,
+// else it does nothing.
+func (w *fileWriter) Flush() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return iofs.ErrClosed
+	}
+	if flusher, ok := w.writer.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close closes the writer of the file system implementation
+// on the first call and does nothing on subsequent calls.
+// A close error is also passed to the callback
+// set with NotifyCloseError.
+func (w *fileWriter) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := w.writer.Close()
+	if err != nil && w.onCloseError != nil {
+		w.onCloseError(err)
+	}
+	return err
+}
+
+var (
+	_ WriteCloser = new(guardedWriter)
+	_ Flusher     = new(guardedWriter)
+)
+
+// GuardWriter wraps writer to protect against runaway or stalled writes.
+//
+// If maxBytes is greater than zero, then a write that would exceed
+// a total of maxBytes is rejected with a wrapped ErrTooLarge error
+// and all further writes will fail with the same error.
+//
+// If inactivityTimeout is greater than zero, then the wrapped writer
+// will be closed when no write happened within inactivityTimeout
+// and all further writes will fail with a wrapped os.ErrDeadlineExceeded.
+//
+// The returned writer implements Flusher and can be closed multiple times.
+// Close returns the error that aborted writing joined with
+// the error from closing the wrapped writer.
+func GuardWriter(writer WriteCloser, maxBytes int64, inactivityTimeout time.Duration) WriteCloser {
+	w := &guardedWriter{writer: writer, maxBytes: maxBytes}
+	if inactivityTimeout > 0 {
+		w.timer = time.AfterFunc(inactivityTimeout, func() { w.onTimeout(inactivityTimeout) })
+		w.timeout = inactivityTimeout
+	}
+	return w
+}
+
+type guardedWriter struct {
+	writer   WriteCloser
+	maxBytes int64
+	timeout  time.Duration
+	timer    *time.Timer
+
+	mtx       sync.Mutex
+	written   int64
+	err       error // sticky error that aborted writing
+	closed    bool
+	closeErr  error
+	closeDone bool // writer has been closed
+}
+
+func (w *guardedWriter) Write(p []byte) (n int, err error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return 0, iofs.ErrClosed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.maxBytes > 0 && w.written+int64(len(p)) > w.maxBytes {
+		w.err = fmt.Errorf("%w: writing more than %d bytes", ErrTooLarge, w.maxBytes)
+		return 0, w.err
+	}
+	n, err = w.writer.Write(p)
+	w.written += int64(n)
+	if w.timer != nil {
+		w.timer.Reset(w.timeout)
+	}
+	return n, err
+}
+
+func (w *guardedWriter) onTimeout(timeout time.Duration) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed || w.err != nil {
+		return
+	}
+	w.err = fmt.Errorf("no write within %s: %w", timeout, os.ErrDeadlineExceeded)
+	w.closeWriter()
+}
+
+func (w *guardedWriter) closeWriter() {
+	if !w.closeDone {
+		w.closeErr = w.writer.Close()
+		w.closeDone = true
+	}
+}
+
+// Flush writes buffered data of the wrapped writer
+// if it implements Flusher, else it does nothing.
+func (w *guardedWriter) Flush() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return iofs.ErrClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if flusher, ok := w.writer.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (w *guardedWriter) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.closeWriter()
+	return errors.Join(w.err, w.closeErr)
+}
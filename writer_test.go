@@ -0,0 +1,108 @@
+package fs
+
+import (
+	"errors"
+	iofs "io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ungerik/go-fs/fsimpl"
+)
+
+type errCloser struct {
+	fsimpl.FileBuffer
+	numClose int
+}
+
+func (c *errCloser) Close() error {
+	c.numClose++
+	return errors.New("close error")
+}
+
+func TestFileWriter_Close(t *testing.T) {
+	closer := new(errCloser)
+	w := newFileWriter(closer)
+	_, err := w.Write([]byte("Hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.(Flusher).Flush())
+	require.Error(t, w.Close())
+	require.NoError(t, w.Close(), "second Close is safe")
+	require.Equal(t, 1, closer.numClose)
+	_, err = w.Write([]byte("World"))
+	require.ErrorIs(t, err, iofs.ErrClosed)
+	require.ErrorIs(t, w.(Flusher).Flush(), iofs.ErrClosed)
+}
+
+func TestFileWriter_LocalFile(t *testing.T) {
+	file := File(t.TempDir()).Join("file.txt")
+	w, err := file.OpenWriter()
+	require.NoError(t, err)
+	require.IsType(t, (*os.File)(nil), w, "not wrapped")
+	_, err = w.Write([]byte("Hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	str, err := file.ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+}
+
+func TestNotifyCloseError(t *testing.T) {
+	var errs []error
+	closer := new(errCloser)
+	w := NotifyCloseError(newFileWriter(closer), func(err error) {
+		errs = append(errs, err)
+	})
+	require.Error(t, w.Close())
+	require.NoError(t, w.Close())
+	require.Equal(t, 1, closer.numClose)
+	require.Len(t, errs, 1)
+}
+
+func TestFileWriter_Flush(t *testing.T) {
+	var flushed []byte
+	var buf *fsimpl.FileBuffer
+	buf = fsimpl.NewFileBufferWithClose(nil, func() error {
+		flushed = append([]byte(nil), buf.Bytes()...)
+		return nil
+	})
+	w := newFileWriter(buf)
+	_, err := w.Write([]byte("Hello"))
+	require.NoError(t, err)
+	require.Nil(t, flushed)
+	require.NoError(t, w.(Flusher).Flush())
+	require.Equal(t, "Hello", string(flushed))
+	require.NoError(t, w.Close())
+}
+
+func TestGuardWriter_MaxBytes(t *testing.T) {
+	buf := fsimpl.NewFileBuffer(nil)
+	w := GuardWriter(buf, 8, 0)
+	n, err := w.Write([]byte("Hello"))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	_, err = w.Write([]byte("World"))
+	require.ErrorIs(t, err, ErrTooLarge)
+	_, err = w.Write([]byte("!"))
+	require.ErrorIs(t, err, ErrTooLarge, "error is sticky")
+	require.Equal(t, "Hello", string(buf.Bytes()))
+	require.ErrorIs(t, w.Close(), ErrTooLarge)
+	require.NoError(t, w.Close())
+}
+
+func TestGuardWriter_InactivityTimeout(t *testing.T) {
+	closer := new(errCloser)
+	w := GuardWriter(closer, 0, 10*time.Millisecond)
+	_, err := w.Write([]byte("Hello"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err = w.Write([]byte("World"))
+		return err != nil
+	}, time.Second, 20*time.Millisecond)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	err = w.Close()
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.Equal(t, 1, closer.numClose, "wrapped writer closed once")
+}
@@ -0,0 +1,95 @@
+package fs
+
+// XAttr returns the value of the extended attribute name
+// or ErrXAttrDoesNotExist if the file has no such attribute.
+// Returns an ErrUnsupported error if the file system
+// does not implement XAttrFileSystem.
+//
+// On Linux the names of user attributes
+// of local files must start with "user.".
+func (file File) XAttr(name string) ([]byte, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	xattrFS, ok := fileSystem.(XAttrFileSystem)
+	if !ok {
+		return nil, NewErrUnsupported(fileSystem, "GetXAttr")
+	}
+	defer beginOp(fileSystem)()
+	return xattrFS.GetXAttr(path, name)
+}
+
+// XAttrString returns the value of the extended attribute name as string.
+func (file File) XAttrString(name string) (string, error) {
+	value, err := file.XAttr(name)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// SetXAttr creates or replaces the extended attribute name.
+// Returns an ErrUnsupported error if the file system
+// does not implement XAttrFileSystem.
+func (file File) SetXAttr(name string, value []byte) error {
+	if file == "" {
+		return ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	xattrFS, ok := fileSystem.(XAttrFileSystem)
+	if !ok {
+		return NewErrUnsupported(fileSystem, "SetXAttr")
+	}
+	defer beginOp(fileSystem)()
+	return xattrFS.SetXAttr(path, name, value)
+}
+
+// ListXAttrs returns the sorted names of all extended attributes.
+// Returns an ErrUnsupported error if the file system
+// does not implement XAttrFileSystem.
+func (file File) ListXAttrs() ([]string, error) {
+	if file == "" {
+		return nil, ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	xattrFS, ok := fileSystem.(XAttrFileSystem)
+	if !ok {
+		return nil, NewErrUnsupported(fileSystem, "ListXAttrs")
+	}
+	defer beginOp(fileSystem)()
+	return xattrFS.ListXAttrs(path)
+}
+
+// XAttrs returns all extended attributes by name.
+func (file File) XAttrs() (map[string][]byte, error) {
+	names, err := file.ListXAttrs()
+	if err != nil {
+		return nil, err
+	}
+	xattrs := make(map[string][]byte, len(names))
+	for _, name := range names {
+		xattrs[name], err = file.XAttr(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return xattrs, nil
+}
+
+// RemoveXAttr removes the extended attribute name
+// or returns ErrXAttrDoesNotExist if the file has no such attribute.
+// Returns an ErrUnsupported error if the file system
+// does not implement XAttrFileSystem.
+func (file File) RemoveXAttr(name string) error {
+	if file == "" {
+		return ErrEmptyPath
+	}
+	fileSystem, path := file.ParseRawURI()
+	xattrFS, ok := fileSystem.(XAttrFileSystem)
+	if !ok {
+		return NewErrUnsupported(fileSystem, "RemoveXAttr")
+	}
+	defer beginOp(fileSystem)()
+	return xattrFS.RemoveXAttr(path, name)
+}
@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testXAttrs(t *testing.T, file File, name string) {
+	t.Helper()
+
+	names, err := file.ListXAttrs()
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	_, err = file.XAttr(name)
+	require.ErrorIs(t, err, ErrXAttrDoesNotExist)
+
+	require.NoError(t, file.SetXAttr(name, []byte("value")))
+	value, err := file.XAttrString(name)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	xattrs, err := file.XAttrs()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{name: []byte("value")}, xattrs)
+
+	require.NoError(t, file.RemoveXAttr(name))
+	err = file.RemoveXAttr(name)
+	require.ErrorIs(t, err, ErrXAttrDoesNotExist)
+}
+
+func TestFile_XAttr_MemFileSystem(t *testing.T) {
+	memFS, err := NewMemFileSystem("/")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = memFS.Close() })
+
+	file := memFS.RootDir().Join("file.txt")
+	require.NoError(t, file.WriteAllString("content"))
+
+	testXAttrs(t, file, "user.test")
+}
+
+func TestFile_XAttr_LocalFileSystem(t *testing.T) {
+	file := File(t.TempDir()).Join("file.txt")
+	require.NoError(t, file.WriteAllString("content"))
+
+	err := file.SetXAttr("user.probe", []byte("x"))
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("extended attributes not supported:", err)
+	}
+	require.NoError(t, err)
+	require.NoError(t, file.RemoveXAttr("user.probe"))
+
+	testXAttrs(t, file, "user.test")
+}
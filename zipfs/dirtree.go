@@ -1,7 +1,7 @@
 package zipfs
 
 import (
-	"path"
+	"archive/zip"
 	"sort"
 	"time"
 
@@ -11,10 +11,51 @@ import (
 type node struct {
 	*fs.FileInfo
 	children map[string]*node
+
+	// zipFile is the archive entry of a file,
+	// nil for directories and files written in read-write mode
+	zipFile *zip.File
+	// data is the content of a file written in read-write mode
+	data []byte
 }
 
-func (n *node) hasChild(name string) bool {
-	return n.children != nil && n.children[name] != nil
+func newDirNode(file fs.File, name string, modTime time.Time) *node {
+	return &node{
+		FileInfo: &fs.FileInfo{
+			File:        file,
+			Name:        name,
+			Exists:      true,
+			IsDir:       true,
+			IsRegular:   true,
+			IsHidden:    len(name) > 0 && name[0] == '.',
+			Modified:    modTime,
+			Permissions: fs.AllRead,
+		},
+		children: make(map[string]*node),
+	}
+}
+
+func newFileNode(file fs.File, name string, modTime time.Time, size int64) *node {
+	return &node{
+		FileInfo: &fs.FileInfo{
+			File:        file,
+			Name:        name,
+			Exists:      true,
+			IsDir:       false,
+			IsRegular:   true,
+			IsHidden:    len(name) > 0 && name[0] == '.',
+			Size:        size,
+			Modified:    modTime,
+			Permissions: fs.AllRead,
+		},
+	}
+}
+
+// info returns a copy of the node's FileInfo
+// that can be passed to callbacks
+func (n *node) info() *fs.FileInfo {
+	info := *n.FileInfo
+	return &info
 }
 
 // sortedChildren returns the children sorted with directories first
@@ -55,64 +96,3 @@ func (n *node) sortedChildDirs() []*node {
 	})
 	return s
 }
-
-// func (n *node) addChild(info fs.FileInfo) {
-// 	child := &node{FileInfo: info}
-// 	if info.IsDir {
-// 		child.children = make(map[string]*node)
-// 	}
-// 	n.children[info.Name] = child
-// }
-
-func (n *node) addChildDir(filePath string, modTime time.Time) (child *node) {
-	name := path.Base(filePath)
-	child = n.children[name]
-	if child != nil {
-		if !child.IsDir {
-			panic("existing child is not a directory")
-		}
-		return child
-	}
-	child = &node{
-		FileInfo: &fs.FileInfo{
-			File:        fs.File(filePath),
-			Name:        name,
-			Exists:      true,
-			IsDir:       true,
-			IsRegular:   true,
-			IsHidden:    name[0] == '.',
-			Size:        0,
-			Modified:    modTime,
-			Permissions: fs.AllRead,
-		},
-		children: make(map[string]*node),
-	}
-	n.children[name] = child
-	return child
-}
-
-func (n *node) addChildFile(filePath string, modTime time.Time, size int64) (child *node) {
-	name := path.Base(filePath)
-	child = n.children[name]
-	if child != nil {
-		if child.IsDir {
-			panic("existing child is a directory")
-		}
-		return child
-	}
-	child = &node{
-		FileInfo: &fs.FileInfo{
-			File:        fs.File(filePath),
-			Name:        name,
-			Exists:      true,
-			IsDir:       false,
-			IsRegular:   true,
-			IsHidden:    name[0] == '.',
-			Size:        size,
-			Modified:    modTime,
-			Permissions: fs.AllRead,
-		},
-	}
-	n.children[name] = child
-	return child
-}
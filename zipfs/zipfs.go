@@ -1,13 +1,19 @@
+// Package zipfs implements a file system for zip archives
+// that are mounted from any fs.File.
 package zipfs
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	iofs "io/fs"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ungerik/go-fs"
 	"github.com/ungerik/go-fs/fsimpl"
@@ -26,14 +32,31 @@ var (
 	_ fs.FileSystem = new(ZipFileSystem)
 )
 
-// ZipFileSystem
+// ZipFileSystem mounts a zip archive as file system
+// registered with the prefix "zip://" followed by a random ID,
+// so the root directory of the archive is "zip://<id>/".
+//
+// There are three modes:
+//   - NewReaderFileSystem returns a read-only file system
+//   - NewWriterFileSystem returns a write-only file system that streams new archive entries
+//   - NewReadWriteFileSystem returns a file system that keeps changes in memory
+//     and rewrites the archive on Close
 type ZipFileSystem struct {
 	prefix    string
 	closer    io.Closer // will be nil after Close()
 	zipReader *zip.Reader
 	zipWriter *zip.Writer
+
+	// root is the directory tree of the archive, nil for write-only mode
+	root *node
+	// archive is the file rewritten on Close in read-write mode
+	archive  fs.File
+	modified bool
+	mtx      sync.RWMutex
 }
 
+// NewReaderFileSystem mounts the zip archive file
+// as read-only file system and registers it.
 func NewReaderFileSystem(file fs.FileReader) (zipfs *ZipFileSystem, err error) {
 	fileReader, err := file.OpenReadSeeker()
 	if err != nil {
@@ -41,17 +64,27 @@ func NewReaderFileSystem(file fs.FileReader) (zipfs *ZipFileSystem, err error) {
 	}
 	zipReader, err := zip.NewReader(fileReader, file.Size())
 	if err != nil {
-		return nil, err
+		return nil, errors.Join(err, fileReader.Close())
 	}
 	zipfs = &ZipFileSystem{
 		prefix:    Prefix + fsimpl.RandomString(),
 		closer:    fileReader,
 		zipReader: zipReader,
 	}
-	fs.Register(zipfs)
-	return zipfs, err
+	err = zipfs.buildTree()
+	if err != nil {
+		return nil, errors.Join(err, fileReader.Close())
+	}
+	_, err = fs.TryRegister(zipfs)
+	if err != nil {
+		return nil, errors.Join(err, fileReader.Close())
+	}
+	return zipfs, nil
 }
 
+// NewWriterFileSystem returns a write-only file system
+// that streams all written files as entries of a new zip archive to file.
+// The archive is finished by Close.
 func NewWriterFileSystem(file fs.File) (zipfs *ZipFileSystem, err error) {
 	fileWriter, err := file.OpenWriter()
 	if err != nil {
@@ -59,16 +92,152 @@ func NewWriterFileSystem(file fs.File) (zipfs *ZipFileSystem, err error) {
 	}
 	zipWriter := zip.NewWriter(fileWriter)
 	zipfs = &ZipFileSystem{
-		prefix:    Prefix + fsimpl.RandomString(),
-		closer:    zipWriter,
+		prefix: Prefix + fsimpl.RandomString(),
+		closer: closerFunc(func() error {
+			return errors.Join(zipWriter.Close(), fileWriter.Close())
+		}),
 		zipWriter: zipWriter,
 	}
-	fs.Register(zipfs)
-	return zipfs, err
+	_, err = fs.TryRegister(zipfs)
+	if err != nil {
+		return nil, errors.Join(err, zipfs.closer.Close())
+	}
+	return zipfs, nil
+}
+
+// NewReadWriteFileSystem mounts the zip archive file
+// as readable and writable file system and registers it.
+// If file does not exist, then the file system starts empty.
+//
+// Written files are kept in memory and the archive
+// is rewritten on Close if there were any changes.
+// Unchanged entries are copied without recompression.
+func NewReadWriteFileSystem(file fs.File) (zipfs *ZipFileSystem, err error) {
+	zipfs = &ZipFileSystem{
+		prefix:  Prefix + fsimpl.RandomString(),
+		closer:  closerFunc(func() error { return nil }),
+		archive: file,
+	}
+	if file.Exists() {
+		fileReader, err := file.OpenReadSeeker()
+		if err != nil {
+			return nil, err
+		}
+		zipfs.zipReader, err = zip.NewReader(fileReader, file.Size())
+		if err != nil {
+			return nil, errors.Join(err, fileReader.Close())
+		}
+		zipfs.closer = fileReader
+	}
+	err = zipfs.buildTree()
+	if err != nil {
+		return nil, errors.Join(err, zipfs.closer.Close())
+	}
+	_, err = fs.TryRegister(zipfs)
+	if err != nil {
+		return nil, errors.Join(err, zipfs.closer.Close())
+	}
+	return zipfs, nil
+}
+
+// buildTree creates the directory tree
+// from the entries of zipReader if not nil
+func (f *ZipFileSystem) buildTree() error {
+	f.root = newDirNode(f.RootDir(), "", time.Time{})
+	if f.zipReader == nil {
+		return nil
+	}
+	for _, zipFile := range f.zipReader.File {
+		entryPath := path.Clean(Separator + zipFile.Name)
+		if entryPath == Separator {
+			continue
+		}
+		dirPath, name := path.Split(entryPath)
+		dir, err := f.makeDirNodes(dirPath, zipFile.Modified)
+		if err != nil {
+			return err
+		}
+		if zipFile.FileInfo().IsDir() {
+			_, err = f.makeDirNodes(entryPath, zipFile.Modified)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if dir.children[name] != nil {
+			return fmt.Errorf("duplicate zip entry: %s", zipFile.Name)
+		}
+		child := newFileNode(f.File(entryPath), name, zipFile.Modified, int64(zipFile.UncompressedSize64))
+		child.zipFile = zipFile
+		dir.children[name] = child
+	}
+	return nil
+}
+
+// makeDirNodes returns the directory node for dirPath
+// and creates all missing nodes up to it.
+func (f *ZipFileSystem) makeDirNodes(dirPath string, modTime time.Time) (*node, error) {
+	dir := f.root
+	currentPath := Separator
+	for _, name := range fsimpl.SplitPath(dirPath, "", Separator) {
+		currentPath = path.Join(currentPath, name)
+		child := dir.children[name]
+		if child == nil {
+			child = newDirNode(f.File(currentPath), name, modTime)
+			dir.children[name] = child
+		} else if !child.IsDir {
+			return nil, fs.NewErrIsNotDirectory(child.File)
+		}
+		dir = child
+	}
+	return dir, nil
+}
+
+// findNode returns the node for filePath or nil
+func (f *ZipFileSystem) findNode(filePath string) *node {
+	n := f.root
+	for _, name := range fsimpl.SplitPath(filePath, f.prefix, Separator) {
+		if n.children == nil {
+			return nil
+		}
+		n = n.children[name]
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// checkReadable returns an error if the file system is
+// closed or can't be read, else it locks the file system for reading.
+func (f *ZipFileSystem) checkReadable() error {
+	if f.root == nil {
+		return fs.ErrWriteOnlyFileSystem
+	}
+	f.mtx.RLock()
+	if f.closer == nil {
+		f.mtx.RUnlock()
+		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	}
+	return nil
+}
+
+// checkWritable returns an error if the file system is
+// closed or can't be written, else it locks the file system for writing.
+func (f *ZipFileSystem) checkWritable() error {
+	if f.zipWriter == nil && f.archive == "" {
+		return fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+	}
+	f.mtx.Lock()
+	if f.closer == nil {
+		f.mtx.Unlock()
+		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	}
+	return nil
 }
 
 func (f *ZipFileSystem) ReadableWritable() (readable, writable bool) {
-	return f.zipReader != nil, f.zipWriter != nil
+	return f.root != nil, f.zipWriter != nil || f.archive != ""
 }
 
 func (f *ZipFileSystem) RootDir() fs.File {
@@ -85,7 +254,7 @@ func (f *ZipFileSystem) Prefix() string {
 }
 
 func (f *ZipFileSystem) Name() string {
-	return "Zip reader filesystem " + path.Base(f.prefix)
+	return "Zip filesystem " + path.Base(f.prefix)
 }
 
 // String implements the fmt.Stringer interface.
@@ -143,87 +312,26 @@ func (f *ZipFileSystem) AbsPath(filePath string) string {
 	return path.Clean(filePath)
 }
 
-// func (f *ZipFileSystem) findFile(filePath string) *zip.File {
-// 	filePath = strings.TrimPrefix(filePath, Separator)
-// 	for _, zipFile := range f.zipReader.File {
-// 		if zipFile.Name == filePath {
-// 			return zipFile
-// 		}
-// 	}
-// 	return nil
-// }
-
-// func (f *ZipFileSystem) findDir(filePath string) bool {
-// 	filePath = strings.TrimPrefix(filePath, Separator)
-// 	if !strings.HasSuffix(filePath, Separator) {
-// 		filePath += Separator
-// 	}
-// 	for _, zipFile := range f.zipReader.File {
-// 		if strings.HasPrefix(zipFile.Name, filePath) {
-// 			return true
-// 		}
-// 	}
-// 	return false
-// }
-
-func (f *ZipFileSystem) findFile(filePath string) (zipFile *zip.File, isDir bool) {
-	if f.closer == nil {
-		return nil, false
-	}
-	filePath = strings.TrimPrefix(filePath, Separator)
-	for _, zipFile := range f.zipReader.File {
-		if zipFile.Name == filePath {
-			return zipFile, false
-		}
-	}
-	if !strings.HasSuffix(filePath, Separator) {
-		filePath += Separator
-	}
-	for _, zipFile := range f.zipReader.File {
-		if strings.HasPrefix(zipFile.Name, filePath) {
-			return zipFile, true
-		}
+func (f *ZipFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
+	if err := f.checkReadable(); err != nil {
+		return nil, err
 	}
-	return nil, false
-}
+	defer f.mtx.RUnlock()
 
-func (f *ZipFileSystem) stat(filePath string, zipFile *zip.File, isDir bool) (iofs.FileInfo, error) {
-	if zipFile == nil {
+	n := f.findNode(filePath)
+	if n == nil {
 		return nil, fs.NewErrDoesNotExist(f.File(filePath))
 	}
-
-	name := path.Base(filePath)
-	size := int64(zipFile.UncompressedSize64)
-	if isDir {
-		size = 0
-	}
-	info := &fs.FileInfo{
-		Name:        name,
-		Exists:      true,
-		IsDir:       isDir,
-		IsRegular:   true,
-		IsHidden:    len(name) > 0 && name[0] == '.',
-		Size:        size,
-		Modified:    zipFile.Modified,
-		Permissions: fs.AllRead,
-	}
-	return info.StdFileInfo(), nil
-}
-
-func (f *ZipFileSystem) Stat(filePath string) (iofs.FileInfo, error) {
-	if f.zipReader == nil {
-		return nil, fs.ErrWriteOnlyFileSystem
-	}
-	zipFile, isDir := f.findFile(filePath)
-	return f.stat(filePath, zipFile, isDir)
+	return n.info().StdFileInfo(), nil
 }
 
 func (f *ZipFileSystem) Exists(filePath string) bool {
-	if f.zipReader == nil {
+	if err := f.checkReadable(); err != nil {
 		return false
 	}
-	zipFile, _ := f.findFile(filePath)
-	return zipFile != nil
+	defer f.mtx.RUnlock()
+
+	return f.findNode(filePath) != nil
 }
 
 func (f *ZipFileSystem) IsHidden(filePath string) bool {
@@ -235,161 +343,456 @@ func (f *ZipFileSystem) IsSymbolicLink(filePath string) bool {
 	return false
 }
 
-func (f *ZipFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) (err error) {
-	if f.zipReader == nil {
-		return fs.ErrWriteOnlyFileSystem
+// dirNode returns the directory node for dirPath
+// or an error if it does not exist or is not a directory.
+func (f *ZipFileSystem) dirNode(dirPath string) (*node, error) {
+	dir := f.findNode(dirPath)
+	if dir == nil {
+		return nil, fs.NewErrDoesNotExist(f.File(dirPath))
 	}
-	if f.closer == nil {
-		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	if !dir.IsDir {
+		return nil, fs.NewErrIsNotDirectory(f.File(dirPath))
 	}
-
-	panic("TODO")
+	return dir, nil
 }
 
-func (f *ZipFileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+// ListDirInfo lists the directories first
+// and then the files sorted by name.
+func (f *ZipFileSystem) ListDirInfo(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) (err error) {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
-	if f.zipReader == nil {
-		return fs.ErrWriteOnlyFileSystem
+	if err := f.checkReadable(); err != nil {
+		return err
 	}
-	if f.closer == nil {
-		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	dir, err := f.dirNode(dirPath)
+	if err != nil {
+		f.mtx.RUnlock()
+		return err
+	}
+	// Don't hold the lock during callbacks
+	// that could modify the file system
+	var infos []*fs.FileInfo
+	for _, child := range dir.sortedChildren() {
+		match, err := f.MatchAnyPattern(child.Name, patterns)
+		if err != nil {
+			f.mtx.RUnlock()
+			return err
+		}
+		if match {
+			infos = append(infos, child.info())
+		}
 	}
+	f.mtx.RUnlock()
 
-	rootNode := &node{
-		FileInfo: &fs.FileInfo{
-			IsDir: true,
-		},
-		children: make(map[string]*node),
-	}
-	for _, file := range f.zipReader.File {
-		currentDir := rootNode
-		parts := strings.Split(file.Name, Separator)
-		lastIndex := len(parts) - 1
-		for i := 0; i < lastIndex; i++ {
-			currentDir = currentDir.addChildDir(parts[i], file.Modified)
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = callback(info)
+		if err != nil {
+			return err
 		}
-		currentDir.addChildFile(parts[lastIndex], file.Modified, int64(file.UncompressedSize64))
 	}
+	return nil
+}
 
-	if dirPath != "" && dirPath != "." && dirPath != Separator {
-		// parts := sipfs.SplitPath(dirPath)
-		panic("TODO set rootNode to dirPath")
+// ListDirInfoRecursive lists all files (not directories)
+// in dirPath and its sub-directories.
+func (f *ZipFileSystem) ListDirInfoRecursive(ctx context.Context, dirPath string, callback func(*fs.FileInfo) error, patterns []string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-
-	if !rootNode.IsDir {
-		return fs.NewErrIsNotDirectory(f.File(dirPath))
+	if err := f.checkReadable(); err != nil {
+		return err
 	}
-
-	var listRecursive func(parent *node) error
+	dir, err := f.dirNode(dirPath)
+	if err != nil {
+		f.mtx.RUnlock()
+		return err
+	}
+	var (
+		infos         []*fs.FileInfo
+		listRecursive func(parent *node) error
+	)
 	listRecursive = func(parent *node) error {
 		for _, child := range parent.sortedChildren() {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-
-			err := callback(child.FileInfo)
-			if err != nil {
-				return err
-			}
 			if child.IsDir {
-				err = listRecursive(child)
+				err := listRecursive(child)
 				if err != nil {
 					return err
 				}
+				continue
+			}
+			match, err := f.MatchAnyPattern(child.Name, patterns)
+			if err != nil {
+				return err
+			}
+			if match {
+				infos = append(infos, child.info())
 			}
 		}
 		return nil
 	}
+	err = listRecursive(dir)
+	f.mtx.RUnlock()
+	if err != nil {
+		return err
+	}
 
-	return listRecursive(rootNode)
+	for _, info := range infos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err = callback(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (f *ZipFileSystem) OpenReader(filePath string) (iofs.File, error) {
-	if f.zipReader == nil {
-		return nil, fs.ErrWriteOnlyFileSystem
-	}
-	if f.closer == nil {
-		return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	if err := f.checkReadable(); err != nil {
+		return nil, err
 	}
+	defer f.mtx.RUnlock()
 
-	zipFile, isDir := f.findFile(filePath)
-	if isDir {
+	n := f.findNode(filePath)
+	if n == nil {
+		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if n.IsDir {
 		return nil, fs.NewErrIsDirectory(f.File(filePath))
 	}
-	if zipFile == nil {
-		return nil, fs.NewErrDoesNotExist(f.File(filePath))
+	info := n.info().StdFileInfo()
+	if n.zipFile == nil {
+		return fsimpl.NewReadonlyFileBuffer(n.data, info), nil
 	}
-	file, err := zipFile.Open()
+	reader, err := n.zipFile.Open()
 	if err != nil {
 		return nil, err
 	}
-	return file.(iofs.File), nil
+	return &entryReader{ReadCloser: reader, info: info}, nil
 }
 
-func (f *ZipFileSystem) Touch(filePath string, perm []fs.Permissions) error {
-	if f.zipWriter == nil {
-		return fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+func (f *ZipFileSystem) ReadAll(ctx context.Context, filePath string) ([]byte, error) {
+	reader, err := f.OpenReader(filePath)
+	if err != nil {
+		return nil, err
 	}
-	if f.closer == nil {
-		return fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	defer reader.Close()
+
+	return fs.ReadAllContext(ctx, reader)
+}
+
+// Touch creates an empty file if filePath does not exist
+// or updates the modified time in read-write mode.
+func (f *ZipFileSystem) Touch(filePath string, perm []fs.Permissions) error {
+	if err := f.checkWritable(); err != nil {
+		return err
 	}
+	defer f.mtx.Unlock()
 
-	filePath = strings.TrimPrefix(filePath, Separator)
-	_, err := f.zipWriter.Create(filePath)
-	return err
+	if f.zipWriter != nil {
+		_, err := f.zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     entryName(filePath),
+			Modified: time.Now(),
+		})
+		return err
+	}
+	if n := f.findNode(filePath); n != nil {
+		n.Modified = time.Now()
+		f.modified = true
+		return nil
+	}
+	return f.writeNode(filePath, nil)
 }
 
+// MakeDir creates a directory entry.
+// In read-write mode the parent directory must exist.
 func (f *ZipFileSystem) MakeDir(dirPath string, perm []fs.Permissions) error {
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	defer f.mtx.Unlock()
+
+	if f.zipWriter != nil {
+		_, err := f.zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     entryName(dirPath) + Separator,
+			Modified: time.Now(),
+		})
+		return err
+	}
+	if f.findNode(dirPath) != nil {
+		return fs.NewErrAlreadyExists(f.File(dirPath))
+	}
+	parentPath, name := path.Split(path.Clean(Separator + dirPath))
+	parent, err := f.dirNode(parentPath)
+	if err != nil {
+		return err
+	}
+	parent.children[name] = newDirNode(f.File(dirPath), name, time.Now())
+	f.modified = true
 	return nil
 }
 
-func (f *ZipFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
-	if f.zipWriter == nil {
-		return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+// writeNode sets the data of the file node at filePath
+// creating the node and all missing parent directories.
+// The caller must hold the write lock.
+func (f *ZipFileSystem) writeNode(filePath string, data []byte) error {
+	entryPath := path.Clean(Separator + filePath)
+	if entryPath == Separator {
+		return fs.NewErrIsDirectory(f.File(filePath))
 	}
-	if f.closer == nil {
-		return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	dirPath, name := path.Split(entryPath)
+	dir, err := f.makeDirNodes(dirPath, time.Now())
+	if err != nil {
+		return err
 	}
+	if child := dir.children[name]; child != nil && child.IsDir {
+		return fs.NewErrIsDirectory(child.File)
+	}
+	child := newFileNode(f.File(entryPath), name, time.Now(), int64(len(data)))
+	child.data = data
+	dir.children[name] = child
+	f.modified = true
+	return nil
+}
 
-	filePath = strings.TrimPrefix(filePath, Separator)
-
-	writer, err := f.zipWriter.Create(filePath)
+// WriteAll writes data as file entry.
+// In read-write mode missing parent directories are created.
+func (f *ZipFileSystem) WriteAll(ctx context.Context, filePath string, data []byte, perm []fs.Permissions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	writer, err := f.OpenWriter(filePath, perm)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	return nopCloser{writer}, nil
+	_, err = writer.Write(data)
+	return errors.Join(err, writer.Close())
 }
 
-func (f *ZipFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
-	if f.zipWriter == nil {
-		return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+// OpenWriter returns a writer for a new file entry.
+// In write-only mode only one writer can be used at a time
+// and the data is streamed into the archive.
+// In read-write mode the data is buffered
+// and stored in the file system when the writer is closed.
+func (f *ZipFileSystem) OpenWriter(filePath string, perm []fs.Permissions) (fs.WriteCloser, error) {
+	if err := f.checkWritable(); err != nil {
+		return nil, err
 	}
-	if f.closer == nil {
-		return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrFileSystemClosed)
+	defer f.mtx.Unlock()
+
+	if f.zipWriter != nil {
+		writer, err := f.zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     entryName(filePath),
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nopCloser{writer}, nil
 	}
 
-	panic("TODO buffered impl")
+	if n := f.findNode(filePath); n != nil && n.IsDir {
+		return nil, fs.NewErrIsDirectory(f.File(filePath))
+	}
+	return &bufferWriter{close: func(data []byte) error {
+		if err := f.checkWritable(); err != nil {
+			return err
+		}
+		defer f.mtx.Unlock()
+		return f.writeNode(filePath, data)
+	}}, nil
+}
 
-	// return nil, fmt.Errorf("%s %w", f.Name(), fs.ErrReadOnlyFileSystem)
+// OpenReadWriter is only supported in read-write mode
+// where the current file content is buffered in memory.
+func (f *ZipFileSystem) OpenReadWriter(filePath string, perm []fs.Permissions) (fs.ReadWriteSeekCloser, error) {
+	if f.archive == "" {
+		return nil, fs.NewErrUnsupported(f, "OpenReadWriter")
+	}
+	var current []byte
+	if f.Exists(filePath) {
+		var err error
+		current, err = f.ReadAll(context.Background(), filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var fileBuffer *fsimpl.FileBuffer
+	fileBuffer = fsimpl.NewFileBufferWithClose(current, func() error {
+		return f.WriteAll(context.Background(), filePath, fileBuffer.Bytes(), perm)
+	})
+	return fileBuffer, nil
 }
 
+// Remove is only supported in read-write mode.
+// Directories must be empty.
 func (f *ZipFileSystem) Remove(filePath string) error {
-	return fs.NewErrUnsupported(f, "Remove")
+	if f.archive == "" {
+		return fs.NewErrUnsupported(f, "Remove")
+	}
+	if err := f.checkWritable(); err != nil {
+		return err
+	}
+	defer f.mtx.Unlock()
+
+	entryPath := path.Clean(Separator + filePath)
+	if entryPath == Separator {
+		return fmt.Errorf("can't remove root directory of %s", f.Name())
+	}
+	n := f.findNode(entryPath)
+	if n == nil {
+		return fs.NewErrDoesNotExist(f.File(filePath))
+	}
+	if len(n.children) > 0 {
+		return fmt.Errorf("can't remove non empty directory %s", n.File)
+	}
+	parent := f.findNode(path.Dir(entryPath))
+	delete(parent.children, n.Name)
+	f.modified = true
+	return nil
 }
 
+// Close finishes the archive in write-only mode
+// and rewrites the archive in read-write mode
+// if there were any changes.
 func (f *ZipFileSystem) Close() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
 	if f.closer == nil {
 		return nil // already closed
 	}
 	fs.Unregister(f)
-	err := f.closer.Close()
+	var err error
+	if f.archive != "" && f.modified {
+		err = f.rewriteArchive()
+	}
+	err = errors.Join(err, f.closer.Close())
 	f.closer = nil
 	return err
 }
 
+// rewriteArchive writes the directory tree into a temp file
+// because unchanged entries are still read from the archive,
+// and then replaces the archive with the temp file.
+func (f *ZipFileSystem) rewriteArchive() (err error) {
+	tempFile := fs.TempFile(".zip")
+	defer func() {
+		err = errors.Join(err, fs.RemoveErrDoesNotExist(tempFile.Remove()))
+	}()
+
+	tempWriter, err := tempFile.OpenWriter()
+	if err != nil {
+		return err
+	}
+	zipWriter := zip.NewWriter(tempWriter)
+	err = writeTree(zipWriter, f.root, "")
+	err = errors.Join(err, zipWriter.Close(), tempWriter.Close())
+	if err != nil {
+		return err
+	}
+
+	// Close the reader of the archive before overwriting it
+	err = f.closer.Close()
+	f.closer = closerFunc(func() error { return nil })
+	if err != nil {
+		return err
+	}
+	return fs.CopyFile(context.Background(), tempFile, f.archive)
+}
+
+// writeTree writes all children of dir to zipWriter
+// using dirName as prefix for the entry names.
+// Entries read from the archive are copied without recompression.
+func writeTree(zipWriter *zip.Writer, dir *node, dirName string) error {
+	for _, child := range dir.sortedChildren() {
+		name := dirName + child.Name
+		switch {
+		case child.IsDir:
+			_, err := zipWriter.CreateHeader(&zip.FileHeader{
+				Name:     name + Separator,
+				Modified: child.Modified,
+			})
+			if err != nil {
+				return err
+			}
+			err = writeTree(zipWriter, child, name+Separator)
+			if err != nil {
+				return err
+			}
+
+		case child.zipFile != nil:
+			header := child.zipFile.FileHeader
+			header.Name = name
+			header.Modified = child.Modified
+			writer, err := zipWriter.CreateRaw(&header)
+			if err != nil {
+				return err
+			}
+			reader, err := child.zipFile.OpenRaw()
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(writer, reader)
+			if err != nil {
+				return err
+			}
+
+		default:
+			writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+				Name:     name,
+				Method:   zip.Deflate,
+				Modified: child.Modified,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = writer.Write(child.data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// entryName returns the name of the archive entry for filePath
+func entryName(filePath string) string {
+	return strings.TrimPrefix(path.Clean(Separator+filePath), Separator)
+}
+
+// entryReader implements iofs.File for a zip archive entry
+type entryReader struct {
+	io.ReadCloser
+	info iofs.FileInfo
+}
+
+func (r *entryReader) Stat() (iofs.FileInfo, error) {
+	return r.info, nil
+}
+
+// bufferWriter buffers all written data
+// and passes it to close when closed
+type bufferWriter struct {
+	bytes.Buffer
+	close func([]byte) error
+}
+
+func (w *bufferWriter) Close() error {
+	if w.close == nil {
+		return fs.ErrFileSystemClosed
+	}
+	err := w.close(w.Bytes())
+	w.close = nil
+	return err
+}
+
 type nopCloser struct {
 	io.Writer
 }
@@ -397,3 +800,9 @@ type nopCloser struct {
 func (w nopCloser) Close() error {
 	return nil
 }
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
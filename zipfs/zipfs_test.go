@@ -0,0 +1,156 @@
+package zipfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ungerik/go-fs"
+)
+
+func newTestArchive(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range entries {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestNewReaderFileSystem(t *testing.T) {
+	archive := fs.NewMemFile("test.zip", newTestArchive(t, map[string]string{
+		"file.txt":            "file",
+		"dir/a.txt":           "a",
+		"dir/sub/b.txt":       "b",
+		"empty/":              "",
+		"../escaped/c.txt":    "c",
+		"dir/sub/.hidden.txt": "hidden",
+	}))
+	zipFS, err := NewReaderFileSystem(archive)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = zipFS.Close() })
+
+	ctx := context.Background()
+	root := zipFS.RootDir()
+	require.Equal(t, fs.File(zipFS.Prefix()+"/"), root)
+	require.True(t, fs.IsRegistered(zipFS))
+	readable, writable := zipFS.ReadableWritable()
+	require.True(t, readable)
+	require.False(t, writable)
+
+	files, err := root.ListDirMax(-1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dir", "empty", "escaped", "file.txt"}, fs.FileNames(files), "dirs first sorted by name")
+	require.Equal(t, root.Join("dir"), files[0])
+	require.True(t, root.Join("empty").IsDir())
+	require.True(t, root.Join("escaped", "c.txt").Exists(), "paths are confined to the root")
+
+	files, err = root.Join("dir").ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a.txt", "b.txt", ".hidden.txt"}, fs.FileNames(files), "only files are listed recursively")
+	require.Contains(t, files, root.Join("dir", "sub", "b.txt"))
+	require.True(t, root.Join("dir", "sub", ".hidden.txt").IsHidden())
+
+	str, err := root.Join("dir", "sub", "b.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "b", str)
+	require.Equal(t, int64(4), root.Join("file.txt").Size())
+
+	_, err = root.Join("missing.txt").ReadAll()
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = root.Join("dir").OpenReader()
+	require.ErrorAs(t, err, new(fs.ErrIsDirectory))
+	err = root.Join("new.txt").WriteAll(nil)
+	require.ErrorIs(t, err, fs.ErrReadOnlyFileSystem)
+
+	require.NoError(t, zipFS.Close())
+	require.False(t, fs.IsRegistered(zipFS))
+	_, err = zipFS.ReadAll(ctx, "/file.txt")
+	require.ErrorIs(t, err, fs.ErrFileSystemClosed)
+}
+
+func TestNewWriterFileSystem(t *testing.T) {
+	archive := fs.File(t.TempDir()).Join("test.zip")
+	zipFS, err := NewWriterFileSystem(archive)
+	require.NoError(t, err)
+
+	readable, writable := zipFS.ReadableWritable()
+	require.False(t, readable)
+	require.True(t, writable)
+	require.NoError(t, zipFS.RootDir().Join("dir", "file.txt").WriteAllString("Hello"))
+	require.NoError(t, zipFS.Close())
+
+	zipFS, err = NewReaderFileSystem(archive)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = zipFS.Close() })
+	str, err := zipFS.RootDir().Join("dir", "file.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "Hello", str)
+}
+
+func TestNewReadWriteFileSystem(t *testing.T) {
+	archive := fs.File(t.TempDir()).Join("test.zip")
+	require.NoError(t, archive.WriteAll(newTestArchive(t, map[string]string{
+		"keep.txt":   "keep",
+		"remove.txt": "remove",
+		"dir/a.txt":  "a",
+	})))
+
+	zipFS, err := NewReadWriteFileSystem(archive)
+	require.NoError(t, err)
+	root := zipFS.RootDir()
+	readable, writable := zipFS.ReadableWritable()
+	require.True(t, readable)
+	require.True(t, writable)
+
+	require.NoError(t, root.Join("remove.txt").Remove())
+	require.Error(t, root.Join("dir").Remove(), "dir not empty")
+	require.NoError(t, root.Join("dir", "a.txt").WriteAllString("changed"))
+	require.NoError(t, root.Join("new", "b.txt").WriteAllString("b"))
+	require.NoError(t, root.Join("empty").MakeDir())
+	str, err := root.Join("dir", "a.txt").ReadAllString()
+	require.NoError(t, err)
+	require.Equal(t, "changed", str, "changes are visible before Close")
+	require.NoError(t, zipFS.Close())
+
+	zipFS, err = NewReaderFileSystem(archive)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = zipFS.Close() })
+	root = zipFS.RootDir()
+	files, err := root.ListDirRecursiveMax(-1)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"keep.txt", "a.txt", "b.txt"}, fs.FileNames(files))
+	require.True(t, root.Join("empty").IsDir())
+	for file, want := range map[fs.File]string{
+		root.Join("keep.txt"):     "keep",
+		root.Join("dir", "a.txt"): "changed",
+		root.Join("new", "b.txt"): "b",
+	} {
+		str, err := file.ReadAllString()
+		require.NoError(t, err)
+		require.Equal(t, want, str)
+	}
+}
+
+func TestNewReadWriteFileSystem_NewArchive(t *testing.T) {
+	archive := fs.File(t.TempDir()).Join("new.zip")
+	zipFS, err := NewReadWriteFileSystem(archive)
+	require.NoError(t, err)
+	require.NoError(t, zipFS.Close())
+	require.False(t, archive.Exists(), "no archive written without changes")
+
+	zipFS, err = NewReadWriteFileSystem(archive)
+	require.NoError(t, err)
+	require.NoError(t, zipFS.RootDir().Join("file.txt").Touch())
+	require.NoError(t, zipFS.Close())
+	require.True(t, archive.Exists())
+}